@@ -0,0 +1,72 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/BurntSushi/toml"
+	"github.com/bruhlord-s/virttable-api/internal/app/apiserver"
+	"github.com/bruhlord-s/virttable-api/internal/app/compendium"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/sqlstore"
+)
+
+var (
+	configPath string
+	srdDir     string
+	packPath   string
+	replace    bool
+)
+
+func init() {
+	flag.StringVar(&configPath, "config-path", "configs/apiserver.toml", "path to config file")
+	flag.StringVar(&srdDir, "srd", "", "directory with the 5e-SRD-*.json files of 5e-database to import")
+	flag.StringVar(&packPath, "pack", "", "path to a compendium pack to import")
+	flag.BoolVar(&replace, "replace", false, "delete the entries imported from the pack before")
+}
+
+func main() {
+	flag.Parse()
+
+	if (srdDir == "") == (packPath == "") {
+		log.Fatal("either -srd or -pack is required")
+	}
+
+	config := apiserver.NewConfig()
+	if _, err := toml.DecodeFile(configPath, config); err != nil {
+		log.Fatal(err)
+	}
+
+	p, err := readPack()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := sql.Open("postgres", config.DatabaseURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	n, err := compendium.Import(sqlstore.New(db).Compendium(), p, replace)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("imported %d entries of %s into the %s compendium", n, p.Name, p.System)
+}
+
+func readPack() (*compendium.Pack, error) {
+	if srdDir != "" {
+		return compendium.ReadSRD(os.DirFS(srdDir))
+	}
+
+	f, err := os.Open(packPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return compendium.ReadPack(f)
+}
@@ -0,0 +1,455 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+// actionHistory is how many of their latest actions members can undo.
+const actionHistory = 100
+
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+	ErrChangedSince  = errors.New("it has been changed since")
+	ErrTimeRequired  = errors.New("at is required")
+)
+
+// handleActionsIndex lists the campaign's table log, latest first, for GMs
+// to see who did what. It takes scene_id, user_id, from, to, limit and
+// offset query parameters.
+func (s *server) handleActionsIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		f, err := parseActionFilter(r.URL.Query())
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		actions, err := s.store.Action().FindAll(r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID, f)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, actions)
+	}
+}
+
+// handleActionsRevert undoes the member's latest action still done, or
+// redoes the latest one undone, as kind says. Members only revert their own
+// actions, and only while nobody has changed the target since.
+func (s *server) handleActionsRevert(kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := r.Context().Value(ctxKeyCampaign).(*model.Campaign)
+		member := r.Context().Value(ctxKeyMember).(*model.Member)
+		if !member.CanPlay() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		latest, err := s.store.Action().FindAll(c.ID, &model.ActionFilter{UserID: &member.UserID, Limit: actionHistory})
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		actions := make([]*model.Action, len(latest))
+		for i, a := range latest {
+			actions[len(latest)-1-i] = a
+		}
+
+		undo, redo := model.History(actions)
+		stack, errEmpty := undo, ErrNothingToUndo
+		if kind == model.ActionRedo {
+			stack, errEmpty = redo, ErrNothingToRedo
+		}
+		if len(stack) == 0 {
+			s.error(w, r, http.StatusConflict, errEmpty)
+			return
+		}
+
+		last := stack[len(stack)-1]
+		a := last.Revert(member.UserID, kind)
+		if err := s.store.Transaction(func(tx store.Store) error {
+			return revertAction(tx, last, a)
+		}); err != nil {
+			switch err {
+			case ErrChangedSince, store.ErrRecordNotFound, store.ErrAlreadyExists:
+				s.error(w, r, http.StatusConflict, ErrChangedSince)
+			default:
+				s.error(w, r, http.StatusInternalServerError, err)
+			}
+			return
+		}
+
+		sc, err := s.store.Scene().Find(a.SceneID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.publishAction(r, sc, a); err != nil {
+			s.logger.Error(err.Error())
+		}
+		s.respond(w, r, http.StatusOK, a)
+	}
+}
+
+// handleScenesReplay reconstructs the tokens, walls and lights of the scene
+// as they were at the given time by rewinding the table log from now.
+func (s *server) handleScenesReplay() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, err := s.findScene(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		v := r.URL.Query().Get("at")
+		if v == "" {
+			s.error(w, r, http.StatusBadRequest, ErrTimeRequired)
+			return
+		}
+		at, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, ErrInvalidFilter)
+			return
+		}
+
+		res := &sceneReplay{At: at}
+		if res.Tokens, err = s.store.Token().FindAll(sc.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		if res.Walls, err = s.store.Wall().FindAll(sc.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		if res.Lights, err = s.store.Light().FindAll(sc.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		actions, err := s.store.Action().FindAll(sc.CampaignID, &model.ActionFilter{SceneID: &sc.ID, From: at})
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := res.rewind(actions); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, res)
+	}
+}
+
+// sceneReplay is the state of a scene's table at a point in time.
+type sceneReplay struct {
+	At     time.Time      `json:"at"`
+	Tokens []*model.Token `json:"tokens"`
+	Walls  []*model.Wall  `json:"walls"`
+	Lights []*model.Light `json:"lights"`
+}
+
+// rewind undoes the actions, latest first, bringing back the state of the
+// table from before them.
+func (p *sceneReplay) rewind(actions []*model.Action) error {
+	tokens := map[uuid.UUID]*model.Token{}
+	for _, t := range p.Tokens {
+		tokens[t.ID] = t
+	}
+	walls := map[uuid.UUID]*model.Wall{}
+	for _, wl := range p.Walls {
+		walls[wl.ID] = wl
+	}
+	lights := map[uuid.UUID]*model.Light{}
+	for _, l := range p.Lights {
+		lights[l.ID] = l
+	}
+
+	for _, a := range actions {
+		var err error
+		switch a.Target {
+		case model.TargetToken:
+			delete(tokens, a.TargetID)
+			if a.Before != nil {
+				t := &model.Token{}
+				err = json.Unmarshal(a.Before, t)
+				tokens[a.TargetID] = t
+			}
+		case model.TargetWall:
+			delete(walls, a.TargetID)
+			if a.Before != nil {
+				wl := &model.Wall{}
+				err = json.Unmarshal(a.Before, wl)
+				walls[a.TargetID] = wl
+			}
+		case model.TargetLight:
+			delete(lights, a.TargetID)
+			if a.Before != nil {
+				l := &model.Light{}
+				err = json.Unmarshal(a.Before, l)
+				lights[a.TargetID] = l
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	p.Tokens = []*model.Token{}
+	for _, t := range tokens {
+		p.Tokens = append(p.Tokens, t)
+	}
+	sort.Slice(p.Tokens, func(i, j int) bool {
+		return createdBefore(p.Tokens[i].CreatedAt, p.Tokens[i].ID, p.Tokens[j].CreatedAt, p.Tokens[j].ID)
+	})
+
+	p.Walls = []*model.Wall{}
+	for _, wl := range walls {
+		p.Walls = append(p.Walls, wl)
+	}
+	sort.Slice(p.Walls, func(i, j int) bool {
+		return createdBefore(p.Walls[i].CreatedAt, p.Walls[i].ID, p.Walls[j].CreatedAt, p.Walls[j].ID)
+	})
+
+	p.Lights = []*model.Light{}
+	for _, l := range lights {
+		p.Lights = append(p.Lights, l)
+	}
+	sort.Slice(p.Lights, func(i, j int) bool {
+		return createdBefore(p.Lights[i].CreatedAt, p.Lights[i].ID, p.Lights[j].CreatedAt, p.Lights[j].ID)
+	})
+
+	return nil
+}
+
+// createdBefore orders records the way the store lists them.
+func createdBefore(a time.Time, aID uuid.UUID, b time.Time, bID uuid.UUID) bool {
+	if !a.Equal(b) {
+		return a.Before(b)
+	}
+
+	return aID.String() < bID.String()
+}
+
+// logAction records a change to a token, wall or light in the table log
+// for its author to undo. Before is nil for creations, after for deletions.
+// It belongs in the transaction making the change, so that the log never
+// misses one.
+func logAction(tx store.Store, r *http.Request, sc *model.Scene, target string, id uuid.UUID, before, after interface{}) error {
+	a, err := model.NewAction(target, id, before, after)
+	if err != nil {
+		return err
+	}
+
+	a.CampaignID = sc.CampaignID
+	a.SceneID = sc.ID
+	a.UserID = r.Context().Value(ctxKeyUser).(*model.User).ID
+
+	return tx.Action().Create(a)
+}
+
+// publishAction tells the campaign about the change an undo or a redo made,
+// the way the handlers of the target do.
+func (s *server) publishAction(r *http.Request, sc *model.Scene, a *model.Action) error {
+	deleted := map[string]uuid.UUID{"id": a.TargetID, "scene_id": sc.ID}
+
+	switch a.Target {
+	case model.TargetToken:
+		var before, after *model.Token
+		if err := decodeAction(a, &before, &after); err != nil {
+			return err
+		}
+
+		switch {
+		case after == nil:
+			s.publish(realtime.EventTokenDeleted, sc.CampaignID, r, deleted, tokenAudience(before))
+			return nil
+		case before == nil:
+			if err := s.publishToken(r, sc, realtime.EventTokenCreated, after, after); err != nil {
+				return err
+			}
+		default:
+			if err := s.publishTokenUpdate(r, sc, before, after); err != nil {
+				return err
+			}
+		}
+		s.exploreWith(r, sc, after)
+	case model.TargetWall, model.TargetLight:
+		events := map[string][3]string{
+			model.TargetWall:  {realtime.EventWallCreated, realtime.EventWallUpdated, realtime.EventWallDeleted},
+			model.TargetLight: {realtime.EventLightCreated, realtime.EventLightUpdated, realtime.EventLightDeleted},
+		}[a.Target]
+
+		switch {
+		case a.After == nil:
+			s.publish(events[2], sc.CampaignID, r, deleted, &realtime.Audience{GMOnly: true})
+		case a.Before == nil:
+			s.publish(events[0], sc.CampaignID, r, a.After, &realtime.Audience{GMOnly: true})
+		default:
+			s.publish(events[1], sc.CampaignID, r, a.After, &realtime.Audience{GMOnly: true})
+		}
+		s.publishVisionChange(r, sc, nil)
+	}
+
+	return nil
+}
+
+// revertAction brings the target of the last action back to the state it
+// found it in, failing with ErrChangedSince if the target has been changed
+// since, and logs the revert. The target stays locked until tx ends, so it
+// can't change between the check and the revert.
+func revertAction(tx store.Store, last *model.Action, revert *model.Action) error {
+	var current interface{}
+	var err error
+	switch last.Target {
+	case model.TargetToken:
+		current, err = tx.Token().Lock(last.TargetID)
+	case model.TargetWall:
+		current, err = tx.Wall().Lock(last.TargetID)
+	case model.TargetLight:
+		current, err = tx.Light().Lock(last.TargetID)
+	}
+
+	var state json.RawMessage
+	if err == nil {
+		if state, err = json.Marshal(current); err != nil {
+			return err
+		}
+	} else if err != store.ErrRecordNotFound {
+		return err
+	}
+
+	if !last.IsCurrent(state) {
+		return ErrChangedSince
+	}
+
+	exists := state != nil
+	switch {
+	case revert.After == nil:
+		err = deleteTarget(tx, revert.Target, revert.TargetID)
+	case revert.Target == model.TargetToken:
+		t := &model.Token{}
+		if err = json.Unmarshal(revert.After, t); err == nil && exists {
+			err = tx.Token().Update(t)
+		} else if err == nil {
+			err = tx.Token().Restore(t)
+		}
+	case revert.Target == model.TargetWall:
+		wl := &model.Wall{}
+		if err = json.Unmarshal(revert.After, wl); err == nil && exists {
+			err = tx.Wall().Update(wl)
+		} else if err == nil {
+			err = tx.Wall().Restore(wl)
+		}
+	case revert.Target == model.TargetLight:
+		l := &model.Light{}
+		if err = json.Unmarshal(revert.After, l); err == nil && exists {
+			err = tx.Light().Update(l)
+		} else if err == nil {
+			err = tx.Light().Restore(l)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Action().Create(revert)
+}
+
+func deleteTarget(tx store.Store, target string, id uuid.UUID) error {
+	switch target {
+	case model.TargetToken:
+		return tx.Token().Delete(id)
+	case model.TargetWall:
+		return tx.Wall().Delete(id)
+	default:
+		return tx.Light().Delete(id)
+	}
+}
+
+func decodeAction(a *model.Action, before, after interface{}) error {
+	if a.Before != nil {
+		if err := json.Unmarshal(a.Before, before); err != nil {
+			return err
+		}
+	}
+
+	if a.After != nil {
+		return json.Unmarshal(a.After, after)
+	}
+
+	return nil
+}
+
+// parseActionFilter reads scene_id, user_id, from, to, limit and offset
+// query parameters. Times are RFC 3339.
+func parseActionFilter(q url.Values) (*model.ActionFilter, error) {
+	f := &model.ActionFilter{Limit: actionHistory}
+
+	ids := map[string]**uuid.UUID{"scene_id": &f.SceneID, "user_id": &f.UserID}
+	for key, dst := range ids {
+		if v := q.Get(key); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				return nil, ErrInvalidFilter
+			}
+			*dst = &id
+		}
+	}
+
+	ints := map[string]*int{"limit": &f.Limit, "offset": &f.Offset}
+	for key, dst := range ints {
+		if v := q.Get(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, ErrInvalidFilter
+			}
+			*dst = n
+		}
+	}
+	if f.Limit < 1 {
+		return nil, ErrInvalidFilter
+	}
+
+	times := map[string]*time.Time{"from": &f.From, "to": &f.To}
+	for key, dst := range times {
+		if v := q.Get(key); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, ErrInvalidFilter
+			}
+			*dst = t
+		}
+	}
+
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
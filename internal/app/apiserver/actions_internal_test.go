@@ -0,0 +1,255 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandleActionsUndo(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	alice := testUser(t, st, "alice")
+	spectator := testUser(t, st, "spectator")
+	c := testCampaign(t, st, gm, map[*model.User]string{alice: model.RolePlayer, spectator: model.RoleSpectator})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	st.Scene().Create(sc)
+	scenePath := fmt.Sprintf("/private/campaigns/%s/scenes/%s", c.ID, sc.ID)
+	undoPath := fmt.Sprintf("/private/campaigns/%s/actions/undo", c.ID)
+	redoPath := fmt.Sprintf("/private/campaigns/%s/actions/redo", c.ID)
+
+	rec := testRequest(t, s, gm, http.MethodPost, scenePath+"/tokens", map[string]interface{}{"name": "Strahd", "x": 105, "y": 105})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	tok := &model.Token{}
+	json.NewDecoder(rec.Body).Decode(tok)
+
+	rec = testRequest(t, s, gm, http.MethodPost, fmt.Sprintf("%s/tokens/%s/move", scenePath, tok.ID), map[string]interface{}{"x": 385, "y": 245})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = testRequest(t, s, gm, http.MethodPost, undoPath, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	a := &model.Action{}
+	json.NewDecoder(rec.Body).Decode(a)
+	assert.Equal(t, model.ActionUndo, a.Kind)
+	found, _ := st.Token().Find(tok.ID)
+	assert.Equal(t, 105, found.X)
+
+	rec = testRequest(t, s, gm, http.MethodPost, undoPath, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	_, err := st.Token().Find(tok.ID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	rec = testRequest(t, s, gm, http.MethodPost, undoPath, nil)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = testRequest(t, s, gm, http.MethodPost, redoPath, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	found, err = st.Token().Find(tok.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Strahd", found.Name)
+
+	rec = testRequest(t, s, gm, http.MethodPost, redoPath, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	found, _ = st.Token().Find(tok.ID)
+	assert.Equal(t, 385, found.X)
+
+	rec = testRequest(t, s, gm, http.MethodPost, redoPath, nil)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = testRequest(t, s, alice, http.MethodPost, undoPath, nil)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = testRequest(t, s, spectator, http.MethodPost, undoPath, nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestServer_HandleActionsUndo_Delete(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	c := testCampaign(t, st, gm, nil)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	st.Scene().Create(sc)
+	wl := model.TestWall(t, sc)
+	st.Wall().Create(wl)
+
+	rec := testRequest(t, s, gm, http.MethodDelete, fmt.Sprintf("/private/campaigns/%s/scenes/%s/walls/%s", c.ID, sc.ID, wl.ID), nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = testRequest(t, s, gm, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/actions/undo", c.ID), nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	found, err := st.Wall().Find(wl.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, wl.X2, found.X2)
+	assert.True(t, wl.CreatedAt.Equal(found.CreatedAt))
+}
+
+func TestServer_HandleActionsUndo_Conflict(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	alice := testUser(t, st, "alice")
+	c := testCampaign(t, st, gm, map[*model.User]string{alice: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	st.Scene().Create(sc)
+	tok := model.TestToken(t, sc, alice)
+	st.Token().Create(tok)
+	movePath := fmt.Sprintf("/private/campaigns/%s/scenes/%s/tokens/%s/move", c.ID, sc.ID, tok.ID)
+	undoPath := fmt.Sprintf("/private/campaigns/%s/actions/undo", c.ID)
+
+	rec := testRequest(t, s, alice, http.MethodPost, movePath, map[string]interface{}{"x": 175, "y": 105})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = testRequest(t, s, gm, http.MethodPost, movePath, map[string]interface{}{"x": 245, "y": 105})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = testRequest(t, s, alice, http.MethodPost, undoPath, nil)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	found, _ := st.Token().Find(tok.ID)
+	assert.Equal(t, 245, found.X)
+
+	rec = testRequest(t, s, gm, http.MethodPost, undoPath, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = testRequest(t, s, alice, http.MethodPost, undoPath, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	found, _ = st.Token().Find(tok.ID)
+	assert.Equal(t, 105, found.X)
+}
+
+func TestServer_HandleActionsUndo_AfterTurn(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	alice := testUser(t, st, "alice")
+	c := testCampaign(t, st, gm, map[*model.User]string{alice: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	sc.EnforceMovement = true
+	st.Scene().Create(sc)
+	tok := model.TestToken(t, sc, alice)
+	st.Token().Create(tok)
+	cb := model.TestCombat(t, c)
+	st.Combat().Create(cb)
+	initiative := func(n int) *int {
+		return &n
+	}
+	st.Combatant().Create(&model.Combatant{CombatID: cb.ID, Name: "Ogre", Initiative: initiative(18)})
+	st.Combatant().Create(&model.Combatant{CombatID: cb.ID, Name: "Ireena", TokenID: &tok.ID, OwnerID: &alice.ID, Initiative: initiative(10)})
+	combatPath := fmt.Sprintf("/private/campaigns/%s/combats/%s", c.ID, cb.ID)
+
+	rec := testRequest(t, s, gm, http.MethodPost, combatPath+"/start", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = testRequest(t, s, alice, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/scenes/%s/tokens/%s/move", c.ID, sc.ID, tok.ID), map[string]interface{}{"x": 175, "y": 105})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	found, _ := st.Token().Find(tok.ID)
+	assert.NotZero(t, found.Moved)
+
+	// Her turn gives the token its movement back, which isn't a change to
+	// the move.
+	rec = testRequest(t, s, gm, http.MethodPost, combatPath+"/next", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	found, _ = st.Token().Find(tok.ID)
+	assert.Zero(t, found.Moved)
+
+	rec = testRequest(t, s, alice, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/actions/undo", c.ID), nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	found, _ = st.Token().Find(tok.ID)
+	assert.Equal(t, tok.X, found.X)
+}
+
+func TestServer_HandleActionsIndex(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	alice := testUser(t, st, "alice")
+	c := testCampaign(t, st, gm, map[*model.User]string{alice: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	st.Scene().Create(sc)
+	scenePath := fmt.Sprintf("/private/campaigns/%s/scenes/%s", c.ID, sc.ID)
+	testRequest(t, s, gm, http.MethodPost, scenePath+"/lights", map[string]interface{}{"x": 140, "y": 140, "radius": 210})
+	testRequest(t, s, alice, http.MethodPost, scenePath+"/tokens", map[string]interface{}{"x": 35, "y": 35})
+	path := fmt.Sprintf("/private/campaigns/%s/actions", c.ID)
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		query        string
+		exceptedCode int
+		exceptedLen  int
+	}{
+		{"gm", gm, "", http.StatusOK, 2},
+		{"by user", gm, "?user_id=" + alice.ID.String(), http.StatusOK, 1},
+		{"invalid filter", gm, "?scene_id=cave", http.StatusBadRequest, 0},
+		{"no limit", gm, "?limit=0", http.StatusBadRequest, 0},
+		{"player", alice, "", http.StatusForbidden, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, http.MethodGet, path+tc.query, nil)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+
+			if tc.exceptedCode == http.StatusOK {
+				actions := []*model.Action{}
+				json.NewDecoder(rec.Body).Decode(&actions)
+				assert.Len(t, actions, tc.exceptedLen)
+			}
+		})
+	}
+}
+
+func TestServer_HandleScenesReplay(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	alice := testUser(t, st, "alice")
+	c := testCampaign(t, st, gm, map[*model.User]string{alice: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	st.Scene().Create(sc)
+	scenePath := fmt.Sprintf("/private/campaigns/%s/scenes/%s", c.ID, sc.ID)
+
+	rec := testRequest(t, s, alice, http.MethodPost, scenePath+"/tokens", map[string]interface{}{"name": "Ireena", "x": 105, "y": 105})
+	tok := &model.Token{}
+	json.NewDecoder(rec.Body).Decode(tok)
+	at := time.Now()
+
+	testRequest(t, s, alice, http.MethodPost, fmt.Sprintf("%s/tokens/%s/move", scenePath, tok.ID), map[string]interface{}{"x": 385, "y": 245})
+	testRequest(t, s, gm, http.MethodPost, scenePath+"/lights", map[string]interface{}{"x": 140, "y": 140, "radius": 210})
+	testRequest(t, s, gm, http.MethodPost, scenePath+"/tokens", map[string]interface{}{"name": "Strahd", "x": 700, "y": 525})
+
+	rec = testRequest(t, s, gm, http.MethodGet, scenePath+"/replay?at="+url.QueryEscape(at.Format(time.RFC3339Nano)), nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	replay := &sceneReplay{}
+	json.NewDecoder(rec.Body).Decode(replay)
+	if assert.Len(t, replay.Tokens, 1) {
+		assert.Equal(t, tok.ID, replay.Tokens[0].ID)
+		assert.Equal(t, 105, replay.Tokens[0].X)
+	}
+	assert.Empty(t, replay.Lights)
+
+	rec = testRequest(t, s, gm, http.MethodGet, scenePath+"/replay?at="+url.QueryEscape(time.Now().Format(time.RFC3339Nano)), nil)
+	json.NewDecoder(rec.Body).Decode(replay)
+	assert.Len(t, replay.Tokens, 2)
+	assert.Len(t, replay.Lights, 1)
+
+	rec = testRequest(t, s, gm, http.MethodGet, scenePath+"/replay", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = testRequest(t, s, alice, http.MethodGet, scenePath+"/replay?at="+url.QueryEscape(at.Format(time.RFC3339)), nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
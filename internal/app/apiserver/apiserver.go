@@ -4,7 +4,9 @@ import (
 	"database/sql"
 	"net/http"
 
+	"github.com/bruhlord-s/virttable-api/internal/app/blob"
 	"github.com/bruhlord-s/virttable-api/internal/app/store/sqlstore"
+	"github.com/sirupsen/logrus"
 )
 
 func Start(config *Config) error {
@@ -15,7 +17,19 @@ func Start(config *Config) error {
 
 	defer db.Close()
 	store := sqlstore.New(db)
-	s := newServer(store, config.JWTKey)
+
+	pubsub, err := sqlstore.NewPubSub(db, config.DatabaseURL, logrus.StandardLogger())
+	if err != nil {
+		return err
+	}
+
+	defer pubsub.Close()
+	s := newServer(store, pubsub, config.JWTKey)
+	defer s.collab.Close()
+	defer s.presence.Close()
+	s.blobs = blob.NewLocal(config.AssetsDir)
+	s.assets = newAssetLimits(config)
+	s.admins = newAdmins(config)
 
 	return http.ListenAndServe(config.BindAddr, s)
 }
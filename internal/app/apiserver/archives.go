@@ -0,0 +1,553 @@
+package apiserver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/archive"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDanglingReference = errors.New("archive refers to a record it doesn't have")
+)
+
+// handleCampaignsExport downloads the campaign as an archive, with the chat
+// as the GM sees it and the assets filed under the campaign.
+func (s *server) handleCampaignsExport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m := r.Context().Value(ctxKeyMember).(*model.Member)
+		if !m.IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		c := r.Context().Value(ctxKeyCampaign).(*model.Campaign)
+		ac, err := s.exportCampaign(c, m)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="campaign-%s.zip"`, c.ID))
+		if err := archive.Write(w, ac, func(hash string) (io.ReadCloser, error) {
+			return s.blobs.Open(contentKey(hash))
+		}); err != nil {
+			// Part of the archive is sent already, so all that's left is to
+			// log it.
+			s.logger.Error(err.Error())
+		}
+	}
+}
+
+// handleCampaignsImport restores an archive sent as the request body as a
+// new campaign with the user as its GM. Members don't come along, so
+// everything that was theirs becomes the user's, and subscriptions to packs
+// the server doesn't have are left out. Either all of it is restored or
+// none of it.
+func (s *server) handleCampaignsImport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(ctxKeyUser).(*model.User)
+
+		f, n, ok := s.spoolImport(w, r)
+		if !ok {
+			return
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		ar, err := archive.NewReader(f, n)
+		if err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		c, ok := s.restoreCampaign(w, r, ar.Campaign, ar, u.ID)
+		if !ok {
+			return
+		}
+
+		s.respond(w, r, http.StatusCreated, c)
+	}
+}
+
+// spoolImport writes the request body to a temporary file for imports to
+// read at random. It's up to the caller to remove the file.
+func (s *server) spoolImport(w http.ResponseWriter, r *http.Request) (*os.File, int64, bool) {
+	f, err := os.CreateTemp("", "import-*.zip")
+	if err != nil {
+		s.error(w, r, http.StatusInternalServerError, err)
+		return nil, 0, false
+	}
+
+	n, err := io.Copy(f, http.MaxBytesReader(w, r.Body, s.assets.maxImportSize))
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+
+		if maxBytesErr := (&http.MaxBytesError{}); errors.As(err, &maxBytesErr) {
+			s.error(w, r, http.StatusRequestEntityTooLarge, ErrUploadTooLarge)
+			return nil, 0, false
+		}
+
+		s.error(w, r, http.StatusBadRequest, err)
+		return nil, 0, false
+	}
+
+	return f, n, true
+}
+
+// contentSource is where an import reads the content of its assets from.
+type contentSource interface {
+	Open(hash string) (io.ReadCloser, error)
+	// ContentSize is how much the content takes decompressed, at most.
+	ContentSize() int64
+}
+
+// restoreCampaign stores the content of the archive's assets and imports
+// the campaign, all of it or, on failure, none of it. The content has to
+// fit in what is left of the user's quota before any of it is stored.
+func (s *server) restoreCampaign(w http.ResponseWriter, r *http.Request, ac *archive.Campaign, src contentSource, userID uuid.UUID) (*model.Campaign, bool) {
+	usage, err := s.store.Usage().Find(userID)
+	if err != nil {
+		s.error(w, r, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	if src.ContentSize() > usage.Available(s.assets.userQuota) {
+		s.error(w, r, http.StatusInsufficientStorage, store.ErrQuotaExceeded)
+		return nil, false
+	}
+
+	hashes := ac.Hashes()
+	stored := []string{}
+	cleanup := func() {
+		for _, hash := range stored {
+			if err := s.deleteContent(hash); err != nil {
+				s.logger.Error(err.Error())
+			}
+		}
+	}
+
+	// The content stays locked until the imported assets refer to it, as
+	// when creating assets one by one.
+	var c *model.Campaign
+	code := http.StatusInternalServerError
+	if err := s.store.Transaction(func(tx store.Store) error {
+		if err := tx.Asset().LockContent(hashes...); err != nil {
+			return err
+		}
+
+		for _, hash := range hashes {
+			stored = append(stored, hash)
+			if err := s.putContent(hash, func(dst string) error {
+				rc, err := src.Open(hash)
+				if err != nil {
+					return err
+				}
+				defer rc.Close()
+
+				_, err = s.blobs.Put(dst, rc)
+				return err
+			}); err != nil {
+				return err
+			}
+		}
+
+		code = http.StatusUnprocessableEntity
+		var err error
+		c, err = s.importCampaign(tx, ac, userID)
+		return err
+	}); err != nil {
+		cleanup()
+
+		if err == archive.ErrContentMismatch {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return nil, false
+		}
+		if err == store.ErrQuotaExceeded {
+			s.error(w, r, http.StatusInsufficientStorage, err)
+			return nil, false
+		}
+		if err == ErrUnsupportedMedia {
+			s.error(w, r, http.StatusUnsupportedMediaType, err)
+			return nil, false
+		}
+
+		s.error(w, r, code, err)
+		return nil, false
+	}
+
+	return c, true
+}
+
+func (s *server) exportCampaign(c *model.Campaign, viewer *model.Member) (*archive.Campaign, error) {
+	ac := &archive.Campaign{Campaign: c}
+
+	var err error
+	if ac.Characters, err = s.store.Character().FindAll(c.ID); err != nil {
+		return nil, err
+	}
+
+	if ac.Scenes, err = s.store.Scene().FindAll(c.ID); err != nil {
+		return nil, err
+	}
+	for _, sc := range ac.Scenes {
+		tokens, err := s.store.Token().FindAll(sc.ID)
+		if err != nil {
+			return nil, err
+		}
+		walls, err := s.store.Wall().FindAll(sc.ID)
+		if err != nil {
+			return nil, err
+		}
+		lights, err := s.store.Light().FindAll(sc.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		ac.Tokens = append(ac.Tokens, tokens...)
+		ac.Walls = append(ac.Walls, walls...)
+		ac.Lights = append(ac.Lights, lights...)
+	}
+
+	if ac.Combats, err = s.store.Combat().FindAll(c.ID); err != nil {
+		return nil, err
+	}
+	for _, cb := range ac.Combats {
+		combatants, err := s.store.Combatant().FindAll(cb.ID)
+		if err != nil {
+			return nil, err
+		}
+		ac.Combatants = append(ac.Combatants, combatants...)
+	}
+
+	if ac.JournalFolders, err = s.store.JournalFolder().FindAll(c.ID); err != nil {
+		return nil, err
+	}
+	if ac.JournalEntries, err = s.store.Journal().FindAll(c.ID, viewer, &model.JournalFilter{}); err != nil {
+		return nil, err
+	}
+
+	if ac.Rolls, err = s.store.Roll().FindAll(c.ID, &model.RollFilter{}); err != nil {
+		return nil, err
+	}
+	if ac.ChatMessages, err = s.store.ChatMessage().FindAll(c.ID, viewer, &model.ChatFilter{}); err != nil {
+		return nil, err
+	}
+
+	if ac.Packs, err = s.store.CampaignPack().FindAll(c.ID); err != nil {
+		return nil, err
+	}
+
+	members, err := s.store.Campaign().Members(c.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		assets, err := s.store.Asset().FindAll(m.UserID, &model.AssetFilter{CampaignID: &c.ID})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, a := range assets {
+			ac.Assets = append(ac.Assets, &archive.Asset{Asset: a, Thumbnail: a.Thumbnail})
+		}
+	}
+
+	return ac, nil
+}
+
+// importCampaign creates everything in the archive anew, under new IDs,
+// with what belonged to anyone made the user's. The content of the assets
+// has to be stored already.
+func (s *server) importCampaign(tx store.Store, ac *archive.Campaign, userID uuid.UUID) (*model.Campaign, error) {
+	c := &model.Campaign{
+		Name:        ac.Campaign.Name,
+		Description: ac.Campaign.Description,
+		System:      ac.Campaign.System,
+		OwnerID:     userID,
+	}
+
+	var err error
+	if c.DescriptionHTML, err = s.markdown.Render(c.Description, &mentionResolver{store: tx}); err != nil {
+		return nil, err
+	}
+	if err := tx.Campaign().Create(c); err != nil {
+		return nil, err
+	}
+
+	render := func(src string) (string, error) {
+		return s.markdown.Render(src, &mentionResolver{store: tx, campaignID: c.ID})
+	}
+	ids := idMap{}
+	user := func(id *uuid.UUID) *uuid.UUID {
+		if id == nil {
+			return nil
+		}
+
+		return &userID
+	}
+
+	// Scenes, tokens and characters point at their assets by URL.
+	replacements := []string{}
+	for _, a := range ac.Assets {
+		// The archive's word on the type isn't taken: served from the API's
+		// origin, HTML content would run as a page of it.
+		mime, size, err := s.sniffContent(a.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if !model.AllowedMIME(mime) {
+			return nil, ErrUnsupportedMedia
+		}
+
+		na := &model.Asset{
+			UserID:     userID,
+			CampaignID: &c.ID,
+			Name:       a.Name,
+			Hash:       a.Hash,
+			MIME:       mime,
+			Size:       size,
+			Width:      a.Width,
+			Height:     a.Height,
+			Thumbnail:  a.Thumbnail,
+			Tags:       a.Tags,
+			Private:    a.Private,
+		}
+		if err := tx.Asset().Create(na, s.assets.userQuota); err != nil {
+			return nil, err
+		}
+
+		ids[a.ID] = na.ID
+		replacements = append(replacements, "/assets/"+a.ID.String(), "/assets/"+na.ID.String())
+	}
+	urls := strings.NewReplacer(replacements...)
+
+	for _, ch := range ac.Characters {
+		old := ch.ID
+		ch.CampaignID, ch.OwnerID = c.ID, userID
+		ch.Portrait = urls.Replace(ch.Portrait)
+		if err := tx.Character().Create(ch); err != nil {
+			return nil, err
+		}
+		ids[old] = ch.ID
+	}
+
+	for _, sc := range ac.Scenes {
+		old := sc.ID
+		sc.CampaignID = c.ID
+		sc.Background = urls.Replace(sc.Background)
+		if err := tx.Scene().Create(sc); err != nil {
+			return nil, err
+		}
+		ids[old] = sc.ID
+	}
+
+	for _, t := range ac.Tokens {
+		old := t.ID
+		if t.SceneID, err = ids.ref(t.SceneID); err != nil {
+			return nil, err
+		}
+		t.Image = urls.Replace(t.Image)
+		t.OwnerID = user(t.OwnerID)
+		t.CharacterID = ids.optional(t.CharacterID)
+		if err := tx.Token().Create(t); err != nil {
+			return nil, err
+		}
+		ids[old] = t.ID
+	}
+
+	for _, wl := range ac.Walls {
+		if wl.SceneID, err = ids.ref(wl.SceneID); err != nil {
+			return nil, err
+		}
+		if err := tx.Wall().Create(wl); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, l := range ac.Lights {
+		if l.SceneID, err = ids.ref(l.SceneID); err != nil {
+			return nil, err
+		}
+		if err := tx.Light().Create(l); err != nil {
+			return nil, err
+		}
+	}
+
+	// The turn is a combatant's, so it's set once they are created.
+	current := map[*model.Combat]*uuid.UUID{}
+	for _, cb := range ac.Combats {
+		old := cb.ID
+		cb.CampaignID = c.ID
+		cb.SceneID = ids.optional(cb.SceneID)
+		current[cb], cb.CurrentID = cb.CurrentID, nil
+		if err := tx.Combat().Create(cb); err != nil {
+			return nil, err
+		}
+		ids[old] = cb.ID
+	}
+
+	for _, cm := range ac.Combatants {
+		old := cm.ID
+		if cm.CombatID, err = ids.ref(cm.CombatID); err != nil {
+			return nil, err
+		}
+		cm.TokenID = ids.optional(cm.TokenID)
+		cm.CharacterID = ids.optional(cm.CharacterID)
+		cm.OwnerID = user(cm.OwnerID)
+		if err := tx.Combatant().Create(cm); err != nil {
+			return nil, err
+		}
+		ids[old] = cm.ID
+	}
+
+	for cb, id := range current {
+		if cb.CurrentID = ids.optional(id); cb.CurrentID != nil {
+			if err := tx.Combat().Update(cb); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Folders are created after their parents.
+	pending := ac.JournalFolders
+	for len(pending) > 0 {
+		next := []*model.JournalFolder{}
+		for _, f := range pending {
+			if f.ParentID != nil {
+				if _, ok := ids[*f.ParentID]; !ok {
+					next = append(next, f)
+					continue
+				}
+			}
+
+			old := f.ID
+			f.CampaignID = c.ID
+			f.ParentID = ids.optional(f.ParentID)
+			if err := tx.JournalFolder().Create(f); err != nil {
+				return nil, err
+			}
+			ids[old] = f.ID
+		}
+
+		if len(next) == len(pending) {
+			return nil, ErrDanglingReference
+		}
+		pending = next
+	}
+
+	for _, e := range ac.JournalEntries {
+		e.CampaignID, e.AuthorID = c.ID, userID
+		e.FolderID = ids.optional(e.FolderID)
+		e.ImageID = ids.optional(e.ImageID)
+		if len(e.Members) > 0 {
+			e.Members = []uuid.UUID{userID}
+		}
+		if e.BodyHTML, err = render(e.Body); err != nil {
+			return nil, err
+		}
+		if err := tx.Journal().Create(e); err != nil {
+			return nil, err
+		}
+	}
+
+	// Rolls and messages are listed newest first, and go back in the order
+	// they were made.
+	for i := len(ac.Rolls) - 1; i >= 0; i-- {
+		roll := ac.Rolls[i]
+		roll.CampaignID, roll.UserID = c.ID, userID
+		roll.CharacterID = ids.optional(roll.CharacterID)
+		if err := tx.Roll().Create(roll); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := len(ac.ChatMessages) - 1; i >= 0; i-- {
+		msg := ac.ChatMessages[i]
+		msg.CampaignID, msg.UserID = c.ID, userID
+		msg.CharacterID = ids.optional(msg.CharacterID)
+		if len(msg.Recipients) > 0 {
+			msg.Recipients = []uuid.UUID{userID}
+		}
+		if msg.BodyHTML, err = render(msg.Body); err != nil {
+			return nil, err
+		}
+		if err := tx.ChatMessage().Create(msg); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, cp := range ac.Packs {
+		p, err := tx.Pack().Find(cp.PackID)
+		if err != nil || p.System != c.System {
+			continue
+		}
+		if _, err := tx.PackVersion().Find(cp.PackID, cp.Version); err != nil {
+			continue
+		}
+
+		if err := tx.CampaignPack().Save(&model.CampaignPack{CampaignID: c.ID, PackID: cp.PackID, Version: cp.Version}); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// sniffContent returns the type, sniffed rather than trusted, and the size
+// of the stored content with the hash.
+func (s *server) sniffContent(hash string) (string, int64, error) {
+	f, err := s.blobs.Open(contentKey(hash))
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", 0, err
+	}
+
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return http.DetectContentType(head[:n]), size, nil
+}
+
+// idMap maps the IDs of an archive to the ones given on import.
+type idMap map[uuid.UUID]uuid.UUID
+
+func (m idMap) ref(id uuid.UUID) (uuid.UUID, error) {
+	newID, ok := m[id]
+	if !ok {
+		return uuid.Nil, ErrDanglingReference
+	}
+
+	return newID, nil
+}
+
+// optional maps an optional reference, dropping it if the record isn't in
+// the archive.
+func (m idMap) optional(id *uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+
+	newID, ok := m[*id]
+	if !ok {
+		return nil
+	}
+
+	return &newID
+}
@@ -0,0 +1,318 @@
+package apiserver
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandleCampaignsExport(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	importer := testUser(t, st, "importer")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	rec := testUpload(t, s, player, "map.png", testPNG(t, 64, 64), map[string][]string{"campaign_id": {c.ID.String()}})
+	if !assert.Equal(t, http.StatusCreated, rec.Code) {
+		return
+	}
+	a := &assetView{}
+	json.NewDecoder(rec.Body).Decode(a)
+
+	ch := model.TestCharacter(t, c, player)
+	st.Character().Create(ch)
+	sc := model.TestScene(t, c)
+	sc.Background = "https://vt.example.com/assets/" + a.ID.String()
+	st.Scene().Create(sc)
+	tk := model.TestToken(t, sc, player)
+	tk.CharacterID = &ch.ID
+	st.Token().Create(tk)
+	st.Wall().Create(model.TestWall(t, sc))
+	st.Light().Create(model.TestLight(t, sc))
+	cb := model.TestCombat(t, c)
+	st.Combat().Create(cb)
+	cm := model.TestCombatant(t, cb)
+	cm.TokenID = &tk.ID
+	st.Combatant().Create(cm)
+	cb.CurrentID = &cm.ID
+	st.Combat().Update(cb)
+	parent := model.TestJournalFolder(t, c)
+	st.JournalFolder().Create(parent)
+	child := model.TestJournalFolder(t, c)
+	child.ParentID = &parent.ID
+	st.JournalFolder().Create(child)
+	e := model.TestJournalEntry(t, c, gm)
+	e.FolderID, e.ImageID = &child.ID, &a.ID
+	st.Journal().Create(e)
+	st.Roll().Create(model.TestRoll(t, c, player))
+	msg := model.TestChatMessage(t, c, player)
+	msg.Kind, msg.Recipients = model.ChatWhisper, []uuid.UUID{gm.ID}
+	st.ChatMessage().Create(msg)
+
+	path := fmt.Sprintf("/private/campaigns/%s/export", c.ID)
+	rec = testRequest(t, s, player, http.MethodGet, path, nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = testRequest(t, s, gm, http.MethodGet, path, nil)
+	if !assert.Equal(t, http.StatusOK, rec.Code) {
+		return
+	}
+	assert.Equal(t, "application/zip", rec.Header().Get("Content-Type"))
+	archive := rec.Body.Bytes()
+
+	rec = testImport(t, s, importer, "/private/campaigns/import", archive)
+	if !assert.Equal(t, http.StatusCreated, rec.Code) {
+		return
+	}
+	imported := &model.Campaign{}
+	json.NewDecoder(rec.Body).Decode(imported)
+	assert.NotEqual(t, c.ID, imported.ID)
+	assert.Equal(t, importer.ID, imported.OwnerID)
+	assert.Equal(t, c.Name, imported.Name)
+
+	assets, _ := st.Asset().FindAll(importer.ID, &model.AssetFilter{CampaignID: &imported.ID})
+	if !assert.Len(t, assets, 1) {
+		return
+	}
+	assert.NotEqual(t, a.ID, assets[0].ID)
+	assert.Equal(t, a.Size, assets[0].Size)
+
+	characters, _ := st.Character().FindAll(imported.ID)
+	if assert.Len(t, characters, 1) {
+		assert.Equal(t, importer.ID, characters[0].OwnerID)
+	}
+	scenes, _ := st.Scene().FindAll(imported.ID)
+	if assert.Len(t, scenes, 1) {
+		assert.Equal(t, "https://vt.example.com/assets/"+assets[0].ID.String(), scenes[0].Background)
+
+		tokens, _ := st.Token().FindAll(scenes[0].ID)
+		if assert.Len(t, tokens, 1) {
+			assert.Equal(t, &importer.ID, tokens[0].OwnerID)
+			assert.Equal(t, &characters[0].ID, tokens[0].CharacterID)
+		}
+		walls, _ := st.Wall().FindAll(scenes[0].ID)
+		assert.Len(t, walls, 1)
+		lights, _ := st.Light().FindAll(scenes[0].ID)
+		assert.Len(t, lights, 1)
+	}
+
+	combats, _ := st.Combat().FindAll(imported.ID)
+	if assert.Len(t, combats, 1) {
+		combatants, _ := st.Combatant().FindAll(combats[0].ID)
+		if assert.Len(t, combatants, 1) {
+			assert.Equal(t, &combatants[0].ID, combats[0].CurrentID)
+		}
+	}
+
+	folders, _ := st.JournalFolder().FindAll(imported.ID)
+	assert.Len(t, folders, 2)
+	owner := &model.Member{CampaignID: imported.ID, UserID: importer.ID, Role: model.RoleGM}
+	entries, _ := st.Journal().FindAll(imported.ID, owner, &model.JournalFilter{})
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, &assets[0].ID, entries[0].ImageID)
+		assert.NotEqual(t, &child.ID, entries[0].FolderID)
+	}
+
+	rolls, _ := st.Roll().FindAll(imported.ID, &model.RollFilter{})
+	assert.Len(t, rolls, 1)
+	messages, _ := st.ChatMessage().FindAll(imported.ID, owner, &model.ChatFilter{})
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, []uuid.UUID{importer.ID}, messages[0].Recipients)
+	}
+}
+
+func TestServer_HandleCampaignsImport(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "importer")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	html := "<html><script>alert(document.cookie)</script></html>"
+	sum := sha256.Sum256([]byte(html))
+	htmlHash := hex.EncodeToString(sum[:])
+
+	testCases := []struct {
+		name         string
+		body         []byte
+		exceptedCode int
+	}{
+		{"not a zip", []byte("campaign"), http.StatusUnprocessableEntity},
+		{"no manifest", testZip(t, map[string]string{"campaign.json": `{"campaign": {"name": "Curse of Strahd"}}`}), http.StatusUnprocessableEntity},
+		{
+			name: "unsupported version",
+			body: testZip(t, map[string]string{
+				"manifest.json": `{"version": 99}`,
+				"campaign.json": `{"campaign": {"name": "Curse of Strahd"}}`,
+			}),
+			exceptedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "dangling reference",
+			body: testZip(t, map[string]string{
+				"manifest.json": `{"version": 1}`,
+				"campaign.json": `{"campaign": {"name": "Curse of Strahd", "system": "generic"}, "walls": [{"x2": 70, "kind": "solid"}]}`,
+			}),
+			exceptedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "html asset",
+			body: testZip(t, map[string]string{
+				"manifest.json":       `{"version": 1}`,
+				"campaign.json":       `{"campaign": {"name": "Curse of Strahd", "system": "generic"}, "assets": [{"name": "map.png", "hash": "` + htmlHash + `", "mime": "image/png", "size": 1}]}`,
+				"content/" + htmlHash: html,
+			}),
+			exceptedCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name: "valid",
+			body: testZip(t, map[string]string{
+				"manifest.json": `{"version": 1}`,
+				"campaign.json": `{"campaign": {"name": "Curse of Strahd", "system": "generic"}}`,
+			}),
+			exceptedCode: http.StatusCreated,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testImport(t, s, u, "/private/campaigns/import", tc.body)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_HandleCampaignsImport_Rollback(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "importer")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	notes := "Ireena is the burgomaster's daughter"
+	sum := sha256.Sum256([]byte(notes))
+	hash := hex.EncodeToString(sum[:])
+
+	rec := testImport(t, s, u, "/private/campaigns/import", testZip(t, map[string]string{
+		"manifest.json": `{"version": 1}`,
+		"campaign.json": `{"campaign": {"name": "Curse of Strahd", "system": "generic"}, "assets": [{"name": "notes.txt", "hash": "` + hash + `"}], ` +
+			`"walls": [{"x2": 70, "kind": "solid"}]}`,
+		"content/" + hash: notes,
+	}))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	campaigns, _ := st.Usage().Campaigns(u.ID)
+	assert.Empty(t, campaigns)
+	usage, _ := st.Usage().Find(u.ID)
+	assert.Equal(t, int64(0), usage.Used)
+	ok, _ := s.blobs.Exists(contentKey(hash))
+	assert.False(t, ok)
+}
+
+func TestServer_HandleCampaignsImport_Content(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "importer")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	s.assets.userQuota = 100
+
+	notes := strings.Repeat("Barovia ", 10)
+	sum := sha256.Sum256([]byte(notes))
+	notesHash := hex.EncodeToString(sum[:])
+	sum = sha256.Sum256([]byte("map"))
+	mapHash := hex.EncodeToString(sum[:])
+
+	testCases := []struct {
+		name         string
+		quota        int64
+		assets       string
+		content      map[string]string
+		exceptedCode int
+	}{
+		{
+			name:         "invalid hash",
+			quota:        100,
+			assets:       `[{"name": "notes.txt", "hash": "a"}]`,
+			content:      map[string]string{"a": notes},
+			exceptedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "over quota",
+			quota:        50,
+			assets:       `[{"name": "notes.txt", "hash": "` + notesHash + `"}]`,
+			content:      map[string]string{notesHash: notes},
+			exceptedCode: http.StatusInsufficientStorage,
+		},
+		{
+			name:         "content mismatch",
+			quota:        100,
+			assets:       `[{"name": "notes.txt", "hash": "` + notesHash + `"}, {"name": "map.txt", "hash": "` + mapHash + `"}]`,
+			content:      map[string]string{notesHash: notes, mapHash: "not the map"},
+			exceptedCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s.assets.userQuota = tc.quota
+			files := map[string]string{
+				"manifest.json": `{"version": 1}`,
+				"campaign.json": `{"campaign": {"name": "Curse of Strahd", "system": "generic"}, "assets": ` + tc.assets + `}`,
+			}
+			for hash, body := range tc.content {
+				files["content/"+hash] = body
+			}
+
+			rec := testImport(t, s, u, "/private/campaigns/import", testZip(t, files))
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+
+			for _, hash := range []string{notesHash, mapHash} {
+				ok, _ := s.blobs.Exists(contentKey(hash))
+				assert.False(t, ok)
+			}
+		})
+	}
+}
+
+func testImport(t *testing.T, s *server, u *model.User, path string, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/zip")
+	token, _ := u.CreateJWT([]byte(testJWTKey))
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func testZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	b := &bytes.Buffer{}
+	zw := zip.NewWriter(b)
+	for name, body := range files {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return b.Bytes()
+}
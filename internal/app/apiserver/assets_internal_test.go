@@ -0,0 +1,446 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandleAssetsCreate(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "user")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	rec := testUpload(t, s, u, "map.png", testPNG(t, 640, 480), map[string][]string{"tags": {"Map", "map", "Barovia"}})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	v := &struct {
+		model.Asset
+		URL          string `json:"url"`
+		ThumbnailURL string `json:"thumbnail_url"`
+	}{}
+	json.NewDecoder(rec.Body).Decode(v)
+	assert.Equal(t, "image/png", v.MIME)
+	assert.Equal(t, 640, v.Width)
+	assert.Equal(t, 480, v.Height)
+	assert.Equal(t, []string{"map", "barovia"}, v.Tags)
+	assert.Equal(t, "/assets/"+v.ID.String(), v.URL)
+	assert.NotEmpty(t, v.ThumbnailURL)
+
+	rec = testRequest(t, s, nil, http.MethodGet, v.ThumbnailURL, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+	thumb, err := png.DecodeConfig(rec.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, 256, thumb.Width)
+	assert.Equal(t, 192, thumb.Height)
+
+	rec = testRequest(t, s, nil, http.MethodGet, v.URL, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, strconv.Quote(v.Hash), rec.Header().Get("ETag"))
+
+	testCases := []struct {
+		name         string
+		filename     string
+		content      []byte
+		exceptedCode int
+	}{
+		{"text", "notes.txt", []byte("The Count is at home."), http.StatusCreated},
+		{"html", "page.html", []byte("<html><script>alert(1)</script></html>"), http.StatusUnsupportedMediaType},
+		{"broken image", "broken.png", testPNG(t, 16, 16)[:64], http.StatusUnprocessableEntity},
+		{"empty", "empty.txt", []byte{}, http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testUpload(t, s, u, tc.filename, tc.content, nil)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_HandleAssetsCreateDeduplicates(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "user")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	content := testPNG(t, 32, 32)
+	assets := []*model.Asset{}
+	for _, name := range []string{"a.png", "b.png"} {
+		rec := testUpload(t, s, u, name, content, nil)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		a := &model.Asset{}
+		json.NewDecoder(rec.Body).Decode(a)
+		assets = append(assets, a)
+	}
+	assert.Equal(t, assets[0].Hash, assets[1].Hash)
+	a, err := st.Asset().Find(assets[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usage, err := st.Usage().Find(u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), usage.Used)
+
+	rec := testRequest(t, s, u, http.MethodDelete, "/private/assets/"+a.ID.String(), nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	ok, _ := s.blobs.Exists(contentKey(a.Hash))
+	assert.True(t, ok)
+
+	rec = testRequest(t, s, u, http.MethodDelete, "/private/assets/"+assets[1].ID.String(), nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	ok, _ = s.blobs.Exists(contentKey(a.Hash))
+	assert.False(t, ok)
+	ok, _ = s.blobs.Exists(contentKey(a.Thumbnail))
+	assert.False(t, ok)
+}
+
+func TestServer_HandleAssetsCreateLimits(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "user")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	s.assets.maxUploadSize = 100
+	s.assets.userQuota = 150
+
+	rec := testUpload(t, s, u, "big.txt", bytes.Repeat([]byte("a"), 101), nil)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	rec = testUpload(t, s, u, "first.txt", bytes.Repeat([]byte("a"), 100), nil)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = testUpload(t, s, u, "second.txt", bytes.Repeat([]byte("b"), 100), nil)
+	assert.Equal(t, http.StatusInsufficientStorage, rec.Code)
+
+	rec = testUpload(t, s, u, "copy.txt", bytes.Repeat([]byte("a"), 100), nil)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestServer_HandleAssetsIndexAndUpdate(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "user")
+	other := testUser(t, st, "other")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	f := model.TestFolder(t, u)
+	st.Folder().Create(f)
+	foreign := model.TestFolder(t, other)
+	st.Folder().Create(foreign)
+	a := model.TestAsset(t, u)
+	st.Asset().Create(a, s.assets.userQuota)
+
+	path := "/private/assets/" + a.ID.String()
+	testCases := []struct {
+		name         string
+		user         *model.User
+		method       string
+		path         string
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"other gets", other, http.MethodGet, path, nil, http.StatusNotFound},
+		{"moves into folder", u, http.MethodPatch, path, map[string]interface{}{"folder_id": f.ID, "tags": []string{"Map", "Castle"}}, http.StatusOK},
+		{"moves into foreign folder", u, http.MethodPatch, path, map[string]interface{}{"folder_id": foreign.ID}, http.StatusUnprocessableEntity},
+		{"empty name", u, http.MethodPatch, path, map[string]interface{}{"name": ""}, http.StatusUnprocessableEntity},
+		{"other updates", other, http.MethodPatch, path, map[string]interface{}{"name": "mine.png"}, http.StatusNotFound},
+		{"invalid folder filter", u, http.MethodGet, "/private/assets?folder_id=nope", nil, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, tc.method, tc.path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+
+	for query, count := range map[string]int{
+		"":                                  1,
+		"?tag=castle":                       1,
+		"?tag=forest":                       0,
+		"?folder_id=" + f.ID.String():       1,
+		"?folder_id=" + foreign.ID.String(): 0,
+	} {
+		rec := testRequest(t, s, u, http.MethodGet, "/private/assets"+query, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		views := []*model.Asset{}
+		json.NewDecoder(rec.Body).Decode(&views)
+		assert.Len(t, views, count, query)
+	}
+}
+
+func TestServer_HandleAssetsDownloadPrivate(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "user")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	rec := testUpload(t, s, u, "secret.txt", []byte("The Count is at home."), map[string][]string{"private": {"true"}})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	v := &struct {
+		model.Asset
+		URL string `json:"url"`
+	}{}
+	json.NewDecoder(rec.Body).Decode(v)
+
+	rec = testRequest(t, s, nil, http.MethodGet, v.URL, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "The Count is at home.", rec.Body.String())
+
+	path := "/assets/" + v.ID.String()
+	expired := strconv.FormatInt(1, 10)
+	testCases := []struct {
+		name string
+		path string
+	}{
+		{"unsigned", path},
+		{"tampered", strings.Replace(v.URL, "signature=", "signature=00", 1)},
+		{"expired", path + "?expires=" + expired + "&signature=" + s.signURL(path, expired)},
+		{"other asset", "/assets/" + v.UserID.String() + v.URL[len(path):]},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, nil, http.MethodGet, tc.path, nil)
+			assert.NotEqual(t, http.StatusOK, rec.Code)
+		})
+	}
+}
+
+func TestServer_HandleUploads(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "user")
+	other := testUser(t, st, "other")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	content := testPNG(t, 300, 100)
+	rec := testRequest(t, s, u, http.MethodPost, "/private/uploads", map[string]interface{}{
+		"name": "banner.png",
+		"size": len(content),
+		"tags": []string{"Banner"},
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	up := &model.Upload{}
+	json.NewDecoder(rec.Body).Decode(up)
+	path := "/private/uploads/" + up.ID.String()
+
+	half := len(content) / 2
+	rec = testChunk(t, s, u, path, 0, content[:half])
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, strconv.Itoa(half), rec.Header().Get(uploadOffsetHeader))
+
+	rec = testChunk(t, s, u, path, 0, content[:half])
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, strconv.Itoa(half), rec.Header().Get(uploadOffsetHeader))
+
+	rec = testChunk(t, s, other, path, half, content[half:])
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = testRequest(t, s, u, http.MethodGet, path, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, strconv.Itoa(half), rec.Header().Get(uploadOffsetHeader))
+
+	rec = testChunk(t, s, u, path, half, append(content[half:], 0))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	rec = testChunk(t, s, u, path, half, content[half:])
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	a := &model.Asset{}
+	json.NewDecoder(rec.Body).Decode(a)
+	assert.Equal(t, "banner.png", a.Name)
+	assert.Equal(t, int64(len(content)), a.Size)
+	assert.Equal(t, []string{"banner"}, a.Tags)
+	assert.Equal(t, 300, a.Width)
+
+	rec = testRequest(t, s, u, http.MethodGet, path, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	ok, _ := s.blobs.Exists(uploadKey(up.ID))
+	assert.False(t, ok)
+
+	testCases := []struct {
+		name         string
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"no size", map[string]interface{}{"name": "theme.ogg"}, http.StatusUnprocessableEntity},
+		{"too large", map[string]interface{}{"name": "theme.ogg", "size": s.assets.maxUploadSize + 1}, http.StatusRequestEntityTooLarge},
+		{"unknown folder", map[string]interface{}{"name": "theme.ogg", "size": 10, "folder_id": up.ID}, http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, u, http.MethodPost, "/private/uploads", tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_HandleUploadsDelete(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "user")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	up := model.TestUpload(t, u)
+	st.Upload().Create(up, s.assets.userQuota)
+	path := "/private/uploads/" + up.ID.String()
+
+	rec := testChunk(t, s, u, path, 0, []byte("OggS"))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = testRequest(t, s, u, http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	ok, _ := s.blobs.Exists(uploadKey(up.ID))
+	assert.False(t, ok)
+}
+
+func TestServer_HandleUploadsCreate_Release(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "user")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	s.assets.userQuota = 6000
+	payload := map[string]interface{}{"name": "theme.ogg", "size": 4096}
+
+	rec := testRequest(t, s, u, http.MethodPost, "/private/uploads", payload)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	up := &model.Upload{}
+	json.NewDecoder(rec.Body).Decode(up)
+
+	rec = testRequest(t, s, u, http.MethodDelete, "/private/uploads/"+up.ID.String(), nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = testRequest(t, s, u, http.MethodPost, "/private/uploads", payload)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	json.NewDecoder(rec.Body).Decode(up)
+	testChunk(t, s, u, "/private/uploads/"+up.ID.String(), 0, []byte("OggS"))
+
+	// Uploads making no progress expire and give back what they reserved.
+	s.assets.uploadTTL = 0
+	rec = testRequest(t, s, u, http.MethodPost, "/private/uploads", payload)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	_, err := st.Upload().Find(up.ID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+	ok, _ := s.blobs.Exists(uploadKey(up.ID))
+	assert.False(t, ok)
+}
+
+func TestServer_HandleFolders(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "user")
+	other := testUser(t, st, "other")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	root := model.TestFolder(t, u)
+	st.Folder().Create(root)
+	child := model.TestFolder(t, u)
+	child.ParentID = &root.ID
+	st.Folder().Create(child)
+	grandchild := model.TestFolder(t, u)
+	grandchild.ParentID = &child.ID
+	st.Folder().Create(grandchild)
+
+	path := "/private/folders/"
+	testCases := []struct {
+		name         string
+		user         *model.User
+		method       string
+		path         string
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"creates", u, http.MethodPost, "/private/folders", map[string]interface{}{"name": "Music", "parent_id": root.ID}, http.StatusCreated},
+		{"creates without name", u, http.MethodPost, "/private/folders", map[string]interface{}{}, http.StatusUnprocessableEntity},
+		{"creates in foreign folder", other, http.MethodPost, "/private/folders", map[string]interface{}{"name": "Music", "parent_id": root.ID}, http.StatusUnprocessableEntity},
+		{"lists", u, http.MethodGet, "/private/folders", nil, http.StatusOK},
+		{"moves into itself", u, http.MethodPatch, path + root.ID.String(), map[string]interface{}{"parent_id": root.ID}, http.StatusUnprocessableEntity},
+		{"moves into subfolder", u, http.MethodPatch, path + root.ID.String(), map[string]interface{}{"parent_id": grandchild.ID}, http.StatusUnprocessableEntity},
+		{"moves to top", u, http.MethodPatch, path + grandchild.ID.String(), map[string]interface{}{"parent_id": nil}, http.StatusOK},
+		{"renames", u, http.MethodPatch, path + child.ID.String(), map[string]interface{}{"name": "Handouts"}, http.StatusOK},
+		{"other renames", other, http.MethodPatch, path + child.ID.String(), map[string]interface{}{"name": "Mine"}, http.StatusNotFound},
+		{"other deletes", other, http.MethodDelete, path + root.ID.String(), nil, http.StatusNotFound},
+		{"deletes", u, http.MethodDelete, path + root.ID.String(), nil, http.StatusNoContent},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, tc.method, tc.path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+
+	folders, _ := st.Folder().FindAll(u.ID)
+	if assert.Len(t, folders, 1) {
+		assert.Equal(t, grandchild.ID, folders[0].ID)
+	}
+}
+
+// testUpload uploads content as an asset in a multipart request.
+func testUpload(t *testing.T, s *server, u *model.User, filename string, content []byte, fields map[string][]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	b := &bytes.Buffer{}
+	mw := multipart.NewWriter(b)
+	for name, values := range fields {
+		for _, v := range values {
+			mw.WriteField(name, v)
+		}
+	}
+	fw, _ := mw.CreateFormFile("file", filename)
+	fw.Write(content)
+	mw.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, "/private/assets", b)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	token, _ := u.CreateJWT([]byte(testJWTKey))
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	return rec
+}
+
+// testChunk sends a chunk of a resumable upload starting at offset.
+func testChunk(t *testing.T, s *server, u *model.User, path string, offset int, chunk []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodPatch, path, bytes.NewReader(chunk))
+	req.Header.Set(uploadOffsetHeader, strconv.Itoa(offset))
+	token, _ := u.CreateJWT([]byte(testJWTKey))
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func testPNG(t *testing.T, width int, height int) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	b := &bytes.Buffer{}
+	if err := png.Encode(b, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return b.Bytes()
+}
@@ -0,0 +1,138 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/sheet"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// authorizeMember loads the campaign from the {id} route variable and makes
+// sure the current user is one of its members.
+func (s *server) authorizeMember(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, ErrNotFound)
+			return
+		}
+
+		s.serveMember(w, r, next, id)
+	})
+}
+
+// serveMember serves the request with the campaign and the user's membership
+// of it in the context.
+func (s *server) serveMember(w http.ResponseWriter, r *http.Request, next http.Handler, campaignID uuid.UUID) {
+	c, err := s.store.Campaign().Find(campaignID)
+	if err != nil {
+		s.error(w, r, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	u := r.Context().Value(ctxKeyUser).(*model.User)
+	m, err := s.store.Campaign().FindMember(c.ID, u.ID)
+	if err != nil {
+		s.error(w, r, http.StatusForbidden, ErrForbidden)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), ctxKeyCampaign, c)
+	next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, ctxKeyMember, m)))
+}
+
+func (s *server) handleCampaignsCreate() http.HandlerFunc {
+	type request struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		System      string `json:"system"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		c := &model.Campaign{
+			Name:        req.Name,
+			Description: req.Description,
+			System:      req.System,
+			OwnerID:     r.Context().Value(ctxKeyUser).(*model.User).ID,
+		}
+		if c.System == "" {
+			c.System = sheet.SystemGeneric
+		}
+
+		html, err := s.render(c.Description, uuid.Nil)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		c.DescriptionHTML = html
+
+		if err := s.store.Campaign().Create(c); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusCreated, c)
+	}
+}
+
+func (s *server) handleCampaignsGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.respond(w, r, http.StatusOK, r.Context().Value(ctxKeyCampaign).(*model.Campaign))
+	}
+}
+
+func (s *server) handleMembersCreate() http.HandlerFunc {
+	type request struct {
+		Username string `json:"username"`
+		Role     string `json:"role"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		u, err := s.store.User().FindByUsername(req.Username)
+		if err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		m := &model.Member{
+			CampaignID: r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID,
+			UserID:     u.ID,
+			Role:       req.Role,
+		}
+		if err := s.store.Campaign().AddMember(m); err != nil {
+			if err == store.ErrAlreadyExists {
+				s.error(w, r, http.StatusConflict, err)
+				return
+			}
+
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.publish(realtime.EventMemberAdded, m.CampaignID, r, m, nil)
+		s.respond(w, r, http.StatusCreated, m)
+	}
+}
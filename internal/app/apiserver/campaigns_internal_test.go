@@ -0,0 +1,116 @@
+package apiserver
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandleCampaignsCreate(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "gm")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	testCases := []struct {
+		name         string
+		payload      interface{}
+		exceptedCode int
+	}{
+		{
+			"valid",
+			map[string]string{"name": "Tomb of Annihilation"},
+			http.StatusCreated,
+		},
+		{
+			"invalid payload",
+			"some invalid payload",
+			http.StatusBadRequest,
+		},
+		{
+			"empty name",
+			map[string]string{"description": "no name"},
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"with system",
+			map[string]string{"name": "Abomination Vaults", "system": "pf2e"},
+			http.StatusCreated,
+		},
+		{
+			"unknown system",
+			map[string]string{"name": "GURPS Dungeon Fantasy", "system": "gurps"},
+			http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, u, http.MethodPost, "/private/campaigns", tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_AuthorizeMember(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	stranger := testUser(t, st, "stranger")
+	c := testCampaign(t, st, gm, nil)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		path         string
+		exceptedCode int
+	}{
+		{"member", gm, fmt.Sprintf("/private/campaigns/%s", c.ID), http.StatusOK},
+		{"not a member", stranger, fmt.Sprintf("/private/campaigns/%s", c.ID), http.StatusForbidden},
+		{"unknown campaign", gm, fmt.Sprintf("/private/campaigns/%s", uuid.New()), http.StatusNotFound},
+		{"invalid id", gm, "/private/campaigns/nope", http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, http.MethodGet, tc.path, nil)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_HandleMembersCreate(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	newbie := testUser(t, st, "newbie")
+	testUser(t, st, "other")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	path := fmt.Sprintf("/private/campaigns/%s/members", c.ID)
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"valid", gm, map[string]string{"username": "newbie", "role": model.RolePlayer}, http.StatusCreated},
+		{"already a member", gm, map[string]string{"username": newbie.Username, "role": model.RolePlayer}, http.StatusConflict},
+		{"not a gm", player, map[string]string{"username": "other", "role": model.RolePlayer}, http.StatusForbidden},
+		{"unknown user", gm, map[string]string{"username": "ghost", "role": model.RolePlayer}, http.StatusUnprocessableEntity},
+		{"invalid role", gm, map[string]string{"username": "other", "role": "overlord"}, http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, http.MethodPost, path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+}
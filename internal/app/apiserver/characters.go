@@ -0,0 +1,351 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/dice"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+var (
+	ErrOwnerNotAMember  = errors.New("owner is not a member of the campaign")
+	ErrUnknownCharacter = errors.New("unknown character")
+	ErrVersionMismatch  = errors.New("character has been changed since it was loaded")
+)
+
+func (s *server) handleCharactersCreate() http.HandlerFunc {
+	type request struct {
+		Name     string          `json:"name"`
+		Portrait string          `json:"portrait"`
+		Data     json.RawMessage `json:"data"`
+		OwnerID  *uuid.UUID      `json:"owner_id"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		member := r.Context().Value(ctxKeyMember).(*model.Member)
+		if !member.CanPlay() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		campaign := r.Context().Value(ctxKeyCampaign).(*model.Campaign)
+		c := &model.Character{
+			CampaignID: campaign.ID,
+			OwnerID:    member.UserID,
+			Name:       req.Name,
+			Portrait:   req.Portrait,
+			System:     campaign.System,
+			Data:       req.Data,
+		}
+		if len(c.Data) == 0 {
+			c.Data = json.RawMessage("{}")
+		}
+
+		if req.OwnerID != nil {
+			if code, err := s.setOwner(member, c, *req.OwnerID); err != nil {
+				s.error(w, r, code, err)
+				return
+			}
+		}
+
+		code := http.StatusInternalServerError
+		if err := s.store.Transaction(func(tx store.Store) error {
+			if err := tx.Character().Create(c); err != nil {
+				code = http.StatusUnprocessableEntity
+				return err
+			}
+
+			return saveRevision(tx, c, nil, member.UserID)
+		}); err != nil {
+			s.error(w, r, code, err)
+			return
+		}
+
+		if err := c.Derive(); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.publish(realtime.EventCharacterCreated, c.CampaignID, r, c, nil)
+		w.Header().Set("ETag", etag(c))
+		s.respond(w, r, http.StatusCreated, c)
+	}
+}
+
+func (s *server) handleCharactersIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		characters, err := s.store.Character().FindAll(r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		for _, c := range characters {
+			if err := c.Derive(); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		s.respond(w, r, http.StatusOK, characters)
+	}
+}
+
+func (s *server) handleCharactersGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, code, err := s.findCharacter(r)
+		if err != nil {
+			s.error(w, r, code, err)
+			return
+		}
+
+		w.Header().Set("ETag", etag(c))
+		s.respond(w, r, http.StatusOK, c)
+	}
+}
+
+func (s *server) handleCharactersUpdate() http.HandlerFunc {
+	type request struct {
+		Name     *string         `json:"name"`
+		Portrait *string         `json:"portrait"`
+		Data     json.RawMessage `json:"data"`
+		OwnerID  *uuid.UUID      `json:"owner_id"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, code, err := s.findCharacter(r)
+		if err != nil {
+			s.error(w, r, code, err)
+			return
+		}
+
+		member := r.Context().Value(ctxKeyMember).(*model.Member)
+		if !c.CanEdit(member) {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		if !matchesVersion(r, c) {
+			s.error(w, r, http.StatusPreconditionFailed, ErrVersionMismatch)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		before, err := c.Snapshot()
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if req.OwnerID != nil {
+			if code, err := s.setOwner(member, c, *req.OwnerID); err != nil {
+				s.error(w, r, code, err)
+				return
+			}
+		}
+		if req.Name != nil {
+			c.Name = *req.Name
+		}
+		if req.Portrait != nil {
+			c.Portrait = *req.Portrait
+		}
+		if req.Data != nil {
+			c.Data = req.Data
+		}
+
+		if code, err := s.updateCharacter(c, before, member.UserID); err != nil {
+			s.error(w, r, code, err)
+			return
+		}
+
+		s.publish(realtime.EventCharacterUpdated, c.CampaignID, r, c, nil)
+		w.Header().Set("ETag", etag(c))
+		s.respond(w, r, http.StatusOK, c)
+	}
+}
+
+func (s *server) handleCharactersDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, code, err := s.findCharacter(r)
+		if err != nil {
+			s.error(w, r, code, err)
+			return
+		}
+
+		if !c.CanEdit(r.Context().Value(ctxKeyMember).(*model.Member)) {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		if err := s.store.Character().Delete(c.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.publish(realtime.EventCharacterDeleted, c.CampaignID, r, map[string]uuid.UUID{"id": c.ID}, nil)
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// setOwner hands the character over to another member of the campaign,
+// which only GMs may do.
+func (s *server) setOwner(member *model.Member, c *model.Character, ownerID uuid.UUID) (int, error) {
+	if ownerID == c.OwnerID {
+		return 0, nil
+	}
+
+	if !member.IsGM() {
+		return http.StatusForbidden, ErrForbidden
+	}
+
+	if _, err := s.store.Campaign().FindMember(c.CampaignID, ownerID); err != nil {
+		return http.StatusUnprocessableEntity, ErrOwnerNotAMember
+	}
+	c.OwnerID = ownerID
+
+	return 0, nil
+}
+
+// updateCharacter saves the changes made to the character since the before
+// snapshot was taken and records them as a new revision, both or neither,
+// then derives its fields for the response.
+func (s *server) updateCharacter(c *model.Character, before []byte, authorID uuid.UUID) (int, error) {
+	code := http.StatusInternalServerError
+	if err := s.store.Transaction(func(tx store.Store) error {
+		if err := tx.Character().Update(c); err != nil {
+			code = http.StatusUnprocessableEntity
+			return err
+		}
+
+		return saveRevision(tx, c, before, authorID)
+	}); err != nil {
+		if err == store.ErrConflict {
+			return http.StatusPreconditionFailed, ErrVersionMismatch
+		}
+
+		return code, err
+	}
+
+	if err := c.Derive(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return 0, nil
+}
+
+// saveRevision records the current version of the character.
+func saveRevision(tx store.Store, c *model.Character, before []byte, authorID uuid.UUID) error {
+	rev, err := model.NewCharacterRevision(c, before, authorID)
+	if err != nil {
+		return err
+	}
+
+	return tx.CharacterRevision().Create(rev)
+}
+
+// etag identifies the version of the character for If-Match requests.
+func etag(c *model.Character) string {
+	return `"` + strconv.Itoa(c.Version) + `"`
+}
+
+// matchesVersion checks the If-Match header, if any, so that an update based
+// on an outdated copy of the character doesn't overwrite newer changes.
+func matchesVersion(r *http.Request, c *model.Character) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" || header == "*" {
+		return true
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == etag(c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// authorizeCharacter loads the campaign of the {characterID} character, for
+// the character's routes outside of its campaign's, and makes sure that the
+// user is a member of it.
+func (s *server) authorizeCharacter(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(mux.Vars(r)["characterID"])
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, ErrNotFound)
+			return
+		}
+
+		c, err := s.store.Character().Find(id)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, ErrNotFound)
+			return
+		}
+
+		s.serveMember(w, r, next, c.CampaignID)
+	})
+}
+
+// findCharacter loads the {characterID} character of the current campaign
+// with its derived fields. Characters whose fields can't be derived are a
+// server error, not a missing character.
+func (s *server) findCharacter(r *http.Request) (*model.Character, int, error) {
+	id, err := uuid.Parse(mux.Vars(r)["characterID"])
+	if err != nil {
+		return nil, http.StatusNotFound, ErrNotFound
+	}
+
+	c, err := s.store.Character().Find(id)
+	if err != nil || c.CampaignID != r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID {
+		return nil, http.StatusNotFound, ErrNotFound
+	}
+
+	if err := c.Derive(); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	return c, 0, nil
+}
+
+// characterLookup resolves dice references against the sheet of a character
+// of the current campaign, one the member can edit. Without a character
+// there is nothing to refer to.
+func (s *server) characterLookup(r *http.Request, id *uuid.UUID) (dice.Lookup, int, error) {
+	if id == nil {
+		return nil, 0, nil
+	}
+
+	c, err := s.store.Character().Find(*id)
+	if err != nil || c.CampaignID != r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID {
+		return nil, http.StatusUnprocessableEntity, ErrUnknownCharacter
+	}
+
+	if !c.CanEdit(r.Context().Value(ctxKeyMember).(*model.Member)) {
+		return nil, http.StatusForbidden, ErrForbidden
+	}
+
+	if err := c.Derive(); err != nil {
+		return nil, http.StatusUnprocessableEntity, err
+	}
+
+	return c.Lookup(), 0, nil
+}
@@ -0,0 +1,165 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/ruleset"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandleCharactersCreate(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	spectator := testUser(t, st, "spectator")
+	stranger := testUser(t, st, "stranger")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer, spectator: model.RoleSpectator})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	path := fmt.Sprintf("/private/campaigns/%s/characters", c.ID)
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"player", player, map[string]interface{}{"name": "Ismark", "data": map[string]interface{}{"class": "fighter", "level": 5}}, http.StatusCreated},
+		{"without data", player, map[string]interface{}{"name": "Ismark"}, http.StatusCreated},
+		{"gm for player", gm, map[string]interface{}{"name": "Ireena", "owner_id": player.ID}, http.StatusCreated},
+		{"gm for stranger", gm, map[string]interface{}{"name": "Ireena", "owner_id": stranger.ID}, http.StatusUnprocessableEntity},
+		{"player for gm", player, map[string]interface{}{"name": "Strahd", "owner_id": gm.ID}, http.StatusForbidden},
+		{"spectator", spectator, map[string]interface{}{"name": "Ghost"}, http.StatusForbidden},
+		{"invalid sheet", player, map[string]interface{}{"name": "Ismark", "data": map[string]interface{}{"level": 42}}, http.StatusUnprocessableEntity},
+		{"empty name", player, map[string]interface{}{"data": map[string]interface{}{}}, http.StatusUnprocessableEntity},
+		{"invalid payload", player, "some invalid payload", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, http.MethodPost, path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_HandleCharactersIndexAndGet(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	spectator := testUser(t, st, "spectator")
+	c := testCampaign(t, st, gm, map[*model.User]string{spectator: model.RoleSpectator})
+	other := testCampaign(t, st, gm, nil)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	ch := model.TestCharacter(t, c, gm)
+	st.Character().Create(ch)
+	foreign := model.TestCharacter(t, other, gm)
+	st.Character().Create(foreign)
+
+	rec := testRequest(t, s, spectator, http.MethodGet, fmt.Sprintf("/private/campaigns/%s/characters", c.ID), nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	characters := []*model.Character{}
+	json.NewDecoder(rec.Body).Decode(&characters)
+	if assert.Len(t, characters, 1) {
+		assert.Equal(t, ch.ID, characters[0].ID)
+		assert.JSONEq(t, string(ch.Data), string(characters[0].Data))
+	}
+
+	rec = testRequest(t, s, spectator, http.MethodGet, fmt.Sprintf("/private/campaigns/%s/characters/%s", c.ID, ch.ID), nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	got := &model.Character{}
+	json.NewDecoder(rec.Body).Decode(got)
+	assert.Equal(t, 2.0, got.Derived["str_mod"])
+	assert.Equal(t, 2.0, got.Derived["proficiency_bonus"])
+
+	rec = testRequest(t, s, gm, http.MethodGet, fmt.Sprintf("/private/campaigns/%s/characters/%s", c.ID, foreign.ID), nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestServer_HandleCharactersUpdateAndDelete(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	alice := testUser(t, st, "alice")
+	bob := testUser(t, st, "bob")
+	spectator := testUser(t, st, "spectator")
+	c := testCampaign(t, st, gm, map[*model.User]string{alice: model.RolePlayer, bob: model.RolePlayer, spectator: model.RoleSpectator})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	ch := model.TestCharacter(t, c, alice)
+	st.Character().Create(ch)
+	path := fmt.Sprintf("/private/campaigns/%s/characters/%s", c.ID, ch.ID)
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		method       string
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"owner edits", alice, http.MethodPatch, map[string]interface{}{"data": map[string]interface{}{"level": 4}}, http.StatusOK},
+		{"owner breaks the sheet", alice, http.MethodPatch, map[string]interface{}{"data": map[string]interface{}{"level": "four"}}, http.StatusUnprocessableEntity},
+		{"owner gives it away", alice, http.MethodPatch, map[string]interface{}{"owner_id": bob.ID}, http.StatusForbidden},
+		{"other player edits", bob, http.MethodPatch, map[string]interface{}{"name": "Bob's now"}, http.StatusForbidden},
+		{"spectator edits", spectator, http.MethodPatch, map[string]interface{}{"name": "Ghost"}, http.StatusForbidden},
+		{"gm renames", gm, http.MethodPatch, map[string]interface{}{"name": "Ireena"}, http.StatusOK},
+		{"gm reassigns to stranger", gm, http.MethodPatch, map[string]interface{}{"owner_id": uuid.New()}, http.StatusUnprocessableEntity},
+		{"gm reassigns", gm, http.MethodPatch, map[string]interface{}{"owner_id": bob.ID}, http.StatusOK},
+		{"previous owner deletes", alice, http.MethodDelete, nil, http.StatusForbidden},
+		{"new owner deletes", bob, http.MethodDelete, nil, http.StatusNoContent},
+		{"deleted", gm, http.MethodPatch, map[string]interface{}{"name": "Ireena"}, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, tc.method, path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+}
+
+// underivable accepts any sheet but can't derive its fields.
+type underivable struct {
+	ruleset.Generic
+}
+
+func (u *underivable) Name() string {
+	return "underivable"
+}
+
+func (u *underivable) ValidateSheet(data []byte) error {
+	return nil
+}
+
+func (u *underivable) Derive(data []byte) (map[string]float64, error) {
+	return nil, errors.New("can't derive")
+}
+
+func init() {
+	ruleset.Register(&underivable{})
+}
+
+func TestServer_HandleCharactersGet_Underivable(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	c := testCampaign(t, st, gm, nil)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	ch := model.TestCharacter(t, c, gm)
+	ch.System = "underivable"
+	st.Character().Create(ch)
+
+	path := fmt.Sprintf("/private/campaigns/%s/characters/%s", c.ID, ch.ID)
+	rec := testRequest(t, s, gm, http.MethodGet, path, nil)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	rec = testRequest(t, s, gm, http.MethodGet, path+"/revisions", nil)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	rec = testRequest(t, s, gm, http.MethodGet, fmt.Sprintf("/private/campaigns/%s/characters/%s", c.ID, uuid.New()), nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
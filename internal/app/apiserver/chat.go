@@ -0,0 +1,272 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/chat"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultMessagesLimit = 50
+	maxMessagesLimit     = 100
+)
+
+var (
+	ErrRecipientNotAMember = errors.New("recipient is not a member of the campaign")
+	ErrMessageHasRolls     = errors.New("messages with rolls can't be edited")
+)
+
+func (s *server) handleMessagesCreate() http.HandlerFunc {
+	type request struct {
+		Kind        string      `json:"kind"`
+		Body        string      `json:"body"`
+		CharacterID *uuid.UUID  `json:"character_id"`
+		Speaker     string      `json:"speaker"`
+		Recipients  []uuid.UUID `json:"recipients"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		cmd, err := chat.Parse(req.Body)
+		if err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		m := &model.ChatMessage{
+			CampaignID:  r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID,
+			UserID:      r.Context().Value(ctxKeyUser).(*model.User).ID,
+			Kind:        req.Kind,
+			Body:        cmd.Body,
+			CharacterID: req.CharacterID,
+			Speaker:     req.Speaker,
+			Recipients:  req.Recipients,
+		}
+		if cmd.Kind != "" {
+			m.Kind = cmd.Kind
+		}
+		if m.Kind == "" {
+			m.Kind = model.ChatOOC
+		}
+		if m.Kind != model.ChatWhisper {
+			m.Recipients = nil
+		}
+
+		if cmd.Recipients != nil {
+			m.Recipients = nil
+			for _, username := range cmd.Recipients {
+				u, err := s.store.User().FindByUsername(username)
+				if err != nil {
+					s.error(w, r, http.StatusUnprocessableEntity, ErrRecipientNotAMember)
+					return
+				}
+				m.Recipients = append(m.Recipients, u.ID)
+			}
+		}
+
+		member := r.Context().Value(ctxKeyMember).(*model.Member)
+		if !m.CanPost(member) {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		lookup, code, err := s.characterLookup(r, m.CharacterID)
+		if err != nil {
+			s.error(w, r, code, err)
+			return
+		}
+
+		if m.Rolls, err = cmd.Evaluate(s.roller, lookup); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+		if len(m.Rolls) > 0 && !member.CanPlay() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		rs, err := s.campaignRuleset(r)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		for i := range m.Rolls {
+			m.Rolls[i].Outcome = rs.Interpret(&m.Rolls[i].Result)
+		}
+
+		for _, id := range m.Recipients {
+			if _, err := s.store.Campaign().FindMember(m.CampaignID, id); err != nil {
+				s.error(w, r, http.StatusUnprocessableEntity, ErrRecipientNotAMember)
+				return
+			}
+		}
+
+		if m.BodyHTML, err = s.render(m.Body, m.CampaignID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.store.ChatMessage().Create(m); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.publish(realtime.EventChatCreated, m.CampaignID, r, m, chatAudience(m))
+		s.respond(w, r, http.StatusCreated, m)
+	}
+}
+
+func (s *server) handleMessagesIndex() http.HandlerFunc {
+	type response struct {
+		Messages   []*model.ChatMessage `json:"messages"`
+		NextCursor *string              `json:"next_cursor"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		f := &model.ChatFilter{Limit: defaultMessagesLimit}
+		if v := r.URL.Query().Get("before"); v != "" {
+			c, err := model.DecodeChatCursor(v)
+			if err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+			f.Before = c
+		}
+
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxMessagesLimit {
+				s.error(w, r, http.StatusBadRequest, ErrInvalidFilter)
+				return
+			}
+			f.Limit = n
+		}
+
+		campaign := r.Context().Value(ctxKeyCampaign).(*model.Campaign)
+		messages, err := s.store.ChatMessage().FindAll(campaign.ID, r.Context().Value(ctxKeyMember).(*model.Member), f)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		res := &response{Messages: messages}
+		if len(messages) == f.Limit {
+			next := messages[len(messages)-1].Cursor().Encode()
+			res.NextCursor = &next
+		}
+
+		s.respond(w, r, http.StatusOK, res)
+	}
+}
+
+func (s *server) handleMessagesUpdate() http.HandlerFunc {
+	type request struct {
+		Body string `json:"body"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		m, err := s.findChatMessage(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !m.CanModify(r.Context().Value(ctxKeyMember).(*model.Member)) {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		if len(m.Rolls) > 0 {
+			s.error(w, r, http.StatusUnprocessableEntity, ErrMessageHasRolls)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		m.Body = req.Body
+		if m.BodyHTML, err = s.render(m.Body, m.CampaignID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.store.ChatMessage().Update(m); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.publish(realtime.EventChatUpdated, m.CampaignID, r, m, chatAudience(m))
+		s.respond(w, r, http.StatusOK, m)
+	}
+}
+
+func (s *server) handleMessagesDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m, err := s.findChatMessage(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !m.CanModify(r.Context().Value(ctxKeyMember).(*model.Member)) {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		if err := s.store.ChatMessage().Delete(m.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.publish(realtime.EventChatDeleted, m.CampaignID, r, map[string]uuid.UUID{"id": m.ID}, chatAudience(m))
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// findChatMessage loads the {messageID} message of the current campaign,
+// pretending it doesn't exist if the member isn't allowed to see it.
+func (s *server) findChatMessage(r *http.Request) (*model.ChatMessage, error) {
+	id, err := uuid.Parse(mux.Vars(r)["messageID"])
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	m, err := s.store.ChatMessage().Find(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	campaign := r.Context().Value(ctxKeyCampaign).(*model.Campaign)
+	if m.CampaignID != campaign.ID || !m.VisibleTo(r.Context().Value(ctxKeyMember).(*model.Member)) {
+		return nil, ErrNotFound
+	}
+
+	return m, nil
+}
+
+// chatAudience mirrors model.ChatMessage.VisibleTo for realtime delivery.
+func chatAudience(m *model.ChatMessage) *realtime.Audience {
+	switch m.Kind {
+	case model.ChatWhisper:
+		return &realtime.Audience{UserIDs: append([]uuid.UUID{m.UserID}, m.Recipients...)}
+	case model.ChatGM:
+		return &realtime.Audience{GMOnly: true, UserIDs: []uuid.UUID{m.UserID}}
+	}
+
+	return nil
+}
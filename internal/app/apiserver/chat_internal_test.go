@@ -0,0 +1,244 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandleMessagesCreate(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	spectator := testUser(t, st, "spectator")
+	stranger := testUser(t, st, "stranger")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer, spectator: model.RoleSpectator})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	path := fmt.Sprintf("/private/campaigns/%s/messages", c.ID)
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"ooc", spectator, map[string]interface{}{"body": "hi all"}, http.StatusCreated},
+		{"in character", player, map[string]interface{}{"kind": "ic", "speaker": "Ismark", "body": "Welcome"}, http.StatusCreated},
+		{"whisper", player, map[string]interface{}{"kind": "whisper", "recipients": []uuid.UUID{gm.ID}, "body": "psst"}, http.StatusCreated},
+		{"whisper to stranger", player, map[string]interface{}{"kind": "whisper", "recipients": []uuid.UUID{stranger.ID}, "body": "psst"}, http.StatusUnprocessableEntity},
+		{"spectator in character", spectator, map[string]interface{}{"kind": "ic", "speaker": "Ghost", "body": "Boo"}, http.StatusForbidden},
+		{"player system message", player, map[string]interface{}{"kind": "system", "body": "Server restarting"}, http.StatusForbidden},
+		{"empty body", player, map[string]interface{}{"body": ""}, http.StatusUnprocessableEntity},
+		{"roll command", player, map[string]interface{}{"body": "/roll 1d20+5 to hit"}, http.StatusCreated},
+		{"inline roll", player, map[string]interface{}{"kind": "ic", "speaker": "Ismark", "body": "I hit for [[2d6+3]]"}, http.StatusCreated},
+		{"spectator rolls", spectator, map[string]interface{}{"body": "/r d20"}, http.StatusForbidden},
+		{"spectator emotes", spectator, map[string]interface{}{"body": "/me waves"}, http.StatusForbidden},
+		{"whisper command", player, map[string]interface{}{"body": "/w gm psst"}, http.StatusCreated},
+		{"whisper command to stranger", player, map[string]interface{}{"body": "/w stranger psst"}, http.StatusUnprocessableEntity},
+		{"whisper command to unknown user", player, map[string]interface{}{"body": "/w nobody psst"}, http.StatusUnprocessableEntity},
+		{"unknown command", player, map[string]interface{}{"body": "/dance"}, http.StatusUnprocessableEntity},
+		{"invalid payload", player, "some invalid payload", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, http.MethodPost, path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_HandleMessagesCreateWithRolls(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	rec := testRequest(t, s, player, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/messages", c.ID), map[string]interface{}{
+		"body":  "/gmroll 1d20+2 stealth, [[1d4]] if sneak attack",
+		"rolls": []map[string]int{{"total": 20}},
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	m := &model.ChatMessage{}
+	json.NewDecoder(rec.Body).Decode(m)
+	assert.Equal(t, model.ChatGM, m.Kind)
+	assert.Equal(t, "stealth, [[1d4]] if sneak attack", m.Body)
+	assert.Len(t, m.Rolls, 2)
+	assert.Equal(t, "1d20+2", m.Rolls[0].Expression)
+	assert.True(t, m.Rolls[0].Total >= 3 && m.Rolls[0].Total <= 22)
+	assert.Equal(t, "[[1d4]]", m.Rolls[1].Source)
+	assert.True(t, m.Rolls[1].Inline)
+
+	rec = testRequest(t, s, player, http.MethodPatch, fmt.Sprintf("/private/campaigns/%s/messages/%s", c.ID, m.ID), map[string]string{
+		"body": "stealth",
+	})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestServer_HandleMessagesCreateRendersMarkdown(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	testUser(t, st, "stranger")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	rec := testRequest(t, s, player, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/messages", c.ID), map[string]interface{}{
+		"body": "**@gm** and @stranger: ||it's a mimic||<script>alert(1)</script>",
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	m := &model.ChatMessage{}
+	json.NewDecoder(rec.Body).Decode(m)
+	assert.Equal(t, "**@gm** and @stranger: ||it's a mimic||<script>alert(1)</script>", m.Body)
+	assert.Equal(t, fmt.Sprintf(
+		`<p><strong><span class="mention" data-user-id="%s">@gm</span></strong> and @stranger: <span class="spoiler">it&#39;s a mimic</span>alert(1)</p>`+"\n",
+		gm.ID,
+	), m.BodyHTML)
+
+	ch := model.TestCharacter(t, c, player)
+	st.Character().Create(ch)
+	rec = testRequest(t, s, player, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/messages", c.ID), map[string]interface{}{
+		"body": "@[ireena kolyana] waves",
+	})
+	json.NewDecoder(rec.Body).Decode(m)
+	assert.Equal(t, fmt.Sprintf(`<p><span class="character" data-character-id="%s">ireena kolyana</span> waves</p>`+"\n", ch.ID), m.BodyHTML)
+}
+
+func TestServer_HandleMessagesCreateWhisperDelivery(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	alice := testUser(t, st, "alice")
+	bob := testUser(t, st, "bob")
+	c := testCampaign(t, st, gm, map[*model.User]string{alice: model.RolePlayer, bob: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	members := map[*model.User]*realtime.Client{}
+	for _, u := range []*model.User{gm, alice, bob} {
+		m, _ := st.Campaign().FindMember(c.ID, u.ID)
+		members[u], _, _ = s.hub.Subscribe(m, "")
+		defer s.hub.Unsubscribe(members[u])
+	}
+
+	rec := testRequest(t, s, alice, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/messages", c.ID), map[string]interface{}{
+		"kind":       model.ChatWhisper,
+		"recipients": []uuid.UUID{bob.ID},
+		"body":       "the GM is a lich",
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	received := func(c *realtime.Client) bool {
+		for {
+			select {
+			case e := <-c.Events():
+				if e.Type == realtime.EventChatCreated {
+					return true
+				}
+			default:
+				return false
+			}
+		}
+	}
+	assert.True(t, received(members[alice]))
+	assert.True(t, received(members[bob]))
+	assert.False(t, received(members[gm]))
+}
+
+func TestServer_HandleMessagesIndex(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	path := fmt.Sprintf("/private/campaigns/%s/messages", c.ID)
+
+	for i := 0; i < 3; i++ {
+		testRequest(t, s, player, http.MethodPost, path, map[string]interface{}{"body": fmt.Sprintf("message %d", i)})
+	}
+	testRequest(t, s, player, http.MethodPost, path, map[string]interface{}{"kind": "gm", "body": "I'm secretly a vampire"})
+
+	type page struct {
+		Messages   []*model.ChatMessage `json:"messages"`
+		NextCursor *string              `json:"next_cursor"`
+	}
+	get := func(u *model.User, query string) (int, *page) {
+		rec := testRequest(t, s, u, http.MethodGet, path+query, nil)
+		p := &page{}
+		json.NewDecoder(rec.Body).Decode(p)
+
+		return rec.Code, p
+	}
+
+	code, p := get(gm, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, p.Messages, 4)
+	assert.Nil(t, p.NextCursor)
+
+	code, p = get(player, "?limit=2")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, p.Messages, 2)
+	if assert.NotNil(t, p.NextCursor) {
+		_, next := get(player, "?limit=2&before="+*p.NextCursor)
+		assert.Len(t, next.Messages, 2)
+		assert.Equal(t, "message 0", next.Messages[1].Body)
+	}
+
+	code, _ = get(player, "?before=garbage")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = get(player, "?limit=0")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestServer_HandleMessagesUpdateAndDelete(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	alice := testUser(t, st, "alice")
+	bob := testUser(t, st, "bob")
+	c := testCampaign(t, st, gm, map[*model.User]string{alice: model.RolePlayer, bob: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	m := model.TestChatMessage(t, c, alice)
+	st.ChatMessage().Create(m)
+	whisper := model.TestChatMessage(t, c, alice)
+	whisper.Kind = model.ChatWhisper
+	whisper.Recipients = []uuid.UUID{gm.ID}
+	st.ChatMessage().Create(whisper)
+
+	path := func(m *model.ChatMessage) string {
+		return fmt.Sprintf("/private/campaigns/%s/messages/%s", c.ID, m.ID)
+	}
+	edit := map[string]string{"body": "edited"}
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		method       string
+		path         string
+		exceptedCode int
+	}{
+		{"author edits", alice, http.MethodPatch, path(m), http.StatusOK},
+		{"other player edits", bob, http.MethodPatch, path(m), http.StatusForbidden},
+		{"gm edits", gm, http.MethodPatch, path(m), http.StatusOK},
+		{"other player edits whisper", bob, http.MethodPatch, path(whisper), http.StatusNotFound},
+		{"unknown message", alice, http.MethodPatch, fmt.Sprintf("/private/campaigns/%s/messages/%s", c.ID, uuid.New()), http.StatusNotFound},
+		{"other player deletes", bob, http.MethodDelete, path(m), http.StatusForbidden},
+		{"gm deletes", gm, http.MethodDelete, path(m), http.StatusNoContent},
+		{"deleted", alice, http.MethodDelete, path(m), http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, tc.method, tc.path, edit)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package apiserver
+
+import (
+	"net/http"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/collab"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+// journalDocuments stores the bodies of journal entries edited together,
+// rendering them as they are saved.
+type journalDocuments struct {
+	server *server
+}
+
+func (d *journalDocuments) Load(docID uuid.UUID) (string, int, error) {
+	e, err := d.server.store.Journal().Find(docID)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return e.Body, e.Revision, nil
+}
+
+func (d *journalDocuments) Operations(docID uuid.UUID, after int) ([]*collab.Operation, error) {
+	stored, err := d.server.store.JournalOperation().FindAll(docID, after)
+	if err != nil {
+		return nil, err
+	}
+
+	operations := []*collab.Operation{}
+	for _, o := range stored {
+		operations = append(operations, &collab.Operation{Revision: o.Revision, UserID: o.UserID, Op: o.Op})
+	}
+
+	return operations, nil
+}
+
+func (d *journalDocuments) Append(docID uuid.UUID, o *collab.Operation) error {
+	err := d.server.store.JournalOperation().Create(&model.JournalOperation{
+		EntryID:  docID,
+		Revision: o.Revision,
+		UserID:   o.UserID,
+		Op:       o.Op,
+	})
+	if err == store.ErrAlreadyExists {
+		return collab.ErrRevisionTaken
+	}
+
+	return err
+}
+
+func (d *journalDocuments) Save(docID uuid.UUID, body string, revision int) error {
+	e, err := d.server.store.Journal().Find(docID)
+	if err != nil {
+		return err
+	}
+
+	e.Body = body
+	e.Revision = revision
+	if e.BodyHTML, err = d.server.render(body, e.CampaignID); err != nil {
+		return err
+	}
+
+	if err := d.server.store.Journal().UpdateBody(e); err != nil && err != store.ErrConflict {
+		return err
+	}
+
+	return nil
+}
+
+func (d *journalDocuments) Prune(docID uuid.UUID, through int) error {
+	return d.server.store.JournalOperation().Prune(docID, through)
+}
+
+// handleJournalCollab opens a WebSocket editing the entry's body together
+// with everyone else who has it open. Members who can see the entry but not
+// edit it follow along read-only.
+func (s *server) handleJournalCollab() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		e, err := s.findJournalEntry(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		m := r.Context().Value(ctxKeyMember).(*model.Member)
+		session, err := s.collab.Open(e.CampaignID, e.ID, m.UserID, !e.CanEdit(m))
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			session.Close()
+			s.logger.Error(err.Error())
+			return
+		}
+
+		session.Serve(conn)
+	}
+}
@@ -0,0 +1,118 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/collab"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandleJournalCollab(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	stranger := testUser(t, st, "stranger")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	shared := model.TestJournalEntry(t, c, gm)
+	shared.Body = "the castle"
+	shared.Visibility = model.VisibilityPlayers
+	shared.Collaborative = true
+	st.Journal().Create(shared)
+	lore := model.TestJournalEntry(t, c, gm)
+	lore.Visibility = model.VisibilityPlayers
+	st.Journal().Create(lore)
+	secret := model.TestJournalEntry(t, c, gm)
+	st.Journal().Create(secret)
+
+	dial := func(u *model.User, e *model.JournalEntry) (*websocket.Conn, *http.Response, error) {
+		ticket, _ := u.CreateTicket([]byte(testJWTKey))
+		url := fmt.Sprintf("ws%s/private/campaigns/%s/journal/%s/collab?ticket=%s", strings.TrimPrefix(srv.URL, "http"), c.ID, e.ID, ticket)
+
+		return websocket.DefaultDialer.Dial(url, nil)
+	}
+	read := func(conn *websocket.Conn) *collab.Message {
+		msg := &collab.Message{}
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		assert.NoError(t, conn.ReadJSON(msg))
+
+		return msg
+	}
+
+	conn, _, err := dial(player, shared)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	msg := read(conn)
+	assert.Equal(t, collab.MessageInit, msg.Type)
+	assert.Equal(t, "the castle", *msg.Body)
+	assert.False(t, msg.ReadOnly)
+
+	conn.WriteJSON(map[string]interface{}{"type": "operation", "revision": 0, "op": []interface{}{4, "dark ", 6}})
+	msg = read(conn)
+	assert.Equal(t, collab.MessageAck, msg.Type)
+	assert.Equal(t, 1, msg.Revision)
+
+	conn, _, err = dial(player, lore)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	msg = read(conn)
+	assert.True(t, msg.ReadOnly)
+	conn.WriteJSON(map[string]interface{}{"type": "operation", "revision": 0, "op": []interface{}{"!"}})
+	msg = read(conn)
+	assert.Equal(t, collab.MessageError, msg.Type)
+	assert.Equal(t, collab.ErrReadOnly.Error(), msg.Error)
+
+	_, res, err := dial(player, secret)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	_, res, err = dial(stranger, shared)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+}
+
+func TestServer_HandleJournalUpdateBody(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	c := testCampaign(t, st, gm, nil)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	e := model.TestJournalEntry(t, c, gm)
+	st.Journal().Create(e)
+	path := fmt.Sprintf("/private/campaigns/%s/journal/%s", c.ID, e.ID)
+
+	rec := testRequest(t, s, gm, http.MethodPatch, path, map[string]interface{}{"body": "*Barovia*", "collaborative": true})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	updated := &model.JournalEntry{}
+	json.NewDecoder(rec.Body).Decode(updated)
+	assert.Equal(t, "*Barovia*", updated.Body)
+	assert.Equal(t, "<p><em>Barovia</em></p>\n", updated.BodyHTML)
+	assert.Equal(t, 1, updated.Revision)
+	assert.True(t, updated.Collaborative)
+
+	operations, _ := st.JournalOperation().FindAll(e.ID, 0)
+	assert.Len(t, operations, 1)
+
+	rec = testRequest(t, s, gm, http.MethodPatch, path, map[string]interface{}{"body": strings.Repeat("a", model.JournalBodyMaxLength+1)})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
@@ -0,0 +1,899 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/dice"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// rollOffExpression is rolled along with initiative to break ties when the
+// combat's rule asks for it.
+const rollOffExpression = "1d20"
+
+var (
+	ErrUnknownScene     = errors.New("unknown scene")
+	ErrUnknownToken     = errors.New("unknown token")
+	ErrInvalidAmount    = errors.New("amount must be positive")
+	ErrCombatantUnnamed = errors.New("combatant needs a name, a token or a character")
+)
+
+// combatView is a combat with its combatants in turn order, as a member
+// sees it.
+type combatView struct {
+	*model.Combat
+	Combatants []*model.Combatant `json:"combatants"`
+}
+
+// newCombatView leaves hidden combatants out for players, along with the
+// current turn when it is one of theirs.
+func newCombatView(c *model.Combat, combatants []*model.Combatant, member *model.Member) *combatView {
+	c.Order(combatants)
+	v := &combatView{Combat: c, Combatants: []*model.Combatant{}}
+	for _, cb := range combatants {
+		if cb.VisibleTo(member) {
+			v.Combatants = append(v.Combatants, cb)
+		} else if c.CurrentID != nil && *c.CurrentID == cb.ID {
+			cc := *c
+			cc.CurrentID = nil
+			v.Combat = &cc
+		}
+	}
+
+	return v
+}
+
+func (s *server) handleCombatsCreate() http.HandlerFunc {
+	type request struct {
+		Name     string     `json:"name"`
+		SceneID  *uuid.UUID `json:"scene_id"`
+		TieBreak string     `json:"tie_break"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{TieBreak: model.TieBreakBonus}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		c := &model.Combat{
+			CampaignID: r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID,
+			Name:       req.Name,
+			TieBreak:   req.TieBreak,
+		}
+		if err := s.setCombatScene(c, req.SceneID); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := s.store.Combat().Create(c); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		v := &combatView{Combat: c, Combatants: []*model.Combatant{}}
+		s.publish(realtime.EventCombatCreated, c.CampaignID, r, v, nil)
+		s.respond(w, r, http.StatusCreated, v)
+	}
+}
+
+// handleCombatsIndex lists the campaign's combats, without the current turn
+// when it is one the member can't see.
+func (s *server) handleCombatsIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		combats, err := s.store.Combat().FindAll(r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		member := r.Context().Value(ctxKeyMember).(*model.Member)
+		for i, c := range combats {
+			combatants, err := s.store.Combatant().FindAll(c.ID)
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			combats[i] = newCombatView(c, combatants, member).Combat
+		}
+
+		s.respond(w, r, http.StatusOK, combats)
+	}
+}
+
+func (s *server) handleCombatsGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := s.findCombat(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		combatants, err := s.store.Combatant().FindAll(c.ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, newCombatView(c, combatants, r.Context().Value(ctxKeyMember).(*model.Member)))
+	}
+}
+
+func (s *server) handleCombatsUpdate() http.HandlerFunc {
+	type request struct {
+		Name     *string  `json:"name"`
+		SceneID  nullUUID `json:"scene_id"`
+		TieBreak *string  `json:"tie_break"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := s.findCombat(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.Name != nil {
+			c.Name = *req.Name
+		}
+		if req.TieBreak != nil {
+			c.TieBreak = *req.TieBreak
+		}
+		if req.SceneID.Set {
+			if err := s.setCombatScene(c, req.SceneID.Value); err != nil {
+				s.error(w, r, http.StatusUnprocessableEntity, err)
+				return
+			}
+		}
+
+		if err := s.store.Combat().Update(c); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.respondCombat(w, r, c)
+	}
+}
+
+func (s *server) handleCombatsDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := s.findCombat(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		if err := s.store.Combat().Delete(c.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.publish(realtime.EventCombatDeleted, c.CampaignID, r, map[string]uuid.UUID{"id": c.ID}, nil)
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// handleCombatsInitiative rolls initiative for every combatant yet to roll.
+func (s *server) handleCombatsInitiative() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := s.findCombat(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		combatants, err := s.store.Combatant().FindAll(c.ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		// Everyone rolls before anything is saved, so that initiative is
+		// either rolled for all of them or for none.
+		rolled := []*model.Combatant{}
+		for _, cb := range combatants {
+			if cb.Initiative != nil {
+				continue
+			}
+
+			if _, code, err := s.rollInitiative(r, cb, ""); err != nil {
+				s.error(w, r, code, err)
+				return
+			}
+			rolled = append(rolled, cb)
+		}
+
+		if err := s.store.Transaction(func(tx store.Store) error {
+			for _, cb := range rolled {
+				if err := tx.Combatant().Update(cb); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.respondCombat(w, r, c)
+	}
+}
+
+// handleCombatsStart begins the first round, or starts the combat over.
+func (s *server) handleCombatsStart() http.HandlerFunc {
+	return s.handleCombatsTurn(false, func(c *model.Combat, combatants []*model.Combatant) (*model.Combatant, error) {
+		return c.Start(combatants)
+	})
+}
+
+func (s *server) handleCombatsNext() http.HandlerFunc {
+	return s.handleCombatsTurn(true, func(c *model.Combat, combatants []*model.Combatant) (*model.Combatant, error) {
+		return c.Next(combatants)
+	})
+}
+
+func (s *server) handleCombatsEnd() http.HandlerFunc {
+	return s.handleCombatsTurn(false, func(c *model.Combat, combatants []*model.Combatant) (*model.Combatant, error) {
+		c.End()
+		return nil, nil
+	})
+}
+
+// handleCombatsTurn changes whose turn it is. GMs run the combat; with
+// byOwner, players may too when it is the turn of a combatant they own. The
+// combatant whose turn begins has its conditions counted down and its
+// token's movement reset.
+func (s *server) handleCombatsTurn(byOwner bool, turn func(*model.Combat, []*model.Combatant) (*model.Combatant, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := s.findCombat(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		combatants, err := s.store.Combatant().FindAll(c.ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		member := r.Context().Value(ctxKeyMember).(*model.Member)
+		if !member.IsGM() && !(byOwner && s.ownsTurn(member, c, combatants)) {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		cb, err := turn(c, combatants)
+		if err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := s.store.Transaction(func(tx store.Store) error {
+			if err := tx.Combat().Update(c); err != nil {
+				return err
+			}
+			if cb == nil {
+				return nil
+			}
+
+			return tx.Combatant().Update(cb)
+		}); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if cb != nil {
+			if err := s.resetMovement(r, cb); err != nil {
+				s.logger.Error(err.Error())
+			}
+		}
+
+		if err := s.publishCombat(r, c, combatants); err != nil {
+			s.logger.Error(err.Error())
+		}
+		s.respond(w, r, http.StatusOK, newCombatView(c, combatants, member))
+	}
+}
+
+func (s *server) handleCombatantsCreate() http.HandlerFunc {
+	type request struct {
+		Name            string            `json:"name"`
+		TokenID         *uuid.UUID        `json:"token_id"`
+		CharacterID     *uuid.UUID        `json:"character_id"`
+		CompendiumID    *uuid.UUID        `json:"compendium_id"`
+		OwnerID         *uuid.UUID        `json:"owner_id"`
+		InitiativeBonus *int              `json:"initiative_bonus"`
+		HP              *int              `json:"hp"`
+		MaxHP           *int              `json:"max_hp"`
+		TempHP          int               `json:"temp_hp"`
+		Hidden          *bool             `json:"hidden"`
+		Conditions      []model.Condition `json:"conditions"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := s.findCombat(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{Conditions: []model.Condition{}}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		cb := &model.Combatant{
+			CombatID:    c.ID,
+			CharacterID: req.CharacterID,
+			OwnerID:     req.OwnerID,
+			TempHP:      req.TempHP,
+			Conditions:  req.Conditions,
+		}
+		if req.Hidden != nil {
+			cb.Hidden = *req.Hidden
+		}
+
+		// Monsters from the compendium of the campaign come with their name
+		// and stats.
+		if req.CompendiumID != nil {
+			e, err := s.store.Compendium().Find(*req.CompendiumID)
+			if err != nil || !s.inCampaignCompendium(r.Context().Value(ctxKeyCampaign).(*model.Campaign), e) {
+				s.error(w, r, http.StatusUnprocessableEntity, ErrUnknownCompendiumEntry)
+				return
+			}
+
+			stats, err := e.Monster()
+			if err != nil {
+				s.error(w, r, http.StatusUnprocessableEntity, err)
+				return
+			}
+
+			cb.Name = e.Name
+			cb.MaxHP = stats.HP
+			cb.InitiativeBonus = stats.InitiativeBonus
+		}
+		if req.InitiativeBonus != nil {
+			cb.InitiativeBonus = *req.InitiativeBonus
+		}
+		if req.MaxHP != nil {
+			cb.MaxHP = *req.MaxHP
+		}
+		cb.HP = cb.MaxHP
+		if req.HP != nil {
+			cb.HP = *req.HP
+		}
+
+		// Combatants take their name and owner from what they stand for, and
+		// are hidden along with a token on the GM layer.
+		if req.TokenID != nil {
+			t, err := s.store.Token().Find(*req.TokenID)
+			if err != nil {
+				s.error(w, r, http.StatusUnprocessableEntity, ErrUnknownToken)
+				return
+			}
+			if sc, err := s.store.Scene().Find(t.SceneID); err != nil || sc.CampaignID != c.CampaignID {
+				s.error(w, r, http.StatusUnprocessableEntity, ErrUnknownToken)
+				return
+			}
+
+			cb.TokenID = &t.ID
+			cb.Name = t.Name
+			if cb.CharacterID == nil {
+				cb.CharacterID = t.CharacterID
+			}
+			if cb.OwnerID == nil {
+				cb.OwnerID = t.OwnerID
+			}
+			if req.Hidden == nil {
+				cb.Hidden = t.Layer == model.LayerGM
+			}
+		}
+		if cb.CharacterID != nil {
+			ch, err := s.store.Character().Find(*cb.CharacterID)
+			if err != nil || ch.CampaignID != c.CampaignID {
+				s.error(w, r, http.StatusUnprocessableEntity, ErrUnknownCharacter)
+				return
+			}
+
+			if cb.Name == "" {
+				cb.Name = ch.Name
+			}
+			if cb.OwnerID == nil {
+				cb.OwnerID = &ch.OwnerID
+			}
+		}
+		if req.Name != "" {
+			cb.Name = req.Name
+		}
+		if cb.Name == "" {
+			s.error(w, r, http.StatusUnprocessableEntity, ErrCombatantUnnamed)
+			return
+		}
+
+		if req.OwnerID != nil {
+			if _, err := s.store.Campaign().FindMember(c.CampaignID, *req.OwnerID); err != nil {
+				s.error(w, r, http.StatusUnprocessableEntity, ErrOwnerNotAMember)
+				return
+			}
+		}
+
+		if err := s.store.Combatant().Create(cb); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := s.publishCombatState(r, c); err != nil {
+			s.logger.Error(err.Error())
+		}
+		s.respond(w, r, http.StatusCreated, cb)
+	}
+}
+
+// handleCombatantsUpdate lets GMs change anything about a combatant and
+// owners track its hit points and conditions.
+func (s *server) handleCombatantsUpdate() http.HandlerFunc {
+	type request struct {
+		Name            *string            `json:"name"`
+		OwnerID         nullUUID           `json:"owner_id"`
+		Initiative      *int               `json:"initiative"`
+		InitiativeBonus *int               `json:"initiative_bonus"`
+		HP              *int               `json:"hp"`
+		MaxHP           *int               `json:"max_hp"`
+		TempHP          *int               `json:"temp_hp"`
+		Hidden          *bool              `json:"hidden"`
+		Defeated        *bool              `json:"defeated"`
+		Conditions      *[]model.Condition `json:"conditions"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, cb, err := s.findCombatant(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		member := r.Context().Value(ctxKeyMember).(*model.Member)
+		if !cb.CanEdit(member) {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if !member.IsGM() && (req.Name != nil || req.OwnerID.Set || req.Initiative != nil || req.InitiativeBonus != nil ||
+			req.MaxHP != nil || req.Hidden != nil || req.Defeated != nil) {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		if req.OwnerID.Set {
+			if req.OwnerID.Value != nil {
+				if _, err := s.store.Campaign().FindMember(c.CampaignID, *req.OwnerID.Value); err != nil {
+					s.error(w, r, http.StatusUnprocessableEntity, ErrOwnerNotAMember)
+					return
+				}
+			}
+			cb.OwnerID = req.OwnerID.Value
+		}
+		if req.Name != nil {
+			cb.Name = *req.Name
+		}
+		if req.Initiative != nil {
+			cb.Initiative = req.Initiative
+		}
+		if req.InitiativeBonus != nil {
+			cb.InitiativeBonus = *req.InitiativeBonus
+		}
+		if req.MaxHP != nil {
+			cb.MaxHP = *req.MaxHP
+		}
+		if req.HP != nil {
+			cb.HP = *req.HP
+		}
+		if req.TempHP != nil {
+			cb.TempHP = *req.TempHP
+		}
+		if req.Hidden != nil {
+			cb.Hidden = *req.Hidden
+		}
+		if req.Defeated != nil {
+			cb.Defeated = *req.Defeated
+		}
+		if req.Conditions != nil {
+			cb.Conditions = *req.Conditions
+		}
+
+		if err := s.store.Combatant().Update(cb); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := s.publishCombatState(r, c); err != nil {
+			s.logger.Error(err.Error())
+		}
+		s.respond(w, r, http.StatusOK, cb)
+	}
+}
+
+// handleCombatantsDelete takes the combatant out of the combat, passing the
+// turn on if it was theirs.
+func (s *server) handleCombatantsDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, cb, err := s.findCombatant(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		if c.CurrentID != nil && *c.CurrentID == cb.ID {
+			combatants, err := s.store.Combatant().FindAll(c.ID)
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			cb.Defeated = true
+			for i := range combatants {
+				if combatants[i].ID == cb.ID {
+					combatants[i] = cb
+				}
+			}
+
+			next, err := c.Next(combatants)
+			if err == model.ErrNoCombatants {
+				c.End()
+			} else if err != nil {
+				s.error(w, r, http.StatusUnprocessableEntity, err)
+				return
+			} else if err := s.store.Combatant().Update(next); err != nil {
+				s.error(w, r, http.StatusUnprocessableEntity, err)
+				return
+			}
+
+			if err := s.store.Combat().Update(c); err != nil {
+				s.error(w, r, http.StatusUnprocessableEntity, err)
+				return
+			}
+		}
+
+		if err := s.store.Combatant().Delete(cb.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.publishCombatState(r, c); err != nil {
+			s.logger.Error(err.Error())
+		}
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// handleCombatantsInitiative rolls the combatant's initiative, 1d20 plus its
+// bonus unless another expression is given. Expressions may refer to the
+// linked character's sheet.
+func (s *server) handleCombatantsInitiative() http.HandlerFunc {
+	type request struct {
+		Expression string `json:"expression"`
+	}
+
+	type response struct {
+		*model.Combatant
+		Roll *dice.Result `json:"roll"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, cb, err := s.findCombatant(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !cb.CanEdit(r.Context().Value(ctxKeyMember).(*model.Member)) {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		res, code, err := s.rollInitiative(r, cb, req.Expression)
+		if err != nil {
+			s.error(w, r, code, err)
+			return
+		}
+
+		if err := s.store.Combatant().Update(cb); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := s.publishCombatState(r, c); err != nil {
+			s.logger.Error(err.Error())
+		}
+		s.respond(w, r, http.StatusOK, &response{cb, res})
+	}
+}
+
+func (s *server) handleCombatantsDamage() http.HandlerFunc {
+	return s.handleCombatantsHP((*model.Combatant).Damage)
+}
+
+func (s *server) handleCombatantsHeal() http.HandlerFunc {
+	return s.handleCombatantsHP((*model.Combatant).Heal)
+}
+
+// handleCombatantsHP applies an amount of damage or healing to the
+// combatant.
+func (s *server) handleCombatantsHP(apply func(*model.Combatant, int)) http.HandlerFunc {
+	type request struct {
+		Amount int `json:"amount"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, cb, err := s.findCombatant(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !cb.CanEdit(r.Context().Value(ctxKeyMember).(*model.Member)) {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.Amount <= 0 {
+			s.error(w, r, http.StatusUnprocessableEntity, ErrInvalidAmount)
+			return
+		}
+
+		apply(cb, req.Amount)
+		if err := s.store.Combatant().Update(cb); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := s.publishCombatState(r, c); err != nil {
+			s.logger.Error(err.Error())
+		}
+		s.respond(w, r, http.StatusOK, cb)
+	}
+}
+
+// findCombat loads the {combatID} combat of the campaign.
+func (s *server) findCombat(r *http.Request) (*model.Combat, error) {
+	id, err := uuid.Parse(mux.Vars(r)["combatID"])
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	c, err := s.store.Combat().Find(id)
+	if err != nil || c.CampaignID != r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID {
+		return nil, ErrNotFound
+	}
+
+	return c, nil
+}
+
+// findCombatant loads the {combatantID} combatant of the {combatID} combat.
+// Combatants hidden from the member are reported as missing.
+func (s *server) findCombatant(r *http.Request) (*model.Combat, *model.Combatant, error) {
+	c, err := s.findCombat(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id, err := uuid.Parse(mux.Vars(r)["combatantID"])
+	if err != nil {
+		return nil, nil, ErrNotFound
+	}
+
+	cb, err := s.store.Combatant().Find(id)
+	if err != nil || cb.CombatID != c.ID || !cb.VisibleTo(r.Context().Value(ctxKeyMember).(*model.Member)) {
+		return nil, nil, ErrNotFound
+	}
+
+	return c, cb, nil
+}
+
+func (s *server) setCombatScene(c *model.Combat, sceneID *uuid.UUID) error {
+	if sceneID != nil {
+		sc, err := s.store.Scene().Find(*sceneID)
+		if err != nil || sc.CampaignID != c.CampaignID {
+			return ErrUnknownScene
+		}
+	}
+	c.SceneID = sceneID
+
+	return nil
+}
+
+// rollInitiative rolls the combatant's initiative with the server's roller,
+// along with a roll-off for ties. Without an expression the combatant rolls
+// what the campaign's ruleset says.
+func (s *server) rollInitiative(r *http.Request, cb *model.Combatant, expression string) (*dice.Result, int, error) {
+	if expression == "" {
+		rs, err := s.campaignRuleset(r)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		expression = rs.Initiative(cb.InitiativeBonus)
+	}
+
+	lookup, code, err := s.characterLookup(r, cb.CharacterID)
+	if err != nil {
+		return nil, code, err
+	}
+
+	e, err := dice.ParseWith(expression, lookup)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity, err
+	}
+
+	res := s.roller.Evaluate(e)
+	rollOff, err := s.roller.Roll(rollOffExpression)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	cb.Initiative = &res.Total
+	cb.RollOff = rollOff.Total
+
+	return res, 0, nil
+}
+
+// ownsTurn reports whether it is the turn of a combatant the member plays.
+func (s *server) ownsTurn(member *model.Member, c *model.Combat, combatants []*model.Combatant) bool {
+	if c.CurrentID == nil {
+		return false
+	}
+
+	for _, cb := range combatants {
+		if cb.ID == *c.CurrentID {
+			return cb.CanEdit(member)
+		}
+	}
+
+	return false
+}
+
+// resetMovement gives the combatant's token its full movement for the turn.
+func (s *server) resetMovement(r *http.Request, cb *model.Combatant) error {
+	if cb.TokenID == nil {
+		return nil
+	}
+
+	t, err := s.store.Token().Find(*cb.TokenID)
+	if err == store.ErrRecordNotFound || err == nil && t.Moved == 0 {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	sc, err := s.store.Scene().Find(t.SceneID)
+	if err != nil {
+		return err
+	}
+
+	t.Moved = 0
+	if err := s.store.Token().Update(t); err != nil {
+		return err
+	}
+
+	return s.publishToken(r, sc, realtime.EventTokenUpdated, t, t)
+}
+
+// respondCombat responds with the combat as the member sees it and
+// broadcasts it.
+func (s *server) respondCombat(w http.ResponseWriter, r *http.Request, c *model.Combat) {
+	combatants, err := s.store.Combatant().FindAll(c.ID)
+	if err != nil {
+		s.error(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := s.publishCombat(r, c, combatants); err != nil {
+		s.logger.Error(err.Error())
+	}
+	s.respond(w, r, http.StatusOK, newCombatView(c, combatants, r.Context().Value(ctxKeyMember).(*model.Member)))
+}
+
+// publishCombatState broadcasts the combat as it is stored.
+func (s *server) publishCombatState(r *http.Request, c *model.Combat) error {
+	c, err := s.store.Combat().Find(c.ID)
+	if err != nil {
+		return err
+	}
+
+	combatants, err := s.store.Combatant().FindAll(c.ID)
+	if err != nil {
+		return err
+	}
+
+	return s.publishCombat(r, c, combatants)
+}
+
+// publishCombat sends the whole combat to every member. With hidden
+// combatants in it, GMs get it whole and players without them.
+func (s *server) publishCombat(r *http.Request, c *model.Combat, combatants []*model.Combatant) error {
+	gm := &model.Member{Role: model.RoleGM}
+	player := &model.Member{Role: model.RolePlayer}
+
+	hidden := false
+	for _, cb := range combatants {
+		hidden = hidden || cb.Hidden
+	}
+	if !hidden {
+		s.publish(realtime.EventCombatUpdated, c.CampaignID, r, newCombatView(c, combatants, gm), nil)
+		return nil
+	}
+
+	s.publish(realtime.EventCombatUpdated, c.CampaignID, r, newCombatView(c, combatants, gm), &realtime.Audience{GMOnly: true})
+
+	players, err := s.playersAudience(c.CampaignID)
+	if err != nil {
+		return err
+	}
+	s.publish(realtime.EventCombatUpdated, c.CampaignID, r, newCombatView(c, combatants, player), players)
+
+	return nil
+}
@@ -0,0 +1,291 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandleCombats(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	st.Scene().Create(sc)
+	cb := model.TestCombat(t, c)
+	st.Combat().Create(cb)
+
+	path := fmt.Sprintf("/private/campaigns/%s/combats", c.ID)
+	testCases := []struct {
+		name         string
+		user         *model.User
+		method       string
+		path         string
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"gm creates", gm, http.MethodPost, path, map[string]interface{}{"name": "Bridge", "scene_id": sc.ID}, http.StatusCreated},
+		{"unknown tie break", gm, http.MethodPost, path, map[string]interface{}{"name": "Bridge", "tie_break": "coin"}, http.StatusUnprocessableEntity},
+		{"player creates", player, http.MethodPost, path, map[string]interface{}{"name": "Bridge"}, http.StatusForbidden},
+		{"player lists", player, http.MethodGet, path, nil, http.StatusOK},
+		{"player gets", player, http.MethodGet, path + "/" + cb.ID.String(), nil, http.StatusOK},
+		{"player renames", player, http.MethodPatch, path + "/" + cb.ID.String(), map[string]interface{}{"name": "Gates"}, http.StatusForbidden},
+		{"gm renames", gm, http.MethodPatch, path + "/" + cb.ID.String(), map[string]interface{}{"name": "Gates"}, http.StatusOK},
+		{"player starts", player, http.MethodPost, path + "/" + cb.ID.String() + "/start", nil, http.StatusForbidden},
+		{"gm starts without combatants", gm, http.MethodPost, path + "/" + cb.ID.String() + "/start", nil, http.StatusUnprocessableEntity},
+		{"gm moves on before starting", gm, http.MethodPost, path + "/" + cb.ID.String() + "/next", nil, http.StatusUnprocessableEntity},
+		{"player deletes", player, http.MethodDelete, path + "/" + cb.ID.String(), nil, http.StatusForbidden},
+		{"gm deletes", gm, http.MethodDelete, path + "/" + cb.ID.String(), nil, http.StatusNoContent},
+		{"deleted", gm, http.MethodGet, path + "/" + cb.ID.String(), nil, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, tc.method, tc.path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_HandleCombatants(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	other := testUser(t, st, "other")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer, other: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	st.Scene().Create(sc)
+	tok := model.TestToken(t, sc, player)
+	st.Token().Create(tok)
+	cb := model.TestCombat(t, c)
+	st.Combat().Create(cb)
+
+	path := fmt.Sprintf("/private/campaigns/%s/combats/%s/combatants", c.ID, cb.ID)
+	rec := testRequest(t, s, gm, http.MethodPost, path, map[string]interface{}{"token_id": tok.ID, "max_hp": 30})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	hero := &model.Combatant{}
+	json.NewDecoder(rec.Body).Decode(hero)
+	assert.Equal(t, tok.Name, hero.Name)
+	assert.True(t, hero.IsOwnedBy(player.ID))
+	assert.Equal(t, 30, hero.HP)
+
+	lurker := model.TestCombatant(t, cb)
+	lurker.Hidden = true
+	st.Combatant().Create(lurker)
+
+	heroPath := path + "/" + hero.ID.String()
+	testCases := []struct {
+		name         string
+		user         *model.User
+		method       string
+		path         string
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"player adds", player, http.MethodPost, path, map[string]interface{}{"name": "Wolf"}, http.StatusForbidden},
+		{"unnamed", gm, http.MethodPost, path, map[string]interface{}{"max_hp": 10}, http.StatusUnprocessableEntity},
+		{"owner not a member", gm, http.MethodPost, path, map[string]interface{}{"name": "Wolf", "owner_id": c.ID}, http.StatusUnprocessableEntity},
+		{"owner rolls", player, http.MethodPost, heroPath + "/initiative", map[string]string{"expression": "15"}, http.StatusOK},
+		{"other rolls", other, http.MethodPost, heroPath + "/initiative", nil, http.StatusForbidden},
+		{"invalid expression", player, http.MethodPost, heroPath + "/initiative", map[string]string{"expression": "d"}, http.StatusUnprocessableEntity},
+		{"owner takes damage", player, http.MethodPost, heroPath + "/damage", map[string]int{"amount": 12}, http.StatusOK},
+		{"negative damage", player, http.MethodPost, heroPath + "/damage", map[string]int{"amount": -5}, http.StatusUnprocessableEntity},
+		{"other heals", other, http.MethodPost, heroPath + "/heal", map[string]int{"amount": 5}, http.StatusForbidden},
+		{"owner heals", player, http.MethodPost, heroPath + "/heal", map[string]int{"amount": 5}, http.StatusOK},
+		{"owner adds a condition", player, http.MethodPatch, heroPath, map[string]interface{}{"conditions": []model.Condition{{Name: "blessed", Rounds: 10}}}, http.StatusOK},
+		{"owner raises max hp", player, http.MethodPatch, heroPath, map[string]interface{}{"max_hp": 50}, http.StatusForbidden},
+		{"player finds a hidden combatant", player, http.MethodPost, path + "/" + lurker.ID.String() + "/damage", map[string]int{"amount": 5}, http.StatusNotFound},
+		{"gm damages a hidden combatant", gm, http.MethodPost, path + "/" + lurker.ID.String() + "/damage", map[string]int{"amount": 5}, http.StatusOK},
+		{"gm hides", gm, http.MethodPatch, path + "/" + lurker.ID.String(), map[string]interface{}{"hidden": false, "defeated": true}, http.StatusOK},
+		{"player removes", player, http.MethodDelete, heroPath, nil, http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, tc.method, tc.path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+
+	hero, _ = st.Combatant().Find(hero.ID)
+	assert.Equal(t, 15, *hero.Initiative)
+	assert.Equal(t, 23, hero.HP)
+	assert.Equal(t, []model.Condition{{Name: "blessed", Rounds: 10}}, hero.Conditions)
+
+	lurker, _ = st.Combatant().Find(lurker.ID)
+	assert.Equal(t, 17, lurker.HP)
+	assert.True(t, lurker.Defeated)
+
+	rec = testRequest(t, s, gm, http.MethodDelete, heroPath, nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestServer_HandleCombatsTurns(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	st.Scene().Create(sc)
+	tok := model.TestToken(t, sc, player)
+	tok.Moved = 25
+	st.Token().Create(tok)
+	cb := model.TestCombat(t, c)
+	st.Combat().Create(cb)
+
+	initiative := func(n int) *int {
+		return &n
+	}
+	hero := &model.Combatant{CombatID: cb.ID, Name: "Hero", TokenID: &tok.ID, OwnerID: &player.ID, Initiative: initiative(10)}
+	st.Combatant().Create(hero)
+	ogre := &model.Combatant{CombatID: cb.ID, Name: "Ogre", Initiative: initiative(18), Conditions: []model.Condition{{Name: "stunned", Rounds: 1}}}
+	st.Combatant().Create(ogre)
+	ghost := &model.Combatant{CombatID: cb.ID, Name: "Ghost", Hidden: true}
+	st.Combatant().Create(ghost)
+
+	path := fmt.Sprintf("/private/campaigns/%s/combats/%s", c.ID, cb.ID)
+	view := func(u *model.User) *combatView {
+		rec := testRequest(t, s, u, http.MethodGet, path, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		v := &combatView{}
+		json.NewDecoder(rec.Body).Decode(v)
+
+		return v
+	}
+
+	v := view(player)
+	assert.Len(t, v.Combatants, 2)
+	assert.Equal(t, "Ogre", v.Combatants[0].Name)
+
+	rec := testRequest(t, s, gm, http.MethodPost, path+"/initiative", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	ghost, _ = st.Combatant().Find(ghost.ID)
+	assert.NotNil(t, ghost.Initiative)
+	ghost.Initiative = initiative(20)
+	st.Combatant().Update(ghost)
+
+	// The ghost goes first, unseen by the player, then the ogre; the player
+	// may end neither turn.
+	rec = testRequest(t, s, gm, http.MethodPost, path+"/start", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, view(player).CurrentID)
+	index := func(u *model.User) *model.Combat {
+		rec := testRequest(t, s, u, http.MethodGet, fmt.Sprintf("/private/campaigns/%s/combats", c.ID), nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		combats := []*model.Combat{}
+		json.NewDecoder(rec.Body).Decode(&combats)
+		if !assert.Len(t, combats, 1) {
+			return &model.Combat{}
+		}
+
+		return combats[0]
+	}
+	assert.Nil(t, index(player).CurrentID)
+	assert.Equal(t, ghost.ID, *index(gm).CurrentID)
+
+	rec = testRequest(t, s, player, http.MethodPost, path+"/next", nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = testRequest(t, s, gm, http.MethodPost, path+"/next", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ogre.ID, *view(player).CurrentID)
+
+	rec = testRequest(t, s, player, http.MethodPost, path+"/next", nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = testRequest(t, s, gm, http.MethodPost, path+"/next", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, hero.ID, *view(player).CurrentID)
+	tok, _ = st.Token().Find(tok.ID)
+	assert.Equal(t, 0.0, tok.Moved)
+	ogre, _ = st.Combatant().Find(ogre.ID)
+	assert.Empty(t, ogre.Conditions)
+
+	rec = testRequest(t, s, player, http.MethodPost, path+"/next", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	v = view(gm)
+	assert.Equal(t, 2, v.Round)
+
+	rec = testRequest(t, s, player, http.MethodPost, path+"/end", nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = testRequest(t, s, gm, http.MethodPost, path+"/end", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 0, view(gm).Round)
+}
+
+func TestServer_HandleCombatsInitiative_Failed(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	c := testCampaign(t, st, gm, nil)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	cb := model.TestCombat(t, c)
+	st.Combat().Create(cb)
+	ogre := &model.Combatant{CombatID: cb.ID, Name: "Ogre"}
+	st.Combatant().Create(ogre)
+	characterID := uuid.New()
+	st.Combatant().Create(&model.Combatant{CombatID: cb.ID, Name: "Ghost", CharacterID: &characterID})
+
+	rec := testRequest(t, s, gm, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/combats/%s/initiative", c.ID, cb.ID), nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	ogre, _ = st.Combatant().Find(ogre.ID)
+	assert.Nil(t, ogre.Initiative)
+}
+
+func TestServer_CombatEvents(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	cb := model.TestCombat(t, c)
+	st.Combat().Create(cb)
+	st.Combatant().Create(&model.Combatant{CombatID: cb.ID, Name: "Hero", OwnerID: &player.ID})
+
+	members := map[*model.User]*realtime.Client{}
+	for _, u := range []*model.User{gm, player} {
+		m, _ := st.Campaign().FindMember(c.ID, u.ID)
+		members[u], _, _ = s.hub.Subscribe(m, "")
+		defer s.hub.Unsubscribe(members[u])
+	}
+
+	received := func(c *realtime.Client) []*combatView {
+		views := []*combatView{}
+		for len(c.Events()) > 0 {
+			if e := <-c.Events(); e.Type == realtime.EventCombatUpdated {
+				v := &combatView{}
+				json.Unmarshal(e.Payload, v)
+				views = append(views, v)
+			}
+		}
+
+		return views
+	}
+
+	rec := testRequest(t, s, gm, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/combats/%s/combatants", c.ID, cb.ID), map[string]interface{}{"name": "Assassin", "hidden": true})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	views := received(members[gm])
+	if assert.Len(t, views, 1) {
+		assert.Len(t, views[0].Combatants, 2)
+	}
+	views = received(members[player])
+	if assert.Len(t, views, 1) {
+		assert.Len(t, views[0].Combatants, 1)
+	}
+}
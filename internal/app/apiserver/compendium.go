@@ -0,0 +1,93 @@
+package apiserver
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const defaultCompendiumLimit = 50
+
+var ErrUnknownCompendiumEntry = errors.New("unknown compendium entry")
+
+func (s *server) handleCompendiumIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f, err := parseCompendiumFilter(r.URL.Query())
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		entries, err := s.store.Compendium().FindAll(f)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, entries)
+	}
+}
+
+func (s *server) handleCompendiumGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(mux.Vars(r)["entryID"])
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, ErrNotFound)
+			return
+		}
+
+		e, err := s.store.Compendium().Find(id)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, ErrNotFound)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, e)
+	}
+}
+
+// parseCompendiumFilter reads system, kind, source, q, limit and offset
+// query parameters. Parameters named data.<field> match fields of the
+// entries' data, like data.level=3 for third level spells.
+func parseCompendiumFilter(q url.Values) (*model.CompendiumFilter, error) {
+	f := &model.CompendiumFilter{
+		System: q.Get("system"),
+		Kind:   q.Get("kind"),
+		Source: q.Get("source"),
+		Query:  q.Get("q"),
+		Data:   map[string]string{},
+		Limit:  defaultCompendiumLimit,
+	}
+
+	ints := map[string]*int{"limit": &f.Limit, "offset": &f.Offset}
+	for key, dst := range ints {
+		if v := q.Get(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, ErrInvalidFilter
+			}
+			*dst = n
+		}
+	}
+	if f.Limit < 1 {
+		return nil, ErrInvalidFilter
+	}
+
+	for key := range q {
+		if field := strings.TrimPrefix(key, "data."); field != key && field != "" {
+			f.Data[field] = q.Get(key)
+		}
+	}
+
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
@@ -0,0 +1,118 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandleCompendium(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "player")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	goblin := model.TestCompendiumEntry(t)
+	st.Compendium().Save(goblin)
+	fireball := model.TestCompendiumEntry(t)
+	fireball.Kind = model.KindSpell
+	fireball.Name = "Fireball"
+	fireball.Body = "A bright streak flashes from your pointing finger."
+	fireball.Data = []byte(`{"level": 3, "school": "evocation"}`)
+	st.Compendium().Save(fireball)
+
+	testCases := []struct {
+		name         string
+		query        string
+		exceptedCode int
+		exceptedLen  int
+	}{
+		{"all", "", http.StatusOK, 2},
+		{"kind", "?system=dnd5e&kind=spell", http.StatusOK, 1},
+		{"search", "?q=streak", http.StatusOK, 1},
+		{"data", "?data.level=3&data.school=evocation", http.StatusOK, 1},
+		{"other data", "?data.level=4", http.StatusOK, 0},
+		{"paged", "?limit=1&offset=1", http.StatusOK, 1},
+		{"unknown kind", "?kind=deity", http.StatusBadRequest, 0},
+		{"invalid limit", "?limit=many", http.StatusBadRequest, 0},
+		{"limit too large", "?limit=1000", http.StatusBadRequest, 0},
+		{"no limit", "?limit=0", http.StatusBadRequest, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, u, http.MethodGet, "/private/compendium"+tc.query, nil)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+			if rec.Code == http.StatusOK {
+				entries := []*model.CompendiumEntry{}
+				json.NewDecoder(rec.Body).Decode(&entries)
+				assert.Len(t, entries, tc.exceptedLen)
+			}
+		})
+	}
+
+	rec := testRequest(t, s, u, http.MethodGet, "/private/compendium/"+goblin.ID.String(), nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = testRequest(t, s, u, http.MethodGet, "/private/compendium/"+uuid.New().String(), nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = testRequest(t, s, nil, http.MethodGet, "/private/compendium", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestServer_HandleCombatantsFromCompendium(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	c := testCampaign(t, st, gm, nil)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	cb := model.TestCombat(t, c)
+	st.Combat().Create(cb)
+	goblin := model.TestCompendiumEntry(t)
+	st.Compendium().Save(goblin)
+	fireball := model.TestCompendiumEntry(t)
+	fireball.Kind = model.KindSpell
+	fireball.Name = "Fireball"
+	fireball.Data = []byte(`{"level": 3}`)
+	st.Compendium().Save(fireball)
+	pf := model.TestCompendiumEntry(t)
+	pf.System = "pf2e"
+	st.Compendium().Save(pf)
+	path := fmt.Sprintf("/private/campaigns/%s/combats/%s/combatants", c.ID, cb.ID)
+
+	rec := testRequest(t, s, gm, http.MethodPost, path, map[string]interface{}{"compendium_id": goblin.ID, "hidden": true})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	added := &model.Combatant{}
+	json.NewDecoder(rec.Body).Decode(added)
+	assert.Equal(t, "Goblin", added.Name)
+	assert.Equal(t, 7, added.MaxHP)
+	assert.Equal(t, 7, added.HP)
+	assert.Equal(t, 2, added.InitiativeBonus)
+
+	rec = testRequest(t, s, gm, http.MethodPost, path, map[string]interface{}{"compendium_id": goblin.ID, "name": "Goblin boss", "max_hp": 21})
+	json.NewDecoder(rec.Body).Decode(added)
+	assert.Equal(t, "Goblin boss", added.Name)
+	assert.Equal(t, 21, added.HP)
+
+	testCases := []struct {
+		name         string
+		id           uuid.UUID
+		exceptedCode int
+	}{
+		{"not a monster", fireball.ID, http.StatusUnprocessableEntity},
+		{"other system", pf.ID, http.StatusUnprocessableEntity},
+		{"unknown", uuid.New(), http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, gm, http.MethodPost, path, map[string]interface{}{"compendium_id": tc.id})
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+}
@@ -5,11 +5,28 @@ type Config struct {
 	LogLevel 	string `toml:"log_level"`
 	DatabaseURL string `toml:"database_url"`
 	JWTKey		string `toml:"jwt_key"`
+	AssetsDir	string `toml:"assets_dir"`
+	// MaxUploadSize, UserQuota and MaxImportSize are in bytes, AssetURLTTL
+	// and UploadTTL in seconds.
+	MaxUploadSize	int64 `toml:"max_upload_size"`
+	UserQuota	int64 `toml:"user_quota"`
+	AssetURLTTL	int `toml:"asset_url_ttl"`
+	// UploadTTL is how long resumable uploads last without progress.
+	UploadTTL	int `toml:"upload_ttl"`
+	MaxImportSize	int64 `toml:"max_import_size"`
+	// Admins are the usernames allowed to manage other accounts.
+	Admins	[]string `toml:"admins"`
 }
 
 func NewConfig() *Config {
 	return &Config{
 		BindAddr: ":8080",
 		LogLevel: "debug",
+		AssetsDir: "assets",
+		MaxUploadSize: 50 << 20,
+		UserQuota: 1 << 30,
+		AssetURLTTL: 3600,
+		UploadTTL: 86400,
+		MaxImportSize: 2 << 30,
 	}
 }
\ No newline at end of file
@@ -0,0 +1,154 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+var (
+	ErrFolderCycle = errors.New("folder can't be moved into itself")
+)
+
+func (s *server) handleFoldersCreate() http.HandlerFunc {
+	type request struct {
+		Name     string     `json:"name"`
+		ParentID *uuid.UUID `json:"parent_id"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		f := &model.Folder{
+			UserID:   r.Context().Value(ctxKeyUser).(*model.User).ID,
+			ParentID: req.ParentID,
+			Name:     req.Name,
+		}
+		if err := s.checkFolder(f.UserID, f.ParentID); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := s.store.Folder().Create(f); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusCreated, f)
+	}
+}
+
+func (s *server) handleFoldersIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		folders, err := s.store.Folder().FindAll(r.Context().Value(ctxKeyUser).(*model.User).ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, folders)
+	}
+}
+
+func (s *server) handleFoldersUpdate() http.HandlerFunc {
+	type request struct {
+		Name     *string  `json:"name"`
+		ParentID nullUUID `json:"parent_id"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		f, err := s.findFolder(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.ParentID.Set {
+			if err := s.checkFolder(f.UserID, req.ParentID.Value); err != nil {
+				s.error(w, r, http.StatusUnprocessableEntity, err)
+				return
+			}
+			if err := s.checkFolderCycle(f.ID, req.ParentID.Value); err != nil {
+				s.error(w, r, http.StatusUnprocessableEntity, err)
+				return
+			}
+			f.ParentID = req.ParentID.Value
+		}
+		if req.Name != nil {
+			f.Name = *req.Name
+		}
+
+		if err := s.store.Folder().Update(f); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, f)
+	}
+}
+
+// handleFoldersDelete deletes the folder and its subfolders. Their assets
+// stay in the library, outside of any folder.
+func (s *server) handleFoldersDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f, err := s.findFolder(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if err := s.store.Folder().Delete(f.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// findFolder loads the user's {folderID} folder.
+func (s *server) findFolder(r *http.Request) (*model.Folder, error) {
+	id, err := uuid.Parse(mux.Vars(r)["folderID"])
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	f, err := s.store.Folder().Find(id)
+	if err != nil || f.UserID != r.Context().Value(ctxKeyUser).(*model.User).ID {
+		return nil, ErrNotFound
+	}
+
+	return f, nil
+}
+
+// checkFolderCycle refuses to move a folder under itself or one of its
+// subfolders.
+func (s *server) checkFolderCycle(id uuid.UUID, parentID *uuid.UUID) error {
+	for parentID != nil {
+		if *parentID == id {
+			return ErrFolderCycle
+		}
+
+		parent, err := s.store.Folder().Find(*parentID)
+		if err != nil {
+			return err
+		}
+		parentID = parent.ParentID
+	}
+
+	return nil
+}
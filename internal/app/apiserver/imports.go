@@ -0,0 +1,67 @@
+package apiserver
+
+import (
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/vtt"
+	"github.com/gorilla/mux"
+)
+
+// handleCampaignsImportFrom converts the export of another virtual tabletop,
+// sent zipped as the request body, into a new campaign the way an archive
+// is imported, and reports what it made of the export. Roll20 exports don't
+// say what system they play, which the system query parameter does.
+func (s *server) handleCampaignsImportFrom() http.HandlerFunc {
+	type response struct {
+		Campaign *model.Campaign `json:"campaign"`
+		Report   *vtt.Report     `json:"report"`
+	}
+
+	readers := map[string]func(io.ReaderAt, int64, *vtt.Options) (*vtt.Import, error){
+		"roll20":  vtt.ReadRoll20,
+		"foundry": vtt.ReadFoundry,
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(ctxKeyUser).(*model.User)
+
+		f, n, ok := s.spoolImport(w, r)
+		if !ok {
+			return
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		im, err := readers[mux.Vars(r)["source"]](f, n, &vtt.Options{
+			System:        r.URL.Query().Get("system"),
+			BaseURL:       baseURL(r),
+			MaxAssetSize:  s.assets.maxUploadSize,
+			ThumbnailSize: thumbnailSize,
+		})
+		if err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		c, ok := s.restoreCampaign(w, r, im.Campaign, im, u.ID)
+		if !ok {
+			return
+		}
+
+		s.respond(w, r, http.StatusCreated, &response{Campaign: c, Report: im.Report})
+	}
+}
+
+// baseURL returns the scheme and host the request was made to, behind a
+// proxy as well.
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
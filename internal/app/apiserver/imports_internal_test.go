@@ -0,0 +1,93 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/bruhlord-s/virttable-api/internal/app/vtt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandleCampaignsImportFrom(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "importer")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	roll20 := testZip(t, map[string]string{
+		"campaign.json": `{
+			"campaign_title": "Tomb of Annihilation",
+			"pages": [{"name": "Port Nyanzaru", "width": 20, "height": 15, "scale_number": 5, "graphics": [
+				{"name": "Syndra", "left": 315, "top": 245, "width": 70, "height": 70, "layer": "objects", "represents": "c1"}
+			]}],
+			"characters": [{"id": "c1", "name": "Syndra", "attribs": [{"name": "level", "current": "13"}]}],
+			"handouts": [{"id": "h1", "name": "Chult", "notes": "<p>Jungles.</p>", "inplayerjournals": "all"}],
+			"macros": [{"name": "Initiative"}]
+		}`,
+	})
+	foundry := testZip(t, map[string]string{
+		"world/world.json":      `{"name": "lost-mine", "title": "Lost Mine of Phandelver", "system": "dnd5e"}`,
+		"world/data/journal.db": `{"_id": "j1", "name": "Phandalin", "content": "<p>A town.</p>"}`,
+	})
+
+	testCases := []struct {
+		name         string
+		path         string
+		body         []byte
+		exceptedCode int
+	}{
+		{"roll20", "/private/campaigns/import/roll20?system=dnd5e", roll20, http.StatusCreated},
+		{"roll20 unknown system", "/private/campaigns/import/roll20?system=gurps", roll20, http.StatusUnprocessableEntity},
+		{"foundry", "/private/campaigns/import/foundry", foundry, http.StatusCreated},
+		{"foundry from roll20", "/private/campaigns/import/foundry", roll20, http.StatusUnprocessableEntity},
+		{"not a zip", "/private/campaigns/import/roll20", []byte("campaign"), http.StatusUnprocessableEntity},
+		{"unknown source", "/private/campaigns/import/fantasygrounds", roll20, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testImport(t, s, u, tc.path, tc.body)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+
+	rec := testImport(t, s, u, "/private/campaigns/import/roll20?system=dnd5e", roll20)
+	if !assert.Equal(t, http.StatusCreated, rec.Code) {
+		return
+	}
+	res := &struct {
+		Campaign *model.Campaign `json:"campaign"`
+		Report   *vtt.Report     `json:"report"`
+	}{}
+	json.NewDecoder(rec.Body).Decode(res)
+	assert.Equal(t, "Tomb of Annihilation", res.Campaign.Name)
+	assert.Equal(t, u.ID, res.Campaign.OwnerID)
+	assert.Equal(t, "roll20", res.Report.Source)
+	assert.Equal(t, 1, res.Report.Count("macro", vtt.StatusSkipped))
+
+	scenes, _ := st.Scene().FindAll(res.Campaign.ID)
+	characters, _ := st.Character().FindAll(res.Campaign.ID)
+	if assert.Len(t, scenes, 1) && assert.Len(t, characters, 1) {
+		tokens, _ := st.Token().FindAll(scenes[0].ID)
+		if assert.Len(t, tokens, 1) {
+			assert.Equal(t, &characters[0].ID, tokens[0].CharacterID)
+		}
+	}
+	owner := &model.Member{CampaignID: res.Campaign.ID, UserID: u.ID, Role: model.RoleGM}
+	entries, _ := st.Journal().FindAll(res.Campaign.ID, owner, &model.JournalFilter{})
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "Jungles.", entries[0].Body)
+		assert.Equal(t, model.VisibilityPlayers, entries[0].Visibility)
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "http://vt.example.com/private/campaigns/import/roll20", nil)
+	assert.Equal(t, "http://vt.example.com", baseURL(req))
+
+	req.Header.Set("X-Forwarded-Proto", "https")
+	assert.Equal(t, "https://vt.example.com", baseURL(req))
+}
@@ -0,0 +1,538 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+var (
+	ErrUnknownMember = errors.New("unknown member")
+	ErrUnknownAsset  = errors.New("unknown asset")
+)
+
+// journalView is a journal entry with a URL to its image, signed if the
+// image is private so every member who sees the entry can load it.
+type journalView struct {
+	*model.JournalEntry
+	ImageURL string `json:"image_url,omitempty"`
+}
+
+func (s *server) newJournalView(e *model.JournalEntry) *journalView {
+	v := &journalView{JournalEntry: e}
+	if e.ImageID != nil {
+		if a, err := s.store.Asset().Find(*e.ImageID); err == nil {
+			v.ImageURL = s.assetURL(a, "/assets/"+a.ID.String())
+		}
+	}
+
+	return v
+}
+
+func (s *server) handleJournalCreate() http.HandlerFunc {
+	type request struct {
+		Title         string      `json:"title"`
+		Body          string      `json:"body"`
+		FolderID      *uuid.UUID  `json:"folder_id"`
+		ImageID       *uuid.UUID  `json:"image_id"`
+		Visibility    string      `json:"visibility"`
+		Members       []uuid.UUID `json:"members"`
+		Collaborative bool        `json:"collaborative"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !r.Context().Value(ctxKeyMember).(*model.Member).CanPlay() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{Visibility: model.VisibilityGM}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		e := &model.JournalEntry{
+			CampaignID:    r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID,
+			AuthorID:      r.Context().Value(ctxKeyUser).(*model.User).ID,
+			FolderID:      req.FolderID,
+			ImageID:       req.ImageID,
+			Title:         req.Title,
+			Body:          req.Body,
+			Visibility:    req.Visibility,
+			Members:       req.Members,
+			Collaborative: req.Collaborative,
+		}
+		if err := s.checkJournalEntry(e); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		var err error
+		if e.BodyHTML, err = s.render(e.Body, e.CampaignID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.store.Journal().Create(e); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		v := s.newJournalView(e)
+		s.publish(realtime.EventJournalCreated, e.CampaignID, r, v, journalAudience(e))
+		s.respond(w, r, http.StatusCreated, v)
+	}
+}
+
+// handleJournalIndex lists the entries the member can see, searched with
+// ?q= and narrowed to a folder with ?folder_id=.
+func (s *server) handleJournalIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f := &model.JournalFilter{Query: r.URL.Query().Get("q")}
+		if v := r.URL.Query().Get("folder_id"); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				s.error(w, r, http.StatusBadRequest, ErrInvalidFilter)
+				return
+			}
+			f.FolderID = &id
+		}
+
+		campaign := r.Context().Value(ctxKeyCampaign).(*model.Campaign)
+		entries, err := s.store.Journal().FindAll(campaign.ID, r.Context().Value(ctxKeyMember).(*model.Member), f)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		views := []*journalView{}
+		for _, e := range entries {
+			views = append(views, s.newJournalView(e))
+		}
+
+		s.respond(w, r, http.StatusOK, views)
+	}
+}
+
+func (s *server) handleJournalGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		e, err := s.findJournalEntry(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, s.newJournalView(e))
+	}
+}
+
+func (s *server) handleJournalUpdate() http.HandlerFunc {
+	type request struct {
+		Title         *string      `json:"title"`
+		Body          *string      `json:"body"`
+		FolderID      nullUUID     `json:"folder_id"`
+		ImageID       nullUUID     `json:"image_id"`
+		Visibility    *string      `json:"visibility"`
+		Members       *[]uuid.UUID `json:"members"`
+		Collaborative *bool        `json:"collaborative"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		e, err := s.findJournalEntry(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !e.CanModify(r.Context().Value(ctxKeyMember).(*model.Member)) {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		before := journalAudience(e)
+		if req.Title != nil {
+			e.Title = *req.Title
+		}
+		if req.Body != nil {
+			e.Body = *req.Body
+		}
+		if req.FolderID.Set {
+			e.FolderID = req.FolderID.Value
+		}
+		if req.ImageID.Set {
+			e.ImageID = req.ImageID.Value
+		}
+		if req.Visibility != nil {
+			e.Visibility = *req.Visibility
+			if e.Visibility != model.VisibilityMembers {
+				e.Members = []uuid.UUID{}
+			}
+		}
+		if req.Members != nil {
+			e.Members = *req.Members
+		}
+		if req.Collaborative != nil {
+			e.Collaborative = *req.Collaborative
+		}
+		if err := s.checkJournalEntry(e); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := s.store.Journal().Update(e); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		// The body is edited like any other edit of the people who have
+		// it open, so theirs aren't lost.
+		if req.Body != nil {
+			if err := s.collab.Replace(e.CampaignID, e.ID, r.Context().Value(ctxKeyUser).(*model.User).ID, *req.Body); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			if e, err = s.store.Journal().Find(e.ID); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		s.publishJournalUpdate(r, e, before)
+		s.respond(w, r, http.StatusOK, s.newJournalView(e))
+	}
+}
+
+func (s *server) handleJournalDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		e, err := s.findJournalEntry(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !e.CanModify(r.Context().Value(ctxKeyMember).(*model.Member)) {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		if err := s.store.Journal().Delete(e.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.publish(realtime.EventJournalDeleted, e.CampaignID, r, map[string]uuid.UUID{"id": e.ID}, journalAudience(e))
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// handleJournalShow shows a handout to the players, or to the members in
+// user_ids. Whoever it is shown to can read it from then on.
+func (s *server) handleJournalShow() http.HandlerFunc {
+	type request struct {
+		UserIDs []uuid.UUID `json:"user_ids"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		e, err := s.findJournalEntry(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil && err != io.EOF {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		for _, id := range req.UserIDs {
+			if _, err := s.store.Campaign().FindMember(e.CampaignID, id); err != nil {
+				s.error(w, r, http.StatusUnprocessableEntity, ErrUnknownMember)
+				return
+			}
+		}
+
+		before := journalAudience(e)
+		e.Reveal(req.UserIDs)
+		if err := s.store.Journal().Update(e); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.publishJournalUpdate(r, e, before)
+
+		var audience *realtime.Audience
+		if len(req.UserIDs) > 0 {
+			audience = &realtime.Audience{UserIDs: req.UserIDs}
+		}
+		v := s.newJournalView(e)
+		s.publish(realtime.EventHandoutShown, e.CampaignID, r, v, audience)
+		s.respond(w, r, http.StatusOK, v)
+	}
+}
+
+func (s *server) handleJournalFoldersCreate() http.HandlerFunc {
+	type request struct {
+		Name     string     `json:"name"`
+		ParentID *uuid.UUID `json:"parent_id"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		f := &model.JournalFolder{
+			CampaignID: r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID,
+			ParentID:   req.ParentID,
+			Name:       req.Name,
+		}
+		if err := s.checkJournalFolder(f.CampaignID, f.ParentID); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := s.store.JournalFolder().Create(f); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.publish(realtime.EventJournalFolderCreated, f.CampaignID, r, f, nil)
+		s.respond(w, r, http.StatusCreated, f)
+	}
+}
+
+func (s *server) handleJournalFoldersIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		folders, err := s.store.JournalFolder().FindAll(r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, folders)
+	}
+}
+
+func (s *server) handleJournalFoldersUpdate() http.HandlerFunc {
+	type request struct {
+		Name     *string  `json:"name"`
+		ParentID nullUUID `json:"parent_id"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		f, err := s.findJournalFolder(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.ParentID.Set {
+			if err := s.checkJournalFolder(f.CampaignID, req.ParentID.Value); err != nil {
+				s.error(w, r, http.StatusUnprocessableEntity, err)
+				return
+			}
+			for id := req.ParentID.Value; id != nil; {
+				if *id == f.ID {
+					s.error(w, r, http.StatusUnprocessableEntity, ErrFolderCycle)
+					return
+				}
+
+				parent, err := s.store.JournalFolder().Find(*id)
+				if err != nil {
+					s.error(w, r, http.StatusInternalServerError, err)
+					return
+				}
+				id = parent.ParentID
+			}
+			f.ParentID = req.ParentID.Value
+		}
+		if req.Name != nil {
+			f.Name = *req.Name
+		}
+
+		if err := s.store.JournalFolder().Update(f); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.publish(realtime.EventJournalFolderUpdated, f.CampaignID, r, f, nil)
+		s.respond(w, r, http.StatusOK, f)
+	}
+}
+
+// handleJournalFoldersDelete deletes the folder and its subfolders; their
+// entries move to the root of the journal.
+func (s *server) handleJournalFoldersDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		f, err := s.findJournalFolder(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if err := s.store.JournalFolder().Delete(f.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.publish(realtime.EventJournalFolderDeleted, f.CampaignID, r, map[string]uuid.UUID{"id": f.ID}, nil)
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// findJournalEntry loads the {entryID} entry of the current campaign,
+// pretending it doesn't exist if the member isn't allowed to see it.
+func (s *server) findJournalEntry(r *http.Request) (*model.JournalEntry, error) {
+	id, err := uuid.Parse(mux.Vars(r)["entryID"])
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	e, err := s.store.Journal().Find(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	campaign := r.Context().Value(ctxKeyCampaign).(*model.Campaign)
+	if e.CampaignID != campaign.ID || !e.VisibleTo(r.Context().Value(ctxKeyMember).(*model.Member)) {
+		return nil, ErrNotFound
+	}
+
+	return e, nil
+}
+
+func (s *server) findJournalFolder(r *http.Request) (*model.JournalFolder, error) {
+	id, err := uuid.Parse(mux.Vars(r)["folderID"])
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	f, err := s.store.JournalFolder().Find(id)
+	if err != nil || f.CampaignID != r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID {
+		return nil, ErrNotFound
+	}
+
+	return f, nil
+}
+
+// checkJournalEntry makes sure the entry only refers to the campaign's
+// folders and members, and to images from its author's library.
+func (s *server) checkJournalEntry(e *model.JournalEntry) error {
+	if err := s.checkJournalFolder(e.CampaignID, e.FolderID); err != nil {
+		return err
+	}
+
+	if e.ImageID != nil {
+		a, err := s.store.Asset().Find(*e.ImageID)
+		if err != nil || a.UserID != e.AuthorID || !a.IsImage() {
+			return ErrUnknownAsset
+		}
+	}
+
+	for _, id := range e.Members {
+		if _, err := s.store.Campaign().FindMember(e.CampaignID, id); err != nil {
+			return ErrUnknownMember
+		}
+	}
+
+	return nil
+}
+
+func (s *server) checkJournalFolder(campaignID uuid.UUID, folderID *uuid.UUID) error {
+	if folderID == nil {
+		return nil
+	}
+
+	f, err := s.store.JournalFolder().Find(*folderID)
+	if err != nil || f.CampaignID != campaignID {
+		return ErrUnknownFolder
+	}
+
+	return nil
+}
+
+// publishJournalUpdate tells who can see the entry that it changed. When
+// that changed too, the ones who could see it before are told it's gone
+// first, so it disappears for those who lost access.
+func (s *server) publishJournalUpdate(r *http.Request, e *model.JournalEntry, before *realtime.Audience) {
+	after := journalAudience(e)
+	if !sameAudience(before, after) {
+		s.publish(realtime.EventJournalDeleted, e.CampaignID, r, map[string]uuid.UUID{"id": e.ID}, before)
+	}
+
+	s.publish(realtime.EventJournalUpdated, e.CampaignID, r, s.newJournalView(e), after)
+}
+
+// journalAudience mirrors model.JournalEntry.VisibleTo for realtime
+// delivery.
+func journalAudience(e *model.JournalEntry) *realtime.Audience {
+	switch e.Visibility {
+	case model.VisibilityGM:
+		return &realtime.Audience{GMOnly: true, UserIDs: []uuid.UUID{e.AuthorID}}
+	case model.VisibilityMembers:
+		return &realtime.Audience{GMOnly: true, UserIDs: append([]uuid.UUID{e.AuthorID}, e.Members...)}
+	}
+
+	return nil
+}
+
+func sameAudience(a, b *realtime.Audience) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	if a.GMOnly != b.GMOnly || len(a.UserIDs) != len(b.UserIDs) {
+		return false
+	}
+
+	for i := range a.UserIDs {
+		if a.UserIDs[i] != b.UserIDs[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,257 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandleJournalCreate(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	spectator := testUser(t, st, "spectator")
+	stranger := testUser(t, st, "stranger")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer, spectator: model.RoleSpectator})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	path := fmt.Sprintf("/private/campaigns/%s/journal", c.ID)
+
+	other := testCampaign(t, st, stranger, nil)
+	f := model.TestJournalFolder(t, other)
+	st.JournalFolder().Create(f)
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"gm secret", gm, map[string]interface{}{"title": "Strahd's plans", "body": "**Ireena**"}, http.StatusCreated},
+		{"player notes", player, map[string]interface{}{"title": "Session 1", "visibility": "players"}, http.StatusCreated},
+		{"shared with member", player, map[string]interface{}{"title": "Our secret", "visibility": "members", "members": []uuid.UUID{gm.ID}}, http.StatusCreated},
+		{"shared with stranger", player, map[string]interface{}{"title": "Our secret", "visibility": "members", "members": []uuid.UUID{stranger.ID}}, http.StatusUnprocessableEntity},
+		{"members without members", player, map[string]interface{}{"title": "Our secret", "visibility": "members"}, http.StatusUnprocessableEntity},
+		{"unknown visibility", player, map[string]interface{}{"title": "Notes", "visibility": "everyone"}, http.StatusUnprocessableEntity},
+		{"other campaign's folder", gm, map[string]interface{}{"title": "Notes", "folder_id": f.ID}, http.StatusUnprocessableEntity},
+		{"unknown image", gm, map[string]interface{}{"title": "Map", "image_id": uuid.New()}, http.StatusUnprocessableEntity},
+		{"no title", gm, map[string]interface{}{"body": "text"}, http.StatusUnprocessableEntity},
+		{"spectator", spectator, map[string]interface{}{"title": "Notes"}, http.StatusForbidden},
+		{"stranger", stranger, map[string]interface{}{"title": "Notes"}, http.StatusForbidden},
+		{"invalid payload", gm, "some invalid payload", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, http.MethodPost, path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_HandleJournalVisibility(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	alice := testUser(t, st, "alice")
+	bob := testUser(t, st, "bob")
+	spectator := testUser(t, st, "spectator")
+	c := testCampaign(t, st, gm, map[*model.User]string{alice: model.RolePlayer, bob: model.RolePlayer, spectator: model.RoleSpectator})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	path := fmt.Sprintf("/private/campaigns/%s/journal", c.ID)
+
+	create := func(u *model.User, payload map[string]interface{}) *model.JournalEntry {
+		rec := testRequest(t, s, u, http.MethodPost, path, payload)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		e := &model.JournalEntry{}
+		json.NewDecoder(rec.Body).Decode(e)
+
+		return e
+	}
+	secret := create(gm, map[string]interface{}{"title": "Secret"})
+	lore := create(gm, map[string]interface{}{"title": "Lore", "visibility": "players"})
+	notes := create(alice, map[string]interface{}{"title": "Alice's notes"})
+	shared := create(alice, map[string]interface{}{"title": "For Bob", "visibility": "members", "members": []uuid.UUID{bob.ID}})
+
+	index := func(u *model.User) []string {
+		rec := testRequest(t, s, u, http.MethodGet, path, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		entries := []*model.JournalEntry{}
+		json.NewDecoder(rec.Body).Decode(&entries)
+
+		titles := []string{}
+		for _, e := range entries {
+			titles = append(titles, e.Title)
+		}
+
+		return titles
+	}
+	assert.Equal(t, []string{"Alice's notes", "For Bob", "Lore", "Secret"}, index(gm))
+	assert.Equal(t, []string{"Alice's notes", "For Bob", "Lore"}, index(alice))
+	assert.Equal(t, []string{"For Bob", "Lore"}, index(bob))
+	assert.Equal(t, []string{"Lore"}, index(spectator))
+
+	get := func(u *model.User, e *model.JournalEntry) int {
+		return testRequest(t, s, u, http.MethodGet, fmt.Sprintf("%s/%s", path, e.ID), nil).Code
+	}
+	assert.Equal(t, http.StatusOK, get(gm, notes))
+	assert.Equal(t, http.StatusNotFound, get(alice, secret))
+	assert.Equal(t, http.StatusNotFound, get(bob, notes))
+	assert.Equal(t, http.StatusOK, get(bob, shared))
+	assert.Equal(t, http.StatusOK, get(spectator, lore))
+
+	update := func(u *model.User, e *model.JournalEntry, payload map[string]interface{}) int {
+		return testRequest(t, s, u, http.MethodPatch, fmt.Sprintf("%s/%s", path, e.ID), payload).Code
+	}
+	assert.Equal(t, http.StatusForbidden, update(bob, shared, map[string]interface{}{"title": "Mine now"}))
+	assert.Equal(t, http.StatusNotFound, update(bob, notes, map[string]interface{}{"title": "Mine now"}))
+	assert.Equal(t, http.StatusOK, update(gm, notes, map[string]interface{}{"body": "_edited_"}))
+	assert.Equal(t, http.StatusOK, update(alice, shared, map[string]interface{}{"visibility": "gm"}))
+	assert.Equal(t, http.StatusNotFound, get(bob, shared))
+	assert.Equal(t, http.StatusUnprocessableEntity, update(alice, notes, map[string]interface{}{"visibility": "members", "members": []uuid.UUID{uuid.New()}}))
+
+	rec := testRequest(t, s, gm, http.MethodGet, fmt.Sprintf("%s/%s", path, notes.ID), nil)
+	json.NewDecoder(rec.Body).Decode(notes)
+	assert.Equal(t, "<p><em>edited</em></p>\n", notes.BodyHTML)
+
+	assert.Equal(t, http.StatusForbidden, testRequest(t, s, bob, http.MethodDelete, fmt.Sprintf("%s/%s", path, lore.ID), nil).Code)
+	assert.Equal(t, http.StatusNoContent, testRequest(t, s, alice, http.MethodDelete, fmt.Sprintf("%s/%s", path, notes.ID), nil).Code)
+	assert.Equal(t, http.StatusNotFound, get(gm, notes))
+}
+
+func TestServer_HandleJournalIndexSearch(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	c := testCampaign(t, st, gm, nil)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	path := fmt.Sprintf("/private/campaigns/%s/journal", c.ID)
+
+	f := model.TestJournalFolder(t, c)
+	st.JournalFolder().Create(f)
+	testRequest(t, s, gm, http.MethodPost, path, map[string]interface{}{"title": "Castle Ravenloft", "body": "Strahd's home", "folder_id": f.ID})
+	testRequest(t, s, gm, http.MethodPost, path, map[string]interface{}{"title": "Village of Barovia", "body": "Ireena lives here"})
+
+	search := func(query string) (int, []*model.JournalEntry) {
+		rec := testRequest(t, s, gm, http.MethodGet, path+query, nil)
+		entries := []*model.JournalEntry{}
+		json.NewDecoder(rec.Body).Decode(&entries)
+
+		return rec.Code, entries
+	}
+
+	code, entries := search("?q=strahd")
+	assert.Equal(t, http.StatusOK, code)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "Castle Ravenloft", entries[0].Title)
+	}
+
+	_, entries = search("?q=ireena+barovia")
+	assert.Len(t, entries, 1)
+
+	_, entries = search("?folder_id=" + f.ID.String())
+	assert.Len(t, entries, 1)
+
+	code, _ = search("?folder_id=garbage")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestServer_HandleJournalShow(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	alice := testUser(t, st, "alice")
+	bob := testUser(t, st, "bob")
+	c := testCampaign(t, st, gm, map[*model.User]string{alice: model.RolePlayer, bob: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	path := fmt.Sprintf("/private/campaigns/%s/journal", c.ID)
+
+	rec := testRequest(t, s, gm, http.MethodPost, path, map[string]interface{}{"title": "Letter from Kolyan"})
+	e := &model.JournalEntry{}
+	json.NewDecoder(rec.Body).Decode(e)
+	show := fmt.Sprintf("%s/%s/show", path, e.ID)
+
+	members := map[*model.User]*realtime.Client{}
+	for _, u := range []*model.User{gm, alice, bob} {
+		m, _ := st.Campaign().FindMember(c.ID, u.ID)
+		members[u], _, _ = s.hub.Subscribe(m, "")
+		defer s.hub.Unsubscribe(members[u])
+	}
+	shown := func(c *realtime.Client) bool {
+		for {
+			select {
+			case e := <-c.Events():
+				if e.Type == realtime.EventHandoutShown {
+					return true
+				}
+			default:
+				return false
+			}
+		}
+	}
+
+	assert.Equal(t, http.StatusForbidden, testRequest(t, s, alice, http.MethodPost, show, map[string]interface{}{}).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, testRequest(t, s, gm, http.MethodPost, show, map[string]interface{}{"user_ids": []uuid.UUID{uuid.New()}}).Code)
+
+	rec = testRequest(t, s, gm, http.MethodPost, show, map[string]interface{}{"user_ids": []uuid.UUID{alice.ID}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	json.NewDecoder(rec.Body).Decode(e)
+	assert.Equal(t, model.VisibilityMembers, e.Visibility)
+	assert.Equal(t, []uuid.UUID{alice.ID}, e.Members)
+	assert.True(t, shown(members[alice]))
+	assert.False(t, shown(members[bob]))
+	assert.Equal(t, http.StatusOK, testRequest(t, s, alice, http.MethodGet, fmt.Sprintf("%s/%s", path, e.ID), nil).Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(t, s, bob, http.MethodGet, fmt.Sprintf("%s/%s", path, e.ID), nil).Code)
+
+	rec = testRequest(t, s, gm, http.MethodPost, show, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	json.NewDecoder(rec.Body).Decode(e)
+	assert.Equal(t, model.VisibilityPlayers, e.Visibility)
+	assert.True(t, shown(members[alice]))
+	assert.True(t, shown(members[bob]))
+	assert.Equal(t, http.StatusOK, testRequest(t, s, bob, http.MethodGet, fmt.Sprintf("%s/%s", path, e.ID), nil).Code)
+}
+
+func TestServer_HandleJournalFolders(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	path := fmt.Sprintf("/private/campaigns/%s/journal/folders", c.ID)
+
+	assert.Equal(t, http.StatusForbidden, testRequest(t, s, player, http.MethodPost, path, map[string]string{"name": "Lore"}).Code)
+
+	create := func(payload map[string]interface{}) *model.JournalFolder {
+		rec := testRequest(t, s, gm, http.MethodPost, path, payload)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		f := &model.JournalFolder{}
+		json.NewDecoder(rec.Body).Decode(f)
+
+		return f
+	}
+	lore := create(map[string]interface{}{"name": "Lore"})
+	places := create(map[string]interface{}{"name": "Places", "parent_id": lore.ID})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, testRequest(t, s, gm, http.MethodPost, path, map[string]interface{}{"name": "NPCs", "parent_id": uuid.New()}).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, testRequest(t, s, gm, http.MethodPatch, fmt.Sprintf("%s/%s", path, lore.ID), map[string]interface{}{"parent_id": places.ID}).Code)
+	assert.Equal(t, http.StatusOK, testRequest(t, s, gm, http.MethodPatch, fmt.Sprintf("%s/%s", path, places.ID), map[string]interface{}{"name": "Locations", "parent_id": nil}).Code)
+
+	rec := testRequest(t, s, player, http.MethodGet, path, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	folders := []*model.JournalFolder{}
+	json.NewDecoder(rec.Body).Decode(&folders)
+	assert.Len(t, folders, 2)
+
+	rec = testRequest(t, s, gm, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/journal", c.ID), map[string]interface{}{"title": "Vallaki", "folder_id": places.ID})
+	e := &model.JournalEntry{}
+	json.NewDecoder(rec.Body).Decode(e)
+
+	assert.Equal(t, http.StatusNoContent, testRequest(t, s, gm, http.MethodDelete, fmt.Sprintf("%s/%s", path, places.ID), nil).Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(t, s, gm, http.MethodDelete, fmt.Sprintf("%s/%s", path, places.ID), nil).Code)
+	e, _ = st.Journal().Find(e.ID)
+	assert.Nil(t, e.FolderID)
+}
@@ -0,0 +1,204 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const defaultLightColor = "#ffffff"
+
+func (s *server) handleLightsCreate() http.HandlerFunc {
+	type request struct {
+		X      int    `json:"x"`
+		Y      int    `json:"y"`
+		Radius int    `json:"radius"`
+		Color  string `json:"color"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, err := s.findScene(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{Color: defaultLightColor}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		l := &model.Light{
+			SceneID: sc.ID,
+			X:       req.X,
+			Y:       req.Y,
+			Radius:  req.Radius,
+			Color:   req.Color,
+		}
+		if !sc.Contains(l.X, l.Y) {
+			s.error(w, r, http.StatusUnprocessableEntity, model.ErrOutOfBounds)
+			return
+		}
+
+		if err := s.store.Transaction(func(tx store.Store) error {
+			if err := tx.Light().Create(l); err != nil {
+				return err
+			}
+
+			return logAction(tx, r, sc, model.TargetLight, l.ID, nil, l)
+		}); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.publish(realtime.EventLightCreated, sc.CampaignID, r, l, &realtime.Audience{GMOnly: true})
+		s.publishVisionChange(r, sc, nil)
+		s.respond(w, r, http.StatusCreated, l)
+	}
+}
+
+func (s *server) handleLightsIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, err := s.findScene(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		lights, err := s.store.Light().FindAll(sc.ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, lights)
+	}
+}
+
+func (s *server) handleLightsUpdate() http.HandlerFunc {
+	type request struct {
+		X      *int    `json:"x"`
+		Y      *int    `json:"y"`
+		Radius *int    `json:"radius"`
+		Color  *string `json:"color"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, l, err := s.findLight(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		before := *l
+		if req.X != nil {
+			l.X = *req.X
+		}
+		if req.Y != nil {
+			l.Y = *req.Y
+		}
+		if req.Radius != nil {
+			l.Radius = *req.Radius
+		}
+		if req.Color != nil {
+			l.Color = *req.Color
+		}
+
+		if !sc.Contains(l.X, l.Y) {
+			s.error(w, r, http.StatusUnprocessableEntity, model.ErrOutOfBounds)
+			return
+		}
+
+		if err := s.store.Transaction(func(tx store.Store) error {
+			if err := tx.Light().Update(l); err != nil {
+				return err
+			}
+
+			return logAction(tx, r, sc, model.TargetLight, l.ID, &before, l)
+		}); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.publish(realtime.EventLightUpdated, sc.CampaignID, r, l, &realtime.Audience{GMOnly: true})
+		s.publishVisionChange(r, sc, nil)
+		s.respond(w, r, http.StatusOK, l)
+	}
+}
+
+func (s *server) handleLightsDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, l, err := s.findLight(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		if err := s.store.Transaction(func(tx store.Store) error {
+			if err := tx.Light().Delete(l.ID); err != nil {
+				return err
+			}
+
+			return logAction(tx, r, sc, model.TargetLight, l.ID, l, nil)
+		}); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.publish(realtime.EventLightDeleted, sc.CampaignID, r, map[string]uuid.UUID{"id": l.ID, "scene_id": sc.ID}, &realtime.Audience{GMOnly: true})
+		s.publishVisionChange(r, sc, nil)
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// findLight loads the {lightID} light of the {sceneID} scene.
+func (s *server) findLight(r *http.Request) (*model.Scene, *model.Light, error) {
+	sc, err := s.findScene(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id, err := uuid.Parse(mux.Vars(r)["lightID"])
+	if err != nil {
+		return nil, nil, ErrNotFound
+	}
+
+	l, err := s.store.Light().Find(id)
+	if err != nil || l.SceneID != sc.ID {
+		return nil, nil, ErrNotFound
+	}
+
+	return sc, l, nil
+}
@@ -0,0 +1,49 @@
+package apiserver
+
+import (
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+// mentionResolver resolves Markdown mentions to members and characters of
+// the campaign. Without a campaign nothing resolves, so that mentions don't
+// tell who has an account.
+type mentionResolver struct {
+	store      store.Store
+	campaignID uuid.UUID
+}
+
+func (r *mentionResolver) User(username string) (uuid.UUID, bool) {
+	if r.campaignID == uuid.Nil {
+		return uuid.Nil, false
+	}
+
+	u, err := r.store.User().FindByUsername(username)
+	if err != nil {
+		return uuid.Nil, false
+	}
+
+	if _, err := r.store.Campaign().FindMember(r.campaignID, u.ID); err != nil {
+		return uuid.Nil, false
+	}
+
+	return u.ID, true
+}
+
+func (r *mentionResolver) Character(name string) (uuid.UUID, bool) {
+	if r.campaignID == uuid.Nil {
+		return uuid.Nil, false
+	}
+
+	c, err := r.store.Character().FindByName(r.campaignID, name)
+	if err != nil {
+		return uuid.Nil, false
+	}
+
+	return c.ID, true
+}
+
+// render renders user Markdown with mentions scoped to the campaign.
+func (s *server) render(source string, campaignID uuid.UUID) (string, error) {
+	return s.markdown.Render(source, &mentionResolver{store: s.store, campaignID: campaignID})
+}
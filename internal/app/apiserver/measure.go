@@ -0,0 +1,118 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/geometry"
+	"github.com/bruhlord-s/virttable-api/internal/app/grid"
+	"github.com/google/uuid"
+)
+
+// maxPathPoints bounds the paths measured at once.
+const maxPathPoints = 1000
+
+var (
+	ErrPathTooShort = errors.New("path needs at least two points")
+	ErrPathTooLong  = errors.New("path has too many points")
+)
+
+// handleScenesMeasure measures a path across the scene with the rules of
+// its grid, in total and leg by leg. Legs add up to the total, so with
+// alternating diagonals a leg's length depends on the ones before it.
+func (s *server) handleScenesMeasure() http.HandlerFunc {
+	type request struct {
+		Path []geometry.Point `json:"path"`
+	}
+
+	type response struct {
+		Distance float64   `json:"distance"`
+		Legs     []float64 `json:"legs"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, err := s.findScene(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if len(req.Path) < 2 {
+			s.error(w, r, http.StatusUnprocessableEntity, ErrPathTooShort)
+			return
+		}
+		if len(req.Path) > maxPathPoints {
+			s.error(w, r, http.StatusUnprocessableEntity, ErrPathTooLong)
+			return
+		}
+
+		res := &response{Legs: sc.Grid().Legs(req.Path)}
+		for _, d := range res.Legs {
+			res.Distance += d
+		}
+
+		s.respond(w, r, http.StatusOK, res)
+	}
+}
+
+// handleScenesTemplate places an area of effect template on the scene and
+// returns the cells it covers and the tokens, visible to the member, that
+// stand in them.
+func (s *server) handleScenesTemplate() http.HandlerFunc {
+	type response struct {
+		Polygon geometry.Polygon `json:"polygon"`
+		Cells   []grid.Cell      `json:"cells"`
+		Tokens  []uuid.UUID      `json:"tokens"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, err := s.findScene(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		t := &grid.Template{}
+		if err := json.NewDecoder(r.Body).Decode(t); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		g := sc.Grid()
+		p, err := g.Area(t)
+		if err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		res := &response{Polygon: p, Cells: g.Cells(p), Tokens: []uuid.UUID{}}
+		covered := map[grid.Cell]bool{}
+		for _, c := range res.Cells {
+			covered[c] = true
+		}
+
+		tokens, err := s.visibleTokens(r, sc)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		for _, tok := range tokens {
+			for _, c := range g.Footprint(tok.Position(), tok.Size) {
+				if covered[c] {
+					res.Tokens = append(res.Tokens, tok.ID)
+					break
+				}
+			}
+		}
+
+		s.respond(w, r, http.StatusOK, res)
+	}
+}
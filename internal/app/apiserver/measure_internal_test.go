@@ -0,0 +1,111 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/grid"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandleScenesMeasure(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	sc.Diagonals = grid.DiagonalsAlternating
+	st.Scene().Create(sc)
+	path := fmt.Sprintf("/private/campaigns/%s/scenes/%s/measure", c.ID, sc.ID)
+
+	testCases := []struct {
+		name         string
+		payload      interface{}
+		exceptedCode int
+		distance     float64
+		legs         []float64
+	}{
+		{"two legs", map[string]interface{}{"path": []map[string]int{{"x": 35, "y": 35}, {"x": 175, "y": 175}, {"x": 245, "y": 245}}}, http.StatusOK, 20, []float64{15, 5}},
+		{"one point", map[string]interface{}{"path": []map[string]int{{"x": 35, "y": 35}}}, http.StatusUnprocessableEntity, 0, nil},
+		{"too many points", map[string]interface{}{"path": make([]map[string]int, maxPathPoints+1)}, http.StatusUnprocessableEntity, 0, nil},
+		{"invalid payload", "some invalid payload", http.StatusBadRequest, 0, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, player, http.MethodPost, path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+
+			if tc.exceptedCode == http.StatusOK {
+				res := &struct {
+					Distance float64   `json:"distance"`
+					Legs     []float64 `json:"legs"`
+				}{}
+				json.NewDecoder(rec.Body).Decode(res)
+				assert.Equal(t, tc.distance, res.Distance)
+				assert.Equal(t, tc.legs, res.Legs)
+			}
+		})
+	}
+}
+
+func TestServer_HandleScenesTemplate(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	st.Scene().Create(sc)
+	inside := model.TestToken(t, sc, player)
+	inside.X, inside.Y = 385, 385
+	st.Token().Create(inside)
+	large := model.TestToken(t, sc, player)
+	large.X, large.Y, large.Size = 560, 420, 2
+	st.Token().Create(large)
+	hidden := model.TestToken(t, sc, gm)
+	hidden.X, hidden.Y, hidden.Layer = 315, 315, model.LayerGM
+	st.Token().Create(hidden)
+	st.Token().Create(model.TestToken(t, sc, player))
+	path := fmt.Sprintf("/private/campaigns/%s/scenes/%s/template", c.ID, sc.ID)
+
+	res := &struct {
+		Cells  []grid.Cell `json:"cells"`
+		Tokens []uuid.UUID `json:"tokens"`
+	}{}
+	rec := testRequest(t, s, player, http.MethodPost, path, map[string]interface{}{
+		"shape":  grid.Sphere,
+		"origin": map[string]int{"x": 350, "y": 350},
+		"size":   20,
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	json.NewDecoder(rec.Body).Decode(res)
+	assert.Contains(t, res.Cells, grid.Cell{Col: 5, Row: 5})
+	assert.ElementsMatch(t, []uuid.UUID{inside.ID, large.ID}, res.Tokens)
+
+	rec = testRequest(t, s, gm, http.MethodPost, path, map[string]interface{}{
+		"shape":  grid.Sphere,
+		"origin": map[string]int{"x": 350, "y": 350},
+		"size":   20,
+	})
+	json.NewDecoder(rec.Body).Decode(res)
+	assert.ElementsMatch(t, []uuid.UUID{inside.ID, large.ID, hidden.ID}, res.Tokens)
+
+	for _, payload := range []map[string]interface{}{
+		{"shape": "pyramid", "size": 20},
+		{"shape": grid.Sphere, "size": 1e9},
+		{"shape": grid.Cube, "origin": map[string]float64{"x": -1e9, "y": 350}, "size": 20},
+	} {
+		rec = testRequest(t, s, player, http.MethodPost, path, payload)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	}
+}
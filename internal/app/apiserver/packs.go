@@ -0,0 +1,470 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+var (
+	ErrUnknownPackVersion = errors.New("unknown pack version")
+	ErrPackSystemMismatch = errors.New("pack is made for another game system")
+	ErrPackVersionTaken   = errors.New("pack version has been published meanwhile")
+)
+
+func (s *server) handlePacksCreate() http.HandlerFunc {
+	type request struct {
+		Name        string `json:"name"`
+		System      string `json:"system"`
+		Description string `json:"description"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		p := &model.Pack{
+			OwnerID:     r.Context().Value(ctxKeyUser).(*model.User).ID,
+			Name:        req.Name,
+			System:      req.System,
+			Description: req.Description,
+		}
+		html, err := s.render(p.Description, uuid.Nil)
+		if err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+		p.DescriptionHTML = html
+
+		if err := s.store.Pack().Create(p); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusCreated, p)
+	}
+}
+
+// handlePacksIndex lists the packs everyone published, narrowed by the
+// system and owner_id query parameters.
+func (s *server) handlePacksIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f := &model.PackFilter{System: r.URL.Query().Get("system")}
+		if v := r.URL.Query().Get("owner_id"); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				s.error(w, r, http.StatusBadRequest, ErrInvalidFilter)
+				return
+			}
+			f.OwnerID = &id
+		}
+
+		packs, err := s.store.Pack().FindAll(f)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, packs)
+	}
+}
+
+func (s *server) handlePacksGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := s.findPack(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, p)
+	}
+}
+
+func (s *server) handlePacksUpdate() http.HandlerFunc {
+	type request struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := s.findPack(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if p.OwnerID != r.Context().Value(ctxKeyUser).(*model.User).ID {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.Name != nil {
+			p.Name = *req.Name
+		}
+		if req.Description != nil {
+			p.Description = *req.Description
+			html, err := s.render(p.Description, uuid.Nil)
+			if err != nil {
+				s.error(w, r, http.StatusUnprocessableEntity, err)
+				return
+			}
+			p.DescriptionHTML = html
+		}
+
+		if err := s.store.Pack().Update(p); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, p)
+	}
+}
+
+// handlePackVersionsCreate publishes the next version of the pack. Published
+// versions never change; fixing an entry takes a new version.
+func (s *server) handlePackVersionsCreate() http.HandlerFunc {
+	type request struct {
+		Changelog string             `json:"changelog"`
+		Entries   []*model.PackEntry `json:"entries"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := s.findPack(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if p.OwnerID != r.Context().Value(ctxKeyUser).(*model.User).ID {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		v := &model.PackVersion{
+			PackID:    p.ID,
+			Version:   p.LatestVersion + 1,
+			Changelog: req.Changelog,
+			Entries:   req.Entries,
+		}
+		if err := v.Validate(p); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		entries := v.CompendiumEntries(p)
+		for _, e := range entries {
+			if e.BodyHTML, err = s.render(e.Body, uuid.Nil); err != nil {
+				s.error(w, r, http.StatusUnprocessableEntity, err)
+				return
+			}
+		}
+
+		if err := s.store.PackVersion().Create(v, entries); err != nil {
+			if err == store.ErrConflict {
+				s.error(w, r, http.StatusConflict, ErrPackVersionTaken)
+				return
+			}
+
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusCreated, v)
+	}
+}
+
+func (s *server) handlePackVersionsIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := s.findPack(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		versions, err := s.store.PackVersion().FindAll(p.ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, versions)
+	}
+}
+
+func (s *server) handlePackVersionsGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := s.findPack(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		version, _ := strconv.Atoi(mux.Vars(r)["version"])
+		v, err := s.store.PackVersion().Find(p.ID, version)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, ErrNotFound)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, v)
+	}
+}
+
+// handlePackVersionsDiff compares the from and to versions of the pack, to
+// defaulting to the latest one.
+func (s *server) handlePackVersionsDiff() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := s.findPack(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		from, err := strconv.Atoi(r.URL.Query().Get("from"))
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, ErrUnknownPackVersion)
+			return
+		}
+
+		d, err := s.diffPack(p, from, r.URL.Query().Get("to"))
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, d)
+	}
+}
+
+func (s *server) handleCampaignPacksIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := r.Context().Value(ctxKeyCampaign).(*model.Campaign)
+		packs, err := s.store.CampaignPack().FindAll(c.ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, packs)
+	}
+}
+
+// handleCampaignPacksSave subscribes the campaign to a version of the pack,
+// the latest one unless told otherwise. Saving another version upgrades
+// or downgrades the subscription.
+func (s *server) handleCampaignPacksSave() http.HandlerFunc {
+	type request struct {
+		Version *int `json:"version"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		p, err := s.findPack(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		c := r.Context().Value(ctxKeyCampaign).(*model.Campaign)
+		if p.System != c.System {
+			s.error(w, r, http.StatusUnprocessableEntity, ErrPackSystemMismatch)
+			return
+		}
+
+		cp := &model.CampaignPack{CampaignID: c.ID, PackID: p.ID, Version: p.LatestVersion}
+		if req.Version != nil {
+			cp.Version = *req.Version
+		}
+		if _, err := s.store.PackVersion().Find(p.ID, cp.Version); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, ErrUnknownPackVersion)
+			return
+		}
+
+		if err := s.store.CampaignPack().Save(cp); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.publish(realtime.EventPackSubscribed, c.ID, r, cp, nil)
+		s.respond(w, r, http.StatusOK, cp)
+	}
+}
+
+// handleCampaignPacksUpgrade previews what upgrading the campaign to a
+// version of the pack, the latest one unless told otherwise, would change.
+func (s *server) handleCampaignPacksUpgrade() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := s.findPack(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		cp, err := s.store.CampaignPack().Find(r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID, p.ID)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, ErrNotFound)
+			return
+		}
+
+		d, err := s.diffPack(p, cp.Version, r.URL.Query().Get("version"))
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, d)
+	}
+}
+
+func (s *server) handleCampaignPacksDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		packID, err := uuid.Parse(mux.Vars(r)["packID"])
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, ErrNotFound)
+			return
+		}
+
+		c := r.Context().Value(ctxKeyCampaign).(*model.Campaign)
+		if err := s.store.CampaignPack().Delete(c.ID, packID); err != nil {
+			s.error(w, r, http.StatusNotFound, ErrNotFound)
+			return
+		}
+
+		s.publish(realtime.EventPackUnsubscribed, c.ID, r, map[string]uuid.UUID{"pack_id": packID}, nil)
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// handleCampaignCompendiumIndex searches the compendium of the campaign's
+// system along with the pack versions the campaign is subscribed to.
+func (s *server) handleCampaignCompendiumIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f, err := parseCompendiumFilter(r.URL.Query())
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		c := r.Context().Value(ctxKeyCampaign).(*model.Campaign)
+		f.System = c.System
+		if f.Packs, err = s.campaignPacks(c.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		entries, err := s.store.Compendium().FindAll(f)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, entries)
+	}
+}
+
+// findPack loads the {packID} pack. Packs are shared with everyone.
+func (s *server) findPack(r *http.Request) (*model.Pack, error) {
+	id, err := uuid.Parse(mux.Vars(r)["packID"])
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	p, err := s.store.Pack().Find(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	return p, nil
+}
+
+// diffPack compares the from version of the pack with the to one, the
+// latest one when to is empty.
+func (s *server) diffPack(p *model.Pack, from int, to string) (*model.PackDiff, error) {
+	toVersion := p.LatestVersion
+	if to != "" {
+		var err error
+		if toVersion, err = strconv.Atoi(to); err != nil {
+			return nil, ErrUnknownPackVersion
+		}
+	}
+
+	fromPV, err := s.store.PackVersion().Find(p.ID, from)
+	if err != nil {
+		return nil, ErrUnknownPackVersion
+	}
+
+	toPV, err := s.store.PackVersion().Find(p.ID, toVersion)
+	if err != nil {
+		return nil, ErrUnknownPackVersion
+	}
+
+	return model.DiffPackVersions(fromPV, toPV), nil
+}
+
+// campaignPacks returns the pack versions the campaign is subscribed to.
+func (s *server) campaignPacks(campaignID uuid.UUID) ([]model.PackRef, error) {
+	packs, err := s.store.CampaignPack().FindAll(campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]model.PackRef, 0, len(packs))
+	for _, cp := range packs {
+		refs = append(refs, model.PackRef{ID: cp.PackID, Version: cp.Version})
+	}
+
+	return refs, nil
+}
+
+// inCampaignCompendium reports whether the entry belongs to the compendium
+// of the campaign: its system's, or a pack version it is subscribed to.
+func (s *server) inCampaignCompendium(c *model.Campaign, e *model.CompendiumEntry) bool {
+	if e.System != c.System {
+		return false
+	}
+	if e.PackID == nil {
+		return true
+	}
+
+	cp, err := s.store.CampaignPack().Find(c.ID, *e.PackID)
+
+	return err == nil && cp.Version == e.PackVersion
+}
@@ -0,0 +1,191 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandlePacks(t *testing.T) {
+	st := teststore.New()
+	author := testUser(t, st, "author")
+	other := testUser(t, st, "other")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	rec := testRequest(t, s, author, http.MethodPost, "/private/packs", map[string]string{
+		"name":        "Tome of Barovia",
+		"system":      "dnd5e",
+		"description": "Monsters of **the mists**, thanks to @other.",
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	p := &model.Pack{}
+	json.NewDecoder(rec.Body).Decode(p)
+	assert.Contains(t, p.DescriptionHTML, "<strong>the mists</strong>")
+	assert.NotContains(t, p.DescriptionHTML, other.ID.String())
+
+	rec = testRequest(t, s, author, http.MethodPost, "/private/packs", map[string]string{"name": "Tome", "system": "gurps"})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = testRequest(t, s, other, http.MethodGet, "/private/packs?owner_id="+author.ID.String(), nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	packs := []*model.Pack{}
+	json.NewDecoder(rec.Body).Decode(&packs)
+	assert.Len(t, packs, 1)
+
+	path := "/private/packs/" + p.ID.String()
+	rec = testRequest(t, s, other, http.MethodPatch, path, map[string]string{"name": "Stolen"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = testRequest(t, s, author, http.MethodPatch, path, map[string]string{"name": "Tome of Barovia, Revised"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = testRequest(t, s, other, http.MethodGet, "/private/packs/"+uuid.New().String(), nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	v := model.TestPackVersion(t, p)
+	testCases := []struct {
+		name         string
+		u            *model.User
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"not the author", other, v, http.StatusForbidden},
+		{"no entries", author, map[string]interface{}{"entries": []interface{}{}}, http.StatusUnprocessableEntity},
+		{
+			name: "data against the schema",
+			u:    author,
+			payload: map[string]interface{}{"entries": []map[string]interface{}{
+				{"kind": "monster", "name": "Strahd Zombie", "data": map[string]interface{}{"hp": "many"}},
+			}},
+			exceptedCode: http.StatusUnprocessableEntity,
+		},
+		{"valid", author, v, http.StatusCreated},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.u, http.MethodPost, path+"/versions", tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+
+	v.Entries[0].Data = []byte(`{"hp": 40, "initiative_bonus": -2}`)
+	rec = testRequest(t, s, author, http.MethodPost, path+"/versions", v)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	published := &model.PackVersion{}
+	json.NewDecoder(rec.Body).Decode(published)
+	assert.Equal(t, 2, published.Version)
+
+	rec = testRequest(t, s, other, http.MethodGet, path+"/versions", nil)
+	versions := []*model.PackVersion{}
+	json.NewDecoder(rec.Body).Decode(&versions)
+	assert.Len(t, versions, 2)
+
+	rec = testRequest(t, s, other, http.MethodGet, path+"/versions/1", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = testRequest(t, s, other, http.MethodGet, path+"/versions/3", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = testRequest(t, s, other, http.MethodGet, path+"/diff?from=1", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	d := &model.PackDiff{}
+	json.NewDecoder(rec.Body).Decode(d)
+	assert.Equal(t, 2, d.To)
+	assert.Len(t, d.Changed, 1)
+	rec = testRequest(t, s, other, http.MethodGet, path+"/diff", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestServer_HandleCampaignPacks(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	p := model.TestPack(t, gm)
+	st.Pack().Create(p)
+	v1 := model.TestPackVersion(t, p)
+	st.PackVersion().Create(v1, v1.CompendiumEntries(p))
+	p.LatestVersion = 1
+	v2 := model.TestPackVersion(t, p)
+	v2.Entries[0].Data = []byte(`{"hp": 40, "initiative_bonus": -2}`)
+	st.PackVersion().Create(v2, v2.CompendiumEntries(p))
+	pf := model.TestPack(t, gm)
+	pf.System = "pf2e"
+	st.Pack().Create(pf)
+
+	path := fmt.Sprintf("/private/campaigns/%s/packs/", c.ID)
+	testCases := []struct {
+		name         string
+		u            *model.User
+		packID       uuid.UUID
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"player", player, p.ID, map[string]int{"version": 1}, http.StatusForbidden},
+		{"unknown pack", gm, uuid.New(), map[string]int{"version": 1}, http.StatusNotFound},
+		{"other system", gm, pf.ID, map[string]int{"version": 1}, http.StatusUnprocessableEntity},
+		{"unknown version", gm, p.ID, map[string]int{"version": 3}, http.StatusUnprocessableEntity},
+		{"valid", gm, p.ID, map[string]int{"version": 1}, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.u, http.MethodPut, path+tc.packID.String(), tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+
+	compendium := func() []*model.CompendiumEntry {
+		rec := testRequest(t, s, player, http.MethodGet, fmt.Sprintf("/private/campaigns/%s/compendium?kind=monster", c.ID), nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		entries := []*model.CompendiumEntry{}
+		json.NewDecoder(rec.Body).Decode(&entries)
+
+		return entries
+	}
+	entries := compendium()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, 1, entries[0].PackVersion)
+	}
+
+	rec := testRequest(t, s, player, http.MethodGet, path+p.ID.String()+"/upgrade", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	d := &model.PackDiff{}
+	json.NewDecoder(rec.Body).Decode(d)
+	assert.Equal(t, 1, d.From)
+	assert.Equal(t, 2, d.To)
+	if assert.Len(t, d.Changed, 1) {
+		assert.Equal(t, "Strahd Zombie", d.Changed[0].After.Name)
+	}
+
+	cb := model.TestCombat(t, c)
+	st.Combat().Create(cb)
+	combatants := fmt.Sprintf("/private/campaigns/%s/combats/%s/combatants", c.ID, cb.ID)
+	rec = testRequest(t, s, gm, http.MethodPost, combatants, map[string]interface{}{"compendium_id": entries[0].ID})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = testRequest(t, s, gm, http.MethodPut, path+p.ID.String(), map[string]interface{}{})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	upgraded := compendium()
+	if assert.Len(t, upgraded, 1) {
+		assert.Equal(t, 2, upgraded[0].PackVersion)
+	}
+
+	rec = testRequest(t, s, gm, http.MethodPost, combatants, map[string]interface{}{"compendium_id": entries[0].ID})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = testRequest(t, s, player, http.MethodDelete, path+p.ID.String(), nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = testRequest(t, s, gm, http.MethodDelete, path+p.ID.String(), nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Len(t, compendium(), 0)
+	rec = testRequest(t, s, gm, http.MethodDelete, path+p.ID.String(), nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,196 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	sseRetry     = 3 * time.Second
+	sseKeepAlive = 30 * time.Second
+)
+
+var (
+	ErrStreamingUnsupported = errors.New("streaming unsupported")
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// handleTicketsCreate issues a short-lived ticket that browsers pass as the
+// ticket query parameter when opening a WebSocket, since they can't set the
+// Authorization header there.
+func (s *server) handleTicketsCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		t, err := r.Context().Value(ctxKeyUser).(*model.User).CreateTicket([]byte(s.jwtKey))
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusCreated, map[string]string{
+			"ticket": t,
+		})
+	}
+}
+
+func (s *server) handleCampaignsWS() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			s.logger.Error(err.Error())
+			return
+		}
+
+		s.hub.Serve(conn, r.Context().Value(ctxKeyMember).(*model.Member))
+	}
+}
+
+// publish sends an event about the campaign to its connected members on
+// every instance. A nil audience means everyone in the campaign.
+func (s *server) publish(typ string, campaignID uuid.UUID, r *http.Request, payload interface{}, audience *realtime.Audience) {
+	var userID *uuid.UUID
+	if u, ok := r.Context().Value(ctxKeyUser).(*model.User); ok {
+		userID = &u.ID
+	}
+
+	e, err := realtime.NewEvent(typ, campaignID, userID, payload)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return
+	}
+	e.Audience = audience
+
+	if err := s.pubsub.Publish(e); err != nil {
+		s.logger.Error(err.Error())
+	}
+}
+
+// playersAudience addresses an event to the members of the campaign who
+// aren't GMs, for events paired with a GM-only one.
+func (s *server) playersAudience(campaignID uuid.UUID) (*realtime.Audience, error) {
+	members, err := s.store.Campaign().Members(campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	players := &realtime.Audience{}
+	for _, m := range members {
+		if !m.IsGM() {
+			players.UserIDs = append(players.UserIDs, m.UserID)
+		}
+	}
+
+	return players, nil
+}
+
+// handleCampaignsEvents streams the same events as the WebSocket as
+// Server-Sent Events for clients behind proxies that break WebSockets.
+// Reconnecting clients send Last-Event-ID (or last_event_id in the query)
+// and get the events they missed replayed, or a stream.reset event when the
+// id fell out of the log and they have to reload.
+func (s *server) handleCampaignsEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			s.error(w, r, http.StatusInternalServerError, ErrStreamingUnsupported)
+			return
+		}
+
+		lastEventID := r.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = r.URL.Query().Get("last_event_id")
+		}
+
+		c, missed, ok := s.hub.Subscribe(r.Context().Value(ctxKeyMember).(*model.Member), lastEventID)
+		defer s.hub.Unsubscribe(c)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+
+		if !ok {
+			fmt.Fprintf(w, "event: %s\ndata: {}\n\n", realtime.EventStreamReset)
+		}
+		for _, e := range missed {
+			if err := writeSSE(w, e); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+
+		ticker := time.NewTicker(sseKeepAlive)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case e, ok := <-c.Events():
+				if !ok {
+					return
+				}
+
+				if err := writeSSE(w, e); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-ticker.C:
+				if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+}
+
+func writeSSE(w io.Writer, e *realtime.Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	// Without an id, the stream resumes from the last event that had one.
+	if !e.Ephemeral {
+		if _, err := fmt.Fprintf(w, "id: %s\n", e.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, b)
+
+	return err
+}
+
+func (s *server) handlePresenceIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.respond(w, r, http.StatusOK, s.presence.List(r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID))
+	}
+}
+
+// campaignScenes tells presence which campaign scenes belong to.
+type campaignScenes struct {
+	server *server
+}
+
+func (cs *campaignScenes) InCampaign(campaignID, sceneID uuid.UUID) bool {
+	sc, err := cs.server.store.Scene().Find(sceneID)
+
+	return err == nil && sc.CampaignID == campaignID
+}
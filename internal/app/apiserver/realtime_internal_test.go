@@ -0,0 +1,284 @@
+package apiserver
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_AuthenticateUserWithTicket(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "player")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	c := testCampaign(t, st, u, nil)
+	ticket, _ := u.CreateTicket([]byte(testJWTKey))
+	token, _ := u.CreateJWT([]byte(testJWTKey))
+	wsPath := fmt.Sprintf("/private/campaigns/%s/ws", c.ID)
+
+	// A valid ticket gets through to the WebSocket handler, which turns down
+	// a request that isn't an upgrade.
+	testCases := []struct {
+		name         string
+		path         string
+		exceptedCode int
+	}{
+		{"valid ticket", wsPath + "?ticket=" + ticket, http.StatusBadRequest},
+		{"session token as ticket", wsPath + "?ticket=" + token, http.StatusUnauthorized},
+		{"invalid ticket", wsPath + "?ticket=nope", http.StatusUnauthorized},
+		{"ticket elsewhere", "/private/whoami?ticket=" + ticket, http.StatusUnauthorized},
+		{"ticket for another campaign route", fmt.Sprintf("/private/campaigns/%s?ticket=%s", c.ID, ticket), http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, nil, http.MethodGet, tc.path, nil)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+
+	t.Run("ticket as bearer token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/private/whoami", nil)
+		req.Header.Set("Authorization", "Bearer "+ticket)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestServer_HandleTicketsCreate(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "player")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	rec := testRequest(t, s, u, http.MethodPost, "/private/tickets", nil)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	res := map[string]string{}
+	json.NewDecoder(rec.Body).Decode(&res)
+	claims, err := s.parseJWT(res["ticket"])
+	assert.NoError(t, err)
+	assert.Equal(t, u.ID, claims.ID)
+	assert.Equal(t, model.TicketAudience, claims.Audience)
+}
+
+func TestServer_HandleCampaignsWS(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	ticket, _ := player.CreateTicket([]byte(testJWTKey))
+	url := fmt.Sprintf("ws%s/private/campaigns/%s/ws?ticket=%s", strings.TrimPrefix(srv.URL, "http"), c.ID, ticket)
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	assert.Eventually(t, func() bool {
+		return len(s.hub.Connected(c.ID)) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	secret, _ := realtime.NewEvent(realtime.EventRollCreated, c.ID, nil, "secret")
+	secret.Audience = &realtime.Audience{GMOnly: true}
+	s.hub.Publish(secret)
+	testRequest(t, s, gm, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/rolls", c.ID), map[string]string{"expression": "1d20"})
+
+	e := &realtime.Event{}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for e.Type != realtime.EventRollCreated {
+		if !assert.NoError(t, conn.ReadJSON(e)) {
+			return
+		}
+		assert.NotEqual(t, secret.ID, e.ID)
+	}
+	assert.Equal(t, gm.ID, *e.UserID)
+	assert.Nil(t, e.Audience)
+}
+
+func TestServer_HandleCampaignsWSNotAMember(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	stranger := testUser(t, st, "stranger")
+	c := testCampaign(t, st, gm, nil)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	ticket, _ := stranger.CreateTicket([]byte(testJWTKey))
+	url := fmt.Sprintf("ws%s/private/campaigns/%s/ws?ticket=%s", strings.TrimPrefix(srv.URL, "http"), c.ID, ticket)
+	_, res, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+}
+
+func TestServer_HandleCampaignsEvents(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	c := testCampaign(t, st, gm, nil)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	first, _ := realtime.NewEvent(realtime.EventRollCreated, c.ID, nil, 1)
+	s.hub.Publish(first)
+	missed, _ := realtime.NewEvent(realtime.EventRollCreated, c.ID, nil, 2)
+	s.hub.Publish(missed)
+
+	testCases := []struct {
+		name        string
+		lastEventID string
+		exceptedID  string
+		exceptedTyp string
+	}{
+		{"resume", first.ID, missed.ID, realtime.EventRollCreated},
+		{"resume from unknown event", "unknown", "", realtime.EventStreamReset},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ticket, _ := gm.CreateTicket([]byte(testJWTKey))
+			req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/private/campaigns/%s/events?ticket=%s", srv.URL, c.ID, ticket), nil)
+			req.Header.Set("Last-Event-ID", tc.lastEventID)
+			res, err := (&http.Client{Timeout: 5 * time.Second}).Do(req)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+			fields := readSSE(t, bufio.NewReader(res.Body))
+			assert.Equal(t, tc.exceptedID, fields["id"])
+			assert.Equal(t, tc.exceptedTyp, fields["event"])
+		})
+	}
+}
+
+func TestServer_HandleCampaignsEventsLive(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	c := testCampaign(t, st, gm, nil)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	token, _ := gm.CreateJWT([]byte(testJWTKey))
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/private/campaigns/%s/events", srv.URL, c.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	res, err := (&http.Client{Timeout: 5 * time.Second}).Do(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer res.Body.Close()
+
+	assert.Eventually(t, func() bool {
+		return len(s.hub.Connected(c.ID)) == 1
+	}, time.Second, 10*time.Millisecond)
+	testRequest(t, s, gm, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/rolls", c.ID), map[string]string{"expression": "1d20"})
+
+	body := bufio.NewReader(res.Body)
+	fields := readSSE(t, body)
+	for fields["event"] != realtime.EventRollCreated {
+		fields = readSSE(t, body)
+	}
+
+	e := &realtime.Event{}
+	assert.NoError(t, json.Unmarshal([]byte(fields["data"]), e))
+	assert.Equal(t, fields["id"], e.ID)
+}
+
+// readSSE reads the next Server-Sent Event that carries data, skipping
+// comments and the retry preamble.
+func readSSE(t *testing.T, r *bufio.Reader) map[string]string {
+	t.Helper()
+
+	fields := map[string]string{}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if _, ok := fields["data"]; ok {
+				return fields
+			}
+			continue
+		}
+
+		if k, v, ok := strings.Cut(line, ": "); ok && k != "" {
+			fields[k] = v
+		}
+	}
+}
+
+func TestServer_PublishAcrossInstances(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	c := testCampaign(t, st, gm, nil)
+
+	pubsub := realtime.NewLocalPubSub()
+	producer := newServer(st, pubsub, testJWTKey)
+	consumer := newServer(st, pubsub, testJWTKey)
+
+	m, _ := st.Campaign().FindMember(c.ID, gm.ID)
+	sub, _, _ := consumer.hub.Subscribe(m, "")
+	defer consumer.hub.Unsubscribe(sub)
+
+	rec := testRequest(t, producer, gm, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/rolls", c.ID), map[string]string{"expression": "1d20"})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	for {
+		select {
+		case e := <-sub.Events():
+			if e.Type == realtime.EventRollCreated {
+				return
+			}
+		case <-time.After(time.Second):
+			t.Fatal("event not received")
+		}
+	}
+}
+
+func TestServer_HandlePresenceIndex(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+
+	pubsub := realtime.NewLocalPubSub()
+	s := newServer(st, pubsub, testJWTKey)
+	other := newServer(st, pubsub, testJWTKey)
+
+	m, _ := st.Campaign().FindMember(c.ID, player.ID)
+	sub, _, _ := other.hub.Subscribe(m, "")
+	defer other.hub.Unsubscribe(sub)
+
+	rec := testRequest(t, s, gm, http.MethodGet, fmt.Sprintf("/private/campaigns/%s/presence", c.ID), nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	presence := []*realtime.UserPresence{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&presence))
+	if assert.Len(t, presence, 1) {
+		assert.Equal(t, player.ID, presence[0].UserID)
+		assert.Equal(t, realtime.StatusOnline, presence[0].Status)
+	}
+}
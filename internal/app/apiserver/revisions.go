@@ -0,0 +1,180 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/jsonpatch"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/gorilla/mux"
+)
+
+func (s *server) handleRevisionsIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, code, err := s.findCharacter(r)
+		if err != nil {
+			s.error(w, r, code, err)
+			return
+		}
+
+		revisions, err := s.store.CharacterRevision().FindAll(c.ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, revisions)
+	}
+}
+
+func (s *server) handleRevisionsGet() http.HandlerFunc {
+	type response struct {
+		*model.CharacterRevision
+		Snapshot json.RawMessage `json:"snapshot"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, code, err := s.findCharacter(r)
+		if err != nil {
+			s.error(w, r, code, err)
+			return
+		}
+
+		version, _ := strconv.Atoi(mux.Vars(r)["version"])
+		rev, err := s.store.CharacterRevision().Find(c.ID, version)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, model.ErrUnknownRevision)
+			return
+		}
+
+		snapshot, err := s.snapshotAt(c, version)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, &response{rev, snapshot})
+	}
+}
+
+// handleRevisionsDiff returns the patch between the from and to versions of
+// the character, to defaulting to the current one.
+func (s *server) handleRevisionsDiff() http.HandlerFunc {
+	type response struct {
+		From  int             `json:"from"`
+		To    int             `json:"to"`
+		Patch jsonpatch.Patch `json:"patch"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, code, err := s.findCharacter(r)
+		if err != nil {
+			s.error(w, r, code, err)
+			return
+		}
+
+		res := &response{To: c.Version}
+		res.From, err = strconv.Atoi(r.URL.Query().Get("from"))
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, model.ErrUnknownRevision)
+			return
+		}
+
+		if v := r.URL.Query().Get("to"); v != "" {
+			if res.To, err = strconv.Atoi(v); err != nil {
+				s.error(w, r, http.StatusBadRequest, model.ErrUnknownRevision)
+				return
+			}
+		}
+
+		from, err := s.snapshotAt(c, res.From)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		to, err := s.snapshotAt(c, res.To)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		res.Patch, err = jsonpatch.Diff(from, to)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, res)
+	}
+}
+
+// handleRevisionsRestore brings the character back to an earlier version.
+// The restore is itself recorded as a new revision, so it can be undone.
+func (s *server) handleRevisionsRestore() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, code, err := s.findCharacter(r)
+		if err != nil {
+			s.error(w, r, code, err)
+			return
+		}
+
+		member := r.Context().Value(ctxKeyMember).(*model.Member)
+		if !c.CanEdit(member) {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		if !matchesVersion(r, c) {
+			s.error(w, r, http.StatusPreconditionFailed, ErrVersionMismatch)
+			return
+		}
+
+		version, _ := strconv.Atoi(mux.Vars(r)["version"])
+		snapshot, err := s.snapshotAt(c, version)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		before, err := c.Snapshot()
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		// The owner at that version goes through the same checks as handing
+		// the character over in an update.
+		ownerID := c.OwnerID
+		if err := c.Restore(snapshot); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		ownerID, c.OwnerID = c.OwnerID, ownerID
+		if code, err := s.setOwner(member, c, ownerID); err != nil {
+			s.error(w, r, code, err)
+			return
+		}
+
+		if code, err := s.updateCharacter(c, before, member.UserID); err != nil {
+			s.error(w, r, code, err)
+			return
+		}
+
+		s.publish(realtime.EventCharacterUpdated, c.CampaignID, r, c, nil)
+		w.Header().Set("ETag", etag(c))
+		s.respond(w, r, http.StatusOK, c)
+	}
+}
+
+// snapshotAt rebuilds the character as it was at the given version.
+func (s *server) snapshotAt(c *model.Character, version int) ([]byte, error) {
+	revisions, err := s.store.CharacterRevision().FindAll(c.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.SnapshotAt(revisions, version)
+}
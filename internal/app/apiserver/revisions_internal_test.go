@@ -0,0 +1,222 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/jsonpatch"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandleCharactersUpdateIfMatch(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	c := testCampaign(t, st, gm, nil)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	rec := testRequest(t, s, gm, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/characters", c.ID), map[string]interface{}{"name": "Ireena"})
+	assert.Equal(t, `"1"`, rec.Header().Get("ETag"))
+	ch := &model.Character{}
+	json.NewDecoder(rec.Body).Decode(ch)
+	path := fmt.Sprintf("/private/campaigns/%s/characters/%s", c.ID, ch.ID)
+
+	patch := func(ifMatch string, name string) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(map[string]interface{}{"name": name})
+		req, _ := http.NewRequest(http.MethodPatch, path, bytes.NewReader(b))
+		token, _ := gm.CreateJWT([]byte(testJWTKey))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		req.Header.Set("If-Match", ifMatch)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	rec = patch(`"1"`, "Ireena Kolyana")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+	rec = patch(`"1"`, "Tatyana")
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = patch(`"1", "2"`, "Tatyana")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = patch("", "Ireena")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = testRequest(t, s, gm, http.MethodGet, path, nil)
+	assert.Equal(t, `"4"`, rec.Header().Get("ETag"))
+}
+
+func TestServer_HandleRevisions(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	alice := testUser(t, st, "alice")
+	bob := testUser(t, st, "bob")
+	c := testCampaign(t, st, gm, map[*model.User]string{alice: model.RolePlayer, bob: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	rec := testRequest(t, s, alice, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/characters", c.ID), map[string]interface{}{
+		"name": "Ismark",
+		"data": map[string]interface{}{"level": 3, "inventory": []interface{}{map[string]string{"name": "Rope"}, map[string]string{"name": "Torch"}}},
+	})
+	ch := &model.Character{}
+	json.NewDecoder(rec.Body).Decode(ch)
+	path := fmt.Sprintf("/private/campaigns/%s/characters/%s", c.ID, ch.ID)
+
+	testRequest(t, s, alice, http.MethodPatch, path, map[string]interface{}{"data": map[string]interface{}{"level": 3, "inventory": []interface{}{}}})
+	testRequest(t, s, gm, http.MethodPatch, path, map[string]interface{}{"owner_id": bob.ID})
+
+	rec = testRequest(t, s, alice, http.MethodGet, path+"/revisions", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	revisions := []*model.CharacterRevision{}
+	json.NewDecoder(rec.Body).Decode(&revisions)
+	if assert.Len(t, revisions, 3) {
+		assert.Equal(t, alice.ID, *revisions[1].AuthorID)
+		assert.Equal(t, jsonpatch.Patch{
+			{Op: jsonpatch.OpRemove, Path: "/data/inventory/1"},
+			{Op: jsonpatch.OpRemove, Path: "/data/inventory/0"},
+		}, revisions[1].Patch)
+		assert.Equal(t, gm.ID, *revisions[2].AuthorID)
+	}
+
+	rec = testRequest(t, s, alice, http.MethodGet, path+"/revisions/1", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	res := &struct {
+		Version  int             `json:"version"`
+		Snapshot json.RawMessage `json:"snapshot"`
+	}{}
+	json.NewDecoder(rec.Body).Decode(res)
+	assert.Equal(t, 1, res.Version)
+	assert.JSONEq(t, fmt.Sprintf(`{"name": "Ismark", "portrait": "", "owner_id": %q, "data": {"level": 3, "inventory": [{"name": "Rope"}, {"name": "Torch"}]}}`, alice.ID), string(res.Snapshot))
+
+	rec = testRequest(t, s, alice, http.MethodGet, path+"/revisions/4", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = testRequest(t, s, alice, http.MethodGet, path+"/revisions/diff?from=1", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	diff := &struct {
+		From  int             `json:"from"`
+		To    int             `json:"to"`
+		Patch jsonpatch.Patch `json:"patch"`
+	}{}
+	json.NewDecoder(rec.Body).Decode(diff)
+	assert.Equal(t, 3, diff.To)
+	assert.Len(t, diff.Patch, 3)
+
+	for _, q := range []string{"", "?from=one", "?from=1&to=9"} {
+		rec = testRequest(t, s, alice, http.MethodGet, path+"/revisions/diff"+q, nil)
+		assert.NotEqual(t, http.StatusOK, rec.Code, q)
+	}
+
+	// Alice no longer owns the character, and bob can't hand it back.
+	rec = testRequest(t, s, alice, http.MethodPost, path+"/revisions/1/restore", nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = testRequest(t, s, bob, http.MethodPost, path+"/revisions/1/restore", nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = testRequest(t, s, gm, http.MethodPost, path+"/revisions/1/restore", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"4"`, rec.Header().Get("ETag"))
+	restored := &model.Character{}
+	json.NewDecoder(rec.Body).Decode(restored)
+	assert.Equal(t, alice.ID, restored.OwnerID)
+	assert.JSONEq(t, `{"level": 3, "inventory": [{"name": "Rope"}, {"name": "Torch"}]}`, string(restored.Data))
+
+	rec = testRequest(t, s, alice, http.MethodGet, path+"/revisions/diff?from=1&to=4", nil)
+	json.NewDecoder(rec.Body).Decode(diff)
+	assert.Empty(t, diff.Patch)
+
+	rec = testRequest(t, s, alice, http.MethodPost, path+"/revisions/5/restore", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestServer_HandleRevisionsOfCharacter(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	alice := testUser(t, st, "alice")
+	stranger := testUser(t, st, "stranger")
+	c := testCampaign(t, st, gm, map[*model.User]string{alice: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	rec := testRequest(t, s, alice, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/characters", c.ID), map[string]interface{}{"name": "Ismark"})
+	ch := &model.Character{}
+	json.NewDecoder(rec.Body).Decode(ch)
+	testRequest(t, s, alice, http.MethodPatch, fmt.Sprintf("/private/campaigns/%s/characters/%s", c.ID, ch.ID), map[string]interface{}{"name": "Ismark the Lesser"})
+	path := fmt.Sprintf("/private/characters/%s/revisions", ch.ID)
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		path         string
+		exceptedCode int
+	}{
+		{
+			name:         "member",
+			user:         gm,
+			path:         path,
+			exceptedCode: http.StatusOK,
+		},
+		{
+			name:         "not a member",
+			user:         stranger,
+			path:         path,
+			exceptedCode: http.StatusForbidden,
+		},
+		{
+			name:         "unknown character",
+			user:         alice,
+			path:         fmt.Sprintf("/private/characters/%s/revisions", c.ID),
+			exceptedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, http.MethodGet, tc.path, nil)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+
+	rec = testRequest(t, s, alice, http.MethodGet, path, nil)
+	revisions := []*model.CharacterRevision{}
+	json.NewDecoder(rec.Body).Decode(&revisions)
+	assert.Len(t, revisions, 2)
+
+	rec = testRequest(t, s, alice, http.MethodGet, path+"/diff?from=1&to=2", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = testRequest(t, s, alice, http.MethodPost, path+"/1/restore", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	found, _ := st.Character().Find(ch.ID)
+	assert.Equal(t, "Ismark", found.Name)
+}
+
+func TestServer_HandleCharactersUpdate_Revision(t *testing.T) {
+	st := teststore.New()
+	alice := testUser(t, st, "alice")
+	c := testCampaign(t, st, alice, nil)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	rec := testRequest(t, s, alice, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/characters", c.ID), map[string]interface{}{"name": "Ismark"})
+	ch := &model.Character{}
+	json.NewDecoder(rec.Body).Decode(ch)
+
+	// A revision taking the next version keeps the update from being
+	// recorded, and so from being saved at all.
+	st.CharacterRevision().Create(&model.CharacterRevision{CharacterID: ch.ID, Version: 2, Patch: jsonpatch.Patch{}})
+	rec = testRequest(t, s, alice, http.MethodPatch, fmt.Sprintf("/private/campaigns/%s/characters/%s", c.ID, ch.ID), map[string]interface{}{"name": "Ismark the Lesser"})
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	found, _ := st.Character().Find(ch.ID)
+	assert.Equal(t, "Ismark", found.Name)
+	assert.Equal(t, 1, found.Version)
+}
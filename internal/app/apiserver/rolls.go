@@ -0,0 +1,170 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/dice"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/google/uuid"
+)
+
+const defaultRollsLimit = 50
+
+var (
+	ErrInvalidFilter = errors.New("invalid filter")
+)
+
+func (s *server) handleRollsCreate() http.HandlerFunc {
+	type request struct {
+		Expression  string     `json:"expression"`
+		CharacterID *uuid.UUID `json:"character_id"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !r.Context().Value(ctxKeyMember).(*model.Member).CanPlay() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		lookup, code, err := s.characterLookup(r, req.CharacterID)
+		if err != nil {
+			s.error(w, r, code, err)
+			return
+		}
+
+		e, err := dice.ParseWith(req.Expression, lookup)
+		if err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+		rs, err := s.campaignRuleset(r)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		res := s.roller.Evaluate(e)
+
+		roll := &model.Roll{
+			CampaignID:  r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID,
+			UserID:      r.Context().Value(ctxKeyUser).(*model.User).ID,
+			CharacterID: req.CharacterID,
+			Outcome:     rs.Interpret(res),
+		}
+		roll.Apply(res)
+		if err := s.store.Roll().Create(roll); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.publish(realtime.EventRollCreated, roll.CampaignID, r, roll, nil)
+
+		s.respond(w, r, http.StatusCreated, roll)
+	}
+}
+
+func (s *server) handleRollsIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f, err := parseRollFilter(r.URL.Query())
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		rolls, err := s.store.Roll().FindAll(r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID, f)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, rolls)
+	}
+}
+
+func (s *server) handleRollsStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f, err := parseRollFilter(r.URL.Query())
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		stats, err := s.store.Roll().Stats(r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID, f)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, stats)
+	}
+}
+
+// parseRollFilter reads user_id, character_id, die (either "20" or "d20"),
+// from, to, limit and offset query parameters. Times are RFC 3339.
+func parseRollFilter(q url.Values) (*model.RollFilter, error) {
+	f := &model.RollFilter{Limit: defaultRollsLimit}
+
+	if v := q.Get("user_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return nil, ErrInvalidFilter
+		}
+		f.UserID = &id
+	}
+
+	if v := q.Get("character_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return nil, ErrInvalidFilter
+		}
+		f.CharacterID = &id
+	}
+
+	ints := map[string]*int{"die": &f.Sides, "limit": &f.Limit, "offset": &f.Offset}
+	for key, dst := range ints {
+		v := q.Get(key)
+		if key == "die" {
+			v = strings.TrimPrefix(v, "d")
+		}
+		if v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, ErrInvalidFilter
+			}
+			*dst = n
+		}
+	}
+	// The store takes a limit of 0 for no limit at all.
+	if f.Limit < 1 {
+		return nil, ErrInvalidFilter
+	}
+
+	times := map[string]*time.Time{"from": &f.From, "to": &f.To}
+	for key, dst := range times {
+		if v := q.Get(key); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, ErrInvalidFilter
+			}
+			*dst = t
+		}
+	}
+
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
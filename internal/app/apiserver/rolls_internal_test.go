@@ -0,0 +1,162 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandleRollsCreate(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	spectator := testUser(t, st, "spectator")
+	c := testCampaign(t, st, gm, map[*model.User]string{spectator: model.RoleSpectator})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	path := fmt.Sprintf("/private/campaigns/%s/rolls", c.ID)
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"valid", gm, map[string]string{"expression": "1d20+5"}, http.StatusCreated},
+		{"invalid expression", gm, map[string]string{"expression": "1d"}, http.StatusUnprocessableEntity},
+		{"invalid payload", gm, "some invalid payload", http.StatusBadRequest},
+		{"spectator", spectator, map[string]string{"expression": "1d20"}, http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, http.MethodPost, path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_HandleRollsCreateWithReferences(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	c := testCampaign(t, st, gm, nil)
+	other := testCampaign(t, st, gm, nil)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	path := fmt.Sprintf("/private/campaigns/%s/rolls", c.ID)
+
+	ch := model.TestCharacter(t, c, gm)
+	st.Character().Create(ch)
+	foreign := model.TestCharacter(t, other, gm)
+	st.Character().Create(foreign)
+
+	rec := testRequest(t, s, gm, http.MethodPost, path, map[string]interface{}{"expression": "1d20+@str_mod+@abilities.str", "character_id": ch.ID})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	roll := &model.Roll{}
+	json.NewDecoder(rec.Body).Decode(roll)
+	assert.Equal(t, "1d20+2+14", roll.Expression)
+
+	testCases := []struct {
+		name    string
+		payload interface{}
+	}{
+		{"without character", map[string]interface{}{"expression": "1d20+@str_mod"}},
+		{"unknown reference", map[string]interface{}{"expression": "1d20+@luck", "character_id": ch.ID}},
+		{"character of another campaign", map[string]interface{}{"expression": "1d20", "character_id": foreign.ID}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, gm, http.MethodPost, path, tc.payload)
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		})
+	}
+}
+
+func TestServer_HandleRollsCreateWithOthersCharacter(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	other := testUser(t, st, "other")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer, other: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	path := fmt.Sprintf("/private/campaigns/%s/rolls", c.ID)
+
+	ch := model.TestCharacter(t, c, other)
+	st.Character().Create(ch)
+	payload := map[string]interface{}{"expression": "1d20+@str_mod", "character_id": ch.ID}
+
+	rec := testRequest(t, s, player, http.MethodPost, path, payload)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = testRequest(t, s, other, http.MethodPost, path, payload)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = testRequest(t, s, gm, http.MethodPost, path, payload)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestServer_HandleRollsIndex(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	path := fmt.Sprintf("/private/campaigns/%s/rolls", c.ID)
+
+	testRequest(t, s, gm, http.MethodPost, path, map[string]string{"expression": "1d20"})
+	testRequest(t, s, player, http.MethodPost, path, map[string]string{"expression": "2d6"})
+	testRequest(t, s, player, http.MethodPost, path, map[string]string{"expression": "1d20+1"})
+
+	testCases := []struct {
+		name         string
+		query        string
+		exceptedCode int
+		exceptedLen  int
+	}{
+		{"all", "", http.StatusOK, 3},
+		{"by user", "?user_id=" + player.ID.String(), http.StatusOK, 2},
+		{"by die", "?die=d20", http.StatusOK, 2},
+		{"paginated", "?limit=2&offset=2", http.StatusOK, 1},
+		{"invalid user", "?user_id=nope", http.StatusBadRequest, 0},
+		{"invalid time", "?from=yesterday", http.StatusBadRequest, 0},
+		{"limit too big", "?limit=1000", http.StatusBadRequest, 0},
+		{"no limit", "?limit=0", http.StatusBadRequest, 0},
+		{"limit as a die", "?limit=d2", http.StatusBadRequest, 0},
+		{"die without d", "?die=20", http.StatusOK, 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, gm, http.MethodGet, path+tc.query, nil)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+			if tc.exceptedCode == http.StatusOK {
+				rolls := []*model.Roll{}
+				assert.NoError(t, json.NewDecoder(rec.Body).Decode(&rolls))
+				assert.Len(t, rolls, tc.exceptedLen)
+			}
+		})
+	}
+}
+
+func TestServer_HandleRollsStats(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	c := testCampaign(t, st, gm, nil)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	path := fmt.Sprintf("/private/campaigns/%s/rolls", c.ID)
+
+	testRequest(t, s, gm, http.MethodPost, path, map[string]string{"expression": "4d6+1d20"})
+
+	rec := testRequest(t, s, gm, http.MethodGet, path+"/stats", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	stats := []*model.RollStats{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&stats))
+	assert.Len(t, stats, 2)
+	assert.Equal(t, 4, stats[0].Count)
+	assert.Equal(t, 1, stats[1].Count)
+}
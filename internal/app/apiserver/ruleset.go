@@ -0,0 +1,32 @@
+package apiserver
+
+import (
+	"net/http"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/ruleset"
+)
+
+// handleRulesetGet describes the ruleset the campaign plays by for clients
+// to offer its conditions.
+func (s *server) handleRulesetGet() http.HandlerFunc {
+	type response struct {
+		Name       string              `json:"name"`
+		Conditions []ruleset.Condition `json:"conditions"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		rs, err := s.campaignRuleset(r)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, &response{Name: rs.Name(), Conditions: rs.Conditions()})
+	}
+}
+
+// campaignRuleset returns the ruleset of the {id} campaign.
+func (s *server) campaignRuleset(r *http.Request) (ruleset.Ruleset, error) {
+	return r.Context().Value(ctxKeyCampaign).(*model.Campaign).Ruleset()
+}
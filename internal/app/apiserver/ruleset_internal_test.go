@@ -0,0 +1,82 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandleRulesetGet(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	stranger := testUser(t, st, "stranger")
+	c := testCampaign(t, st, gm, nil)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	path := fmt.Sprintf("/private/campaigns/%s/ruleset", c.ID)
+	rec := testRequest(t, s, gm, http.MethodGet, path, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	res := &struct {
+		Name       string `json:"name"`
+		Conditions []struct {
+			Name string `json:"name"`
+		} `json:"conditions"`
+	}{}
+	json.NewDecoder(rec.Body).Decode(res)
+	assert.Equal(t, "dnd5e", res.Name)
+	assert.Len(t, res.Conditions, 15)
+
+	rec = testRequest(t, s, stranger, http.MethodGet, path, nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestServer_HandleRollsOutcome(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	dnd := testCampaign(t, st, gm, nil)
+	generic := model.TestCampaign(t, gm)
+	generic.System = "generic"
+	st.Campaign().Create(generic)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	testCases := []struct {
+		name       string
+		campaign   *model.Campaign
+		expression string
+		exceptedOk bool
+	}{
+		{"advantage", dnd, "2d20kh1+3", true},
+		{"damage", dnd, "1d6", false},
+		{"generic", generic, "2d20kh1+3", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, gm, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/rolls", tc.campaign.ID), map[string]string{"expression": tc.expression})
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			roll := &model.Roll{}
+			json.NewDecoder(rec.Body).Decode(roll)
+			if tc.exceptedOk {
+				if assert.NotNil(t, roll.Outcome) {
+					assert.Equal(t, "advantage", roll.Outcome.Label)
+				}
+			} else {
+				assert.Nil(t, roll.Outcome)
+			}
+		})
+	}
+
+	rec := testRequest(t, s, gm, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/messages", dnd.ID), map[string]string{"body": "/roll 2d20kl1"})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	m := &model.ChatMessage{}
+	json.NewDecoder(rec.Body).Decode(m)
+	if assert.Len(t, m.Rolls, 1) && assert.NotNil(t, m.Rolls[0].Outcome) {
+		assert.Equal(t, "disadvantage", m.Rolls[0].Outcome.Label)
+	}
+}
@@ -0,0 +1,250 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/grid"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultGridSize     = 70
+	defaultGridDistance = 5
+)
+
+func (s *server) handleScenesCreate() http.HandlerFunc {
+	type request struct {
+		Name            string `json:"name"`
+		Background      string `json:"background"`
+		GridType        string `json:"grid_type"`
+		GridSize        int    `json:"grid_size"`
+		GridOffsetX     int    `json:"grid_offset_x"`
+		GridOffsetY     int    `json:"grid_offset_y"`
+		HexOrientation  string `json:"hex_orientation"`
+		Diagonals       string `json:"diagonals"`
+		GridDistance    int    `json:"grid_distance"`
+		Width           int    `json:"width"`
+		Height          int    `json:"height"`
+		FreeMovement    bool   `json:"free_movement"`
+		EnforceMovement bool   `json:"enforce_movement"`
+		FogOfWar        bool   `json:"fog_of_war"`
+		Dark            bool   `json:"dark"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{
+			GridType:       model.GridSquare,
+			GridSize:       defaultGridSize,
+			HexOrientation: grid.Pointy,
+			Diagonals:      grid.DiagonalsFive,
+			GridDistance:   defaultGridDistance,
+		}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		sc := &model.Scene{
+			CampaignID:      r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID,
+			Name:            req.Name,
+			Background:      req.Background,
+			GridType:        req.GridType,
+			GridSize:        req.GridSize,
+			GridOffsetX:     req.GridOffsetX,
+			GridOffsetY:     req.GridOffsetY,
+			HexOrientation:  req.HexOrientation,
+			Diagonals:       req.Diagonals,
+			GridDistance:    req.GridDistance,
+			Width:           req.Width,
+			Height:          req.Height,
+			FreeMovement:    req.FreeMovement,
+			EnforceMovement: req.EnforceMovement,
+			FogOfWar:        req.FogOfWar,
+			Dark:            req.Dark,
+		}
+		if err := s.store.Scene().Create(sc); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.publish(realtime.EventSceneCreated, sc.CampaignID, r, sc, nil)
+		s.respond(w, r, http.StatusCreated, sc)
+	}
+}
+
+func (s *server) handleScenesIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scenes, err := s.store.Scene().FindAll(r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, scenes)
+	}
+}
+
+// handleScenesGet returns the scene with the tokens the member can see, all
+// a client needs to draw the map.
+func (s *server) handleScenesGet() http.HandlerFunc {
+	type response struct {
+		*model.Scene
+		Tokens []*model.Token `json:"tokens"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, err := s.findScene(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		tokens, err := s.visibleTokens(r, sc)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, &response{sc, tokens})
+	}
+}
+
+func (s *server) handleScenesUpdate() http.HandlerFunc {
+	type request struct {
+		Name            *string `json:"name"`
+		Background      *string `json:"background"`
+		GridType        *string `json:"grid_type"`
+		GridSize        *int    `json:"grid_size"`
+		GridOffsetX     *int    `json:"grid_offset_x"`
+		GridOffsetY     *int    `json:"grid_offset_y"`
+		HexOrientation  *string `json:"hex_orientation"`
+		Diagonals       *string `json:"diagonals"`
+		GridDistance    *int    `json:"grid_distance"`
+		Width           *int    `json:"width"`
+		Height          *int    `json:"height"`
+		FreeMovement    *bool   `json:"free_movement"`
+		EnforceMovement *bool   `json:"enforce_movement"`
+		FogOfWar        *bool   `json:"fog_of_war"`
+		Dark            *bool   `json:"dark"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, err := s.findScene(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.Name != nil {
+			sc.Name = *req.Name
+		}
+		if req.Background != nil {
+			sc.Background = *req.Background
+		}
+		if req.GridType != nil {
+			sc.GridType = *req.GridType
+		}
+		if req.GridSize != nil {
+			sc.GridSize = *req.GridSize
+		}
+		if req.GridOffsetX != nil {
+			sc.GridOffsetX = *req.GridOffsetX
+		}
+		if req.GridOffsetY != nil {
+			sc.GridOffsetY = *req.GridOffsetY
+		}
+		if req.HexOrientation != nil {
+			sc.HexOrientation = *req.HexOrientation
+		}
+		if req.Diagonals != nil {
+			sc.Diagonals = *req.Diagonals
+		}
+		if req.GridDistance != nil {
+			sc.GridDistance = *req.GridDistance
+		}
+		if req.Width != nil {
+			sc.Width = *req.Width
+		}
+		if req.Height != nil {
+			sc.Height = *req.Height
+		}
+		if req.FreeMovement != nil {
+			sc.FreeMovement = *req.FreeMovement
+		}
+		if req.EnforceMovement != nil {
+			sc.EnforceMovement = *req.EnforceMovement
+		}
+		if req.FogOfWar != nil {
+			sc.FogOfWar = *req.FogOfWar
+		}
+		if req.Dark != nil {
+			sc.Dark = *req.Dark
+		}
+
+		if err := s.store.Scene().Update(sc); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.publish(realtime.EventSceneUpdated, sc.CampaignID, r, sc, nil)
+		s.respond(w, r, http.StatusOK, sc)
+	}
+}
+
+func (s *server) handleScenesDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, err := s.findScene(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		if err := s.store.Scene().Delete(sc.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.publish(realtime.EventSceneDeleted, sc.CampaignID, r, map[string]uuid.UUID{"id": sc.ID}, nil)
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// findScene loads the {sceneID} scene of the current campaign.
+func (s *server) findScene(r *http.Request) (*model.Scene, error) {
+	id, err := uuid.Parse(mux.Vars(r)["sceneID"])
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	sc, err := s.store.Scene().Find(id)
+	if err != nil || sc.CampaignID != r.Context().Value(ctxKeyCampaign).(*model.Campaign).ID {
+		return nil, ErrNotFound
+	}
+
+	return sc, nil
+}
@@ -0,0 +1,129 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandleScenesCreate(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	path := fmt.Sprintf("/private/campaigns/%s/scenes", c.ID)
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"gm", gm, map[string]interface{}{"name": "Death House", "width": 1400, "height": 1050}, http.StatusCreated},
+		{"hex grid", gm, map[string]interface{}{"name": "Barovia", "grid_type": "hex", "grid_size": 50, "width": 4000, "height": 3000}, http.StatusCreated},
+		{"flat hexes", gm, map[string]interface{}{"name": "Svalich Woods", "grid_type": "hex", "hex_orientation": "flat", "grid_distance": 10, "width": 4000, "height": 3000}, http.StatusCreated},
+		{"unknown diagonals", gm, map[string]interface{}{"name": "Death House", "diagonals": "5-15-5", "width": 1400, "height": 1050}, http.StatusUnprocessableEntity},
+		{"player", player, map[string]interface{}{"name": "My map", "width": 1400, "height": 1050}, http.StatusForbidden},
+		{"no dimensions", gm, map[string]interface{}{"name": "Death House"}, http.StatusUnprocessableEntity},
+		{"unknown grid", gm, map[string]interface{}{"name": "Death House", "grid_type": "triangle", "width": 1400, "height": 1050}, http.StatusUnprocessableEntity},
+		{"invalid payload", gm, "some invalid payload", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, http.MethodPost, path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_HandleScenesIndexAndGet(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	other := testCampaign(t, st, gm, nil)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	st.Scene().Create(sc)
+	st.Scene().Create(model.TestScene(t, other))
+	visible := model.TestToken(t, sc, player)
+	st.Token().Create(visible)
+	hidden := model.TestToken(t, sc, gm)
+	hidden.Layer = model.LayerGM
+	st.Token().Create(hidden)
+
+	rec := testRequest(t, s, player, http.MethodGet, fmt.Sprintf("/private/campaigns/%s/scenes", c.ID), nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	scenes := []*model.Scene{}
+	json.NewDecoder(rec.Body).Decode(&scenes)
+	assert.Len(t, scenes, 1)
+
+	res := &struct {
+		ID     string         `json:"id"`
+		Tokens []*model.Token `json:"tokens"`
+	}{}
+	rec = testRequest(t, s, player, http.MethodGet, fmt.Sprintf("/private/campaigns/%s/scenes/%s", c.ID, sc.ID), nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	json.NewDecoder(rec.Body).Decode(res)
+	assert.Equal(t, sc.ID.String(), res.ID)
+	if assert.Len(t, res.Tokens, 1) {
+		assert.Equal(t, visible.ID, res.Tokens[0].ID)
+	}
+
+	rec = testRequest(t, s, gm, http.MethodGet, fmt.Sprintf("/private/campaigns/%s/scenes/%s/tokens", c.ID, sc.ID), nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	tokens := []*model.Token{}
+	json.NewDecoder(rec.Body).Decode(&tokens)
+	assert.Len(t, tokens, 2)
+
+	rec = testRequest(t, s, gm, http.MethodGet, fmt.Sprintf("/private/campaigns/%s/scenes/%s", other.ID, sc.ID), nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestServer_HandleScenesUpdateAndDelete(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	st.Scene().Create(sc)
+	tok := model.TestToken(t, sc, player)
+	st.Token().Create(tok)
+	path := fmt.Sprintf("/private/campaigns/%s/scenes/%s", c.ID, sc.ID)
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		method       string
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"player edits", player, http.MethodPatch, map[string]interface{}{"free_movement": true}, http.StatusForbidden},
+		{"gm edits", gm, http.MethodPatch, map[string]interface{}{"grid_size": 50, "grid_offset_x": 10, "free_movement": true}, http.StatusOK},
+		{"gm breaks the grid", gm, http.MethodPatch, map[string]interface{}{"grid_offset_y": 60}, http.StatusUnprocessableEntity},
+		{"player deletes", player, http.MethodDelete, nil, http.StatusForbidden},
+		{"gm deletes", gm, http.MethodDelete, nil, http.StatusNoContent},
+		{"deleted", gm, http.MethodPatch, map[string]interface{}{"name": "Castle Ravenloft"}, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, tc.method, path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+
+	_, err := st.Token().Find(tok.ID)
+	assert.Error(t, err)
+}
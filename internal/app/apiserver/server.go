@@ -7,7 +7,12 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/bruhlord-s/virttable-api/internal/app/blob"
+	"github.com/bruhlord-s/virttable-api/internal/app/collab"
+	"github.com/bruhlord-s/virttable-api/internal/app/dice"
+	"github.com/bruhlord-s/virttable-api/internal/app/markdown"
 	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
 	"github.com/bruhlord-s/virttable-api/internal/app/store"
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
@@ -19,12 +24,23 @@ import (
 const (
 	ctxKeyUser ctxKey = iota
 	ctxKeyRequestID
+	ctxKeyCampaign
+	ctxKeyMember
+)
+
+// Routes browsers open without setting headers, which tickets authenticate.
+const (
+	routeWS = "ws"
+	routeEvents = "events"
+	routeCollab = "collab"
 )
 
 var (
 	ErrIncorrectEmailOrPassword = errors.New("incorrect email or password")
 	ErrNotAuthenticated = errors.New("not authenticated")
 	ErrUnprocessableAuthorizationHeader = errors.New("unprocessable authorization header")
+	ErrNotFound = errors.New("not found")
+	ErrForbidden = errors.New("forbidden")
 )
 
 type ctxKey int8
@@ -34,16 +50,40 @@ type server struct {
 	logger 	 *logrus.Logger
 	store 	 store.Store
 	jwtKey	 string
+	roller	 *dice.Roller
+	hub		 *realtime.Hub
+	pubsub	 realtime.PubSub
+	presence *realtime.Presence
+	collab	 *collab.Manager
+	markdown *markdown.Renderer
+	blobs	 blob.Storage
+	assets	 *assetLimits
+	admins	 map[string]bool
 }
 
-func newServer(store store.Store, jwtKey string) *server {
+func newServer(store store.Store, pubsub realtime.PubSub, jwtKey string) *server {
+	logger := logrus.New()
 	s := &server{
 		router: mux.NewRouter(),
-		logger: logrus.New(),
+		logger: logger,
 		store: store,
 		jwtKey: jwtKey,
+		roller: dice.NewRoller(nil),
+		hub: realtime.NewHub(logger),
+		pubsub: pubsub,
+		markdown: markdown.NewRenderer(),
+		blobs: blob.NewMemory(),
+		assets: newAssetLimits(NewConfig()),
+		admins: newAdmins(NewConfig()),
 	}
 
+	s.presence = realtime.NewPresence(s.hub, &campaignScenes{server: s}, pubsub, logger)
+	s.collab = collab.NewManager(&journalDocuments{server: s}, pubsub, logger, model.JournalBodyMaxLength)
+	s.pubsub.Subscribe(func(e *realtime.Event) {
+		if !s.presence.Receive(e) && !s.collab.Receive(e) {
+			s.hub.Publish(e)
+		}
+	})
 	s.configureRouter()
 	s.logger.Info("starting API server")
 
@@ -57,13 +97,138 @@ func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (s *server) configureRouter() {
 	s.router.Use(s.setRequestID)
 	s.router.Use(s.setContentType)
-	s.router.Use(handlers.CORS(handlers.AllowedOrigins([]string{"*"})))
+	s.router.Use(handlers.CORS(handlers.AllowedOrigins([]string{"*"}), handlers.ExposedHeaders([]string{"ETag", "Upload-Offset"})))
 	s.router.HandleFunc("/users", s.handleUsersCreate()).Methods("POST")
 	s.router.HandleFunc("/sessions", s.handleSessionsCreate()).Methods("POST")
+	s.router.HandleFunc("/assets/{assetID}", s.handleAssetsDownload(false)).Methods("GET")
+	s.router.HandleFunc("/assets/{assetID}/thumbnail", s.handleAssetsDownload(true)).Methods("GET")
 
 	private := s.router.PathPrefix("/private").Subrouter()
 	private.Use(s.authenticateUser)
 	private.HandleFunc("/whoami", s.handleWhoami()).Methods("GET")
+	private.HandleFunc("/me/usage", s.handleUsageGet()).Methods("GET")
+	private.HandleFunc("/tickets", s.handleTicketsCreate()).Methods("POST")
+	private.HandleFunc("/campaigns", s.handleCampaignsCreate()).Methods("POST")
+	private.HandleFunc("/campaigns/import", s.handleCampaignsImport()).Methods("POST")
+	private.HandleFunc("/campaigns/import/{source:roll20|foundry}", s.handleCampaignsImportFrom()).Methods("POST")
+	private.HandleFunc("/assets", s.handleAssetsCreate()).Methods("POST")
+	private.HandleFunc("/assets", s.handleAssetsIndex()).Methods("GET")
+	private.HandleFunc("/assets/{assetID}", s.handleAssetsGet()).Methods("GET")
+	private.HandleFunc("/assets/{assetID}", s.handleAssetsUpdate()).Methods("PATCH")
+	private.HandleFunc("/assets/{assetID}", s.handleAssetsDelete()).Methods("DELETE")
+	private.HandleFunc("/uploads", s.handleUploadsCreate()).Methods("POST")
+	private.HandleFunc("/uploads/{uploadID}", s.handleUploadsGet()).Methods("GET")
+	private.HandleFunc("/uploads/{uploadID}", s.handleUploadsUpdate()).Methods("PATCH")
+	private.HandleFunc("/uploads/{uploadID}", s.handleUploadsDelete()).Methods("DELETE")
+	private.HandleFunc("/folders", s.handleFoldersCreate()).Methods("POST")
+	private.HandleFunc("/folders", s.handleFoldersIndex()).Methods("GET")
+	private.HandleFunc("/folders/{folderID}", s.handleFoldersUpdate()).Methods("PATCH")
+	private.HandleFunc("/folders/{folderID}", s.handleFoldersDelete()).Methods("DELETE")
+	private.HandleFunc("/compendium", s.handleCompendiumIndex()).Methods("GET")
+	private.HandleFunc("/compendium/{entryID}", s.handleCompendiumGet()).Methods("GET")
+	private.HandleFunc("/packs", s.handlePacksCreate()).Methods("POST")
+	private.HandleFunc("/packs", s.handlePacksIndex()).Methods("GET")
+	private.HandleFunc("/packs/{packID}", s.handlePacksGet()).Methods("GET")
+	private.HandleFunc("/packs/{packID}", s.handlePacksUpdate()).Methods("PATCH")
+	private.HandleFunc("/packs/{packID}/versions", s.handlePackVersionsCreate()).Methods("POST")
+	private.HandleFunc("/packs/{packID}/versions", s.handlePackVersionsIndex()).Methods("GET")
+	private.HandleFunc("/packs/{packID}/versions/{version:[0-9]+}", s.handlePackVersionsGet()).Methods("GET")
+	private.HandleFunc("/packs/{packID}/diff", s.handlePackVersionsDiff()).Methods("GET")
+
+	admin := private.PathPrefix("/admin").Subrouter()
+	admin.Use(s.authorizeAdmin)
+	admin.HandleFunc("/users/{userID}/usage", s.handleAdminUsageGet()).Methods("GET")
+	admin.HandleFunc("/users/{userID}/quota", s.handleAdminQuotaUpdate()).Methods("PUT")
+
+	character := private.PathPrefix("/characters/{characterID}").Subrouter()
+	character.Use(s.authorizeCharacter)
+	character.HandleFunc("/revisions", s.handleRevisionsIndex()).Methods("GET")
+	character.HandleFunc("/revisions/diff", s.handleRevisionsDiff()).Methods("GET")
+	character.HandleFunc("/revisions/{version:[0-9]+}", s.handleRevisionsGet()).Methods("GET")
+	character.HandleFunc("/revisions/{version:[0-9]+}/restore", s.handleRevisionsRestore()).Methods("POST")
+
+	campaign := private.PathPrefix("/campaigns/{id}").Subrouter()
+	campaign.Use(s.authorizeMember)
+	campaign.HandleFunc("", s.handleCampaignsGet()).Methods("GET")
+	campaign.HandleFunc("/ruleset", s.handleRulesetGet()).Methods("GET")
+	campaign.HandleFunc("/export", s.handleCampaignsExport()).Methods("GET")
+	campaign.HandleFunc("/members", s.handleMembersCreate()).Methods("POST")
+	campaign.HandleFunc("/rolls", s.handleRollsCreate()).Methods("POST")
+	campaign.HandleFunc("/rolls", s.handleRollsIndex()).Methods("GET")
+	campaign.HandleFunc("/rolls/stats", s.handleRollsStats()).Methods("GET")
+	campaign.HandleFunc("/messages", s.handleMessagesCreate()).Methods("POST")
+	campaign.HandleFunc("/messages", s.handleMessagesIndex()).Methods("GET")
+	campaign.HandleFunc("/messages/{messageID}", s.handleMessagesUpdate()).Methods("PATCH")
+	campaign.HandleFunc("/messages/{messageID}", s.handleMessagesDelete()).Methods("DELETE")
+	campaign.HandleFunc("/characters", s.handleCharactersCreate()).Methods("POST")
+	campaign.HandleFunc("/characters", s.handleCharactersIndex()).Methods("GET")
+	campaign.HandleFunc("/characters/{characterID}", s.handleCharactersGet()).Methods("GET")
+	campaign.HandleFunc("/characters/{characterID}", s.handleCharactersUpdate()).Methods("PATCH")
+	campaign.HandleFunc("/characters/{characterID}", s.handleCharactersDelete()).Methods("DELETE")
+	campaign.HandleFunc("/characters/{characterID}/revisions", s.handleRevisionsIndex()).Methods("GET")
+	campaign.HandleFunc("/characters/{characterID}/revisions/diff", s.handleRevisionsDiff()).Methods("GET")
+	campaign.HandleFunc("/characters/{characterID}/revisions/{version:[0-9]+}", s.handleRevisionsGet()).Methods("GET")
+	campaign.HandleFunc("/characters/{characterID}/revisions/{version:[0-9]+}/restore", s.handleRevisionsRestore()).Methods("POST")
+	campaign.HandleFunc("/scenes", s.handleScenesCreate()).Methods("POST")
+	campaign.HandleFunc("/scenes", s.handleScenesIndex()).Methods("GET")
+	campaign.HandleFunc("/scenes/{sceneID}", s.handleScenesGet()).Methods("GET")
+	campaign.HandleFunc("/scenes/{sceneID}", s.handleScenesUpdate()).Methods("PATCH")
+	campaign.HandleFunc("/scenes/{sceneID}", s.handleScenesDelete()).Methods("DELETE")
+	campaign.HandleFunc("/scenes/{sceneID}/measure", s.handleScenesMeasure()).Methods("POST")
+	campaign.HandleFunc("/scenes/{sceneID}/template", s.handleScenesTemplate()).Methods("POST")
+	campaign.HandleFunc("/scenes/{sceneID}/tokens", s.handleTokensCreate()).Methods("POST")
+	campaign.HandleFunc("/scenes/{sceneID}/tokens", s.handleTokensIndex()).Methods("GET")
+	campaign.HandleFunc("/scenes/{sceneID}/tokens/{tokenID}", s.handleTokensUpdate()).Methods("PATCH")
+	campaign.HandleFunc("/scenes/{sceneID}/tokens/{tokenID}", s.handleTokensDelete()).Methods("DELETE")
+	campaign.HandleFunc("/scenes/{sceneID}/tokens/{tokenID}/move", s.handleTokensMove()).Methods("POST")
+	campaign.HandleFunc("/scenes/{sceneID}/walls", s.handleWallsCreate()).Methods("POST")
+	campaign.HandleFunc("/scenes/{sceneID}/walls", s.handleWallsIndex()).Methods("GET")
+	campaign.HandleFunc("/scenes/{sceneID}/walls/{wallID}", s.handleWallsUpdate()).Methods("PATCH")
+	campaign.HandleFunc("/scenes/{sceneID}/walls/{wallID}", s.handleWallsDelete()).Methods("DELETE")
+	campaign.HandleFunc("/scenes/{sceneID}/lights", s.handleLightsCreate()).Methods("POST")
+	campaign.HandleFunc("/scenes/{sceneID}/lights", s.handleLightsIndex()).Methods("GET")
+	campaign.HandleFunc("/scenes/{sceneID}/lights/{lightID}", s.handleLightsUpdate()).Methods("PATCH")
+	campaign.HandleFunc("/scenes/{sceneID}/lights/{lightID}", s.handleLightsDelete()).Methods("DELETE")
+	campaign.HandleFunc("/scenes/{sceneID}/vision", s.handleVisionGet()).Methods("GET")
+	campaign.HandleFunc("/scenes/{sceneID}/exploration", s.handleExplorationDelete()).Methods("DELETE")
+	campaign.HandleFunc("/scenes/{sceneID}/replay", s.handleScenesReplay()).Methods("GET")
+	campaign.HandleFunc("/actions", s.handleActionsIndex()).Methods("GET")
+	campaign.HandleFunc("/actions/undo", s.handleActionsRevert(model.ActionUndo)).Methods("POST")
+	campaign.HandleFunc("/actions/redo", s.handleActionsRevert(model.ActionRedo)).Methods("POST")
+	campaign.HandleFunc("/combats", s.handleCombatsCreate()).Methods("POST")
+	campaign.HandleFunc("/combats", s.handleCombatsIndex()).Methods("GET")
+	campaign.HandleFunc("/combats/{combatID}", s.handleCombatsGet()).Methods("GET")
+	campaign.HandleFunc("/combats/{combatID}", s.handleCombatsUpdate()).Methods("PATCH")
+	campaign.HandleFunc("/combats/{combatID}", s.handleCombatsDelete()).Methods("DELETE")
+	campaign.HandleFunc("/combats/{combatID}/initiative", s.handleCombatsInitiative()).Methods("POST")
+	campaign.HandleFunc("/combats/{combatID}/start", s.handleCombatsStart()).Methods("POST")
+	campaign.HandleFunc("/combats/{combatID}/next", s.handleCombatsNext()).Methods("POST")
+	campaign.HandleFunc("/combats/{combatID}/end", s.handleCombatsEnd()).Methods("POST")
+	campaign.HandleFunc("/combats/{combatID}/combatants", s.handleCombatantsCreate()).Methods("POST")
+	campaign.HandleFunc("/combats/{combatID}/combatants/{combatantID}", s.handleCombatantsUpdate()).Methods("PATCH")
+	campaign.HandleFunc("/combats/{combatID}/combatants/{combatantID}", s.handleCombatantsDelete()).Methods("DELETE")
+	campaign.HandleFunc("/combats/{combatID}/combatants/{combatantID}/initiative", s.handleCombatantsInitiative()).Methods("POST")
+	campaign.HandleFunc("/combats/{combatID}/combatants/{combatantID}/damage", s.handleCombatantsDamage()).Methods("POST")
+	campaign.HandleFunc("/combats/{combatID}/combatants/{combatantID}/heal", s.handleCombatantsHeal()).Methods("POST")
+	campaign.HandleFunc("/journal/folders", s.handleJournalFoldersCreate()).Methods("POST")
+	campaign.HandleFunc("/journal/folders", s.handleJournalFoldersIndex()).Methods("GET")
+	campaign.HandleFunc("/journal/folders/{folderID}", s.handleJournalFoldersUpdate()).Methods("PATCH")
+	campaign.HandleFunc("/journal/folders/{folderID}", s.handleJournalFoldersDelete()).Methods("DELETE")
+	campaign.HandleFunc("/journal", s.handleJournalCreate()).Methods("POST")
+	campaign.HandleFunc("/journal", s.handleJournalIndex()).Methods("GET")
+	campaign.HandleFunc("/journal/{entryID}", s.handleJournalGet()).Methods("GET")
+	campaign.HandleFunc("/journal/{entryID}", s.handleJournalUpdate()).Methods("PATCH")
+	campaign.HandleFunc("/journal/{entryID}", s.handleJournalDelete()).Methods("DELETE")
+	campaign.HandleFunc("/journal/{entryID}/show", s.handleJournalShow()).Methods("POST")
+	campaign.HandleFunc("/journal/{entryID}/collab", s.handleJournalCollab()).Methods("GET").Name(routeCollab)
+	campaign.HandleFunc("/compendium", s.handleCampaignCompendiumIndex()).Methods("GET")
+	campaign.HandleFunc("/packs", s.handleCampaignPacksIndex()).Methods("GET")
+	campaign.HandleFunc("/packs/{packID}", s.handleCampaignPacksSave()).Methods("PUT")
+	campaign.HandleFunc("/packs/{packID}", s.handleCampaignPacksDelete()).Methods("DELETE")
+	campaign.HandleFunc("/packs/{packID}/upgrade", s.handleCampaignPacksUpgrade()).Methods("GET")
+	campaign.HandleFunc("/ws", s.handleCampaignsWS()).Methods("GET").Name(routeWS)
+	campaign.HandleFunc("/events", s.handleCampaignsEvents()).Methods("GET").Name(routeEvents)
+	campaign.HandleFunc("/presence", s.handlePresenceIndex()).Methods("GET")
 }
 
 func (s *server) setContentType(next http.Handler) http.Handler {
@@ -84,21 +249,42 @@ func (s *server) setRequestID(next http.Handler) http.Handler {
 func (s *server) authenticateUser(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		h := r.Header.Get("Authorization")
-		if h == "" {
-			s.error(w, r, http.StatusUnauthorized, ErrNotAuthenticated)
-			return
+		ticket := ""
+		if acceptsTicket(r) {
+			ticket = r.URL.Query().Get("ticket")
 		}
-
-		split := strings.Split(h, " ")
-		if len(split) != 2 {
-			s.error(w, r, http.StatusBadRequest, ErrUnprocessableAuthorizationHeader)
+		if h == "" && ticket == "" {
+			s.error(w, r, http.StatusUnauthorized, ErrNotAuthenticated)
 			return
 		}
 
-		claims, err := s.parseJWT(split[1])
-		if err != nil {
-			s.error(w, r, http.StatusBadRequest, err)
-			return
+		var claims *model.Claims
+		if h != "" {
+			split := strings.Split(h, " ")
+			if len(split) != 2 {
+				s.error(w, r, http.StatusBadRequest, ErrUnprocessableAuthorizationHeader)
+				return
+			}
+
+			c, err := s.parseJWT(split[1])
+			if err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+			// Tickets travel in URLs, where they are easily leaked, so they
+			// are good for nothing else.
+			if c.VerifyAudience(model.TicketAudience, true) {
+				s.error(w, r, http.StatusUnauthorized, ErrNotAuthenticated)
+				return
+			}
+			claims = c
+		} else {
+			c, err := s.parseJWT(ticket)
+			if err != nil || !c.VerifyAudience(model.TicketAudience, true) {
+				s.error(w, r, http.StatusUnauthorized, ErrNotAuthenticated)
+				return
+			}
+			claims = c
 		}
 
 		u, err := s.store.User().Find(claims.ID)
@@ -111,6 +297,22 @@ func (s *server) authenticateUser(next http.Handler) http.Handler {
 	})
 }
 
+// acceptsTicket reports whether the request is for one of the routes tickets
+// are good for.
+func acceptsTicket(r *http.Request) bool {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return false
+	}
+
+	switch route.GetName() {
+	case routeWS, routeEvents, routeCollab:
+		return true
+	}
+
+	return false
+}
+
 func (s *server) parseJWT(t string) (claims *model.Claims, err error) {
 	token, err := jwt.ParseWithClaims(t, &model.Claims{}, func(t *jwt.Token) (interface{}, error) {
 		return []byte(s.jwtKey), nil
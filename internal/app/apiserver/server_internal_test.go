@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
 	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -58,7 +59,7 @@ func TestServer_AuthenticateUser(t *testing.T) {
 		},
 	}
 
-	s := newServer(store, jwtKey)
+	s := newServer(store, realtime.NewLocalPubSub(), jwtKey)
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
@@ -75,7 +76,7 @@ func TestServer_AuthenticateUser(t *testing.T) {
 }
 
 func TestServer_HandleUsersCreate(t *testing.T) {
-	s := newServer(teststore.New(), "secret_key")
+	s := newServer(teststore.New(), realtime.NewLocalPubSub(), "secret_key")
 
 	testCases := []struct {
 		name 		 string
@@ -122,7 +123,7 @@ func TestServer_HandleSessionCreate(t *testing.T) {
 	store := teststore.New()
 	store.User().Create(u)
 
-	s := newServer(store, "secret_key")
+	s := newServer(store, realtime.NewLocalPubSub(), "secret_key")
 
 	testCases := []struct {
 		name 		 string
@@ -0,0 +1,65 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+)
+
+const testJWTKey = "secret_key"
+
+func testUser(t *testing.T, st store.Store, username string) *model.User {
+	t.Helper()
+
+	u := model.TestUser(t)
+	u.Username = username
+	u.Email = username + "@test.com"
+	if err := st.User().Create(u); err != nil {
+		t.Fatal(err)
+	}
+
+	return u
+}
+
+func testCampaign(t *testing.T, st store.Store, gm *model.User, members map[*model.User]string) *model.Campaign {
+	t.Helper()
+
+	c := model.TestCampaign(t, gm)
+	if err := st.Campaign().Create(c); err != nil {
+		t.Fatal(err)
+	}
+
+	for u, role := range members {
+		if err := st.Campaign().AddMember(&model.Member{CampaignID: c.ID, UserID: u.ID, Role: role}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return c
+}
+
+func testRequest(t *testing.T, s *server, u *model.User, method string, path string, payload interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	b := &bytes.Buffer{}
+	if payload != nil {
+		json.NewEncoder(b).Encode(payload)
+	}
+
+	req, _ := http.NewRequest(method, path, b)
+	if u != nil {
+		token, _ := u.CreateJWT([]byte(testJWTKey))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	return rec
+}
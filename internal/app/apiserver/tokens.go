@@ -0,0 +1,554 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/geometry"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+var (
+	ErrPositionRequired = errors.New("x and y are required")
+)
+
+// nullUUID tells a field missing from a PATCH request apart from an explicit
+// null, which clears it.
+type nullUUID struct {
+	Set   bool
+	Value *uuid.UUID
+}
+
+func (n *nullUUID) UnmarshalJSON(b []byte) error {
+	n.Set = true
+
+	return json.Unmarshal(b, &n.Value)
+}
+
+func (s *server) handleTokensCreate() http.HandlerFunc {
+	type request struct {
+		Name        string     `json:"name"`
+		Image       string     `json:"image"`
+		X           int        `json:"x"`
+		Y           int        `json:"y"`
+		Size        float64    `json:"size"`
+		Rotation    int        `json:"rotation"`
+		Layer       string     `json:"layer"`
+		Vision      int        `json:"vision"`
+		Speed       int        `json:"speed"`
+		OwnerID     *uuid.UUID `json:"owner_id"`
+		CharacterID *uuid.UUID `json:"character_id"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, err := s.findScene(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		member := r.Context().Value(ctxKeyMember).(*model.Member)
+		if !member.CanPlay() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{Size: 1, Layer: model.LayerTokens}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		t := &model.Token{
+			SceneID: sc.ID,
+			Name:    req.Name,
+			Image:   req.Image,
+			X:       req.X,
+			Y:       req.Y,
+			Size:    req.Size,
+			Layer:   req.Layer,
+			Vision:  req.Vision,
+			Speed:   req.Speed,
+		}
+		t.Rotate(req.Rotation)
+
+		// Players place tokens for themselves; GMs anywhere, for anyone and
+		// with any vision and speed.
+		if !member.IsGM() {
+			if t.Layer != model.LayerTokens || t.Vision != 0 || t.Speed != 0 || req.OwnerID != nil && *req.OwnerID != member.UserID {
+				s.error(w, r, http.StatusForbidden, ErrForbidden)
+				return
+			}
+			t.OwnerID = &member.UserID
+		} else if req.OwnerID != nil {
+			if code, err := s.setTokenOwner(member, sc, t, req.OwnerID); err != nil {
+				s.error(w, r, code, err)
+				return
+			}
+		}
+
+		if code, err := s.linkCharacter(member, sc, t, req.CharacterID); err != nil {
+			s.error(w, r, code, err)
+			return
+		}
+
+		if !sc.Contains(t.X, t.Y) {
+			s.error(w, r, http.StatusUnprocessableEntity, model.ErrOutOfBounds)
+			return
+		}
+
+		if err := s.store.Transaction(func(tx store.Store) error {
+			if err := tx.Token().Create(t); err != nil {
+				return err
+			}
+
+			return logAction(tx, r, sc, model.TargetToken, t.ID, nil, t)
+		}); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.exploreWith(r, sc, t)
+		if err := s.publishToken(r, sc, realtime.EventTokenCreated, t, t); err != nil {
+			s.logger.Error(err.Error())
+		}
+		s.respond(w, r, http.StatusCreated, t)
+	}
+}
+
+func (s *server) handleTokensIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, err := s.findScene(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		tokens, err := s.visibleTokens(r, sc)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, tokens)
+	}
+}
+
+func (s *server) handleTokensUpdate() http.HandlerFunc {
+	type request struct {
+		Name        *string  `json:"name"`
+		Image       *string  `json:"image"`
+		Size        *float64 `json:"size"`
+		Rotation    *int     `json:"rotation"`
+		Layer       *string  `json:"layer"`
+		Vision      *int     `json:"vision"`
+		Speed       *int     `json:"speed"`
+		Moved       *float64 `json:"moved"`
+		OwnerID     nullUUID `json:"owner_id"`
+		CharacterID nullUUID `json:"character_id"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, t, err := s.findToken(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		member := r.Context().Value(ctxKeyMember).(*model.Member)
+		if !t.CanEdit(member) {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		before := *t
+		if req.Layer != nil && *req.Layer != t.Layer {
+			if !member.IsGM() {
+				s.error(w, r, http.StatusForbidden, ErrForbidden)
+				return
+			}
+			t.Layer = *req.Layer
+		}
+		if req.Vision != nil && *req.Vision != t.Vision {
+			if !member.IsGM() {
+				s.error(w, r, http.StatusForbidden, ErrForbidden)
+				return
+			}
+			t.Vision = *req.Vision
+		}
+		if req.Speed != nil && *req.Speed != t.Speed || req.Moved != nil && *req.Moved != t.Moved {
+			if !member.IsGM() {
+				s.error(w, r, http.StatusForbidden, ErrForbidden)
+				return
+			}
+			if req.Speed != nil {
+				t.Speed = *req.Speed
+			}
+			if req.Moved != nil {
+				t.Moved = *req.Moved
+			}
+		}
+		if req.OwnerID.Set {
+			if code, err := s.setTokenOwner(member, sc, t, req.OwnerID.Value); err != nil {
+				s.error(w, r, code, err)
+				return
+			}
+		}
+		if req.CharacterID.Set {
+			if code, err := s.linkCharacter(member, sc, t, req.CharacterID.Value); err != nil {
+				s.error(w, r, code, err)
+				return
+			}
+		}
+		if req.Name != nil {
+			t.Name = *req.Name
+		}
+		if req.Image != nil {
+			t.Image = *req.Image
+		}
+		if req.Size != nil {
+			t.Size = *req.Size
+		}
+		if req.Rotation != nil {
+			t.Rotate(*req.Rotation)
+		}
+
+		if err := s.store.Transaction(func(tx store.Store) error {
+			if err := tx.Token().Update(t); err != nil {
+				return err
+			}
+
+			return logAction(tx, r, sc, model.TargetToken, t.ID, &before, t)
+		}); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := s.publishTokenUpdate(r, sc, &before, t); err != nil {
+			s.logger.Error(err.Error())
+		}
+		s.exploreWith(r, sc, t)
+		s.respond(w, r, http.StatusOK, t)
+	}
+}
+
+// handleTokensMove moves the token to a new position on its scene, through
+// the waypoints of path if given. On scenes enforcing movement, players'
+// moves are checked against walls and the token's speed, and every move
+// counts towards the distance the token moved. Moves are broadcast as a
+// small token.moved event as they happen a lot.
+func (s *server) handleTokensMove() http.HandlerFunc {
+	type request struct {
+		X    *int             `json:"x"`
+		Y    *int             `json:"y"`
+		Path []geometry.Point `json:"path"`
+	}
+
+	type event struct {
+		ID      uuid.UUID `json:"id"`
+		SceneID uuid.UUID `json:"scene_id"`
+		X       int       `json:"x"`
+		Y       int       `json:"y"`
+		Moved   float64   `json:"moved"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, t, err := s.findToken(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		member := r.Context().Value(ctxKeyMember).(*model.Member)
+		if !t.CanMove(member, sc) {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.X == nil || req.Y == nil {
+			s.error(w, r, http.StatusUnprocessableEntity, ErrPositionRequired)
+			return
+		}
+
+		if !sc.Contains(*req.X, *req.Y) {
+			s.error(w, r, http.StatusUnprocessableEntity, model.ErrOutOfBounds)
+			return
+		}
+
+		path := append(append([]geometry.Point{t.Position()}, req.Path...), geometry.Point{X: float64(*req.X), Y: float64(*req.Y)})
+		walls := []*model.Wall{}
+		if sc.EnforceMovement {
+			if walls, err = s.store.Wall().FindAll(sc.ID); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		distance, err := sc.CheckMove(t, path, walls)
+		if err != nil && !member.IsGM() {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		before := *t
+		t.X, t.Y = *req.X, *req.Y
+		if sc.EnforceMovement {
+			t.Moved += distance
+		}
+		if err := s.store.Transaction(func(tx store.Store) error {
+			if err := tx.Token().Update(t); err != nil {
+				return err
+			}
+
+			return logAction(tx, r, sc, model.TargetToken, t.ID, &before, t)
+		}); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.exploreWith(r, sc, t)
+		if err := s.publishToken(r, sc, realtime.EventTokenMoved, t, &event{t.ID, t.SceneID, t.X, t.Y, t.Moved}); err != nil {
+			s.logger.Error(err.Error())
+		}
+		s.respond(w, r, http.StatusOK, t)
+	}
+}
+
+func (s *server) handleTokensDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, t, err := s.findToken(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !t.CanEdit(r.Context().Value(ctxKeyMember).(*model.Member)) {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		if err := s.store.Transaction(func(tx store.Store) error {
+			if err := tx.Token().Delete(t.ID); err != nil {
+				return err
+			}
+
+			return logAction(tx, r, sc, model.TargetToken, t.ID, t, nil)
+		}); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.publish(realtime.EventTokenDeleted, sc.CampaignID, r, map[string]uuid.UUID{"id": t.ID, "scene_id": t.SceneID}, tokenAudience(t))
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// findToken loads the {tokenID} token of the {sceneID} scene. Tokens hidden
+// from the member are reported as missing.
+func (s *server) findToken(r *http.Request) (*model.Scene, *model.Token, error) {
+	sc, err := s.findScene(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id, err := uuid.Parse(mux.Vars(r)["tokenID"])
+	if err != nil {
+		return nil, nil, ErrNotFound
+	}
+
+	t, err := s.store.Token().Find(id)
+	if err != nil || t.SceneID != sc.ID || !t.VisibleTo(r.Context().Value(ctxKeyMember).(*model.Member)) {
+		return nil, nil, ErrNotFound
+	}
+
+	return sc, t, nil
+}
+
+// visibleTokens returns the tokens of the scene the member sees. On scenes
+// with fog of war players only see their own tokens and those in sight.
+func (s *server) visibleTokens(r *http.Request, sc *model.Scene) ([]*model.Token, error) {
+	tokens, err := s.store.Token().FindAll(sc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	member := r.Context().Value(ctxKeyMember).(*model.Member)
+	var v *model.Vision
+	if sc.FogOfWar && !member.IsGM() {
+		if v, err = s.explore(sc, member.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	visible := []*model.Token{}
+	for _, t := range tokens {
+		if t.VisibleTo(member) && (v == nil || t.IsOwnedBy(member.UserID) || v.Sees(t)) {
+			visible = append(visible, t)
+		}
+	}
+
+	return visible, nil
+}
+
+// setTokenOwner gives the token to another member or, with a nil owner, to
+// nobody. Only GMs hand tokens over.
+func (s *server) setTokenOwner(member *model.Member, sc *model.Scene, t *model.Token, ownerID *uuid.UUID) (int, error) {
+	if ownerID == nil && t.OwnerID == nil || ownerID != nil && t.IsOwnedBy(*ownerID) {
+		return 0, nil
+	}
+
+	if !member.IsGM() {
+		return http.StatusForbidden, ErrForbidden
+	}
+
+	if ownerID != nil {
+		if _, err := s.store.Campaign().FindMember(sc.CampaignID, *ownerID); err != nil {
+			return http.StatusUnprocessableEntity, ErrOwnerNotAMember
+		}
+	}
+	t.OwnerID = ownerID
+
+	return 0, nil
+}
+
+// linkCharacter links the token to a character of the campaign, or unlinks
+// it. Players only link characters they can edit. A token without an owner
+// is given to the character's owner.
+func (s *server) linkCharacter(member *model.Member, sc *model.Scene, t *model.Token, characterID *uuid.UUID) (int, error) {
+	if characterID == nil {
+		t.CharacterID = nil
+		return 0, nil
+	}
+
+	c, err := s.store.Character().Find(*characterID)
+	if err != nil || c.CampaignID != sc.CampaignID {
+		return http.StatusUnprocessableEntity, ErrUnknownCharacter
+	}
+
+	if !c.CanEdit(member) {
+		return http.StatusForbidden, ErrForbidden
+	}
+
+	t.CharacterID = &c.ID
+	if t.OwnerID == nil {
+		t.OwnerID = &c.OwnerID
+	}
+
+	return 0, nil
+}
+
+// publishTokenUpdate sends the updated token to the members who can see it.
+// Players are told a token was deleted when it moves to the GM layer and
+// created when it comes back, so their clients don't have to know layers.
+// Either only goes to the players who see the token off the GM layer.
+func (s *server) publishTokenUpdate(r *http.Request, sc *model.Scene, before, t *model.Token) error {
+	wasVisible, isVisible := before.Layer != model.LayerGM, t.Layer != model.LayerGM
+	if wasVisible == isVisible {
+		return s.publishToken(r, sc, realtime.EventTokenUpdated, t, t)
+	}
+
+	s.publish(realtime.EventTokenUpdated, sc.CampaignID, r, t, &realtime.Audience{GMOnly: true})
+
+	if isVisible {
+		players, err := s.tokenPlayers(sc, t)
+		if err != nil {
+			return err
+		}
+		s.publish(realtime.EventTokenCreated, sc.CampaignID, r, t, players)
+	} else {
+		players, err := s.tokenPlayers(sc, before)
+		if err != nil {
+			return err
+		}
+		s.publish(realtime.EventTokenDeleted, sc.CampaignID, r, map[string]uuid.UUID{"id": t.ID, "scene_id": t.SceneID}, players)
+	}
+
+	return nil
+}
+
+// tokenPlayers returns the audience of the players, and only them, who see
+// the token, which is off the GM layer.
+func (s *server) tokenPlayers(sc *model.Scene, t *model.Token) (*realtime.Audience, error) {
+	if !sc.FogOfWar {
+		return s.playersAudience(sc.CampaignID)
+	}
+
+	viewers, err := s.tokenViewers(sc, t)
+	if err != nil {
+		return nil, err
+	}
+
+	return &realtime.Audience{UserIDs: viewers.UserIDs}, nil
+}
+
+// publishToken sends an event telling where the token is to the members
+// who see it.
+func (s *server) publishToken(r *http.Request, sc *model.Scene, typ string, t *model.Token, payload interface{}) error {
+	audience, err := s.tokenViewers(sc, t)
+	if err != nil {
+		return err
+	}
+	s.publish(typ, sc.CampaignID, r, payload, audience)
+
+	return nil
+}
+
+// tokenViewers returns the audience of events telling where the token is.
+// On scenes with fog of war they only reach GMs and the players who see
+// the token.
+func (s *server) tokenViewers(sc *model.Scene, t *model.Token) (*realtime.Audience, error) {
+	if !sc.FogOfWar || t.Layer == model.LayerGM {
+		return tokenAudience(t), nil
+	}
+
+	members, err := s.store.Campaign().Members(sc.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	audience := &realtime.Audience{GMOnly: true}
+	for _, m := range members {
+		if m.IsGM() {
+			continue
+		}
+
+		if !t.IsOwnedBy(m.UserID) {
+			v, err := s.explore(sc, m.UserID)
+			if err != nil {
+				return nil, err
+			}
+			if !v.Sees(t) {
+				continue
+			}
+		}
+		audience.UserIDs = append(audience.UserIDs, m.UserID)
+	}
+
+	return audience, nil
+}
+
+// tokenAudience keeps events about tokens on the GM layer between GMs.
+func tokenAudience(t *model.Token) *realtime.Audience {
+	if t.Layer == model.LayerGM {
+		return &realtime.Audience{GMOnly: true}
+	}
+
+	return nil
+}
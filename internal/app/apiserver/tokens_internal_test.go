@@ -0,0 +1,249 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandleTokensCreate(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	alice := testUser(t, st, "alice")
+	bob := testUser(t, st, "bob")
+	spectator := testUser(t, st, "spectator")
+	c := testCampaign(t, st, gm, map[*model.User]string{alice: model.RolePlayer, bob: model.RolePlayer, spectator: model.RoleSpectator})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	st.Scene().Create(sc)
+	ch := model.TestCharacter(t, c, bob)
+	st.Character().Create(ch)
+	path := fmt.Sprintf("/private/campaigns/%s/scenes/%s/tokens", c.ID, sc.ID)
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		payload      map[string]interface{}
+		exceptedCode int
+		owner        *uuid.UUID
+	}{
+		{"player", alice, map[string]interface{}{"name": "Wolf", "x": 35, "y": 35}, http.StatusCreated, &alice.ID},
+		{"player for other", alice, map[string]interface{}{"x": 35, "y": 35, "owner_id": bob.ID}, http.StatusForbidden, nil},
+		{"player on gm layer", alice, map[string]interface{}{"x": 35, "y": 35, "layer": model.LayerGM}, http.StatusForbidden, nil},
+		{"player links other's character", alice, map[string]interface{}{"x": 35, "y": 35, "character_id": ch.ID}, http.StatusForbidden, nil},
+		{"spectator", spectator, map[string]interface{}{"x": 35, "y": 35}, http.StatusForbidden, nil},
+		{"gm monster", gm, map[string]interface{}{"name": "Strahd", "x": 700, "y": 525, "size": 1, "layer": model.LayerGM}, http.StatusCreated, nil},
+		{"gm for character", gm, map[string]interface{}{"x": 105, "y": 105, "character_id": ch.ID, "rotation": -90}, http.StatusCreated, &bob.ID},
+		{"gm for stranger", gm, map[string]interface{}{"x": 35, "y": 35, "owner_id": uuid.New()}, http.StatusUnprocessableEntity, nil},
+		{"unknown character", gm, map[string]interface{}{"x": 35, "y": 35, "character_id": uuid.New()}, http.StatusUnprocessableEntity, nil},
+		{"out of bounds", gm, map[string]interface{}{"x": 1500, "y": 35}, http.StatusUnprocessableEntity, nil},
+		{"too small", gm, map[string]interface{}{"x": 35, "y": 35, "size": 0.1}, http.StatusUnprocessableEntity, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, http.MethodPost, path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+
+			if tc.exceptedCode == http.StatusCreated {
+				tok := &model.Token{}
+				json.NewDecoder(rec.Body).Decode(tok)
+				assert.Equal(t, tc.owner, tok.OwnerID)
+				assert.True(t, tok.Rotation >= 0)
+			}
+		})
+	}
+}
+
+func TestServer_HandleTokensMove(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	alice := testUser(t, st, "alice")
+	bob := testUser(t, st, "bob")
+	spectator := testUser(t, st, "spectator")
+	c := testCampaign(t, st, gm, map[*model.User]string{alice: model.RolePlayer, bob: model.RolePlayer, spectator: model.RoleSpectator})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	st.Scene().Create(sc)
+	tok := model.TestToken(t, sc, alice)
+	st.Token().Create(tok)
+	path := fmt.Sprintf("/private/campaigns/%s/scenes/%s/tokens/%s/move", c.ID, sc.ID, tok.ID)
+
+	m, _ := st.Campaign().FindMember(c.ID, bob.ID)
+	sub, _, _ := s.hub.Subscribe(m, "")
+	defer s.hub.Unsubscribe(sub)
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		payload      interface{}
+		freeMovement bool
+		exceptedCode int
+	}{
+		{"owner", alice, map[string]int{"x": 175, "y": 105}, false, http.StatusOK},
+		{"other player", bob, map[string]int{"x": 245, "y": 105}, false, http.StatusForbidden},
+		{"other player with free movement", bob, map[string]int{"x": 245, "y": 105}, true, http.StatusOK},
+		{"spectator with free movement", spectator, map[string]int{"x": 315, "y": 105}, true, http.StatusForbidden},
+		{"gm", gm, map[string]int{"x": 315, "y": 105}, false, http.StatusOK},
+		{"out of bounds", alice, map[string]int{"x": 315, "y": -5}, false, http.StatusUnprocessableEntity},
+		{"without position", alice, map[string]int{"x": 315}, false, http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sc.FreeMovement = tc.freeMovement
+			st.Scene().Update(sc)
+
+			rec := testRequest(t, s, tc.user, http.MethodPost, path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+
+	tok, _ = st.Token().Find(tok.ID)
+	assert.Equal(t, 315, tok.X)
+
+	moves := 0
+	for len(sub.Events()) > 0 {
+		if e := <-sub.Events(); e.Type == realtime.EventTokenMoved {
+			moves++
+		}
+	}
+	assert.Equal(t, 3, moves)
+}
+
+func TestServer_HandleTokensMove_EnforceMovement(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	sc.EnforceMovement = true
+	st.Scene().Create(sc)
+	st.Wall().Create(&model.Wall{SceneID: sc.ID, X1: 280, Y1: 0, X2: 280, Y2: 420, Kind: model.WallSolid})
+	tok := model.TestToken(t, sc, player)
+	tok.Speed = 30
+	st.Token().Create(tok)
+	path := fmt.Sprintf("/private/campaigns/%s/scenes/%s/tokens/%s/move", c.ID, sc.ID, tok.ID)
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"through the wall", player, map[string]interface{}{"x": 315, "y": 105}, http.StatusUnprocessableEntity},
+		{"around the wall", player, map[string]interface{}{"x": 315, "y": 455, "path": []map[string]int{{"x": 245, "y": 455}}}, http.StatusOK},
+		{"too far", player, map[string]interface{}{"x": 315, "y": 105}, http.StatusUnprocessableEntity},
+		{"gm drags it anyway", gm, map[string]interface{}{"x": 105, "y": 105}, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, http.MethodPost, path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+
+	tok, _ = st.Token().Find(tok.ID)
+	assert.Equal(t, 105, tok.X)
+	assert.Equal(t, 55.0, tok.Moved)
+}
+
+func TestServer_HandleTokensUpdateAndDelete(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	alice := testUser(t, st, "alice")
+	bob := testUser(t, st, "bob")
+	c := testCampaign(t, st, gm, map[*model.User]string{alice: model.RolePlayer, bob: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	sc.FreeMovement = true
+	st.Scene().Create(sc)
+	tok := model.TestToken(t, sc, alice)
+	st.Token().Create(tok)
+	path := fmt.Sprintf("/private/campaigns/%s/scenes/%s/tokens/%s", c.ID, sc.ID, tok.ID)
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		method       string
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"owner edits", alice, http.MethodPatch, map[string]interface{}{"name": "Ireena", "rotation": 45}, http.StatusOK},
+		{"owner hides", alice, http.MethodPatch, map[string]interface{}{"layer": model.LayerGM}, http.StatusForbidden},
+		{"owner gives away", alice, http.MethodPatch, map[string]interface{}{"owner_id": bob.ID}, http.StatusForbidden},
+		{"owner speeds up", alice, http.MethodPatch, map[string]interface{}{"speed": 60}, http.StatusForbidden},
+		{"gm sets speed", gm, http.MethodPatch, map[string]interface{}{"speed": 30, "moved": 0}, http.StatusOK},
+		{"other player with free movement", bob, http.MethodPatch, map[string]interface{}{"name": "Bob's"}, http.StatusForbidden},
+		{"gm unassigns", gm, http.MethodPatch, map[string]interface{}{"owner_id": nil}, http.StatusOK},
+		{"previous owner edits", alice, http.MethodPatch, map[string]interface{}{"name": "Ireena"}, http.StatusForbidden},
+		{"gm hides", gm, http.MethodPatch, map[string]interface{}{"layer": model.LayerGM}, http.StatusOK},
+		{"player looks for it", alice, http.MethodDelete, nil, http.StatusNotFound},
+		{"gm deletes", gm, http.MethodDelete, nil, http.StatusNoContent},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, tc.method, path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_HandleTokensUpdateLayerDelivery(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	st.Scene().Create(sc)
+	tok := model.TestToken(t, sc, gm)
+	st.Token().Create(tok)
+	path := fmt.Sprintf("/private/campaigns/%s/scenes/%s/tokens/%s", c.ID, sc.ID, tok.ID)
+
+	members := map[*model.User]*realtime.Client{}
+	for _, u := range []*model.User{gm, player} {
+		m, _ := st.Campaign().FindMember(c.ID, u.ID)
+		members[u], _, _ = s.hub.Subscribe(m, "")
+		defer s.hub.Unsubscribe(members[u])
+	}
+
+	received := func(c *realtime.Client) []string {
+		types := []string{}
+		for len(c.Events()) > 0 {
+			if e := <-c.Events(); strings.HasPrefix(e.Type, "token.") {
+				types = append(types, e.Type)
+			}
+		}
+
+		return types
+	}
+
+	testRequest(t, s, gm, http.MethodPatch, path, map[string]interface{}{"layer": model.LayerGM})
+	assert.Equal(t, []string{realtime.EventTokenUpdated}, received(members[gm]))
+	assert.Equal(t, []string{realtime.EventTokenDeleted}, received(members[player]))
+
+	testRequest(t, s, gm, http.MethodPatch, path, map[string]interface{}{"name": "Strahd"})
+	assert.Equal(t, []string{realtime.EventTokenUpdated}, received(members[gm]))
+	assert.Empty(t, received(members[player]))
+
+	testRequest(t, s, gm, http.MethodPatch, path, map[string]interface{}{"layer": model.LayerTokens})
+	assert.Equal(t, []string{realtime.EventTokenUpdated}, received(members[gm]))
+	assert.Equal(t, []string{realtime.EventTokenCreated}, received(members[player]))
+}
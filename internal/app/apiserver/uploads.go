@@ -0,0 +1,233 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/blob"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// uploadOffsetHeader carries how many bytes of a resumable upload the
+// server has, and where a chunk starts.
+const uploadOffsetHeader = "Upload-Offset"
+
+var (
+	ErrOffsetMismatch = errors.New("upload offset mismatch")
+)
+
+// handleUploadsCreate starts a resumable upload of size bytes. The size is
+// reserved in the user's quota until the upload completes, is deleted or
+// expires, so clients don't upload files they can't keep.
+func (s *server) handleUploadsCreate() http.HandlerFunc {
+	type request struct {
+		Name       string     `json:"name"`
+		Size       int64      `json:"size"`
+		FolderID   *uuid.UUID `json:"folder_id"`
+		CampaignID *uuid.UUID `json:"campaign_id"`
+		Tags       []string   `json:"tags"`
+		Private    bool       `json:"private"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		up := &model.Upload{
+			UserID:     r.Context().Value(ctxKeyUser).(*model.User).ID,
+			FolderID:   req.FolderID,
+			CampaignID: req.CampaignID,
+			Name:       req.Name,
+			Size:       req.Size,
+			Tags:       model.NormalizeTags(req.Tags),
+			Private:    req.Private,
+		}
+		if err := up.Validate(); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+		if err := s.checkFolder(up.UserID, up.FolderID); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+		if err := s.checkCampaign(up.UserID, up.CampaignID); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+		if code, err := s.checkQuota(up.UserID, up.Size); err != nil {
+			s.error(w, r, code, err)
+			return
+		}
+
+		if err := s.store.Upload().Create(up, s.assets.userQuota); err != nil {
+			if err == store.ErrQuotaExceeded {
+				s.error(w, r, http.StatusInsufficientStorage, err)
+				return
+			}
+
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		w.Header().Set(uploadOffsetHeader, "0")
+		s.respond(w, r, http.StatusCreated, up)
+	}
+}
+
+// handleUploadsGet tells a client resuming an upload where to continue.
+func (s *server) handleUploadsGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		up, err := s.findUpload(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		w.Header().Set(uploadOffsetHeader, strconv.FormatInt(up.Offset, 10))
+		s.respond(w, r, http.StatusOK, up)
+	}
+}
+
+// handleUploadsUpdate appends a chunk at the Upload-Offset header, which
+// must be the offset the server has. The last chunk creates the asset.
+func (s *server) handleUploadsUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		up, err := s.findUpload(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		offset, err := strconv.ParseInt(r.Header.Get(uploadOffsetHeader), 10, 64)
+		if err != nil || offset != up.Offset {
+			w.Header().Set(uploadOffsetHeader, strconv.FormatInt(up.Offset, 10))
+			s.error(w, r, http.StatusConflict, ErrOffsetMismatch)
+			return
+		}
+
+		remaining := up.Size - up.Offset
+		if r.ContentLength > remaining {
+			s.error(w, r, http.StatusRequestEntityTooLarge, ErrUploadTooLarge)
+			return
+		}
+
+		// Concurrent chunks wait for the lock and then find the offset
+		// moved, instead of both being appended.
+		var appendErr error
+		code := http.StatusInternalServerError
+		if err := s.store.Transaction(func(tx store.Store) error {
+			locked, err := tx.Upload().Lock(up.ID)
+			if err != nil {
+				code = http.StatusNotFound
+				return ErrNotFound
+			}
+			if locked.Offset != up.Offset {
+				up = locked
+				code = http.StatusConflict
+				return ErrOffsetMismatch
+			}
+
+			// Whatever arrived before a dropped connection is kept, so
+			// the client can resume from there.
+			up.Offset, appendErr = s.blobs.Append(uploadKey(up.ID), io.LimitReader(r.Body, remaining))
+
+			return tx.Upload().Update(up)
+		}); err != nil {
+			if code == http.StatusConflict {
+				w.Header().Set(uploadOffsetHeader, strconv.FormatInt(up.Offset, 10))
+			}
+			s.error(w, r, code, err)
+			return
+		}
+		if appendErr != nil {
+			s.error(w, r, http.StatusBadRequest, appendErr)
+			return
+		}
+
+		if !up.Complete() {
+			w.Header().Set(uploadOffsetHeader, strconv.FormatInt(up.Offset, 10))
+			s.respond(w, r, http.StatusNoContent, nil)
+			return
+		}
+
+		if err := s.store.Upload().Delete(up.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		a, code, err := s.createAsset(up, uploadKey(up.ID))
+		if err != nil {
+			s.error(w, r, code, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusCreated, s.newAssetView(a))
+	}
+}
+
+func (s *server) handleUploadsDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		up, err := s.findUpload(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if err := s.store.Upload().Delete(up.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.blobs.Delete(uploadKey(up.ID)); err != nil && err != blob.ErrNotFound {
+			s.logger.Error(err.Error())
+		}
+
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// expireUploads deletes the uploads that made no progress for the upload
+// TTL, releasing what they reserved, along with what they uploaded.
+func (s *server) expireUploads() error {
+	ids, err := s.store.Upload().DeleteStale(time.Now().Add(-s.assets.uploadTTL))
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := s.blobs.Delete(uploadKey(id)); err != nil && err != blob.ErrNotFound {
+			s.logger.Error(err.Error())
+		}
+	}
+
+	return nil
+}
+
+// findUpload loads the user's {uploadID} upload.
+func (s *server) findUpload(r *http.Request) (*model.Upload, error) {
+	id, err := uuid.Parse(mux.Vars(r)["uploadID"])
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	up, err := s.store.Upload().Find(id)
+	if err != nil || up.UserID != r.Context().Value(ctxKeyUser).(*model.User).ID {
+		return nil, ErrNotFound
+	}
+
+	return up, nil
+}
+
+func uploadKey(id uuid.UUID) string {
+	return "uploads/" + id.String()
+}
@@ -0,0 +1,150 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+var (
+	ErrInvalidQuota = errors.New("quota can't be negative")
+)
+
+// usageView is a user's storage usage against the quota in effect for them,
+// with the bytes filed under each of their campaigns.
+type usageView struct {
+	*model.Usage
+	Limit     int64                  `json:"limit"`
+	Available int64                  `json:"available"`
+	Campaigns []*model.CampaignUsage `json:"campaigns"`
+}
+
+func newAdmins(config *Config) map[string]bool {
+	admins := map[string]bool{}
+	for _, username := range config.Admins {
+		admins[username] = true
+	}
+
+	return admins
+}
+
+// authorizeAdmin only lets the usernames configured as admins through.
+func (s *server) authorizeAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.admins[r.Context().Value(ctxKeyUser).(*model.User).Username] {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *server) handleUsageGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		v, err := s.usageView(r.Context().Value(ctxKeyUser).(*model.User).ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, v)
+	}
+}
+
+func (s *server) handleAdminUsageGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, err := s.findUser(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		v, err := s.usageView(u.ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, v)
+	}
+}
+
+// handleAdminQuotaUpdate sets the account's quota in bytes, or puts it back
+// on the default with a null quota. Lowering it below what the account uses
+// keeps their assets but stops new uploads.
+func (s *server) handleAdminQuotaUpdate() http.HandlerFunc {
+	type request struct {
+		Quota *int64 `json:"quota"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, err := s.findUser(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.Quota != nil && *req.Quota < 0 {
+			s.error(w, r, http.StatusUnprocessableEntity, ErrInvalidQuota)
+			return
+		}
+
+		if err := s.store.Usage().SetQuota(u.ID, req.Quota); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		v, err := s.usageView(u.ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, v)
+	}
+}
+
+func (s *server) usageView(userID uuid.UUID) (*usageView, error) {
+	usage, err := s.store.Usage().Find(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	campaigns, err := s.store.Usage().Campaigns(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &usageView{
+		Usage:     usage,
+		Limit:     usage.Limit(s.assets.userQuota),
+		Available: usage.Available(s.assets.userQuota),
+		Campaigns: campaigns,
+	}, nil
+}
+
+// findUser loads the {userID} account.
+func (s *server) findUser(r *http.Request) (*model.User, error) {
+	id, err := uuid.Parse(mux.Vars(r)["userID"])
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	u, err := s.store.User().Find(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	return u, nil
+}
@@ -0,0 +1,136 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandleUsageGet(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "user")
+	c := testCampaign(t, st, u, nil)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	s.assets.userQuota = 1000
+
+	rec := testUpload(t, s, u, "notes.txt", bytes.Repeat([]byte("a"), 100), map[string][]string{"campaign_id": {c.ID.String()}})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	rec = testUpload(t, s, u, "copy.txt", bytes.Repeat([]byte("a"), 100), nil)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	rec = testUpload(t, s, u, "other.txt", bytes.Repeat([]byte("b"), 50), nil)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = testRequest(t, s, u, http.MethodGet, "/private/me/usage", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	v := &usageView{}
+	json.NewDecoder(rec.Body).Decode(v)
+	assert.Equal(t, int64(150), v.Used)
+	assert.Nil(t, v.Quota)
+	assert.Equal(t, int64(1000), v.Limit)
+	assert.Equal(t, int64(850), v.Available)
+	if assert.Len(t, v.Campaigns, 1) {
+		assert.Equal(t, c.ID, v.Campaigns[0].CampaignID)
+		assert.Equal(t, int64(100), v.Campaigns[0].Used)
+	}
+
+	rec = testRequest(t, s, nil, http.MethodGet, "/private/me/usage", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestServer_HandleAssetsCreateCampaign(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "user")
+	other := testUser(t, st, "other")
+	c := testCampaign(t, st, other, nil)
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	rec := testUpload(t, s, u, "notes.txt", []byte("notes"), map[string][]string{"campaign_id": {c.ID.String()}})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = testRequest(t, s, u, http.MethodPost, "/private/uploads", map[string]interface{}{"name": "theme.ogg", "size": 10, "campaign_id": c.ID})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestServer_HandleUploadsQuota(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, "user")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	s.assets.userQuota = 100
+
+	rec := testRequest(t, s, u, http.MethodPost, "/private/uploads", map[string]interface{}{"name": "theme.ogg", "size": 101})
+	assert.Equal(t, http.StatusInsufficientStorage, rec.Code)
+
+	// Both uploads fit on their own, the second can't be started while the
+	// first holds its reservation.
+	rec = testRequest(t, s, u, http.MethodPost, "/private/uploads", map[string]interface{}{"name": "a.txt", "size": 60})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	up := &model.Upload{}
+	json.NewDecoder(rec.Body).Decode(up)
+
+	rec = testRequest(t, s, u, http.MethodPost, "/private/uploads", map[string]interface{}{"name": "b.txt", "size": 60})
+	assert.Equal(t, http.StatusInsufficientStorage, rec.Code)
+
+	rec = testChunk(t, s, u, "/private/uploads/"+up.ID.String(), 0, bytes.Repeat([]byte("a"), 60))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	usage, _ := st.Usage().Find(u.ID)
+	assert.Equal(t, int64(60), usage.Used)
+	assert.Equal(t, int64(0), usage.Reserved)
+}
+
+func TestServer_HandleAdminQuota(t *testing.T) {
+	st := teststore.New()
+	admin := testUser(t, st, "admin")
+	u := testUser(t, st, "user")
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+	s.admins = map[string]bool{"admin": true}
+	s.assets.userQuota = 100
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		method       string
+		path         string
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"user gets usage", u, http.MethodGet, "/private/admin/users/" + u.ID.String() + "/usage", nil, http.StatusForbidden},
+		{"user sets quota", u, http.MethodPut, "/private/admin/users/" + u.ID.String() + "/quota", map[string]interface{}{"quota": 1 << 40}, http.StatusForbidden},
+		{"admin gets usage", admin, http.MethodGet, "/private/admin/users/" + u.ID.String() + "/usage", nil, http.StatusOK},
+		{"unknown user", admin, http.MethodGet, "/private/admin/users/" + admin.ID.String() + "0/usage", nil, http.StatusNotFound},
+		{"negative quota", admin, http.MethodPut, "/private/admin/users/" + u.ID.String() + "/quota", map[string]interface{}{"quota": -1}, http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, tc.method, tc.path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+
+	rec := testUpload(t, s, u, "big.txt", bytes.Repeat([]byte("a"), 150), nil)
+	assert.Equal(t, http.StatusInsufficientStorage, rec.Code)
+
+	rec = testRequest(t, s, admin, http.MethodPut, "/private/admin/users/"+u.ID.String()+"/quota", map[string]interface{}{"quota": 200})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	v := &usageView{}
+	json.NewDecoder(rec.Body).Decode(v)
+	assert.Equal(t, int64(200), v.Limit)
+
+	rec = testUpload(t, s, u, "big.txt", bytes.Repeat([]byte("a"), 150), nil)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = testRequest(t, s, admin, http.MethodPut, "/private/admin/users/"+u.ID.String()+"/quota", map[string]interface{}{"quota": nil})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	v = &usageView{}
+	json.NewDecoder(rec.Body).Decode(v)
+	assert.Nil(t, v.Quota)
+	assert.Equal(t, int64(100), v.Limit)
+	assert.Equal(t, int64(0), v.Available)
+}
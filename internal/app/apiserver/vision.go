@@ -0,0 +1,155 @@
+package apiserver
+
+import (
+	"net/http"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+// handleVisionGet returns what the member sees of the scene. Players see
+// through their own tokens and keep what they have seen explored; GMs, and
+// everyone on scenes without fog of war, see it all.
+func (s *server) handleVisionGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, err := s.findScene(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		member := r.Context().Value(ctxKeyMember).(*model.Member)
+		var v *model.Vision
+		if member.IsGM() || !sc.FogOfWar {
+			v, err = s.fullVision(sc)
+		} else {
+			v, err = s.explore(sc, member.UserID)
+		}
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, v)
+	}
+}
+
+// handleExplorationDelete resets the fog of war of the scene for every
+// player.
+func (s *server) handleExplorationDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, err := s.findScene(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		if err := s.store.Exploration().DeleteAll(sc.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.publishVisionChange(r, sc, nil)
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+func (s *server) fullVision(sc *model.Scene) (*model.Vision, error) {
+	walls, err := s.store.Wall().FindAll(sc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &model.Vision{
+		Tokens:   []model.TokenSight{},
+		Doors:    []*model.Wall{},
+		Visible:  sc.NewMask(),
+		Explored: sc.NewMask(),
+	}
+	v.Visible.SetAll()
+	v.Explored.SetAll()
+	for _, w := range walls {
+		if w.Kind == model.WallDoor {
+			v.Doors = append(v.Doors, w)
+		}
+	}
+
+	return v, nil
+}
+
+// explore computes what the user's tokens on the scene see and adds it to
+// what the user explored before. An exploration made for other scene
+// dimensions is started over.
+func (s *server) explore(sc *model.Scene, userID uuid.UUID) (*model.Vision, error) {
+	tokens, err := s.store.Token().FindAll(sc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	own := []*model.Token{}
+	for _, t := range tokens {
+		if t.Layer == model.LayerTokens && t.IsOwnedBy(userID) {
+			own = append(own, t)
+		}
+	}
+
+	walls, err := s.store.Wall().FindAll(sc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	lights, err := s.store.Light().FindAll(sc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	v := sc.See(own, walls, lights)
+
+	e, err := s.store.Exploration().Find(sc.ID, userID)
+	if err == store.ErrRecordNotFound || err == nil && !e.Mask.Fits(float64(sc.Width), float64(sc.Height), float64(sc.GridSize)) {
+		e, err = &model.Exploration{SceneID: sc.ID, UserID: userID, Mask: sc.NewMask()}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := e.Mask.Merge(v.Visible)
+	if err != nil {
+		return nil, err
+	}
+
+	if changed {
+		if err := s.store.Exploration().Save(e); err != nil {
+			return nil, err
+		}
+	}
+	v.Explored = e.Mask
+
+	return v, nil
+}
+
+// exploreWith updates the exploration of the token's owner after the token
+// was placed or moved on a scene with fog of war.
+func (s *server) exploreWith(r *http.Request, sc *model.Scene, t *model.Token) {
+	if !sc.FogOfWar || t.OwnerID == nil || t.Layer != model.LayerTokens {
+		return
+	}
+
+	if _, err := s.explore(sc, *t.OwnerID); err != nil {
+		s.logger.Error(err.Error())
+		return
+	}
+
+	s.publishVisionChange(r, sc, &realtime.Audience{UserIDs: []uuid.UUID{*t.OwnerID}})
+}
+
+func (s *server) publishVisionChange(r *http.Request, sc *model.Scene, audience *realtime.Audience) {
+	s.publish(realtime.EventVisionChanged, sc.CampaignID, r, map[string]uuid.UUID{"scene_id": sc.ID}, audience)
+}
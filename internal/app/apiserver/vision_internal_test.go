@@ -0,0 +1,349 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_HandleWalls(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	sc.FogOfWar = true
+	st.Scene().Create(sc)
+	st.Token().Create(model.TestToken(t, sc, player))
+
+	// The player's token stands west of the first door; the second one is
+	// behind it.
+	near := &model.Wall{SceneID: sc.ID, X1: 280, Y1: 0, X2: 280, Y2: 1050, Kind: model.WallDoor}
+	st.Wall().Create(near)
+	far := &model.Wall{SceneID: sc.ID, X1: 700, Y1: 0, X2: 700, Y2: 1050, Kind: model.WallDoor}
+	st.Wall().Create(far)
+	solid := model.TestWall(t, sc)
+	st.Wall().Create(solid)
+
+	path := fmt.Sprintf("/private/campaigns/%s/scenes/%s/walls", c.ID, sc.ID)
+	testCases := []struct {
+		name         string
+		user         *model.User
+		method       string
+		path         string
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"gm creates", gm, http.MethodPost, path, map[string]interface{}{"x1": 0, "y1": 700, "x2": 280, "y2": 700}, http.StatusCreated},
+		{"gm creates a point", gm, http.MethodPost, path, map[string]interface{}{"x1": 70, "y1": 70, "x2": 70, "y2": 70}, http.StatusUnprocessableEntity},
+		{"player creates", player, http.MethodPost, path, map[string]interface{}{"x1": 0, "y1": 700, "x2": 280, "y2": 700}, http.StatusForbidden},
+		{"player lists", player, http.MethodGet, path, nil, http.StatusForbidden},
+		{"gm lists", gm, http.MethodGet, path, nil, http.StatusOK},
+		{"player opens a door in sight", player, http.MethodPatch, path + "/" + near.ID.String(), map[string]interface{}{"open": true}, http.StatusOK},
+		{"player opens a door out of sight", player, http.MethodPatch, path + "/" + far.ID.String(), map[string]interface{}{"open": true}, http.StatusForbidden},
+		{"player opens a wall", player, http.MethodPatch, path + "/" + solid.ID.String(), map[string]interface{}{"open": true}, http.StatusForbidden},
+		{"player moves a door", player, http.MethodPatch, path + "/" + near.ID.String(), map[string]interface{}{"x1": 350}, http.StatusForbidden},
+		{"gm opens a wall", gm, http.MethodPatch, path + "/" + solid.ID.String(), map[string]interface{}{"open": true}, http.StatusUnprocessableEntity},
+		{"player deletes", player, http.MethodDelete, path + "/" + solid.ID.String(), nil, http.StatusForbidden},
+		{"gm deletes", gm, http.MethodDelete, path + "/" + solid.ID.String(), nil, http.StatusNoContent},
+		{"deleted", gm, http.MethodDelete, path + "/" + solid.ID.String(), nil, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, tc.method, tc.path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+
+	w, _ := st.Wall().Find(near.ID)
+	assert.True(t, w.Open)
+}
+
+func TestServer_HandleLights(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	st.Scene().Create(sc)
+	l := model.TestLight(t, sc)
+	st.Light().Create(l)
+
+	path := fmt.Sprintf("/private/campaigns/%s/scenes/%s/lights", c.ID, sc.ID)
+	testCases := []struct {
+		name         string
+		user         *model.User
+		method       string
+		path         string
+		payload      interface{}
+		exceptedCode int
+	}{
+		{"gm creates", gm, http.MethodPost, path, map[string]interface{}{"x": 700, "y": 525, "radius": 140}, http.StatusCreated},
+		{"gm creates off the map", gm, http.MethodPost, path, map[string]interface{}{"x": 2000, "y": 525, "radius": 140}, http.StatusUnprocessableEntity},
+		{"invalid color", gm, http.MethodPost, path, map[string]interface{}{"x": 700, "y": 525, "radius": 140, "color": "orange"}, http.StatusUnprocessableEntity},
+		{"player creates", player, http.MethodPost, path, map[string]interface{}{"x": 700, "y": 525, "radius": 140}, http.StatusForbidden},
+		{"player lists", player, http.MethodGet, path, nil, http.StatusForbidden},
+		{"gm dims", gm, http.MethodPatch, path + "/" + l.ID.String(), map[string]interface{}{"radius": 70}, http.StatusOK},
+		{"player dims", player, http.MethodPatch, path + "/" + l.ID.String(), map[string]interface{}{"radius": 70}, http.StatusForbidden},
+		{"gm deletes", gm, http.MethodDelete, path + "/" + l.ID.String(), nil, http.StatusNoContent},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.user, tc.method, tc.path, tc.payload)
+			assert.Equal(t, tc.exceptedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_HandleVisionGet(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	// A wall splits the scene at x=280; the player starts west of it.
+	sc := model.TestScene(t, c)
+	sc.FogOfWar = true
+	st.Scene().Create(sc)
+	st.Wall().Create(&model.Wall{SceneID: sc.ID, X1: 280, Y1: 0, X2: 280, Y2: 1050, Kind: model.WallSolid})
+	tok := model.TestToken(t, sc, player)
+	st.Token().Create(tok)
+	hidden := model.TestToken(t, sc, player)
+	hidden.Layer = model.LayerGM
+	hidden.X = 700
+	st.Token().Create(hidden)
+
+	path := fmt.Sprintf("/private/campaigns/%s/scenes/%s/vision", c.ID, sc.ID)
+	vision := func(u *model.User) *model.Vision {
+		rec := testRequest(t, s, u, http.MethodGet, path, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		v := &model.Vision{}
+		json.NewDecoder(rec.Body).Decode(v)
+
+		return v
+	}
+
+	v := vision(player)
+	assert.Len(t, v.Tokens, 1)
+	assert.True(t, v.Visible.Has(1, 1))
+	assert.False(t, v.Visible.Has(10, 1))
+	assert.Equal(t, v.Visible.Count(), v.Explored.Count())
+
+	v = vision(gm)
+	assert.Equal(t, 20*15, v.Visible.Count())
+
+	// Moving past the wall keeps the west side explored but out of sight.
+	rec := testRequest(t, s, player, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/scenes/%s/tokens/%s/move", c.ID, sc.ID, tok.ID), map[string]int{"x": 700, "y": 105})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	e, err := st.Exploration().Find(sc.ID, player.ID)
+	assert.NoError(t, err)
+	assert.True(t, e.Mask.Has(10, 1))
+
+	v = vision(player)
+	assert.False(t, v.Visible.Has(1, 1))
+	assert.True(t, v.Explored.Has(1, 1))
+	assert.True(t, v.Explored.Has(10, 1))
+
+	rec = testRequest(t, s, player, http.MethodDelete, fmt.Sprintf("/private/campaigns/%s/scenes/%s/exploration", c.ID, sc.ID), nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = testRequest(t, s, gm, http.MethodDelete, fmt.Sprintf("/private/campaigns/%s/scenes/%s/exploration", c.ID, sc.ID), nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	v = vision(player)
+	assert.False(t, v.Explored.Has(1, 1))
+	assert.True(t, v.Explored.Has(10, 1))
+}
+
+func TestServer_HandleVisionGet_Dark(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	sc.FogOfWar = true
+	sc.Dark = true
+	st.Scene().Create(sc)
+	st.Token().Create(model.TestToken(t, sc, player))
+	st.Light().Create(&model.Light{SceneID: sc.ID, X: 1050, Y: 105, Radius: 70, Color: "#ffffff"})
+
+	rec := testRequest(t, s, player, http.MethodGet, fmt.Sprintf("/private/campaigns/%s/scenes/%s/vision", c.ID, sc.ID), nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	v := &model.Vision{}
+	json.NewDecoder(rec.Body).Decode(v)
+	assert.False(t, v.Visible.Has(1, 1))
+	assert.True(t, v.Visible.Has(15, 1))
+	assert.False(t, v.Visible.Has(10, 1))
+}
+
+func TestServer_VisionChangedEvents(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	other := testUser(t, st, "other")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer, other: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	sc := model.TestScene(t, c)
+	sc.FogOfWar = true
+	st.Scene().Create(sc)
+	tok := model.TestToken(t, sc, player)
+	st.Token().Create(tok)
+
+	members := map[*model.User]*realtime.Client{}
+	for _, u := range []*model.User{player, other} {
+		m, _ := st.Campaign().FindMember(c.ID, u.ID)
+		members[u], _, _ = s.hub.Subscribe(m, "")
+		defer s.hub.Unsubscribe(members[u])
+	}
+
+	received := func(c *realtime.Client) []string {
+		types := []string{}
+		for len(c.Events()) > 0 {
+			if e := <-c.Events(); e.Type == realtime.EventVisionChanged {
+				types = append(types, e.Type)
+			}
+		}
+
+		return types
+	}
+
+	rec := testRequest(t, s, player, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/scenes/%s/tokens/%s/move", c.ID, sc.ID, tok.ID), map[string]int{"x": 175, "y": 105})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, received(members[player]), 1)
+	assert.Empty(t, received(members[other]))
+
+	rec = testRequest(t, s, gm, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/scenes/%s/walls", c.ID, sc.ID), map[string]int{"x1": 280, "y1": 0, "x2": 280, "y2": 1050})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Len(t, received(members[player]), 1)
+	assert.Len(t, received(members[other]), 1)
+}
+
+func TestServer_TokensFogOfWar(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	other := testUser(t, st, "other")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer, other: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	// A wall splits the scene at x=280; the player is west of it, the other
+	// player east.
+	sc := model.TestScene(t, c)
+	sc.FogOfWar = true
+	st.Scene().Create(sc)
+	st.Wall().Create(&model.Wall{SceneID: sc.ID, X1: 280, Y1: 0, X2: 280, Y2: 1050, Kind: model.WallSolid})
+	st.Token().Create(model.TestToken(t, sc, player))
+	tok := model.TestToken(t, sc, other)
+	tok.X = 700
+	st.Token().Create(tok)
+	tokensPath := fmt.Sprintf("/private/campaigns/%s/scenes/%s/tokens", c.ID, sc.ID)
+
+	tokens := func(u *model.User) []*model.Token {
+		rec := testRequest(t, s, u, http.MethodGet, tokensPath, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		tokens := []*model.Token{}
+		json.NewDecoder(rec.Body).Decode(&tokens)
+
+		return tokens
+	}
+	assert.Len(t, tokens(player), 1)
+	assert.Len(t, tokens(other), 1)
+	assert.Len(t, tokens(gm), 2)
+
+	m, _ := st.Campaign().FindMember(c.ID, player.ID)
+	client, _, _ := s.hub.Subscribe(m, "")
+	defer s.hub.Unsubscribe(client)
+	moves := func() int {
+		n := 0
+		for len(client.Events()) > 0 {
+			if e := <-client.Events(); e.Type == realtime.EventTokenMoved {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	rec := testRequest(t, s, other, http.MethodPost, fmt.Sprintf("%s/%s/move", tokensPath, tok.ID), map[string]int{"x": 1050, "y": 105})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 0, moves())
+
+	rec = testRequest(t, s, gm, http.MethodPost, fmt.Sprintf("%s/%s/move", tokensPath, tok.ID), map[string]int{"x": 175, "y": 105})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1, moves())
+	assert.Len(t, tokens(player), 2)
+}
+
+func TestServer_TokensFogOfWarLayers(t *testing.T) {
+	st := teststore.New()
+	gm := testUser(t, st, "gm")
+	player := testUser(t, st, "player")
+	other := testUser(t, st, "other")
+	c := testCampaign(t, st, gm, map[*model.User]string{player: model.RolePlayer, other: model.RolePlayer})
+	s := newServer(st, realtime.NewLocalPubSub(), testJWTKey)
+
+	// As above, the player is west of the wall and the other player east,
+	// where the GM hides a token.
+	sc := model.TestScene(t, c)
+	sc.FogOfWar = true
+	st.Scene().Create(sc)
+	st.Wall().Create(&model.Wall{SceneID: sc.ID, X1: 280, Y1: 0, X2: 280, Y2: 1050, Kind: model.WallSolid})
+	st.Token().Create(model.TestToken(t, sc, player))
+	lookout := model.TestToken(t, sc, other)
+	lookout.X = 700
+	st.Token().Create(lookout)
+	tok := model.TestToken(t, sc, gm)
+	tok.X, tok.Layer = 840, model.LayerGM
+	st.Token().Create(tok)
+	path := fmt.Sprintf("/private/campaigns/%s/scenes/%s/tokens/%s", c.ID, sc.ID, tok.ID)
+
+	subscribe := func(u *model.User) *realtime.Client {
+		m, _ := st.Campaign().FindMember(c.ID, u.ID)
+		client, _, _ := s.hub.Subscribe(m, "")
+		t.Cleanup(func() { s.hub.Unsubscribe(client) })
+
+		return client
+	}
+	count := func(client *realtime.Client, typ string) int {
+		n := 0
+		for len(client.Events()) > 0 {
+			if e := <-client.Events(); e.Type == typ {
+				n++
+			}
+		}
+
+		return n
+	}
+	west, east := subscribe(player), subscribe(other)
+
+	rec := testRequest(t, s, gm, http.MethodPatch, path, map[string]string{"layer": model.LayerTokens})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 0, count(west, realtime.EventTokenCreated))
+	assert.Equal(t, 1, count(east, realtime.EventTokenCreated))
+
+	rec = testRequest(t, s, gm, http.MethodPatch, path, map[string]string{"layer": model.LayerGM})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 0, count(west, realtime.EventTokenDeleted))
+	assert.Equal(t, 1, count(east, realtime.EventTokenDeleted))
+
+	// Undoing brings the token back the same way.
+	rec = testRequest(t, s, gm, http.MethodPost, fmt.Sprintf("/private/campaigns/%s/actions/undo", c.ID), nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 0, count(west, realtime.EventTokenCreated))
+	assert.Equal(t, 1, count(east, realtime.EventTokenCreated))
+}
@@ -0,0 +1,247 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// handleWallsCreate adds a wall to the scene. Walls give away the layout of
+// the scene, so only GMs see them; players learn about the doors in sight
+// from their vision.
+func (s *server) handleWallsCreate() http.HandlerFunc {
+	type request struct {
+		X1   int    `json:"x1"`
+		Y1   int    `json:"y1"`
+		X2   int    `json:"x2"`
+		Y2   int    `json:"y2"`
+		Kind string `json:"kind"`
+		Open bool   `json:"open"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, err := s.findScene(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		req := &request{Kind: model.WallSolid}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		wl := &model.Wall{
+			SceneID: sc.ID,
+			X1:      req.X1,
+			Y1:      req.Y1,
+			X2:      req.X2,
+			Y2:      req.Y2,
+			Kind:    req.Kind,
+			Open:    req.Open,
+		}
+		if err := s.store.Transaction(func(tx store.Store) error {
+			if err := tx.Wall().Create(wl); err != nil {
+				return err
+			}
+
+			return logAction(tx, r, sc, model.TargetWall, wl.ID, nil, wl)
+		}); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.publish(realtime.EventWallCreated, sc.CampaignID, r, wl, &realtime.Audience{GMOnly: true})
+		s.publishVisionChange(r, sc, nil)
+		s.respond(w, r, http.StatusCreated, wl)
+	}
+}
+
+func (s *server) handleWallsIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, err := s.findScene(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		walls, err := s.store.Wall().FindAll(sc.ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, walls)
+	}
+}
+
+// handleWallsUpdate edits a wall. Players may only open and close the doors
+// their tokens see.
+func (s *server) handleWallsUpdate() http.HandlerFunc {
+	type request struct {
+		X1   *int    `json:"x1"`
+		Y1   *int    `json:"y1"`
+		X2   *int    `json:"x2"`
+		Y2   *int    `json:"y2"`
+		Kind *string `json:"kind"`
+		Open *bool   `json:"open"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, wl, err := s.findWall(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		member := r.Context().Value(ctxKeyMember).(*model.Member)
+		if !member.IsGM() {
+			if req.X1 != nil || req.Y1 != nil || req.X2 != nil || req.Y2 != nil || req.Kind != nil {
+				s.error(w, r, http.StatusForbidden, ErrForbidden)
+				return
+			}
+
+			ok, err := s.canOpen(member, sc, wl)
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			if !ok {
+				s.error(w, r, http.StatusForbidden, ErrForbidden)
+				return
+			}
+		}
+
+		before := *wl
+		if req.X1 != nil {
+			wl.X1 = *req.X1
+		}
+		if req.Y1 != nil {
+			wl.Y1 = *req.Y1
+		}
+		if req.X2 != nil {
+			wl.X2 = *req.X2
+		}
+		if req.Y2 != nil {
+			wl.Y2 = *req.Y2
+		}
+		if req.Kind != nil {
+			wl.Kind = *req.Kind
+		}
+		if req.Open != nil {
+			wl.Open = *req.Open
+		}
+
+		if err := s.store.Transaction(func(tx store.Store) error {
+			if err := tx.Wall().Update(wl); err != nil {
+				return err
+			}
+
+			return logAction(tx, r, sc, model.TargetWall, wl.ID, &before, wl)
+		}); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.publish(realtime.EventWallUpdated, sc.CampaignID, r, wl, &realtime.Audience{GMOnly: true})
+		s.publishVisionChange(r, sc, nil)
+		s.respond(w, r, http.StatusOK, wl)
+	}
+}
+
+func (s *server) handleWallsDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, wl, err := s.findWall(r)
+		if err != nil {
+			s.error(w, r, http.StatusNotFound, err)
+			return
+		}
+
+		if !r.Context().Value(ctxKeyMember).(*model.Member).IsGM() {
+			s.error(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		if err := s.store.Transaction(func(tx store.Store) error {
+			if err := tx.Wall().Delete(wl.ID); err != nil {
+				return err
+			}
+
+			return logAction(tx, r, sc, model.TargetWall, wl.ID, wl, nil)
+		}); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.publish(realtime.EventWallDeleted, sc.CampaignID, r, map[string]uuid.UUID{"id": wl.ID, "scene_id": sc.ID}, &realtime.Audience{GMOnly: true})
+		s.publishVisionChange(r, sc, nil)
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// findWall loads the {wallID} wall of the {sceneID} scene.
+func (s *server) findWall(r *http.Request) (*model.Scene, *model.Wall, error) {
+	sc, err := s.findScene(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id, err := uuid.Parse(mux.Vars(r)["wallID"])
+	if err != nil {
+		return nil, nil, ErrNotFound
+	}
+
+	wl, err := s.store.Wall().Find(id)
+	if err != nil || wl.SceneID != sc.ID {
+		return nil, nil, ErrNotFound
+	}
+
+	return sc, wl, nil
+}
+
+// canOpen reports whether a player may open or close the wall: it has to
+// be a door, and on scenes with fog of war one their tokens see.
+func (s *server) canOpen(member *model.Member, sc *model.Scene, wl *model.Wall) (bool, error) {
+	if !member.CanPlay() || wl.Kind != model.WallDoor {
+		return false, nil
+	}
+
+	if !sc.FogOfWar {
+		return true, nil
+	}
+
+	v, err := s.explore(sc, member.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, d := range v.Doors {
+		if d.ID == wl.ID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
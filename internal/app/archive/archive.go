@@ -0,0 +1,264 @@
+// Package archive reads and writes campaign archives, ZIP files a campaign
+// is exported to and imported from:
+//
+//	manifest.json     the Manifest
+//	campaign.json     the Campaign, every table of it
+//	content/<sha256>  the content of its assets and their thumbnails
+//
+// Archives keep the IDs the campaign had where it was exported, so they
+// have to be given new ones on import.
+package archive
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+)
+
+// Version is the version of the archives written, and the latest one read.
+const Version = 1
+
+const (
+	manifestName = "manifest.json"
+	campaignName = "campaign.json"
+	contentDir   = "content/"
+)
+
+var (
+	ErrNoManifest         = errors.New("archive has no manifest")
+	ErrUnsupportedVersion = errors.New("archive version is not supported")
+	ErrNoCampaign         = errors.New("archive has no campaign")
+	ErrMissingContent     = errors.New("archive is missing asset content")
+	ErrContentMismatch    = errors.New("asset content doesn't match its hash")
+	ErrInvalidHash        = errors.New("asset hash is not a SHA-256 hash")
+)
+
+var hashRegexp = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+type Manifest struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Name       string    `json:"name"`
+	System     string    `json:"system"`
+}
+
+// Campaign is everything about a campaign but its members, who have
+// accounts on the server it was exported from only.
+type Campaign struct {
+	Campaign       *model.Campaign        `json:"campaign"`
+	Characters     []*model.Character     `json:"characters"`
+	Scenes         []*model.Scene         `json:"scenes"`
+	Tokens         []*model.Token         `json:"tokens"`
+	Walls          []*model.Wall          `json:"walls"`
+	Lights         []*model.Light         `json:"lights"`
+	Combats        []*model.Combat        `json:"combats"`
+	Combatants     []*model.Combatant     `json:"combatants"`
+	JournalFolders []*model.JournalFolder `json:"journal_folders"`
+	JournalEntries []*model.JournalEntry  `json:"journal_entries"`
+	Rolls          []*model.Roll          `json:"rolls"`
+	ChatMessages   []*model.ChatMessage   `json:"chat_messages"`
+	Packs          []*model.CampaignPack  `json:"packs"`
+	Assets         []*Asset               `json:"assets"`
+}
+
+// Asset is an asset filed under the campaign, with the thumbnail its API
+// representation leaves out.
+type Asset struct {
+	*model.Asset
+	Thumbnail string `json:"thumbnail,omitempty"`
+}
+
+// Hashes returns the hashes of the content of the assets, once each.
+func (c *Campaign) Hashes() []string {
+	seen := map[string]bool{}
+	hashes := []string{}
+	for _, a := range c.Assets {
+		for _, h := range []string{a.Hash, a.Thumbnail} {
+			if h != "" && !seen[h] {
+				seen[h] = true
+				hashes = append(hashes, h)
+			}
+		}
+	}
+
+	return hashes
+}
+
+// Write writes the campaign to w as an archive, with the content of its
+// assets read through open.
+func Write(w io.Writer, c *Campaign, open func(hash string) (io.ReadCloser, error)) error {
+	zw := zip.NewWriter(w)
+
+	m := &Manifest{
+		Version:    Version,
+		ExportedAt: time.Now().UTC(),
+		Name:       c.Campaign.Name,
+		System:     c.Campaign.System,
+	}
+	if err := writeJSON(zw, manifestName, m); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, campaignName, c); err != nil {
+		return err
+	}
+
+	for _, h := range c.Hashes() {
+		// Content is compressed already more often than not.
+		dst, err := zw.CreateHeader(&zip.FileHeader{Name: contentDir + h, Method: zip.Store})
+		if err != nil {
+			return err
+		}
+
+		src, err := open(h)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(dst, src)
+		src.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func writeJSON(zw *zip.Writer, name string, v interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(f).Encode(v)
+}
+
+// Reader reads an archive, checked to be complete.
+type Reader struct {
+	Manifest *Manifest
+	Campaign *Campaign
+	content  map[string]*zip.File
+}
+
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]*zip.File{}
+	content := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+		if strings.HasPrefix(f.Name, contentDir) {
+			content[strings.TrimPrefix(f.Name, contentDir)] = f
+		}
+	}
+
+	ar := &Reader{
+		Manifest: &Manifest{},
+		Campaign: &Campaign{},
+		content:  content,
+	}
+
+	f, ok := files[manifestName]
+	if !ok {
+		return nil, ErrNoManifest
+	}
+	if err := readJSON(f, ar.Manifest); err != nil {
+		return nil, err
+	}
+	if ar.Manifest.Version < 1 || ar.Manifest.Version > Version {
+		return nil, ErrUnsupportedVersion
+	}
+
+	f, ok = files[campaignName]
+	if !ok {
+		return nil, ErrNoCampaign
+	}
+	if err := readJSON(f, ar.Campaign); err != nil {
+		return nil, err
+	}
+	if ar.Campaign.Campaign == nil {
+		return nil, ErrNoCampaign
+	}
+
+	for _, a := range ar.Campaign.Assets {
+		if a.Asset == nil {
+			return nil, ErrMissingContent
+		}
+	}
+	for _, h := range ar.Campaign.Hashes() {
+		if !hashRegexp.MatchString(h) {
+			return nil, ErrInvalidHash
+		}
+		if _, ok := content[h]; !ok {
+			return nil, ErrMissingContent
+		}
+	}
+
+	return ar, nil
+}
+
+// ContentSize is how much the content of the assets takes decompressed, as
+// the archive declares it. Reading more than declared fails, so it's safe
+// to check against a quota before anything is read.
+func (r *Reader) ContentSize() int64 {
+	var size int64
+	for _, h := range r.Campaign.Hashes() {
+		size += int64(r.content[h].UncompressedSize64)
+	}
+
+	return size
+}
+
+func readJSON(f *zip.File, v interface{}) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return json.NewDecoder(r).Decode(v)
+}
+
+// Open opens the content with the hash. Reading it fails with
+// ErrContentMismatch at the end if the content doesn't have the hash, so
+// it can be stored under the hash as it's read.
+func (r *Reader) Open(hash string) (io.ReadCloser, error) {
+	f, ok := r.content[hash]
+	if !ok {
+		return nil, ErrMissingContent
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	return &verifier{ReadCloser: rc, hash: sha256.New(), want: hash}, nil
+}
+
+type verifier struct {
+	io.ReadCloser
+	hash hash.Hash
+	want string
+}
+
+func (v *verifier) Read(p []byte) (int, error) {
+	n, err := v.ReadCloser.Read(p)
+	v.hash.Write(p[:n])
+	if err == io.EOF && hex.EncodeToString(v.hash.Sum(nil)) != v.want {
+		return n, ErrContentMismatch
+	}
+
+	return n, err
+}
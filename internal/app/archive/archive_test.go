@@ -0,0 +1,166 @@
+package archive_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/archive"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func testCampaign(t *testing.T, content map[string][]byte) *archive.Campaign {
+	t.Helper()
+
+	u := model.TestUser(t)
+	c := model.TestCampaign(t, u)
+	a := model.TestAsset(t, u)
+	sum := sha256.Sum256([]byte("map"))
+	a.Hash = hex.EncodeToString(sum[:])
+	content[a.Hash] = []byte("map")
+
+	return &archive.Campaign{
+		Campaign: c,
+		Scenes:   []*model.Scene{model.TestScene(t, c)},
+		Assets:   []*archive.Asset{{Asset: a}, {Asset: a}},
+	}
+}
+
+func testOpen(content map[string][]byte) func(string) (io.ReadCloser, error) {
+	return func(hash string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(content[hash])), nil
+	}
+}
+
+func TestWrite(t *testing.T) {
+	content := map[string][]byte{}
+	c := testCampaign(t, content)
+
+	b := &bytes.Buffer{}
+	assert.NoError(t, archive.Write(b, c, testOpen(content)))
+
+	r, err := archive.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, archive.Version, r.Manifest.Version)
+	assert.Equal(t, c.Campaign.Name, r.Manifest.Name)
+	assert.Equal(t, c.Campaign.Name, r.Campaign.Campaign.Name)
+	assert.Len(t, r.Campaign.Scenes, 1)
+	assert.Len(t, r.Campaign.Assets, 2)
+
+	f, err := r.Open(c.Assets[0].Hash)
+	if assert.NoError(t, err) {
+		got, err := io.ReadAll(f)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("map"), got)
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	content := map[string][]byte{}
+	c := testCampaign(t, content)
+	hash := c.Assets[0].Hash
+
+	testCases := []struct {
+		name  string
+		files map[string]string
+		err   error
+	}{
+		{
+			name:  "no manifest",
+			files: map[string]string{"campaign.json": `{"campaign": {}}`},
+			err:   archive.ErrNoManifest,
+		},
+		{
+			name: "unsupported version",
+			files: map[string]string{
+				"manifest.json": `{"version": 2}`,
+				"campaign.json": `{"campaign": {}}`,
+			},
+			err: archive.ErrUnsupportedVersion,
+		},
+		{
+			name:  "no campaign",
+			files: map[string]string{"manifest.json": `{"version": 1}`},
+			err:   archive.ErrNoCampaign,
+		},
+		{
+			name: "missing content",
+			files: map[string]string{
+				"manifest.json": `{"version": 1}`,
+				"campaign.json": `{"campaign": {}, "assets": [{"hash": "` + hash + `"}]}`,
+			},
+			err: archive.ErrMissingContent,
+		},
+		{
+			name: "invalid hash",
+			files: map[string]string{
+				"manifest.json": `{"version": 1}`,
+				"campaign.json": `{"campaign": {}, "assets": [{"hash": "a"}]}`,
+				"content/a":     "map",
+			},
+			err: archive.ErrInvalidHash,
+		},
+		{
+			name: "invalid thumbnail",
+			files: map[string]string{
+				"manifest.json":      `{"version": 1}`,
+				"campaign.json":      `{"campaign": {}, "assets": [{"hash": "` + hash + `", "thumbnail": "../` + hash + `"}]}`,
+				"content/" + hash:    "map",
+				"content/../" + hash: "map",
+			},
+			err: archive.ErrInvalidHash,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			zw := zip.NewWriter(b)
+			for name, body := range tc.files {
+				f, _ := zw.Create(name)
+				f.Write([]byte(body))
+			}
+			zw.Close()
+
+			_, err := archive.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+			assert.Equal(t, tc.err, err)
+		})
+	}
+}
+
+func TestReader_ContentSize(t *testing.T) {
+	content := map[string][]byte{}
+	c := testCampaign(t, content)
+
+	b := &bytes.Buffer{}
+	assert.NoError(t, archive.Write(b, c, testOpen(content)))
+	r, err := archive.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(len("map")), r.ContentSize())
+	}
+}
+
+func TestReader_Open(t *testing.T) {
+	content := map[string][]byte{}
+	c := testCampaign(t, content)
+	content[c.Assets[0].Hash] = []byte("not the map")
+
+	b := &bytes.Buffer{}
+	assert.NoError(t, archive.Write(b, c, testOpen(content)))
+	r, err := archive.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	f, err := r.Open(c.Assets[0].Hash)
+	if assert.NoError(t, err) {
+		_, err = io.ReadAll(f)
+		assert.Equal(t, archive.ErrContentMismatch, err)
+	}
+}
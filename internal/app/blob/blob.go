@@ -0,0 +1,293 @@
+// Package blob stores opaque files by key, on local disk or in memory.
+// Keys are slash-separated paths chosen by the server, never by users.
+package blob
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+type Storage interface {
+	// Put stores the contents of r under key, replacing any blob there, and
+	// returns the number of bytes written.
+	Put(key string, r io.Reader) (int64, error)
+	// Append adds the contents of r to the blob under key, creating it if
+	// needed, and returns its new size, which counts what was read before r
+	// failed if it did.
+	Append(key string, r io.Reader) (int64, error)
+	Open(key string) (io.ReadSeekCloser, error)
+	// Move renames a blob, replacing any blob under the new key.
+	Move(from string, to string) error
+	Exists(key string) (bool, error)
+	Delete(key string) error
+}
+
+// Local keeps blobs as files under a directory.
+type Local struct {
+	dir string
+}
+
+func NewLocal(dir string) *Local {
+	return &Local{
+		dir: dir,
+	}
+}
+
+func (l *Local) Put(key string, r io.Reader) (int64, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+
+	// Write next to the destination and rename, so readers never see a
+	// partial blob.
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(f.Name())
+
+	n, err := io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		return 0, err
+	}
+
+	if err := f.Close(); err != nil {
+		return 0, err
+	}
+
+	return n, os.Rename(f.Name(), path)
+}
+
+func (l *Local) Append(key string, r io.Reader) (int64, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	info, serr := f.Stat()
+	if serr != nil {
+		return 0, serr
+	}
+
+	return info.Size(), err
+}
+
+func (l *Local) Open(key string) (io.ReadSeekCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	return f, err
+}
+
+func (l *Local) Move(from string, to string) error {
+	src, err := l.path(from)
+	if err != nil {
+		return err
+	}
+
+	dst, err := l.path(to)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	if err := os.Rename(src, dst); errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (l *Local) Exists(key string) (bool, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (l *Local) Delete(key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (l *Local) path(key string) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
+
+// Memory keeps blobs in memory, for tests.
+type Memory struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		blobs: make(map[string][]byte),
+	}
+}
+
+func (m *Memory) Put(key string, r io.Reader) (int64, error) {
+	if !validKey(key) {
+		return 0, ErrInvalidKey
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blobs[key] = b
+
+	return int64(len(b)), nil
+}
+
+func (m *Memory) Append(key string, r io.Reader) (int64, error) {
+	if !validKey(key) {
+		return 0, ErrInvalidKey
+	}
+
+	b, err := io.ReadAll(r)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blobs[key] = append(m.blobs[key], b...)
+
+	return int64(len(m.blobs[key])), err
+}
+
+func (m *Memory) Open(key string) (io.ReadSeekCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.blobs[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return nopCloser{bytes.NewReader(b)}, nil
+}
+
+func (m *Memory) Move(from string, to string) error {
+	if !validKey(to) {
+		return ErrInvalidKey
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.blobs[from]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(m.blobs, from)
+	m.blobs[to] = b
+
+	return nil
+}
+
+func (m *Memory) Exists(key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.blobs[key]
+
+	return ok, nil
+}
+
+func (m *Memory) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.blobs[key]; !ok {
+		return ErrNotFound
+	}
+	delete(m.blobs, key)
+
+	return nil
+}
+
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// validKey rejects keys that could escape the storage root.
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,51 @@
+package blob_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/blob"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage(t *testing.T) {
+	storages := map[string]blob.Storage{
+		"local":  blob.NewLocal(t.TempDir()),
+		"memory": blob.NewMemory(),
+	}
+
+	for name, s := range storages {
+		t.Run(name, func(t *testing.T) {
+			n, err := s.Put("a/b/c", strings.NewReader("hello"))
+			assert.NoError(t, err)
+			assert.Equal(t, int64(5), n)
+
+			n, err = s.Append("a/b/c", strings.NewReader(", world"))
+			assert.NoError(t, err)
+			assert.Equal(t, int64(12), n)
+
+			assert.NoError(t, s.Move("a/b/c", "d"))
+			ok, err := s.Exists("a/b/c")
+			assert.NoError(t, err)
+			assert.False(t, ok)
+
+			f, err := s.Open("d")
+			assert.NoError(t, err)
+			f.Seek(7, io.SeekStart)
+			b, _ := io.ReadAll(f)
+			f.Close()
+			assert.Equal(t, "world", string(b))
+
+			assert.NoError(t, s.Delete("d"))
+			assert.ErrorIs(t, s.Delete("d"), blob.ErrNotFound)
+			_, err = s.Open("d")
+			assert.ErrorIs(t, err, blob.ErrNotFound)
+
+			for _, key := range []string{"", "../etc/passwd", "/abs", "a//b", "a/./b"} {
+				_, err := s.Put(key, strings.NewReader("x"))
+				assert.ErrorIs(t, err, blob.ErrInvalidKey, key)
+			}
+		})
+	}
+}
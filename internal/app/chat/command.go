@@ -0,0 +1,138 @@
+package chat
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/dice"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+)
+
+// MaxRolls limits how many rolls a single message can make.
+const MaxRolls = 10
+
+var (
+	ErrUnknownCommand = errors.New("unknown chat command")
+	ErrInvalidCommand = errors.New("invalid chat command")
+	ErrTooManyRolls   = errors.New("too many rolls in a message")
+)
+
+var inlineRoll = regexp.MustCompile(`\[\[([^\[\]]+)\]\]`)
+
+// Command is a parsed chat input. Kind is the message kind the command
+// implies, empty if it leaves the kind up to the author. Roll is the dice
+// expression of a roll command, which may refer to character sheet values.
+type Command struct {
+	Kind       string
+	Body       string
+	Recipients []string
+	Roll       string
+}
+
+// Parse reads a chat input that may start with a slash command:
+//
+//	/roll, /r <dice> [label]          roll for everyone to see
+//	/gmroll, /gr <dice> [label]       roll only the GMs see
+//	/me, /em <action>                 emote
+//	/w, /whisper <user>[,<user>] <text> whisper to users by username
+//
+// A leading "//" escapes the slash. Input without a command is plain text.
+func Parse(input string) (*Command, error) {
+	input = strings.TrimSpace(input)
+	if strings.HasPrefix(input, "//") {
+		return &Command{Body: input[1:]}, nil
+	}
+	if !strings.HasPrefix(input, "/") {
+		return &Command{Body: input}, nil
+	}
+
+	name, rest, _ := strings.Cut(input[1:], " ")
+	rest = strings.TrimSpace(rest)
+
+	switch strings.ToLower(name) {
+	case "r", "roll":
+		return parseRoll("", rest)
+	case "gr", "gmroll":
+		return parseRoll(model.ChatGM, rest)
+	case "me", "em":
+		if rest == "" {
+			return nil, ErrInvalidCommand
+		}
+
+		return &Command{Kind: model.ChatEmote, Body: rest}, nil
+	case "w", "whisper":
+		to, body, _ := strings.Cut(rest, " ")
+		body = strings.TrimSpace(body)
+		if to == "" || body == "" {
+			return nil, ErrInvalidCommand
+		}
+
+		c := &Command{Kind: model.ChatWhisper, Body: body}
+		for _, username := range strings.Split(to, ",") {
+			if username = strings.TrimPrefix(username, "@"); username != "" {
+				c.Recipients = append(c.Recipients, username)
+			}
+		}
+		if len(c.Recipients) == 0 {
+			return nil, ErrInvalidCommand
+		}
+
+		return c, nil
+	}
+
+	return nil, ErrUnknownCommand
+}
+
+func parseRoll(kind string, rest string) (*Command, error) {
+	// References are resolved on evaluation, here any name will do.
+	_, source, label, err := dice.ParsePrefix(rest, func(string) (int, bool) {
+		return 0, true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Command{
+		Kind: kind,
+		Body: strings.TrimSpace(label),
+		Roll: source,
+	}, nil
+}
+
+// Evaluate rolls the command dice and every inline [[dice]] block of the
+// body, in that order, resolving references with lookup, which may be nil.
+// Blocks that aren't dice expressions stay plain text.
+func (c *Command) Evaluate(r *dice.Roller, lookup dice.Lookup) ([]model.ChatRoll, error) {
+	rolls := []model.ChatRoll{}
+	if c.Roll != "" {
+		e, err := dice.ParseWith(c.Roll, lookup)
+		if err != nil {
+			return nil, err
+		}
+
+		rolls = append(rolls, model.ChatRoll{
+			Result: *r.Evaluate(e),
+			Source: c.Roll,
+		})
+	}
+
+	for _, m := range inlineRoll.FindAllStringSubmatch(c.Body, -1) {
+		e, err := dice.ParseWith(m[1], lookup)
+		if err != nil {
+			continue
+		}
+
+		if len(rolls) == MaxRolls {
+			return nil, ErrTooManyRolls
+		}
+
+		rolls = append(rolls, model.ChatRoll{
+			Result: *r.Evaluate(e),
+			Source: m[0],
+			Inline: true,
+		})
+	}
+
+	return rolls, nil
+}
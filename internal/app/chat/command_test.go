@@ -0,0 +1,87 @@
+package chat_test
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/chat"
+	"github.com/bruhlord-s/virttable-api/internal/app/dice"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name       string
+		input      string
+		kind       string
+		body       string
+		recipients []string
+		roll       string
+		err        error
+	}{
+		{"plain text", "hello there", "", "hello there", nil, "", nil},
+		{"escaped slash", "//shrug", "", "/shrug", nil, "", nil},
+		{"roll", "/roll 1d20+5 to hit", "", "to hit", nil, "1d20+5", nil},
+		{"short roll", "/r d20", "", "", nil, "d20", nil},
+		{"gm roll", "/gmroll 1d20 stealth", model.ChatGM, "stealth", nil, "1d20", nil},
+		{"roll with reference", "/r 1d20+@str_mod to hit", "", "to hit", nil, "1d20+@str_mod", nil},
+		{"emote", "/me draws her sword", model.ChatEmote, "draws her sword", nil, "", nil},
+		{"whisper", "/w @alice,bob meet me at the inn", model.ChatWhisper, "meet me at the inn", []string{"alice", "bob"}, "", nil},
+		{"roll without dice", "/roll to hit", "", "", nil, "", dice.ErrInvalidExpression},
+		{"emote without action", "/me", "", "", nil, "", chat.ErrInvalidCommand},
+		{"whisper without text", "/w alice", "", "", nil, "", chat.ErrInvalidCommand},
+		{"unknown command", "/dance", "", "", nil, "", chat.ErrUnknownCommand},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := chat.Parse(tc.input)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.kind, c.Kind)
+			assert.Equal(t, tc.body, c.Body)
+			assert.Equal(t, tc.recipients, c.Recipients)
+			assert.Equal(t, tc.roll, c.Roll)
+		})
+	}
+}
+
+func TestCommand_Evaluate(t *testing.T) {
+	r := dice.NewRoller(rand.NewSource(1))
+
+	c, err := chat.Parse("/roll 1d20+5 attack, [[2d6+3]] slashing and [[not dice]]")
+	assert.NoError(t, err)
+
+	rolls, err := c.Evaluate(r, nil)
+	assert.NoError(t, err)
+	assert.Len(t, rolls, 2)
+	assert.Equal(t, "1d20+5", rolls[0].Source)
+	assert.False(t, rolls[0].Inline)
+	assert.Equal(t, "[[2d6+3]]", rolls[1].Source)
+	assert.True(t, rolls[1].Inline)
+	assert.Len(t, rolls[1].Dice, 2)
+	assert.Equal(t, 3, rolls[1].Modifier)
+
+	c, _ = chat.Parse(strings.Repeat("[[d6]]", chat.MaxRolls+1))
+	_, err = c.Evaluate(r, nil)
+	assert.ErrorIs(t, err, chat.ErrTooManyRolls)
+
+	lookup := func(name string) (int, bool) {
+		return 4, name == "str_mod"
+	}
+	c, _ = chat.Parse("/r 1d20+@str_mod to hit, [[1d6+@str_mod]] damage")
+	rolls, err = c.Evaluate(r, lookup)
+	assert.NoError(t, err)
+	assert.Equal(t, "1d20+4", rolls[0].Expression)
+	assert.Equal(t, "1d20+@str_mod", rolls[0].Source)
+	assert.Equal(t, "1d6+4", rolls[1].Expression)
+
+	_, err = c.Evaluate(r, nil)
+	assert.ErrorIs(t, err, dice.ErrInvalidExpression)
+}
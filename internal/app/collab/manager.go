@@ -0,0 +1,700 @@
+// Package collab lets several people edit a text document at once. Each
+// instance keeps the documents its editors have open in memory and
+// transforms their concurrent operations against each other. The storage
+// decides the order of operations between instances: an operation is only
+// applied once it got the next revision there. Snapshots of the text are
+// saved every few seconds.
+package collab
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/ot"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// EventOperation and EventEditor carry operations and editors between
+	// instances. They are consumed by Manager and never delivered to
+	// campaign clients.
+	EventOperation = "collab.operation"
+	EventEditor    = "collab.editor"
+
+	saveInterval = 5 * time.Second
+	// historySize is how many revisions behind an operation can be and
+	// still be transformed instead of rejected.
+	historySize = 200
+	// appendAttempts bounds how often an operation is transformed again
+	// after other instances took the revision it was meant for.
+	appendAttempts = 10
+)
+
+var (
+	ErrRevisionTaken    = errors.New("revision taken")
+	ErrStaleRevision    = errors.New("revision is too old, reload the document")
+	ErrReadOnly         = errors.New("document is read-only")
+	ErrTooLong          = errors.New("document is too long")
+	ErrInvalidSelection = errors.New("invalid selection")
+)
+
+// Operation is an op that made the revision of a document.
+type Operation struct {
+	Revision int       `json:"revision"`
+	ClientID string    `json:"client_id,omitempty"`
+	UserID   uuid.UUID `json:"user_id"`
+	Op       ot.Op     `json:"op"`
+}
+
+// Storage keeps the documents and the operations made on them.
+type Storage interface {
+	// Load returns the last snapshot of the document and its revision.
+	Load(docID uuid.UUID) (body string, revision int, err error)
+	// Operations returns the operations after the revision, in order.
+	Operations(docID uuid.UUID, after int) ([]*Operation, error)
+	// Append stores the operation, failing with ErrRevisionTaken if the
+	// document has an operation with its revision already.
+	Append(docID uuid.UUID, o *Operation) error
+	// Save stores a snapshot unless a later one is stored already.
+	Save(docID uuid.UUID, body string, revision int) error
+	// Prune deletes the operations up to the revision.
+	Prune(docID uuid.UUID, through int) error
+}
+
+// Editor is someone with a document open, on any instance.
+type Editor struct {
+	ClientID  string     `json:"client_id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	Selection *Selection `json:"selection"`
+}
+
+// Selection is an editor's selection, the cursor when Anchor equals Head.
+type Selection struct {
+	Anchor int `json:"anchor"`
+	Head   int `json:"head"`
+}
+
+func (s *Selection) transform(op ot.Op) *Selection {
+	return &Selection{Anchor: op.TransformIndex(s.Anchor), Head: op.TransformIndex(s.Head)}
+}
+
+// Manager keeps the documents open on this instance.
+type Manager struct {
+	id        string
+	mu        sync.Mutex
+	storage   Storage
+	pubsub    realtime.PubSub
+	logger    *logrus.Logger
+	maxLength int
+	docs      map[uuid.UUID]*document
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+type document struct {
+	mu         sync.Mutex
+	id         uuid.UUID
+	campaignID uuid.UUID
+	loaded     bool
+	body       string
+	revision   int
+	saved      int
+	// history holds the operations up to revision, at most historySize.
+	history  []*Operation
+	editors  map[string]*Editor
+	sessions map[*Session]struct{}
+	refs     int
+}
+
+type operationEvent struct {
+	DocID     uuid.UUID  `json:"doc_id"`
+	Operation *Operation `json:"operation"`
+}
+
+// editorEvent tells other instances about an editor of the Instance. Their
+// selection is as of Revision. Instances answer a Joined event with their
+// own editors.
+type editorEvent struct {
+	Instance string    `json:"instance"`
+	DocID    uuid.UUID `json:"doc_id"`
+	Revision int       `json:"revision"`
+	Editor   *Editor   `json:"editor"`
+	Joined   bool      `json:"joined,omitempty"`
+	Left     bool      `json:"left,omitempty"`
+}
+
+// NewManager returns a manager of documents up to maxLength characters
+// long.
+func NewManager(storage Storage, pubsub realtime.PubSub, logger *logrus.Logger, maxLength int) *Manager {
+	m := &Manager{
+		id:        uuid.New().String(),
+		storage:   storage,
+		pubsub:    pubsub,
+		logger:    logger,
+		maxLength: maxLength,
+		docs:      make(map[uuid.UUID]*document),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	go m.run()
+
+	return m
+}
+
+// Open joins the document as a new editor. Read-only editors follow the
+// changes without making any.
+func (m *Manager) Open(campaignID uuid.UUID, docID uuid.UUID, userID uuid.UUID, readOnly bool) (*Session, error) {
+	d, err := m.acquire(campaignID, docID)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newSession(m, d, userID, readOnly)
+	editor := &Editor{ClientID: s.id, UserID: userID}
+
+	d.mu.Lock()
+	if err := m.catchUp(d); err != nil {
+		d.mu.Unlock()
+		m.release(d)
+
+		return nil, err
+	}
+
+	editors := []*Editor{}
+	for _, e := range d.editors {
+		editors = append(editors, copyEditor(e))
+	}
+	d.sessions[s] = struct{}{}
+	d.editors[s.id] = editor
+	s.deliver(d.init(s, editors))
+	d.broadcast(&Message{Type: MessageJoined, ClientID: s.id, UserID: &userID}, s)
+	revision := d.revision
+	d.mu.Unlock()
+
+	m.publish(EventEditor, d, &editorEvent{Instance: m.id, DocID: d.id, Revision: revision, Editor: copyEditor(editor), Joined: true})
+
+	return s, nil
+}
+
+// Replace sets the whole text of the document, as an operation of the user
+// concurrent with the editors', and saves it right away.
+func (m *Manager) Replace(campaignID uuid.UUID, docID uuid.UUID, userID uuid.UUID, body string) error {
+	d, err := m.acquire(campaignID, docID)
+	if err != nil {
+		return err
+	}
+	defer m.release(d)
+
+	d.mu.Lock()
+	if err := m.catchUp(d); err != nil {
+		d.mu.Unlock()
+		return err
+	}
+
+	var o *Operation
+	if op := ot.Diff(d.body, body); !op.Noop() {
+		o, err = m.submit(d, nil, userID, d.revision, op)
+	}
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if o != nil {
+		m.publish(EventOperation, d, &operationEvent{DocID: d.id, Operation: o})
+	}
+
+	return m.save(d)
+}
+
+// Receive applies operations and editors from other instances. It returns
+// false for every other event so the caller can hand it to the hub.
+func (m *Manager) Receive(e *realtime.Event) bool {
+	switch e.Type {
+	case EventOperation:
+		ev := &operationEvent{}
+		if err := json.Unmarshal(e.Payload, ev); err != nil {
+			m.logger.Error(err.Error())
+			return true
+		}
+
+		m.receiveOperation(ev)
+	case EventEditor:
+		ev := &editorEvent{}
+		if err := json.Unmarshal(e.Payload, ev); err != nil {
+			m.logger.Error(err.Error())
+			return true
+		}
+
+		m.receiveEditor(ev)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// Close stops saving snapshots periodically after saving them one last
+// time.
+func (m *Manager) Close() {
+	close(m.done)
+	<-m.stopped
+}
+
+func (m *Manager) receiveOperation(ev *operationEvent) {
+	d := m.find(ev.DocID)
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch {
+	case !d.loaded, ev.Operation.Revision <= d.revision:
+		return
+	case ev.Operation.Revision == d.revision+1:
+		if err := d.apply(ev.Operation, nil); err != nil {
+			m.logger.Error(err.Error())
+		}
+	default:
+		if err := m.catchUp(d); err != nil {
+			m.logger.Error(err.Error())
+		}
+	}
+}
+
+func (m *Manager) receiveEditor(ev *editorEvent) {
+	d := m.find(ev.DocID)
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	if !d.loaded || ev.Editor == nil || ev.Instance == m.id {
+		d.mu.Unlock()
+		return
+	}
+
+	if ev.Left {
+		delete(d.editors, ev.Editor.ClientID)
+		d.broadcast(&Message{Type: MessageLeft, ClientID: ev.Editor.ClientID, UserID: &ev.Editor.UserID}, nil)
+		d.mu.Unlock()
+
+		return
+	}
+
+	if ev.Revision > d.revision {
+		if err := m.catchUp(d); err != nil {
+			m.logger.Error(err.Error())
+		}
+	}
+
+	editor := copyEditor(ev.Editor)
+	if editor.Selection != nil {
+		editor.Selection = d.transformSelection(editor.Selection, ev.Revision)
+	}
+	_, known := d.editors[editor.ClientID]
+	d.editors[editor.ClientID] = editor
+
+	msg := &Message{Type: MessageSelection, ClientID: editor.ClientID, UserID: &editor.UserID, Selection: editor.Selection}
+	if !known {
+		msg.Type = MessageJoined
+	}
+	d.broadcast(msg, nil)
+
+	local := []*editorEvent{}
+	if ev.Joined {
+		for s := range d.sessions {
+			local = append(local, &editorEvent{Instance: m.id, DocID: d.id, Revision: d.revision, Editor: copyEditor(d.editors[s.id])})
+		}
+	}
+	d.mu.Unlock()
+
+	for _, ev := range local {
+		m.publish(EventEditor, d, ev)
+	}
+}
+
+// submit transforms the op made at the revision against the operations
+// since and stores it with the next revision. d.mu must be held.
+func (m *Manager) submit(d *document, origin *Session, userID uuid.UUID, revision int, op ot.Op) (*Operation, error) {
+	for attempt := 0; attempt < appendAttempts; attempt++ {
+		if err := m.catchUp(d); err != nil {
+			return nil, err
+		}
+
+		transformed, err := d.transform(op, revision)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := transformed.Apply(d.body)
+		if err != nil {
+			return nil, err
+		}
+		if utf8.RuneCountInString(body) > m.maxLength {
+			return nil, ErrTooLong
+		}
+
+		o := &Operation{Revision: d.revision + 1, UserID: userID, Op: transformed}
+		if origin != nil {
+			o.ClientID = origin.id
+		}
+		if err := m.storage.Append(d.id, o); err != nil {
+			if err == ErrRevisionTaken {
+				continue
+			}
+
+			return nil, err
+		}
+
+		return o, d.apply(o, origin)
+	}
+
+	return nil, ErrRevisionTaken
+}
+
+func (m *Manager) selectText(s *Session, revision int, sel *Selection) error {
+	d := s.doc
+	d.mu.Lock()
+	if revision > d.revision {
+		if err := m.catchUp(d); err != nil {
+			d.mu.Unlock()
+			return err
+		}
+	}
+
+	length := utf8.RuneCountInString(d.body)
+	sel = d.transformSelection(sel, revision)
+	if sel == nil || sel.Anchor < 0 || sel.Head < 0 || sel.Anchor > length || sel.Head > length {
+		d.mu.Unlock()
+		return ErrInvalidSelection
+	}
+
+	editor, ok := d.editors[s.id]
+	if !ok {
+		d.mu.Unlock()
+		return nil
+	}
+	editor.Selection = sel
+	d.broadcast(&Message{Type: MessageSelection, ClientID: s.id, UserID: &s.userID, Selection: sel}, s)
+	ev := &editorEvent{Instance: m.id, DocID: d.id, Revision: d.revision, Editor: copyEditor(editor)}
+	d.mu.Unlock()
+
+	m.publish(EventEditor, d, ev)
+
+	return nil
+}
+
+func (m *Manager) leave(s *Session) {
+	d := s.doc
+	d.mu.Lock()
+	if _, ok := d.sessions[s]; !ok {
+		d.mu.Unlock()
+		return
+	}
+
+	delete(d.sessions, s)
+	editor := d.editors[s.id]
+	delete(d.editors, s.id)
+	s.closeSend()
+	d.broadcast(&Message{Type: MessageLeft, ClientID: s.id, UserID: &s.userID}, nil)
+	d.mu.Unlock()
+
+	m.publish(EventEditor, d, &editorEvent{Instance: m.id, DocID: d.id, Editor: editor, Left: true})
+	m.release(d)
+}
+
+// acquire returns the document loaded, to be released when done with it.
+func (m *Manager) acquire(campaignID uuid.UUID, docID uuid.UUID) (*document, error) {
+	m.mu.Lock()
+	d, ok := m.docs[docID]
+	if !ok {
+		d = &document{
+			id:         docID,
+			campaignID: campaignID,
+			editors:    make(map[string]*Editor),
+			sessions:   make(map[*Session]struct{}),
+		}
+		m.docs[docID] = d
+	}
+	d.refs++
+	m.mu.Unlock()
+
+	d.mu.Lock()
+	err := m.load(d)
+	d.mu.Unlock()
+	if err != nil {
+		m.release(d)
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// release unloads the document once nobody uses it, saving it first.
+func (m *Manager) release(d *document) {
+	m.mu.Lock()
+	d.refs--
+	unused := d.refs == 0
+	if unused && m.docs[d.id] == d {
+		delete(m.docs, d.id)
+	}
+	m.mu.Unlock()
+
+	if unused {
+		if err := m.save(d); err != nil {
+			m.logger.Error(err.Error())
+		}
+	}
+}
+
+func (m *Manager) find(docID uuid.UUID) *document {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.docs[docID]
+}
+
+// load reads the document from the storage unless it's loaded already.
+// d.mu must be held.
+func (m *Manager) load(d *document) error {
+	if d.loaded {
+		return nil
+	}
+
+	body, revision, err := m.storage.Load(d.id)
+	if err != nil {
+		return err
+	}
+
+	operations, err := m.storage.Operations(d.id, revision)
+	if err != nil {
+		return err
+	}
+	if len(operations) > 0 && operations[0].Revision != revision+1 {
+		return ErrStaleRevision
+	}
+
+	d.body = body
+	d.revision = revision
+	d.saved = revision
+	d.history = nil
+	for _, o := range operations {
+		if err := d.apply(o, nil); err != nil {
+			return err
+		}
+	}
+	d.loaded = true
+
+	return nil
+}
+
+// catchUp applies the operations other instances stored since the
+// document's revision. If some of them were pruned already, the document
+// is loaded again and its editors start over from the snapshot. d.mu must
+// be held.
+func (m *Manager) catchUp(d *document) error {
+	operations, err := m.storage.Operations(d.id, d.revision)
+	if err != nil {
+		return err
+	}
+
+	if len(operations) == 0 || operations[0].Revision == d.revision+1 {
+		for _, o := range operations {
+			if err := d.apply(o, nil); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	d.loaded = false
+	if err := m.load(d); err != nil {
+		return err
+	}
+
+	for s := range d.sessions {
+		editors := []*Editor{}
+		for id, e := range d.editors {
+			if id != s.id {
+				editors = append(editors, copyEditor(e))
+			}
+		}
+		s.deliver(d.init(s, editors))
+	}
+
+	return nil
+}
+
+func (m *Manager) run() {
+	defer close(m.stopped)
+
+	ticker := time.NewTicker(saveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.saveAll()
+		case <-m.done:
+			m.saveAll()
+			return
+		}
+	}
+}
+
+func (m *Manager) saveAll() {
+	m.mu.Lock()
+	docs := make([]*document, 0, len(m.docs))
+	for _, d := range m.docs {
+		docs = append(docs, d)
+	}
+	m.mu.Unlock()
+
+	for _, d := range docs {
+		if err := m.save(d); err != nil {
+			m.logger.Error(err.Error())
+		}
+	}
+}
+
+// save stores a snapshot of the document if it changed since the last one,
+// and prunes the operations too old to be transformed against anymore.
+func (m *Manager) save(d *document) error {
+	d.mu.Lock()
+	body, revision, saved := d.body, d.revision, d.saved
+	d.mu.Unlock()
+
+	if revision <= saved {
+		return nil
+	}
+
+	if err := m.storage.Save(d.id, body, revision); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	if revision > d.saved {
+		d.saved = revision
+	}
+	d.mu.Unlock()
+
+	if revision > historySize {
+		return m.storage.Prune(d.id, revision-historySize)
+	}
+
+	return nil
+}
+
+func (m *Manager) publish(typ string, d *document, payload interface{}) {
+	e, err := realtime.NewEvent(typ, d.campaignID, nil, payload)
+	if err != nil {
+		m.logger.Error(err.Error())
+		return
+	}
+
+	if err := m.pubsub.Publish(e); err != nil {
+		m.logger.Error(err.Error())
+	}
+}
+
+// apply makes the next revision of the document and passes it on to the
+// local editors, acknowledging it to the one who made it.
+func (d *document) apply(o *Operation, origin *Session) error {
+	body, err := o.Op.Apply(d.body)
+	if err != nil {
+		return err
+	}
+
+	d.body = body
+	d.revision = o.Revision
+	d.history = append(d.history, o)
+	if len(d.history) > historySize {
+		d.history = d.history[len(d.history)-historySize:]
+	}
+
+	for _, e := range d.editors {
+		if e.Selection != nil {
+			e.Selection = e.Selection.transform(o.Op)
+		}
+	}
+
+	if origin != nil {
+		origin.deliver(&Message{Type: MessageAck, Revision: o.Revision})
+	}
+	d.broadcast(&Message{Type: MessageOperation, Revision: o.Revision, Op: o.Op, ClientID: o.ClientID, UserID: &o.UserID}, origin)
+
+	return nil
+}
+
+// transform brings an op made at the revision up to date.
+func (d *document) transform(op ot.Op, revision int) (ot.Op, error) {
+	behind := d.revision - revision
+	if behind < 0 || behind > len(d.history) {
+		return nil, ErrStaleRevision
+	}
+
+	for _, o := range d.history[len(d.history)-behind:] {
+		var err error
+		if op, _, err = ot.Transform(op, o.Op); err != nil {
+			return nil, err
+		}
+	}
+
+	return op, nil
+}
+
+// transformSelection brings a selection made at the revision up to date, or
+// returns nil if the revision is out of the history.
+func (d *document) transformSelection(sel *Selection, revision int) *Selection {
+	behind := d.revision - revision
+	if behind < 0 || behind > len(d.history) {
+		return nil
+	}
+
+	for _, o := range d.history[len(d.history)-behind:] {
+		sel = sel.transform(o.Op)
+	}
+
+	return sel
+}
+
+func (d *document) init(s *Session, editors []*Editor) *Message {
+	body := d.body
+
+	return &Message{
+		Type:     MessageInit,
+		Revision: d.revision,
+		Body:     &body,
+		ClientID: s.id,
+		Editors:  editors,
+		ReadOnly: s.readOnly,
+	}
+}
+
+// broadcast delivers the message to the local editors but one.
+func (d *document) broadcast(msg *Message, except *Session) {
+	for s := range d.sessions {
+		if s != except {
+			s.deliver(msg)
+		}
+	}
+}
+
+func copyEditor(e *Editor) *Editor {
+	ce := *e
+	if e.Selection != nil {
+		sel := *e.Selection
+		ce.Selection = &sel
+	}
+
+	return &ce
+}
@@ -0,0 +1,322 @@
+package collab
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/ot"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryStorage struct {
+	mu         sync.Mutex
+	bodies     map[uuid.UUID]string
+	revisions  map[uuid.UUID]int
+	operations map[uuid.UUID][]*Operation
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{
+		bodies:     make(map[uuid.UUID]string),
+		revisions:  make(map[uuid.UUID]int),
+		operations: make(map[uuid.UUID][]*Operation),
+	}
+}
+
+func (s *memoryStorage) Load(docID uuid.UUID) (string, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.bodies[docID], s.revisions[docID], nil
+}
+
+func (s *memoryStorage) Operations(docID uuid.UUID, after int) ([]*Operation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	operations := []*Operation{}
+	for _, o := range s.operations[docID] {
+		if o.Revision > after {
+			operations = append(operations, o)
+		}
+	}
+
+	return operations, nil
+}
+
+func (s *memoryStorage) Append(docID uuid.UUID, o *Operation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	last := s.revisions[docID]
+	if n := len(s.operations[docID]); n > 0 {
+		last = s.operations[docID][n-1].Revision
+	}
+	if o.Revision != last+1 {
+		return ErrRevisionTaken
+	}
+	s.operations[docID] = append(s.operations[docID], o)
+
+	return nil
+}
+
+func (s *memoryStorage) Save(docID uuid.UUID, body string, revision int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if revision > s.revisions[docID] {
+		s.bodies[docID] = body
+		s.revisions[docID] = revision
+	}
+
+	return nil
+}
+
+func (s *memoryStorage) Prune(docID uuid.UUID, through int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := []*Operation{}
+	for _, o := range s.operations[docID] {
+		if o.Revision > through {
+			kept = append(kept, o)
+		}
+	}
+	s.operations[docID] = kept
+
+	return nil
+}
+
+func testManager(t *testing.T, storage Storage, pubsub realtime.PubSub) *Manager {
+	t.Helper()
+
+	m := NewManager(storage, pubsub, logrus.New(), 100)
+	pubsub.Subscribe(func(e *realtime.Event) {
+		m.Receive(e)
+	})
+	t.Cleanup(m.Close)
+
+	return m
+}
+
+func testOp(t *testing.T, s string) ot.Op {
+	t.Helper()
+
+	op := ot.Op{}
+	if err := json.Unmarshal([]byte(s), &op); err != nil {
+		t.Fatal(err)
+	}
+
+	return op
+}
+
+// drain returns the messages queued for the session.
+func drain(s *Session) []*Message {
+	messages := []*Message{}
+	for {
+		select {
+		case msg, ok := <-s.Messages():
+			if !ok {
+				return messages
+			}
+			messages = append(messages, msg)
+		default:
+			return messages
+		}
+	}
+}
+
+func TestManager_Open(t *testing.T) {
+	storage := newMemoryStorage()
+	docID := uuid.New()
+	storage.bodies[docID] = "Session 1"
+	m := testManager(t, storage, realtime.NewLocalPubSub())
+
+	alice, err := m.Open(uuid.New(), docID, uuid.New(), false)
+	assert.NoError(t, err)
+	defer alice.Close()
+
+	messages := drain(alice)
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, MessageInit, messages[0].Type)
+		assert.Equal(t, "Session 1", *messages[0].Body)
+		assert.Equal(t, alice.id, messages[0].ClientID)
+		assert.Empty(t, messages[0].Editors)
+	}
+
+	bobID := uuid.New()
+	bob, _ := m.Open(uuid.New(), docID, bobID, true)
+	messages = drain(bob)
+	if assert.Len(t, messages, 1) {
+		assert.True(t, messages[0].ReadOnly)
+		assert.Len(t, messages[0].Editors, 1)
+	}
+
+	messages = drain(alice)
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, MessageJoined, messages[0].Type)
+		assert.Equal(t, bobID, *messages[0].UserID)
+	}
+
+	assert.Equal(t, ErrReadOnly, bob.Submit(0, testOp(t, `[9, "!"]`)))
+
+	bob.Close()
+	messages = drain(alice)
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, MessageLeft, messages[0].Type)
+	}
+	_, ok := <-bob.Messages()
+	assert.False(t, ok)
+}
+
+func TestSession_Submit(t *testing.T) {
+	storage := newMemoryStorage()
+	docID := uuid.New()
+	storage.bodies[docID] = "the castle"
+	m := testManager(t, storage, realtime.NewLocalPubSub())
+
+	alice, _ := m.Open(uuid.New(), docID, uuid.New(), false)
+	defer alice.Close()
+	bob, _ := m.Open(uuid.New(), docID, uuid.New(), false)
+	defer bob.Close()
+	drain(alice)
+	drain(bob)
+
+	// Both edit revision 0 at once.
+	assert.NoError(t, alice.Submit(0, testOp(t, `[4, "dark ", 6]`)))
+	assert.NoError(t, bob.Submit(0, testOp(t, `[4, "old ", -6, "keep"]`)))
+
+	messages := drain(alice)
+	if assert.Len(t, messages, 2) {
+		assert.Equal(t, MessageAck, messages[0].Type)
+		assert.Equal(t, 1, messages[0].Revision)
+		assert.Equal(t, MessageOperation, messages[1].Type)
+		assert.Equal(t, 2, messages[1].Revision)
+		assert.Equal(t, bob.id, messages[1].ClientID)
+	}
+	messages = drain(bob)
+	if assert.Len(t, messages, 2) {
+		assert.Equal(t, MessageOperation, messages[0].Type)
+		assert.Equal(t, MessageAck, messages[1].Type)
+	}
+
+	d := m.find(docID)
+	assert.Equal(t, "the old keepdark ", d.body)
+	assert.Equal(t, 2, d.revision)
+
+	assert.Equal(t, ErrStaleRevision, alice.Submit(3, testOp(t, `[17, "!"]`)))
+	assert.Equal(t, ot.ErrLengthMismatch, alice.Submit(2, testOp(t, `[3, "!"]`)))
+	assert.Equal(t, ErrTooLong, alice.Submit(2, ot.Diff(d.body, string(make([]rune, 101)))))
+}
+
+func TestSession_Select(t *testing.T) {
+	storage := newMemoryStorage()
+	docID := uuid.New()
+	storage.bodies[docID] = "hello"
+	m := testManager(t, storage, realtime.NewLocalPubSub())
+
+	alice, _ := m.Open(uuid.New(), docID, uuid.New(), false)
+	defer alice.Close()
+	bob, _ := m.Open(uuid.New(), docID, uuid.New(), false)
+	defer bob.Close()
+	drain(alice)
+	drain(bob)
+
+	assert.NoError(t, bob.Select(0, Selection{Anchor: 5, Head: 5}))
+	messages := drain(alice)
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, MessageSelection, messages[0].Type)
+		assert.Equal(t, &Selection{Anchor: 5, Head: 5}, messages[0].Selection)
+	}
+
+	alice.Submit(0, testOp(t, `["oh, ", 5]`))
+	assert.Equal(t, &Selection{Anchor: 9, Head: 9}, m.find(docID).editors[bob.id].Selection)
+
+	// A selection made before alice's edit lands is moved by it.
+	assert.NoError(t, bob.Select(0, Selection{Anchor: 0, Head: 5}))
+	assert.Equal(t, &Selection{Anchor: 4, Head: 9}, m.find(docID).editors[bob.id].Selection)
+
+	assert.Equal(t, ErrInvalidSelection, bob.Select(1, Selection{Anchor: 0, Head: 10}))
+}
+
+func TestManager_Instances(t *testing.T) {
+	storage := newMemoryStorage()
+	docID := uuid.New()
+	storage.bodies[docID] = "recap"
+	pubsub := realtime.NewLocalPubSub()
+	m1 := testManager(t, storage, pubsub)
+	m2 := testManager(t, storage, pubsub)
+
+	alice, _ := m1.Open(uuid.New(), docID, uuid.New(), false)
+	defer alice.Close()
+	bob, _ := m2.Open(uuid.New(), docID, uuid.New(), false)
+	defer bob.Close()
+
+	messages := drain(bob)
+	if assert.Len(t, messages, 2) {
+		assert.Equal(t, MessageInit, messages[0].Type)
+		assert.Equal(t, MessageJoined, messages[1].Type)
+		assert.Equal(t, alice.id, messages[1].ClientID)
+	}
+	messages = drain(alice)
+	if assert.Len(t, messages, 2) {
+		assert.Equal(t, MessageJoined, messages[1].Type)
+		assert.Equal(t, bob.id, messages[1].ClientID)
+	}
+
+	assert.NoError(t, alice.Submit(0, testOp(t, `[5, " of session 1"]`)))
+	assert.NoError(t, bob.Submit(0, testOp(t, `["Long ", 5]`)))
+	assert.Equal(t, "Long recap of session 1", m1.find(docID).body)
+	assert.Equal(t, "Long recap of session 1", m2.find(docID).body)
+
+	// bob's instance missed an operation published before it loaded the
+	// document, so it catches up from the storage.
+	storage.Append(docID, &Operation{Revision: 3, Op: testOp(t, `[23, "."]`)})
+	assert.NoError(t, bob.Submit(2, testOp(t, `[23, "!"]`)))
+	assert.Equal(t, "Long recap of session 1!.", m2.find(docID).body)
+	assert.Equal(t, "Long recap of session 1!.", m1.find(docID).body)
+
+	assert.NoError(t, bob.Select(4, Selection{Anchor: 0, Head: 4}))
+	assert.Equal(t, &Selection{Anchor: 0, Head: 4}, m1.find(docID).editors[bob.id].Selection)
+
+	bob.Close()
+	assert.NotContains(t, m1.find(docID).editors, bob.id)
+}
+
+func TestManager_Save(t *testing.T) {
+	storage := newMemoryStorage()
+	docID := uuid.New()
+	m := testManager(t, storage, realtime.NewLocalPubSub())
+
+	s, _ := m.Open(uuid.New(), docID, uuid.New(), false)
+	body := ""
+	for i := 0; i < historySize+5; i++ {
+		op := ot.Op{}
+		op.Retain(len(body) % 50).Insert("a").Retain(len(body) - len(body)%50)
+		s.Submit(i, op)
+		body += "a"
+		if len(body) == 50 {
+			s.Submit(i+1, ot.Diff(body, ""))
+			body = ""
+			i++
+		}
+	}
+	revision := m.find(docID).revision
+
+	m.saveAll()
+	assert.Equal(t, revision, storage.revisions[docID])
+	assert.Equal(t, m.find(docID).body, storage.bodies[docID])
+	assert.Len(t, storage.operations[docID], historySize)
+
+	assert.NoError(t, m.Replace(uuid.New(), docID, uuid.New(), "rewritten"))
+	assert.Equal(t, "rewritten", storage.bodies[docID])
+	messages := drain(s)
+	assert.Equal(t, MessageOperation, messages[len(messages)-1].Type)
+
+	s.Close()
+	assert.Nil(t, m.find(docID))
+}
@@ -0,0 +1,224 @@
+package collab
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/ot"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// MessageInit starts a session, and starts it over when the document
+	// had to be reloaded: the client drops its pending operations.
+	MessageInit = "init"
+	// MessageOperation is an operation made by the client at Revision, or
+	// one made by another editor that produced Revision. Clients transform
+	// their pending operations as the first argument of ot.Transform.
+	MessageOperation = "operation"
+	// MessageAck tells the client its operation produced Revision.
+	MessageAck       = "ack"
+	MessageSelection = "selection"
+	MessageJoined    = "joined"
+	MessageLeft      = "left"
+	// MessageError reports an operation or selection that was rejected.
+	// After an operation is rejected the client should reconnect.
+	MessageError = "error"
+
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = pongWait * 9 / 10
+	maxMessageSize = 512 << 10
+	sendBufferSize = 256
+)
+
+// Message is what editors and the server say to each other.
+type Message struct {
+	Type      string     `json:"type"`
+	Revision  int        `json:"revision"`
+	Body      *string    `json:"body,omitempty"`
+	Op        ot.Op      `json:"op,omitempty"`
+	ClientID  string     `json:"client_id,omitempty"`
+	UserID    *uuid.UUID `json:"user_id,omitempty"`
+	Selection *Selection `json:"selection,omitempty"`
+	Editors   []*Editor  `json:"editors,omitempty"`
+	ReadOnly  bool       `json:"read_only,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// Session is a local editor of a document.
+type Session struct {
+	id       string
+	manager  *Manager
+	doc      *document
+	userID   uuid.UUID
+	readOnly bool
+	send     chan *Message
+
+	// closed is set when send is closed, and dropped too if that's because
+	// the session couldn't keep up. Both are guarded by doc.mu.
+	closed  bool
+	dropped bool
+}
+
+func newSession(m *Manager, d *document, userID uuid.UUID, readOnly bool) *Session {
+	return &Session{
+		id:       uuid.New().String(),
+		manager:  m,
+		doc:      d,
+		userID:   userID,
+		readOnly: readOnly,
+		send:     make(chan *Message, sendBufferSize),
+	}
+}
+
+// Messages returns the channel of messages for the editor. It is closed
+// when the session is closed or dropped for being too slow.
+func (s *Session) Messages() <-chan *Message {
+	return s.send
+}
+
+// Submit applies an operation the editor made at the revision.
+func (s *Session) Submit(revision int, op ot.Op) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+
+	o, err := s.submit(revision, op)
+	if err != nil {
+		return err
+	}
+
+	s.manager.publish(EventOperation, s.doc, &operationEvent{DocID: s.doc.id, Operation: o})
+
+	return nil
+}
+
+// submit stores the operation holding the document's lock, which is
+// released even if applying the operation fails badly.
+func (s *Session) submit(revision int, op ot.Op) (*Operation, error) {
+	d := s.doc
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return s.manager.submit(d, s, s.userID, revision, op)
+}
+
+// Select moves the editor's selection, made at the revision.
+func (s *Session) Select(revision int, sel Selection) error {
+	return s.manager.selectText(s, revision, &sel)
+}
+
+// Close leaves the document.
+func (s *Session) Close() {
+	s.manager.leave(s)
+}
+
+// Serve speaks the session's messages over the WebSocket until either side
+// closes it, then closes the session.
+func (s *Session) Serve(conn *websocket.Conn) {
+	go s.writePump(conn)
+	s.readPump(conn)
+}
+
+// deliver queues the message for the editor. d.mu must be held.
+func (s *Session) deliver(msg *Message) {
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.send <- msg:
+	default:
+		s.manager.logger.Warnf("dropping slow collab session %s", s.userID)
+		s.dropped = true
+		s.closeSend()
+	}
+}
+
+func (s *Session) readPump(conn *websocket.Conn) {
+	defer func() {
+		s.Close()
+		conn.Close()
+	}()
+
+	conn.SetReadLimit(maxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, b, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		msg := &Message{}
+		if err := json.Unmarshal(b, msg); err != nil {
+			s.fail(err)
+			continue
+		}
+
+		switch msg.Type {
+		case MessageOperation:
+			err = s.Submit(msg.Revision, msg.Op)
+		case MessageSelection:
+			if msg.Selection == nil {
+				err = ErrInvalidSelection
+				break
+			}
+			err = s.Select(msg.Revision, *msg.Selection)
+		}
+		if err != nil {
+			s.fail(err)
+		}
+	}
+}
+
+func (s *Session) writePump(conn *websocket.Conn) {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-s.send:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				frame := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+				if s.dropped {
+					frame = websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "slow consumer")
+				}
+				conn.WriteMessage(websocket.CloseMessage, frame)
+				return
+			}
+
+			if err := conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Session) fail(err error) {
+	s.doc.mu.Lock()
+	s.deliver(&Message{Type: MessageError, Error: err.Error()})
+	s.doc.mu.Unlock()
+}
+
+// closeSend closes send once. doc.mu must be held.
+func (s *Session) closeSend() {
+	if !s.closed {
+		s.closed = true
+		close(s.send)
+	}
+}
@@ -0,0 +1,113 @@
+// Package compendium reads packs of reference material into the compendium.
+//
+// A pack is a JSON document naming itself and the game system it is for:
+//
+//	{
+//	  "name": "homebrew",
+//	  "system": "dnd5e",
+//	  "entries": [
+//	    {
+//	      "kind": "monster",
+//	      "name": "Vampire Spawn",
+//	      "body": "*Medium undead, neutral evil*\n\n**Regeneration.** ...",
+//	      "data": {"cr": 5, "hp": 82, "initiative_bonus": 3}
+//	    }
+//	  ]
+//	}
+//
+// Kinds are spell, monster, item, condition and rule; a pack has one entry
+// of a kind with a name. Bodies are Markdown. Data is an object of whatever
+// fields the pack wants to filter entries by; monsters with integer "hp" and
+// "initiative_bonus" fields can be added to combat as they are.
+package compendium
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/markdown"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+)
+
+var ErrUnnamedPack = errors.New("pack has no name or system")
+
+type Pack struct {
+	Name    string             `json:"name"`
+	System  string             `json:"system"`
+	Entries []*model.PackEntry `json:"entries"`
+}
+
+// ReadPack decodes and validates a pack.
+func ReadPack(r io.Reader) (*Pack, error) {
+	p := &Pack{}
+	if err := json.NewDecoder(r).Decode(p); err != nil {
+		return nil, err
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *Pack) Validate() error {
+	if p.Name == "" || p.System == "" {
+		return ErrUnnamedPack
+	}
+
+	seen := map[string]bool{}
+	for _, e := range p.CompendiumEntries() {
+		if err := e.Validate(); err != nil {
+			return fmt.Errorf("%s %q: %w", e.Kind, e.Name, err)
+		}
+
+		key := e.Kind + "\n" + e.Name
+		if seen[key] {
+			return fmt.Errorf("%s %q: %w", e.Kind, e.Name, model.ErrDuplicatePackEntry)
+		}
+		seen[key] = true
+	}
+
+	return nil
+}
+
+// CompendiumEntries returns the entries of the pack as compendium entries,
+// with bodies not rendered yet.
+func (p *Pack) CompendiumEntries() []*model.CompendiumEntry {
+	entries := make([]*model.CompendiumEntry, 0, len(p.Entries))
+	for _, e := range p.Entries {
+		entries = append(entries, e.CompendiumEntry(p.System, p.Name))
+	}
+
+	return entries
+}
+
+// Import saves the entries of a valid pack, replacing the ones it had
+// already. With replace the entries the pack had before are deleted first,
+// so the ones it no longer has go away.
+func Import(repo store.CompendiumRepository, p *Pack, replace bool) (int, error) {
+	if replace {
+		if err := repo.DeleteSource(p.System, p.Name); err != nil {
+			return 0, err
+		}
+	}
+
+	renderer := markdown.NewRenderer()
+	for i, e := range p.CompendiumEntries() {
+		html, err := renderer.Render(e.Body, nil)
+		if err != nil {
+			return i, err
+		}
+		e.BodyHTML = html
+
+		if err := repo.Save(e); err != nil {
+			return i, fmt.Errorf("%s %q: %w", e.Kind, e.Name, err)
+		}
+	}
+
+	return len(p.Entries), nil
+}
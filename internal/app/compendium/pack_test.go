@@ -0,0 +1,77 @@
+package compendium_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/compendium"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadPack(t *testing.T) {
+	f, err := os.Open("testdata/homebrew.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	p, err := compendium.ReadPack(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "homebrew", p.Name)
+	if assert.Len(t, p.CompendiumEntries(), 2) {
+		e := p.CompendiumEntries()[1]
+		assert.Equal(t, model.KindRule, e.Kind)
+		assert.Equal(t, "homebrew", e.Source)
+		assert.JSONEq(t, `{}`, string(e.Data))
+	}
+
+	testCases := []struct {
+		name string
+		pack string
+	}{
+		{"no name", `{"system": "dnd5e", "entries": []}`},
+		{"unknown system", `{"name": "x", "system": "gurps", "entries": [{"kind": "rule", "name": "Rule"}]}`},
+		{"unknown kind", `{"name": "x", "system": "dnd5e", "entries": [{"kind": "deity", "name": "Pelor"}]}`},
+		{"duplicate", `{"name": "x", "system": "dnd5e", "entries": [{"kind": "rule", "name": "Rule"}, {"kind": "rule", "name": "Rule"}]}`},
+		{"data not an object", `{"name": "x", "system": "dnd5e", "entries": [{"kind": "rule", "name": "Rule", "data": 1}]}`},
+		{"invalid json", `{"name": `},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := compendium.ReadPack(strings.NewReader(tc.pack))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	st := teststore.New()
+	p := &compendium.Pack{Name: "homebrew", System: "dnd5e", Entries: []*model.PackEntry{
+		{Kind: model.KindRule, Name: "Gothic Horror", Body: "Long rests take **a week**."},
+		{Kind: model.KindRule, Name: "Fog", Body: "Nobody leaves."},
+	}}
+
+	n, err := compendium.Import(st.Compendium(), p, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	entries, _ := st.Compendium().FindAll(&model.CompendiumFilter{Source: "homebrew"})
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "<p>Nobody leaves.</p>\n", entries[0].BodyHTML)
+	}
+
+	p.Entries = p.Entries[:1]
+	compendium.Import(st.Compendium(), p, false)
+	entries, _ = st.Compendium().FindAll(&model.CompendiumFilter{Source: "homebrew"})
+	assert.Len(t, entries, 2)
+
+	compendium.Import(st.Compendium(), p, true)
+	entries, _ = st.Compendium().FindAll(&model.CompendiumFilter{Source: "homebrew"})
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "Gothic Horror", entries[0].Name)
+	}
+}
@@ -0,0 +1,76 @@
+// Package schema validates the data of compendium entries against the JSON
+// Schema of their kind.
+package schema
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+var (
+	ErrUnknownKind = errors.New("unknown compendium entry kind")
+	ErrInvalidData = errors.New("invalid compendium entry data")
+)
+
+//go:embed schemas/*.json
+var files embed.FS
+
+var schemas = map[string]*jsonschema.Schema{}
+
+func init() {
+	entries, err := files.ReadDir("schemas")
+	if err != nil {
+		panic(err)
+	}
+
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft2020
+	for _, e := range entries {
+		b, err := files.ReadFile(path.Join("schemas", e.Name()))
+		if err != nil {
+			panic(err)
+		}
+		if err := c.AddResource(e.Name(), bytes.NewReader(b)); err != nil {
+			panic(err)
+		}
+
+		schemas[strings.TrimSuffix(e.Name(), ".json")] = c.MustCompile(e.Name())
+	}
+}
+
+// Validate checks the data of an entry against the schema of its kind.
+func Validate(kind string, data []byte) error {
+	schema, ok := schemas[kind]
+	if !ok {
+		return ErrUnknownKind
+	}
+
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidData, err)
+	}
+
+	if err := schema.Validate(v); err != nil {
+		var ve *jsonschema.ValidationError
+		if errors.As(err, &ve) {
+			for len(ve.Causes) > 0 {
+				ve = ve.Causes[0]
+			}
+
+			return fmt.Errorf("%w: %s %s", ErrInvalidData, ve.InstanceLocation, ve.Message)
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package schema_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/compendium/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		kind    string
+		data    string
+		isValid bool
+	}{
+		{"monster", "monster", `{"hp": 7, "initiative_bonus": 2, "cr": 0.25}`, true},
+		{"monster without hp", "monster", `{"initiative_bonus": 2}`, false},
+		{"monster with fractional hp", "monster", `{"hp": 7.5, "initiative_bonus": 2}`, false},
+		{"spell", "spell", `{"level": 3, "school": "evocation"}`, true},
+		{"spell of level 12", "spell", `{"level": 12}`, false},
+		{"item", "item", `{"rarity": "rare", "weight": 3}`, true},
+		{"item of negative weight", "item", `{"weight": -1}`, false},
+		{"rule", "rule", `{"anything": ["goes"]}`, true},
+		{"not an object", "condition", `[]`, false},
+		{"invalid json", "rule", `{`, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := schema.Validate(tc.kind, []byte(tc.data))
+			if tc.isValid {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, schema.ErrInvalidData), err)
+			}
+		})
+	}
+
+	assert.Equal(t, schema.ErrUnknownKind, schema.Validate("deity", []byte(`{}`)))
+}
@@ -0,0 +1,437 @@
+package compendium
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+)
+
+// SRDName names the pack of the System Reference Document 5.1.
+const SRDName = "srd-5.1"
+
+var ErrNoSRDFiles = errors.New("no SRD files found")
+
+// srdFiles are the files of the 5e-bits/5e-database project read by ReadSRD,
+// by the kind of entries they have.
+var srdFiles = []struct {
+	name  string
+	kind  string
+	parse func([]byte) ([]*model.PackEntry, error)
+}{
+	{"5e-SRD-Spells.json", model.KindSpell, parseSRDSpells},
+	{"5e-SRD-Monsters.json", model.KindMonster, parseSRDMonsters},
+	{"5e-SRD-Equipment.json", model.KindItem, parseSRDEquipment},
+	{"5e-SRD-Magic-Items.json", model.KindItem, parseSRDEquipment},
+	{"5e-SRD-Conditions.json", model.KindCondition, parseSRDConditions},
+	{"5e-SRD-Rule-Sections.json", model.KindRule, parseSRDRules},
+}
+
+// ReadSRD converts the 5e SRD as published in JSON by the 5e-database
+// project (https://github.com/5e-bits/5e-database) to a pack. fsys holds
+// its 5e-SRD-*.json files; the ones missing are skipped. Items are both
+// mundane equipment and magic items, the first of the same name wins.
+func ReadSRD(fsys fs.FS) (*Pack, error) {
+	p := &Pack{Name: SRDName, System: "dnd5e", Entries: []*model.PackEntry{}}
+	seen := map[string]bool{}
+	found := false
+	for _, f := range srdFiles {
+		b, err := fs.ReadFile(fsys, f.name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+
+		entries, err := f.parse(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.name, err)
+		}
+		for _, e := range entries {
+			e.Kind = f.kind
+			if key := e.Kind + "\n" + e.Name; !seen[key] {
+				seen[key] = true
+				p.Entries = append(p.Entries, e)
+			}
+		}
+	}
+
+	if !found {
+		return nil, ErrNoSRDFiles
+	}
+
+	return p, p.Validate()
+}
+
+type srdReference struct {
+	Name string `json:"name"`
+}
+
+type srdFeature struct {
+	Name string `json:"name"`
+	Desc string `json:"desc"`
+}
+
+func parseSRDSpells(b []byte) ([]*model.PackEntry, error) {
+	spells := []struct {
+		Name          string         `json:"name"`
+		Desc          []string       `json:"desc"`
+		HigherLevel   []string       `json:"higher_level"`
+		Range         string         `json:"range"`
+		Components    []string       `json:"components"`
+		Material      string         `json:"material"`
+		Ritual        bool           `json:"ritual"`
+		Duration      string         `json:"duration"`
+		Concentration bool           `json:"concentration"`
+		CastingTime   string         `json:"casting_time"`
+		Level         int            `json:"level"`
+		School        srdReference   `json:"school"`
+		Classes       []srdReference `json:"classes"`
+	}{}
+	if err := json.Unmarshal(b, &spells); err != nil {
+		return nil, err
+	}
+
+	entries := []*model.PackEntry{}
+	for _, s := range spells {
+		school := strings.ToLower(s.School.Name)
+		classes := []string{}
+		for _, c := range s.Classes {
+			classes = append(classes, strings.ToLower(c.Name))
+		}
+
+		body := &strings.Builder{}
+		if s.Level == 0 {
+			fmt.Fprintf(body, "*%s cantrip*\n\n", s.School.Name)
+		} else {
+			fmt.Fprintf(body, "*%s-level %s", ordinal(s.Level), school)
+			if s.Ritual {
+				body.WriteString(" (ritual)")
+			}
+			body.WriteString("*\n\n")
+		}
+		components := strings.Join(s.Components, ", ")
+		if s.Material != "" {
+			components += " (" + s.Material + ")"
+		}
+		fmt.Fprintf(body, "**Casting Time:** %s  \n**Range:** %s  \n**Components:** %s  \n**Duration:** %s\n\n", s.CastingTime, s.Range, components, s.Duration)
+		body.WriteString(strings.Join(s.Desc, "\n\n"))
+		if len(s.HigherLevel) > 0 {
+			fmt.Fprintf(body, "\n\n***At Higher Levels.*** %s", strings.Join(s.HigherLevel, "\n\n"))
+		}
+
+		data, err := json.Marshal(map[string]interface{}{
+			"level":         s.Level,
+			"school":        school,
+			"casting_time":  s.CastingTime,
+			"range":         s.Range,
+			"components":    s.Components,
+			"duration":      s.Duration,
+			"concentration": s.Concentration,
+			"ritual":        s.Ritual,
+			"classes":       classes,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &model.PackEntry{Name: s.Name, Body: body.String(), Data: data})
+	}
+
+	return entries, nil
+}
+
+func parseSRDMonsters(b []byte) ([]*model.PackEntry, error) {
+	monsters := []struct {
+		Name                  string                 `json:"name"`
+		Size                  string                 `json:"size"`
+		Type                  string                 `json:"type"`
+		Subtype               string                 `json:"subtype"`
+		Alignment             string                 `json:"alignment"`
+		ArmorClass            json.RawMessage        `json:"armor_class"`
+		HitPoints             int                    `json:"hit_points"`
+		HitDice               string                 `json:"hit_dice"`
+		Speed                 map[string]interface{} `json:"speed"`
+		Strength              int                    `json:"strength"`
+		Dexterity             int                    `json:"dexterity"`
+		Constitution          int                    `json:"constitution"`
+		Intelligence          int                    `json:"intelligence"`
+		Wisdom                int                    `json:"wisdom"`
+		Charisma              int                    `json:"charisma"`
+		Languages             string                 `json:"languages"`
+		ChallengeRating       float64                `json:"challenge_rating"`
+		XP                    int                    `json:"xp"`
+		SpecialAbilities      []srdFeature           `json:"special_abilities"`
+		Actions               []srdFeature           `json:"actions"`
+		Reactions             []srdFeature           `json:"reactions"`
+		LegendaryActions      []srdFeature           `json:"legendary_actions"`
+		ConditionImmunities   []srdReference         `json:"condition_immunities"`
+		DamageImmunities      []string               `json:"damage_immunities"`
+		DamageResistances     []string               `json:"damage_resistances"`
+		DamageVulnerabilities []string               `json:"damage_vulnerabilities"`
+	}{}
+	if err := json.Unmarshal(b, &monsters); err != nil {
+		return nil, err
+	}
+
+	entries := []*model.PackEntry{}
+	for _, m := range monsters {
+		ac, err := parseSRDArmorClass(m.ArmorClass)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Name, err)
+		}
+
+		kind := m.Type
+		if m.Subtype != "" {
+			kind += " (" + m.Subtype + ")"
+		}
+		speeds := []string{}
+		if walk, ok := m.Speed["walk"].(string); ok {
+			speeds = append(speeds, walk)
+		}
+		modes := []string{}
+		for mode := range m.Speed {
+			modes = append(modes, mode)
+		}
+		sort.Strings(modes)
+		for _, mode := range modes {
+			switch speed := m.Speed[mode].(type) {
+			case string:
+				if mode != "walk" {
+					speeds = append(speeds, mode+" "+speed)
+				}
+			case bool:
+				if speed {
+					speeds = append(speeds, "("+mode+")")
+				}
+			}
+		}
+		scores := []int{m.Strength, m.Dexterity, m.Constitution, m.Intelligence, m.Wisdom, m.Charisma}
+		abilities := map[string]int{}
+		cells := []string{}
+		for i, a := range []string{"str", "dex", "con", "int", "wis", "cha"} {
+			abilities[a] = scores[i]
+			cells = append(cells, fmt.Sprintf("%d (%+d)", scores[i], modifier(scores[i])))
+		}
+
+		body := &strings.Builder{}
+		fmt.Fprintf(body, "*%s %s, %s*\n\n", m.Size, kind, m.Alignment)
+		fmt.Fprintf(body, "**Armor Class** %d  \n**Hit Points** %d (%s)  \n**Speed** %s\n\n", ac, m.HitPoints, m.HitDice, strings.Join(speeds, ", "))
+		fmt.Fprintf(body, "| STR | DEX | CON | INT | WIS | CHA |\n|---|---|---|---|---|---|\n| %s |\n\n", strings.Join(cells, " | "))
+		lines := []string{}
+		for _, l := range []struct{ label, value string }{
+			{"Damage Vulnerabilities", strings.Join(m.DamageVulnerabilities, ", ")},
+			{"Damage Resistances", strings.Join(m.DamageResistances, ", ")},
+			{"Damage Immunities", strings.Join(m.DamageImmunities, ", ")},
+			{"Condition Immunities", joinNames(m.ConditionImmunities)},
+			{"Languages", m.Languages},
+		} {
+			if l.value != "" {
+				lines = append(lines, fmt.Sprintf("**%s** %s", l.label, l.value))
+			}
+		}
+		lines = append(lines, fmt.Sprintf("**Challenge** %s (%d XP)", challenge(m.ChallengeRating), m.XP))
+		body.WriteString(strings.Join(lines, "  \n"))
+		writeSRDFeatures(body, "", m.SpecialAbilities)
+		writeSRDFeatures(body, "Actions", m.Actions)
+		writeSRDFeatures(body, "Reactions", m.Reactions)
+		writeSRDFeatures(body, "Legendary Actions", m.LegendaryActions)
+
+		data, err := json.Marshal(map[string]interface{}{
+			"size":             strings.ToLower(m.Size),
+			"type":             m.Type,
+			"alignment":        m.Alignment,
+			"ac":               ac,
+			"hp":               m.HitPoints,
+			"hit_dice":         m.HitDice,
+			"speed":            m.Speed,
+			"abilities":        abilities,
+			"cr":               m.ChallengeRating,
+			"xp":               m.XP,
+			"initiative_bonus": modifier(m.Dexterity),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &model.PackEntry{Name: m.Name, Body: body.String(), Data: data})
+	}
+
+	return entries, nil
+}
+
+// parseSRDArmorClass reads an armor class, either a number or a list of the
+// ways the monster gets one, the first being its usual.
+func parseSRDArmorClass(b json.RawMessage) (int, error) {
+	ac := 0
+	if err := json.Unmarshal(b, &ac); err == nil {
+		return ac, nil
+	}
+
+	acs := []struct {
+		Value int `json:"value"`
+	}{}
+	if err := json.Unmarshal(b, &acs); err != nil {
+		return 0, err
+	}
+	if len(acs) == 0 {
+		return 0, errors.New("no armor class")
+	}
+
+	return acs[0].Value, nil
+}
+
+func parseSRDEquipment(b []byte) ([]*model.PackEntry, error) {
+	items := []struct {
+		Name              string       `json:"name"`
+		Desc              []string     `json:"desc"`
+		EquipmentCategory srdReference `json:"equipment_category"`
+		Rarity            srdReference `json:"rarity"`
+		Cost              *struct {
+			Quantity int    `json:"quantity"`
+			Unit     string `json:"unit"`
+		} `json:"cost"`
+		Weight float64 `json:"weight"`
+		Damage *struct {
+			Dice string       `json:"damage_dice"`
+			Type srdReference `json:"damage_type"`
+		} `json:"damage"`
+	}{}
+	if err := json.Unmarshal(b, &items); err != nil {
+		return nil, err
+	}
+
+	entries := []*model.PackEntry{}
+	for _, it := range items {
+		fields := map[string]interface{}{"category": strings.ToLower(it.EquipmentCategory.Name)}
+		header := []string{it.EquipmentCategory.Name}
+		if it.Rarity.Name != "" {
+			fields["rarity"] = strings.ToLower(it.Rarity.Name)
+			header = append(header, strings.ToLower(it.Rarity.Name))
+		}
+
+		body := &strings.Builder{}
+		fmt.Fprintf(body, "*%s*\n\n", strings.Join(header, ", "))
+		lines := []string{}
+		if it.Cost != nil {
+			fields["cost"] = fmt.Sprintf("%d %s", it.Cost.Quantity, it.Cost.Unit)
+			lines = append(lines, fmt.Sprintf("**Cost** %s", fields["cost"]))
+		}
+		if it.Weight > 0 {
+			fields["weight"] = it.Weight
+			lines = append(lines, fmt.Sprintf("**Weight** %g lb.", it.Weight))
+		}
+		if it.Damage != nil {
+			fields["damage"] = it.Damage.Dice
+			fields["damage_type"] = strings.ToLower(it.Damage.Type.Name)
+			lines = append(lines, fmt.Sprintf("**Damage** %s %s", it.Damage.Dice, strings.ToLower(it.Damage.Type.Name)))
+		}
+		if len(lines) > 0 {
+			body.WriteString(strings.Join(lines, "  \n") + "\n\n")
+		}
+		body.WriteString(strings.Join(it.Desc, "\n\n"))
+
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &model.PackEntry{Name: it.Name, Body: strings.TrimSpace(body.String()), Data: data})
+	}
+
+	return entries, nil
+}
+
+func parseSRDConditions(b []byte) ([]*model.PackEntry, error) {
+	conditions := []struct {
+		Name string   `json:"name"`
+		Desc []string `json:"desc"`
+	}{}
+	if err := json.Unmarshal(b, &conditions); err != nil {
+		return nil, err
+	}
+
+	entries := []*model.PackEntry{}
+	for _, c := range conditions {
+		entries = append(entries, &model.PackEntry{Name: c.Name, Body: strings.Join(c.Desc, "\n"), Data: json.RawMessage("{}")})
+	}
+
+	return entries, nil
+}
+
+func parseSRDRules(b []byte) ([]*model.PackEntry, error) {
+	rules := []struct {
+		Name string `json:"name"`
+		Desc string `json:"desc"`
+	}{}
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, err
+	}
+
+	entries := []*model.PackEntry{}
+	for _, r := range rules {
+		entries = append(entries, &model.PackEntry{Name: r.Name, Body: r.Desc, Data: json.RawMessage("{}")})
+	}
+
+	return entries, nil
+}
+
+func writeSRDFeatures(body *strings.Builder, heading string, features []srdFeature) {
+	if len(features) == 0 {
+		return
+	}
+
+	if heading != "" {
+		fmt.Fprintf(body, "\n\n### %s", heading)
+	}
+	for _, f := range features {
+		fmt.Fprintf(body, "\n\n***%s.*** %s", f.Name, f.Desc)
+	}
+}
+
+func joinNames(refs []srdReference) string {
+	names := []string{}
+	for _, r := range refs {
+		names = append(names, strings.ToLower(r.Name))
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func modifier(score int) int {
+	return int(math.Floor(float64(score-10) / 2))
+}
+
+// challenge formats a challenge rating the way stat blocks do.
+func challenge(cr float64) string {
+	switch cr {
+	case 0.125:
+		return "1/8"
+	case 0.25:
+		return "1/4"
+	case 0.5:
+		return "1/2"
+	default:
+		return fmt.Sprint(cr)
+	}
+}
+
+func ordinal(n int) string {
+	switch n {
+	case 1:
+		return "1st"
+	case 2:
+		return "2nd"
+	case 3:
+		return "3rd"
+	default:
+		return fmt.Sprintf("%dth", n)
+	}
+}
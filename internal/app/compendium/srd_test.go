@@ -0,0 +1,64 @@
+package compendium_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/compendium"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadSRD(t *testing.T) {
+	p, err := compendium.ReadSRD(os.DirFS("testdata/srd"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, compendium.SRDName, p.Name)
+	assert.Equal(t, "dnd5e", p.System)
+
+	entries := map[string]*model.CompendiumEntry{}
+	for _, e := range p.CompendiumEntries() {
+		entries[e.Name] = e
+	}
+	assert.Len(t, entries, 7)
+
+	goblin := entries["Goblin"]
+	stats, err := goblin.Monster()
+	assert.NoError(t, err)
+	assert.Equal(t, &model.MonsterStats{HP: 7, InitiativeBonus: 2}, stats)
+	assert.Contains(t, goblin.Body, "*Small humanoid (goblinoid), neutral evil*")
+	assert.Contains(t, goblin.Body, "**Challenge** 1/4 (50 XP)")
+	assert.Contains(t, goblin.Body, "***Nimble Escape.***")
+	assert.Contains(t, goblin.Body, "### Actions")
+
+	specter := entries["Specter"]
+	data := map[string]interface{}{}
+	json.Unmarshal(specter.Data, &data)
+	assert.Equal(t, 12.0, data["ac"])
+	assert.Contains(t, specter.Body, "**Speed** 0 ft., fly 50 ft., (hover)")
+	assert.Contains(t, specter.Body, "**Condition Immunities** charmed")
+
+	fireball := entries["Fireball"]
+	assert.Equal(t, model.KindSpell, fireball.Kind)
+	assert.Contains(t, fireball.Body, "*3rd-level evocation*")
+	assert.Contains(t, fireball.Body, "***At Higher Levels.***")
+	assert.True(t, (&model.CompendiumFilter{Data: map[string]string{"level": "3", "school": "evocation"}}).Match(fireball))
+	assert.Contains(t, entries["Light"].Body, "*Evocation cantrip*")
+
+	longsword := entries["Longsword"]
+	assert.Equal(t, model.KindItem, longsword.Kind)
+	assert.NotContains(t, longsword.Body, "mundane")
+	assert.True(t, (&model.CompendiumFilter{Data: map[string]string{"cost": "15 gp", "damage": "1d8"}}).Match(longsword))
+	assert.True(t, (&model.CompendiumFilter{Data: map[string]string{"rarity": "common"}}).Match(entries["Potion of Healing"]))
+
+	assert.Equal(t, model.KindCondition, entries["Blinded"].Kind)
+
+	_, err = compendium.ReadSRD(fstest.MapFS{})
+	assert.Equal(t, compendium.ErrNoSRDFiles, err)
+
+	_, err = compendium.ReadSRD(fstest.MapFS{"5e-SRD-Spells.json": {Data: []byte(`{}`)}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package dice
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+const (
+	MaxDice  = 100
+	MaxSides = 1000
+)
+
+var (
+	ErrInvalidExpression = errors.New("invalid dice expression")
+	ErrTooManyDice       = errors.New("too many dice")
+)
+
+type Die struct {
+	Sides   int  `json:"sides"`
+	Value   int  `json:"value"`
+	Dropped bool `json:"dropped,omitempty"`
+}
+
+type Result struct {
+	Expression string `json:"expression"`
+	Dice       []Die  `json:"dice"`
+	Modifier   int    `json:"modifier"`
+	Total      int    `json:"total"`
+}
+
+type Roller struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func NewRoller(src rand.Source) *Roller {
+	if src == nil {
+		var seed [8]byte
+		crand.Read(seed[:])
+		src = rand.NewSource(int64(binary.LittleEndian.Uint64(seed[:])))
+	}
+
+	return &Roller{
+		rnd: rand.New(src),
+	}
+}
+
+func (r *Roller) Roll(expression string) (*Result, error) {
+	e, err := Parse(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Evaluate(e), nil
+}
+
+func (r *Roller) Evaluate(e *Expression) *Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := &Result{
+		Expression: e.String(),
+		Dice:       []Die{},
+	}
+
+	for _, t := range e.Terms {
+		if t.Sides == 0 {
+			res.Modifier += t.Sign * t.Count
+			res.Total += t.Sign * t.Count
+			continue
+		}
+
+		rolled := make([]Die, t.Count)
+		for i := range rolled {
+			rolled[i] = Die{Sides: t.Sides, Value: r.rnd.Intn(t.Sides) + 1}
+		}
+		t.keep(rolled)
+
+		for _, d := range rolled {
+			if !d.Dropped {
+				res.Total += t.Sign * d.Value
+			}
+		}
+		res.Dice = append(res.Dice, rolled...)
+	}
+
+	return res
+}
+
+func (t *Term) keep(rolled []Die) {
+	if t.Keep == 0 || t.Keep >= len(rolled) {
+		return
+	}
+
+	idx := make([]int, len(rolled))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		if t.KeepLowest {
+			return rolled[idx[a]].Value < rolled[idx[b]].Value
+		}
+
+		return rolled[idx[a]].Value > rolled[idx[b]].Value
+	})
+
+	for _, i := range idx[t.Keep:] {
+		rolled[i].Dropped = true
+	}
+}
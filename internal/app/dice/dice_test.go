@@ -0,0 +1,155 @@
+package dice_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/dice"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expr     string
+		expected string
+		isValid  bool
+	}{
+		{"single die", "d20", "1d20", true},
+		{"with modifier", "1d20 + 5", "1d20+5", true},
+		{"several terms", "2d6+1d4-1", "2d6+1d4-1", true},
+		{"percentile", "d%", "1d100", true},
+		{"keep highest", "2d20kh1", "2d20kh1", true},
+		{"keep lowest", "4d6kl3", "4d6kl3", true},
+		{"flat number", "7", "7", true},
+		{"empty", "", "", false},
+		{"no sides", "2d", "", false},
+		{"trailing operator", "1d20+", "", false},
+		{"keep more than rolled", "2d20kh3", "", false},
+		{"too many sides", "1d100000", "", false},
+		{"too many dice", "101d6", "", false},
+		{"garbage", "roll", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e, err := dice.Parse(tc.expr)
+			if tc.isValid {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, e.String())
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestRoller_Roll(t *testing.T) {
+	r := dice.NewRoller(rand.NewSource(1))
+
+	for i := 0; i < 100; i++ {
+		res, err := r.Roll("3d6+2")
+		assert.NoError(t, err)
+		assert.Len(t, res.Dice, 3)
+		assert.Equal(t, 2, res.Modifier)
+
+		sum := res.Modifier
+		for _, d := range res.Dice {
+			assert.Equal(t, 6, d.Sides)
+			assert.True(t, d.Value >= 1 && d.Value <= 6)
+			sum += d.Value
+		}
+		assert.Equal(t, sum, res.Total)
+	}
+}
+
+func TestRoller_RollKeep(t *testing.T) {
+	r := dice.NewRoller(rand.NewSource(1))
+
+	for i := 0; i < 100; i++ {
+		res, err := r.Roll("2d20kh1")
+		assert.NoError(t, err)
+		assert.Len(t, res.Dice, 2)
+
+		kept, dropped := res.Dice[0], res.Dice[1]
+		if kept.Dropped {
+			kept, dropped = dropped, kept
+		}
+		assert.False(t, kept.Dropped)
+		assert.True(t, dropped.Dropped)
+		assert.True(t, kept.Value >= dropped.Value)
+		assert.Equal(t, kept.Value, res.Total)
+	}
+}
+
+func TestParsePrefix(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		source   string
+		rest     string
+		isValid  bool
+	}{
+		{"with label", "1d20+5 to hit", "1d20+5", "1d20+5", " to hit", true},
+		{"label starting with dice letters", "2d6 damage", "2d6", "2d6", " damage", true},
+		{"label starting with k", "1d20 knowledge", "1d20", "1d20", " knowledge", true},
+		{"trailing operator", "1d8+ fire", "1d8", "1d8", "+ fire", true},
+		{"only expression", "4d6kh3", "4d6kh3", "4d6kh3", "", true},
+		{"no expression", "to hit", "", "", "to hit", false},
+		{"reference", "1d20+@str_mod to hit", "1d20+3", "1d20+@str_mod", " to hit", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e, source, rest, err := dice.ParsePrefix(tc.input, func(name string) (int, bool) {
+				return 3, name == "str_mod"
+			})
+			if !tc.isValid {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, e.String())
+			assert.Equal(t, tc.source, source)
+			assert.Equal(t, tc.rest, rest)
+		})
+	}
+}
+
+func TestParseWith(t *testing.T) {
+	lookup := func(name string) (int, bool) {
+		v, ok := map[string]int{"str_mod": 3, "dex_mod": -1, "abilities.wis": 12}[name]
+		return v, ok
+	}
+
+	testCases := []struct {
+		name     string
+		expr     string
+		expected string
+		isValid  bool
+	}{
+		{"reference", "1d20+@str_mod", "1d20+3", true},
+		{"negative reference", "1d20+@DEX_MOD", "1d20-1", true},
+		{"subtracted negative reference", "1d20-@dex_mod", "1d20+1", true},
+		{"nested field", "@abilities.wis", "12", true},
+		{"unknown reference", "1d20+@luck", "", false},
+		{"empty reference", "1d20+@", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e, err := dice.ParseWith(tc.expr, lookup)
+			if tc.isValid {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, e.String())
+			} else {
+				assert.ErrorIs(t, err, dice.ErrInvalidExpression)
+			}
+		})
+	}
+
+	_, err := dice.Parse("1d20+@str_mod")
+	assert.ErrorIs(t, err, dice.ErrInvalidExpression)
+}
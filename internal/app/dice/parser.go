@@ -0,0 +1,267 @@
+package dice
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Term is a single summand of a dice expression: either a group of dice
+// (Sides > 0) or a flat modifier stored in Count.
+type Term struct {
+	Sign       int
+	Count      int
+	Sides      int
+	Keep       int
+	KeepLowest bool
+}
+
+type Expression struct {
+	Terms []*Term
+}
+
+// Lookup resolves @name references to character sheet values. Names are
+// passed in lower case.
+type Lookup func(name string) (int, bool)
+
+// Parse parses expressions like "1d20+5", "2d6 + 1d4 - 1", "d%" or
+// "2d20kh1" (keep highest) and "4d6kl3" (keep lowest).
+func Parse(s string) (*Expression, error) {
+	return ParseWith(s, nil)
+}
+
+// ParseWith parses an expression that may also refer to values by name,
+// e.g. "1d20+@str_mod". References are replaced with the values lookup
+// returns for them.
+func ParseWith(s string, lookup Lookup) (*Expression, error) {
+	p := &parser{src: strings.ToLower(strings.Join(strings.Fields(s), "")), lookup: lookup}
+	if p.src == "" {
+		return nil, ErrInvalidExpression
+	}
+
+	e := &Expression{}
+	dice := 0
+	sign := 1
+	for {
+		t, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+
+		t.Sign = sign
+		if t.Count < 0 {
+			t.Count, t.Sign = -t.Count, -sign
+		}
+		if t.Sides > 0 {
+			dice += t.Count
+		}
+		e.Terms = append(e.Terms, t)
+
+		if p.eof() {
+			break
+		}
+
+		switch p.next() {
+		case '+':
+			sign = 1
+		case '-':
+			sign = -1
+		default:
+			return nil, p.errorf("unexpected %q", p.src[p.pos-1])
+		}
+	}
+
+	if dice > MaxDice {
+		return nil, ErrTooManyDice
+	}
+
+	return e, nil
+}
+
+// ParsePrefix parses the longest dice expression at the start of s and
+// returns it with the text it was parsed from and the remainder, e.g.
+// "1d20+5 to hit" yields "1d20+5" and " to hit".
+func ParsePrefix(s string, lookup Lookup) (e *Expression, source string, rest string, err error) {
+	end := 0
+	for end < len(s) {
+		if s[end] == '@' {
+			for end++; end < len(s) && isNameByte(s[end]); end++ {
+			}
+			continue
+		}
+
+		if strings.IndexByte("0123456789dDkKlLhH%+- ", s[end]) < 0 {
+			break
+		}
+		end++
+	}
+
+	for ; end > 0; end-- {
+		if s[end-1] == ' ' {
+			continue
+		}
+
+		source = strings.TrimSpace(s[:end])
+		if e, err := ParseWith(source, lookup); err == nil {
+			return e, source, s[end:], nil
+		}
+	}
+
+	return nil, "", s, ErrInvalidExpression
+}
+
+func (e *Expression) String() string {
+	b := &strings.Builder{}
+	for i, t := range e.Terms {
+		if i > 0 || t.Sign < 0 {
+			if t.Sign < 0 {
+				b.WriteByte('-')
+			} else {
+				b.WriteByte('+')
+			}
+		}
+
+		if t.Sides == 0 {
+			b.WriteString(strconv.Itoa(t.Count))
+			continue
+		}
+
+		fmt.Fprintf(b, "%dd%d", t.Count, t.Sides)
+		if t.Keep > 0 {
+			if t.KeepLowest {
+				fmt.Fprintf(b, "kl%d", t.Keep)
+			} else {
+				fmt.Fprintf(b, "kh%d", t.Keep)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+type parser struct {
+	src    string
+	pos    int
+	lookup Lookup
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+
+	return p.src[p.pos]
+}
+
+func (p *parser) next() byte {
+	c := p.peek()
+	p.pos++
+
+	return c
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidExpression, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) number() (int, bool) {
+	start := p.pos
+	for !p.eof() && p.peek() >= '0' && p.peek() <= '9' {
+		p.pos++
+	}
+
+	if start == p.pos || p.pos-start > 6 {
+		return 0, false
+	}
+
+	n, _ := strconv.Atoi(p.src[start:p.pos])
+
+	return n, true
+}
+
+// reference reads an @name reference. The value may be negative, which
+// Parse turns into a subtraction.
+func (p *parser) reference() (*Term, error) {
+	p.next()
+	start := p.pos
+	for !p.eof() && isNameByte(p.peek()) {
+		p.pos++
+	}
+
+	name := p.src[start:p.pos]
+	if name == "" {
+		return nil, p.errorf("reference name expected at %d", start)
+	}
+
+	if p.lookup == nil {
+		return nil, p.errorf("unknown reference @%s", name)
+	}
+	v, ok := p.lookup(name)
+	if !ok {
+		return nil, p.errorf("unknown reference @%s", name)
+	}
+
+	return &Term{Count: v}, nil
+}
+
+func isNameByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.'
+}
+
+func (p *parser) term() (*Term, error) {
+	if p.peek() == '@' {
+		return p.reference()
+	}
+
+	count, hasCount := p.number()
+	if p.peek() != 'd' {
+		if !hasCount {
+			return nil, p.errorf("number or dice expected at %d", p.pos)
+		}
+
+		return &Term{Count: count}, nil
+	}
+
+	p.next()
+	if !hasCount {
+		count = 1
+	}
+	if count < 1 {
+		return nil, p.errorf("dice count must be positive")
+	}
+
+	t := &Term{Count: count}
+	if p.peek() == '%' {
+		p.next()
+		t.Sides = 100
+	} else {
+		sides, ok := p.number()
+		if !ok || sides < 1 || sides > MaxSides {
+			return nil, p.errorf("invalid number of sides")
+		}
+		t.Sides = sides
+	}
+
+	if p.peek() == 'k' {
+		p.next()
+		switch p.next() {
+		case 'h':
+		case 'l':
+			t.KeepLowest = true
+		default:
+			return nil, p.errorf("kh or kl expected")
+		}
+
+		keep, ok := p.number()
+		if !ok || keep < 1 || keep > t.Count {
+			return nil, p.errorf("invalid number of dice to keep")
+		}
+		t.Keep = keep
+	}
+
+	return t, nil
+}
@@ -0,0 +1,106 @@
+package formula
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Compute evaluates named formulas that may refer to each other and to
+// variables of env, each formula once and after the ones it depends on.
+// Formulas that can't be evaluated, e.g. because the data they need is
+// missing, are left out of the result; syntax errors and cycles fail the
+// whole computation.
+func Compute(formulas map[string]string, env Env) (map[string]float64, error) {
+	exprs := make(map[string]*Expr, len(formulas))
+	names := make([]string, 0, len(formulas))
+	for name, src := range formulas {
+		name = strings.ToLower(name)
+		e, err := Parse(src)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		exprs[name] = e
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	order, err := sortFormulas(names, exprs)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]float64{}
+	lookup := func(name string) (interface{}, bool) {
+		if _, ok := exprs[name]; ok {
+			v, ok := values[name]
+
+			return v, ok
+		}
+
+		return env(name)
+	}
+
+	for _, name := range order {
+		v, err := exprs[name].Eval(lookup)
+		if err != nil {
+			continue
+		}
+
+		if f, ok := v.(float64); ok {
+			values[name] = f
+		}
+	}
+
+	return values, nil
+}
+
+// sortFormulas orders the formulas so that each comes after the formulas it
+// refers to.
+func sortFormulas(names []string, exprs map[string]*Expr) ([]string, error) {
+	const (
+		visiting = 1
+		done     = 2
+	)
+
+	order := make([]string, 0, len(names))
+	state := map[string]int{}
+	path := []string{}
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			for i, n := range path {
+				if n == name {
+					return fmt.Errorf("%w: %s", ErrCycle, strings.Join(append(path[i:], name), " -> "))
+				}
+			}
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		for _, ref := range exprs[name].Refs() {
+			if _, ok := exprs[ref]; ok {
+				if err := visit(ref); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		order = append(order, name)
+
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
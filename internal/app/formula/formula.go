@@ -0,0 +1,295 @@
+// Package formula implements the small expression language of computed
+// character sheet fields, e.g. "floor((abilities.str - 10) / 2)".
+//
+// Values are numbers or strings. Identifiers are case-insensitive and may
+// contain dots to reach into nested sheet data. Supported are + - * / %,
+// comparisons (yielding 1 or 0), parentheses and the functions floor, ceil,
+// round, abs, min, max, if(cond, then, else) and default(x, fallback), which
+// yields fallback if x refers to missing variables.
+package formula
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	maxLength = 1000
+	maxDepth  = 64
+)
+
+var (
+	ErrSyntax          = errors.New("formula syntax error")
+	ErrUnknownVariable = errors.New("unknown variable")
+	ErrType            = errors.New("type mismatch")
+	ErrDivisionByZero  = errors.New("division by zero")
+	ErrCycle           = errors.New("formulas depend on each other")
+)
+
+// Env resolves variables to float64 or string values.
+type Env func(name string) (interface{}, bool)
+
+type Expr struct {
+	root node
+	refs []string
+}
+
+func Parse(s string) (*Expr, error) {
+	if len(s) > maxLength {
+		return nil, fmt.Errorf("%w: formula is too long", ErrSyntax)
+	}
+
+	p := &parser{lex: &lexer{src: s}}
+	p.advance()
+	root, err := p.comparison()
+	if err != nil {
+		return nil, err
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %q", p.tok.text)
+	}
+
+	e := &Expr{root: root}
+	seen := map[string]bool{}
+	root.walk(func(n node) {
+		if v, ok := n.(*variable); ok && !seen[v.name] {
+			seen[v.name] = true
+			e.refs = append(e.refs, v.name)
+		}
+	})
+
+	return e, nil
+}
+
+// Refs lists the variables the expression uses.
+func (e *Expr) Refs() []string {
+	return e.refs
+}
+
+func (e *Expr) Eval(env Env) (interface{}, error) {
+	return e.root.eval(env)
+}
+
+type node interface {
+	eval(env Env) (interface{}, error)
+	walk(func(node))
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (n *literal) eval(Env) (interface{}, error) {
+	return n.value, nil
+}
+
+func (n *literal) walk(f func(node)) {
+	f(n)
+}
+
+type variable struct {
+	name string
+}
+
+func (n *variable) eval(env Env) (interface{}, error) {
+	v, ok := env(n.name)
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownVariable, n.name)
+	}
+
+	return v, nil
+}
+
+func (n *variable) walk(f func(node)) {
+	f(n)
+}
+
+type unary struct {
+	x node
+}
+
+func (n *unary) eval(env Env) (interface{}, error) {
+	x, err := number(n.x, env)
+	if err != nil {
+		return nil, err
+	}
+
+	return -x, nil
+}
+
+func (n *unary) walk(f func(node)) {
+	f(n)
+	n.x.walk(f)
+}
+
+type binary struct {
+	op   string
+	x, y node
+}
+
+func (n *binary) eval(env Env) (interface{}, error) {
+	if n.op == "==" || n.op == "!=" {
+		x, err := n.x.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		y, err := n.y.eval(env)
+		if err != nil {
+			return nil, err
+		}
+
+		return boolean((x == y) == (n.op == "==")), nil
+	}
+
+	x, err := number(n.x, env)
+	if err != nil {
+		return nil, err
+	}
+	y, err := number(n.y, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "+":
+		return x + y, nil
+	case "-":
+		return x - y, nil
+	case "*":
+		return x * y, nil
+	case "/", "%":
+		if y == 0 {
+			return nil, ErrDivisionByZero
+		}
+		if n.op == "%" {
+			return math.Mod(x, y), nil
+		}
+
+		return x / y, nil
+	case "<":
+		return boolean(x < y), nil
+	case "<=":
+		return boolean(x <= y), nil
+	case ">":
+		return boolean(x > y), nil
+	case ">=":
+		return boolean(x >= y), nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown operator %q", ErrSyntax, n.op)
+}
+
+func (n *binary) walk(f func(node)) {
+	f(n)
+	n.x.walk(f)
+	n.y.walk(f)
+}
+
+type call struct {
+	fn   string
+	args []node
+}
+
+func (n *call) eval(env Env) (interface{}, error) {
+	if n.fn == "default" {
+		v, err := n.args[0].eval(env)
+		if errors.Is(err, ErrUnknownVariable) {
+			return n.args[1].eval(env)
+		}
+
+		return v, err
+	}
+
+	if n.fn == "if" {
+		cond, err := number(n.args[0], env)
+		if err != nil {
+			return nil, err
+		}
+		if cond != 0 {
+			return n.args[1].eval(env)
+		}
+
+		return n.args[2].eval(env)
+	}
+
+	args := make([]float64, len(n.args))
+	for i, a := range n.args {
+		x, err := number(a, env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = x
+	}
+
+	switch n.fn {
+	case "floor":
+		return math.Floor(args[0]), nil
+	case "ceil":
+		return math.Ceil(args[0]), nil
+	case "round":
+		return math.Round(args[0]), nil
+	case "abs":
+		return math.Abs(args[0]), nil
+	case "min", "max":
+		res := args[0]
+		for _, x := range args[1:] {
+			if n.fn == "min" {
+				res = math.Min(res, x)
+			} else {
+				res = math.Max(res, x)
+			}
+		}
+
+		return res, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown function %q", ErrSyntax, n.fn)
+}
+
+func (n *call) walk(f func(node)) {
+	f(n)
+	for _, a := range n.args {
+		a.walk(f)
+	}
+}
+
+// arity lists the functions with their minimum and maximum (-1 for any)
+// number of arguments.
+var arity = map[string][2]int{
+	"floor":   {1, 1},
+	"ceil":    {1, 1},
+	"round":   {1, 1},
+	"abs":     {1, 1},
+	"min":     {1, -1},
+	"max":     {1, -1},
+	"if":      {3, 3},
+	"default": {2, 2},
+}
+
+func number(n node, env Env) (float64, error) {
+	v, err := n.eval(env)
+	if err != nil {
+		return 0, err
+	}
+
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case bool:
+		return boolean(v), nil
+	}
+
+	return 0, fmt.Errorf("%w: %v is not a number", ErrType, v)
+}
+
+func boolean(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
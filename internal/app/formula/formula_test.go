@@ -0,0 +1,99 @@
+package formula_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/formula"
+	"github.com/stretchr/testify/assert"
+)
+
+func testEnv(vars map[string]interface{}) formula.Env {
+	return func(name string) (interface{}, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func TestExpr_Eval(t *testing.T) {
+	env := testEnv(map[string]interface{}{
+		"abilities.str": 15.0,
+		"level":         5.0,
+		"skills.arcana": "expertise",
+	})
+
+	testCases := []struct {
+		name     string
+		src      string
+		expected interface{}
+		err      error
+	}{
+		{"arithmetic", "1 + 2 * 3 - 4 / 2", 5.0, nil},
+		{"parentheses", "(1 + 2) * 3", 9.0, nil},
+		{"unary minus", "-2 * -3", 6.0, nil},
+		{"modulo", "7 % 4", 3.0, nil},
+		{"modifier", "floor((abilities.str - 10) / 2)", 2.0, nil},
+		{"case insensitive", "floor((ABILITIES.STR - 10) / 2)", 2.0, nil},
+		{"proficiency bonus", "ceil(level / 4) + 1", 3.0, nil},
+		{"min and max", "max(1, min(level, 3), 2)", 3.0, nil},
+		{"comparison", "level >= 5", 1.0, nil},
+		{"string comparison", "if(skills.arcana == 'expertise', 2, 1) * 3", 6.0, nil},
+		{"string", `"hello"`, "hello", nil},
+		{"default", "default(skills.history, 'none') == 'none'", 1.0, nil},
+		{"default not needed", "default(level, 1)", 5.0, nil},
+		{"unknown variable", "abilities.dex + 1", nil, formula.ErrUnknownVariable},
+		{"string arithmetic", "skills.arcana + 1", nil, formula.ErrType},
+		{"division by zero", "1 / (level - 5)", nil, formula.ErrDivisionByZero},
+		{"unknown function", "sqrt(4)", nil, formula.ErrSyntax},
+		{"wrong arity", "floor(1, 2)", nil, formula.ErrSyntax},
+		{"unbalanced", "(1 + 2", nil, formula.ErrSyntax},
+		{"trailing garbage", "1 2", nil, formula.ErrSyntax},
+		{"invalid character", "1 $", nil, formula.ErrSyntax},
+		{"unterminated string", "'abc", nil, formula.ErrSyntax},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e, err := formula.Parse(tc.src)
+			if err == nil {
+				var v interface{}
+				v, err = e.Eval(env)
+				if tc.err == nil {
+					assert.Equal(t, tc.expected, v)
+				}
+			}
+
+			if tc.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestExpr_Refs(t *testing.T) {
+	e, err := formula.Parse("str_mod + if(Level > 4, proficiency_bonus, str_mod)")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"str_mod", "level", "proficiency_bonus"}, e.Refs())
+}
+
+func TestCompute(t *testing.T) {
+	env := testEnv(map[string]interface{}{"abilities.str": 16.0, "level": 5.0})
+
+	values, err := formula.Compute(map[string]string{
+		"attack":            "str_mod + proficiency_bonus",
+		"str_mod":           "floor((abilities.str - 10) / 2)",
+		"proficiency_bonus": "ceil(level / 4) + 1",
+		"dex_mod":           "floor((abilities.dex - 10) / 2)",
+		"initiative":        "dex_mod",
+	}, env)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"attack": 6, "str_mod": 3, "proficiency_bonus": 3}, values)
+
+	_, err = formula.Compute(map[string]string{"a": "b + 1", "b": "c + 1", "c": "a + 1"}, env)
+	assert.ErrorIs(t, err, formula.ErrCycle)
+	assert.EqualError(t, err, "formulas depend on each other: a -> b -> c -> a")
+
+	_, err = formula.Compute(map[string]string{"a": "1 +"}, env)
+	assert.ErrorIs(t, err, formula.ErrSyntax)
+}
@@ -0,0 +1,266 @@
+package formula
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	tokEOF = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind int
+	text string
+	pos  int
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && (l.src[l.pos] == ' ' || l.src[l.pos] == '\t' || l.src[l.pos] == '\n') {
+		l.pos++
+	}
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case isDigit(c) || c == '.' && l.pos+1 < len(l.src) && isDigit(l.src[l.pos+1]):
+		for l.pos < len(l.src) && (isDigit(l.src[l.pos]) || l.src[l.pos] == '.') {
+			l.pos++
+		}
+
+		return token{kind: tokNumber, text: l.src[start:l.pos], pos: start}, nil
+	case isIdentStart(c):
+		for l.pos < len(l.src) && (isIdentStart(l.src[l.pos]) || isDigit(l.src[l.pos]) || l.src[l.pos] == '.') {
+			l.pos++
+		}
+
+		return token{kind: tokIdent, text: strings.ToLower(l.src[start:l.pos]), pos: start}, nil
+	case c == '"' || c == '\'':
+		end := strings.IndexByte(l.src[l.pos+1:], c)
+		if end < 0 {
+			return token{}, fmt.Errorf("%w: unterminated string at %d", ErrSyntax, start)
+		}
+		l.pos += end + 2
+
+		return token{kind: tokString, text: l.src[start+1 : l.pos-1], pos: start}, nil
+	}
+
+	for _, op := range []string{"<=", ">=", "==", "!=", "+", "-", "*", "/", "%", "<", ">", "(", ")", ","} {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += len(op)
+
+			return token{kind: tokOp, text: op, pos: start}, nil
+		}
+	}
+
+	return token{}, fmt.Errorf("%w: unexpected %q at %d", ErrSyntax, c, start)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+type parser struct {
+	lex   *lexer
+	tok   token
+	err   error
+	depth int
+}
+
+func (p *parser) advance() {
+	if p.err != nil {
+		return
+	}
+
+	p.tok, p.err = p.lex.next()
+	if p.err != nil {
+		p.tok = token{kind: tokEOF}
+	}
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	return fmt.Errorf("%w: %s at %d", ErrSyntax, fmt.Sprintf(format, args...), p.tok.pos)
+}
+
+func (p *parser) isOp(ops ...string) bool {
+	if p.tok.kind != tokOp {
+		return false
+	}
+	for _, op := range ops {
+		if p.tok.text == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *parser) comparison() (node, error) {
+	x, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.isOp("<", "<=", ">", ">=", "==", "!=") {
+		op := p.tok.text
+		p.advance()
+		y, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		x = &binary{op: op, x: x, y: y}
+	}
+
+	return x, nil
+}
+
+func (p *parser) additive() (node, error) {
+	x, err := p.multiplicative()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOp("+", "-") {
+		op := p.tok.text
+		p.advance()
+		y, err := p.multiplicative()
+		if err != nil {
+			return nil, err
+		}
+		x = &binary{op: op, x: x, y: y}
+	}
+
+	return x, nil
+}
+
+func (p *parser) multiplicative() (node, error) {
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOp("*", "/", "%") {
+		op := p.tok.text
+		p.advance()
+		y, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		x = &binary{op: op, x: x, y: y}
+	}
+
+	return x, nil
+}
+
+func (p *parser) unary() (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, p.errorf("formula is nested too deeply")
+	}
+
+	if p.isOp("-") {
+		p.advance()
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &unary{x: x}, nil
+	}
+
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	tok := p.tok
+	switch {
+	case tok.kind == tokNumber:
+		p.advance()
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid number %q", ErrSyntax, tok.text)
+		}
+
+		return &literal{value: f}, nil
+	case tok.kind == tokString:
+		p.advance()
+
+		return &literal{value: tok.text}, nil
+	case tok.kind == tokIdent:
+		p.advance()
+		if !p.isOp("(") {
+			return &variable{name: tok.text}, nil
+		}
+
+		return p.call(tok)
+	case p.isOp("("):
+		p.advance()
+		x, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isOp(")") {
+			return nil, p.errorf("missing )")
+		}
+		p.advance()
+
+		return x, nil
+	case tok.kind == tokEOF:
+		return nil, p.errorf("unexpected end of formula")
+	}
+
+	return nil, p.errorf("unexpected %q", tok.text)
+}
+
+func (p *parser) call(name token) (node, error) {
+	n, ok := arity[name.text]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown function %q at %d", ErrSyntax, name.text, name.pos)
+	}
+
+	p.advance()
+	c := &call{fn: name.text}
+	for !p.isOp(")") {
+		if len(c.args) > 0 {
+			if !p.isOp(",") {
+				return nil, p.errorf("missing , or )")
+			}
+			p.advance()
+		}
+
+		x, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		c.args = append(c.args, x)
+	}
+	p.advance()
+
+	if len(c.args) < n[0] || n[1] >= 0 && len(c.args) > n[1] {
+		return nil, fmt.Errorf("%w: wrong number of arguments to %s at %d", ErrSyntax, name.text, name.pos)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,161 @@
+// Package geometry computes what can be seen on a scene: visibility
+// polygons cast from a point against wall segments, and masks of grid cells
+// for fog of war.
+package geometry
+
+import (
+	"math"
+	"sort"
+)
+
+// circleSteps is the number of vertices approximating the edge of a
+// limited sight or light radius.
+const circleSteps = 64
+
+const epsilon = 1e-4
+
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type Segment struct {
+	A Point `json:"a"`
+	B Point `json:"b"`
+}
+
+// Polygon is a simple polygon given by its vertices in order.
+type Polygon []Point
+
+// Rect returns the edges of the rectangle from the origin to (w, h).
+func Rect(w, h float64) []Segment {
+	tl, tr, br, bl := Point{0, 0}, Point{w, 0}, Point{w, h}, Point{0, h}
+
+	return []Segment{{tl, tr}, {tr, br}, {br, bl}, {bl, tl}}
+}
+
+func (p Point) Distance(q Point) float64 {
+	return math.Hypot(q.X-p.X, q.Y-p.Y)
+}
+
+// Visibility casts rays from origin towards every wall endpoint, slightly to
+// either side of it, and returns the polygon of what the walls leave in
+// sight. A positive radius limits sight to a circle; without one, walls must
+// enclose the origin, e.g. the scene's Rect.
+func Visibility(origin Point, walls []Segment, radius float64) Polygon {
+	angles := []float64{}
+	for _, w := range walls {
+		for _, p := range []Point{w.A, w.B} {
+			a := math.Atan2(p.Y-origin.Y, p.X-origin.X)
+			angles = append(angles, a-epsilon, a, a+epsilon)
+		}
+	}
+
+	if radius > 0 {
+		for i := 0; i < circleSteps; i++ {
+			angles = append(angles, -math.Pi+2*math.Pi*float64(i)/circleSteps)
+		}
+	}
+
+	sort.Float64s(angles)
+
+	polygon := Polygon{}
+	for i, a := range angles {
+		if i > 0 && a-angles[i-1] < epsilon/10 {
+			continue
+		}
+
+		dx, dy := math.Cos(a), math.Sin(a)
+		t := math.Inf(1)
+		if radius > 0 {
+			t = radius
+		}
+
+		for _, w := range walls {
+			if hit, ok := cast(origin, dx, dy, w); ok && hit < t {
+				t = hit
+			}
+		}
+
+		if math.IsInf(t, 1) {
+			continue
+		}
+
+		polygon = append(polygon, Point{origin.X + dx*t, origin.Y + dy*t})
+	}
+
+	return polygon
+}
+
+// cast returns the distance along the ray from origin in direction (dx, dy)
+// at which it hits the segment.
+func cast(origin Point, dx, dy float64, s Segment) (float64, bool) {
+	ex, ey := s.B.X-s.A.X, s.B.Y-s.A.Y
+	denom := dx*ey - dy*ex
+	if math.Abs(denom) < 1e-12 {
+		return 0, false
+	}
+
+	ox, oy := s.A.X-origin.X, s.A.Y-origin.Y
+	t := (ox*ey - oy*ex) / denom
+	u := (ox*dy - oy*dx) / denom
+	if t < 0 || u < 0 || u > 1 {
+		return 0, false
+	}
+
+	return t, true
+}
+
+// Intersects reports whether the segments cross or touch.
+func (s Segment) Intersects(o Segment) bool {
+	d1, d2 := orientation(o.A, o.B, s.A), orientation(o.A, o.B, s.B)
+	d3, d4 := orientation(s.A, s.B, o.A), orientation(s.A, s.B, o.B)
+	if d1*d2 < 0 && d3*d4 < 0 {
+		return true
+	}
+
+	return d1 == 0 && o.covers(s.A) || d2 == 0 && o.covers(s.B) ||
+		d3 == 0 && s.covers(o.A) || d4 == 0 && s.covers(o.B)
+}
+
+// covers reports whether a point known to be collinear with the segment
+// lies on it.
+func (s Segment) covers(p Point) bool {
+	return math.Min(s.A.X, s.B.X) <= p.X && p.X <= math.Max(s.A.X, s.B.X) &&
+		math.Min(s.A.Y, s.B.Y) <= p.Y && p.Y <= math.Max(s.A.Y, s.B.Y)
+}
+
+// orientation is positive when c lies left of the line from a to b,
+// negative when right and zero when on it.
+func orientation(a, b, c Point) float64 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+// Contains reports whether the point lies inside the polygon.
+func (p Polygon) Contains(pt Point) bool {
+	inside := false
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		a, b := p[i], p[j]
+		if (a.Y > pt.Y) != (b.Y > pt.Y) && pt.X < (b.X-a.X)*(pt.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// Bounds returns the smallest rectangle containing the polygon as its
+// minimum and maximum corners.
+func (p Polygon) Bounds() (Point, Point) {
+	if len(p) == 0 {
+		return Point{}, Point{}
+	}
+
+	min, max := p[0], p[0]
+	for _, pt := range p[1:] {
+		min.X, min.Y = math.Min(min.X, pt.X), math.Min(min.Y, pt.Y)
+		max.X, max.Y = math.Max(max.X, pt.X), math.Max(max.Y, pt.Y)
+	}
+
+	return min, max
+}
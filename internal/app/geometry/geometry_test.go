@@ -0,0 +1,112 @@
+package geometry_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/geometry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisibility(t *testing.T) {
+	// A 100x100 room split by a wall from (50, 0) to (50, 80), leaving a gap
+	// at the bottom.
+	walls := append(geometry.Rect(100, 100), geometry.Segment{A: geometry.Point{X: 50, Y: 0}, B: geometry.Point{X: 50, Y: 80}})
+	p := geometry.Visibility(geometry.Point{X: 25, Y: 25}, walls, 0)
+
+	testCases := []struct {
+		name    string
+		point   geometry.Point
+		visible bool
+	}{
+		{"same side", geometry.Point{X: 10, Y: 90}, true},
+		{"behind the wall", geometry.Point{X: 75, Y: 25}, false},
+		{"through the gap", geometry.Point{X: 55, Y: 98}, true},
+		{"around the corner", geometry.Point{X: 90, Y: 85}, false},
+		{"outside the room", geometry.Point{X: 10, Y: 110}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.visible, p.Contains(tc.point))
+		})
+	}
+}
+
+func TestVisibility_Radius(t *testing.T) {
+	p := geometry.Visibility(geometry.Point{X: 50, Y: 50}, geometry.Rect(100, 100), 20)
+
+	assert.True(t, p.Contains(geometry.Point{X: 65, Y: 50}))
+	assert.False(t, p.Contains(geometry.Point{X: 75, Y: 50}))
+	assert.False(t, p.Contains(geometry.Point{X: 65, Y: 65}))
+
+	min, max := p.Bounds()
+	assert.InDelta(t, 30, min.X, 0.01)
+	assert.InDelta(t, 70, max.Y, 0.01)
+}
+
+func TestVisibility_NoWalls(t *testing.T) {
+	assert.Empty(t, geometry.Visibility(geometry.Point{X: 50, Y: 50}, nil, 0))
+}
+
+func TestSegment_Intersects(t *testing.T) {
+	wall := geometry.Segment{A: geometry.Point{X: 50, Y: 0}, B: geometry.Point{X: 50, Y: 100}}
+
+	testCases := []struct {
+		name       string
+		s          geometry.Segment
+		intersects bool
+	}{
+		{"crossing", geometry.Segment{A: geometry.Point{X: 0, Y: 50}, B: geometry.Point{X: 100, Y: 50}}, true},
+		{"short", geometry.Segment{A: geometry.Point{X: 0, Y: 50}, B: geometry.Point{X: 40, Y: 50}}, false},
+		{"past the end", geometry.Segment{A: geometry.Point{X: 0, Y: 120}, B: geometry.Point{X: 100, Y: 110}}, false},
+		{"touching", geometry.Segment{A: geometry.Point{X: 0, Y: 50}, B: geometry.Point{X: 50, Y: 50}}, true},
+		{"along", geometry.Segment{A: geometry.Point{X: 50, Y: 90}, B: geometry.Point{X: 50, Y: 150}}, true},
+		{"parallel", geometry.Segment{A: geometry.Point{X: 60, Y: 0}, B: geometry.Point{X: 60, Y: 100}}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.intersects, tc.s.Intersects(wall))
+			assert.Equal(t, tc.intersects, wall.Intersects(tc.s))
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	m := geometry.NewMask(100, 50, 20)
+	assert.Equal(t, 5, m.Columns)
+	assert.Equal(t, 3, m.Rows)
+	assert.True(t, m.Fits(100, 50, 20))
+	assert.False(t, m.Fits(100, 70, 20))
+
+	m.Fill(geometry.Polygon{{X: 0, Y: 0}, {X: 40, Y: 0}, {X: 40, Y: 40}, {X: 0, Y: 40}}, nil)
+	assert.Equal(t, 4, m.Count())
+	assert.True(t, m.Has(1, 1))
+	assert.False(t, m.Has(2, 1))
+	assert.False(t, m.Has(7, 1))
+
+	other := geometry.NewMask(100, 50, 20)
+	other.Fill(geometry.Polygon{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 20}, {X: 0, Y: 20}}, func(p geometry.Point) bool {
+		return p.X > 60
+	})
+	assert.Equal(t, 2, other.Count())
+
+	changed, err := m.Merge(other)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 6, m.Count())
+	changed, _ = m.Merge(other)
+	assert.False(t, changed)
+
+	_, err = m.Merge(geometry.NewMask(10, 10, 20))
+	assert.ErrorIs(t, err, geometry.ErrInvalidMask)
+
+	b, err := json.Marshal(m)
+	assert.NoError(t, err)
+	decoded := &geometry.Mask{}
+	assert.NoError(t, json.Unmarshal(b, decoded))
+	assert.Equal(t, m, decoded)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"columns": 5, "rows": 3, "cell_size": 20, "cells": "AAAA"}`), decoded))
+}
@@ -0,0 +1,153 @@
+package geometry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+)
+
+var (
+	ErrInvalidMask = errors.New("invalid mask")
+)
+
+// Mask marks square cells of an area, e.g. the parts of a scene a player
+// has explored. Cells are stored row by row, one bit each.
+type Mask struct {
+	Columns  int
+	Rows     int
+	CellSize float64
+	bits     []byte
+}
+
+type maskJSON struct {
+	Columns  int     `json:"columns"`
+	Rows     int     `json:"rows"`
+	CellSize float64 `json:"cell_size"`
+	Cells    string  `json:"cells"`
+}
+
+// NewMask returns an empty mask covering a w by h area with cells of the
+// given size.
+func NewMask(w, h, cellSize float64) *Mask {
+	m := &Mask{
+		Columns:  int(math.Ceil(w / cellSize)),
+		Rows:     int(math.Ceil(h / cellSize)),
+		CellSize: cellSize,
+	}
+	m.bits = make([]byte, (m.Columns*m.Rows+7)/8)
+
+	return m
+}
+
+// Fits reports whether the mask covers a w by h area with cells of the
+// given size, i.e. whether it was made for the same scene.
+func (m *Mask) Fits(w, h, cellSize float64) bool {
+	n := NewMask(w, h, cellSize)
+
+	return m.Columns == n.Columns && m.Rows == n.Rows && m.CellSize == cellSize
+}
+
+func (m *Mask) Has(col, row int) bool {
+	if col < 0 || row < 0 || col >= m.Columns || row >= m.Rows {
+		return false
+	}
+
+	i := row*m.Columns + col
+
+	return m.bits[i/8]&(1<<(i%8)) != 0
+}
+
+func (m *Mask) Set(col, row int) {
+	if col < 0 || row < 0 || col >= m.Columns || row >= m.Rows {
+		return
+	}
+
+	i := row*m.Columns + col
+	m.bits[i/8] |= 1 << (i % 8)
+}
+
+// Contains reports whether the cell p lies in is marked.
+func (m *Mask) Contains(p Point) bool {
+	return m.Has(int(math.Floor(p.X/m.CellSize)), int(math.Floor(p.Y/m.CellSize)))
+}
+
+// Fill sets every cell whose centre lies inside the polygon and passes
+// the filter, if any.
+func (m *Mask) Fill(p Polygon, filter func(Point) bool) {
+	min, max := p.Bounds()
+	for row := int(min.Y / m.CellSize); row <= int(max.Y/m.CellSize) && row < m.Rows; row++ {
+		for col := int(min.X / m.CellSize); col <= int(max.X/m.CellSize) && col < m.Columns; col++ {
+			centre := Point{(float64(col) + 0.5) * m.CellSize, (float64(row) + 0.5) * m.CellSize}
+			if p.Contains(centre) && (filter == nil || filter(centre)) {
+				m.Set(col, row)
+			}
+		}
+	}
+}
+
+// SetAll marks every cell.
+func (m *Mask) SetAll() {
+	for col := 0; col < m.Columns; col++ {
+		for row := 0; row < m.Rows; row++ {
+			m.Set(col, row)
+		}
+	}
+}
+
+// Merge marks the cells marked in other and reports whether that changed
+// the mask. Masks of different sizes can't be merged.
+func (m *Mask) Merge(other *Mask) (bool, error) {
+	if m.Columns != other.Columns || m.Rows != other.Rows {
+		return false, ErrInvalidMask
+	}
+
+	changed := false
+	for i, b := range other.bits {
+		if m.bits[i]|b != m.bits[i] {
+			m.bits[i] |= b
+			changed = true
+		}
+	}
+
+	return changed, nil
+}
+
+// Count returns the number of marked cells.
+func (m *Mask) Count() int {
+	n := 0
+	for col := 0; col < m.Columns; col++ {
+		for row := 0; row < m.Rows; row++ {
+			if m.Has(col, row) {
+				n++
+			}
+		}
+	}
+
+	return n
+}
+
+func (m *Mask) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&maskJSON{
+		Columns:  m.Columns,
+		Rows:     m.Rows,
+		CellSize: m.CellSize,
+		Cells:    base64.StdEncoding.EncodeToString(m.bits),
+	})
+}
+
+func (m *Mask) UnmarshalJSON(b []byte) error {
+	v := &maskJSON{}
+	if err := json.Unmarshal(b, v); err != nil {
+		return err
+	}
+
+	bits, err := base64.StdEncoding.DecodeString(v.Cells)
+	if err != nil || v.Columns < 0 || v.Rows < 0 || len(bits) != (v.Columns*v.Rows+7)/8 {
+		return ErrInvalidMask
+	}
+
+	m.Columns, m.Rows, m.CellSize, m.bits = v.Columns, v.Rows, v.CellSize, bits
+
+	return nil
+}
@@ -0,0 +1,199 @@
+// Package grid measures distances and areas on the grid of a scene, square
+// or hex, the way tabletop rules count them.
+package grid
+
+import (
+	"math"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/geometry"
+)
+
+const (
+	Square = "square"
+	Hex    = "hex"
+)
+
+// Hex grids have either pointy or flat tops.
+const (
+	Pointy = "pointy"
+	Flat   = "flat"
+)
+
+// Diagonal rules of square grids: every diagonal step costs one cell, every
+// second one costs two, or steps are measured as the crow flies.
+const (
+	DiagonalsFive        = "5-5-5"
+	DiagonalsAlternating = "5-10-5"
+	DiagonalsEuclidean   = "euclidean"
+)
+
+// Cell is a grid cell. Hex cells use offset coordinates: odd rows are
+// shifted right on pointy grids, odd columns down on flat ones.
+type Cell struct {
+	Col int `json:"col"`
+	Row int `json:"row"`
+}
+
+// Grid lays cells over a Width by Height area. Size and offsets are in
+// pixels; on hex grids Size is the distance between the centres of
+// neighbouring cells. Distance is how far one cell is in game units, e.g.
+// 5 feet.
+type Grid struct {
+	Type        string
+	Orientation string
+	Diagonals   string
+	Size        float64
+	OffsetX     float64
+	OffsetY     float64
+	Width       float64
+	Height      float64
+	Distance    float64
+}
+
+// CellAt returns the cell containing the point.
+func (g *Grid) CellAt(p geometry.Point) Cell {
+	x, y := p.X-g.OffsetX, p.Y-g.OffsetY
+	if g.Type != Hex {
+		return Cell{int(math.Floor(x / g.Size)), int(math.Floor(y / g.Size))}
+	}
+
+	r := g.radius()
+	if g.Orientation == Flat {
+		x, y = x-r, y-g.Size/2
+		q, s := cubeRound(2.0/3*x/r, (-x/3+math.Sqrt(3)/3*y)/r)
+		return Cell{q, s + (q-q&1)/2}
+	}
+
+	x, y = x-g.Size/2, y-r
+	q, s := cubeRound((math.Sqrt(3)/3*x-y/3)/r, 2.0/3*y/r)
+	return Cell{q + (s-s&1)/2, s}
+}
+
+// Center returns the centre of the cell in pixels.
+func (g *Grid) Center(c Cell) geometry.Point {
+	if g.Type != Hex {
+		return geometry.Point{
+			X: g.OffsetX + (float64(c.Col)+0.5)*g.Size,
+			Y: g.OffsetY + (float64(c.Row)+0.5)*g.Size,
+		}
+	}
+
+	r := g.radius()
+	if g.Orientation == Flat {
+		return geometry.Point{
+			X: g.OffsetX + r + float64(c.Col)*1.5*r,
+			Y: g.OffsetY + g.Size/2 + g.Size*(float64(c.Row)+0.5*float64(c.Col&1)),
+		}
+	}
+
+	return geometry.Point{
+		X: g.OffsetX + g.Size/2 + g.Size*(float64(c.Col)+0.5*float64(c.Row&1)),
+		Y: g.OffsetY + r + float64(c.Row)*1.5*r,
+	}
+}
+
+// Contains reports whether the centre of the cell lies on the grid's area.
+func (g *Grid) Contains(c Cell) bool {
+	p := g.Center(c)
+
+	return p.X >= 0 && p.Y >= 0 && p.X <= g.Width && p.Y <= g.Height
+}
+
+// Measure returns the length in game units of the path through the points,
+// counted in cells between the cells they lie in.
+func (g *Grid) Measure(path []geometry.Point) float64 {
+	cells := 0.0
+	for _, n := range g.legs(path) {
+		cells += n
+	}
+
+	return cells * g.Distance
+}
+
+// Legs returns the length in game units of every segment of the path, as
+// measured along the whole path. They add up to what Measure returns.
+func (g *Grid) Legs(path []geometry.Point) []float64 {
+	legs := g.legs(path)
+	for i := range legs {
+		legs[i] *= g.Distance
+	}
+
+	return legs
+}
+
+// legs returns the length of every segment of the path in cells.
+func (g *Grid) legs(path []geometry.Point) []float64 {
+	legs := []float64{}
+	diagonals := 0
+	for i := 1; i < len(path); i++ {
+		a, b := g.CellAt(path[i-1]), g.CellAt(path[i])
+		if g.Type == Hex {
+			q1, r1 := g.axial(a)
+			q2, r2 := g.axial(b)
+			legs = append(legs, float64(hexDistance(q2-q1, r2-r1)))
+			continue
+		}
+
+		dx, dy := abs(b.Col-a.Col), abs(b.Row-a.Row)
+		straight, d := dx-dy, dy
+		if dy > dx {
+			straight, d = dy-dx, dx
+		}
+
+		switch g.Diagonals {
+		case DiagonalsEuclidean:
+			legs = append(legs, math.Hypot(float64(dx), float64(dy)))
+		case DiagonalsAlternating:
+			// Every second diagonal of the whole path costs double, so
+			// the count carries over between segments.
+			legs = append(legs, float64(straight+d+(diagonals+d)/2-diagonals/2))
+			diagonals += d
+		default:
+			legs = append(legs, float64(straight+d))
+		}
+	}
+
+	return legs
+}
+
+// radius is the distance from the centre of a hex cell to its corners.
+func (g *Grid) radius() float64 {
+	return g.Size / math.Sqrt(3)
+}
+
+// axial converts the offset coordinates of a hex cell to axial ones.
+func (g *Grid) axial(c Cell) (int, int) {
+	if g.Orientation == Flat {
+		return c.Col, c.Row - (c.Col-c.Col&1)/2
+	}
+
+	return c.Col - (c.Row-c.Row&1)/2, c.Row
+}
+
+// hexDistance returns the number of steps between hex cells dq and dr apart
+// in axial coordinates.
+func hexDistance(dq, dr int) int {
+	return (abs(dq) + abs(dr) + abs(dq+dr)) / 2
+}
+
+// cubeRound rounds fractional axial coordinates to the nearest hex cell.
+func cubeRound(q, r float64) (int, int) {
+	s := -q - r
+	rq, rr, rs := math.Round(q), math.Round(r), math.Round(s)
+	dq, dr, ds := math.Abs(rq-q), math.Abs(rr-r), math.Abs(rs-s)
+	if dq > dr && dq > ds {
+		rq = -rr - rs
+	} else if dr > ds {
+		rr = -rq - rs
+	}
+
+	return int(rq), int(rr)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
@@ -0,0 +1,143 @@
+package grid_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/geometry"
+	"github.com/bruhlord-s/virttable-api/internal/app/grid"
+	"github.com/stretchr/testify/assert"
+)
+
+func squareGrid(diagonals string) *grid.Grid {
+	return &grid.Grid{Type: grid.Square, Diagonals: diagonals, Size: 70, Width: 1400, Height: 1050, Distance: 5}
+}
+
+func TestGrid_CellAt(t *testing.T) {
+	g := squareGrid(grid.DiagonalsFive)
+	g.OffsetX = 10
+	assert.Equal(t, grid.Cell{Col: 0, Row: 1}, g.CellAt(geometry.Point{X: 79, Y: 70}))
+	assert.Equal(t, grid.Cell{Col: 1, Row: 1}, g.CellAt(geometry.Point{X: 80, Y: 139}))
+	assert.Equal(t, grid.Cell{Col: -1, Row: 0}, g.CellAt(geometry.Point{X: 5, Y: 0}))
+
+	for _, orientation := range []string{grid.Pointy, grid.Flat} {
+		t.Run(orientation, func(t *testing.T) {
+			g := &grid.Grid{Type: grid.Hex, Orientation: orientation, Size: 60, OffsetX: 5, OffsetY: 7, Width: 1000, Height: 1000, Distance: 5}
+			for _, c := range []grid.Cell{{0, 0}, {1, 0}, {0, 1}, {3, 4}, {4, 3}, {7, 7}} {
+				assert.Equal(t, c, g.CellAt(g.Center(c)))
+
+				p := g.Center(c)
+				p.X += 20
+				assert.Equal(t, c, g.CellAt(p))
+			}
+		})
+	}
+}
+
+func TestGrid_Measure(t *testing.T) {
+	// Six cells right and four down, then four more down.
+	path := []geometry.Point{{X: 35, Y: 35}, {X: 455, Y: 315}, {X: 455, Y: 595}}
+
+	testCases := []struct {
+		name     string
+		grid     *grid.Grid
+		path     []geometry.Point
+		expected float64
+	}{
+		{"5-5-5", squareGrid(grid.DiagonalsFive), path, 50},
+		{"5-10-5", squareGrid(grid.DiagonalsAlternating), path, 60},
+		{"5-10-5 carries over", squareGrid(grid.DiagonalsAlternating), []geometry.Point{{X: 35, Y: 35}, {X: 105, Y: 105}, {X: 175, Y: 175}}, 15},
+		{"euclidean", squareGrid(grid.DiagonalsEuclidean), []geometry.Point{{X: 35, Y: 35}, {X: 245, Y: 315}}, 25},
+		{"standing still", squareGrid(grid.DiagonalsFive), []geometry.Point{{X: 35, Y: 35}, {X: 60, Y: 60}}, 0},
+		{"single point", squareGrid(grid.DiagonalsFive), []geometry.Point{{X: 35, Y: 35}}, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.InDelta(t, tc.expected, tc.grid.Measure(tc.path), 0.001)
+		})
+	}
+}
+
+func TestGrid_Legs(t *testing.T) {
+	g := squareGrid(grid.DiagonalsAlternating)
+	path := []geometry.Point{{X: 35, Y: 35}, {X: 105, Y: 105}, {X: 175, Y: 175}, {X: 455, Y: 175}}
+
+	assert.Equal(t, []float64{5, 10, 20}, g.Legs(path))
+	assert.Empty(t, g.Legs(path[:1]))
+}
+
+func TestGrid_Measure_Hex(t *testing.T) {
+	for _, orientation := range []string{grid.Pointy, grid.Flat} {
+		t.Run(orientation, func(t *testing.T) {
+			g := &grid.Grid{Type: grid.Hex, Orientation: orientation, Size: 60, Width: 1000, Height: 1000, Distance: 5}
+			from := g.Center(grid.Cell{Col: 2, Row: 2})
+
+			assert.Equal(t, 5.0, g.Measure([]geometry.Point{from, g.Center(grid.Cell{Col: 3, Row: 2})}))
+			assert.Equal(t, 5.0, g.Measure([]geometry.Point{from, g.Center(grid.Cell{Col: 2, Row: 3})}))
+			assert.Equal(t, 20.0, g.Measure([]geometry.Point{from, g.Center(grid.Cell{Col: 6, Row: 2})}))
+			assert.Equal(t, 20.0, g.Measure([]geometry.Point{from, g.Center(grid.Cell{Col: 2, Row: 6})}))
+		})
+	}
+}
+
+func TestGrid_Area(t *testing.T) {
+	g := squareGrid(grid.DiagonalsFive)
+	origin := geometry.Point{X: 350, Y: 350}
+
+	testCases := []struct {
+		name     string
+		template *grid.Template
+		cells    int
+		inside   []grid.Cell
+		outside  []grid.Cell
+	}{
+		{"sphere", &grid.Template{Shape: grid.Sphere, Origin: origin, Size: 10}, 12, []grid.Cell{{4, 4}, {3, 5}}, []grid.Cell{{3, 3}, {6, 6}}},
+		{"cube", &grid.Template{Shape: grid.Cube, Origin: geometry.Point{X: 350, Y: 385}, Size: 15}, 9, []grid.Cell{{5, 4}, {7, 6}}, []grid.Cell{{4, 5}, {8, 5}}},
+		{"line", &grid.Template{Shape: grid.Line, Origin: geometry.Point{X: 350, Y: 385}, Direction: 90, Size: 30}, 6, []grid.Cell{{4, 5}, {4, 10}}, []grid.Cell{{5, 5}, {4, 11}}},
+		{"cone", &grid.Template{Shape: grid.Cone, Origin: geometry.Point{X: 350, Y: 385}, Size: 15}, 5, []grid.Cell{{5, 5}, {7, 4}, {7, 6}}, []grid.Cell{{4, 5}, {5, 4}}},
+		{"off the map", &grid.Template{Shape: grid.Sphere, Origin: geometry.Point{}, Size: 10}, 3, []grid.Cell{{0, 0}}, []grid.Cell{{-1, -1}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := g.Area(tc.template)
+			assert.NoError(t, err)
+
+			cells := g.Cells(p)
+			assert.Len(t, cells, tc.cells)
+			for _, c := range tc.inside {
+				assert.Contains(t, cells, c)
+			}
+			for _, c := range tc.outside {
+				assert.NotContains(t, cells, c)
+			}
+		})
+	}
+
+	_, err := g.Area(&grid.Template{Shape: "pyramid", Size: 10})
+	assert.ErrorIs(t, err, grid.ErrUnknownShape)
+	_, err = g.Area(&grid.Template{Shape: grid.Sphere})
+	assert.ErrorIs(t, err, grid.ErrInvalidTemplate)
+	_, err = g.Area(&grid.Template{Shape: grid.Sphere, Origin: geometry.Point{X: -70, Y: 350}, Size: 10})
+	assert.ErrorIs(t, err, grid.ErrTemplateOffGrid)
+	_, err = g.Area(&grid.Template{Shape: grid.Sphere, Origin: origin, Size: 1e9})
+	assert.ErrorIs(t, err, grid.ErrTemplateTooLarge)
+	_, err = g.Area(&grid.Template{Shape: grid.Line, Origin: origin, Size: 10, Width: 1e9})
+	assert.ErrorIs(t, err, grid.ErrTemplateTooLarge)
+}
+
+func TestGrid_Cells(t *testing.T) {
+	g := squareGrid(grid.DiagonalsFive)
+	huge := geometry.Polygon{{X: -1e12, Y: -1e12}, {X: 1e12, Y: -1e12}, {X: 1e12, Y: 1e12}, {X: -1e12, Y: 1e12}}
+	assert.Len(t, g.Cells(huge), 20*15)
+
+	beside := geometry.Polygon{{X: 2000, Y: 0}, {X: 2100, Y: 0}, {X: 2100, Y: 100}}
+	assert.Empty(t, g.Cells(beside))
+}
+
+func TestGrid_Footprint(t *testing.T) {
+	g := squareGrid(grid.DiagonalsFive)
+	assert.Equal(t, []grid.Cell{{1, 1}}, g.Footprint(geometry.Point{X: 105, Y: 105}, 1))
+	assert.Equal(t, []grid.Cell{{1, 1}, {2, 1}, {1, 2}, {2, 2}}, g.Footprint(geometry.Point{X: 140, Y: 140}, 2))
+	assert.Len(t, g.Footprint(geometry.Point{X: 175, Y: 175}, 3), 9)
+}
@@ -0,0 +1,130 @@
+package grid
+
+import (
+	"errors"
+	"math"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/geometry"
+)
+
+// Shapes of area of effect templates.
+const (
+	Cone   = "cone"
+	Sphere = "sphere"
+	Line   = "line"
+	Cube   = "cube"
+)
+
+// circleSteps is the number of vertices approximating a sphere.
+const circleSteps = 64
+
+var (
+	ErrUnknownShape     = errors.New("unknown template shape")
+	ErrInvalidTemplate  = errors.New("template size must be positive")
+	ErrTemplateOffGrid  = errors.New("template origin must lie on the grid")
+	ErrTemplateTooLarge = errors.New("template can't be larger than the grid")
+)
+
+// Template is an area of effect. Size is the radius of a sphere, the length
+// of a cone or line and the side of a cube, in game units; Width is the
+// width of a line and defaults to one cell. Cones, lines and cubes start at
+// Origin and extend in Direction, in degrees clockwise from east.
+type Template struct {
+	Shape     string         `json:"shape"`
+	Origin    geometry.Point `json:"origin"`
+	Direction float64        `json:"direction"`
+	Size      float64        `json:"size"`
+	Width     float64        `json:"width"`
+}
+
+// Area returns the outline of the template in pixels. As in 5e, a cone is
+// as wide at its end as it is long. Templates start on the grid and are no
+// larger than its diagonal.
+func (g *Grid) Area(t *Template) (geometry.Polygon, error) {
+	if t.Size <= 0 || t.Width < 0 {
+		return nil, ErrInvalidTemplate
+	}
+
+	if t.Origin.X < 0 || t.Origin.Y < 0 || t.Origin.X > g.Width || t.Origin.Y > g.Height {
+		return nil, ErrTemplateOffGrid
+	}
+
+	scale := g.Size / g.Distance
+	size := t.Size * scale
+	diagonal := math.Hypot(g.Width, g.Height)
+	if size > diagonal || t.Width*scale > diagonal {
+		return nil, ErrTemplateTooLarge
+	}
+	a := t.Direction * math.Pi / 180
+	dx, dy := math.Cos(a), math.Sin(a)
+	at := func(along, across float64) geometry.Point {
+		return geometry.Point{
+			X: t.Origin.X + dx*along - dy*across,
+			Y: t.Origin.Y + dy*along + dx*across,
+		}
+	}
+
+	switch t.Shape {
+	case Sphere:
+		p := geometry.Polygon{}
+		for i := 0; i < circleSteps; i++ {
+			s := 2 * math.Pi * float64(i) / circleSteps
+			p = append(p, geometry.Point{X: t.Origin.X + size*math.Cos(s), Y: t.Origin.Y + size*math.Sin(s)})
+		}
+		return p, nil
+	case Cone:
+		return geometry.Polygon{t.Origin, at(size, -size/2), at(size, size/2)}, nil
+	case Line:
+		w := g.Size / 2
+		if t.Width > 0 {
+			w = t.Width * scale / 2
+		}
+		return geometry.Polygon{at(0, -w), at(size, -w), at(size, w), at(0, w)}, nil
+	case Cube:
+		return geometry.Polygon{at(0, -size/2), at(size, -size/2), at(size, size/2), at(0, size/2)}, nil
+	}
+
+	return nil, ErrUnknownShape
+}
+
+// Cells returns the cells of the grid whose centres lie inside the polygon.
+// Only the part of the polygon over the grid is looked at.
+func (g *Grid) Cells(p geometry.Polygon) []Cell {
+	cells := []Cell{}
+	min, max := p.Bounds()
+	min.X, min.Y = math.Max(min.X, 0), math.Max(min.Y, 0)
+	max.X, max.Y = math.Min(max.X, g.Width), math.Min(max.Y, g.Height)
+	if min.X > max.X || min.Y > max.Y {
+		return cells
+	}
+	from, to := g.CellAt(min), g.CellAt(max)
+
+	// Hex cells of a row or column zigzag, so look one cell further.
+	for row := from.Row - 1; row <= to.Row+1; row++ {
+		for col := from.Col - 1; col <= to.Col+1; col++ {
+			c := Cell{col, row}
+			if g.Contains(c) && p.Contains(g.Center(c)) {
+				cells = append(cells, c)
+			}
+		}
+	}
+
+	return cells
+}
+
+// Footprint returns the cells a token of the given size, in cells, covers
+// with its centre at the point. Tokens cover a square of cells on square
+// grids and the cell under their centre on hex grids.
+func (g *Grid) Footprint(center geometry.Point, size float64) []Cell {
+	if g.Type == Hex || size <= 1 {
+		return []Cell{g.CellAt(center)}
+	}
+
+	half := size * g.Size / 2
+	return g.Cells(geometry.Polygon{
+		{X: center.X - half, Y: center.Y - half},
+		{X: center.X + half, Y: center.Y - half},
+		{X: center.X + half, Y: center.Y + half},
+		{X: center.X - half, Y: center.Y + half},
+	})
+}
@@ -0,0 +1,286 @@
+// Package jsonpatch computes and applies JSON patches (RFC 6902) made of
+// add, remove and replace operations.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidPatch = errors.New("invalid patch")
+	ErrPathNotFound = errors.New("path not found")
+)
+
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+)
+
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type Patch []Operation
+
+// Diff returns the patch that turns document a into document b. Arrays are
+// compared element by element, so items are added and removed at the end.
+func Diff(a, b []byte) (Patch, error) {
+	va, err := decode(a)
+	if err != nil {
+		return nil, err
+	}
+
+	vb, err := decode(b)
+	if err != nil {
+		return nil, err
+	}
+
+	p := Patch{}
+	if err := p.diff("", va, vb); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Apply applies the patch to the document and returns the result.
+func Apply(doc []byte, p Patch) ([]byte, error) {
+	v, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range p {
+		if v, err = apply(v, op); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(v)
+}
+
+func (p *Patch) diff(path string, a, b interface{}) error {
+	ma, okA := a.(map[string]interface{})
+	mb, okB := b.(map[string]interface{})
+	if okA && okB {
+		for _, k := range keys(ma) {
+			if _, ok := mb[k]; !ok {
+				*p = append(*p, Operation{Op: OpRemove, Path: path + "/" + escape(k)})
+			}
+		}
+
+		for _, k := range keys(mb) {
+			va, ok := ma[k]
+			if !ok {
+				if err := p.add(OpAdd, path+"/"+escape(k), mb[k]); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := p.diff(path+"/"+escape(k), va, mb[k]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	sa, okA := a.([]interface{})
+	sb, okB := b.([]interface{})
+	if okA && okB {
+		i := 0
+		for ; i < len(sa) && i < len(sb); i++ {
+			if err := p.diff(path+"/"+strconv.Itoa(i), sa[i], sb[i]); err != nil {
+				return err
+			}
+		}
+
+		for j := len(sa) - 1; j >= i; j-- {
+			*p = append(*p, Operation{Op: OpRemove, Path: path + "/" + strconv.Itoa(j)})
+		}
+
+		for ; i < len(sb); i++ {
+			if err := p.add(OpAdd, path+"/"+strconv.Itoa(i), sb[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if reflect.DeepEqual(a, b) {
+		return nil
+	}
+
+	return p.add(OpReplace, path, b)
+}
+
+func (p *Patch) add(op string, path string, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	*p = append(*p, Operation{Op: op, Path: path, Value: value})
+
+	return nil
+}
+
+func apply(doc interface{}, op Operation) (interface{}, error) {
+	var value interface{}
+	switch op.Op {
+	case OpAdd, OpReplace:
+		if len(op.Value) == 0 {
+			return nil, fmt.Errorf("%w: %s %q without value", ErrInvalidPatch, op.Op, op.Path)
+		}
+
+		v, err := decode(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		value = v
+	case OpRemove:
+	default:
+		return nil, fmt.Errorf("%w: unsupported operation %q", ErrInvalidPatch, op.Op)
+	}
+
+	if op.Path == "" {
+		if op.Op == OpRemove {
+			return nil, fmt.Errorf("%w: can't remove the document", ErrInvalidPatch)
+		}
+
+		return value, nil
+	}
+
+	if !strings.HasPrefix(op.Path, "/") {
+		return nil, fmt.Errorf("%w: invalid path %q", ErrInvalidPatch, op.Path)
+	}
+
+	tokens := strings.Split(op.Path[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = unescape(t)
+	}
+
+	return set(doc, tokens, op.Op, value, op.Path)
+}
+
+// set performs the operation at the location tokens point to inside node
+// and returns the changed node.
+func set(node interface{}, tokens []string, op string, value interface{}, path string) (interface{}, error) {
+	key, last := tokens[0], len(tokens) == 1
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[key]
+		if !ok && (!last || op != OpAdd) {
+			return nil, fmt.Errorf("%w: %s", ErrPathNotFound, path)
+		}
+
+		if !last {
+			c, err := set(child, tokens[1:], op, value, path)
+			if err != nil {
+				return nil, err
+			}
+			n[key] = c
+
+			return n, nil
+		}
+
+		if op == OpRemove {
+			delete(n, key)
+		} else {
+			n[key] = value
+		}
+
+		return n, nil
+	case []interface{}:
+		i, err := index(key, len(n), last && op == OpAdd)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, path)
+		}
+
+		if !last {
+			c, err := set(n[i], tokens[1:], op, value, path)
+			if err != nil {
+				return nil, err
+			}
+			n[i] = c
+
+			return n, nil
+		}
+
+		switch op {
+		case OpAdd:
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = value
+		case OpRemove:
+			n = append(n[:i], n[i+1:]...)
+		default:
+			n[i] = value
+		}
+
+		return n, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrPathNotFound, path)
+	}
+}
+
+// index parses an array index. Adding may also point just past the end,
+// either by number or with "-".
+func index(token string, length int, adding bool) (int, error) {
+	if adding && token == "-" {
+		return length, nil
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || token != strconv.Itoa(i) {
+		return 0, ErrInvalidPatch
+	}
+
+	if i > length || i == length && !adding {
+		return 0, ErrPathNotFound
+	}
+
+	return i, nil
+}
+
+func decode(data []byte) (interface{}, error) {
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func keys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func escape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func unescape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
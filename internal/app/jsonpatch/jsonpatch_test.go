@@ -0,0 +1,81 @@
+package jsonpatch_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/jsonpatch"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a        string
+		b        string
+		expected string
+	}{
+		{"equal", `{"a":1}`, `{"a":1}`, `[]`},
+		{"added field", `{}`, `{"a":1}`, `[{"op":"add","path":"/a","value":1}]`},
+		{"removed field", `{"a":1,"b":2}`, `{"b":2}`, `[{"op":"remove","path":"/a"}]`},
+		{"replaced value", `{"a":{"b":1}}`, `{"a":{"b":false}}`, `[{"op":"replace","path":"/a/b","value":false}]`},
+		{"null value", `{"a":1}`, `{"a":null}`, `[{"op":"replace","path":"/a","value":null}]`},
+		{"appended items", `{"a":[1]}`, `{"a":[1,2,3]}`, `[{"op":"add","path":"/a/1","value":2},{"op":"add","path":"/a/2","value":3}]`},
+		{"truncated items", `{"a":[1,2,3]}`, `{"a":[1]}`, `[{"op":"remove","path":"/a/2"},{"op":"remove","path":"/a/1"}]`},
+		{"escaped key", `{}`, `{"a/b~":1}`, `[{"op":"add","path":"/a~1b~0","value":1}]`},
+		{"different types", `{"a":[1]}`, `{"a":{"0":1}}`, `[{"op":"replace","path":"/a","value":{"0":1}}]`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := jsonpatch.Diff([]byte(tc.a), []byte(tc.b))
+			assert.NoError(t, err)
+
+			actual, _ := json.Marshal(p)
+			assert.JSONEq(t, tc.expected, string(actual))
+
+			doc, err := jsonpatch.Apply([]byte(tc.a), p)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.b, string(doc))
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	testCases := []struct {
+		name     string
+		doc      string
+		patch    string
+		expected string
+		err      error
+	}{
+		{"insert item", `{"a":[1,3]}`, `[{"op":"add","path":"/a/1","value":2}]`, `{"a":[1,2,3]}`, nil},
+		{"append item", `{"a":[1]}`, `[{"op":"add","path":"/a/-","value":2}]`, `{"a":[1,2]}`, nil},
+		{"remove item", `{"a":[1,2,3]}`, `[{"op":"remove","path":"/a/0"}]`, `{"a":[2,3]}`, nil},
+		{"replace document", `{"a":1}`, `[{"op":"replace","path":"","value":{"b":2}}]`, `{"b":2}`, nil},
+		{"keeps numbers", `{"a":0.1}`, `[{"op":"add","path":"/b","value":12345678901234567890}]`, `{"a":0.1,"b":12345678901234567890}`, nil},
+		{"replace missing", `{}`, `[{"op":"replace","path":"/a","value":1}]`, "", jsonpatch.ErrPathNotFound},
+		{"remove missing", `{"a":[]}`, `[{"op":"remove","path":"/a/0"}]`, "", jsonpatch.ErrPathNotFound},
+		{"missing parent", `{}`, `[{"op":"add","path":"/a/b","value":1}]`, "", jsonpatch.ErrPathNotFound},
+		{"invalid index", `{"a":[1]}`, `[{"op":"replace","path":"/a/01","value":1}]`, "", jsonpatch.ErrInvalidPatch},
+		{"unsupported operation", `{}`, `[{"op":"move","from":"/a","path":"/b"}]`, "", jsonpatch.ErrInvalidPatch},
+		{"missing value", `{}`, `[{"op":"add","path":"/a"}]`, "", jsonpatch.ErrInvalidPatch},
+		{"relative path", `{}`, `[{"op":"add","path":"a","value":1}]`, "", jsonpatch.ErrInvalidPatch},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := jsonpatch.Patch{}
+			assert.NoError(t, json.Unmarshal([]byte(tc.patch), &p))
+
+			doc, err := jsonpatch.Apply([]byte(tc.doc), p)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(doc))
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+var resolverKey = parser.NewContextKey()
+
+// Resolver looks up the targets of @username mentions and @[Name]
+// character links. Anything it can't find is rendered as plain text.
+type Resolver interface {
+	User(username string) (uuid.UUID, bool)
+	Character(name string) (uuid.UUID, bool)
+}
+
+// Renderer turns CommonMark with tables, ~~strikethrough~~ and ||spoilers||
+// into HTML that is safe to show to other users.
+type Renderer struct {
+	md     goldmark.Markdown
+	policy *bluemonday.Policy
+}
+
+func NewRenderer() *Renderer {
+	policy := bluemonday.UGCPolicy()
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^(spoiler|mention|character)$`)).OnElements("span")
+	policy.AllowAttrs("data-user-id", "data-character-id").Matching(regexp.MustCompile(`^[0-9a-f-]{36}$`)).OnElements("span")
+	policy.RequireNoFollowOnLinks(true)
+	policy.AddTargetBlankToFullyQualifiedLinks(true)
+
+	return &Renderer{
+		md: goldmark.New(
+			goldmark.WithExtensions(extension.Table, extension.Strikethrough, spoilers, mentions),
+			goldmark.WithRendererOptions(html.WithHardWraps()),
+		),
+		policy: policy,
+	}
+}
+
+// Render converts the source to sanitized HTML. The resolver may be nil,
+// in which case mentions stay plain text.
+func (r *Renderer) Render(source string, resolver Resolver) (string, error) {
+	pc := parser.NewContext()
+	if resolver != nil {
+		pc.Set(resolverKey, resolver)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := r.md.Convert([]byte(source), buf, parser.WithContext(pc)); err != nil {
+		return "", err
+	}
+
+	return r.policy.Sanitize(buf.String()), nil
+}
@@ -0,0 +1,65 @@
+package markdown_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/markdown"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type testResolver struct {
+	users      map[string]uuid.UUID
+	characters map[string]uuid.UUID
+}
+
+func (r *testResolver) User(username string) (uuid.UUID, bool) {
+	id, ok := r.users[username]
+	return id, ok
+}
+
+func (r *testResolver) Character(name string) (uuid.UUID, bool) {
+	id, ok := r.characters[name]
+	return id, ok
+}
+
+func TestRenderer_Render(t *testing.T) {
+	alice := uuid.MustParse("6f1a2d3e-7b5c-4f00-9d4e-2a6b8c0d1e2f")
+	strahd := uuid.MustParse("0c9b8a7d-6e5f-4a3b-8c2d-1e0f9a8b7c6d")
+	resolver := &testResolver{
+		users:      map[string]uuid.UUID{"alice": alice},
+		characters: map[string]uuid.UUID{"Strahd von Zarovich": strahd},
+	}
+
+	testCases := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{"emphasis", "*hello* **there**", "<p><em>hello</em> <strong>there</strong></p>\n"},
+		{"hard wraps", "one\ntwo", "<p>one<br>\ntwo</p>\n"},
+		{"strikethrough", "~~gone~~", "<p><del>gone</del></p>\n"},
+		{"spoiler", "the butler ||did it||", `<p>the butler <span class="spoiler">did it</span></p>` + "\n"},
+		{"single pipe", "a | b", "<p>a | b</p>\n"},
+		{"table", "| a | b |\n| - | - |\n| 1 | 2 |", "<table>\n<thead>\n<tr>\n<th>a</th>\n<th>b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>1</td>\n<td>2</td>\n</tr>\n</tbody>\n</table>\n"},
+		{"mention", "hi @alice.", `<p>hi <span class="mention" data-user-id="` + alice.String() + `">@alice</span>.</p>` + "\n"},
+		{"unknown mention", "hi @bob", "<p>hi @bob</p>\n"},
+		{"email", "mail alice@example.com", "<p>mail alice@example.com</p>\n"},
+		{"character", "beware @[Strahd von Zarovich]", `<p>beware <span class="character" data-character-id="` + strahd.String() + `">Strahd von Zarovich</span></p>` + "\n"},
+		{"inline roll", "I hit for [[2d6+3]]", "<p>I hit for [[2d6+3]]</p>\n"},
+		{"script", "<script>alert(1)</script>", "\n"},
+		{"inline html", `<img src=x onerror="alert(1)">`, "\n"},
+		{"javascript link", "[click](javascript:alert(1))", "<p>click</p>\n"},
+		{"external link", "[srd](https://example.com)", `<p><a href="https://example.com" rel="nofollow noopener" target="_blank">srd</a></p>` + "\n"},
+		{"forged mention", `<span class="mention" data-user-id="x">@gm</span>`, "<p>@gm</p>\n"},
+	}
+
+	r := markdown.NewRenderer()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			html, err := r.Render(tc.source, resolver)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, html)
+		})
+	}
+}
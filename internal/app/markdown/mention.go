@@ -0,0 +1,126 @@
+package markdown
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var kindMention = ast.NewNodeKind("Mention")
+
+// mentionNode is either a user mention or a character link.
+type mentionNode struct {
+	ast.BaseInline
+	ID        uuid.UUID
+	Name      string
+	Character bool
+}
+
+func (n *mentionNode) Kind() ast.NodeKind {
+	return kindMention
+}
+
+func (n *mentionNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"ID": n.ID.String(), "Name": n.Name}, nil)
+}
+
+// mentionParser parses @username mentions and @[Character Name] links.
+type mentionParser struct{}
+
+func (p *mentionParser) Trigger() []byte {
+	return []byte{'@'}
+}
+
+func (p *mentionParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	resolver, ok := pc.Get(resolverKey).(Resolver)
+	if !ok {
+		return nil
+	}
+
+	before := block.PrecendingCharacter()
+	if unicode.IsLetter(before) || unicode.IsDigit(before) {
+		return nil
+	}
+
+	line, _ := block.PeekLine()
+	if len(line) > 1 && line[1] == '[' {
+		end := bytes.IndexByte(line, ']')
+		if end < 0 {
+			return nil
+		}
+
+		name := strings.TrimSpace(string(line[2:end]))
+		id, ok := resolver.Character(name)
+		if !ok {
+			return nil
+		}
+		block.Advance(end + 1)
+
+		return &mentionNode{ID: id, Name: name, Character: true}
+	}
+
+	end := 1
+	for end < len(line) && isUsernameByte(line[end]) {
+		end++
+	}
+	for end > 1 && line[end-1] == '.' {
+		end--
+	}
+	if end == 1 {
+		return nil
+	}
+
+	name := string(line[1:end])
+	id, ok := resolver.User(name)
+	if !ok {
+		return nil
+	}
+	block.Advance(end)
+
+	return &mentionNode{ID: id, Name: name}
+}
+
+func (p *mentionParser) CloseBlock(parent ast.Node, pc parser.Context) {}
+
+func isUsernameByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b == '_' || b == '-' || b == '.'
+}
+
+type mentionRenderer struct{}
+
+func (r *mentionRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindMention, func(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		n := node.(*mentionNode)
+		if n.Character {
+			w.WriteString(`<span class="character" data-character-id="` + n.ID.String() + `">`)
+			w.Write(util.EscapeHTML([]byte(n.Name)))
+		} else {
+			w.WriteString(`<span class="mention" data-user-id="` + n.ID.String() + `">@`)
+			w.Write(util.EscapeHTML([]byte(n.Name)))
+		}
+		w.WriteString("</span>")
+
+		return ast.WalkContinue, nil
+	})
+}
+
+type mentionExtension struct{}
+
+var mentions = &mentionExtension{}
+
+func (e *mentionExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithInlineParsers(util.Prioritized(&mentionParser{}, 500)))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(&mentionRenderer{}, 500)))
+}
@@ -0,0 +1,85 @@
+package markdown
+
+import (
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var kindSpoiler = ast.NewNodeKind("Spoiler")
+
+type spoilerNode struct {
+	ast.BaseInline
+}
+
+func (n *spoilerNode) Kind() ast.NodeKind {
+	return kindSpoiler
+}
+
+func (n *spoilerNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+type spoilerDelimiterProcessor struct{}
+
+func (p *spoilerDelimiterProcessor) IsDelimiter(b byte) bool {
+	return b == '|'
+}
+
+func (p *spoilerDelimiterProcessor) CanOpenCloser(opener, closer *parser.Delimiter) bool {
+	return opener.Char == closer.Char
+}
+
+func (p *spoilerDelimiterProcessor) OnMatch(consumes int) ast.Node {
+	return &spoilerNode{}
+}
+
+// spoilerParser parses ||hidden text||.
+type spoilerParser struct{}
+
+func (p *spoilerParser) Trigger() []byte {
+	return []byte{'|'}
+}
+
+func (p *spoilerParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	before := block.PrecendingCharacter()
+	line, segment := block.PeekLine()
+	node := parser.ScanDelimiter(line, before, 2, &spoilerDelimiterProcessor{})
+	if node == nil || node.OriginalLength != 2 {
+		return nil
+	}
+
+	node.Segment = segment.WithStop(segment.Start + node.OriginalLength)
+	block.Advance(node.OriginalLength)
+	pc.PushDelimiter(node)
+
+	return node
+}
+
+func (p *spoilerParser) CloseBlock(parent ast.Node, pc parser.Context) {}
+
+type spoilerRenderer struct{}
+
+func (r *spoilerRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindSpoiler, func(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			w.WriteString(`<span class="spoiler">`)
+		} else {
+			w.WriteString("</span>")
+		}
+
+		return ast.WalkContinue, nil
+	})
+}
+
+type spoilerExtension struct{}
+
+var spoilers = &spoilerExtension{}
+
+func (e *spoilerExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithInlineParsers(util.Prioritized(&spoilerParser{}, 500)))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(&spoilerRenderer{}, 500)))
+}
@@ -0,0 +1,205 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+)
+
+const (
+	ActionDo   = "do"
+	ActionUndo = "undo"
+	ActionRedo = "redo"
+)
+
+const (
+	TargetToken = "token"
+	TargetWall  = "wall"
+	TargetLight = "light"
+)
+
+// Action is an entry of a campaign's append-only table log: a change to one
+// token, wall or light, with its state before and after. Before is null for
+// creations and After for deletions, so every action can be inverted. Undos
+// and redos are actions themselves, pointing at the action they revert. Seq
+// orders the log.
+type Action struct {
+	ID         uuid.UUID       `json:"id"`
+	Seq        int64           `json:"seq"`
+	CampaignID uuid.UUID       `json:"campaign_id"`
+	SceneID    uuid.UUID       `json:"scene_id"`
+	UserID     uuid.UUID       `json:"user_id"`
+	Kind       string          `json:"kind"`
+	Target     string          `json:"target"`
+	TargetID   uuid.UUID       `json:"target_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	RevertsID  *uuid.UUID      `json:"reverts_id"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// ActionFilter narrows action log queries. Zero values mean "no
+// restriction".
+type ActionFilter struct {
+	SceneID *uuid.UUID
+	UserID  *uuid.UUID
+	From    time.Time
+	To      time.Time
+	Limit   int
+	Offset  int
+}
+
+// NewAction records the change of a target from before to after, either of
+// which may be nil.
+func NewAction(target string, targetID uuid.UUID, before, after interface{}) (*Action, error) {
+	a := &Action{
+		Kind:     ActionDo,
+		Target:   target,
+		TargetID: targetID,
+	}
+
+	var err error
+	if a.Before, err = marshalState(before); err != nil {
+		return nil, err
+	}
+	if a.After, err = marshalState(after); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (a *Action) Validate() error {
+	return validation.ValidateStruct(
+		a,
+		validation.Field(&a.Kind, validation.Required, validation.In(ActionDo, ActionUndo, ActionRedo)),
+		validation.Field(&a.Target, validation.Required, validation.In(TargetToken, TargetWall, TargetLight)),
+		validation.Field(&a.TargetID, validation.Required),
+		validation.Field(&a.After, validation.By(func(interface{}) error {
+			if a.Before == nil && a.After == nil {
+				return errors.New("cannot be blank along with before")
+			}
+
+			return nil
+		})),
+		validation.Field(&a.RevertsID, validation.By(requiredIf(a.Kind != ActionDo))),
+	)
+}
+
+// Revert returns the action undoing or redoing a, as kind says: the same
+// change the other way round.
+func (a *Action) Revert(userID uuid.UUID, kind string) *Action {
+	return &Action{
+		CampaignID: a.CampaignID,
+		SceneID:    a.SceneID,
+		UserID:     userID,
+		Kind:       kind,
+		Target:     a.Target,
+		TargetID:   a.TargetID,
+		Before:     a.After,
+		After:      a.Before,
+		RevertsID:  &a.ID,
+	}
+}
+
+// IsCurrent reports whether state, the target's snapshot or nil if it is
+// gone, is still the one the action left. Timestamps are ignored, and so is
+// the movement a token used, which combat resets every turn.
+func (a *Action) IsCurrent(state json.RawMessage) bool {
+	if a.After == nil || state == nil {
+		return a.After == nil && state == nil
+	}
+
+	var after, current map[string]interface{}
+	if err := json.Unmarshal(a.After, &after); err != nil {
+		return false
+	}
+	if err := json.Unmarshal(state, &current); err != nil {
+		return false
+	}
+	for _, m := range []map[string]interface{}{after, current} {
+		delete(m, "created_at")
+		delete(m, "updated_at")
+		delete(m, "moved")
+	}
+
+	return reflect.DeepEqual(after, current)
+}
+
+func (f *ActionFilter) Validate() error {
+	return validation.ValidateStruct(
+		f,
+		validation.Field(&f.Limit, validation.Min(0), validation.Max(100)),
+		validation.Field(&f.Offset, validation.Min(0)),
+	)
+}
+
+// Match reports whether the action satisfies every restriction of the
+// filter except pagination.
+func (f *ActionFilter) Match(a *Action) bool {
+	if f.SceneID != nil && a.SceneID != *f.SceneID {
+		return false
+	}
+
+	if f.UserID != nil && a.UserID != *f.UserID {
+		return false
+	}
+
+	if !f.From.IsZero() && a.CreatedAt.Before(f.From) {
+		return false
+	}
+
+	if !f.To.IsZero() && !a.CreatedAt.Before(f.To) {
+		return false
+	}
+
+	return true
+}
+
+// History replays a user's actions, oldest first, and returns what they
+// may undo and redo, the next one last. A new action clears the redo
+// stack.
+func History(actions []*Action) (undo, redo []*Action) {
+	undo, redo = []*Action{}, []*Action{}
+	for _, a := range actions {
+		switch a.Kind {
+		case ActionDo:
+			undo = append(undo, a)
+			redo = redo[:0]
+		case ActionUndo:
+			var ok bool
+			if undo, ok = without(undo, *a.RevertsID); ok {
+				redo = append(redo, a)
+			}
+		case ActionRedo:
+			var ok bool
+			if redo, ok = without(redo, *a.RevertsID); ok {
+				undo = append(undo, a)
+			}
+		}
+	}
+
+	return undo, redo
+}
+
+func without(actions []*Action, id uuid.UUID) ([]*Action, bool) {
+	for i := len(actions) - 1; i >= 0; i-- {
+		if actions[i].ID == id {
+			return append(actions[:i], actions[i+1:]...), true
+		}
+	}
+
+	return actions, false
+}
+
+func marshalState(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(v)
+}
@@ -0,0 +1,121 @@
+package model_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAction_Validate(t *testing.T) {
+	id := uuid.New()
+	testCases := []struct {
+		name    string
+		a       *model.Action
+		isValid bool
+	}{
+		{"create", &model.Action{Kind: model.ActionDo, Target: model.TargetToken, TargetID: id, After: json.RawMessage(`{}`)}, true},
+		{"delete", &model.Action{Kind: model.ActionDo, Target: model.TargetWall, TargetID: id, Before: json.RawMessage(`{}`)}, true},
+		{"undo", &model.Action{Kind: model.ActionUndo, Target: model.TargetLight, TargetID: id, Before: json.RawMessage(`{}`), RevertsID: &id}, true},
+		{"undo of nothing", &model.Action{Kind: model.ActionUndo, Target: model.TargetLight, TargetID: id, Before: json.RawMessage(`{}`)}, false},
+		{"no change", &model.Action{Kind: model.ActionDo, Target: model.TargetToken, TargetID: id}, false},
+		{"unknown target", &model.Action{Kind: model.ActionDo, Target: "scene", TargetID: id, After: json.RawMessage(`{}`)}, false},
+		{"unknown kind", &model.Action{Kind: "rewind", Target: model.TargetToken, TargetID: id, After: json.RawMessage(`{}`)}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.a.Validate())
+			} else {
+				assert.Error(t, tc.a.Validate())
+			}
+		})
+	}
+}
+
+func TestNewAction(t *testing.T) {
+	tok := &model.Token{ID: uuid.New(), Name: "Ireena", X: 105}
+
+	a, err := model.NewAction(model.TargetToken, tok.ID, nil, tok)
+	assert.NoError(t, err)
+	assert.Nil(t, a.Before)
+	assert.NoError(t, a.Validate())
+
+	data, _ := json.Marshal(a)
+	assert.Contains(t, string(data), `"before":null`)
+
+	r := a.Revert(tok.ID, model.ActionUndo)
+	assert.Equal(t, a.After, r.Before)
+	assert.Nil(t, r.After)
+	assert.Equal(t, a.ID, *r.RevertsID)
+}
+
+func TestAction_IsCurrent(t *testing.T) {
+	tok := &model.Token{ID: uuid.New(), Name: "Ireena", X: 105, UpdatedAt: time.Now()}
+	a, _ := model.NewAction(model.TargetToken, tok.ID, nil, tok)
+
+	moved := *tok
+	moved.UpdatedAt = tok.UpdatedAt.Add(time.Second)
+	state, _ := json.Marshal(moved)
+	assert.True(t, a.IsCurrent(state))
+
+	moved.Moved = 35
+	state, _ = json.Marshal(moved)
+	assert.True(t, a.IsCurrent(state))
+
+	moved.X = 175
+	state, _ = json.Marshal(moved)
+	assert.False(t, a.IsCurrent(state))
+	assert.False(t, a.IsCurrent(nil))
+
+	deleted, _ := model.NewAction(model.TargetToken, tok.ID, tok, nil)
+	assert.True(t, deleted.IsCurrent(nil))
+}
+
+func TestActionFilter_Match(t *testing.T) {
+	sceneID, userID := uuid.New(), uuid.New()
+	now := time.Now()
+	a := &model.Action{SceneID: sceneID, UserID: userID, CreatedAt: now}
+
+	assert.True(t, (&model.ActionFilter{}).Match(a))
+	assert.True(t, (&model.ActionFilter{SceneID: &sceneID, UserID: &userID}).Match(a))
+	assert.False(t, (&model.ActionFilter{SceneID: &userID}).Match(a))
+	assert.True(t, (&model.ActionFilter{From: now}).Match(a))
+	assert.False(t, (&model.ActionFilter{To: now}).Match(a))
+}
+
+func TestHistory(t *testing.T) {
+	do := func() *model.Action {
+		return &model.Action{ID: uuid.New(), Kind: model.ActionDo}
+	}
+	revert := func(a *model.Action, kind string) *model.Action {
+		r := a.Revert(uuid.Nil, kind)
+		r.ID = uuid.New()
+
+		return r
+	}
+
+	first, second := do(), do()
+	undo, redo := model.History([]*model.Action{first, second})
+	assert.Equal(t, []*model.Action{first, second}, undo)
+	assert.Empty(t, redo)
+
+	undone := revert(second, model.ActionUndo)
+	undo, redo = model.History([]*model.Action{first, second, undone})
+	assert.Equal(t, []*model.Action{first}, undo)
+	assert.Equal(t, []*model.Action{undone}, redo)
+
+	redone := revert(undone, model.ActionRedo)
+	undo, redo = model.History([]*model.Action{first, second, undone, redone})
+	assert.Equal(t, []*model.Action{first, redone}, undo)
+	assert.Empty(t, redo)
+
+	third := do()
+	undo, redo = model.History([]*model.Action{first, second, undone, third})
+	assert.Equal(t, []*model.Action{first, third}, undo)
+	assert.Empty(t, redo)
+}
@@ -0,0 +1,173 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+)
+
+// MaxTags is the number of tags an asset can have.
+const MaxTags = 20
+
+// Asset is a file in a user's library: a map, token art, a handout or a
+// track. Its content is stored once per Hash however many assets share it.
+// Private assets are only served through signed URLs. CampaignID files the
+// asset under a table for usage accounting.
+type Asset struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	FolderID   *uuid.UUID `json:"folder_id"`
+	CampaignID *uuid.UUID `json:"campaign_id"`
+	Name       string     `json:"name"`
+	Hash       string     `json:"hash"`
+	MIME       string     `json:"mime"`
+	Size       int64      `json:"size"`
+	Width      int        `json:"width,omitempty"`
+	Height     int        `json:"height,omitempty"`
+	Thumbnail  string     `json:"-"`
+	Tags       []string   `json:"tags"`
+	Private    bool       `json:"private"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Folder groups assets of a user's library. Folders nest.
+type Folder struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	ParentID  *uuid.UUID `json:"parent_id"`
+	Name      string     `json:"name"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Upload is a resumable upload in progress: Offset of Size bytes have been
+// received. The asset is created once all of them are.
+type Upload struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	FolderID   *uuid.UUID `json:"folder_id"`
+	CampaignID *uuid.UUID `json:"campaign_id"`
+	Name       string     `json:"name"`
+	Size       int64      `json:"size"`
+	Offset     int64      `json:"offset"`
+	Tags       []string   `json:"tags"`
+	Private    bool       `json:"private"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// AssetFilter narrows asset listings. Zero values mean "no restriction".
+type AssetFilter struct {
+	FolderID   *uuid.UUID
+	CampaignID *uuid.UUID
+	Tag        string
+	Hash       string
+}
+
+// allowedMIMETypes are the sniffed types accepted for assets, by prefix.
+var allowedMIMETypes = []string{
+	"image/",
+	"audio/",
+	"video/",
+	"application/ogg",
+	"application/pdf",
+	"text/plain",
+}
+
+func (a *Asset) Validate() error {
+	return validation.ValidateStruct(
+		a,
+		validation.Field(&a.Name, validation.Required, validation.Length(1, 255)),
+		validation.Field(&a.Hash, validation.Required, validation.Length(64, 64)),
+		validation.Field(&a.MIME, validation.Required, validation.By(func(interface{}) error {
+			if !AllowedMIME(a.MIME) {
+				return errors.New("is not an allowed type")
+			}
+
+			return nil
+		})),
+		validation.Field(&a.Size, validation.Required, validation.Min(int64(1))),
+		validation.Field(&a.Tags, validation.Length(0, MaxTags), validation.Each(validation.Length(1, 30))),
+	)
+}
+
+func (a *Asset) IsImage() bool {
+	return strings.HasPrefix(a.MIME, "image/")
+}
+
+func (f *Folder) Validate() error {
+	return validation.ValidateStruct(
+		f,
+		validation.Field(&f.Name, validation.Required, validation.Length(1, 100)),
+	)
+}
+
+func (u *Upload) Validate() error {
+	return validation.ValidateStruct(
+		u,
+		validation.Field(&u.Name, validation.Required, validation.Length(1, 255)),
+		validation.Field(&u.Size, validation.Required, validation.Min(int64(1))),
+		validation.Field(&u.Offset, validation.Min(int64(0)), validation.Max(u.Size)),
+		validation.Field(&u.Tags, validation.Length(0, MaxTags), validation.Each(validation.Length(1, 30))),
+	)
+}
+
+func (u *Upload) Complete() bool {
+	return u.Offset == u.Size
+}
+
+func (f *AssetFilter) Match(a *Asset) bool {
+	if f.FolderID != nil && (a.FolderID == nil || *a.FolderID != *f.FolderID) {
+		return false
+	}
+
+	if f.CampaignID != nil && (a.CampaignID == nil || *a.CampaignID != *f.CampaignID) {
+		return false
+	}
+
+	if f.Hash != "" && a.Hash != f.Hash {
+		return false
+	}
+
+	if f.Tag != "" {
+		for _, t := range a.Tags {
+			if t == f.Tag {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// AllowedMIME reports whether assets of the sniffed MIME type are accepted.
+func AllowedMIME(mime string) bool {
+	for _, prefix := range allowedMIMETypes {
+		if strings.HasPrefix(mime, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NormalizeTags lowercases and trims tags and drops empty and repeated ones.
+func NormalizeTags(tags []string) []string {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t != "" && !seen[t] {
+			seen[t] = true
+			normalized = append(normalized, t)
+		}
+	}
+
+	return normalized
+}
@@ -0,0 +1,94 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsset_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		a       func() *model.Asset
+		isValid bool
+	}{
+		{
+			name: "valid",
+			a: func() *model.Asset {
+				return model.TestAsset(t, &model.User{})
+			},
+			isValid: true,
+		},
+		{
+			name: "short hash",
+			a: func() *model.Asset {
+				a := model.TestAsset(t, &model.User{})
+				a.Hash = "9f86d081"
+
+				return a
+			},
+			isValid: false,
+		},
+		{
+			name: "long tag",
+			a: func() *model.Asset {
+				a := model.TestAsset(t, &model.User{})
+				a.Tags = []string{strings.Repeat("a", 31)}
+
+				return a
+			},
+			isValid: false,
+		},
+		{
+			name: "html",
+			a: func() *model.Asset {
+				a := model.TestAsset(t, &model.User{})
+				a.MIME = "text/html; charset=utf-8"
+
+				return a
+			},
+			isValid: false,
+		},
+		{
+			name: "empty",
+			a: func() *model.Asset {
+				a := model.TestAsset(t, &model.User{})
+				a.Size = 0
+
+				return a
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.a().Validate())
+			} else {
+				assert.Error(t, tc.a().Validate())
+			}
+		})
+	}
+}
+
+func TestUpload_Validate(t *testing.T) {
+	u := model.TestUpload(t, &model.User{})
+	assert.NoError(t, u.Validate())
+
+	u.Offset = u.Size + 1
+	assert.Error(t, u.Validate())
+}
+
+func TestAllowedMIME(t *testing.T) {
+	assert.True(t, model.AllowedMIME("image/png"))
+	assert.True(t, model.AllowedMIME("text/plain; charset=utf-8"))
+	assert.False(t, model.AllowedMIME("text/html; charset=utf-8"))
+	assert.False(t, model.AllowedMIME("application/octet-stream"))
+}
+
+func TestNormalizeTags(t *testing.T) {
+	assert.Equal(t, []string{"map", "dungeon"}, model.NormalizeTags([]string{" Map", "", "dungeon", "MAP"}))
+}
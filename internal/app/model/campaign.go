@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/ruleset"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+)
+
+// Campaign is a game table. Its System names the ruleset it plays by.
+type Campaign struct {
+	ID              uuid.UUID `json:"id"`
+	Name            string    `json:"name"`
+	Description     string    `json:"description"`
+	DescriptionHTML string    `json:"description_html"`
+	System          string    `json:"system"`
+	OwnerID         uuid.UUID `json:"owner_id"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func (c *Campaign) Validate() error {
+	return validation.ValidateStruct(
+		c,
+		validation.Field(&c.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&c.Description, validation.Length(0, 5000)),
+		validation.Field(&c.System, validation.Required, validation.In(systems()...)),
+	)
+}
+
+// Ruleset returns the rules of the campaign's system.
+func (c *Campaign) Ruleset() (ruleset.Ruleset, error) {
+	return ruleset.Get(c.System)
+}
+
+func systems() []interface{} {
+	systems := []interface{}{}
+	for _, s := range ruleset.Names() {
+		systems = append(systems, s)
+	}
+
+	return systems
+}
@@ -0,0 +1,90 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCampaign_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		c       func() *model.Campaign
+		isValid bool
+	}{
+		{
+			name: "valid",
+			c: func() *model.Campaign {
+				return model.TestCampaign(t, model.TestUser(t))
+			},
+			isValid: true,
+		},
+		{
+			name: "empty name",
+			c: func() *model.Campaign {
+				c := model.TestCampaign(t, model.TestUser(t))
+				c.Name = ""
+
+				return c
+			},
+			isValid: false,
+		},
+		{
+			name: "long name",
+			c: func() *model.Campaign {
+				c := model.TestCampaign(t, model.TestUser(t))
+				c.Name = strings.Repeat("a", 101)
+
+				return c
+			},
+			isValid: false,
+		},
+		{
+			name: "unknown system",
+			c: func() *model.Campaign {
+				c := model.TestCampaign(t, model.TestUser(t))
+				c.System = "gurps"
+
+				return c
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.c().Validate())
+			} else {
+				assert.Error(t, tc.c().Validate())
+			}
+		})
+	}
+}
+
+func TestMember_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		role    string
+		isValid bool
+	}{
+		{"gm", model.RoleGM, true},
+		{"player", model.RolePlayer, true},
+		{"spectator", model.RoleSpectator, true},
+		{"empty", "", false},
+		{"unknown", "overlord", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &model.Member{Role: tc.role}
+			if tc.isValid {
+				assert.NoError(t, m.Validate())
+			} else {
+				assert.Error(t, m.Validate())
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/dice"
+	"github.com/bruhlord-s/virttable-api/internal/app/ruleset"
+	"github.com/bruhlord-s/virttable-api/internal/app/sheet"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+	"github.com/google/uuid"
+)
+
+// Character is a character sheet. Data is free-form JSON validated against
+// the schema of the campaign's ruleset; Derived holds the fields the ruleset
+// computes from it. Version grows with every update.
+type Character struct {
+	ID         uuid.UUID          `json:"id"`
+	CampaignID uuid.UUID          `json:"campaign_id"`
+	OwnerID    uuid.UUID          `json:"owner_id"`
+	Name       string             `json:"name"`
+	Portrait   string             `json:"portrait"`
+	System     string             `json:"system"`
+	Data       json.RawMessage    `json:"data"`
+	Derived    map[string]float64 `json:"derived"`
+	Version    int                `json:"version"`
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+}
+
+func (c *Character) Validate() error {
+	return validation.ValidateStruct(
+		c,
+		validation.Field(&c.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&c.Portrait, is.URL, validation.Length(0, 2048)),
+		validation.Field(&c.System, validation.Required),
+		validation.Field(&c.Data, validation.Required, validation.By(func(interface{}) error {
+			rs, err := ruleset.Get(c.System)
+			if err != nil {
+				return err
+			}
+
+			return rs.ValidateSheet(c.Data)
+		})),
+	)
+}
+
+// CanEdit reports whether the member may change or delete the character:
+// players edit their own characters, GMs edit all of them.
+func (c *Character) CanEdit(member *Member) bool {
+	return member.IsGM() || member.CanPlay() && c.OwnerID == member.UserID
+}
+
+// characterSnapshot is the part of a character that revisions track.
+type characterSnapshot struct {
+	Name     string          `json:"name"`
+	Portrait string          `json:"portrait"`
+	OwnerID  uuid.UUID       `json:"owner_id"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// Snapshot returns the editable fields of the character as a JSON document.
+func (c *Character) Snapshot() ([]byte, error) {
+	return json.Marshal(&characterSnapshot{
+		Name:     c.Name,
+		Portrait: c.Portrait,
+		OwnerID:  c.OwnerID,
+		Data:     c.Data,
+	})
+}
+
+// Restore sets the editable fields of the character from a snapshot.
+func (c *Character) Restore(snapshot []byte) error {
+	s := &characterSnapshot{}
+	if err := json.Unmarshal(snapshot, s); err != nil {
+		return err
+	}
+
+	c.Name = s.Name
+	c.Portrait = s.Portrait
+	c.OwnerID = s.OwnerID
+	c.Data = s.Data
+
+	return nil
+}
+
+func (c *Character) Derive() error {
+	rs, err := ruleset.Get(c.System)
+	if err != nil {
+		return err
+	}
+
+	derived, err := rs.Derive(c.Data)
+	if err != nil {
+		return err
+	}
+	c.Derived = derived
+
+	return nil
+}
+
+// Lookup resolves dice references to derived fields, falling back to
+// numeric sheet data, e.g. "1d20+@str_mod" or "1d6+@abilities.str". Derive
+// has to be called first for derived fields to resolve.
+func (c *Character) Lookup() dice.Lookup {
+	var data interface{}
+	d := json.NewDecoder(bytes.NewReader(c.Data))
+	d.UseNumber()
+	d.Decode(&data)
+
+	return func(name string) (int, bool) {
+		if v, ok := c.Derived[name]; ok {
+			return int(math.Floor(v)), true
+		}
+
+		if v, ok := sheet.Field(data, name); ok {
+			if f, ok := v.(float64); ok {
+				return int(math.Floor(f)), true
+			}
+		}
+
+		return 0, false
+	}
+}
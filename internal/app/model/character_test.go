@@ -0,0 +1,124 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCharacter_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		c       func() *model.Character
+		isValid bool
+	}{
+		{
+			name: "valid",
+			c: func() *model.Character {
+				return model.TestCharacter(t, model.TestCampaign(t, model.TestUser(t)), model.TestUser(t))
+			},
+			isValid: true,
+		},
+		{
+			name: "empty name",
+			c: func() *model.Character {
+				c := model.TestCharacter(t, model.TestCampaign(t, model.TestUser(t)), model.TestUser(t))
+				c.Name = ""
+
+				return c
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid portrait",
+			c: func() *model.Character {
+				c := model.TestCharacter(t, model.TestCampaign(t, model.TestUser(t)), model.TestUser(t))
+				c.Portrait = "not a url"
+
+				return c
+			},
+			isValid: false,
+		},
+		{
+			name: "no data",
+			c: func() *model.Character {
+				c := model.TestCharacter(t, model.TestCampaign(t, model.TestUser(t)), model.TestUser(t))
+				c.Data = nil
+
+				return c
+			},
+			isValid: false,
+		},
+		{
+			name: "data not matching the system",
+			c: func() *model.Character {
+				c := model.TestCharacter(t, model.TestCampaign(t, model.TestUser(t)), model.TestUser(t))
+				c.Data = []byte(`{"level": "three"}`)
+
+				return c
+			},
+			isValid: false,
+		},
+		{
+			name: "unknown system",
+			c: func() *model.Character {
+				c := model.TestCharacter(t, model.TestCampaign(t, model.TestUser(t)), model.TestUser(t))
+				c.System = "gurps"
+
+				return c
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.c().Validate())
+			} else {
+				assert.Error(t, tc.c().Validate())
+			}
+		})
+	}
+}
+
+func TestCharacter_CanEdit(t *testing.T) {
+	owner := uuid.New()
+	c := &model.Character{OwnerID: owner}
+
+	testCases := []struct {
+		name     string
+		member   *model.Member
+		expected bool
+	}{
+		{"owner", &model.Member{UserID: owner, Role: model.RolePlayer}, true},
+		{"other player", &model.Member{UserID: uuid.New(), Role: model.RolePlayer}, false},
+		{"gm", &model.Member{UserID: uuid.New(), Role: model.RoleGM}, true},
+		{"owner turned spectator", &model.Member{UserID: owner, Role: model.RoleSpectator}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, c.CanEdit(tc.member))
+		})
+	}
+}
+
+func TestCharacter_Lookup(t *testing.T) {
+	c := model.TestCharacter(t, model.TestCampaign(t, model.TestUser(t)), model.TestUser(t))
+	assert.NoError(t, c.Derive())
+
+	lookup := c.Lookup()
+	v, ok := lookup("str_mod")
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+
+	v, ok = lookup("abilities.str")
+	assert.True(t, ok)
+	assert.Equal(t, 14, v)
+
+	_, ok = lookup("class")
+	assert.False(t, ok)
+}
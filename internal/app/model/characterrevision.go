@@ -0,0 +1,83 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/jsonpatch"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownRevision = errors.New("unknown revision")
+)
+
+// CharacterRevision records one version of a character as the patch from
+// the snapshot of the previous version. The first revision patches an empty
+// document.
+type CharacterRevision struct {
+	ID          uuid.UUID       `json:"id"`
+	CharacterID uuid.UUID       `json:"character_id"`
+	Version     int             `json:"version"`
+	AuthorID    *uuid.UUID      `json:"author_id"`
+	Patch       jsonpatch.Patch `json:"patch"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// NewCharacterRevision records the change from the before snapshot to the
+// character's current state. Before is nil for new characters.
+func NewCharacterRevision(c *Character, before []byte, authorID uuid.UUID) (*CharacterRevision, error) {
+	if before == nil {
+		before = []byte("{}")
+	}
+
+	after, err := c.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	patch, err := jsonpatch.Diff(before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CharacterRevision{
+		CharacterID: c.ID,
+		Version:     c.Version,
+		AuthorID:    &authorID,
+		Patch:       patch,
+	}, nil
+}
+
+func (r *CharacterRevision) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.CharacterID, validation.Required),
+		validation.Field(&r.Version, validation.Required, validation.Min(1)),
+		validation.Field(&r.Patch, validation.NotNil),
+	)
+}
+
+// SnapshotAt replays revisions, ordered by version, up to the given version
+// and returns the character snapshot at that point.
+func SnapshotAt(revisions []*CharacterRevision, version int) ([]byte, error) {
+	snapshot := []byte("{}")
+	for i, r := range revisions {
+		if r.Version != i+1 || r.Version > version {
+			break
+		}
+
+		s, err := jsonpatch.Apply(snapshot, r.Patch)
+		if err != nil {
+			return nil, err
+		}
+		snapshot = s
+
+		if r.Version == version {
+			return snapshot, nil
+		}
+	}
+
+	return nil, ErrUnknownRevision
+}
@@ -0,0 +1,55 @@
+package model_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotAt(t *testing.T) {
+	u := model.TestUser(t)
+	u.ID = uuid.New()
+	c := model.TestCharacter(t, model.TestCampaign(t, u), u)
+	c.ID = uuid.New()
+	c.Version = 1
+
+	first, err := model.NewCharacterRevision(c, nil, u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, first.Version)
+	assert.NoError(t, first.Validate())
+
+	before, err := c.Snapshot()
+	assert.NoError(t, err)
+	c.Name = "Ireena"
+	c.Data = json.RawMessage(`{"class": "fighter", "level": 4, "abilities": {"str": 14}}`)
+	c.Version = 2
+
+	second, err := model.NewCharacterRevision(c, before, u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, second.Patch, 2)
+
+	revisions := []*model.CharacterRevision{first, second}
+
+	snapshot, err := model.SnapshotAt(revisions, 1)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(before), string(snapshot))
+
+	restored := &model.Character{}
+	assert.NoError(t, restored.Restore(snapshot))
+	assert.Equal(t, "Ireena Kolyana", restored.Name)
+	assert.Equal(t, u.ID, restored.OwnerID)
+
+	snapshot, err = model.SnapshotAt(revisions, 2)
+	assert.NoError(t, err)
+	current, _ := c.Snapshot()
+	assert.JSONEq(t, string(current), string(snapshot))
+
+	_, err = model.SnapshotAt(revisions, 3)
+	assert.ErrorIs(t, err, model.ErrUnknownRevision)
+
+	_, err = model.SnapshotAt(revisions[1:], 2)
+	assert.ErrorIs(t, err, model.ErrUnknownRevision)
+}
@@ -0,0 +1,166 @@
+package model
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/dice"
+	"github.com/bruhlord-s/virttable-api/internal/app/ruleset"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+)
+
+const (
+	ChatOOC     = "ooc"
+	ChatIC      = "ic"
+	ChatEmote   = "emote"
+	ChatWhisper = "whisper"
+	ChatGM      = "gm"
+	ChatSystem  = "system"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+type ChatMessage struct {
+	ID          uuid.UUID   `json:"id"`
+	CampaignID  uuid.UUID   `json:"campaign_id"`
+	UserID      uuid.UUID   `json:"user_id"`
+	Kind        string      `json:"kind"`
+	CharacterID *uuid.UUID  `json:"character_id"`
+	Speaker     string      `json:"speaker"`
+	Recipients  []uuid.UUID `json:"recipients"`
+	Body        string      `json:"body"`
+	BodyHTML    string      `json:"body_html"`
+	Rolls       []ChatRoll  `json:"rolls"`
+	CreatedAt   time.Time   `json:"created_at"`
+	EditedAt    *time.Time  `json:"edited_at"`
+}
+
+// ChatRoll is a roll made by a chat command. Inline rolls are written as
+// [[expression]] in the body, in which case Source holds the whole block
+// for clients to replace with the result. Outcome is what the campaign's
+// ruleset read into it, if anything.
+type ChatRoll struct {
+	dice.Result
+	Source  string           `json:"source"`
+	Inline  bool             `json:"inline"`
+	Outcome *ruleset.Outcome `json:"outcome,omitempty"`
+}
+
+// ChatCursor points at a message in the chat history. Pages are fetched
+// backwards in time, starting right before the cursor.
+type ChatCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+type ChatFilter struct {
+	Before *ChatCursor
+	Limit  int
+}
+
+func (m *ChatMessage) Validate() error {
+	return validation.ValidateStruct(
+		m,
+		validation.Field(&m.Kind, validation.Required, validation.In(ChatOOC, ChatIC, ChatEmote, ChatWhisper, ChatGM, ChatSystem)),
+		validation.Field(&m.Body, validation.By(requiredIf(len(m.Rolls) == 0)), validation.Length(0, 4000)),
+		validation.Field(&m.Speaker, validation.By(requiredIf(m.Kind == ChatIC)), validation.Length(0, 100)),
+		validation.Field(&m.Recipients, validation.By(requiredIf(m.Kind == ChatWhisper))),
+	)
+}
+
+// VisibleTo reports whether the member may read the message: whispers are
+// only for their author and recipients, GM messages for their author and
+// the GMs.
+func (m *ChatMessage) VisibleTo(member *Member) bool {
+	if m.UserID == member.UserID {
+		return true
+	}
+
+	switch m.Kind {
+	case ChatWhisper:
+		for _, id := range m.Recipients {
+			if id == member.UserID {
+				return true
+			}
+		}
+
+		return false
+	case ChatGM:
+		return member.IsGM()
+	}
+
+	return true
+}
+
+// CanPost reports whether the member may post messages of this kind.
+// Spectators can only talk out of character and whisper, and only GMs post
+// system messages.
+func (m *ChatMessage) CanPost(member *Member) bool {
+	switch m.Kind {
+	case ChatSystem:
+		return member.IsGM()
+	case ChatOOC, ChatWhisper:
+		return true
+	}
+
+	return member.CanPlay()
+}
+
+// CanModify reports whether the member may edit or delete the message.
+func (m *ChatMessage) CanModify(member *Member) bool {
+	return m.UserID == member.UserID || member.IsGM()
+}
+
+func (m *ChatMessage) Cursor() *ChatCursor {
+	return &ChatCursor{
+		CreatedAt: m.CreatedAt,
+		ID:        m.ID,
+	}
+}
+
+// OlderThan reports whether the message comes after the cursor in the
+// history order (newest first), i.e. belongs to the page it starts.
+func (m *ChatMessage) OlderThan(c *ChatCursor) bool {
+	if !m.CreatedAt.Equal(c.CreatedAt) {
+		return m.CreatedAt.Before(c.CreatedAt)
+	}
+
+	return bytes.Compare(m.ID[:], c.ID[:]) < 0
+}
+
+func (c *ChatCursor) Encode() string {
+	s := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + c.ID.String()
+
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+func DecodeChatCursor(s string) (*ChatCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	ts, id, ok := strings.Cut(string(b), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	nano, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	c := &ChatCursor{CreatedAt: time.Unix(0, nano).UTC()}
+	if c.ID, err = uuid.Parse(id); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return c, nil
+}
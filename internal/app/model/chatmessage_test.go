@@ -0,0 +1,148 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChatMessage_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		m       func() *model.ChatMessage
+		isValid bool
+	}{
+		{
+			name: "valid",
+			m: func() *model.ChatMessage {
+				return model.TestChatMessage(t, &model.Campaign{}, &model.User{})
+			},
+			isValid: true,
+		},
+		{
+			name: "unknown kind",
+			m: func() *model.ChatMessage {
+				m := model.TestChatMessage(t, &model.Campaign{}, &model.User{})
+				m.Kind = "shout"
+
+				return m
+			},
+			isValid: false,
+		},
+		{
+			name: "empty body",
+			m: func() *model.ChatMessage {
+				m := model.TestChatMessage(t, &model.Campaign{}, &model.User{})
+				m.Body = ""
+
+				return m
+			},
+			isValid: false,
+		},
+		{
+			name: "roll without label",
+			m: func() *model.ChatMessage {
+				m := model.TestChatMessage(t, &model.Campaign{}, &model.User{})
+				m.Body = ""
+				m.Rolls = []model.ChatRoll{{Source: "1d20"}}
+
+				return m
+			},
+			isValid: true,
+		},
+		{
+			name: "in character without speaker",
+			m: func() *model.ChatMessage {
+				m := model.TestChatMessage(t, &model.Campaign{}, &model.User{})
+				m.Kind = model.ChatIC
+
+				return m
+			},
+			isValid: false,
+		},
+		{
+			name: "in character",
+			m: func() *model.ChatMessage {
+				m := model.TestChatMessage(t, &model.Campaign{}, &model.User{})
+				m.Kind = model.ChatIC
+				m.Speaker = "Ireena"
+
+				return m
+			},
+			isValid: true,
+		},
+		{
+			name: "whisper without recipients",
+			m: func() *model.ChatMessage {
+				m := model.TestChatMessage(t, &model.Campaign{}, &model.User{})
+				m.Kind = model.ChatWhisper
+
+				return m
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.m().Validate())
+			} else {
+				assert.Error(t, tc.m().Validate())
+			}
+		})
+	}
+}
+
+func TestChatMessage_VisibleTo(t *testing.T) {
+	author := &model.Member{UserID: uuid.New(), Role: model.RolePlayer}
+	recipient := &model.Member{UserID: uuid.New(), Role: model.RolePlayer}
+	gm := &model.Member{UserID: uuid.New(), Role: model.RoleGM}
+	other := &model.Member{UserID: uuid.New(), Role: model.RolePlayer}
+
+	testCases := []struct {
+		kind    string
+		visible map[*model.Member]bool
+	}{
+		{model.ChatOOC, map[*model.Member]bool{author: true, recipient: true, gm: true, other: true}},
+		{model.ChatWhisper, map[*model.Member]bool{author: true, recipient: true, gm: false, other: false}},
+		{model.ChatGM, map[*model.Member]bool{author: true, recipient: false, gm: true, other: false}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.kind, func(t *testing.T) {
+			m := &model.ChatMessage{UserID: author.UserID, Kind: tc.kind, Recipients: []uuid.UUID{recipient.UserID}}
+			for member, visible := range tc.visible {
+				assert.Equal(t, visible, m.VisibleTo(member))
+			}
+		})
+	}
+}
+
+func TestChatMessage_CanPost(t *testing.T) {
+	gm := &model.Member{Role: model.RoleGM}
+	player := &model.Member{Role: model.RolePlayer}
+	spectator := &model.Member{Role: model.RoleSpectator}
+
+	assert.True(t, (&model.ChatMessage{Kind: model.ChatSystem}).CanPost(gm))
+	assert.False(t, (&model.ChatMessage{Kind: model.ChatSystem}).CanPost(player))
+	assert.True(t, (&model.ChatMessage{Kind: model.ChatIC}).CanPost(player))
+	assert.False(t, (&model.ChatMessage{Kind: model.ChatIC}).CanPost(spectator))
+	assert.True(t, (&model.ChatMessage{Kind: model.ChatOOC}).CanPost(spectator))
+}
+
+func TestChatCursor(t *testing.T) {
+	c := &model.ChatCursor{CreatedAt: time.Now().UTC(), ID: uuid.New()}
+	decoded, err := model.DecodeChatCursor(c.Encode())
+	assert.NoError(t, err)
+	assert.True(t, c.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, c.ID, decoded.ID)
+
+	for _, invalid := range []string{"", "!!!", "bm9jb2xvbg", "MTIzOm5vdGF1dWlk"} {
+		_, err := model.DecodeChatCursor(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -1,10 +1,17 @@
 package model
 
 import (
+	"time"
+
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
 )
 
+const (
+	TicketAudience = "ticket"
+	TicketTTL = 30 * time.Second
+)
+
 type Claims struct {
 	ID uuid.UUID `json:"id"`
 	jwt.StandardClaims
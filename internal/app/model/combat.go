@@ -0,0 +1,257 @@
+package model
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+)
+
+// Ways to order combatants with the same initiative: by initiative bonus,
+// by player characters first, or by the roll-off made with initiative.
+// Every rule falls back to the next ones.
+const (
+	TieBreakBonus   = "bonus"
+	TieBreakPlayers = "players"
+	TieBreakRollOff = "roll_off"
+)
+
+var (
+	ErrCombatNotStarted = errors.New("combat has not started")
+	ErrNoCombatants     = errors.New("no combatants can act")
+)
+
+// Combat is an encounter on a campaign, optionally fought on a scene. Round
+// counts from 1 once the combat starts; CurrentID is the combatant whose
+// turn it is.
+type Combat struct {
+	ID         uuid.UUID  `json:"id"`
+	CampaignID uuid.UUID  `json:"campaign_id"`
+	SceneID    *uuid.UUID `json:"scene_id"`
+	Name       string     `json:"name"`
+	TieBreak   string     `json:"tie_break"`
+	Round      int        `json:"round"`
+	CurrentID  *uuid.UUID `json:"current_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Combatant takes part in a combat, standing for a token, a character or
+// neither. Initiative is nil until rolled. Hidden combatants are only shown
+// to GMs.
+type Combatant struct {
+	ID              uuid.UUID   `json:"id"`
+	CombatID        uuid.UUID   `json:"combat_id"`
+	Name            string      `json:"name"`
+	TokenID         *uuid.UUID  `json:"token_id"`
+	CharacterID     *uuid.UUID  `json:"character_id"`
+	OwnerID         *uuid.UUID  `json:"owner_id"`
+	Initiative      *int        `json:"initiative"`
+	InitiativeBonus int         `json:"initiative_bonus"`
+	RollOff         int         `json:"-"`
+	HP              int         `json:"hp"`
+	MaxHP           int         `json:"max_hp"`
+	TempHP          int         `json:"temp_hp"`
+	Hidden          bool        `json:"hidden"`
+	Defeated        bool        `json:"defeated"`
+	Conditions      []Condition `json:"conditions"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
+}
+
+// Condition affects a combatant for a number of its turns, or until removed
+// when Rounds is zero.
+type Condition struct {
+	Name   string `json:"name"`
+	Rounds int    `json:"rounds"`
+}
+
+func (c *Combat) Validate() error {
+	return validation.ValidateStruct(
+		c,
+		validation.Field(&c.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&c.TieBreak, validation.Required, validation.In(TieBreakBonus, TieBreakPlayers, TieBreakRollOff)),
+		validation.Field(&c.Round, validation.Min(0)),
+	)
+}
+
+func (c *Combat) Started() bool {
+	return c.Round > 0
+}
+
+func (c *Combatant) Validate() error {
+	return validation.ValidateStruct(
+		c,
+		validation.Field(&c.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&c.InitiativeBonus, validation.Min(-100), validation.Max(100)),
+		validation.Field(&c.MaxHP, validation.Min(0), validation.Max(100000)),
+		validation.Field(&c.HP, validation.Min(0), validation.Max(c.MaxHP)),
+		validation.Field(&c.TempHP, validation.Min(0), validation.Max(100000)),
+		validation.Field(&c.Conditions, validation.Length(0, 50)),
+	)
+}
+
+func (c Condition) Validate() error {
+	return validation.ValidateStruct(
+		&c,
+		validation.Field(&c.Name, validation.Required, validation.Length(1, 50)),
+		validation.Field(&c.Rounds, validation.Min(0), validation.Max(1000)),
+	)
+}
+
+func (c *Combatant) IsOwnedBy(userID uuid.UUID) bool {
+	return c.OwnerID != nil && *c.OwnerID == userID
+}
+
+func (c *Combatant) VisibleTo(member *Member) bool {
+	return !c.Hidden || member.IsGM()
+}
+
+// CanEdit reports whether the member may roll initiative for the combatant
+// and track its hit points and conditions.
+func (c *Combatant) CanEdit(member *Member) bool {
+	return member.IsGM() || member.CanPlay() && !c.Hidden && c.IsOwnedBy(member.UserID)
+}
+
+// Damage takes hit points, temporary ones first, down to zero.
+func (c *Combatant) Damage(amount int) {
+	absorbed := amount
+	if absorbed > c.TempHP {
+		absorbed = c.TempHP
+	}
+	c.TempHP -= absorbed
+
+	c.HP -= amount - absorbed
+	if c.HP < 0 {
+		c.HP = 0
+	}
+}
+
+// Heal restores hit points up to the maximum.
+func (c *Combatant) Heal(amount int) {
+	c.HP += amount
+	if c.HP > c.MaxHP {
+		c.HP = c.MaxHP
+	}
+}
+
+// AddCondition applies the condition, replacing one of the same name.
+func (c *Combatant) AddCondition(cond Condition) {
+	c.RemoveCondition(cond.Name)
+	c.Conditions = append(c.Conditions, cond)
+}
+
+func (c *Combatant) RemoveCondition(name string) bool {
+	for i, cond := range c.Conditions {
+		if cond.Name == name {
+			c.Conditions = append(c.Conditions[:i], c.Conditions[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// tick counts a turn of the combatant against its conditions and removes
+// the ones that ran out.
+func (c *Combatant) tick() {
+	conditions := []Condition{}
+	for _, cond := range c.Conditions {
+		if cond.Rounds == 1 {
+			continue
+		}
+		if cond.Rounds > 1 {
+			cond.Rounds--
+		}
+		conditions = append(conditions, cond)
+	}
+	c.Conditions = conditions
+}
+
+// Order sorts combatants by initiative, highest first, breaking ties by
+// the combat's rule. Combatants yet to roll come last.
+func (c *Combat) Order(combatants []*Combatant) {
+	sort.SliceStable(combatants, func(i, j int) bool {
+		a, b := combatants[i], combatants[j]
+		if (a.Initiative == nil) != (b.Initiative == nil) {
+			return b.Initiative == nil
+		}
+		if a.Initiative != nil && *a.Initiative != *b.Initiative {
+			return *a.Initiative > *b.Initiative
+		}
+
+		if c.TieBreak == TieBreakPlayers && (a.OwnerID == nil) != (b.OwnerID == nil) {
+			return b.OwnerID == nil
+		}
+		if c.TieBreak != TieBreakRollOff && a.InitiativeBonus != b.InitiativeBonus {
+			return a.InitiativeBonus > b.InitiativeBonus
+		}
+		if a.RollOff != b.RollOff {
+			return a.RollOff > b.RollOff
+		}
+
+		return a.ID.String() < b.ID.String()
+	})
+}
+
+// Start begins the first round with the first combatant able to act and
+// returns it.
+func (c *Combat) Start(combatants []*Combatant) (*Combatant, error) {
+	c.Order(combatants)
+	for _, cb := range combatants {
+		if !cb.Defeated {
+			c.Round = 1
+			c.CurrentID = &cb.ID
+			cb.tick()
+			return cb, nil
+		}
+	}
+
+	return nil, ErrNoCombatants
+}
+
+// Next passes the turn to the next combatant able to act, starting a new
+// round after the last one, and returns it. Its conditions lose a round.
+func (c *Combat) Next(combatants []*Combatant) (*Combatant, error) {
+	if !c.Started() {
+		return nil, ErrCombatNotStarted
+	}
+
+	c.Order(combatants)
+	current := -1
+	for i, cb := range combatants {
+		if c.CurrentID != nil && cb.ID == *c.CurrentID {
+			current = i
+		}
+	}
+
+	for n := 1; n <= len(combatants); n++ {
+		i := current + n
+		if i >= len(combatants) {
+			i -= len(combatants)
+		}
+
+		cb := combatants[i]
+		if cb.Defeated {
+			continue
+		}
+
+		if i <= current {
+			c.Round++
+		}
+		c.CurrentID = &cb.ID
+		cb.tick()
+
+		return cb, nil
+	}
+
+	return nil, ErrNoCombatants
+}
+
+// End stops the combat; it can be started again.
+func (c *Combat) End() {
+	c.Round = 0
+	c.CurrentID = nil
+}
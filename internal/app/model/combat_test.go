@@ -0,0 +1,159 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCombatant_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		c       func() *model.Combatant
+		isValid bool
+	}{
+		{
+			name: "valid",
+			c: func() *model.Combatant {
+				return model.TestCombatant(t, &model.Combat{})
+			},
+			isValid: true,
+		},
+		{
+			name: "more hp than the maximum",
+			c: func() *model.Combatant {
+				c := model.TestCombatant(t, &model.Combat{})
+				c.HP = 30
+
+				return c
+			},
+			isValid: false,
+		},
+		{
+			name: "unnamed condition",
+			c: func() *model.Combatant {
+				c := model.TestCombatant(t, &model.Combat{})
+				c.Conditions = []model.Condition{{Rounds: 2}}
+
+				return c
+			},
+			isValid: false,
+		},
+		{
+			name: "no name",
+			c: func() *model.Combatant {
+				c := model.TestCombatant(t, &model.Combat{})
+				c.Name = ""
+
+				return c
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.c().Validate())
+			} else {
+				assert.Error(t, tc.c().Validate())
+			}
+		})
+	}
+}
+
+func TestCombatant_DamageAndHeal(t *testing.T) {
+	c := model.TestCombatant(t, &model.Combat{})
+	c.TempHP = 5
+
+	c.Damage(8)
+	assert.Equal(t, 0, c.TempHP)
+	assert.Equal(t, 19, c.HP)
+
+	c.Damage(30)
+	assert.Equal(t, 0, c.HP)
+
+	c.Heal(10)
+	assert.Equal(t, 10, c.HP)
+	c.Heal(100)
+	assert.Equal(t, 22, c.HP)
+}
+
+func TestCombat_Order(t *testing.T) {
+	initiative := func(n int) *int {
+		return &n
+	}
+	owner := uuid.New()
+	fighter := &model.Combatant{ID: uuid.New(), Name: "Fighter", Initiative: initiative(15), InitiativeBonus: 1, RollOff: 3, OwnerID: &owner}
+	rogue := &model.Combatant{ID: uuid.New(), Name: "Rogue", Initiative: initiative(15), InitiativeBonus: 4, RollOff: 2, OwnerID: &owner}
+	goblin := &model.Combatant{ID: uuid.New(), Name: "Goblin", Initiative: initiative(15), InitiativeBonus: 2, RollOff: 20}
+	ogre := &model.Combatant{ID: uuid.New(), Name: "Ogre", Initiative: initiative(20), InitiativeBonus: -1}
+	late := &model.Combatant{ID: uuid.New(), Name: "Late", InitiativeBonus: 10}
+
+	testCases := []struct {
+		tieBreak string
+		expected []*model.Combatant
+	}{
+		{model.TieBreakBonus, []*model.Combatant{ogre, rogue, goblin, fighter, late}},
+		{model.TieBreakPlayers, []*model.Combatant{ogre, rogue, fighter, goblin, late}},
+		{model.TieBreakRollOff, []*model.Combatant{ogre, goblin, fighter, rogue, late}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.tieBreak, func(t *testing.T) {
+			combatants := []*model.Combatant{late, fighter, goblin, rogue, ogre}
+			(&model.Combat{TieBreak: tc.tieBreak}).Order(combatants)
+			assert.Equal(t, tc.expected, combatants)
+		})
+	}
+}
+
+func TestCombat_Turns(t *testing.T) {
+	initiative := func(n int) *int {
+		return &n
+	}
+	c := &model.Combat{TieBreak: model.TieBreakBonus}
+	a := &model.Combatant{ID: uuid.New(), Initiative: initiative(18)}
+	b := &model.Combatant{ID: uuid.New(), Initiative: initiative(12), Defeated: true}
+	d := &model.Combatant{ID: uuid.New(), Initiative: initiative(7), Conditions: []model.Condition{{Name: "poisoned", Rounds: 2}, {Name: "prone"}}}
+	combatants := []*model.Combatant{d, b, a}
+
+	_, err := c.Next(combatants)
+	assert.ErrorIs(t, err, model.ErrCombatNotStarted)
+
+	cb, err := c.Start(combatants)
+	assert.NoError(t, err)
+	assert.Equal(t, a, cb)
+	assert.Equal(t, 1, c.Round)
+
+	cb, _ = c.Next(combatants)
+	assert.Equal(t, d, cb, "defeated combatants are skipped")
+	assert.Equal(t, []model.Condition{{Name: "poisoned", Rounds: 1}, {Name: "prone"}}, d.Conditions)
+
+	cb, _ = c.Next(combatants)
+	assert.Equal(t, a, cb)
+	assert.Equal(t, 2, c.Round)
+
+	c.Next(combatants)
+	assert.Equal(t, []model.Condition{{Name: "prone"}}, d.Conditions)
+
+	a.Defeated, d.Defeated = true, true
+	_, err = c.Next(combatants)
+	assert.ErrorIs(t, err, model.ErrNoCombatants)
+
+	c.End()
+	assert.False(t, c.Started())
+}
+
+func TestCombatant_Conditions(t *testing.T) {
+	c := model.TestCombatant(t, &model.Combat{})
+	c.AddCondition(model.Condition{Name: "frightened", Rounds: 1})
+	c.AddCondition(model.Condition{Name: "frightened", Rounds: 3})
+	assert.Equal(t, []model.Condition{{Name: "frightened", Rounds: 3}}, c.Conditions)
+
+	assert.True(t, c.RemoveCondition("frightened"))
+	assert.False(t, c.RemoveCondition("frightened"))
+	assert.Empty(t, c.Conditions)
+}
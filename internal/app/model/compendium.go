@@ -0,0 +1,167 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/compendium/schema"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+)
+
+const (
+	KindSpell     = "spell"
+	KindMonster   = "monster"
+	KindItem      = "item"
+	KindCondition = "condition"
+	KindRule      = "rule"
+)
+
+var ErrNotAMonster = errors.New("compendium entry is not a monster")
+
+// CompendiumEntry is reference material of a game system: a spell, a
+// monster, an item, a condition or a rule. Entries come from packs, Source
+// naming the pack; a pack has one entry of a kind with a name. Data holds
+// the kind's stats as a JSON object matching the kind's schema.
+//
+// Entries of homebrew packs belong to a published version of the pack, and
+// are only part of the compendium of campaigns subscribed to it.
+type CompendiumEntry struct {
+	ID          uuid.UUID       `json:"id"`
+	System      string          `json:"system"`
+	Kind        string          `json:"kind"`
+	Source      string          `json:"source"`
+	Name        string          `json:"name"`
+	Body        string          `json:"body"`
+	BodyHTML    string          `json:"body_html"`
+	Data        json.RawMessage `json:"data"`
+	PackID      *uuid.UUID      `json:"pack_id"`
+	PackVersion int             `json:"pack_version,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// CompendiumFilter narrows compendium listings. Query searches names and
+// bodies, Data matches top-level fields of Data by their text. Zero values
+// mean "no restriction", except that entries of homebrew packs are only
+// listed for the pack versions in Packs.
+type CompendiumFilter struct {
+	System string
+	Kind   string
+	Source string
+	Query  string
+	Data   map[string]string
+	Packs  []PackRef
+	Limit  int
+	Offset int
+}
+
+// MonsterStats are the fields of a monster's Data used to add it to combat.
+type MonsterStats struct {
+	HP              int `json:"hp"`
+	InitiativeBonus int `json:"initiative_bonus"`
+}
+
+func (e *CompendiumEntry) Validate() error {
+	return validation.ValidateStruct(
+		e,
+		validation.Field(&e.System, validation.Required, validation.In(systems()...)),
+		validation.Field(&e.Kind, validation.Required, validation.In(compendiumKinds()...)),
+		validation.Field(&e.Source, validation.Required, validation.Length(1, 100)),
+		validation.Field(&e.Name, validation.Required, validation.Length(1, 200)),
+		validation.Field(&e.Body, validation.Length(0, 100000)),
+		validation.Field(&e.Data, validation.Required, validation.By(func(interface{}) error {
+			return schema.Validate(e.Kind, e.Data)
+		})),
+	)
+}
+
+// Monster returns the stats of a monster entry.
+func (e *CompendiumEntry) Monster() (*MonsterStats, error) {
+	if e.Kind != KindMonster {
+		return nil, ErrNotAMonster
+	}
+
+	stats := &MonsterStats{}
+	if err := json.Unmarshal(e.Data, stats); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func (f *CompendiumFilter) Validate() error {
+	return validation.ValidateStruct(
+		f,
+		validation.Field(&f.Kind, validation.In(compendiumKinds()...)),
+		validation.Field(&f.Data, validation.Length(0, 10)),
+		validation.Field(&f.Limit, validation.Min(0), validation.Max(100)),
+		validation.Field(&f.Offset, validation.Min(0)),
+	)
+}
+
+// Match reports whether the entry satisfies every restriction of the
+// filter except pagination. Query words must all appear in the name or the
+// body.
+func (f *CompendiumFilter) Match(e *CompendiumEntry) bool {
+	if f.System != "" && e.System != f.System || f.Kind != "" && e.Kind != f.Kind || f.Source != "" && e.Source != f.Source {
+		return false
+	}
+
+	if e.PackID != nil && !f.includes(PackRef{ID: *e.PackID, Version: e.PackVersion}) {
+		return false
+	}
+
+	text := strings.ToLower(e.Name + "\n" + e.Body)
+	for _, word := range strings.Fields(strings.ToLower(f.Query)) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+
+	if len(f.Data) == 0 {
+		return true
+	}
+
+	data := map[string]interface{}{}
+	if err := json.Unmarshal(e.Data, &data); err != nil {
+		return false
+	}
+	for key, value := range f.Data {
+		if v, ok := data[key]; !ok || v == nil || jsonText(v) != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f *CompendiumFilter) includes(ref PackRef) bool {
+	for _, p := range f.Packs {
+		if p == ref {
+			return true
+		}
+	}
+
+	return false
+}
+
+func compendiumKinds() []interface{} {
+	return []interface{}{KindSpell, KindMonster, KindItem, KindCondition, KindRule}
+}
+
+// jsonText formats a decoded JSON value like Postgres' ->> operator does.
+func jsonText(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64, bool:
+		return fmt.Sprint(v)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
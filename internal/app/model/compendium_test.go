@@ -0,0 +1,121 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompendiumEntry_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		e       func() *model.CompendiumEntry
+		isValid bool
+	}{
+		{
+			name: "valid",
+			e: func() *model.CompendiumEntry {
+				return model.TestCompendiumEntry(t)
+			},
+			isValid: true,
+		},
+		{
+			name: "unknown system",
+			e: func() *model.CompendiumEntry {
+				e := model.TestCompendiumEntry(t)
+				e.System = "gurps"
+
+				return e
+			},
+			isValid: false,
+		},
+		{
+			name: "unknown kind",
+			e: func() *model.CompendiumEntry {
+				e := model.TestCompendiumEntry(t)
+				e.Kind = "deity"
+
+				return e
+			},
+			isValid: false,
+		},
+		{
+			name: "no source",
+			e: func() *model.CompendiumEntry {
+				e := model.TestCompendiumEntry(t)
+				e.Source = ""
+
+				return e
+			},
+			isValid: false,
+		},
+		{
+			name: "data not an object",
+			e: func() *model.CompendiumEntry {
+				e := model.TestCompendiumEntry(t)
+				e.Data = []byte(`[7]`)
+
+				return e
+			},
+			isValid: false,
+		},
+		{
+			name: "data against the schema",
+			e: func() *model.CompendiumEntry {
+				e := model.TestCompendiumEntry(t)
+				e.Data = []byte(`{"hp": "seven", "initiative_bonus": 2}`)
+
+				return e
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.e().Validate())
+			} else {
+				assert.Error(t, tc.e().Validate())
+			}
+		})
+	}
+}
+
+func TestCompendiumEntry_Monster(t *testing.T) {
+	e := model.TestCompendiumEntry(t)
+	stats, err := e.Monster()
+	assert.NoError(t, err)
+	assert.Equal(t, &model.MonsterStats{HP: 7, InitiativeBonus: 2}, stats)
+
+	e.Kind = model.KindSpell
+	_, err = e.Monster()
+	assert.Equal(t, model.ErrNotAMonster, err)
+}
+
+func TestCompendiumFilter_Match(t *testing.T) {
+	e := model.TestCompendiumEntry(t)
+
+	testCases := []struct {
+		name    string
+		filter  *model.CompendiumFilter
+		matches bool
+	}{
+		{"empty", &model.CompendiumFilter{}, true},
+		{"kind", &model.CompendiumFilter{System: "dnd5e", Kind: model.KindMonster}, true},
+		{"other kind", &model.CompendiumFilter{Kind: model.KindSpell}, false},
+		{"other source", &model.CompendiumFilter{Source: "homebrew"}, false},
+		{"query", &model.CompendiumFilter{Query: "goblin DISENGAGE"}, true},
+		{"query not found", &model.CompendiumFilter{Query: "goblin dragon"}, false},
+		{"data", &model.CompendiumFilter{Data: map[string]string{"size": "Small", "cr": "0.25"}}, true},
+		{"other data", &model.CompendiumFilter{Data: map[string]string{"cr": "1"}}, false},
+		{"missing data", &model.CompendiumFilter{Data: map[string]string{"school": "evocation"}}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.matches, tc.filter.Match(e))
+		})
+	}
+}
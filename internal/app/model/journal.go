@@ -0,0 +1,164 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/ot"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+)
+
+// JournalBodyMaxLength is the longest a journal entry's body can get, in
+// characters.
+const JournalBodyMaxLength = 100000
+
+const (
+	VisibilityGM      = "gm"
+	VisibilityPlayers = "players"
+	VisibilityMembers = "members"
+)
+
+// JournalEntry is a page of a campaign's journal: lore, a handout or a
+// player's notes. GM entries are for the GMs, player entries for everyone
+// and member entries for the GMs and Members. Authors always see their own
+// entries. Collaborative entries can be edited together by everyone who sees
+// them and can play; Revision counts the edits of the body.
+type JournalEntry struct {
+	ID            uuid.UUID   `json:"id"`
+	CampaignID    uuid.UUID   `json:"campaign_id"`
+	FolderID      *uuid.UUID  `json:"folder_id"`
+	AuthorID      uuid.UUID   `json:"author_id"`
+	Title         string      `json:"title"`
+	Body          string      `json:"body"`
+	BodyHTML      string      `json:"body_html"`
+	ImageID       *uuid.UUID  `json:"image_id"`
+	Visibility    string      `json:"visibility"`
+	Members       []uuid.UUID `json:"members"`
+	Collaborative bool        `json:"collaborative"`
+	Revision      int         `json:"revision"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+// JournalOperation is an edit of a journal entry's body, the one that made
+// its Revision.
+type JournalOperation struct {
+	EntryID   uuid.UUID `json:"entry_id"`
+	Revision  int       `json:"revision"`
+	UserID    uuid.UUID `json:"user_id"`
+	Op        ot.Op     `json:"op"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// JournalFolder groups the entries of a campaign's journal. Folders nest.
+type JournalFolder struct {
+	ID         uuid.UUID  `json:"id"`
+	CampaignID uuid.UUID  `json:"campaign_id"`
+	ParentID   *uuid.UUID `json:"parent_id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// JournalFilter narrows journal listings. Query searches titles and bodies.
+type JournalFilter struct {
+	FolderID *uuid.UUID
+	Query    string
+}
+
+func (e *JournalEntry) Validate() error {
+	return validation.ValidateStruct(
+		e,
+		validation.Field(&e.Title, validation.Required, validation.Length(1, 200)),
+		validation.Field(&e.Body, validation.Length(0, JournalBodyMaxLength)),
+		validation.Field(&e.Visibility, validation.Required, validation.In(VisibilityGM, VisibilityPlayers, VisibilityMembers)),
+		validation.Field(&e.Members, validation.By(requiredIf(e.Visibility == VisibilityMembers))),
+	)
+}
+
+func (e *JournalEntry) VisibleTo(member *Member) bool {
+	if e.AuthorID == member.UserID || member.IsGM() {
+		return true
+	}
+
+	switch e.Visibility {
+	case VisibilityPlayers:
+		return true
+	case VisibilityMembers:
+		return e.Shared(member.UserID)
+	}
+
+	return false
+}
+
+// Shared reports whether the entry is shared with the user by name.
+func (e *JournalEntry) Shared(userID uuid.UUID) bool {
+	for _, id := range e.Members {
+		if id == userID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanModify reports whether the member may edit or delete the entry.
+func (e *JournalEntry) CanModify(member *Member) bool {
+	return e.AuthorID == member.UserID || member.IsGM()
+}
+
+// CanEdit reports whether the member may edit the entry's body.
+func (e *JournalEntry) CanEdit(member *Member) bool {
+	return e.CanModify(member) || (e.Collaborative && member.CanPlay() && e.VisibleTo(member))
+}
+
+// Reveal makes the entry visible to the users, or to every player when
+// none are given. It never narrows who can already see it.
+func (e *JournalEntry) Reveal(userIDs []uuid.UUID) {
+	if len(userIDs) == 0 || e.Visibility == VisibilityPlayers {
+		e.Visibility = VisibilityPlayers
+		e.Members = []uuid.UUID{}
+
+		return
+	}
+
+	e.Visibility = VisibilityMembers
+	for _, id := range userIDs {
+		if !e.Shared(id) {
+			e.Members = append(e.Members, id)
+		}
+	}
+}
+
+// Match is the naive search used where full-text search isn't available:
+// every word of the query has to appear in the title or body.
+func (f *JournalFilter) Match(e *JournalEntry) bool {
+	if f.FolderID != nil && (e.FolderID == nil || *e.FolderID != *f.FolderID) {
+		return false
+	}
+
+	text := strings.ToLower(e.Title + "\n" + e.Body)
+	for _, word := range strings.Fields(strings.ToLower(f.Query)) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (o *JournalOperation) Validate() error {
+	return validation.ValidateStruct(
+		o,
+		validation.Field(&o.Revision, validation.Required, validation.Min(1)),
+		validation.Field(&o.Op, validation.Required),
+	)
+}
+
+func (f *JournalFolder) Validate() error {
+	return validation.ValidateStruct(
+		f,
+		validation.Field(&f.Name, validation.Required, validation.Length(1, 100)),
+	)
+}
@@ -0,0 +1,141 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalEntry_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		e       func() *model.JournalEntry
+		isValid bool
+	}{
+		{
+			name: "valid",
+			e: func() *model.JournalEntry {
+				return model.TestJournalEntry(t, &model.Campaign{}, &model.User{})
+			},
+			isValid: true,
+		},
+		{
+			name: "empty title",
+			e: func() *model.JournalEntry {
+				e := model.TestJournalEntry(t, &model.Campaign{}, &model.User{})
+				e.Title = ""
+
+				return e
+			},
+			isValid: false,
+		},
+		{
+			name: "unknown visibility",
+			e: func() *model.JournalEntry {
+				e := model.TestJournalEntry(t, &model.Campaign{}, &model.User{})
+				e.Visibility = "everyone"
+
+				return e
+			},
+			isValid: false,
+		},
+		{
+			name: "members without members",
+			e: func() *model.JournalEntry {
+				e := model.TestJournalEntry(t, &model.Campaign{}, &model.User{})
+				e.Visibility = model.VisibilityMembers
+
+				return e
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.e().Validate())
+			} else {
+				assert.Error(t, tc.e().Validate())
+			}
+		})
+	}
+}
+
+func TestJournalEntry_VisibleTo(t *testing.T) {
+	author := &model.Member{UserID: uuid.New(), Role: model.RolePlayer}
+	gm := &model.Member{UserID: uuid.New(), Role: model.RoleGM}
+	friend := &model.Member{UserID: uuid.New(), Role: model.RolePlayer}
+	other := &model.Member{UserID: uuid.New(), Role: model.RoleSpectator}
+
+	e := &model.JournalEntry{AuthorID: author.UserID, Visibility: model.VisibilityGM}
+	assert.True(t, e.VisibleTo(author))
+	assert.True(t, e.VisibleTo(gm))
+	assert.False(t, e.VisibleTo(friend))
+
+	e.Visibility = model.VisibilityMembers
+	e.Members = []uuid.UUID{friend.UserID}
+	assert.True(t, e.VisibleTo(friend))
+	assert.False(t, e.VisibleTo(other))
+
+	e.Visibility = model.VisibilityPlayers
+	assert.True(t, e.VisibleTo(other))
+
+	assert.True(t, e.CanModify(author))
+	assert.True(t, e.CanModify(gm))
+	assert.False(t, e.CanModify(friend))
+}
+
+func TestJournalEntry_CanEdit(t *testing.T) {
+	author := &model.Member{UserID: uuid.New(), Role: model.RolePlayer}
+	friend := &model.Member{UserID: uuid.New(), Role: model.RolePlayer}
+	stranger := &model.Member{UserID: uuid.New(), Role: model.RolePlayer}
+	spectator := &model.Member{UserID: uuid.New(), Role: model.RoleSpectator}
+
+	e := &model.JournalEntry{
+		AuthorID:   author.UserID,
+		Visibility: model.VisibilityMembers,
+		Members:    []uuid.UUID{friend.UserID, spectator.UserID},
+	}
+	assert.True(t, e.CanEdit(author))
+	assert.False(t, e.CanEdit(friend))
+
+	e.Collaborative = true
+	assert.True(t, e.CanEdit(friend))
+	assert.False(t, e.CanEdit(stranger))
+	assert.False(t, e.CanEdit(spectator))
+}
+
+func TestJournalEntry_Reveal(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	e := &model.JournalEntry{Visibility: model.VisibilityGM}
+
+	e.Reveal([]uuid.UUID{a})
+	assert.Equal(t, model.VisibilityMembers, e.Visibility)
+	assert.Equal(t, []uuid.UUID{a}, e.Members)
+
+	e.Reveal([]uuid.UUID{a, b})
+	assert.Equal(t, []uuid.UUID{a, b}, e.Members)
+
+	e.Reveal(nil)
+	assert.Equal(t, model.VisibilityPlayers, e.Visibility)
+	assert.Empty(t, e.Members)
+
+	e.Reveal([]uuid.UUID{a})
+	assert.Equal(t, model.VisibilityPlayers, e.Visibility)
+}
+
+func TestJournalFilter_Match(t *testing.T) {
+	e := model.TestJournalEntry(t, &model.Campaign{}, &model.User{})
+
+	assert.True(t, (&model.JournalFilter{}).Match(e))
+	assert.True(t, (&model.JournalFilter{Query: "burgomaster LETTER"}).Match(e))
+	assert.False(t, (&model.JournalFilter{Query: "burgomaster strahd"}).Match(e))
+
+	folderID := uuid.New()
+	assert.False(t, (&model.JournalFilter{FolderID: &folderID}).Match(e))
+	e.FolderID = &folderID
+	assert.True(t, (&model.JournalFilter{FolderID: &folderID}).Match(e))
+}
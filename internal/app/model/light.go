@@ -0,0 +1,38 @@
+package model
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/geometry"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+)
+
+var (
+	colorRegexp = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+// Light lights up a circle of the given radius, in pixels, on a dark scene.
+type Light struct {
+	ID        uuid.UUID `json:"id"`
+	SceneID   uuid.UUID `json:"scene_id"`
+	X         int       `json:"x"`
+	Y         int       `json:"y"`
+	Radius    int       `json:"radius"`
+	Color     string    `json:"color"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (l *Light) Validate() error {
+	return validation.ValidateStruct(
+		l,
+		validation.Field(&l.Radius, validation.Required, validation.Min(1), validation.Max(20000)),
+		validation.Field(&l.Color, validation.Match(colorRegexp)),
+	)
+}
+
+func (l *Light) Position() geometry.Point {
+	return geometry.Point{X: float64(l.X), Y: float64(l.Y)}
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+)
+
+const (
+	RoleGM        = "gm"
+	RolePlayer    = "player"
+	RoleSpectator = "spectator"
+)
+
+type Member struct {
+	CampaignID uuid.UUID `json:"campaign_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Role       string    `json:"role"`
+}
+
+func (m *Member) Validate() error {
+	return validation.ValidateStruct(
+		m,
+		validation.Field(&m.Role, validation.Required, validation.In(RoleGM, RolePlayer, RoleSpectator)),
+	)
+}
+
+func (m *Member) IsGM() bool {
+	return m.Role == RoleGM
+}
+
+func (m *Member) CanPlay() bool {
+	return m.Role == RoleGM || m.Role == RolePlayer
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/geometry"
+)
+
+var (
+	ErrPathBlocked = errors.New("path is blocked by a wall")
+	ErrTooFar      = errors.New("not enough movement left")
+)
+
+// CheckMove measures a move of the token along the path, which starts at
+// its current position and ends where it moves to. On scenes enforcing
+// movement the path mustn't cross walls and its length must fit in what is
+// left of the token's speed.
+func (s *Scene) CheckMove(t *Token, path []geometry.Point, walls []*Wall) (float64, error) {
+	distance := s.Grid().Measure(path)
+	if !s.EnforceMovement {
+		return distance, nil
+	}
+
+	for i := 1; i < len(path); i++ {
+		step := geometry.Segment{A: path[i-1], B: path[i]}
+		for _, w := range walls {
+			if w.BlocksMovement() && step.Intersects(w.Segment()) {
+				return distance, ErrPathBlocked
+			}
+		}
+	}
+
+	if t.Speed > 0 && t.Moved+distance > float64(t.Speed) {
+		return distance, ErrTooFar
+	}
+
+	return distance, nil
+}
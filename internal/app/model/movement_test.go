@@ -0,0 +1,57 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/geometry"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScene_CheckMove(t *testing.T) {
+	u := model.TestUser(t)
+	s := model.TestScene(t, model.TestCampaign(t, u))
+	s.EnforceMovement = true
+	tok := model.TestToken(t, s, u)
+	tok.Speed = 30
+	tok.Moved = 10
+
+	// A wall east of the token with a door further south.
+	walls := []*model.Wall{
+		{X1: 280, Y1: 0, X2: 280, Y2: 420, Kind: model.WallSolid},
+		{X1: 280, Y1: 420, X2: 280, Y2: 490, Kind: model.WallDoor},
+		{X1: 280, Y1: 490, X2: 280, Y2: 1050, Kind: model.WallWindow},
+	}
+	start := tok.Position()
+
+	testCases := []struct {
+		name     string
+		path     []geometry.Point
+		distance float64
+		err      error
+	}{
+		{"within speed", []geometry.Point{start, {X: 245, Y: 105}}, 10, nil},
+		{"too far", []geometry.Point{start, {X: 105, Y: 525}}, 30, model.ErrTooFar},
+		{"through a wall", []geometry.Point{start, {X: 315, Y: 105}}, 15, model.ErrPathBlocked},
+		{"through a closed door", []geometry.Point{start, {X: 245, Y: 455}, {X: 315, Y: 455}}, 30, model.ErrPathBlocked},
+		{"through a window", []geometry.Point{start, {X: 245, Y: 105}, {X: 315, Y: 525}}, 40, model.ErrPathBlocked},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			distance, err := s.CheckMove(tok, tc.path, walls)
+			assert.Equal(t, tc.distance, distance)
+			assert.Equal(t, tc.err, err)
+		})
+	}
+
+	walls[1].Open = true
+	tok.Speed = 0
+	_, err := s.CheckMove(tok, []geometry.Point{start, {X: 245, Y: 455}, {X: 315, Y: 455}}, walls)
+	assert.NoError(t, err, "through an open door")
+
+	s.EnforceMovement = false
+	distance, err := s.CheckMove(tok, []geometry.Point{start, {X: 315, Y: 105}}, walls)
+	assert.NoError(t, err)
+	assert.Equal(t, 15.0, distance)
+}
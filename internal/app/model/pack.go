@@ -0,0 +1,206 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+)
+
+var ErrDuplicatePackEntry = errors.New("duplicate pack entry")
+
+// Pack is a user's homebrew bundle of compendium entries for a game system.
+// Its entries are published in numbered versions that never change, so
+// campaigns keep the version they subscribed to until they upgrade.
+type Pack struct {
+	ID              uuid.UUID `json:"id"`
+	OwnerID         uuid.UUID `json:"owner_id"`
+	Name            string    `json:"name"`
+	System          string    `json:"system"`
+	Description     string    `json:"description"`
+	DescriptionHTML string    `json:"description_html"`
+	LatestVersion   int       `json:"latest_version"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// PackFilter narrows pack listings. Zero values mean "no restriction".
+type PackFilter struct {
+	OwnerID *uuid.UUID
+	System  string
+}
+
+// PackVersion is a published version of a pack. Versions count from 1.
+// Entries are left out of listings.
+type PackVersion struct {
+	PackID    uuid.UUID    `json:"pack_id"`
+	Version   int          `json:"version"`
+	Changelog string       `json:"changelog"`
+	Entries   []*PackEntry `json:"entries,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// PackEntry is a compendium entry as it is written in a pack.
+type PackEntry struct {
+	Kind string          `json:"kind"`
+	Name string          `json:"name"`
+	Body string          `json:"body"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// PackRef points at a version of a pack.
+type PackRef struct {
+	ID      uuid.UUID `json:"pack_id"`
+	Version int       `json:"version"`
+}
+
+// CampaignPack subscribes a campaign to a version of a pack, adding its
+// entries to the campaign's compendium.
+type CampaignPack struct {
+	CampaignID uuid.UUID `json:"campaign_id"`
+	PackID     uuid.UUID `json:"pack_id"`
+	Version    int       `json:"version"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// PackDiff tells what changes between two versions of a pack. Entries are
+// matched by kind and name.
+type PackDiff struct {
+	From    int                `json:"from"`
+	To      int                `json:"to"`
+	Added   []*PackEntry       `json:"added"`
+	Removed []*PackEntry       `json:"removed"`
+	Changed []*PackEntryChange `json:"changed"`
+}
+
+type PackEntryChange struct {
+	Before *PackEntry `json:"before"`
+	After  *PackEntry `json:"after"`
+}
+
+func (p *Pack) Validate() error {
+	return validation.ValidateStruct(
+		p,
+		validation.Field(&p.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&p.System, validation.Required, validation.In(systems()...)),
+		validation.Field(&p.Description, validation.Length(0, 5000)),
+	)
+}
+
+// Validate checks the version's entries as compendium entries of the pack.
+func (v *PackVersion) Validate(p *Pack) error {
+	if err := validation.ValidateStruct(
+		v,
+		validation.Field(&v.Changelog, validation.Length(0, 5000)),
+		validation.Field(&v.Entries, validation.Required, validation.Length(1, 5000)),
+	); err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for _, e := range v.CompendiumEntries(p) {
+		if err := e.Validate(); err != nil {
+			return fmt.Errorf("%s %q: %w", e.Kind, e.Name, err)
+		}
+
+		key := e.Kind + "\n" + e.Name
+		if seen[key] {
+			return fmt.Errorf("%s %q: %w", e.Kind, e.Name, ErrDuplicatePackEntry)
+		}
+		seen[key] = true
+	}
+
+	return nil
+}
+
+// CompendiumEntries returns the entries of the version as compendium
+// entries of the pack, with bodies not rendered yet.
+func (v *PackVersion) CompendiumEntries(p *Pack) []*CompendiumEntry {
+	entries := make([]*CompendiumEntry, 0, len(v.Entries))
+	for _, e := range v.Entries {
+		ce := e.CompendiumEntry(p.System, p.Name)
+		ce.PackID = &p.ID
+		ce.PackVersion = v.Version
+		entries = append(entries, ce)
+	}
+
+	return entries
+}
+
+// CompendiumEntry returns the entry as a compendium entry of the system's
+// pack named source.
+func (e *PackEntry) CompendiumEntry(system string, source string) *CompendiumEntry {
+	data := e.Data
+	if len(data) == 0 {
+		data = json.RawMessage("{}")
+	}
+
+	return &CompendiumEntry{
+		System: system,
+		Kind:   e.Kind,
+		Source: source,
+		Name:   e.Name,
+		Body:   e.Body,
+		Data:   data,
+	}
+}
+
+func (c *CampaignPack) Validate() error {
+	return validation.ValidateStruct(
+		c,
+		validation.Field(&c.Version, validation.Required, validation.Min(1)),
+	)
+}
+
+// DiffPackVersions compares the entries of two versions of a pack.
+func DiffPackVersions(from *PackVersion, to *PackVersion) *PackDiff {
+	d := &PackDiff{
+		From:    from.Version,
+		To:      to.Version,
+		Added:   []*PackEntry{},
+		Removed: []*PackEntry{},
+		Changed: []*PackEntryChange{},
+	}
+
+	before := map[string]*PackEntry{}
+	for _, e := range from.Entries {
+		before[e.Kind+"\n"+e.Name] = e
+	}
+
+	for _, e := range to.Entries {
+		key := e.Kind + "\n" + e.Name
+		old, ok := before[key]
+		delete(before, key)
+
+		switch {
+		case !ok:
+			d.Added = append(d.Added, e)
+		case old.Body != e.Body || !sameJSON(old.Data, e.Data):
+			d.Changed = append(d.Changed, &PackEntryChange{Before: old, After: e})
+		}
+	}
+
+	for _, e := range from.Entries {
+		if _, ok := before[e.Kind+"\n"+e.Name]; ok {
+			d.Removed = append(d.Removed, e)
+		}
+	}
+
+	return d
+}
+
+// sameJSON reports whether two JSON documents are equal, ignoring
+// formatting and the order of keys. Missing documents are empty objects.
+func sameJSON(a json.RawMessage, b json.RawMessage) bool {
+	var va, vb interface{} = map[string]interface{}{}, map[string]interface{}{}
+	if len(a) > 0 && json.Unmarshal(a, &va) != nil || len(b) > 0 && json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(va, vb)
+}
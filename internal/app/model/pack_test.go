@@ -0,0 +1,170 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPack_Validate(t *testing.T) {
+	u := model.TestUser(t)
+
+	testCases := []struct {
+		name    string
+		p       func() *model.Pack
+		isValid bool
+	}{
+		{
+			name: "valid",
+			p: func() *model.Pack {
+				return model.TestPack(t, u)
+			},
+			isValid: true,
+		},
+		{
+			name: "no name",
+			p: func() *model.Pack {
+				p := model.TestPack(t, u)
+				p.Name = ""
+
+				return p
+			},
+			isValid: false,
+		},
+		{
+			name: "unknown system",
+			p: func() *model.Pack {
+				p := model.TestPack(t, u)
+				p.System = "gurps"
+
+				return p
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.p().Validate())
+			} else {
+				assert.Error(t, tc.p().Validate())
+			}
+		})
+	}
+}
+
+func TestPackVersion_Validate(t *testing.T) {
+	p := model.TestPack(t, model.TestUser(t))
+
+	testCases := []struct {
+		name    string
+		v       func() *model.PackVersion
+		isValid bool
+	}{
+		{
+			name: "valid",
+			v: func() *model.PackVersion {
+				return model.TestPackVersion(t, p)
+			},
+			isValid: true,
+		},
+		{
+			name: "no entries",
+			v: func() *model.PackVersion {
+				v := model.TestPackVersion(t, p)
+				v.Entries = nil
+
+				return v
+			},
+			isValid: false,
+		},
+		{
+			name: "data against the schema",
+			v: func() *model.PackVersion {
+				v := model.TestPackVersion(t, p)
+				v.Entries[1].Data = []byte(`{"level": "second"}`)
+
+				return v
+			},
+			isValid: false,
+		},
+		{
+			name: "duplicate entry",
+			v: func() *model.PackVersion {
+				v := model.TestPackVersion(t, p)
+				v.Entries = append(v.Entries, v.Entries[0])
+
+				return v
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.v().Validate(p))
+			} else {
+				assert.Error(t, tc.v().Validate(p))
+			}
+		})
+	}
+}
+
+func TestPackVersion_CompendiumEntries(t *testing.T) {
+	p := model.TestPack(t, model.TestUser(t))
+	p.ID = uuid.New()
+	v := model.TestPackVersion(t, p)
+
+	entries := v.CompendiumEntries(p)
+	assert.Len(t, entries, 2)
+	for _, e := range entries {
+		assert.Equal(t, p.System, e.System)
+		assert.Equal(t, p.Name, e.Source)
+		assert.Equal(t, &p.ID, e.PackID)
+		assert.Equal(t, 1, e.PackVersion)
+	}
+
+	filter := &model.CompendiumFilter{}
+	assert.False(t, filter.Match(entries[0]))
+	filter.Packs = []model.PackRef{{ID: p.ID, Version: 2}}
+	assert.False(t, filter.Match(entries[0]))
+	filter.Packs = []model.PackRef{{ID: p.ID, Version: 1}}
+	assert.True(t, filter.Match(entries[0]))
+}
+
+func TestDiffPackVersions(t *testing.T) {
+	p := model.TestPack(t, model.TestUser(t))
+	from := model.TestPackVersion(t, p)
+	to := model.TestPackVersion(t, p)
+	to.Version = 2
+	to.Entries[0].Data = []byte(`{"initiative_bonus": -2, "hp": 40}`)
+	to.Entries[1].Data = []byte(` {"level":2} `)
+	to.Entries = append(to.Entries, &model.PackEntry{
+		Kind: model.KindCondition,
+		Name: "Mist-Touched",
+		Body: "You have disadvantage on Wisdom saving throws.",
+	})
+	from.Entries = append(from.Entries, &model.PackEntry{
+		Kind: model.KindItem,
+		Name: "Holy Symbol of Ravenkind",
+		Body: "A unique holy symbol.",
+	})
+
+	d := model.DiffPackVersions(from, to)
+	assert.Equal(t, 1, d.From)
+	assert.Equal(t, 2, d.To)
+	if assert.Len(t, d.Added, 1) {
+		assert.Equal(t, "Mist-Touched", d.Added[0].Name)
+	}
+	if assert.Len(t, d.Removed, 1) {
+		assert.Equal(t, "Holy Symbol of Ravenkind", d.Removed[0].Name)
+	}
+	if assert.Len(t, d.Changed, 1) {
+		assert.Equal(t, "Strahd Zombie", d.Changed[0].Before.Name)
+		assert.JSONEq(t, `{"hp": 40, "initiative_bonus": -2}`, string(d.Changed[0].After.Data))
+	}
+}
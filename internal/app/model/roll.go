@@ -0,0 +1,92 @@
+package model
+
+import (
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/dice"
+	"github.com/bruhlord-s/virttable-api/internal/app/ruleset"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+)
+
+// Roll is a dice roll made on a campaign. Outcome is what the campaign's
+// ruleset read into it, if anything.
+type Roll struct {
+	ID          uuid.UUID        `json:"id"`
+	CampaignID  uuid.UUID        `json:"campaign_id"`
+	UserID      uuid.UUID        `json:"user_id"`
+	CharacterID *uuid.UUID       `json:"character_id"`
+	Expression  string           `json:"expression"`
+	Dice        []dice.Die       `json:"dice"`
+	Modifier    int              `json:"modifier"`
+	Total       int              `json:"total"`
+	Outcome     *ruleset.Outcome `json:"outcome"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// RollFilter narrows roll history and statistics queries. Zero values mean
+// "no restriction".
+type RollFilter struct {
+	UserID      *uuid.UUID
+	CharacterID *uuid.UUID
+	Sides       int
+	From        time.Time
+	To          time.Time
+	Limit       int
+	Offset      int
+}
+
+func (r *Roll) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Expression, validation.Required, validation.Length(1, 255)),
+	)
+}
+
+func (r *Roll) Apply(res *dice.Result) {
+	r.Expression = res.Expression
+	r.Dice = res.Dice
+	r.Modifier = res.Modifier
+	r.Total = res.Total
+}
+
+func (f *RollFilter) Validate() error {
+	return validation.ValidateStruct(
+		f,
+		validation.Field(&f.Sides, validation.Min(0), validation.Max(dice.MaxSides)),
+		validation.Field(&f.Limit, validation.Min(0), validation.Max(100)),
+		validation.Field(&f.Offset, validation.Min(0)),
+	)
+}
+
+// Match reports whether the roll satisfies every restriction of the filter
+// except pagination.
+func (f *RollFilter) Match(r *Roll) bool {
+	if f.UserID != nil && r.UserID != *f.UserID {
+		return false
+	}
+
+	if f.CharacterID != nil && (r.CharacterID == nil || *r.CharacterID != *f.CharacterID) {
+		return false
+	}
+
+	if !f.From.IsZero() && r.CreatedAt.Before(f.From) {
+		return false
+	}
+
+	if !f.To.IsZero() && !r.CreatedAt.Before(f.To) {
+		return false
+	}
+
+	if f.Sides > 0 {
+		for _, d := range r.Dice {
+			if d.Sides == f.Sides {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
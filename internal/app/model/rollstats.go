@@ -0,0 +1,87 @@
+package model
+
+import "math"
+
+// FairnessSignificance is the p-value below which a die is reported as
+// suspicious by the chi-squared goodness-of-fit test.
+const FairnessSignificance = 0.01
+
+type RollStats struct {
+	Sides        int         `json:"sides"`
+	Count        int         `json:"count"`
+	Average      float64     `json:"average"`
+	Crits        int         `json:"crits"`
+	Fumbles      int         `json:"fumbles"`
+	Distribution map[int]int `json:"distribution"`
+	ChiSquared   float64     `json:"chi_squared"`
+	PValue       float64     `json:"p_value"`
+	Fair         bool        `json:"fair"`
+}
+
+// ComputeFairness runs a chi-squared goodness-of-fit test of the observed
+// distribution against a uniform one with Sides faces.
+func (s *RollStats) ComputeFairness() {
+	s.ChiSquared, s.PValue, s.Fair = 0, 1, true
+	if s.Sides < 2 || s.Count == 0 {
+		return
+	}
+
+	expected := float64(s.Count) / float64(s.Sides)
+	for face := 1; face <= s.Sides; face++ {
+		d := float64(s.Distribution[face]) - expected
+		s.ChiSquared += d * d / expected
+	}
+
+	s.PValue = chiSquaredSurvival(s.ChiSquared, float64(s.Sides-1))
+	s.Fair = s.PValue >= FairnessSignificance
+}
+
+// chiSquaredSurvival returns P(X >= x) for a chi-squared distribution with k
+// degrees of freedom, i.e. the regularized upper incomplete gamma Q(k/2, x/2).
+func chiSquaredSurvival(x, k float64) float64 {
+	if x <= 0 {
+		return 1
+	}
+
+	a, x := k/2, x/2
+	lga, _ := math.Lgamma(a)
+	if x < a+1 {
+		sum, term := 1/a, 1/a
+		for n := 1.0; n < 1000; n++ {
+			term *= x / (a + n)
+			sum += term
+			if term < sum*1e-15 {
+				break
+			}
+		}
+
+		return 1 - sum*math.Exp(-x+a*math.Log(x)-lga)
+	}
+
+	// Lentz's continued fraction for the upper incomplete gamma.
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for i := 1.0; i < 1000; i++ {
+		an := -i * (i - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < 1e-15 {
+			break
+		}
+	}
+
+	return h * math.Exp(-x+a*math.Log(x)-lga)
+}
@@ -0,0 +1,72 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollStats_ComputeFairness(t *testing.T) {
+	testCases := []struct {
+		name         string
+		sides        int
+		distribution map[int]int
+		chiSquared   float64
+		pValue       float64
+		fair         bool
+	}{
+		{
+			name:         "uniform",
+			sides:        6,
+			distribution: map[int]int{1: 10, 2: 10, 3: 10, 4: 10, 5: 10, 6: 10},
+			chiSquared:   0,
+			pValue:       1,
+			fair:         true,
+		},
+		{
+			name:         "coin at the 5% boundary",
+			sides:        2,
+			distribution: map[int]int{1: 60, 2: 40},
+			chiSquared:   4,
+			pValue:       0.0455,
+			fair:         true,
+		},
+		{
+			name:         "loaded d20",
+			sides:        20,
+			distribution: map[int]int{1: 100, 20: 5},
+			chiSquared:   1804.52,
+			pValue:       0,
+			fair:         false,
+		},
+		{
+			name:         "no rolls",
+			sides:        20,
+			distribution: map[int]int{},
+			chiSquared:   0,
+			pValue:       1,
+			fair:         true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &model.RollStats{Sides: tc.sides, Distribution: tc.distribution}
+			for _, n := range tc.distribution {
+				s.Count += n
+			}
+
+			s.ComputeFairness()
+			assert.InDelta(t, tc.chiSquared, s.ChiSquared, 0.01)
+			assert.InDelta(t, tc.pValue, s.PValue, 0.001)
+			assert.Equal(t, tc.fair, s.Fair)
+		})
+	}
+}
+
+func TestRollFilter_Validate(t *testing.T) {
+	assert.NoError(t, (&model.RollFilter{Sides: 20, Limit: 10}).Validate())
+	assert.Error(t, (&model.RollFilter{Limit: 1000}).Validate())
+	assert.Error(t, (&model.RollFilter{Offset: -1}).Validate())
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/grid"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+	"github.com/google/uuid"
+)
+
+const (
+	GridSquare = grid.Square
+	GridHex    = grid.Hex
+)
+
+var (
+	ErrOutOfBounds = errors.New("position is outside the scene")
+)
+
+// Scene is a battle map. Dimensions, grid size and offset are in pixels of
+// the background image; the offset aligns the grid with one drawn on it.
+// GridDistance is the length of a cell in game units, measured across
+// diagonals by the Diagonals rule on square grids. FreeMovement lets players
+// move every token on the tokens layer, not just their own, and
+// EnforceMovement holds their moves to walls and token speeds. With FogOfWar
+// players only see what their tokens see, and on Dark scenes only where
+// there is light.
+type Scene struct {
+	ID              uuid.UUID `json:"id"`
+	CampaignID      uuid.UUID `json:"campaign_id"`
+	Name            string    `json:"name"`
+	Background      string    `json:"background"`
+	GridType        string    `json:"grid_type"`
+	GridSize        int       `json:"grid_size"`
+	GridOffsetX     int       `json:"grid_offset_x"`
+	GridOffsetY     int       `json:"grid_offset_y"`
+	HexOrientation  string    `json:"hex_orientation"`
+	Diagonals       string    `json:"diagonals"`
+	GridDistance    int       `json:"grid_distance"`
+	Width           int       `json:"width"`
+	Height          int       `json:"height"`
+	FreeMovement    bool      `json:"free_movement"`
+	EnforceMovement bool      `json:"enforce_movement"`
+	FogOfWar        bool      `json:"fog_of_war"`
+	Dark            bool      `json:"dark"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (s *Scene) Validate() error {
+	return validation.ValidateStruct(
+		s,
+		validation.Field(&s.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&s.Background, is.URL, validation.Length(0, 2048)),
+		validation.Field(&s.GridType, validation.Required, validation.In(GridSquare, GridHex)),
+		validation.Field(&s.GridSize, validation.Required, validation.Min(10), validation.Max(500)),
+		validation.Field(&s.GridOffsetX, validation.Min(0), validation.Max(s.GridSize-1)),
+		validation.Field(&s.GridOffsetY, validation.Min(0), validation.Max(s.GridSize-1)),
+		validation.Field(&s.HexOrientation, validation.Required, validation.In(grid.Pointy, grid.Flat)),
+		validation.Field(&s.Diagonals, validation.Required, validation.In(grid.DiagonalsFive, grid.DiagonalsAlternating, grid.DiagonalsEuclidean)),
+		validation.Field(&s.GridDistance, validation.Required, validation.Min(1), validation.Max(1000)),
+		validation.Field(&s.Width, validation.Required, validation.Min(1), validation.Max(20000)),
+		validation.Field(&s.Height, validation.Required, validation.Min(1), validation.Max(20000)),
+	)
+}
+
+// Contains reports whether the point lies on the scene.
+func (s *Scene) Contains(x, y int) bool {
+	return x >= 0 && y >= 0 && x <= s.Width && y <= s.Height
+}
+
+func (s *Scene) Grid() *grid.Grid {
+	return &grid.Grid{
+		Type:        s.GridType,
+		Orientation: s.HexOrientation,
+		Diagonals:   s.Diagonals,
+		Size:        float64(s.GridSize),
+		OffsetX:     float64(s.GridOffsetX),
+		OffsetY:     float64(s.GridOffsetY),
+		Width:       float64(s.Width),
+		Height:      float64(s.Height),
+		Distance:    float64(s.GridDistance),
+	}
+}
@@ -0,0 +1,114 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScene_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		s       func() *model.Scene
+		isValid bool
+	}{
+		{
+			name: "valid",
+			s: func() *model.Scene {
+				return model.TestScene(t, model.TestCampaign(t, model.TestUser(t)))
+			},
+			isValid: true,
+		},
+		{
+			name: "hex grid with offset",
+			s: func() *model.Scene {
+				s := model.TestScene(t, model.TestCampaign(t, model.TestUser(t)))
+				s.GridType = model.GridHex
+				s.GridOffsetX = 35
+
+				return s
+			},
+			isValid: true,
+		},
+		{
+			name: "unknown grid",
+			s: func() *model.Scene {
+				s := model.TestScene(t, model.TestCampaign(t, model.TestUser(t)))
+				s.GridType = "triangle"
+
+				return s
+			},
+			isValid: false,
+		},
+		{
+			name: "offset larger than a cell",
+			s: func() *model.Scene {
+				s := model.TestScene(t, model.TestCampaign(t, model.TestUser(t)))
+				s.GridOffsetY = 70
+
+				return s
+			},
+			isValid: false,
+		},
+		{
+			name: "no dimensions",
+			s: func() *model.Scene {
+				s := model.TestScene(t, model.TestCampaign(t, model.TestUser(t)))
+				s.Width = 0
+
+				return s
+			},
+			isValid: false,
+		},
+		{
+			name: "unknown diagonals",
+			s: func() *model.Scene {
+				s := model.TestScene(t, model.TestCampaign(t, model.TestUser(t)))
+				s.Diagonals = "5-15-5"
+
+				return s
+			},
+			isValid: false,
+		},
+		{
+			name: "no grid distance",
+			s: func() *model.Scene {
+				s := model.TestScene(t, model.TestCampaign(t, model.TestUser(t)))
+				s.GridDistance = 0
+
+				return s
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid background",
+			s: func() *model.Scene {
+				s := model.TestScene(t, model.TestCampaign(t, model.TestUser(t)))
+				s.Background = "not a url"
+
+				return s
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.s().Validate())
+			} else {
+				assert.Error(t, tc.s().Validate())
+			}
+		})
+	}
+}
+
+func TestScene_Contains(t *testing.T) {
+	s := model.TestScene(t, model.TestCampaign(t, model.TestUser(t)))
+
+	assert.True(t, s.Contains(0, 0))
+	assert.True(t, s.Contains(1400, 1050))
+	assert.False(t, s.Contains(-1, 10))
+	assert.False(t, s.Contains(10, 1051))
+}
@@ -1,6 +1,11 @@
 package model
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/grid"
+	"github.com/google/uuid"
+)
 
 func TestUser(t *testing.T) *User {
 	return &User{
@@ -8,4 +13,206 @@ func TestUser(t *testing.T) *User {
 		Username: "test",
 		Password: "password",
 	}
-}
\ No newline at end of file
+}
+
+func TestCampaign(t *testing.T, owner *User) *Campaign {
+	return &Campaign{
+		Name:        "Curse of Strahd",
+		Description: "Gothic horror in Barovia",
+		System:      "dnd5e",
+		OwnerID:     owner.ID,
+	}
+}
+
+func TestRoll(t *testing.T, campaign *Campaign, user *User) *Roll {
+	return &Roll{
+		CampaignID: campaign.ID,
+		UserID:     user.ID,
+		Expression: "1d20+5",
+	}
+}
+
+func TestChatMessage(t *testing.T, campaign *Campaign, user *User) *ChatMessage {
+	return &ChatMessage{
+		CampaignID: campaign.ID,
+		UserID:     user.ID,
+		Kind:       ChatOOC,
+		Body:       "brb, pizza",
+	}
+}
+
+func TestCharacter(t *testing.T, campaign *Campaign, owner *User) *Character {
+	return &Character{
+		CampaignID: campaign.ID,
+		OwnerID:    owner.ID,
+		Name:       "Ireena Kolyana",
+		System:     campaign.System,
+		Data:       []byte(`{"class": "fighter", "level": 3, "abilities": {"str": 14}}`),
+	}
+}
+
+func TestScene(t *testing.T, campaign *Campaign) *Scene {
+	return &Scene{
+		CampaignID:     campaign.ID,
+		Name:           "Death House",
+		Background:     "https://example.com/maps/death-house.png",
+		GridType:       GridSquare,
+		GridSize:       70,
+		HexOrientation: grid.Pointy,
+		Diagonals:      grid.DiagonalsFive,
+		GridDistance:   5,
+		Width:          1400,
+		Height:         1050,
+	}
+}
+
+func TestToken(t *testing.T, scene *Scene, owner *User) *Token {
+	return &Token{
+		SceneID: scene.ID,
+		Name:    "Ireena",
+		X:       105,
+		Y:       105,
+		Size:    1,
+		Layer:   LayerTokens,
+		OwnerID: &owner.ID,
+	}
+}
+
+func TestWall(t *testing.T, scene *Scene) *Wall {
+	return &Wall{
+		SceneID: scene.ID,
+		X1:      280,
+		Y1:      0,
+		X2:      280,
+		Y2:      420,
+		Kind:    WallSolid,
+	}
+}
+
+func TestLight(t *testing.T, scene *Scene) *Light {
+	return &Light{
+		SceneID: scene.ID,
+		X:       140,
+		Y:       140,
+		Radius:  210,
+		Color:   "#ffcc66",
+	}
+}
+
+func TestCombat(t *testing.T, campaign *Campaign) *Combat {
+	return &Combat{
+		CampaignID: campaign.ID,
+		Name:       "Ambush at the gates",
+		TieBreak:   TieBreakBonus,
+	}
+}
+
+func TestCombatant(t *testing.T, combat *Combat) *Combatant {
+	return &Combatant{
+		CombatID:        combat.ID,
+		Name:            "Zombie",
+		InitiativeBonus: -2,
+		HP:              22,
+		MaxHP:           22,
+		Conditions:      []Condition{},
+	}
+}
+
+func TestAsset(t *testing.T, user *User) *Asset {
+	return &Asset{
+		UserID: user.ID,
+		Name:   "barovia.png",
+		Hash:   "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		MIME:   "image/png",
+		Size:   2048,
+		Tags:   []string{"map"},
+	}
+}
+
+func TestFolder(t *testing.T, user *User) *Folder {
+	return &Folder{
+		UserID: user.ID,
+		Name:   "Maps",
+	}
+}
+
+func TestUpload(t *testing.T, user *User) *Upload {
+	return &Upload{
+		UserID: user.ID,
+		Name:   "theme.ogg",
+		Size:   4096,
+		Tags:   []string{},
+	}
+}
+
+func TestJournalEntry(t *testing.T, campaign *Campaign, author *User) *JournalEntry {
+	return &JournalEntry{
+		CampaignID: campaign.ID,
+		AuthorID:   author.ID,
+		Title:      "Letter from Kolyan Indirovich",
+		Body:       "Hail to thee of might and valor. I, the Burgomaster of Barovia, send to you my plea.",
+		Visibility: VisibilityGM,
+		Members:    []uuid.UUID{},
+	}
+}
+
+func TestJournalFolder(t *testing.T, campaign *Campaign) *JournalFolder {
+	return &JournalFolder{
+		CampaignID: campaign.ID,
+		Name:       "Handouts",
+	}
+}
+
+func TestCompendiumEntry(t *testing.T) *CompendiumEntry {
+	return &CompendiumEntry{
+		System: "dnd5e",
+		Kind:   KindMonster,
+		Source: "srd-5.1",
+		Name:   "Goblin",
+		Body:   "**Nimble Escape.** The goblin can take the Disengage or Hide action as a bonus action on each of its turns.",
+		Data:   []byte(`{"size": "Small", "cr": 0.25, "hp": 7, "initiative_bonus": 2}`),
+	}
+}
+
+func TestPack(t *testing.T, owner *User) *Pack {
+	return &Pack{
+		OwnerID:     owner.ID,
+		Name:        "Tome of Barovia",
+		System:      "dnd5e",
+		Description: "Monsters and spells of the mists.",
+	}
+}
+
+func TestPackVersion(t *testing.T, pack *Pack) *PackVersion {
+	return &PackVersion{
+		PackID:    pack.ID,
+		Version:   pack.LatestVersion + 1,
+		Changelog: "First printing.",
+		Entries: []*PackEntry{
+			{
+				Kind: KindMonster,
+				Name: "Strahd Zombie",
+				Body: "**Loathsome Limbs.** Whenever the zombie takes at least 5 damage of one type, one of its limbs falls off.",
+				Data: []byte(`{"hp": 37, "initiative_bonus": -2}`),
+			},
+			{
+				Kind: KindSpell,
+				Name: "Mist Step",
+				Body: "Briefly surrounded by silvery mist, you teleport up to 30 feet.",
+				Data: []byte(`{"level": 2}`),
+			},
+		},
+	}
+}
+
+func TestAction(t *testing.T, scene *Scene, user *User) *Action {
+	return &Action{
+		CampaignID: scene.CampaignID,
+		SceneID:    scene.ID,
+		UserID:     user.ID,
+		Kind:       ActionDo,
+		Target:     TargetWall,
+		TargetID:   uuid.New(),
+		After:      []byte(`{"x1": 280, "y1": 0, "x2": 280, "y2": 420, "kind": "wall"}`),
+	}
+}
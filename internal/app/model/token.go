@@ -0,0 +1,92 @@
+package model
+
+import (
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/geometry"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+	"github.com/google/uuid"
+)
+
+const (
+	LayerMap    = "map"
+	LayerTokens = "tokens"
+	LayerGM     = "gm"
+)
+
+// Token is a piece on a scene. X and Y are the pixel position of its
+// centre, Size is measured in grid cells and Rotation in degrees. Vision is
+// how far, in pixels, the token sees without light. Speed is how far it may
+// move in game units until its Moved distance is reset, or unlimited when
+// zero. Tokens on the GM layer are hidden from players.
+type Token struct {
+	ID          uuid.UUID  `json:"id"`
+	SceneID     uuid.UUID  `json:"scene_id"`
+	Name        string     `json:"name"`
+	Image       string     `json:"image"`
+	X           int        `json:"x"`
+	Y           int        `json:"y"`
+	Size        float64    `json:"size"`
+	Rotation    int        `json:"rotation"`
+	Vision      int        `json:"vision"`
+	Speed       int        `json:"speed"`
+	Moved       float64    `json:"moved"`
+	Layer       string     `json:"layer"`
+	OwnerID     *uuid.UUID `json:"owner_id"`
+	CharacterID *uuid.UUID `json:"character_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (t *Token) Validate() error {
+	return validation.ValidateStruct(
+		t,
+		validation.Field(&t.Name, validation.Length(0, 100)),
+		validation.Field(&t.Image, is.URL, validation.Length(0, 2048)),
+		validation.Field(&t.Size, validation.Required, validation.Min(0.25), validation.Max(20.0)),
+		validation.Field(&t.Rotation, validation.Min(0), validation.Max(359)),
+		validation.Field(&t.Vision, validation.Min(0), validation.Max(20000)),
+		validation.Field(&t.Speed, validation.Min(0), validation.Max(10000)),
+		validation.Field(&t.Moved, validation.Min(0.0)),
+		validation.Field(&t.Layer, validation.Required, validation.In(LayerMap, LayerTokens, LayerGM)),
+	)
+}
+
+func (t *Token) IsOwnedBy(userID uuid.UUID) bool {
+	return t.OwnerID != nil && *t.OwnerID == userID
+}
+
+func (t *Token) VisibleTo(member *Member) bool {
+	return t.Layer != LayerGM || member.IsGM()
+}
+
+// CanMove reports whether the member may move the token: GMs move all of
+// them, players their own tokens on the tokens layer, or any token there if
+// the scene allows free movement.
+func (t *Token) CanMove(member *Member, scene *Scene) bool {
+	if member.IsGM() {
+		return true
+	}
+
+	if !member.CanPlay() || t.Layer != LayerTokens {
+		return false
+	}
+
+	return scene.FreeMovement || t.IsOwnedBy(member.UserID)
+}
+
+// CanEdit reports whether the member may change the token itself. Unlike
+// moving, free movement doesn't extend to this.
+func (t *Token) CanEdit(member *Member) bool {
+	return member.IsGM() || member.CanPlay() && t.Layer == LayerTokens && t.IsOwnedBy(member.UserID)
+}
+
+// Rotate sets the rotation, wrapping it into [0, 360).
+func (t *Token) Rotate(degrees int) {
+	t.Rotation = (degrees%360 + 360) % 360
+}
+
+func (t *Token) Position() geometry.Point {
+	return geometry.Point{X: float64(t.X), Y: float64(t.Y)}
+}
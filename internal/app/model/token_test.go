@@ -0,0 +1,117 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToken_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		t       func() *model.Token
+		isValid bool
+	}{
+		{
+			name: "valid",
+			t: func() *model.Token {
+				return model.TestToken(t, model.TestScene(t, model.TestCampaign(t, model.TestUser(t))), model.TestUser(t))
+			},
+			isValid: true,
+		},
+		{
+			name: "without owner",
+			t: func() *model.Token {
+				tok := model.TestToken(t, model.TestScene(t, model.TestCampaign(t, model.TestUser(t))), model.TestUser(t))
+				tok.OwnerID = nil
+
+				return tok
+			},
+			isValid: true,
+		},
+		{
+			name: "zero size",
+			t: func() *model.Token {
+				tok := model.TestToken(t, model.TestScene(t, model.TestCampaign(t, model.TestUser(t))), model.TestUser(t))
+				tok.Size = 0
+
+				return tok
+			},
+			isValid: false,
+		},
+		{
+			name: "full turn",
+			t: func() *model.Token {
+				tok := model.TestToken(t, model.TestScene(t, model.TestCampaign(t, model.TestUser(t))), model.TestUser(t))
+				tok.Rotation = 360
+
+				return tok
+			},
+			isValid: false,
+		},
+		{
+			name: "unknown layer",
+			t: func() *model.Token {
+				tok := model.TestToken(t, model.TestScene(t, model.TestCampaign(t, model.TestUser(t))), model.TestUser(t))
+				tok.Layer = "sky"
+
+				return tok
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.t().Validate())
+			} else {
+				assert.Error(t, tc.t().Validate())
+			}
+		})
+	}
+}
+
+func TestToken_CanMove(t *testing.T) {
+	owner := model.TestUser(t)
+	owner.ID = uuid.New()
+	s := model.TestScene(t, model.TestCampaign(t, owner))
+	tok := model.TestToken(t, s, owner)
+
+	gm := &model.Member{UserID: uuid.New(), Role: model.RoleGM}
+	player := &model.Member{UserID: owner.ID, Role: model.RolePlayer}
+	other := &model.Member{UserID: uuid.New(), Role: model.RolePlayer}
+	spectator := &model.Member{UserID: uuid.New(), Role: model.RoleSpectator}
+
+	assert.True(t, tok.CanMove(gm, s))
+	assert.True(t, tok.CanMove(player, s))
+	assert.False(t, tok.CanMove(other, s))
+	assert.False(t, tok.CanMove(spectator, s))
+	assert.True(t, tok.CanEdit(player))
+	assert.False(t, tok.CanEdit(other))
+
+	s.FreeMovement = true
+	assert.True(t, tok.CanMove(other, s))
+	assert.False(t, tok.CanMove(spectator, s))
+	assert.False(t, tok.CanEdit(other))
+
+	tok.Layer = model.LayerGM
+	assert.True(t, tok.CanMove(gm, s))
+	assert.False(t, tok.CanMove(player, s))
+	assert.False(t, tok.CanEdit(player))
+	assert.False(t, tok.VisibleTo(player))
+	assert.True(t, tok.VisibleTo(gm))
+}
+
+func TestToken_Rotate(t *testing.T) {
+	tok := &model.Token{}
+
+	tok.Rotate(90)
+	assert.Equal(t, 90, tok.Rotation)
+	tok.Rotate(450)
+	assert.Equal(t, 90, tok.Rotation)
+	tok.Rotate(-90)
+	assert.Equal(t, 270, tok.Rotation)
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Usage is how many bytes of storage a user's assets take. Content shared by
+// several of the user's assets is counted once. Reserved is set aside for
+// their uploads in progress. Quota overrides the server's default quota for
+// the user when set.
+type Usage struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Used      int64     `json:"used"`
+	Reserved  int64     `json:"reserved"`
+	Quota     *int64    `json:"quota"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CampaignUsage is how many bytes of a user's assets are filed under a
+// campaign.
+type CampaignUsage struct {
+	CampaignID uuid.UUID `json:"campaign_id"`
+	Used       int64     `json:"used"`
+}
+
+// Limit is the user's quota, falling back to defaultQuota.
+func (u *Usage) Limit(defaultQuota int64) int64 {
+	if u.Quota != nil {
+		return *u.Quota
+	}
+
+	return defaultQuota
+}
+
+// Available is how many more bytes the user can store, never negative even
+// when a lowered quota leaves them over it.
+func (u *Usage) Available(defaultQuota int64) int64 {
+	if available := u.Limit(defaultQuota) - u.Used - u.Reserved; available > 0 {
+		return available
+	}
+
+	return 0
+}
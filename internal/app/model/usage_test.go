@@ -0,0 +1,19 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsage_Limit(t *testing.T) {
+	u := &model.Usage{Used: 300}
+	assert.Equal(t, int64(1000), u.Limit(1000))
+	assert.Equal(t, int64(700), u.Available(1000))
+
+	quota := int64(200)
+	u.Quota = &quota
+	assert.Equal(t, int64(200), u.Limit(1000))
+	assert.Equal(t, int64(0), u.Available(1000))
+}
@@ -61,6 +61,21 @@ func (u *User) CreateJWT(key []byte) (string, error) {
 	return jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString(key);
 }
 
+// CreateTicket issues a short-lived token meant to be passed in a query
+// string by clients that can't set headers (WebSocket, EventSource).
+func (u *User) CreateTicket(key []byte) (string, error) {
+	exp := time.Now().Add(TicketTTL)
+	c := &Claims{
+		u.ID,
+		jwt.StandardClaims{
+			Subject: u.Email,
+			Audience: TicketAudience,
+			ExpiresAt: exp.Unix(),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString(key)
+}
+
 func encryptString(s string) (string, error) {
 	b, err := bcrypt.GenerateFromPassword([]byte(s), bcrypt.MinCost)
 	if err != nil {
@@ -0,0 +1,113 @@
+package model
+
+import (
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/geometry"
+	"github.com/google/uuid"
+)
+
+// Exploration is the part of a scene a player's tokens have seen so far.
+type Exploration struct {
+	SceneID   uuid.UUID      `json:"scene_id"`
+	UserID    uuid.UUID      `json:"user_id"`
+	Mask      *geometry.Mask `json:"mask"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// TokenSight is the line of sight of a token, ignoring light.
+type TokenSight struct {
+	TokenID uuid.UUID        `json:"token_id"`
+	Polygon geometry.Polygon `json:"polygon"`
+}
+
+// Vision is what a member sees of a scene: the line of sight of their
+// tokens, the doors within it, the cells visible right now and those
+// explored before.
+type Vision struct {
+	Tokens   []TokenSight   `json:"tokens"`
+	Doors    []*Wall        `json:"doors"`
+	Visible  *geometry.Mask `json:"visible"`
+	Explored *geometry.Mask `json:"explored"`
+}
+
+// Sees reports whether the token is in sight: on a visible cell or, for
+// scenery on the map layer, an explored one.
+func (v *Vision) Sees(t *Token) bool {
+	if t.Layer == LayerMap && v.Explored != nil && v.Explored.Contains(t.Position()) {
+		return true
+	}
+
+	return v.Visible.Contains(t.Position())
+}
+
+// NewMask returns an empty fog mask for the scene. Fog is tracked in square
+// cells of the grid size, whatever the grid type.
+func (s *Scene) NewMask() *geometry.Mask {
+	return geometry.NewMask(float64(s.Width), float64(s.Height), float64(s.GridSize))
+}
+
+// See computes what the tokens see of the scene. On dark scenes cells are
+// only visible within a token's vision or the radius of a light that
+// reaches them.
+func (s *Scene) See(tokens []*Token, walls []*Wall, lights []*Light) *Vision {
+	segments := geometry.Rect(float64(s.Width), float64(s.Height))
+	for _, w := range walls {
+		if w.BlocksSight() {
+			segments = append(segments, w.Segment())
+		}
+	}
+
+	lit := []geometry.Polygon{}
+	if s.Dark {
+		for _, l := range lights {
+			lit = append(lit, geometry.Visibility(l.Position(), segments, float64(l.Radius)))
+		}
+	}
+
+	v := &Vision{
+		Tokens:  []TokenSight{},
+		Doors:   []*Wall{},
+		Visible: s.NewMask(),
+	}
+	seen := map[uuid.UUID]bool{}
+	for _, t := range tokens {
+		origin := t.Position()
+		sight := geometry.Visibility(origin, segments, 0)
+		v.Tokens = append(v.Tokens, TokenSight{TokenID: t.ID, Polygon: sight})
+
+		v.Visible.Fill(sight, func(p geometry.Point) bool {
+			if !s.Dark || t.Vision > 0 && origin.Distance(p) <= float64(t.Vision) {
+				return true
+			}
+
+			for _, l := range lit {
+				if l.Contains(p) {
+					return true
+				}
+			}
+
+			return false
+		})
+
+		for _, w := range walls {
+			if w.Kind == WallDoor && !seen[w.ID] && sight.Contains(nudge(w.Midpoint(), origin)) {
+				seen[w.ID] = true
+				v.Doors = append(v.Doors, w)
+			}
+		}
+	}
+
+	return v
+}
+
+// nudge moves p a pixel towards origin. Closed doors bound the line of
+// sight, so their midpoints lie on its edge rather than inside.
+func nudge(p geometry.Point, origin geometry.Point) geometry.Point {
+	d := p.Distance(origin)
+	if d < 1 {
+		return origin
+	}
+
+	return geometry.Point{X: p.X + (origin.X-p.X)/d, Y: p.Y + (origin.Y-p.Y)/d}
+}
@@ -0,0 +1,73 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScene_See(t *testing.T) {
+	u := model.TestUser(t)
+	s := model.TestScene(t, model.TestCampaign(t, u))
+	s.Width, s.Height = 700, 350
+
+	// Two rooms, split at x=350 by a wall with a door in it.
+	door := &model.Wall{ID: uuid.New(), X1: 350, Y1: 140, X2: 350, Y2: 210, Kind: model.WallDoor}
+	walls := []*model.Wall{
+		{ID: uuid.New(), X1: 350, Y1: 0, X2: 350, Y2: 140, Kind: model.WallSolid},
+		door,
+		{ID: uuid.New(), X1: 350, Y1: 210, X2: 350, Y2: 350, Kind: model.WallSolid},
+	}
+	tok := model.TestToken(t, s, u)
+	tok.ID = uuid.New()
+	tok.X, tok.Y = 105, 175
+
+	v := s.See([]*model.Token{tok}, walls, nil)
+	assert.Len(t, v.Tokens, 1)
+	assert.Equal(t, 25, v.Visible.Count())
+	assert.True(t, v.Visible.Has(0, 0))
+	assert.False(t, v.Visible.Has(5, 2))
+	if assert.Len(t, v.Doors, 1) {
+		assert.Equal(t, door.ID, v.Doors[0].ID)
+	}
+
+	door.Open = true
+	v = s.See([]*model.Token{tok}, walls, nil)
+	assert.True(t, v.Visible.Has(5, 2))
+	assert.False(t, v.Visible.Has(9, 0))
+
+	s.Dark = true
+	v = s.See([]*model.Token{tok}, walls, nil)
+	assert.Equal(t, 0, v.Visible.Count())
+
+	tok.Vision = 70
+	v = s.See([]*model.Token{tok}, walls, nil)
+	assert.True(t, v.Visible.Has(1, 2))
+	assert.False(t, v.Visible.Has(3, 2))
+
+	light := &model.Light{X: 525, Y: 175, Radius: 105}
+	v = s.See([]*model.Token{tok}, walls, []*model.Light{light})
+	assert.True(t, v.Visible.Has(6, 2))
+	assert.False(t, v.Visible.Has(9, 4))
+	assert.False(t, v.Visible.Has(3, 0))
+}
+
+func TestVision_Sees(t *testing.T) {
+	u := model.TestUser(t)
+	s := model.TestScene(t, model.TestCampaign(t, u))
+	v := &model.Vision{Visible: s.NewMask(), Explored: s.NewMask()}
+	v.Visible.Set(1, 1)
+	v.Explored.Set(1, 1)
+	v.Explored.Set(5, 1)
+
+	tok := model.TestToken(t, s, u)
+	assert.True(t, v.Sees(tok))
+
+	tok.X = 385
+	assert.False(t, v.Sees(tok))
+
+	tok.Layer = model.LayerMap
+	assert.True(t, v.Sees(tok))
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/geometry"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+)
+
+const (
+	WallSolid  = "wall"
+	WallDoor   = "door"
+	WallWindow = "window"
+)
+
+// Wall is a segment on a scene that blocks sight. Windows never do, doors
+// only while closed.
+type Wall struct {
+	ID        uuid.UUID `json:"id"`
+	SceneID   uuid.UUID `json:"scene_id"`
+	X1        int       `json:"x1"`
+	Y1        int       `json:"y1"`
+	X2        int       `json:"x2"`
+	Y2        int       `json:"y2"`
+	Kind      string    `json:"kind"`
+	Open      bool      `json:"open"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (w *Wall) Validate() error {
+	return validation.ValidateStruct(
+		w,
+		validation.Field(&w.Kind, validation.Required, validation.In(WallSolid, WallDoor, WallWindow)),
+		validation.Field(&w.X2, validation.By(func(interface{}) error {
+			if w.X1 == w.X2 && w.Y1 == w.Y2 {
+				return errors.New("must differ from the start of the wall")
+			}
+
+			return nil
+		})),
+		validation.Field(&w.Open, validation.By(func(interface{}) error {
+			if w.Open && w.Kind != WallDoor {
+				return errors.New("only doors can be open")
+			}
+
+			return nil
+		})),
+	)
+}
+
+func (w *Wall) BlocksSight() bool {
+	switch w.Kind {
+	case WallWindow:
+		return false
+	case WallDoor:
+		return !w.Open
+	}
+
+	return true
+}
+
+// BlocksMovement reports whether tokens can't pass the wall: windows only
+// let sight through, open doors let everything through.
+func (w *Wall) BlocksMovement() bool {
+	return w.Kind != WallDoor || !w.Open
+}
+
+func (w *Wall) Segment() geometry.Segment {
+	return geometry.Segment{
+		A: geometry.Point{X: float64(w.X1), Y: float64(w.Y1)},
+		B: geometry.Point{X: float64(w.X2), Y: float64(w.Y2)},
+	}
+}
+
+// Midpoint is where players see and use doors.
+func (w *Wall) Midpoint() geometry.Point {
+	return geometry.Point{X: float64(w.X1+w.X2) / 2, Y: float64(w.Y1+w.Y2) / 2}
+}
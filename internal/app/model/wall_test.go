@@ -0,0 +1,46 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWall_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		w       *model.Wall
+		isValid bool
+	}{
+		{"wall", &model.Wall{X1: 0, Y1: 0, X2: 100, Y2: 0, Kind: model.WallSolid}, true},
+		{"open door", &model.Wall{X1: 0, Y1: 0, X2: 70, Y2: 0, Kind: model.WallDoor, Open: true}, true},
+		{"open window", &model.Wall{X1: 0, Y1: 0, X2: 70, Y2: 0, Kind: model.WallWindow, Open: true}, false},
+		{"unknown kind", &model.Wall{X1: 0, Y1: 0, X2: 70, Y2: 0, Kind: "curtain"}, false},
+		{"point", &model.Wall{X1: 10, Y1: 10, X2: 10, Y2: 10, Kind: model.WallSolid}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.w.Validate())
+			} else {
+				assert.Error(t, tc.w.Validate())
+			}
+		})
+	}
+}
+
+func TestWall_BlocksSight(t *testing.T) {
+	assert.True(t, (&model.Wall{Kind: model.WallSolid}).BlocksSight())
+	assert.True(t, (&model.Wall{Kind: model.WallDoor}).BlocksSight())
+	assert.False(t, (&model.Wall{Kind: model.WallDoor, Open: true}).BlocksSight())
+	assert.False(t, (&model.Wall{Kind: model.WallWindow}).BlocksSight())
+}
+
+func TestLight_Validate(t *testing.T) {
+	assert.NoError(t, (&model.Light{Radius: 140}).Validate())
+	assert.NoError(t, (&model.Light{Radius: 140, Color: "#ffaa00"}).Validate())
+	assert.Error(t, (&model.Light{Radius: 140, Color: "orange"}).Validate())
+	assert.Error(t, (&model.Light{}).Validate())
+}
@@ -0,0 +1,357 @@
+// Package ot implements operational transformation of plain text. An Op
+// walks the whole document it applies to, retaining, inserting and deleting
+// runs of characters. Positions and lengths count Unicode code points.
+package ot
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxLength bounds the documents ops apply to, and so every component of an
+// op decoded from JSON. It keeps op lengths far from overflowing.
+const MaxLength = 1 << 24
+
+var (
+	ErrInvalidOp      = errors.New("invalid operation")
+	ErrLengthMismatch = errors.New("operation doesn't match the document length")
+)
+
+// Component is a single step of an Op. Exactly one of its fields is set.
+type Component struct {
+	Retain int
+	Insert string
+	Delete int
+}
+
+func (c Component) length() int {
+	switch {
+	case c.Retain > 0:
+		return c.Retain
+	case c.Delete > 0:
+		return c.Delete
+	}
+
+	return utf8.RuneCountInString(c.Insert)
+}
+
+// Op is a text operation. In JSON it is an array where positive numbers
+// retain, negative numbers delete and strings insert, so [5, "abc", -2]
+// inserts "abc" after the fifth character and deletes the two after it.
+type Op []Component
+
+// Retain appends skipping over n characters.
+func (op *Op) Retain(n int) *Op {
+	if n <= 0 {
+		return op
+	}
+
+	if last := len(*op) - 1; last >= 0 && (*op)[last].Retain > 0 {
+		(*op)[last].Retain += n
+		return op
+	}
+	*op = append(*op, Component{Retain: n})
+
+	return op
+}
+
+// Insert appends inserting s. Inserts are kept before adjacent deletes so
+// equal edits always have the same components.
+func (op *Op) Insert(s string) *Op {
+	if s == "" {
+		return op
+	}
+
+	ops := *op
+	last := len(ops) - 1
+	if last >= 0 && ops[last].Insert != "" {
+		ops[last].Insert += s
+		return op
+	}
+	if last >= 0 && ops[last].Delete > 0 {
+		if last > 0 && ops[last-1].Insert != "" {
+			ops[last-1].Insert += s
+			return op
+		}
+
+		*op = append(ops[:last], Component{Insert: s}, ops[last])
+		return op
+	}
+	*op = append(ops, Component{Insert: s})
+
+	return op
+}
+
+// Delete appends deleting the next n characters.
+func (op *Op) Delete(n int) *Op {
+	if n <= 0 {
+		return op
+	}
+
+	if last := len(*op) - 1; last >= 0 && (*op)[last].Delete > 0 {
+		(*op)[last].Delete += n
+		return op
+	}
+	*op = append(*op, Component{Delete: n})
+
+	return op
+}
+
+// BaseLen is the length of the documents the op applies to.
+func (op Op) BaseLen() int {
+	n := 0
+	for _, c := range op {
+		if c.Insert == "" {
+			n += c.length()
+		}
+	}
+
+	return n
+}
+
+// TargetLen is the length of the documents the op produces.
+func (op Op) TargetLen() int {
+	n := 0
+	for _, c := range op {
+		if c.Delete == 0 {
+			n += c.length()
+		}
+	}
+
+	return n
+}
+
+// Noop reports whether applying the op leaves documents unchanged.
+func (op Op) Noop() bool {
+	for _, c := range op {
+		if c.Retain == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (op Op) Apply(doc string) (string, error) {
+	runes := []rune(doc)
+	if len(runes) != op.BaseLen() {
+		return "", ErrLengthMismatch
+	}
+
+	b := strings.Builder{}
+	i := 0
+	for _, c := range op {
+		switch {
+		case c.Retain > 0:
+			if c.Retain > len(runes)-i {
+				return "", ErrLengthMismatch
+			}
+			b.WriteString(string(runes[i : i+c.Retain]))
+			i += c.Retain
+		case c.Delete > 0:
+			if c.Delete > len(runes)-i {
+				return "", ErrLengthMismatch
+			}
+			i += c.Delete
+		default:
+			b.WriteString(c.Insert)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// TransformIndex returns where a cursor at i ends up once the op is
+// applied. Text inserted right at the cursor goes before it.
+func (op Op) TransformIndex(i int) int {
+	index := i
+	for _, c := range op {
+		switch {
+		case c.Retain > 0:
+			i -= c.Retain
+		case c.Delete > 0:
+			if c.Delete < i {
+				index -= c.Delete
+			} else {
+				index -= i
+			}
+			i -= c.Delete
+		default:
+			index += c.length()
+		}
+
+		if i < 0 {
+			break
+		}
+	}
+
+	return index
+}
+
+// Transform takes two ops made concurrently on the same document and returns
+// a' and b' such that applying a then b' gives the same document as b then
+// a'. When both insert at the same place, a's text goes first.
+func Transform(a, b Op) (Op, Op, error) {
+	if a.BaseLen() != b.BaseLen() {
+		return nil, nil, ErrLengthMismatch
+	}
+
+	a1, b1 := Op{}, Op{}
+	ia, ib := &iterator{op: a}, &iterator{op: b}
+	for {
+		ca, okA := ia.peek()
+		cb, okB := ib.peek()
+		if !okA && !okB {
+			return a1, b1, nil
+		}
+
+		if okA && ca.Insert != "" {
+			a1.Insert(ca.Insert)
+			b1.Retain(ca.length())
+			ia.take(ca.length())
+			continue
+		}
+		if okB && cb.Insert != "" {
+			a1.Retain(cb.length())
+			b1.Insert(cb.Insert)
+			ib.take(cb.length())
+			continue
+		}
+		if !okA || !okB {
+			return nil, nil, ErrLengthMismatch
+		}
+
+		n := ca.length()
+		if cb.length() < n {
+			n = cb.length()
+		}
+		switch {
+		case ca.Retain > 0 && cb.Retain > 0:
+			a1.Retain(n)
+			b1.Retain(n)
+		case ca.Delete > 0 && cb.Retain > 0:
+			a1.Delete(n)
+		case ca.Retain > 0 && cb.Delete > 0:
+			b1.Delete(n)
+		}
+		ia.take(n)
+		ib.take(n)
+	}
+}
+
+// Diff returns an op turning a into b, replacing what lies between their
+// common prefix and suffix.
+func Diff(a, b string) Op {
+	ra, rb := []rune(a), []rune(b)
+
+	prefix := 0
+	for prefix < len(ra) && prefix < len(rb) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(ra)-prefix && suffix < len(rb)-prefix && ra[len(ra)-1-suffix] == rb[len(rb)-1-suffix] {
+		suffix++
+	}
+
+	op := Op{}
+	op.Retain(prefix).
+		Insert(string(rb[prefix : len(rb)-suffix])).
+		Delete(len(ra) - prefix - suffix).
+		Retain(suffix)
+
+	return op
+}
+
+func (op Op) MarshalJSON() ([]byte, error) {
+	values := make([]interface{}, 0, len(op))
+	for _, c := range op {
+		switch {
+		case c.Retain > 0:
+			values = append(values, c.Retain)
+		case c.Delete > 0:
+			values = append(values, -c.Delete)
+		default:
+			values = append(values, c.Insert)
+		}
+	}
+
+	return json.Marshal(values)
+}
+
+func (op *Op) UnmarshalJSON(b []byte) error {
+	values := []json.RawMessage{}
+	if err := json.Unmarshal(b, &values); err != nil {
+		return ErrInvalidOp
+	}
+
+	*op = Op{}
+	for _, v := range values {
+		var n int
+		if err := json.Unmarshal(v, &n); err == nil && n != 0 {
+			if n > MaxLength || n < -MaxLength {
+				return ErrInvalidOp
+			}
+
+			if n > 0 {
+				op.Retain(n)
+			} else {
+				op.Delete(-n)
+			}
+			continue
+		}
+
+		var s string
+		if err := json.Unmarshal(v, &s); err == nil && s != "" {
+			if utf8.RuneCountInString(s) > MaxLength {
+				return ErrInvalidOp
+			}
+
+			op.Insert(s)
+			continue
+		}
+
+		return ErrInvalidOp
+	}
+
+	if op.BaseLen() > MaxLength || op.TargetLen() > MaxLength {
+		return ErrInvalidOp
+	}
+
+	return nil
+}
+
+// iterator walks the components of an op, taking retains and deletes a
+// few characters at a time. Inserts are always taken whole.
+type iterator struct {
+	op     Op
+	i      int
+	offset int
+}
+
+func (it *iterator) peek() (Component, bool) {
+	if it.i >= len(it.op) {
+		return Component{}, false
+	}
+
+	c := it.op[it.i]
+	if c.Retain > 0 {
+		c.Retain -= it.offset
+	} else if c.Delete > 0 {
+		c.Delete -= it.offset
+	}
+
+	return c, true
+}
+
+func (it *iterator) take(n int) {
+	c, _ := it.peek()
+	if n < c.length() {
+		it.offset += n
+		return
+	}
+
+	it.i++
+	it.offset = 0
+}
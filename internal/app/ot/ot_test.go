@@ -0,0 +1,170 @@
+package ot_test
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/ot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOp_Apply(t *testing.T) {
+	testCases := []struct {
+		name     string
+		op       string
+		doc      string
+		expected string
+		err      error
+	}{
+		{"insert", `[5, " there"]`, "hello", "hello there", nil},
+		{"delete", `[-6, 5]`, "hello world", "world", nil},
+		{"replace", `[6, "Barovia", -5]`, "hello world", "hello Barovia", nil},
+		{"unicode", `[1, "ё", -1, 1]`, "дуб", "дёб", nil},
+		{"too short", `[3]`, "hello", "", ot.ErrLengthMismatch},
+		{"too long", `[6]`, "hello", "", ot.ErrLengthMismatch},
+	}
+
+	t.Run("out of bounds", func(t *testing.T) {
+		op := ot.Op{{Retain: math.MaxInt64}, {Retain: math.MaxInt64}, {Retain: 7}}
+		doc, err := op.Apply("hello")
+		assert.Equal(t, ot.ErrLengthMismatch, err)
+		assert.Empty(t, doc)
+	})
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			op := ot.Op{}
+			assert.NoError(t, json.Unmarshal([]byte(tc.op), &op))
+
+			doc, err := op.Apply(tc.doc)
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, tc.expected, doc)
+		})
+	}
+}
+
+func TestOp_JSON(t *testing.T) {
+	op := ot.Op{}
+	assert.NoError(t, json.Unmarshal([]byte(`[2, 3, -1, "a", "b", -2]`), &op))
+	b, _ := json.Marshal(op)
+	assert.JSONEq(t, `[5, "ab", -3]`, string(b))
+
+	overflow := `[9223372036854775807, "x", 9223372036854775807, "y", 4]`
+	tooLong := `[16777216, 16777216]`
+	for _, invalid := range []string{`[0]`, `[""]`, `[1.5]`, `[null]`, `{}`, `"abc"`, overflow, tooLong} {
+		assert.Equal(t, ot.ErrInvalidOp, json.Unmarshal([]byte(invalid), &op), invalid)
+	}
+}
+
+func TestOp_TransformIndex(t *testing.T) {
+	op := ot.Op{}
+	op.Retain(2).Insert("xyz").Retain(3).Delete(2).Retain(3)
+
+	assert.Equal(t, 0, op.TransformIndex(0))
+	assert.Equal(t, 5, op.TransformIndex(2))
+	assert.Equal(t, 8, op.TransformIndex(5))
+	assert.Equal(t, 8, op.TransformIndex(6))
+	assert.Equal(t, 8, op.TransformIndex(7))
+	assert.Equal(t, 11, op.TransformIndex(10))
+}
+
+func TestTransform(t *testing.T) {
+	doc := "the castle"
+	a := ot.Op{}
+	a.Retain(4).Insert("dark ").Retain(6)
+	b := ot.Op{}
+	b.Retain(4).Insert("old ").Delete(6).Insert("keep")
+
+	a1, b1, err := ot.Transform(a, b)
+	assert.NoError(t, err)
+
+	ab := apply(t, apply(t, doc, a), b1)
+	ba := apply(t, apply(t, doc, b), a1)
+	assert.Equal(t, ab, ba)
+	assert.Equal(t, "the dark old keep", ab)
+
+	c := ot.Op{}
+	c.Retain(3)
+	_, _, err = ot.Transform(a, c)
+	assert.Equal(t, ot.ErrLengthMismatch, err)
+}
+
+func TestTransform_Random(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		doc := randomString(rnd, rnd.Intn(20))
+		a := randomOp(rnd, doc)
+		b := randomOp(rnd, doc)
+
+		a1, b1, err := ot.Transform(a, b)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, apply(t, apply(t, doc, a), b1), apply(t, apply(t, doc, b), a1))
+	}
+}
+
+func TestDiff(t *testing.T) {
+	testCases := []struct {
+		a string
+		b string
+	}{
+		{"", ""},
+		{"", "hello"},
+		{"hello", ""},
+		{"hello world", "hello Barovia"},
+		{"aaa", "aaaa"},
+		{"Strahd von Zarovich", "Strahd Zarovich"},
+		{"ёжик", "ёлка"},
+	}
+
+	for _, tc := range testCases {
+		op := ot.Diff(tc.a, tc.b)
+		assert.Equal(t, tc.b, apply(t, tc.a, op))
+	}
+
+	assert.True(t, ot.Diff("same", "same").Noop())
+}
+
+func apply(t *testing.T, doc string, op ot.Op) string {
+	t.Helper()
+
+	doc, err := op.Apply(doc)
+	assert.NoError(t, err)
+
+	return doc
+}
+
+func randomString(rnd *rand.Rand, n int) string {
+	runes := make([]rune, n)
+	for i := range runes {
+		runes[i] = []rune("abcdé ")[rnd.Intn(6)]
+	}
+
+	return string(runes)
+}
+
+func randomOp(rnd *rand.Rand, doc string) ot.Op {
+	op := ot.Op{}
+	left := len([]rune(doc))
+	for left > 0 {
+		n := 1 + rnd.Intn(left)
+		switch rnd.Intn(3) {
+		case 0:
+			op.Retain(n)
+			left -= n
+		case 1:
+			op.Delete(n)
+			left -= n
+		default:
+			op.Insert(randomString(rnd, 1+rnd.Intn(4)))
+		}
+	}
+	if rnd.Intn(2) == 0 {
+		op.Insert(randomString(rnd, 1+rnd.Intn(4)))
+	}
+
+	return op
+}
@@ -0,0 +1,107 @@
+package realtime
+
+import (
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = pongWait * 9 / 10
+	maxMessageSize = 4096
+	sendBufferSize = 64
+)
+
+// Client is a single realtime connection of a campaign member.
+type Client struct {
+	id     string
+	hub    *Hub
+	conn   *websocket.Conn
+	member *model.Member
+	send   chan *Event
+
+	// dropped is set by the hub before closing send when the client can't
+	// keep up with the event rate.
+	dropped bool
+}
+
+func newClient(h *Hub, conn *websocket.Conn, m *model.Member) *Client {
+	return &Client{
+		id:     uuid.New().String(),
+		hub:    h,
+		conn:   conn,
+		member: m,
+		send:   make(chan *Event, sendBufferSize),
+	}
+}
+
+// readPump hands incoming messages to the hub, processes control messages
+// (pong, close) and detects dead peers through the read deadline.
+func (c *Client) readPump() {
+	defer func() {
+		c.hub.unregister(c, false)
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, msg, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		c.hub.received(c, msg)
+	}
+}
+
+// Events returns the channel of events delivered to the client. It is
+// closed when the client is unsubscribed or dropped for being too slow.
+func (c *Client) Events() <-chan *Event {
+	return c.send
+}
+
+// Dropped reports whether the hub disconnected the client for not keeping up.
+func (c *Client) Dropped() bool {
+	return c.dropped
+}
+
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case e, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+				if c.dropped {
+					msg = websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "slow consumer")
+				}
+				c.conn.WriteMessage(websocket.CloseMessage, msg)
+				return
+			}
+
+			if err := c.conn.WriteJSON(e); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
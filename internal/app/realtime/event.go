@@ -0,0 +1,114 @@
+package realtime
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+)
+
+const (
+	EventRollCreated = "roll.created"
+	EventMemberAdded = "member.added"
+	EventChatCreated = "chat.created"
+	EventChatUpdated = "chat.updated"
+	EventChatDeleted = "chat.deleted"
+
+	EventCharacterCreated = "character.created"
+	EventCharacterUpdated = "character.updated"
+	EventCharacterDeleted = "character.deleted"
+
+	EventSceneCreated = "scene.created"
+	EventSceneUpdated = "scene.updated"
+	EventSceneDeleted = "scene.deleted"
+	EventTokenCreated = "token.created"
+	EventTokenUpdated = "token.updated"
+	EventTokenMoved   = "token.moved"
+	EventTokenDeleted = "token.deleted"
+	EventWallCreated  = "wall.created"
+	EventWallUpdated  = "wall.updated"
+	EventWallDeleted  = "wall.deleted"
+	EventLightCreated = "light.created"
+	EventLightUpdated = "light.updated"
+	EventLightDeleted = "light.deleted"
+
+	EventCombatCreated = "combat.created"
+	EventCombatUpdated = "combat.updated"
+	EventCombatDeleted = "combat.deleted"
+
+	EventJournalCreated       = "journal.created"
+	EventJournalUpdated       = "journal.updated"
+	EventJournalDeleted       = "journal.deleted"
+	EventJournalFolderCreated = "journal_folder.created"
+	EventJournalFolderUpdated = "journal_folder.updated"
+	EventJournalFolderDeleted = "journal_folder.deleted"
+	// EventHandoutShown asks clients to pop an entry up on the screen of
+	// the players it was shown to.
+	EventHandoutShown = "handout.shown"
+
+	// EventPackSubscribed and EventPackUnsubscribed tell clients that the
+	// campaign's compendium changed with its homebrew packs.
+	EventPackSubscribed   = "pack.subscribed"
+	EventPackUnsubscribed = "pack.unsubscribed"
+
+	// EventVisionChanged tells clients that what they see of a scene may have
+	// changed and they should fetch their vision again.
+	EventVisionChanged = "vision.changed"
+
+	// EventStreamReset tells a resuming client that the events it missed are
+	// no longer available and it has to reload the campaign state.
+	EventStreamReset = "stream.reset"
+)
+
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	CampaignID uuid.UUID       `json:"campaign_id"`
+	UserID     *uuid.UUID      `json:"user_id,omitempty"`
+	Payload    json.RawMessage `json:"payload"`
+	Audience   *Audience       `json:"audience,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+
+	// Ephemeral events only reach the clients connected to this instance
+	// and are left out of the log, so streams can't resume from them.
+	Ephemeral bool `json:"-"`
+}
+
+// Audience restricts an event to GMs and/or specific users. Events without
+// an audience are delivered to every member of the campaign.
+type Audience struct {
+	GMOnly  bool        `json:"gm_only,omitempty"`
+	UserIDs []uuid.UUID `json:"user_ids,omitempty"`
+}
+
+func NewEvent(typ string, campaignID uuid.UUID, userID *uuid.UUID, payload interface{}) (*Event, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		ID:         uuid.New().String(),
+		Type:       typ,
+		CampaignID: campaignID,
+		UserID:     userID,
+		Payload:    b,
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+// VisibleTo reports whether the member is allowed to receive the event.
+func (e *Event) VisibleTo(m *model.Member) bool {
+	if e.Audience == nil {
+		return true
+	}
+
+	for _, id := range e.Audience.UserIDs {
+		if id == m.UserID {
+			return true
+		}
+	}
+
+	return e.Audience.GMOnly && m.IsGM()
+}
@@ -0,0 +1,55 @@
+package realtime_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvent_VisibleTo(t *testing.T) {
+	gm := &model.Member{UserID: uuid.New(), Role: model.RoleGM}
+	alice := &model.Member{UserID: uuid.New(), Role: model.RolePlayer}
+	bob := &model.Member{UserID: uuid.New(), Role: model.RolePlayer}
+
+	testCases := []struct {
+		name     string
+		audience *realtime.Audience
+		visible  map[*model.Member]bool
+	}{
+		{
+			name:     "everyone",
+			audience: nil,
+			visible:  map[*model.Member]bool{gm: true, alice: true, bob: true},
+		},
+		{
+			name:     "gm only",
+			audience: &realtime.Audience{GMOnly: true},
+			visible:  map[*model.Member]bool{gm: true, alice: false, bob: false},
+		},
+		{
+			name:     "whisper",
+			audience: &realtime.Audience{UserIDs: []uuid.UUID{alice.UserID, bob.UserID}},
+			visible:  map[*model.Member]bool{gm: false, alice: true, bob: true},
+		},
+		{
+			name:     "gm roll",
+			audience: &realtime.Audience{GMOnly: true, UserIDs: []uuid.UUID{alice.UserID}},
+			visible:  map[*model.Member]bool{gm: true, alice: true, bob: false},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e, err := realtime.NewEvent(realtime.EventRollCreated, uuid.New(), nil, nil)
+			assert.NoError(t, err)
+			e.Audience = tc.audience
+
+			for m, visible := range tc.visible {
+				assert.Equal(t, visible, e.VisibleTo(m))
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package realtime
+
+import (
+	"sync"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+// Hub keeps track of the realtime connections of every campaign and fans
+// events out to them.
+type Hub struct {
+	mu       sync.Mutex
+	rooms    map[uuid.UUID]map[*Client]struct{}
+	log      *eventLog
+	observer observer
+	logger   *logrus.Logger
+}
+
+// observer is told about clients coming and going and about the messages
+// they send. Its methods are never called with the hub lock held.
+type observer interface {
+	connected(*Client)
+	disconnected(*Client)
+	received(*Client, []byte)
+}
+
+func NewHub(logger *logrus.Logger) *Hub {
+	return &Hub{
+		rooms:  make(map[uuid.UUID]map[*Client]struct{}),
+		log:    newEventLog(eventLogSize),
+		logger: logger,
+	}
+}
+
+// Publish records the event in the campaign log, unless it is ephemeral,
+// and delivers it to every connected member allowed to see it. It never
+// blocks: clients that can't keep up are disconnected.
+func (h *Hub) Publish(e *Event) {
+	h.mu.Lock()
+	if !e.Ephemeral {
+		h.log.append(e)
+	}
+	dropped := []*Client{}
+	for c := range h.rooms[e.CampaignID] {
+		if !e.VisibleTo(c.member) {
+			continue
+		}
+
+		select {
+		case c.send <- e:
+		default:
+			h.logger.Warnf("dropping slow realtime client %s", c.member.UserID)
+			h.remove(c, true)
+			dropped = append(dropped, c)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, c := range dropped {
+		h.disconnected(c)
+	}
+}
+
+// Serve registers the connection in the campaign room of the member and
+// pumps events to it until either side closes the connection.
+func (h *Hub) Serve(conn *websocket.Conn, m *model.Member) {
+	c := newClient(h, conn, m)
+	h.register(c)
+
+	go c.writePump()
+	c.readPump()
+}
+
+// Subscribe registers a connection-less client, e.g. a Server-Sent Events
+// stream, and returns the events published after lastEventID that the member
+// may see. If lastEventID is no longer in the log, ok is false and the client
+// has to reload the campaign state instead of replaying.
+func (h *Hub) Subscribe(m *model.Member, lastEventID string) (c *Client, missed []*Event, ok bool) {
+	c = newClient(h, nil, m)
+	ok = true
+
+	h.mu.Lock()
+	h.add(c)
+	if lastEventID != "" {
+		var events []*Event
+		events, ok = h.log.since(m.CampaignID, lastEventID)
+		for _, e := range events {
+			if e.VisibleTo(m) {
+				missed = append(missed, e)
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	h.connected(c)
+
+	return c, missed, ok
+}
+
+// Unsubscribe removes a client registered with Subscribe.
+func (h *Hub) Unsubscribe(c *Client) {
+	h.unregister(c, false)
+}
+
+// Connected returns the members currently connected to the campaign.
+func (h *Hub) Connected(campaignID uuid.UUID) []*model.Member {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	members := []*model.Member{}
+	for c := range h.rooms[campaignID] {
+		members = append(members, c.member)
+	}
+
+	return members
+}
+
+func (h *Hub) register(c *Client) {
+	h.mu.Lock()
+	h.add(c)
+	h.mu.Unlock()
+
+	h.connected(c)
+}
+
+func (h *Hub) unregister(c *Client, dropped bool) {
+	h.mu.Lock()
+	removed := h.remove(c, dropped)
+	h.mu.Unlock()
+
+	if removed {
+		h.disconnected(c)
+	}
+}
+
+func (h *Hub) add(c *Client) {
+	room, ok := h.rooms[c.member.CampaignID]
+	if !ok {
+		room = make(map[*Client]struct{})
+		h.rooms[c.member.CampaignID] = room
+	}
+	room[c] = struct{}{}
+}
+
+func (h *Hub) remove(c *Client, dropped bool) bool {
+	room := h.rooms[c.member.CampaignID]
+	if _, ok := room[c]; !ok {
+		return false
+	}
+
+	delete(room, c)
+	c.dropped = dropped
+	close(c.send)
+	if len(room) == 0 {
+		delete(h.rooms, c.member.CampaignID)
+	}
+
+	return true
+}
+
+func (h *Hub) connected(c *Client) {
+	if h.observer != nil {
+		h.observer.connected(c)
+	}
+}
+
+func (h *Hub) disconnected(c *Client) {
+	if h.observer != nil {
+		h.observer.disconnected(c)
+	}
+}
+
+func (h *Hub) received(c *Client, msg []byte) {
+	if h.observer != nil {
+		h.observer.received(c, msg)
+	}
+}
@@ -0,0 +1,48 @@
+package realtime
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHub_PublishFiltersAudience(t *testing.T) {
+	h := NewHub(logrus.New())
+	campaignID := uuid.New()
+	gm := newClient(h, nil, &model.Member{CampaignID: campaignID, UserID: uuid.New(), Role: model.RoleGM})
+	player := newClient(h, nil, &model.Member{CampaignID: campaignID, UserID: uuid.New(), Role: model.RolePlayer})
+	outsider := newClient(h, nil, &model.Member{CampaignID: uuid.New(), UserID: uuid.New(), Role: model.RoleGM})
+	h.register(gm)
+	h.register(player)
+	h.register(outsider)
+
+	e, _ := NewEvent(EventRollCreated, campaignID, nil, nil)
+	h.Publish(e)
+
+	secret, _ := NewEvent(EventRollCreated, campaignID, nil, nil)
+	secret.Audience = &Audience{GMOnly: true}
+	h.Publish(secret)
+
+	assert.Len(t, gm.send, 2)
+	assert.Len(t, player.send, 1)
+	assert.Len(t, outsider.send, 0)
+	assert.Len(t, h.Connected(campaignID), 2)
+}
+
+func TestHub_PublishDropsSlowClients(t *testing.T) {
+	h := NewHub(logrus.New())
+	campaignID := uuid.New()
+	c := newClient(h, nil, &model.Member{CampaignID: campaignID, UserID: uuid.New(), Role: model.RolePlayer})
+	h.register(c)
+
+	for i := 0; i <= sendBufferSize; i++ {
+		e, _ := NewEvent(EventRollCreated, campaignID, nil, i)
+		h.Publish(e)
+	}
+
+	assert.True(t, c.dropped)
+	assert.Empty(t, h.Connected(campaignID))
+}
@@ -0,0 +1,40 @@
+package realtime
+
+import "github.com/google/uuid"
+
+// eventLogSize is how many recent events are kept per campaign for clients
+// resuming a stream with Last-Event-ID.
+const eventLogSize = 256
+
+type eventLog struct {
+	size   int
+	events map[uuid.UUID][]*Event
+}
+
+func newEventLog(size int) *eventLog {
+	return &eventLog{
+		size:   size,
+		events: make(map[uuid.UUID][]*Event),
+	}
+}
+
+func (l *eventLog) append(e *Event) {
+	events := append(l.events[e.CampaignID], e)
+	if len(events) > l.size {
+		events = append([]*Event(nil), events[len(events)-l.size:]...)
+	}
+	l.events[e.CampaignID] = events
+}
+
+// since returns the events logged after the one with the given id. ok is
+// false when the id is unknown, in which case nothing can be replayed.
+func (l *eventLog) since(campaignID uuid.UUID, id string) (events []*Event, ok bool) {
+	logged := l.events[campaignID]
+	for i := len(logged) - 1; i >= 0; i-- {
+		if logged[i].ID == id {
+			return append([]*Event(nil), logged[i+1:]...), true
+		}
+	}
+
+	return nil, false
+}
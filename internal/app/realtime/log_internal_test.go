@@ -0,0 +1,65 @@
+package realtime
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventLog_Since(t *testing.T) {
+	l := newEventLog(3)
+	campaignID := uuid.New()
+
+	events := []*Event{}
+	for i := 0; i < 5; i++ {
+		e, _ := NewEvent(EventRollCreated, campaignID, nil, i)
+		l.append(e)
+		events = append(events, e)
+	}
+
+	missed, ok := l.since(campaignID, events[2].ID)
+	assert.True(t, ok)
+	assert.Equal(t, events[3:], missed)
+
+	missed, ok = l.since(campaignID, events[4].ID)
+	assert.True(t, ok)
+	assert.Empty(t, missed)
+
+	_, ok = l.since(campaignID, events[1].ID)
+	assert.False(t, ok)
+
+	_, ok = l.since(uuid.New(), events[4].ID)
+	assert.False(t, ok)
+}
+
+func TestHub_Subscribe(t *testing.T) {
+	h := NewHub(logrus.New())
+	campaignID := uuid.New()
+	player := &model.Member{CampaignID: campaignID, UserID: uuid.New(), Role: model.RolePlayer}
+
+	first, _ := NewEvent(EventRollCreated, campaignID, nil, 1)
+	h.Publish(first)
+	secret, _ := NewEvent(EventRollCreated, campaignID, nil, 2)
+	secret.Audience = &Audience{GMOnly: true}
+	h.Publish(secret)
+	last, _ := NewEvent(EventRollCreated, campaignID, nil, 3)
+	h.Publish(last)
+
+	c, missed, ok := h.Subscribe(player, first.ID)
+	assert.True(t, ok)
+	assert.Equal(t, []*Event{last}, missed)
+
+	next, _ := NewEvent(EventRollCreated, campaignID, nil, 4)
+	h.Publish(next)
+	assert.Equal(t, next, <-c.Events())
+
+	h.Unsubscribe(c)
+	_, open := <-c.Events()
+	assert.False(t, open)
+
+	_, _, ok = h.Subscribe(player, "unknown")
+	assert.False(t, ok)
+}
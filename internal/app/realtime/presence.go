@@ -0,0 +1,387 @@
+package realtime
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	StatusOnline = "online"
+	StatusIdle   = "idle"
+	StatusAway   = "away"
+
+	EventPresenceJoined  = "presence.joined"
+	EventPresenceUpdated = "presence.updated"
+	EventPresenceLeft    = "presence.left"
+
+	// EventPresenceHeartbeat carries connection states between instances.
+	// It is consumed by Presence and never delivered to clients.
+	EventPresenceHeartbeat = "presence.heartbeat"
+
+	// messagePresence is what clients send to report their status and the
+	// scene they are looking at.
+	messagePresence = "presence.update"
+
+	heartbeatInterval = 15 * time.Second
+	heartbeatTTL      = 3 * heartbeatInterval
+	idleAfter         = 5 * time.Minute
+)
+
+// UserPresence is the presence of a member, merged over all of their
+// connections to the campaign on every instance.
+type UserPresence struct {
+	CampaignID   uuid.UUID  `json:"campaign_id"`
+	UserID       uuid.UUID  `json:"user_id"`
+	Status       string     `json:"status"`
+	SceneID      *uuid.UUID `json:"scene_id"`
+	LastActivity time.Time  `json:"last_activity"`
+	Connections  int        `json:"connections"`
+}
+
+type connectionState struct {
+	ID           string     `json:"id"`
+	CampaignID   uuid.UUID  `json:"campaign_id"`
+	UserID       uuid.UUID  `json:"user_id"`
+	Status       string     `json:"status"`
+	SceneID      *uuid.UUID `json:"scene_id,omitempty"`
+	LastActivity time.Time  `json:"last_activity"`
+	Gone         bool       `json:"gone,omitempty"`
+
+	expires time.Time
+}
+
+// Scenes tells which campaign scenes belong to, so that members only report
+// looking at scenes of their campaign.
+type Scenes interface {
+	InCampaign(campaignID, sceneID uuid.UUID) bool
+}
+
+// Presence tracks who is connected to each campaign. Every instance
+// heartbeats the state of its own connections through the PubSub and merges
+// the heartbeats of all instances, so connections of a crashed instance
+// expire after heartbeatTTL.
+type Presence struct {
+	mu     sync.Mutex
+	hub    *Hub
+	scenes Scenes
+	pubsub PubSub
+	logger *logrus.Logger
+	local  map[*Client]*connectionState
+	states map[uuid.UUID]map[string]*connectionState
+	views  map[uuid.UUID]map[uuid.UUID]*UserPresence
+	done   chan struct{}
+	now    func() time.Time
+}
+
+func NewPresence(hub *Hub, scenes Scenes, pubsub PubSub, logger *logrus.Logger) *Presence {
+	p := &Presence{
+		hub:    hub,
+		scenes: scenes,
+		pubsub: pubsub,
+		logger: logger,
+		local:  make(map[*Client]*connectionState),
+		states: make(map[uuid.UUID]map[string]*connectionState),
+		views:  make(map[uuid.UUID]map[uuid.UUID]*UserPresence),
+		done:   make(chan struct{}),
+		now:    time.Now,
+	}
+	hub.observer = p
+	go p.run()
+
+	return p
+}
+
+// Receive applies presence heartbeats from the PubSub. It returns false for
+// every other event so the caller can hand it to the hub.
+func (p *Presence) Receive(e *Event) bool {
+	if e.Type != EventPresenceHeartbeat {
+		return false
+	}
+
+	states := []*connectionState{}
+	if err := json.Unmarshal(e.Payload, &states); err != nil {
+		p.logger.Error(err.Error())
+		return true
+	}
+
+	p.mu.Lock()
+	now := p.now()
+	for _, s := range states {
+		room, ok := p.states[s.CampaignID]
+		if !ok {
+			room = make(map[string]*connectionState)
+			p.states[s.CampaignID] = room
+		}
+
+		if s.Gone {
+			delete(room, s.ID)
+			continue
+		}
+
+		s.expires = now.Add(heartbeatTTL)
+		room[s.ID] = s
+	}
+	events := p.diff(e.CampaignID)
+	p.mu.Unlock()
+
+	p.publishLocal(events)
+
+	return true
+}
+
+// List returns the presence of every member connected to the campaign.
+func (p *Presence) List(campaignID uuid.UUID) []*UserPresence {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	list := []*UserPresence{}
+	for _, up := range p.snapshot(campaignID) {
+		list = append(list, up)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].LastActivity.After(list[j].LastActivity)
+	})
+
+	return list
+}
+
+func (p *Presence) Close() {
+	close(p.done)
+}
+
+func (p *Presence) connected(c *Client) {
+	s := &connectionState{
+		ID:           c.id,
+		CampaignID:   c.member.CampaignID,
+		UserID:       c.member.UserID,
+		Status:       StatusOnline,
+		LastActivity: p.now(),
+	}
+
+	p.mu.Lock()
+	p.local[c] = s
+	p.mu.Unlock()
+
+	p.heartbeat(c.member.CampaignID, []*connectionState{s})
+}
+
+func (p *Presence) disconnected(c *Client) {
+	p.mu.Lock()
+	s, ok := p.local[c]
+	delete(p.local, c)
+	p.mu.Unlock()
+
+	if ok {
+		gone := *s
+		gone.Gone = true
+		p.heartbeat(c.member.CampaignID, []*connectionState{&gone})
+	}
+}
+
+func (p *Presence) received(c *Client, msg []byte) {
+	m := &struct {
+		Type    string     `json:"type"`
+		Status  string     `json:"status"`
+		SceneID *uuid.UUID `json:"scene_id"`
+	}{}
+	if err := json.Unmarshal(msg, m); err != nil {
+		return
+	}
+
+	validScene := m.Type == messagePresence && (m.SceneID == nil || p.scenes.InCampaign(c.member.CampaignID, *m.SceneID))
+
+	p.mu.Lock()
+	s, ok := p.local[c]
+	if !ok {
+		p.mu.Unlock()
+		return
+	}
+
+	now := p.now()
+	changed := now.Sub(s.LastActivity) > idleAfter
+	s.LastActivity = now
+	if m.Type == messagePresence {
+		switch m.Status {
+		case StatusOnline, StatusIdle, StatusAway:
+			changed = changed || s.Status != m.Status
+			s.Status = m.Status
+		}
+		if validScene && !sameScene(s.SceneID, m.SceneID) {
+			s.SceneID = m.SceneID
+			changed = true
+		}
+	}
+	update := *s
+	p.mu.Unlock()
+
+	// Any other activity only keeps the member from going idle, which the
+	// regular heartbeats are frequent enough to tell.
+	if changed {
+		p.heartbeat(c.member.CampaignID, []*connectionState{&update})
+	}
+}
+
+func (p *Presence) heartbeat(campaignID uuid.UUID, states []*connectionState) {
+	e, err := NewEvent(EventPresenceHeartbeat, campaignID, nil, states)
+	if err != nil {
+		p.logger.Error(err.Error())
+		return
+	}
+
+	if err := p.pubsub.Publish(e); err != nil {
+		p.logger.Error(err.Error())
+	}
+}
+
+func (p *Presence) run() {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.beat()
+			p.expire()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// beat re-announces every local connection, one event per campaign.
+func (p *Presence) beat() {
+	p.mu.Lock()
+	byCampaign := map[uuid.UUID][]*connectionState{}
+	for _, s := range p.local {
+		state := *s
+		byCampaign[s.CampaignID] = append(byCampaign[s.CampaignID], &state)
+	}
+	p.mu.Unlock()
+
+	for campaignID, states := range byCampaign {
+		p.heartbeat(campaignID, states)
+	}
+}
+
+// expire forgets connections whose instance stopped heartbeating and
+// reports members that went idle.
+func (p *Presence) expire() {
+	now := p.now()
+	events := []*Event{}
+
+	p.mu.Lock()
+	for campaignID, room := range p.states {
+		for id, s := range room {
+			if now.After(s.expires) {
+				delete(room, id)
+			}
+		}
+		events = append(events, p.diff(campaignID)...)
+		if len(room) == 0 {
+			delete(p.states, campaignID)
+			delete(p.views, campaignID)
+		}
+	}
+	p.mu.Unlock()
+
+	p.publishLocal(events)
+}
+
+// snapshot merges the connection states of the campaign per user.
+func (p *Presence) snapshot(campaignID uuid.UUID) map[uuid.UUID]*UserPresence {
+	users := map[uuid.UUID]*UserPresence{}
+	now := p.now()
+	for _, s := range p.states[campaignID] {
+		status := s.Status
+		if status == StatusOnline && now.Sub(s.LastActivity) > idleAfter {
+			status = StatusIdle
+		}
+
+		up, ok := users[s.UserID]
+		if !ok {
+			up = &UserPresence{CampaignID: campaignID, UserID: s.UserID, Status: StatusAway}
+			users[s.UserID] = up
+		}
+
+		up.Connections++
+		if statusRank(status) > statusRank(up.Status) {
+			up.Status = status
+		}
+		if s.LastActivity.After(up.LastActivity) {
+			up.LastActivity = s.LastActivity
+			up.SceneID = s.SceneID
+		}
+	}
+
+	return users
+}
+
+// diff compares the current presence of the campaign against the one
+// reported last time and returns the events describing the changes.
+func (p *Presence) diff(campaignID uuid.UUID) []*Event {
+	before := p.views[campaignID]
+	after := p.snapshot(campaignID)
+	p.views[campaignID] = after
+
+	events := []*Event{}
+	add := func(typ string, up *UserPresence) {
+		e, err := NewEvent(typ, campaignID, &up.UserID, up)
+		if err != nil {
+			p.logger.Error(err.Error())
+			return
+		}
+		events = append(events, e)
+	}
+
+	for id, up := range after {
+		old, ok := before[id]
+		switch {
+		case !ok:
+			add(EventPresenceJoined, up)
+		case old.Status != up.Status || !sameScene(old.SceneID, up.SceneID):
+			add(EventPresenceUpdated, up)
+		}
+	}
+
+	for id, up := range before {
+		if _, ok := after[id]; !ok {
+			add(EventPresenceLeft, up)
+		}
+	}
+
+	return events
+}
+
+// publishLocal hands presence changes to the local hub only: every instance
+// computes them from the same heartbeats and notifies its own clients. They
+// stay out of the log, whose ids have to be the same on every instance.
+func (p *Presence) publishLocal(events []*Event) {
+	for _, e := range events {
+		e.Ephemeral = true
+		p.hub.Publish(e)
+	}
+}
+
+func statusRank(status string) int {
+	switch status {
+	case StatusOnline:
+		return 2
+	case StatusIdle:
+		return 1
+	}
+
+	return 0
+}
+
+func sameScene(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
@@ -0,0 +1,221 @@
+package realtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type testInstance struct {
+	hub      *Hub
+	presence *Presence
+	pubsub   PubSub
+	scenes   testScenes
+}
+
+// testScenes maps scenes to the campaigns they belong to.
+type testScenes map[uuid.UUID]uuid.UUID
+
+func (s testScenes) InCampaign(campaignID, sceneID uuid.UUID) bool {
+	id, ok := s[sceneID]
+
+	return ok && id == campaignID
+}
+
+func newTestInstances(t *testing.T, n int) []*testInstance {
+	pubsub := NewLocalPubSub()
+	scenes := testScenes{}
+	instances := []*testInstance{}
+	for i := 0; i < n; i++ {
+		hub := NewHub(logrus.New())
+		p := NewPresence(hub, scenes, pubsub, logrus.New())
+		t.Cleanup(p.Close)
+		instances = append(instances, &testInstance{hub, p, pubsub, scenes})
+	}
+
+	pubsub.Subscribe(func(e *Event) {
+		for _, i := range instances {
+			if !i.presence.Receive(e) {
+				i.hub.Publish(e)
+			}
+		}
+	})
+
+	return instances
+}
+
+func nextEvent(t *testing.T, c *Client, typ string) *Event {
+	t.Helper()
+
+	for {
+		select {
+		case e := <-c.Events():
+			if e.Type == typ {
+				return e
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s not received", typ)
+		}
+	}
+}
+
+func TestPresence_AcrossInstances(t *testing.T) {
+	instances := newTestInstances(t, 2)
+	a, b := instances[0], instances[1]
+	campaignID := uuid.New()
+	sceneID := uuid.New()
+	a.scenes[sceneID] = campaignID
+	gm := &model.Member{CampaignID: campaignID, UserID: uuid.New(), Role: model.RoleGM}
+	player := &model.Member{CampaignID: campaignID, UserID: uuid.New(), Role: model.RolePlayer}
+
+	watcher, _, _ := b.hub.Subscribe(gm, "")
+	c, _, _ := a.hub.Subscribe(player, "")
+
+	e := nextEvent(t, watcher, EventPresenceJoined)
+	for *e.UserID != player.UserID {
+		e = nextEvent(t, watcher, EventPresenceJoined)
+	}
+	assert.Len(t, b.presence.List(campaignID), 2)
+
+	a.hub.received(c, []byte(`{"type":"presence.update","status":"away","scene_id":"`+sceneID.String()+`"}`))
+	nextEvent(t, watcher, EventPresenceUpdated)
+	for _, up := range b.presence.List(campaignID) {
+		if up.UserID == player.UserID {
+			assert.Equal(t, StatusAway, up.Status)
+			assert.Equal(t, sceneID, *up.SceneID)
+		}
+	}
+
+	a.hub.Unsubscribe(c)
+	e = nextEvent(t, watcher, EventPresenceLeft)
+	assert.Equal(t, player.UserID, *e.UserID)
+	assert.Len(t, b.presence.List(campaignID), 1)
+}
+
+func TestPresence_MergesConnections(t *testing.T) {
+	i := newTestInstances(t, 1)[0]
+	campaignID := uuid.New()
+	player := &model.Member{CampaignID: campaignID, UserID: uuid.New(), Role: model.RolePlayer}
+
+	first, _, _ := i.hub.Subscribe(player, "")
+	second, _, _ := i.hub.Subscribe(player, "")
+	i.hub.received(first, []byte(`{"type":"presence.update","status":"away"}`))
+
+	list := i.presence.List(campaignID)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, 2, list[0].Connections)
+		assert.Equal(t, StatusOnline, list[0].Status)
+	}
+
+	i.hub.Unsubscribe(second)
+	assert.Equal(t, StatusAway, i.presence.List(campaignID)[0].Status)
+}
+
+func TestPresence_ReceivedChanges(t *testing.T) {
+	i := newTestInstances(t, 1)[0]
+	now := time.Now()
+	i.presence.now = func() time.Time { return now }
+
+	campaignID := uuid.New()
+	sceneID, otherSceneID := uuid.New(), uuid.New()
+	i.scenes[sceneID] = campaignID
+	i.scenes[otherSceneID] = uuid.New()
+	player := &model.Member{CampaignID: campaignID, UserID: uuid.New(), Role: model.RolePlayer}
+	c, _, _ := i.hub.Subscribe(player, "")
+
+	heartbeats := 0
+	i.pubsub.Subscribe(func(e *Event) {
+		if e.Type == EventPresenceHeartbeat {
+			heartbeats++
+		}
+	})
+
+	for n := 0; n < 10; n++ {
+		i.hub.received(c, []byte(`{"type":"cursor","x":1}`))
+		i.hub.received(c, []byte(`{"type":"presence.update","status":"online"}`))
+	}
+	assert.Equal(t, 0, heartbeats)
+
+	i.hub.received(c, []byte(`{"type":"presence.update","status":"online","scene_id":"`+sceneID.String()+`"}`))
+	assert.Equal(t, 1, heartbeats)
+	i.hub.received(c, []byte(`{"type":"presence.update","status":"online","scene_id":"`+otherSceneID.String()+`"}`))
+	assert.Equal(t, 1, heartbeats)
+	assert.Equal(t, sceneID, *i.presence.List(campaignID)[0].SceneID)
+
+	now = now.Add(idleAfter + time.Second)
+	assert.Equal(t, StatusIdle, i.presence.List(campaignID)[0].Status)
+	i.hub.received(c, []byte(`{"type":"cursor","x":1}`))
+	assert.Equal(t, 2, heartbeats)
+	assert.Equal(t, StatusOnline, i.presence.List(campaignID)[0].Status)
+}
+
+func TestPresence_Expire(t *testing.T) {
+	i := newTestInstances(t, 1)[0]
+	now := time.Now()
+	i.presence.now = func() time.Time { return now }
+
+	campaignID := uuid.New()
+	gm := &model.Member{CampaignID: campaignID, UserID: uuid.New(), Role: model.RoleGM}
+	watcher, _, _ := i.hub.Subscribe(gm, "")
+
+	remote := &connectionState{
+		ID:           "remote",
+		CampaignID:   campaignID,
+		UserID:       uuid.New(),
+		Status:       StatusOnline,
+		LastActivity: now.Add(-idleAfter - time.Second),
+	}
+	e, _ := NewEvent(EventPresenceHeartbeat, campaignID, nil, []*connectionState{remote})
+	assert.True(t, i.presence.Receive(e))
+
+	list := i.presence.List(campaignID)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, gm.UserID, list[0].UserID)
+		assert.Equal(t, StatusOnline, list[0].Status)
+		assert.Equal(t, remote.UserID, list[1].UserID)
+		assert.Equal(t, StatusIdle, list[1].Status)
+	}
+
+	now = now.Add(heartbeatInterval)
+	i.presence.beat()
+	now = now.Add(heartbeatTTL - heartbeatInterval + time.Second)
+	i.presence.expire()
+
+	e = nextEvent(t, watcher, EventPresenceLeft)
+	assert.Equal(t, remote.UserID, *e.UserID)
+	list = i.presence.List(campaignID)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, gm.UserID, list[0].UserID)
+	}
+}
+
+func TestPresence_ReceiveIgnoresOtherEvents(t *testing.T) {
+	i := newTestInstances(t, 1)[0]
+	e, _ := NewEvent(EventRollCreated, uuid.New(), nil, nil)
+	assert.False(t, i.presence.Receive(e))
+}
+
+func TestPresence_StaysOutOfLog(t *testing.T) {
+	i := newTestInstances(t, 1)[0]
+	campaignID := uuid.New()
+	gm := &model.Member{CampaignID: campaignID, UserID: uuid.New(), Role: model.RoleGM}
+	player := &model.Member{CampaignID: campaignID, UserID: uuid.New(), Role: model.RolePlayer}
+
+	first, _ := NewEvent(EventRollCreated, campaignID, nil, 1)
+	i.hub.Publish(first)
+	watcher, _, _ := i.hub.Subscribe(gm, "")
+	i.hub.Subscribe(player, "")
+	e := nextEvent(t, watcher, EventPresenceJoined)
+	assert.True(t, e.Ephemeral)
+
+	_, missed, ok := i.hub.Subscribe(gm, first.ID)
+	assert.True(t, ok)
+	assert.Empty(t, missed)
+
+	_, _, ok = i.hub.Subscribe(gm, e.ID)
+	assert.False(t, ok)
+}
@@ -0,0 +1,45 @@
+package realtime
+
+import "sync"
+
+// PubSub carries campaign events between apiserver instances, so that an
+// event produced on one instance reaches clients connected to another.
+type PubSub interface {
+	Publish(*Event) error
+	Subscribe(func(*Event))
+	Close() error
+}
+
+// LocalPubSub delivers events to subscribers of the same process. It is
+// enough for a single instance and for tests.
+type LocalPubSub struct {
+	mu       sync.RWMutex
+	handlers []func(*Event)
+}
+
+func NewLocalPubSub() *LocalPubSub {
+	return &LocalPubSub{}
+}
+
+func (p *LocalPubSub) Publish(e *Event) error {
+	p.mu.RLock()
+	handlers := p.handlers
+	p.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(e)
+	}
+
+	return nil
+}
+
+func (p *LocalPubSub) Subscribe(h func(*Event)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.handlers = append(p.handlers, h)
+}
+
+func (p *LocalPubSub) Close() error {
+	return nil
+}
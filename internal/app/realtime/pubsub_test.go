@@ -0,0 +1,27 @@
+package realtime_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalPubSub(t *testing.T) {
+	p := realtime.NewLocalPubSub()
+	defer p.Close()
+
+	received := [][]*realtime.Event{{}, {}}
+	for i := range received {
+		i := i
+		p.Subscribe(func(e *realtime.Event) {
+			received[i] = append(received[i], e)
+		})
+	}
+
+	e, _ := realtime.NewEvent(realtime.EventRollCreated, uuid.New(), nil, nil)
+	assert.NoError(t, p.Publish(e))
+	assert.Equal(t, []*realtime.Event{e}, received[0])
+	assert.Equal(t, []*realtime.Event{e}, received[1])
+}
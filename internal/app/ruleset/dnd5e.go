@@ -0,0 +1,96 @@
+package ruleset
+
+import (
+	"fmt"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/dice"
+	"github.com/bruhlord-s/virttable-api/internal/app/sheet"
+)
+
+func init() {
+	Register(&DnD5e{})
+}
+
+// DnD5e is the fifth edition of Dungeons & Dragons.
+type DnD5e struct{}
+
+var dnd5eConditions = []Condition{
+	{"Blinded", "Can't see. Attack rolls against the creature have advantage, its attack rolls have disadvantage."},
+	{"Charmed", "Can't attack the charmer, who has advantage on ability checks to interact socially with the creature."},
+	{"Deafened", "Can't hear and automatically fails any ability check that requires hearing."},
+	{"Exhaustion", "Measured in six levels, each adding to the effects of the previous ones."},
+	{"Frightened", "Disadvantage on ability checks and attack rolls while the source of fear is within sight; can't willingly move closer to it."},
+	{"Grappled", "Speed becomes 0."},
+	{"Incapacitated", "Can't take actions or reactions."},
+	{"Invisible", "Impossible to see without magic. Attack rolls against the creature have disadvantage, its attack rolls have advantage."},
+	{"Paralyzed", "Incapacitated and can't move or speak. Attacks against the creature have advantage and hits from within 5 feet are critical."},
+	{"Petrified", "Transformed into solid inanimate substance. Incapacitated, unaware and resistant to all damage."},
+	{"Poisoned", "Disadvantage on attack rolls and ability checks."},
+	{"Prone", "Can only crawl. Disadvantage on attack rolls; attacks from within 5 feet have advantage, others disadvantage."},
+	{"Restrained", "Speed becomes 0. Disadvantage on attack rolls and Dexterity saving throws; attacks against the creature have advantage."},
+	{"Stunned", "Incapacitated, can't move and can speak only falteringly. Attacks against the creature have advantage."},
+	{"Unconscious", "Incapacitated, can't move or speak, unaware and prone. Hits from within 5 feet are critical."},
+}
+
+func (d *DnD5e) Name() string {
+	return sheet.SystemDnD5e
+}
+
+func (d *DnD5e) ValidateSheet(data []byte) error {
+	return sheet.Validate(sheet.SystemDnD5e, data)
+}
+
+func (d *DnD5e) Derive(data []byte) (map[string]float64, error) {
+	return sheet.Derive(sheet.SystemDnD5e, data)
+}
+
+// Interpret reads rolls of a single d20, or two keeping one for advantage
+// and disadvantage: a natural 20 is a critical, a natural 1 a fumble.
+// Rolls with more d20s are left alone.
+func (d *DnD5e) Interpret(res *dice.Result) *Outcome {
+	e, err := dice.Parse(res.Expression)
+	if err != nil {
+		return nil
+	}
+
+	var d20 *dice.Term
+	for _, t := range e.Terms {
+		if t.Sides != 20 {
+			continue
+		}
+		if d20 != nil || t.Count > 2 || t.Count == 2 && t.Keep != 1 {
+			return nil
+		}
+		d20 = t
+	}
+	if d20 == nil {
+		return nil
+	}
+
+	o := &Outcome{}
+	for _, die := range res.Dice {
+		if die.Sides == 20 && !die.Dropped {
+			o.Critical = die.Value == 20
+			o.Fumble = die.Value == 1
+		}
+	}
+	if d20.Count == 2 {
+		o.Label = "advantage"
+		if d20.KeepLowest {
+			o.Label = "disadvantage"
+		}
+	}
+	if !o.Critical && !o.Fumble && o.Label == "" {
+		return nil
+	}
+
+	return o
+}
+
+func (d *DnD5e) Initiative(bonus int) string {
+	return fmt.Sprintf("1d20%+d", bonus)
+}
+
+func (d *DnD5e) Conditions() []Condition {
+	return dnd5eConditions
+}
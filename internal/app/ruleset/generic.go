@@ -0,0 +1,44 @@
+package ruleset
+
+import (
+	"fmt"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/dice"
+	"github.com/bruhlord-s/virttable-api/internal/app/sheet"
+)
+
+func init() {
+	Register(&Generic{System: sheet.SystemGeneric})
+	Register(&Generic{System: sheet.SystemPF2e})
+}
+
+// Generic plays by the character sheets of a system and nothing else: rolls
+// are plain totals, initiative is a d20 plus the bonus and there are no
+// predefined conditions.
+type Generic struct {
+	System string
+}
+
+func (g *Generic) Name() string {
+	return g.System
+}
+
+func (g *Generic) ValidateSheet(data []byte) error {
+	return sheet.Validate(g.System, data)
+}
+
+func (g *Generic) Derive(data []byte) (map[string]float64, error) {
+	return sheet.Derive(g.System, data)
+}
+
+func (g *Generic) Interpret(res *dice.Result) *Outcome {
+	return nil
+}
+
+func (g *Generic) Initiative(bonus int) string {
+	return fmt.Sprintf("1d20%+d", bonus)
+}
+
+func (g *Generic) Conditions() []Condition {
+	return []Condition{}
+}
@@ -0,0 +1,90 @@
+// Package ruleset holds the rules a campaign plays by. Game systems work
+// things out differently, so features that depend on the rules ask the
+// campaign's ruleset instead of assuming one system.
+package ruleset
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/dice"
+)
+
+var ErrUnknownRuleset = errors.New("unknown ruleset")
+
+// Ruleset is the rules of a game system. Rulesets are registered under
+// the name of their system, which campaigns select.
+type Ruleset interface {
+	Name() string
+	// ValidateSheet checks character sheet data against the system's schema.
+	ValidateSheet(data []byte) error
+	// Derive computes the fields of a character sheet that follow from
+	// others, like ability modifiers.
+	Derive(data []byte) (map[string]float64, error)
+	// Interpret reads the system's meaning into a roll. It returns nil when
+	// the roll means nothing special.
+	Interpret(res *dice.Result) *Outcome
+	// Initiative returns the dice expression a combatant with the bonus
+	// rolls for initiative.
+	Initiative(bonus int) string
+	// Conditions lists the conditions the system defines.
+	Conditions() []Condition
+}
+
+// Outcome is what a ruleset reads into a roll. Label names the result or
+// the way it was rolled, like "advantage" or "partial success".
+type Outcome struct {
+	Critical bool   `json:"critical,omitempty"`
+	Fumble   bool   `json:"fumble,omitempty"`
+	Label    string `json:"label,omitempty"`
+}
+
+type Condition struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Ruleset{}
+)
+
+// Register makes the ruleset available under its name. It panics if the
+// name is taken.
+func Register(r Ruleset) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := registry[r.Name()]; ok {
+		panic(fmt.Sprintf("ruleset: %s registered twice", r.Name()))
+	}
+	registry[r.Name()] = r
+}
+
+func Get(name string) (Ruleset, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	r, ok := registry[name]
+	if !ok {
+		return nil, ErrUnknownRuleset
+	}
+
+	return r, nil
+}
+
+// Names lists the registered rulesets.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,103 @@
+package ruleset_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/dice"
+	"github.com/bruhlord-s/virttable-api/internal/app/ruleset"
+	"github.com/bruhlord-s/virttable-api/internal/app/sheet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNames(t *testing.T) {
+	assert.Equal(t, []string{sheet.SystemDnD5e, sheet.SystemGeneric, sheet.SystemPF2e}, ruleset.Names())
+}
+
+func TestGet(t *testing.T) {
+	r, err := ruleset.Get(sheet.SystemDnD5e)
+	assert.NoError(t, err)
+	assert.Equal(t, sheet.SystemDnD5e, r.Name())
+
+	_, err = ruleset.Get("gurps")
+	assert.Equal(t, ruleset.ErrUnknownRuleset, err)
+}
+
+func TestRegister(t *testing.T) {
+	assert.Panics(t, func() {
+		ruleset.Register(&ruleset.Generic{System: sheet.SystemGeneric})
+	})
+}
+
+func TestDnD5e_Interpret(t *testing.T) {
+	testCases := []struct {
+		name     string
+		res      *dice.Result
+		expected *ruleset.Outcome
+	}{
+		{
+			name:     "critical",
+			res:      &dice.Result{Expression: "1d20+5", Dice: []dice.Die{{Sides: 20, Value: 20}}},
+			expected: &ruleset.Outcome{Critical: true},
+		},
+		{
+			name:     "fumble",
+			res:      &dice.Result{Expression: "1d20", Dice: []dice.Die{{Sides: 20, Value: 1}}},
+			expected: &ruleset.Outcome{Fumble: true},
+		},
+		{
+			name:     "plain",
+			res:      &dice.Result{Expression: "1d20+5", Dice: []dice.Die{{Sides: 20, Value: 12}}},
+			expected: nil,
+		},
+		{
+			name:     "advantage",
+			res:      &dice.Result{Expression: "2d20kh1", Dice: []dice.Die{{Sides: 20, Value: 20}, {Sides: 20, Value: 3, Dropped: true}}},
+			expected: &ruleset.Outcome{Critical: true, Label: "advantage"},
+		},
+		{
+			name:     "disadvantage",
+			res:      &dice.Result{Expression: "2d20kl1", Dice: []dice.Die{{Sides: 20, Value: 20, Dropped: true}, {Sides: 20, Value: 9}}},
+			expected: &ruleset.Outcome{Label: "disadvantage"},
+		},
+		{
+			name:     "damage",
+			res:      &dice.Result{Expression: "2d6+3", Dice: []dice.Die{{Sides: 6, Value: 6}, {Sides: 6, Value: 6}}},
+			expected: nil,
+		},
+		{
+			name:     "many d20s",
+			res:      &dice.Result{Expression: "3d20", Dice: []dice.Die{{Sides: 20, Value: 20}, {Sides: 20, Value: 1}, {Sides: 20, Value: 5}}},
+			expected: nil,
+		},
+	}
+
+	r, _ := ruleset.Get(sheet.SystemDnD5e)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, r.Interpret(tc.res))
+		})
+	}
+}
+
+func TestDnD5e_Derive(t *testing.T) {
+	r, _ := ruleset.Get(sheet.SystemDnD5e)
+	derived, err := r.Derive([]byte(`{"level": 5, "abilities": {"dex": 14}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, derived["initiative"])
+	assert.Equal(t, 3.0, derived["proficiency_bonus"])
+
+	assert.Error(t, r.ValidateSheet([]byte(`{"level": 21}`)))
+	assert.Len(t, r.Conditions(), 15)
+	assert.Equal(t, "1d20-1", r.Initiative(-1))
+}
+
+func TestGeneric(t *testing.T) {
+	r, _ := ruleset.Get(sheet.SystemGeneric)
+	assert.NoError(t, r.ValidateSheet([]byte(`{"sanity": 42}`)))
+	assert.Nil(t, r.Interpret(&dice.Result{Expression: "1d20", Dice: []dice.Die{{Sides: 20, Value: 20}}}))
+	assert.Empty(t, r.Conditions())
+	assert.Equal(t, "1d20+2", r.Initiative(2))
+
+	pf, _ := ruleset.Get(sheet.SystemPF2e)
+	assert.Error(t, pf.ValidateSheet([]byte(`{"perception": "grandmaster"}`)))
+}
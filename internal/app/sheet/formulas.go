@@ -0,0 +1,163 @@
+package sheet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/formula"
+)
+
+var abilities = []string{"str", "dex", "con", "int", "wis", "cha"}
+
+// defaultFormulas are the computed fields every sheet of a system has.
+var defaultFormulas = map[string]map[string]string{
+	SystemDnD5e: dnd5eFormulas(),
+	SystemPF2e:  pf2eFormulas(),
+}
+
+func dnd5eFormulas() map[string]string {
+	f := map[string]string{
+		"proficiency_bonus":  "ceil(default(level, 1) / 4) + 1",
+		"initiative":         "dex_mod",
+		"passive_perception": "10 + perception",
+	}
+
+	proficiency := func(field string) string {
+		return fmt.Sprintf(
+			"if(default(%[1]s, 'none') == 'expertise', 2 * proficiency_bonus, "+
+				"if(default(%[1]s, 'none') == 'proficient', proficiency_bonus, "+
+				"if(default(%[1]s, 'none') == 'half', floor(proficiency_bonus / 2), 0)))",
+			field,
+		)
+	}
+
+	for _, a := range abilities {
+		f[a+"_mod"] = fmt.Sprintf("floor((abilities.%s - 10) / 2)", a)
+		f[a+"_save"] = fmt.Sprintf("%s_mod + %s", a, proficiency("saves."+a))
+	}
+
+	skills := map[string]string{
+		"acrobatics": "dex", "animal_handling": "wis", "arcana": "int", "athletics": "str",
+		"deception": "cha", "history": "int", "insight": "wis", "intimidation": "cha",
+		"investigation": "int", "medicine": "wis", "nature": "int", "perception": "wis",
+		"performance": "cha", "persuasion": "cha", "religion": "int", "sleight_of_hand": "dex",
+		"stealth": "dex", "survival": "wis",
+	}
+	for skill, a := range skills {
+		f[skill] = fmt.Sprintf("%s_mod + %s", a, proficiency("skills."+skill))
+	}
+
+	return f
+}
+
+func pf2eFormulas() map[string]string {
+	f := map[string]string{
+		"perception_bonus": "wis_mod + " + pf2eProficiency("perception"),
+		"fortitude":        "con_mod + " + pf2eProficiency("saves.fortitude"),
+		"reflex":           "dex_mod + " + pf2eProficiency("saves.reflex"),
+		"will":             "wis_mod + " + pf2eProficiency("saves.will"),
+	}
+
+	for _, a := range abilities {
+		f[a+"_mod"] = "abilities." + a
+	}
+
+	skills := map[string]string{
+		"acrobatics": "dex", "arcana": "int", "athletics": "str", "crafting": "int",
+		"deception": "cha", "diplomacy": "cha", "intimidation": "cha", "medicine": "wis",
+		"nature": "wis", "occultism": "int", "performance": "cha", "religion": "wis",
+		"society": "int", "stealth": "dex", "survival": "wis", "thievery": "dex",
+	}
+	for skill, a := range skills {
+		f[skill] = fmt.Sprintf("%s_mod + %s", a, pf2eProficiency("skills."+skill))
+	}
+
+	return f
+}
+
+// pf2eProficiency is the proficiency bonus of a rank: nothing when
+// untrained, the level plus 2, 4, 6 or 8 otherwise.
+func pf2eProficiency(field string) string {
+	rank := fmt.Sprintf("default(%s, 'untrained')", field)
+
+	return fmt.Sprintf(
+		"if(%[1]s == 'untrained', 0, default(level, 1) + if(%[1]s == 'trained', 2, if(%[1]s == 'expert', 4, if(%[1]s == 'master', 6, 8))))",
+		rank,
+	)
+}
+
+// Derive computes the fields the system defines for every sheet and those
+// of the sheet's own "formulas" object, which may add fields or override
+// the defaults.
+func Derive(system string, data []byte) (map[string]float64, error) {
+	var sheet interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&sheet); err != nil {
+		return nil, err
+	}
+
+	formulas := map[string]string{}
+	for name, src := range defaultFormulas[system] {
+		formulas[name] = src
+	}
+	if m, ok := sheet.(map[string]interface{}); ok {
+		own, _ := m["formulas"].(map[string]interface{})
+		for name, src := range own {
+			if s, ok := src.(string); ok {
+				formulas[strings.ToLower(name)] = s
+			}
+		}
+	}
+
+	return formula.Compute(formulas, func(name string) (interface{}, bool) {
+		return Field(sheet, name)
+	})
+}
+
+// Field looks up a dotted path like "abilities.str" or "inventory.0.name" in
+// decoded sheet data. Object keys match case-insensitively. Only numbers,
+// strings and booleans are returned; numbers as float64.
+func Field(data interface{}, path string) (interface{}, bool) {
+	v := data
+	for _, key := range strings.Split(path, ".") {
+		switch c := v.(type) {
+		case map[string]interface{}:
+			next, ok := c[key]
+			if !ok {
+				for k, val := range c {
+					if strings.EqualFold(k, key) {
+						next, ok = val, true
+						break
+					}
+				}
+			}
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, false
+			}
+			v = c[i]
+		default:
+			return nil, false
+		}
+	}
+
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+
+		return f, err == nil
+	case float64, string, bool:
+		return v, true
+	}
+
+	return nil, false
+}
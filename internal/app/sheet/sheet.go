@@ -0,0 +1,98 @@
+// Package sheet validates character sheet data against the JSON Schema of
+// the campaign's game system.
+package sheet
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const (
+	SystemGeneric = "generic"
+	SystemDnD5e   = "dnd5e"
+	SystemPF2e    = "pf2e"
+)
+
+var (
+	ErrUnknownSystem = errors.New("unknown game system")
+	ErrInvalidSheet  = errors.New("invalid character sheet")
+)
+
+//go:embed schemas/*.json
+var files embed.FS
+
+var schemas = map[string]*jsonschema.Schema{}
+
+func init() {
+	entries, err := files.ReadDir("schemas")
+	if err != nil {
+		panic(err)
+	}
+
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft2020
+	for _, e := range entries {
+		b, err := files.ReadFile(path.Join("schemas", e.Name()))
+		if err != nil {
+			panic(err)
+		}
+		if err := c.AddResource(e.Name(), bytes.NewReader(b)); err != nil {
+			panic(err)
+		}
+
+		schemas[strings.TrimSuffix(e.Name(), ".json")] = c.MustCompile(e.Name())
+	}
+}
+
+// Systems lists the supported game systems.
+func Systems() []string {
+	systems := make([]string, 0, len(schemas))
+	for s := range schemas {
+		systems = append(systems, s)
+	}
+	sort.Strings(systems)
+
+	return systems
+}
+
+// Validate checks the sheet data against the schema of the system.
+func Validate(system string, data []byte) error {
+	schema, ok := schemas[system]
+	if !ok {
+		return ErrUnknownSystem
+	}
+
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSheet, err)
+	}
+
+	if err := schema.Validate(v); err != nil {
+		var ve *jsonschema.ValidationError
+		if errors.As(err, &ve) {
+			for len(ve.Causes) > 0 {
+				ve = ve.Causes[0]
+			}
+
+			return fmt.Errorf("%w: %s %s", ErrInvalidSheet, ve.InstanceLocation, ve.Message)
+		}
+
+		return err
+	}
+
+	if _, err := Derive(system, data); err != nil {
+		return fmt.Errorf("%w: formulas: %s", ErrInvalidSheet, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package sheet_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/sheet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystems(t *testing.T) {
+	assert.Equal(t, []string{sheet.SystemDnD5e, sheet.SystemGeneric, sheet.SystemPF2e}, sheet.Systems())
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		system string
+		data   string
+		err    error
+	}{
+		{"generic", sheet.SystemGeneric, `{"sanity": 42, "tags": ["eldritch"]}`, nil},
+		{"generic array", sheet.SystemGeneric, `[]`, sheet.ErrInvalidSheet},
+		{"dnd5e", sheet.SystemDnD5e, `{"class": "wizard", "level": 3, "abilities": {"int": 17}, "skills": {"arcana": "proficient"}}`, nil},
+		{"dnd5e homebrew field", sheet.SystemDnD5e, `{"level": 1, "corruption": 2}`, nil},
+		{"dnd5e level too high", sheet.SystemDnD5e, `{"level": 21}`, sheet.ErrInvalidSheet},
+		{"dnd5e unknown ability", sheet.SystemDnD5e, `{"abilities": {"luck": 10}}`, sheet.ErrInvalidSheet},
+		{"dnd5e item without name", sheet.SystemDnD5e, `{"inventory": [{"quantity": 1}]}`, sheet.ErrInvalidSheet},
+		{"pf2e", sheet.SystemPF2e, `{"ancestry": "elf", "abilities": {"dex": 4}, "saves": {"reflex": "expert"}}`, nil},
+		{"pf2e invalid rank", sheet.SystemPF2e, `{"perception": "grandmaster"}`, sheet.ErrInvalidSheet},
+		{"malformed json", sheet.SystemGeneric, `{`, sheet.ErrInvalidSheet},
+		{"unknown system", "gurps", `{}`, sheet.ErrUnknownSystem},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := sheet.Validate(tc.system, []byte(tc.data))
+			if tc.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestDerive(t *testing.T) {
+	data := []byte(`{
+		"level": 5,
+		"abilities": {"str": 16, "dex": 13, "wis": 8},
+		"skills": {"perception": "proficient", "stealth": "expertise"},
+		"formulas": {"attack": "str_mod + proficiency_bonus", "initiative": "dex_mod + 2"}
+	}`)
+
+	derived, err := sheet.Derive(sheet.SystemDnD5e, data)
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, derived["str_mod"])
+	assert.Equal(t, 3.0, derived["proficiency_bonus"])
+	assert.Equal(t, 6.0, derived["attack"])
+	assert.Equal(t, 3.0, derived["initiative"])
+	assert.Equal(t, 2.0, derived["perception"])
+	assert.Equal(t, 12.0, derived["passive_perception"])
+	assert.Equal(t, 7.0, derived["stealth"])
+	assert.Equal(t, 3.0, derived["athletics"])
+	assert.NotContains(t, derived, "con_mod")
+
+	derived, err = sheet.Derive(sheet.SystemPF2e, []byte(`{"level": 3, "abilities": {"dex": 4}, "saves": {"reflex": "expert"}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 11.0, derived["reflex"])
+	assert.Equal(t, 4.0, derived["thievery"])
+
+	_, err = sheet.Derive(sheet.SystemGeneric, []byte(`{"formulas": {"a": "b", "b": "a"}}`))
+	assert.Error(t, err)
+	assert.ErrorIs(t, sheet.Validate(sheet.SystemGeneric, []byte(`{"formulas": {"a": "floor("}}`)), sheet.ErrInvalidSheet)
+}
+
+func TestField(t *testing.T) {
+	data := map[string]interface{}{
+		"Abilities": map[string]interface{}{"str": 16.0},
+		"inventory": []interface{}{map[string]interface{}{"name": "rope"}},
+		"inspired":  true,
+	}
+
+	testCases := []struct {
+		path     string
+		expected interface{}
+		ok       bool
+	}{
+		{"abilities.str", 16.0, true},
+		{"inventory.0.name", "rope", true},
+		{"inspired", true, true},
+		{"inventory.1.name", nil, false},
+		{"abilities", nil, false},
+		{"abilities.str.value", nil, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			v, ok := sheet.Field(data, tc.path)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expected, v)
+		})
+	}
+}
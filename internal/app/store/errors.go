@@ -4,4 +4,7 @@ import "errors"
 
 var (
 	ErrRecordNotFound = errors.New("record not found")
-)
\ No newline at end of file
+	ErrAlreadyExists  = errors.New("record already exists")
+	ErrConflict       = errors.New("record has been changed")
+	ErrQuotaExceeded  = errors.New("storage quota exceeded")
+)
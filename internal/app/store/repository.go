@@ -1,6 +1,8 @@
 package store
 
 import (
+	"time"
+
 	"github.com/bruhlord-s/virttable-api/internal/app/model"
 	"github.com/google/uuid"
 )
@@ -10,4 +12,234 @@ type UserRepository interface {
 	Find(uuid.UUID)		   (*model.User, error)
 	FindByUsername(string) (*model.User, error)
 	FindByEmail(string)	   (*model.User, error)
-}
\ No newline at end of file
+}
+
+type CampaignRepository interface {
+	Create(*model.Campaign) error
+	Find(uuid.UUID) (*model.Campaign, error)
+	AddMember(*model.Member) error
+	FindMember(campaignID uuid.UUID, userID uuid.UUID) (*model.Member, error)
+	Members(uuid.UUID) ([]*model.Member, error)
+}
+
+type RollRepository interface {
+	Create(*model.Roll) error
+	FindAll(campaignID uuid.UUID, filter *model.RollFilter) ([]*model.Roll, error)
+	Stats(campaignID uuid.UUID, filter *model.RollFilter) ([]*model.RollStats, error)
+}
+
+type ChatMessageRepository interface {
+	Create(*model.ChatMessage) error
+	Find(uuid.UUID) (*model.ChatMessage, error)
+	Update(*model.ChatMessage) error
+	Delete(uuid.UUID) error
+	FindAll(campaignID uuid.UUID, viewer *model.Member, filter *model.ChatFilter) ([]*model.ChatMessage, error)
+}
+
+type CharacterRepository interface {
+	Create(*model.Character) error
+	Find(uuid.UUID) (*model.Character, error)
+	FindByName(campaignID uuid.UUID, name string) (*model.Character, error)
+	FindAll(campaignID uuid.UUID) ([]*model.Character, error)
+	Update(*model.Character) error
+	Delete(uuid.UUID) error
+}
+
+type CharacterRevisionRepository interface {
+	Create(*model.CharacterRevision) error
+	Find(characterID uuid.UUID, version int) (*model.CharacterRevision, error)
+	FindAll(characterID uuid.UUID) ([]*model.CharacterRevision, error)
+}
+
+type SceneRepository interface {
+	Create(*model.Scene) error
+	Find(uuid.UUID) (*model.Scene, error)
+	FindAll(campaignID uuid.UUID) ([]*model.Scene, error)
+	Update(*model.Scene) error
+	Delete(uuid.UUID) error
+}
+
+type TokenRepository interface {
+	Create(*model.Token) error
+	Find(uuid.UUID) (*model.Token, error)
+	// Lock finds the token and locks it until the transaction ends.
+	Lock(uuid.UUID) (*model.Token, error)
+	FindAll(sceneID uuid.UUID) ([]*model.Token, error)
+	Update(*model.Token) error
+	Delete(uuid.UUID) error
+	// Restore inserts the token as it was, ID and timestamps included.
+	Restore(*model.Token) error
+}
+
+type WallRepository interface {
+	Create(*model.Wall) error
+	Find(uuid.UUID) (*model.Wall, error)
+	// Lock finds the wall and locks it until the transaction ends.
+	Lock(uuid.UUID) (*model.Wall, error)
+	FindAll(sceneID uuid.UUID) ([]*model.Wall, error)
+	Update(*model.Wall) error
+	Delete(uuid.UUID) error
+	// Restore inserts the wall as it was, ID and timestamps included.
+	Restore(*model.Wall) error
+}
+
+type LightRepository interface {
+	Create(*model.Light) error
+	Find(uuid.UUID) (*model.Light, error)
+	// Lock finds the light and locks it until the transaction ends.
+	Lock(uuid.UUID) (*model.Light, error)
+	FindAll(sceneID uuid.UUID) ([]*model.Light, error)
+	Update(*model.Light) error
+	Delete(uuid.UUID) error
+	// Restore inserts the light as it was, ID and timestamps included.
+	Restore(*model.Light) error
+}
+
+type ExplorationRepository interface {
+	Find(sceneID uuid.UUID, userID uuid.UUID) (*model.Exploration, error)
+	Save(*model.Exploration) error
+	DeleteAll(sceneID uuid.UUID) error
+}
+
+type CombatRepository interface {
+	Create(*model.Combat) error
+	Find(uuid.UUID) (*model.Combat, error)
+	FindAll(campaignID uuid.UUID) ([]*model.Combat, error)
+	Update(*model.Combat) error
+	Delete(uuid.UUID) error
+}
+
+type CombatantRepository interface {
+	Create(*model.Combatant) error
+	Find(uuid.UUID) (*model.Combatant, error)
+	FindAll(combatID uuid.UUID) ([]*model.Combatant, error)
+	Update(*model.Combatant) error
+	Delete(uuid.UUID) error
+}
+
+type AssetRepository interface {
+	// Create adds the asset's size to the user's usage in the same
+	// transaction, unless they store its content already. It fails with
+	// ErrQuotaExceeded past the user's quota, or defaultQuota if they have
+	// none, less what their uploads reserve.
+	Create(a *model.Asset, defaultQuota int64) error
+	Find(uuid.UUID) (*model.Asset, error)
+	FindAll(userID uuid.UUID, filter *model.AssetFilter) ([]*model.Asset, error)
+	Update(*model.Asset) error
+	// Delete takes the asset's size off the user's usage once none of their
+	// other assets share its content.
+	Delete(uuid.UUID) error
+	// InUse reports whether any asset's content or thumbnail has the hash.
+	InUse(hash string) (bool, error)
+	// LockContent locks the content with the hashes until the transaction
+	// ends, so that storing content and referring to it can't interleave
+	// with deleting it. Empty hashes are skipped.
+	LockContent(hashes ...string) error
+}
+
+type FolderRepository interface {
+	Create(*model.Folder) error
+	Find(uuid.UUID) (*model.Folder, error)
+	FindAll(userID uuid.UUID) ([]*model.Folder, error)
+	Update(*model.Folder) error
+	Delete(uuid.UUID) error
+}
+
+type UploadRepository interface {
+	// Create reserves the upload's size in the user's usage, failing with
+	// ErrQuotaExceeded if it doesn't fit in their quota or defaultQuota.
+	Create(u *model.Upload, defaultQuota int64) error
+	Find(uuid.UUID) (*model.Upload, error)
+	// Lock finds the upload and locks it until the transaction ends, so
+	// its chunks are written one at a time.
+	Lock(uuid.UUID) (*model.Upload, error)
+	Update(*model.Upload) error
+	Delete(uuid.UUID) error
+	// DeleteStale deletes the uploads not updated since before and returns
+	// their IDs.
+	DeleteStale(before time.Time) ([]uuid.UUID, error)
+}
+
+type UsageRepository interface {
+	// Find returns the user's usage, empty if they have no assets yet.
+	Find(userID uuid.UUID) (*model.Usage, error)
+	// SetQuota overrides the user's quota, or restores the default with nil.
+	SetQuota(userID uuid.UUID, quota *int64) error
+	Campaigns(userID uuid.UUID) ([]*model.CampaignUsage, error)
+}
+
+type JournalRepository interface {
+	Create(*model.JournalEntry) error
+	Find(uuid.UUID) (*model.JournalEntry, error)
+	// FindAll returns the entries of the campaign visible to the viewer.
+	FindAll(campaignID uuid.UUID, viewer *model.Member, filter *model.JournalFilter) ([]*model.JournalEntry, error)
+	// Update changes everything but the body, which changes with UpdateBody.
+	Update(*model.JournalEntry) error
+	// UpdateBody stores the body as of the entry's Revision, failing with
+	// ErrConflict if a later revision is stored already.
+	UpdateBody(*model.JournalEntry) error
+	Delete(uuid.UUID) error
+}
+
+type JournalOperationRepository interface {
+	// Create fails with ErrAlreadyExists if the revision is taken.
+	Create(*model.JournalOperation) error
+	// FindAll returns the operations of the entry after the revision.
+	FindAll(entryID uuid.UUID, after int) ([]*model.JournalOperation, error)
+	// Prune deletes the operations of the entry up to the revision.
+	Prune(entryID uuid.UUID, through int) error
+}
+
+type JournalFolderRepository interface {
+	Create(*model.JournalFolder) error
+	Find(uuid.UUID) (*model.JournalFolder, error)
+	FindAll(campaignID uuid.UUID) ([]*model.JournalFolder, error)
+	Update(*model.JournalFolder) error
+	Delete(uuid.UUID) error
+}
+
+type CompendiumRepository interface {
+	// Save creates the entry, or replaces the entry of the same kind and
+	// name from its pack. Entries of homebrew packs are created with their
+	// version instead.
+	Save(*model.CompendiumEntry) error
+	Find(uuid.UUID) (*model.CompendiumEntry, error)
+	FindAll(*model.CompendiumFilter) ([]*model.CompendiumEntry, error)
+	// DeleteSource deletes the entries of the system imported from the pack,
+	// leaving homebrew packs alone.
+	DeleteSource(system string, source string) error
+}
+
+type PackRepository interface {
+	Create(*model.Pack) error
+	Find(uuid.UUID) (*model.Pack, error)
+	FindAll(*model.PackFilter) ([]*model.Pack, error)
+	// Update changes the name and the description.
+	Update(*model.Pack) error
+}
+
+type PackVersionRepository interface {
+	// Create publishes the version along with its entries, rendered, and
+	// makes it the latest of its pack. It fails with ErrConflict if the
+	// version is taken.
+	Create(*model.PackVersion, []*model.CompendiumEntry) error
+	// Find returns the version with its entries.
+	Find(packID uuid.UUID, version int) (*model.PackVersion, error)
+	// FindAll returns the versions of the pack without their entries.
+	FindAll(packID uuid.UUID) ([]*model.PackVersion, error)
+}
+
+type CampaignPackRepository interface {
+	// Save subscribes the campaign to the version of the pack, replacing the
+	// version it was subscribed to.
+	Save(*model.CampaignPack) error
+	Find(campaignID uuid.UUID, packID uuid.UUID) (*model.CampaignPack, error)
+	FindAll(campaignID uuid.UUID) ([]*model.CampaignPack, error)
+	Delete(campaignID uuid.UUID, packID uuid.UUID) error
+}
+
+type ActionRepository interface {
+	Create(*model.Action) error
+	// FindAll returns the campaign's actions, the latest first.
+	FindAll(campaignID uuid.UUID, filter *model.ActionFilter) ([]*model.Action, error)
+}
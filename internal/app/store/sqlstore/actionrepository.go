@@ -0,0 +1,117 @@
+package sqlstore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+const actionColumns = "id, seq, campaign_id, scene_id, user_id, kind, target, target_id, before, after, reverts_id, created_at"
+
+type ActionRepository struct {
+	store *Store
+}
+
+func (r *ActionRepository) Create(a *model.Action) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.store.db.QueryRow(
+		"INSERT INTO actions (campaign_id, scene_id, user_id, kind, target, target_id, before, after, reverts_id) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, seq, created_at",
+		a.CampaignID,
+		a.SceneID,
+		a.UserID,
+		a.Kind,
+		a.Target,
+		a.TargetID,
+		[]byte(a.Before),
+		[]byte(a.After),
+		a.RevertsID,
+	).Scan(&a.ID, &a.Seq, &a.CreatedAt); err != nil {
+		if isForeignKeyViolation(err) {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *ActionRepository) FindAll(campaignID uuid.UUID, filter *model.ActionFilter) ([]*model.Action, error) {
+	conds := []string{"campaign_id = $1"}
+	args := []interface{}{campaignID}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.SceneID != nil {
+		add("scene_id = $%d", *filter.SceneID)
+	}
+	if filter.UserID != nil {
+		add("user_id = $%d", *filter.UserID)
+	}
+	if !filter.From.IsZero() {
+		add("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("created_at < $%d", filter.To)
+	}
+
+	query := "SELECT " + actionColumns + " FROM actions WHERE " + strings.Join(conds, " AND ") + " ORDER BY seq DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.store.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actions := []*model.Action{}
+	for rows.Next() {
+		a := &model.Action{}
+		before, after := []byte{}, []byte{}
+		revertsID := uuid.NullUUID{}
+		if err := rows.Scan(
+			&a.ID,
+			&a.Seq,
+			&a.CampaignID,
+			&a.SceneID,
+			&a.UserID,
+			&a.Kind,
+			&a.Target,
+			&a.TargetID,
+			&before,
+			&after,
+			&revertsID,
+			&a.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if len(before) > 0 {
+			a.Before = before
+		}
+		if len(after) > 0 {
+			a.After = after
+		}
+		if revertsID.Valid {
+			a.RevertsID = &revertsID.UUID
+		}
+		actions = append(actions, a)
+	}
+
+	return actions, rows.Err()
+}
@@ -0,0 +1,75 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/sqlstore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("actions", "scenes", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	a := model.TestAction(t, sc, u)
+	assert.NoError(t, s.Action().Create(a))
+	assert.NotEqual(t, uuid.Nil, a.ID)
+
+	undo := a.Revert(u.ID, model.ActionUndo)
+	assert.NoError(t, s.Action().Create(undo))
+	assert.Greater(t, undo.Seq, a.Seq)
+
+	a = model.TestAction(t, sc, u)
+	a.After = nil
+	assert.Error(t, s.Action().Create(a))
+}
+
+func TestActionRepository_FindAll(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("actions", "scenes", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	other := model.TestUser(t)
+	other.Username, other.Email = "ireena", "ireena@example.org"
+	s.User().Create(other)
+
+	first := model.TestAction(t, sc, u)
+	s.Action().Create(first)
+	s.Action().Create(first.Revert(u.ID, model.ActionUndo))
+	s.Action().Create(model.TestAction(t, sc, other))
+
+	actions, err := s.Action().FindAll(c.ID, &model.ActionFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, actions, 3)
+	assert.Equal(t, other.ID, actions[0].UserID)
+	assert.Equal(t, first.ID, *actions[1].RevertsID)
+	assert.Nil(t, actions[1].After)
+	assert.JSONEq(t, string(first.After), string(actions[2].After))
+
+	actions, err = s.Action().FindAll(c.ID, &model.ActionFilter{UserID: &u.ID, Limit: 1, Offset: 1})
+	assert.NoError(t, err)
+	assert.Len(t, actions, 1)
+	assert.Equal(t, first.ID, actions[0].ID)
+
+	actions, err = s.Action().FindAll(c.ID, &model.ActionFilter{From: time.Now().Add(time.Hour)})
+	assert.NoError(t, err)
+	assert.Empty(t, actions)
+}
@@ -0,0 +1,269 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const assetColumns = "id, user_id, folder_id, campaign_id, name, hash, mime, size, width, height, thumbnail, tags, private, created_at, updated_at"
+
+type AssetRepository struct {
+	store *Store
+}
+
+func (r *AssetRepository) Create(a *model.Asset, defaultQuota int64) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+
+	if a.Tags == nil {
+		a.Tags = []string{}
+	}
+
+	tx, err := r.store.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Locking the user's usage row serializes their uploads, so concurrent
+	// ones can't both squeeze under the quota.
+	usage, err := lockUsage(tx, a.UserID)
+	if err != nil {
+		return err
+	}
+
+	stored := false
+	if err := tx.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM assets WHERE user_id=$1 AND hash=$2)",
+		a.UserID,
+		a.Hash,
+	).Scan(&stored); err != nil {
+		return err
+	}
+
+	if !stored {
+		if usage.Used+usage.Reserved+a.Size > usage.Limit(defaultQuota) {
+			return store.ErrQuotaExceeded
+		}
+
+		if _, err := tx.Exec(
+			"UPDATE storage_usage SET used=used+$2, updated_at=now() WHERE user_id=$1",
+			a.UserID,
+			a.Size,
+		); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.QueryRow(
+		"INSERT INTO assets (user_id, folder_id, campaign_id, name, hash, mime, size, width, height, thumbnail, tags, private) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id, created_at, updated_at",
+		a.UserID,
+		a.FolderID,
+		a.CampaignID,
+		a.Name,
+		a.Hash,
+		a.MIME,
+		a.Size,
+		a.Width,
+		a.Height,
+		a.Thumbnail,
+		pq.Array(a.Tags),
+		a.Private,
+	).Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *AssetRepository) Find(id uuid.UUID) (*model.Asset, error) {
+	a, err := scanAsset(r.store.db.QueryRow("SELECT "+assetColumns+" FROM assets WHERE id=$1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (r *AssetRepository) FindAll(userID uuid.UUID, filter *model.AssetFilter) ([]*model.Asset, error) {
+	where, args := "user_id=$1", []interface{}{userID}
+	if filter.FolderID != nil {
+		args = append(args, *filter.FolderID)
+		where += fmt.Sprintf(" AND folder_id=$%d", len(args))
+	}
+	if filter.CampaignID != nil {
+		args = append(args, *filter.CampaignID)
+		where += fmt.Sprintf(" AND campaign_id=$%d", len(args))
+	}
+	if filter.Tag != "" {
+		args = append(args, filter.Tag)
+		where += fmt.Sprintf(" AND $%d = ANY(tags)", len(args))
+	}
+	if filter.Hash != "" {
+		args = append(args, filter.Hash)
+		where += fmt.Sprintf(" AND hash=$%d", len(args))
+	}
+
+	rows, err := r.store.db.Query("SELECT "+assetColumns+" FROM assets WHERE "+where+" ORDER BY created_at, id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assets := []*model.Asset{}
+	for rows.Next() {
+		a, err := scanAsset(rows)
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, a)
+	}
+
+	return assets, rows.Err()
+}
+
+func (r *AssetRepository) Update(a *model.Asset) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+
+	if a.Tags == nil {
+		a.Tags = []string{}
+	}
+
+	if err := r.store.db.QueryRow(
+		"UPDATE assets SET folder_id=$2, campaign_id=$3, name=$4, tags=$5, private=$6, updated_at=now() WHERE id=$1 RETURNING updated_at",
+		a.ID,
+		a.FolderID,
+		a.CampaignID,
+		a.Name,
+		pq.Array(a.Tags),
+		a.Private,
+	).Scan(&a.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *AssetRepository) Delete(id uuid.UUID) error {
+	a, err := r.Find(id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.store.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockUsage(tx, a.UserID); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec("DELETE FROM assets WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE storage_usage SET used=GREATEST(used-$3, 0), updated_at=now() "+
+			"WHERE user_id=$1 AND NOT EXISTS (SELECT 1 FROM assets WHERE user_id=$1 AND hash=$2)",
+		a.UserID,
+		a.Hash,
+		a.Size,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *AssetRepository) InUse(hash string) (bool, error) {
+	inUse := false
+	if err := r.store.db.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM assets WHERE hash=$1 OR thumbnail=$1)",
+		hash,
+	).Scan(&inUse); err != nil {
+		return false, err
+	}
+
+	return inUse, nil
+}
+
+func (r *AssetRepository) LockContent(hashes ...string) error {
+	// Locks are taken in order, so transactions locking several hashes
+	// can't deadlock.
+	sorted := append([]string{}, hashes...)
+	sort.Strings(sorted)
+	for i, hash := range sorted {
+		if hash == "" || i > 0 && hash == sorted[i-1] {
+			continue
+		}
+
+		if _, err := r.store.db.Exec("SELECT pg_advisory_xact_lock(hashtext($1))", hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func scanAsset(row scanner) (*model.Asset, error) {
+	a := &model.Asset{}
+	folderID := uuid.NullUUID{}
+	campaignID := uuid.NullUUID{}
+	tags := pq.StringArray{}
+	if err := row.Scan(
+		&a.ID,
+		&a.UserID,
+		&folderID,
+		&campaignID,
+		&a.Name,
+		&a.Hash,
+		&a.MIME,
+		&a.Size,
+		&a.Width,
+		&a.Height,
+		&a.Thumbnail,
+		&tags,
+		&a.Private,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if folderID.Valid {
+		a.FolderID = &folderID.UUID
+	}
+	if campaignID.Valid {
+		a.CampaignID = &campaignID.UUID
+	}
+	a.Tags = []string(tags)
+
+	return a, nil
+}
@@ -0,0 +1,158 @@
+package sqlstore_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/sqlstore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const testQuota = 1 << 30
+
+func TestAssetRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("assets", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	a := model.TestAsset(t, u)
+	assert.NoError(t, s.Asset().Create(a, testQuota))
+	assert.NotEqual(t, uuid.Nil, a.ID)
+
+	a = model.TestAsset(t, u)
+	a.Hash = ""
+	assert.Error(t, s.Asset().Create(a, testQuota))
+}
+
+func TestAssetRepository_Find(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("assets", "folders", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	f := model.TestFolder(t, u)
+	s.Folder().Create(f)
+
+	_, err := s.Asset().Find(uuid.New())
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	a := model.TestAsset(t, u)
+	a.FolderID = &f.ID
+	s.Asset().Create(a, testQuota)
+	found, err := s.Asset().Find(a.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, &f.ID, found.FolderID)
+	assert.Equal(t, []string{"map"}, found.Tags)
+
+	other := model.TestAsset(t, u)
+	other.Tags = []string{"token"}
+	s.Asset().Create(other, testQuota)
+
+	assets, err := s.Asset().FindAll(u.ID, &model.AssetFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, assets, 2)
+
+	assets, _ = s.Asset().FindAll(u.ID, &model.AssetFilter{FolderID: &f.ID})
+	assert.Len(t, assets, 1)
+
+	assets, _ = s.Asset().FindAll(u.ID, &model.AssetFilter{Tag: "token"})
+	if assert.Len(t, assets, 1) {
+		assert.Equal(t, other.ID, assets[0].ID)
+	}
+
+	assets, _ = s.Asset().FindAll(u.ID, &model.AssetFilter{Hash: a.Hash})
+	assert.Len(t, assets, 2)
+
+	assets, _ = s.Asset().FindAll(u.ID, &model.AssetFilter{Hash: strings.Repeat("0", 64)})
+	assert.Len(t, assets, 0)
+}
+
+func TestAssetRepository_Update(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("assets", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	a := model.TestAsset(t, u)
+	s.Asset().Create(a, testQuota)
+	a.Name = "castle.png"
+	a.Private = true
+	a.Tags = []string{"map", "castle"}
+	assert.NoError(t, s.Asset().Update(a))
+
+	a, _ = s.Asset().Find(a.ID)
+	assert.Equal(t, "castle.png", a.Name)
+	assert.True(t, a.Private)
+	assert.Equal(t, []string{"map", "castle"}, a.Tags)
+}
+
+func TestAssetRepository_Delete(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("assets", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	a := model.TestAsset(t, u)
+	a.Thumbnail = "a0a1d1a4ad1f1d7c08fa1a3c1fb1f1e0d1b1f1f1f1f1f1f1f1f1f1f1f1f1f1f1"
+	s.Asset().Create(a, testQuota)
+	inUse, err := s.Asset().InUse(a.Thumbnail)
+	assert.NoError(t, err)
+	assert.True(t, inUse)
+
+	assert.NoError(t, s.Asset().Delete(a.ID))
+	assert.EqualError(t, s.Asset().Delete(a.ID), store.ErrRecordNotFound.Error())
+
+	inUse, _ = s.Asset().InUse(a.Hash)
+	assert.False(t, inUse)
+}
+
+func TestAssetRepository_LockContent(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("assets", "users")
+
+	s := sqlstore.New(db)
+	hash := strings.Repeat("b", 64)
+
+	locked := make(chan struct{})
+	done := make(chan struct{})
+	assert.NoError(t, s.Transaction(func(tx store.Store) error {
+		if err := tx.Asset().LockContent(hash, "", hash); err != nil {
+			return err
+		}
+
+		go func() {
+			s.Transaction(func(tx store.Store) error {
+				close(locked)
+				return tx.Asset().LockContent(hash)
+			})
+			close(done)
+		}()
+
+		<-locked
+		select {
+		case <-done:
+			t.Error("content locked twice")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		return nil
+	}))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("lock not released")
+	}
+}
@@ -0,0 +1,90 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type CampaignPackRepository struct {
+	store *Store
+}
+
+func (r *CampaignPackRepository) Save(c *model.CampaignPack) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.store.db.QueryRow(
+		"INSERT INTO campaign_packs (campaign_id, pack_id, version) VALUES ($1, $2, $3) "+
+			"ON CONFLICT (campaign_id, pack_id) DO UPDATE SET version=EXCLUDED.version, updated_at=now() "+
+			"RETURNING created_at, updated_at",
+		c.CampaignID,
+		c.PackID,
+		c.Version,
+	).Scan(&c.CreatedAt, &c.UpdatedAt); err != nil {
+		if isForeignKeyViolation(err) {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *CampaignPackRepository) Find(campaignID uuid.UUID, packID uuid.UUID) (*model.CampaignPack, error) {
+	c := &model.CampaignPack{}
+	if err := r.store.db.QueryRow(
+		"SELECT campaign_id, pack_id, version, created_at, updated_at FROM campaign_packs WHERE campaign_id=$1 AND pack_id=$2",
+		campaignID,
+		packID,
+	).Scan(&c.CampaignID, &c.PackID, &c.Version, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (r *CampaignPackRepository) FindAll(campaignID uuid.UUID) ([]*model.CampaignPack, error) {
+	rows, err := r.store.db.Query(
+		"SELECT campaign_id, pack_id, version, created_at, updated_at FROM campaign_packs WHERE campaign_id=$1 ORDER BY created_at, pack_id",
+		campaignID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	packs := []*model.CampaignPack{}
+	for rows.Next() {
+		c := &model.CampaignPack{}
+		if err := rows.Scan(&c.CampaignID, &c.PackID, &c.Version, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		packs = append(packs, c)
+	}
+
+	return packs, rows.Err()
+}
+
+func (r *CampaignPackRepository) Delete(campaignID uuid.UUID, packID uuid.UUID) error {
+	res, err := r.store.db.Exec("DELETE FROM campaign_packs WHERE campaign_id=$1 AND pack_id=$2", campaignID, packID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
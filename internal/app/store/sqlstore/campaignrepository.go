@@ -0,0 +1,136 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type CampaignRepository struct {
+	store *Store
+}
+
+func (r *CampaignRepository) Create(c *model.Campaign) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	tx, err := r.store.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(
+		"INSERT INTO campaigns (name, description, description_html, system, owner_id) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at",
+		c.Name,
+		c.Description,
+		c.DescriptionHTML,
+		c.System,
+		c.OwnerID,
+	).Scan(&c.ID, &c.CreatedAt); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO campaign_members (campaign_id, user_id, role) VALUES ($1, $2, $3)",
+		c.ID,
+		c.OwnerID,
+		model.RoleGM,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *CampaignRepository) Find(id uuid.UUID) (*model.Campaign, error) {
+	c := &model.Campaign{}
+	if err := r.store.db.QueryRow(
+		"SELECT id, name, description, description_html, system, owner_id, created_at FROM campaigns WHERE id=$1",
+		id,
+	).Scan(&c.ID, &c.Name, &c.Description, &c.DescriptionHTML, &c.System, &c.OwnerID, &c.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (r *CampaignRepository) AddMember(m *model.Member) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := r.store.db.Exec(
+		"INSERT INTO campaign_members (campaign_id, user_id, role) VALUES ($1, $2, $3)",
+		m.CampaignID,
+		m.UserID,
+		m.Role,
+	); err != nil {
+		if isUniqueViolation(err) {
+			return store.ErrAlreadyExists
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *CampaignRepository) FindMember(campaignID uuid.UUID, userID uuid.UUID) (*model.Member, error) {
+	m := &model.Member{}
+	if err := r.store.db.QueryRow(
+		"SELECT campaign_id, user_id, role FROM campaign_members WHERE campaign_id=$1 AND user_id=$2",
+		campaignID,
+		userID,
+	).Scan(&m.CampaignID, &m.UserID, &m.Role); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *CampaignRepository) Members(campaignID uuid.UUID) ([]*model.Member, error) {
+	rows, err := r.store.db.Query(
+		"SELECT campaign_id, user_id, role FROM campaign_members WHERE campaign_id=$1",
+		campaignID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []*model.Member{}
+	for rows.Next() {
+		m := &model.Member{}
+		if err := rows.Scan(&m.CampaignID, &m.UserID, &m.Role); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+
+	return members, rows.Err()
+}
+
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+
+	return ok && pqErr.Code == "23505"
+}
+
+func isForeignKeyViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+
+	return ok && pqErr.Code == "23503"
+}
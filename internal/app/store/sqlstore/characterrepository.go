@@ -0,0 +1,149 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+const characterColumns = "id, campaign_id, owner_id, name, portrait, system, data, version, created_at, updated_at"
+
+type CharacterRepository struct {
+	store *Store
+}
+
+func (r *CharacterRepository) Create(c *model.Character) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO characters (campaign_id, owner_id, name, portrait, system, data) VALUES ($1, $2, $3, $4, $5, $6) "+
+			"RETURNING id, version, created_at, updated_at",
+		c.CampaignID,
+		c.OwnerID,
+		c.Name,
+		c.Portrait,
+		c.System,
+		[]byte(c.Data),
+	).Scan(&c.ID, &c.Version, &c.CreatedAt, &c.UpdatedAt)
+}
+
+func (r *CharacterRepository) Find(id uuid.UUID) (*model.Character, error) {
+	return r.findOne("SELECT "+characterColumns+" FROM characters WHERE id=$1", id)
+}
+
+// FindByName looks the character up by case-insensitive name, preferring
+// the oldest one if several share it.
+func (r *CharacterRepository) FindByName(campaignID uuid.UUID, name string) (*model.Character, error) {
+	return r.findOne(
+		"SELECT "+characterColumns+" FROM characters WHERE campaign_id=$1 AND lower(name)=lower($2) ORDER BY created_at LIMIT 1",
+		campaignID,
+		name,
+	)
+}
+
+func (r *CharacterRepository) FindAll(campaignID uuid.UUID) ([]*model.Character, error) {
+	rows, err := r.store.db.Query(
+		"SELECT "+characterColumns+" FROM characters WHERE campaign_id=$1 ORDER BY name, id",
+		campaignID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	characters := []*model.Character{}
+	for rows.Next() {
+		c, err := scanCharacter(rows)
+		if err != nil {
+			return nil, err
+		}
+		characters = append(characters, c)
+	}
+
+	return characters, rows.Err()
+}
+
+// Update saves the character if nobody has updated it since it was loaded,
+// bumping its version.
+func (r *CharacterRepository) Update(c *model.Character) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.store.db.QueryRow(
+		"UPDATE characters SET owner_id=$3, name=$4, portrait=$5, data=$6, version=version+1, updated_at=now() "+
+			"WHERE id=$1 AND version=$2 RETURNING version, updated_at",
+		c.ID,
+		c.Version,
+		c.OwnerID,
+		c.Name,
+		c.Portrait,
+		[]byte(c.Data),
+	).Scan(&c.Version, &c.UpdatedAt); err != nil {
+		if err != sql.ErrNoRows {
+			return err
+		}
+
+		if _, err := r.Find(c.ID); err != nil {
+			return err
+		}
+
+		return store.ErrConflict
+	}
+
+	return nil
+}
+
+func (r *CharacterRepository) Delete(id uuid.UUID) error {
+	res, err := r.store.db.Exec("DELETE FROM characters WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *CharacterRepository) findOne(query string, args ...interface{}) (*model.Character, error) {
+	c, err := scanCharacter(r.store.db.QueryRow(query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func scanCharacter(row scanner) (*model.Character, error) {
+	c := &model.Character{}
+	data := []byte{}
+	if err := row.Scan(
+		&c.ID,
+		&c.CampaignID,
+		&c.OwnerID,
+		&c.Name,
+		&c.Portrait,
+		&c.System,
+		&data,
+		&c.Version,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	c.Data = data
+
+	return c, nil
+}
@@ -0,0 +1,130 @@
+package sqlstore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/sqlstore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCharacterRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("characters", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	ch := model.TestCharacter(t, c, u)
+	assert.NoError(t, s.Character().Create(ch))
+	assert.NotEqual(t, uuid.Nil, ch.ID)
+
+	ch = model.TestCharacter(t, c, u)
+	ch.Data = []byte(`{"level": 0}`)
+	assert.Error(t, s.Character().Create(ch))
+}
+
+func TestCharacterRepository_Find(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("characters", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	_, err := s.Character().Find(uuid.New())
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	tc := model.TestCharacter(t, c, u)
+	s.Character().Create(tc)
+	ch, err := s.Character().Find(tc.ID)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(tc.Data), string(ch.Data))
+
+	ch, err = s.Character().FindByName(c.ID, "ireena kolyana")
+	assert.NoError(t, err)
+	assert.Equal(t, tc.ID, ch.ID)
+
+	_, err = s.Character().FindByName(c.ID, "Strahd")
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+}
+
+func TestCharacterRepository_FindAll(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("characters", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	other := model.TestCampaign(t, u)
+	s.Campaign().Create(other)
+
+	for _, name := range []string{"Van Richten", "Ezmerelda"} {
+		ch := model.TestCharacter(t, c, u)
+		ch.Name = name
+		s.Character().Create(ch)
+	}
+	s.Character().Create(model.TestCharacter(t, other, u))
+
+	characters, err := s.Character().FindAll(c.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, characters, 2) {
+		assert.Equal(t, "Ezmerelda", characters[0].Name)
+		assert.Equal(t, "Van Richten", characters[1].Name)
+	}
+}
+
+func TestCharacterRepository_Update(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("characters", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	ch := model.TestCharacter(t, c, u)
+	s.Character().Create(ch)
+	stale, _ := s.Character().Find(ch.ID)
+	ch.Name = "Ireena"
+	ch.Data = []byte(`{"level": 4}`)
+	assert.NoError(t, s.Character().Update(ch))
+	assert.Equal(t, 2, ch.Version)
+
+	ch, _ = s.Character().Find(ch.ID)
+	assert.Equal(t, "Ireena", ch.Name)
+	assert.JSONEq(t, `{"level": 4}`, string(ch.Data))
+	assert.Equal(t, 2, ch.Version)
+
+	stale.Name = "Tatyana"
+	assert.EqualError(t, s.Character().Update(stale), store.ErrConflict.Error())
+
+	stale.ID = uuid.New()
+	assert.EqualError(t, s.Character().Update(stale), store.ErrRecordNotFound.Error())
+}
+
+func TestCharacterRepository_Delete(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("characters", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	ch := model.TestCharacter(t, c, u)
+	s.Character().Create(ch)
+	assert.NoError(t, s.Character().Delete(ch.ID))
+	assert.EqualError(t, s.Character().Delete(ch.ID), store.ErrRecordNotFound.Error())
+}
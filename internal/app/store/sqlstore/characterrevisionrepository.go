@@ -0,0 +1,104 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+const characterRevisionColumns = "id, character_id, version, author_id, patch, created_at"
+
+type CharacterRevisionRepository struct {
+	store *Store
+}
+
+func (r *CharacterRevisionRepository) Create(rev *model.CharacterRevision) error {
+	if err := rev.Validate(); err != nil {
+		return err
+	}
+
+	patch, err := json.Marshal(rev.Patch)
+	if err != nil {
+		return err
+	}
+
+	if err := r.store.db.QueryRow(
+		"INSERT INTO character_revisions (character_id, version, author_id, patch) VALUES ($1, $2, $3, $4) "+
+			"RETURNING id, created_at",
+		rev.CharacterID,
+		rev.Version,
+		rev.AuthorID,
+		patch,
+	).Scan(&rev.ID, &rev.CreatedAt); err != nil {
+		if isUniqueViolation(err) {
+			return store.ErrAlreadyExists
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *CharacterRevisionRepository) Find(characterID uuid.UUID, version int) (*model.CharacterRevision, error) {
+	rev, err := scanCharacterRevision(r.store.db.QueryRow(
+		"SELECT "+characterRevisionColumns+" FROM character_revisions WHERE character_id=$1 AND version=$2",
+		characterID,
+		version,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return rev, nil
+}
+
+func (r *CharacterRevisionRepository) FindAll(characterID uuid.UUID) ([]*model.CharacterRevision, error) {
+	rows, err := r.store.db.Query(
+		"SELECT "+characterRevisionColumns+" FROM character_revisions WHERE character_id=$1 ORDER BY version",
+		characterID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*model.CharacterRevision{}
+	for rows.Next() {
+		rev, err := scanCharacterRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+
+	return revisions, rows.Err()
+}
+
+func scanCharacterRevision(row scanner) (*model.CharacterRevision, error) {
+	rev := &model.CharacterRevision{}
+	patch := []byte{}
+	if err := row.Scan(
+		&rev.ID,
+		&rev.CharacterID,
+		&rev.Version,
+		&rev.AuthorID,
+		&patch,
+		&rev.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(patch, &rev.Patch); err != nil {
+		return nil, err
+	}
+
+	return rev, nil
+}
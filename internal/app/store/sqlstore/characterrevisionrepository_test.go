@@ -0,0 +1,69 @@
+package sqlstore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/sqlstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCharacterRevisionRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("character_revisions", "characters", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	ch := model.TestCharacter(t, c, u)
+	s.Character().Create(ch)
+
+	rev, err := model.NewCharacterRevision(ch, nil, u.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, s.CharacterRevision().Create(rev))
+	assert.NotNil(t, rev.ID)
+
+	rev, _ = model.NewCharacterRevision(ch, nil, u.ID)
+	assert.EqualError(t, s.CharacterRevision().Create(rev), store.ErrAlreadyExists.Error())
+}
+
+func TestCharacterRevisionRepository_Find(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("character_revisions", "characters", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	ch := model.TestCharacter(t, c, u)
+	s.Character().Create(ch)
+
+	first, _ := model.NewCharacterRevision(ch, nil, u.ID)
+	s.CharacterRevision().Create(first)
+	before, _ := ch.Snapshot()
+	ch.Name = "Ireena"
+	s.Character().Update(ch)
+	second, _ := model.NewCharacterRevision(ch, before, u.ID)
+	s.CharacterRevision().Create(second)
+
+	rev, err := s.CharacterRevision().Find(ch.ID, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, second.Patch, rev.Patch)
+	assert.Equal(t, u.ID, *rev.AuthorID)
+
+	_, err = s.CharacterRevision().Find(ch.ID, 3)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	revisions, err := s.CharacterRevision().FindAll(ch.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, revisions, 2) {
+		snapshot, err := model.SnapshotAt(revisions, 2)
+		assert.NoError(t, err)
+		current, _ := ch.Snapshot()
+		assert.JSONEq(t, string(current), string(snapshot))
+	}
+}
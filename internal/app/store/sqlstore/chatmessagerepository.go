@@ -0,0 +1,177 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const chatMessageColumns = "id, campaign_id, user_id, kind, character_id, speaker, recipients, body, body_html, rolls, created_at, edited_at"
+
+type ChatMessageRepository struct {
+	store *Store
+}
+
+func (r *ChatMessageRepository) Create(m *model.ChatMessage) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	if m.Rolls == nil {
+		m.Rolls = []model.ChatRoll{}
+	}
+	rolls, err := json.Marshal(m.Rolls)
+	if err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO chat_messages (campaign_id, user_id, kind, character_id, speaker, recipients, body, body_html, rolls) "+
+			"VALUES ($1, $2, $3, $4, $5, $6::uuid[], $7, $8, $9) RETURNING id, created_at",
+		m.CampaignID,
+		m.UserID,
+		m.Kind,
+		m.CharacterID,
+		m.Speaker,
+		uuidArray(m.Recipients),
+		m.Body,
+		m.BodyHTML,
+		rolls,
+	).Scan(&m.ID, &m.CreatedAt)
+}
+
+func (r *ChatMessageRepository) Find(id uuid.UUID) (*model.ChatMessage, error) {
+	m, err := scanChatMessage(r.store.db.QueryRow(
+		"SELECT "+chatMessageColumns+" FROM chat_messages WHERE id=$1",
+		id,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *ChatMessageRepository) Update(m *model.ChatMessage) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.store.db.QueryRow(
+		"UPDATE chat_messages SET body=$2, body_html=$3, edited_at=now() WHERE id=$1 RETURNING edited_at",
+		m.ID,
+		m.Body,
+		m.BodyHTML,
+	).Scan(&m.EditedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *ChatMessageRepository) Delete(id uuid.UUID) error {
+	res, err := r.store.db.Exec("DELETE FROM chat_messages WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *ChatMessageRepository) FindAll(campaignID uuid.UUID, viewer *model.Member, filter *model.ChatFilter) ([]*model.ChatMessage, error) {
+	args := []interface{}{campaignID, viewer.UserID, viewer.IsGM()}
+	query := "SELECT " + chatMessageColumns + " FROM chat_messages WHERE campaign_id=$1 " +
+		"AND (user_id=$2 OR (kind='whisper' AND $2=ANY(recipients)) OR (kind='gm' AND $3) OR kind NOT IN ('whisper', 'gm'))"
+	if filter.Before != nil {
+		args = append(args, filter.Before.CreatedAt, filter.Before.ID)
+		query += fmt.Sprintf(" AND (created_at, id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.store.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []*model.ChatMessage{}
+	for rows.Next() {
+		m, err := scanChatMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+
+	return messages, rows.Err()
+}
+
+type scanner interface {
+	Scan(...interface{}) error
+}
+
+func scanChatMessage(row scanner) (*model.ChatMessage, error) {
+	m := &model.ChatMessage{}
+	characterID := uuid.NullUUID{}
+	recipients := pq.StringArray{}
+	rolls := []byte{}
+	editedAt := sql.NullTime{}
+	if err := row.Scan(
+		&m.ID,
+		&m.CampaignID,
+		&m.UserID,
+		&m.Kind,
+		&characterID,
+		&m.Speaker,
+		&recipients,
+		&m.Body,
+		&m.BodyHTML,
+		&rolls,
+		&m.CreatedAt,
+		&editedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if characterID.Valid {
+		m.CharacterID = &characterID.UUID
+	}
+	if editedAt.Valid {
+		m.EditedAt = &editedAt.Time
+	}
+
+	if err := json.Unmarshal(rolls, &m.Rolls); err != nil {
+		return nil, err
+	}
+
+	ids, err := parseUUIDs(recipients)
+	if err != nil {
+		return nil, err
+	}
+	m.Recipients = ids
+
+	return m, nil
+}
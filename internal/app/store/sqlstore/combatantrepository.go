@@ -0,0 +1,193 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+const combatantColumns = "id, combat_id, name, token_id, character_id, owner_id, initiative, initiative_bonus, roll_off, " +
+	"hp, max_hp, temp_hp, hidden, defeated, conditions, created_at, updated_at"
+
+type CombatantRepository struct {
+	store *Store
+}
+
+func (r *CombatantRepository) Create(c *model.Combatant) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if c.Conditions == nil {
+		c.Conditions = []model.Condition{}
+	}
+	conditions, err := json.Marshal(c.Conditions)
+	if err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO combatants (combat_id, name, token_id, character_id, owner_id, initiative, initiative_bonus, roll_off, "+
+			"hp, max_hp, temp_hp, hidden, defeated, conditions) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id, created_at, updated_at",
+		c.CombatID,
+		c.Name,
+		c.TokenID,
+		c.CharacterID,
+		c.OwnerID,
+		c.Initiative,
+		c.InitiativeBonus,
+		c.RollOff,
+		c.HP,
+		c.MaxHP,
+		c.TempHP,
+		c.Hidden,
+		c.Defeated,
+		conditions,
+	).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+}
+
+func (r *CombatantRepository) Find(id uuid.UUID) (*model.Combatant, error) {
+	c, err := scanCombatant(r.store.db.QueryRow("SELECT "+combatantColumns+" FROM combatants WHERE id=$1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (r *CombatantRepository) FindAll(combatID uuid.UUID) ([]*model.Combatant, error) {
+	rows, err := r.store.db.Query(
+		"SELECT "+combatantColumns+" FROM combatants WHERE combat_id=$1 ORDER BY created_at, id",
+		combatID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	combatants := []*model.Combatant{}
+	for rows.Next() {
+		c, err := scanCombatant(rows)
+		if err != nil {
+			return nil, err
+		}
+		combatants = append(combatants, c)
+	}
+
+	return combatants, rows.Err()
+}
+
+func (r *CombatantRepository) Update(c *model.Combatant) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if c.Conditions == nil {
+		c.Conditions = []model.Condition{}
+	}
+	conditions, err := json.Marshal(c.Conditions)
+	if err != nil {
+		return err
+	}
+
+	if err := r.store.db.QueryRow(
+		"UPDATE combatants SET name=$2, token_id=$3, character_id=$4, owner_id=$5, initiative=$6, initiative_bonus=$7, "+
+			"roll_off=$8, hp=$9, max_hp=$10, temp_hp=$11, hidden=$12, defeated=$13, conditions=$14, updated_at=now() "+
+			"WHERE id=$1 RETURNING updated_at",
+		c.ID,
+		c.Name,
+		c.TokenID,
+		c.CharacterID,
+		c.OwnerID,
+		c.Initiative,
+		c.InitiativeBonus,
+		c.RollOff,
+		c.HP,
+		c.MaxHP,
+		c.TempHP,
+		c.Hidden,
+		c.Defeated,
+		conditions,
+	).Scan(&c.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *CombatantRepository) Delete(id uuid.UUID) error {
+	res, err := r.store.db.Exec("DELETE FROM combatants WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func scanCombatant(row scanner) (*model.Combatant, error) {
+	c := &model.Combatant{}
+	tokenID := uuid.NullUUID{}
+	characterID := uuid.NullUUID{}
+	ownerID := uuid.NullUUID{}
+	initiative := sql.NullInt64{}
+	conditions := []byte{}
+	if err := row.Scan(
+		&c.ID,
+		&c.CombatID,
+		&c.Name,
+		&tokenID,
+		&characterID,
+		&ownerID,
+		&initiative,
+		&c.InitiativeBonus,
+		&c.RollOff,
+		&c.HP,
+		&c.MaxHP,
+		&c.TempHP,
+		&c.Hidden,
+		&c.Defeated,
+		&conditions,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if tokenID.Valid {
+		c.TokenID = &tokenID.UUID
+	}
+	if characterID.Valid {
+		c.CharacterID = &characterID.UUID
+	}
+	if ownerID.Valid {
+		c.OwnerID = &ownerID.UUID
+	}
+	if initiative.Valid {
+		n := int(initiative.Int64)
+		c.Initiative = &n
+	}
+
+	if err := json.Unmarshal(conditions, &c.Conditions); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
@@ -0,0 +1,106 @@
+package sqlstore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/sqlstore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCombatantRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("combatants", "combats", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	cb := model.TestCombat(t, c)
+	s.Combat().Create(cb)
+
+	cbt := model.TestCombatant(t, cb)
+	assert.NoError(t, s.Combatant().Create(cbt))
+	assert.NotEqual(t, uuid.Nil, cbt.ID)
+
+	cbt = model.TestCombatant(t, cb)
+	cbt.HP = cbt.MaxHP + 1
+	assert.Error(t, s.Combatant().Create(cbt))
+}
+
+func TestCombatantRepository_Find(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("combatants", "combats", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	cb := model.TestCombat(t, c)
+	s.Combat().Create(cb)
+
+	_, err := s.Combatant().Find(uuid.New())
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	cbt := model.TestCombatant(t, cb)
+	cbt.OwnerID = &u.ID
+	cbt.Conditions = []model.Condition{{Name: "prone"}}
+	s.Combatant().Create(cbt)
+	found, err := s.Combatant().Find(cbt.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, &u.ID, found.OwnerID)
+	assert.Nil(t, found.Initiative)
+	assert.Equal(t, cbt.Conditions, found.Conditions)
+
+	combatants, err := s.Combatant().FindAll(cb.ID)
+	assert.NoError(t, err)
+	assert.Len(t, combatants, 1)
+}
+
+func TestCombatantRepository_Update(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("combatants", "combats", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	cb := model.TestCombat(t, c)
+	s.Combat().Create(cb)
+
+	cbt := model.TestCombatant(t, cb)
+	s.Combatant().Create(cbt)
+	initiative := 17
+	cbt.Initiative = &initiative
+	cbt.RollOff = 12
+	cbt.Damage(5)
+	assert.NoError(t, s.Combatant().Update(cbt))
+
+	cbt, _ = s.Combatant().Find(cbt.ID)
+	assert.Equal(t, &initiative, cbt.Initiative)
+	assert.Equal(t, 12, cbt.RollOff)
+	assert.Equal(t, 17, cbt.HP)
+}
+
+func TestCombatantRepository_Delete(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("combatants", "combats", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	cb := model.TestCombat(t, c)
+	s.Combat().Create(cb)
+
+	cbt := model.TestCombatant(t, cb)
+	s.Combatant().Create(cbt)
+	assert.NoError(t, s.Combatant().Delete(cbt.ID))
+	assert.EqualError(t, s.Combatant().Delete(cbt.ID), store.ErrRecordNotFound.Error())
+}
@@ -0,0 +1,134 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+const combatColumns = "id, campaign_id, scene_id, name, tie_break, round, current_id, created_at, updated_at"
+
+type CombatRepository struct {
+	store *Store
+}
+
+func (r *CombatRepository) Create(c *model.Combat) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO combats (campaign_id, scene_id, name, tie_break, round, current_id) VALUES ($1, $2, $3, $4, $5, $6) "+
+			"RETURNING id, created_at, updated_at",
+		c.CampaignID,
+		c.SceneID,
+		c.Name,
+		c.TieBreak,
+		c.Round,
+		c.CurrentID,
+	).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+}
+
+func (r *CombatRepository) Find(id uuid.UUID) (*model.Combat, error) {
+	c, err := scanCombat(r.store.db.QueryRow("SELECT "+combatColumns+" FROM combats WHERE id=$1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (r *CombatRepository) FindAll(campaignID uuid.UUID) ([]*model.Combat, error) {
+	rows, err := r.store.db.Query(
+		"SELECT "+combatColumns+" FROM combats WHERE campaign_id=$1 ORDER BY created_at, id",
+		campaignID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	combats := []*model.Combat{}
+	for rows.Next() {
+		c, err := scanCombat(rows)
+		if err != nil {
+			return nil, err
+		}
+		combats = append(combats, c)
+	}
+
+	return combats, rows.Err()
+}
+
+func (r *CombatRepository) Update(c *model.Combat) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.store.db.QueryRow(
+		"UPDATE combats SET scene_id=$2, name=$3, tie_break=$4, round=$5, current_id=$6, updated_at=now() WHERE id=$1 RETURNING updated_at",
+		c.ID,
+		c.SceneID,
+		c.Name,
+		c.TieBreak,
+		c.Round,
+		c.CurrentID,
+	).Scan(&c.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *CombatRepository) Delete(id uuid.UUID) error {
+	res, err := r.store.db.Exec("DELETE FROM combats WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func scanCombat(row scanner) (*model.Combat, error) {
+	c := &model.Combat{}
+	sceneID := uuid.NullUUID{}
+	currentID := uuid.NullUUID{}
+	if err := row.Scan(
+		&c.ID,
+		&c.CampaignID,
+		&sceneID,
+		&c.Name,
+		&c.TieBreak,
+		&c.Round,
+		&currentID,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if sceneID.Valid {
+		c.SceneID = &sceneID.UUID
+	}
+	if currentID.Valid {
+		c.CurrentID = &currentID.UUID
+	}
+
+	return c, nil
+}
@@ -0,0 +1,102 @@
+package sqlstore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/sqlstore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCombatRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("combats", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	cb := model.TestCombat(t, c)
+	assert.NoError(t, s.Combat().Create(cb))
+	assert.NotEqual(t, uuid.Nil, cb.ID)
+
+	cb = model.TestCombat(t, c)
+	cb.TieBreak = "coin"
+	assert.Error(t, s.Combat().Create(cb))
+}
+
+func TestCombatRepository_Find(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("combats", "scenes", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	_, err := s.Combat().Find(uuid.New())
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	cb := model.TestCombat(t, c)
+	cb.SceneID = &sc.ID
+	s.Combat().Create(cb)
+	found, err := s.Combat().Find(cb.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, &sc.ID, found.SceneID)
+	assert.Nil(t, found.CurrentID)
+
+	combats, err := s.Combat().FindAll(c.ID)
+	assert.NoError(t, err)
+	assert.Len(t, combats, 1)
+}
+
+func TestCombatRepository_Update(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("combats", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	cb := model.TestCombat(t, c)
+	s.Combat().Create(cb)
+	current := uuid.New()
+	cb.Round = 3
+	cb.CurrentID = &current
+	assert.NoError(t, s.Combat().Update(cb))
+
+	cb, _ = s.Combat().Find(cb.ID)
+	assert.Equal(t, 3, cb.Round)
+	assert.Equal(t, &current, cb.CurrentID)
+}
+
+func TestCombatRepository_Delete(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("combatants", "combats", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	cb := model.TestCombat(t, c)
+	s.Combat().Create(cb)
+	cbt := model.TestCombatant(t, cb)
+	s.Combatant().Create(cbt)
+
+	assert.NoError(t, s.Combat().Delete(cb.ID))
+	assert.EqualError(t, s.Combat().Delete(cb.ID), store.ErrRecordNotFound.Error())
+
+	_, err := s.Combatant().Find(cbt.ID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+}
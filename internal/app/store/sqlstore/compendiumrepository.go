@@ -0,0 +1,154 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+const compendiumEntryColumns = "id, system, kind, source, name, body, body_html, data, pack_id, pack_version, created_at, updated_at"
+
+type CompendiumRepository struct {
+	store *Store
+}
+
+func (r *CompendiumRepository) Save(e *model.CompendiumEntry) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO compendium_entries (system, kind, source, name, body, body_html, data) VALUES ($1, $2, $3, $4, $5, $6, $7) "+
+			"ON CONFLICT (system, kind, source, name) WHERE pack_id IS NULL DO UPDATE SET body=EXCLUDED.body, body_html=EXCLUDED.body_html, data=EXCLUDED.data, updated_at=now() "+
+			"RETURNING id, created_at, updated_at",
+		e.System,
+		e.Kind,
+		e.Source,
+		e.Name,
+		e.Body,
+		e.BodyHTML,
+		[]byte(e.Data),
+	).Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt)
+}
+
+func (r *CompendiumRepository) Find(id uuid.UUID) (*model.CompendiumEntry, error) {
+	e, err := scanCompendiumEntry(r.store.db.QueryRow("SELECT "+compendiumEntryColumns+" FROM compendium_entries WHERE id=$1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// FindAll ranks entries found with a query by the full-text index, names
+// weighing more than bodies, and orders the rest by name.
+func (r *CompendiumRepository) FindAll(filter *model.CompendiumFilter) ([]*model.CompendiumEntry, error) {
+	conditions := []string{"true"}
+	args := []interface{}{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.System != "" {
+		where("system=$%d", filter.System)
+	}
+	if filter.Kind != "" {
+		where("kind=$%d", filter.Kind)
+	}
+	if filter.Source != "" {
+		where("source=$%d", filter.Source)
+	}
+	keys := make([]string, 0, len(filter.Data))
+	for key := range filter.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, key, filter.Data[key])
+		conditions = append(conditions, fmt.Sprintf("data->>$%d=$%d", len(args)-1, len(args)))
+	}
+
+	packs := []string{"pack_id IS NULL"}
+	for _, p := range filter.Packs {
+		args = append(args, p.ID, p.Version)
+		packs = append(packs, fmt.Sprintf("(pack_id=$%d AND pack_version=$%d)", len(args)-1, len(args)))
+	}
+	conditions = append(conditions, "("+strings.Join(packs, " OR ")+")")
+
+	order := "name, id"
+	if filter.Query != "" {
+		where("search @@ websearch_to_tsquery('english', $%d)", filter.Query)
+		order = fmt.Sprintf("ts_rank(search, websearch_to_tsquery('english', $%d)) DESC, %s", len(args), order)
+	}
+
+	query := "SELECT " + compendiumEntryColumns + " FROM compendium_entries WHERE " + strings.Join(conditions, " AND ") + " ORDER BY " + order
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.store.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*model.CompendiumEntry{}
+	for rows.Next() {
+		e, err := scanCompendiumEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *CompendiumRepository) DeleteSource(system string, source string) error {
+	_, err := r.store.db.Exec("DELETE FROM compendium_entries WHERE system=$1 AND source=$2 AND pack_id IS NULL", system, source)
+
+	return err
+}
+
+func scanCompendiumEntry(row scanner) (*model.CompendiumEntry, error) {
+	e := &model.CompendiumEntry{}
+	data := []byte{}
+	packID := uuid.NullUUID{}
+	if err := row.Scan(
+		&e.ID,
+		&e.System,
+		&e.Kind,
+		&e.Source,
+		&e.Name,
+		&e.Body,
+		&e.BodyHTML,
+		&data,
+		&packID,
+		&e.PackVersion,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	e.Data = data
+	if packID.Valid {
+		e.PackID = &packID.UUID
+	}
+
+	return e, nil
+}
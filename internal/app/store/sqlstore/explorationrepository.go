@@ -0,0 +1,60 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/geometry"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type ExplorationRepository struct {
+	store *Store
+}
+
+func (r *ExplorationRepository) Find(sceneID uuid.UUID, userID uuid.UUID) (*model.Exploration, error) {
+	e := &model.Exploration{SceneID: sceneID, UserID: userID}
+	mask := []byte{}
+	if err := r.store.db.QueryRow(
+		"SELECT mask, updated_at FROM explorations WHERE scene_id=$1 AND user_id=$2",
+		sceneID,
+		userID,
+	).Scan(&mask, &e.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	e.Mask = &geometry.Mask{}
+	if err := json.Unmarshal(mask, e.Mask); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// Save stores the exploration, replacing the player's previous one.
+func (r *ExplorationRepository) Save(e *model.Exploration) error {
+	mask, err := json.Marshal(e.Mask)
+	if err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO explorations (scene_id, user_id, mask) VALUES ($1, $2, $3) "+
+			"ON CONFLICT (scene_id, user_id) DO UPDATE SET mask=excluded.mask, updated_at=now() RETURNING updated_at",
+		e.SceneID,
+		e.UserID,
+		mask,
+	).Scan(&e.UpdatedAt)
+}
+
+func (r *ExplorationRepository) DeleteAll(sceneID uuid.UUID) error {
+	_, err := r.store.db.Exec("DELETE FROM explorations WHERE scene_id=$1", sceneID)
+
+	return err
+}
@@ -0,0 +1,120 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+const folderColumns = "id, user_id, parent_id, name, created_at, updated_at"
+
+type FolderRepository struct {
+	store *Store
+}
+
+func (r *FolderRepository) Create(f *model.Folder) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO folders (user_id, parent_id, name) VALUES ($1, $2, $3) RETURNING id, created_at, updated_at",
+		f.UserID,
+		f.ParentID,
+		f.Name,
+	).Scan(&f.ID, &f.CreatedAt, &f.UpdatedAt)
+}
+
+func (r *FolderRepository) Find(id uuid.UUID) (*model.Folder, error) {
+	f, err := scanFolder(r.store.db.QueryRow("SELECT "+folderColumns+" FROM folders WHERE id=$1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (r *FolderRepository) FindAll(userID uuid.UUID) ([]*model.Folder, error) {
+	rows, err := r.store.db.Query(
+		"SELECT "+folderColumns+" FROM folders WHERE user_id=$1 ORDER BY created_at, id",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	folders := []*model.Folder{}
+	for rows.Next() {
+		f, err := scanFolder(rows)
+		if err != nil {
+			return nil, err
+		}
+		folders = append(folders, f)
+	}
+
+	return folders, rows.Err()
+}
+
+func (r *FolderRepository) Update(f *model.Folder) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.store.db.QueryRow(
+		"UPDATE folders SET parent_id=$2, name=$3, updated_at=now() WHERE id=$1 RETURNING updated_at",
+		f.ID,
+		f.ParentID,
+		f.Name,
+	).Scan(&f.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *FolderRepository) Delete(id uuid.UUID) error {
+	res, err := r.store.db.Exec("DELETE FROM folders WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func scanFolder(row scanner) (*model.Folder, error) {
+	f := &model.Folder{}
+	parentID := uuid.NullUUID{}
+	if err := row.Scan(
+		&f.ID,
+		&f.UserID,
+		&parentID,
+		&f.Name,
+		&f.CreatedAt,
+		&f.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if parentID.Valid {
+		f.ParentID = &parentID.UUID
+	}
+
+	return f, nil
+}
@@ -0,0 +1,97 @@
+package sqlstore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/sqlstore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFolderRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("folders", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	f := model.TestFolder(t, u)
+	assert.NoError(t, s.Folder().Create(f))
+	assert.NotEqual(t, uuid.Nil, f.ID)
+
+	f = model.TestFolder(t, u)
+	f.Name = ""
+	assert.Error(t, s.Folder().Create(f))
+}
+
+func TestFolderRepository_Find(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("folders", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	_, err := s.Folder().Find(uuid.New())
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	f := model.TestFolder(t, u)
+	s.Folder().Create(f)
+	sub := model.TestFolder(t, u)
+	sub.ParentID = &f.ID
+	s.Folder().Create(sub)
+
+	found, err := s.Folder().Find(sub.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, &f.ID, found.ParentID)
+
+	folders, err := s.Folder().FindAll(u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, folders, 2)
+}
+
+func TestFolderRepository_Update(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("folders", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	f := model.TestFolder(t, u)
+	s.Folder().Create(f)
+	f.Name = "Handouts"
+	assert.NoError(t, s.Folder().Update(f))
+
+	f, _ = s.Folder().Find(f.ID)
+	assert.Equal(t, "Handouts", f.Name)
+}
+
+func TestFolderRepository_Delete(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("assets", "folders", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	f := model.TestFolder(t, u)
+	s.Folder().Create(f)
+	sub := model.TestFolder(t, u)
+	sub.ParentID = &f.ID
+	s.Folder().Create(sub)
+	a := model.TestAsset(t, u)
+	a.FolderID = &sub.ID
+	s.Asset().Create(a, testQuota)
+
+	assert.NoError(t, s.Folder().Delete(f.ID))
+	assert.EqualError(t, s.Folder().Delete(f.ID), store.ErrRecordNotFound.Error())
+
+	_, err := s.Folder().Find(sub.ID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+	a, _ = s.Asset().Find(a.ID)
+	assert.Nil(t, a.FolderID)
+}
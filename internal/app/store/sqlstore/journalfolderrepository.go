@@ -0,0 +1,120 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+const journalFolderColumns = "id, campaign_id, parent_id, name, created_at, updated_at"
+
+type JournalFolderRepository struct {
+	store *Store
+}
+
+func (r *JournalFolderRepository) Create(f *model.JournalFolder) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO journal_folders (campaign_id, parent_id, name) VALUES ($1, $2, $3) RETURNING id, created_at, updated_at",
+		f.CampaignID,
+		f.ParentID,
+		f.Name,
+	).Scan(&f.ID, &f.CreatedAt, &f.UpdatedAt)
+}
+
+func (r *JournalFolderRepository) Find(id uuid.UUID) (*model.JournalFolder, error) {
+	f, err := scanJournalFolder(r.store.db.QueryRow("SELECT "+journalFolderColumns+" FROM journal_folders WHERE id=$1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (r *JournalFolderRepository) FindAll(campaignID uuid.UUID) ([]*model.JournalFolder, error) {
+	rows, err := r.store.db.Query(
+		"SELECT "+journalFolderColumns+" FROM journal_folders WHERE campaign_id=$1 ORDER BY created_at, id",
+		campaignID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	folders := []*model.JournalFolder{}
+	for rows.Next() {
+		f, err := scanJournalFolder(rows)
+		if err != nil {
+			return nil, err
+		}
+		folders = append(folders, f)
+	}
+
+	return folders, rows.Err()
+}
+
+func (r *JournalFolderRepository) Update(f *model.JournalFolder) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.store.db.QueryRow(
+		"UPDATE journal_folders SET parent_id=$2, name=$3, updated_at=now() WHERE id=$1 RETURNING updated_at",
+		f.ID,
+		f.ParentID,
+		f.Name,
+	).Scan(&f.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *JournalFolderRepository) Delete(id uuid.UUID) error {
+	res, err := r.store.db.Exec("DELETE FROM journal_folders WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func scanJournalFolder(row scanner) (*model.JournalFolder, error) {
+	f := &model.JournalFolder{}
+	parentID := uuid.NullUUID{}
+	if err := row.Scan(
+		&f.ID,
+		&f.CampaignID,
+		&parentID,
+		&f.Name,
+		&f.CreatedAt,
+		&f.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if parentID.Valid {
+		f.ParentID = &parentID.UUID
+	}
+
+	return f, nil
+}
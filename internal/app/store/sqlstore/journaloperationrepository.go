@@ -0,0 +1,78 @@
+package sqlstore
+
+import (
+	"encoding/json"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type JournalOperationRepository struct {
+	store *Store
+}
+
+func (r *JournalOperationRepository) Create(o *model.JournalOperation) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+
+	op, err := json.Marshal(o.Op)
+	if err != nil {
+		return err
+	}
+
+	if err := r.store.db.QueryRow(
+		"INSERT INTO journal_operations (entry_id, revision, user_id, op) VALUES ($1, $2, $3, $4) RETURNING created_at",
+		o.EntryID,
+		o.Revision,
+		o.UserID,
+		op,
+	).Scan(&o.CreatedAt); err != nil {
+		if isUniqueViolation(err) {
+			return store.ErrAlreadyExists
+		}
+		if isForeignKeyViolation(err) {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *JournalOperationRepository) FindAll(entryID uuid.UUID, after int) ([]*model.JournalOperation, error) {
+	rows, err := r.store.db.Query(
+		"SELECT entry_id, revision, user_id, op, created_at FROM journal_operations "+
+			"WHERE entry_id=$1 AND revision>$2 ORDER BY revision",
+		entryID,
+		after,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	operations := []*model.JournalOperation{}
+	for rows.Next() {
+		o := &model.JournalOperation{}
+		op := []byte{}
+		if err := rows.Scan(&o.EntryID, &o.Revision, &o.UserID, &op, &o.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(op, &o.Op); err != nil {
+			return nil, err
+		}
+		operations = append(operations, o)
+	}
+
+	return operations, rows.Err()
+}
+
+func (r *JournalOperationRepository) Prune(entryID uuid.UUID, through int) error {
+	_, err := r.store.db.Exec("DELETE FROM journal_operations WHERE entry_id=$1 AND revision<=$2", entryID, through)
+
+	return err
+}
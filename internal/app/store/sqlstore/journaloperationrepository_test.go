@@ -0,0 +1,75 @@
+package sqlstore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/ot"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/sqlstore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalOperationRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("journal_operations", "journal_entries", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	e := model.TestJournalEntry(t, c, u)
+	s.Journal().Create(e)
+
+	op := ot.Op{}
+	op.Insert("Dear ").Retain(len([]rune(e.Body)))
+	o := &model.JournalOperation{EntryID: e.ID, Revision: 1, UserID: u.ID, Op: op}
+	assert.NoError(t, s.JournalOperation().Create(o))
+	assert.False(t, o.CreatedAt.IsZero())
+
+	assert.EqualError(t, s.JournalOperation().Create(o), store.ErrAlreadyExists.Error())
+
+	o.EntryID = uuid.New()
+	assert.EqualError(t, s.JournalOperation().Create(o), store.ErrRecordNotFound.Error())
+
+	o.Revision = 0
+	assert.Error(t, s.JournalOperation().Create(o))
+}
+
+func TestJournalOperationRepository_FindAll(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("journal_operations", "journal_entries", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	e := model.TestJournalEntry(t, c, u)
+	s.Journal().Create(e)
+
+	for i := 1; i <= 3; i++ {
+		op := ot.Op{}
+		op.Retain(len([]rune(e.Body))).Insert("!")
+		e.Body += "!"
+		s.JournalOperation().Create(&model.JournalOperation{EntryID: e.ID, Revision: i, UserID: u.ID, Op: op})
+	}
+
+	operations, err := s.JournalOperation().FindAll(e.ID, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, operations, 2) {
+		assert.Equal(t, 2, operations[0].Revision)
+		assert.Equal(t, 3, operations[1].Revision)
+		assert.Equal(t, "!", operations[1].Op[1].Insert)
+	}
+
+	assert.NoError(t, s.JournalOperation().Prune(e.ID, 2))
+	operations, _ = s.JournalOperation().FindAll(e.ID, 0)
+	assert.Len(t, operations, 1)
+
+	s.Journal().Delete(e.ID)
+	operations, _ = s.JournalOperation().FindAll(e.ID, 0)
+	assert.Len(t, operations, 0)
+}
@@ -0,0 +1,207 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const journalEntryColumns = "id, campaign_id, folder_id, author_id, title, body, body_html, image_id, visibility, members, collaborative, revision, created_at, updated_at"
+
+type JournalRepository struct {
+	store *Store
+}
+
+func (r *JournalRepository) Create(e *model.JournalEntry) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+
+	if e.Members == nil {
+		e.Members = []uuid.UUID{}
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO journal_entries (campaign_id, folder_id, author_id, title, body, body_html, image_id, visibility, members, collaborative) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9::uuid[], $10) RETURNING id, revision, created_at, updated_at",
+		e.CampaignID,
+		e.FolderID,
+		e.AuthorID,
+		e.Title,
+		e.Body,
+		e.BodyHTML,
+		e.ImageID,
+		e.Visibility,
+		uuidArray(e.Members),
+		e.Collaborative,
+	).Scan(&e.ID, &e.Revision, &e.CreatedAt, &e.UpdatedAt)
+}
+
+func (r *JournalRepository) Find(id uuid.UUID) (*model.JournalEntry, error) {
+	e, err := scanJournalEntry(r.store.db.QueryRow("SELECT "+journalEntryColumns+" FROM journal_entries WHERE id=$1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// FindAll returns the entries of the campaign the viewer can see. With a
+// query they are searched with the full-text index and ranked, titles
+// weighing more than bodies.
+func (r *JournalRepository) FindAll(campaignID uuid.UUID, viewer *model.Member, filter *model.JournalFilter) ([]*model.JournalEntry, error) {
+	args := []interface{}{campaignID, viewer.UserID, viewer.IsGM()}
+	query := "SELECT " + journalEntryColumns + " FROM journal_entries WHERE campaign_id=$1 " +
+		"AND (author_id=$2 OR $3 OR visibility='players' OR (visibility='members' AND $2=ANY(members)))"
+	if filter.FolderID != nil {
+		args = append(args, *filter.FolderID)
+		query += fmt.Sprintf(" AND folder_id=$%d", len(args))
+	}
+	order := "title, id"
+	if filter.Query != "" {
+		args = append(args, filter.Query)
+		query += fmt.Sprintf(" AND search @@ websearch_to_tsquery('english', $%d)", len(args))
+		order = fmt.Sprintf("ts_rank(search, websearch_to_tsquery('english', $%d)) DESC, %s", len(args), order)
+	}
+	query += " ORDER BY " + order
+
+	rows, err := r.store.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*model.JournalEntry{}
+	for rows.Next() {
+		e, err := scanJournalEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// Update leaves the body alone, it changes through UpdateBody.
+func (r *JournalRepository) Update(e *model.JournalEntry) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+
+	if e.Members == nil {
+		e.Members = []uuid.UUID{}
+	}
+
+	if err := r.store.db.QueryRow(
+		"UPDATE journal_entries SET folder_id=$2, title=$3, image_id=$4, visibility=$5, members=$6::uuid[], collaborative=$7, updated_at=now() "+
+			"WHERE id=$1 RETURNING body, body_html, revision, updated_at",
+		e.ID,
+		e.FolderID,
+		e.Title,
+		e.ImageID,
+		e.Visibility,
+		uuidArray(e.Members),
+		e.Collaborative,
+	).Scan(&e.Body, &e.BodyHTML, &e.Revision, &e.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// UpdateBody stores the body as of the entry's Revision. It fails with
+// ErrConflict if a later revision is stored already.
+func (r *JournalRepository) UpdateBody(e *model.JournalEntry) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.store.db.QueryRow(
+		"UPDATE journal_entries SET body=$2, body_html=$3, revision=$4, updated_at=now() "+
+			"WHERE id=$1 AND revision<=$4 RETURNING updated_at",
+		e.ID,
+		e.Body,
+		e.BodyHTML,
+		e.Revision,
+	).Scan(&e.UpdatedAt); err != nil {
+		if err != sql.ErrNoRows {
+			return err
+		}
+
+		if _, err := r.Find(e.ID); err != nil {
+			return err
+		}
+
+		return store.ErrConflict
+	}
+
+	return nil
+}
+
+func (r *JournalRepository) Delete(id uuid.UUID) error {
+	res, err := r.store.db.Exec("DELETE FROM journal_entries WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func scanJournalEntry(row scanner) (*model.JournalEntry, error) {
+	e := &model.JournalEntry{}
+	folderID := uuid.NullUUID{}
+	imageID := uuid.NullUUID{}
+	members := pq.StringArray{}
+	if err := row.Scan(
+		&e.ID,
+		&e.CampaignID,
+		&folderID,
+		&e.AuthorID,
+		&e.Title,
+		&e.Body,
+		&e.BodyHTML,
+		&imageID,
+		&e.Visibility,
+		&members,
+		&e.Collaborative,
+		&e.Revision,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if folderID.Valid {
+		e.FolderID = &folderID.UUID
+	}
+	if imageID.Valid {
+		e.ImageID = &imageID.UUID
+	}
+
+	ids, err := parseUUIDs(members)
+	if err != nil {
+		return nil, err
+	}
+	e.Members = ids
+
+	return e, nil
+}
@@ -0,0 +1,158 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+const lightColumns = "id, scene_id, x, y, radius, color, created_at, updated_at"
+
+type LightRepository struct {
+	store *Store
+}
+
+func (r *LightRepository) Create(l *model.Light) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO lights (scene_id, x, y, radius, color) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at",
+		l.SceneID,
+		l.X,
+		l.Y,
+		l.Radius,
+		l.Color,
+	).Scan(&l.ID, &l.CreatedAt, &l.UpdatedAt)
+}
+
+func (r *LightRepository) Find(id uuid.UUID) (*model.Light, error) {
+	return r.find("SELECT "+lightColumns+" FROM lights WHERE id=$1", id)
+}
+
+func (r *LightRepository) Lock(id uuid.UUID) (*model.Light, error) {
+	return r.find("SELECT "+lightColumns+" FROM lights WHERE id=$1 FOR UPDATE", id)
+}
+
+func (r *LightRepository) find(query string, id uuid.UUID) (*model.Light, error) {
+	l, err := scanLight(r.store.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (r *LightRepository) FindAll(sceneID uuid.UUID) ([]*model.Light, error) {
+	rows, err := r.store.db.Query(
+		"SELECT "+lightColumns+" FROM lights WHERE scene_id=$1 ORDER BY created_at, id",
+		sceneID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lights := []*model.Light{}
+	for rows.Next() {
+		l, err := scanLight(rows)
+		if err != nil {
+			return nil, err
+		}
+		lights = append(lights, l)
+	}
+
+	return lights, rows.Err()
+}
+
+func (r *LightRepository) Update(l *model.Light) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.store.db.QueryRow(
+		"UPDATE lights SET x=$2, y=$3, radius=$4, color=$5, updated_at=now() WHERE id=$1 RETURNING updated_at",
+		l.ID,
+		l.X,
+		l.Y,
+		l.Radius,
+		l.Color,
+	).Scan(&l.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *LightRepository) Restore(l *model.Light) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := r.store.db.Exec(
+		"INSERT INTO lights ("+lightColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		l.ID,
+		l.SceneID,
+		l.X,
+		l.Y,
+		l.Radius,
+		l.Color,
+		l.CreatedAt,
+		l.UpdatedAt,
+	); err != nil {
+		if isUniqueViolation(err) {
+			return store.ErrAlreadyExists
+		}
+		if isForeignKeyViolation(err) {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *LightRepository) Delete(id uuid.UUID) error {
+	res, err := r.store.db.Exec("DELETE FROM lights WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func scanLight(row scanner) (*model.Light, error) {
+	l := &model.Light{}
+	if err := row.Scan(
+		&l.ID,
+		&l.SceneID,
+		&l.X,
+		&l.Y,
+		&l.Radius,
+		&l.Color,
+		&l.CreatedAt,
+		&l.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
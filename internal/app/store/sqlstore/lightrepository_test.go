@@ -0,0 +1,120 @@
+package sqlstore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/sqlstore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLightRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("lights", "scenes", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	l := model.TestLight(t, sc)
+	assert.NoError(t, s.Light().Create(l))
+	assert.NotEqual(t, uuid.Nil, l.ID)
+
+	l = model.TestLight(t, sc)
+	l.Color = "orange"
+	assert.Error(t, s.Light().Create(l))
+}
+
+func TestLightRepository_Find(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("lights", "scenes", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	_, err := s.Light().Find(uuid.New())
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	l := model.TestLight(t, sc)
+	s.Light().Create(l)
+	found, err := s.Light().Find(l.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, l.Radius, found.Radius)
+
+	lights, err := s.Light().FindAll(sc.ID)
+	assert.NoError(t, err)
+	assert.Len(t, lights, 1)
+}
+
+func TestLightRepository_Update(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("lights", "scenes", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	l := model.TestLight(t, sc)
+	s.Light().Create(l)
+	l.Radius = 70
+	assert.NoError(t, s.Light().Update(l))
+
+	l, _ = s.Light().Find(l.ID)
+	assert.Equal(t, 70, l.Radius)
+}
+
+func TestLightRepository_Delete(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("lights", "scenes", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	l := model.TestLight(t, sc)
+	s.Light().Create(l)
+	assert.NoError(t, s.Light().Delete(l.ID))
+	assert.EqualError(t, s.Light().Delete(l.ID), store.ErrRecordNotFound.Error())
+}
+
+func TestLightRepository_Restore(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("lights", "scenes", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	l := model.TestLight(t, sc)
+	s.Light().Create(l)
+	s.Light().Delete(l.ID)
+	assert.NoError(t, s.Light().Restore(l))
+
+	found, err := s.Light().Find(l.ID)
+	assert.NoError(t, err)
+	assert.True(t, l.CreatedAt.Equal(found.CreatedAt))
+	assert.EqualError(t, s.Light().Restore(l), store.ErrAlreadyExists.Error())
+}
@@ -0,0 +1,117 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+const packColumns = "id, owner_id, name, system, description, description_html, latest_version, created_at, updated_at"
+
+type PackRepository struct {
+	store *Store
+}
+
+func (r *PackRepository) Create(p *model.Pack) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO packs (owner_id, name, system, description, description_html) VALUES ($1, $2, $3, $4, $5) "+
+			"RETURNING id, latest_version, created_at, updated_at",
+		p.OwnerID,
+		p.Name,
+		p.System,
+		p.Description,
+		p.DescriptionHTML,
+	).Scan(&p.ID, &p.LatestVersion, &p.CreatedAt, &p.UpdatedAt)
+}
+
+func (r *PackRepository) Find(id uuid.UUID) (*model.Pack, error) {
+	p, err := scanPack(r.store.db.QueryRow("SELECT "+packColumns+" FROM packs WHERE id=$1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (r *PackRepository) FindAll(filter *model.PackFilter) ([]*model.Pack, error) {
+	conditions := []string{"true"}
+	args := []interface{}{}
+	if filter.OwnerID != nil {
+		args = append(args, *filter.OwnerID)
+		conditions = append(conditions, fmt.Sprintf("owner_id=$%d", len(args)))
+	}
+	if filter.System != "" {
+		args = append(args, filter.System)
+		conditions = append(conditions, fmt.Sprintf("system=$%d", len(args)))
+	}
+
+	rows, err := r.store.db.Query("SELECT "+packColumns+" FROM packs WHERE "+strings.Join(conditions, " AND ")+" ORDER BY name, id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	packs := []*model.Pack{}
+	for rows.Next() {
+		p, err := scanPack(rows)
+		if err != nil {
+			return nil, err
+		}
+		packs = append(packs, p)
+	}
+
+	return packs, rows.Err()
+}
+
+func (r *PackRepository) Update(p *model.Pack) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.store.db.QueryRow(
+		"UPDATE packs SET name=$2, description=$3, description_html=$4, updated_at=now() WHERE id=$1 RETURNING updated_at",
+		p.ID,
+		p.Name,
+		p.Description,
+		p.DescriptionHTML,
+	).Scan(&p.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func scanPack(row scanner) (*model.Pack, error) {
+	p := &model.Pack{}
+	if err := row.Scan(
+		&p.ID,
+		&p.OwnerID,
+		&p.Name,
+		&p.System,
+		&p.Description,
+		&p.DescriptionHTML,
+		&p.LatestVersion,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
@@ -0,0 +1,99 @@
+package sqlstore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/sqlstore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("packs", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	p := model.TestPack(t, u)
+	assert.NoError(t, s.Pack().Create(p))
+	assert.NotEqual(t, uuid.Nil, p.ID)
+	assert.Equal(t, 0, p.LatestVersion)
+
+	p = model.TestPack(t, u)
+	p.Name = ""
+	assert.Error(t, s.Pack().Create(p))
+}
+
+func TestPackRepository_Find(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("packs", "users")
+
+	s := sqlstore.New(db)
+	_, err := s.Pack().Find(uuid.New())
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	u := model.TestUser(t)
+	s.User().Create(u)
+	p := model.TestPack(t, u)
+	s.Pack().Create(p)
+
+	found, err := s.Pack().Find(p.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, p.Name, found.Name)
+	assert.Equal(t, u.ID, found.OwnerID)
+}
+
+func TestPackRepository_FindAll(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("packs", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	other := model.TestUser(t)
+	other.Email = "other@example.org"
+	s.User().Create(other)
+
+	p1 := model.TestPack(t, u)
+	s.Pack().Create(p1)
+	p2 := model.TestPack(t, other)
+	p2.Name = "Grimoire of Sorrows"
+	s.Pack().Create(p2)
+	p3 := model.TestPack(t, other)
+	p3.System = "pf2e"
+	s.Pack().Create(p3)
+
+	packs, err := s.Pack().FindAll(&model.PackFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, packs, 3)
+
+	packs, err = s.Pack().FindAll(&model.PackFilter{OwnerID: &other.ID, System: "dnd5e"})
+	assert.NoError(t, err)
+	if assert.Len(t, packs, 1) {
+		assert.Equal(t, p2.ID, packs[0].ID)
+	}
+}
+
+func TestPackRepository_Update(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("packs", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	p := model.TestPack(t, u)
+	s.Pack().Create(p)
+
+	p.Name = "Tome of Barovia, Revised"
+	assert.NoError(t, s.Pack().Update(p))
+	found, _ := s.Pack().Find(p.ID)
+	assert.Equal(t, p.Name, found.Name)
+
+	missing := model.TestPack(t, u)
+	missing.ID = uuid.New()
+	assert.EqualError(t, s.Pack().Update(missing), store.ErrRecordNotFound.Error())
+}
@@ -0,0 +1,122 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type PackVersionRepository struct {
+	store *Store
+}
+
+func (r *PackVersionRepository) Create(v *model.PackVersion, entries []*model.CompendiumEntry) error {
+	for _, e := range entries {
+		if err := e.Validate(); err != nil {
+			return err
+		}
+	}
+
+	b, err := json.Marshal(v.Entries)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.store.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(
+		"INSERT INTO pack_versions (pack_id, version, changelog, entries) VALUES ($1, $2, $3, $4) RETURNING created_at",
+		v.PackID,
+		v.Version,
+		v.Changelog,
+		b,
+	).Scan(&v.CreatedAt); err != nil {
+		if isUniqueViolation(err) {
+			return store.ErrConflict
+		}
+		if isForeignKeyViolation(err) {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE packs SET latest_version=$2, updated_at=now() WHERE id=$1 AND latest_version<$2",
+		v.PackID,
+		v.Version,
+	); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if err := tx.QueryRow(
+			"INSERT INTO compendium_entries (system, kind, source, name, body, body_html, data, pack_id, pack_version) "+
+				"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at, updated_at",
+			e.System,
+			e.Kind,
+			e.Source,
+			e.Name,
+			e.Body,
+			e.BodyHTML,
+			[]byte(e.Data),
+			v.PackID,
+			v.Version,
+		).Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *PackVersionRepository) Find(packID uuid.UUID, version int) (*model.PackVersion, error) {
+	v := &model.PackVersion{}
+	entries := []byte{}
+	if err := r.store.db.QueryRow(
+		"SELECT pack_id, version, changelog, entries, created_at FROM pack_versions WHERE pack_id=$1 AND version=$2",
+		packID,
+		version,
+	).Scan(&v.PackID, &v.Version, &v.Changelog, &entries, &v.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	if err := json.Unmarshal(entries, &v.Entries); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func (r *PackVersionRepository) FindAll(packID uuid.UUID) ([]*model.PackVersion, error) {
+	rows, err := r.store.db.Query(
+		"SELECT pack_id, version, changelog, created_at FROM pack_versions WHERE pack_id=$1 ORDER BY version",
+		packID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []*model.PackVersion{}
+	for rows.Next() {
+		v := &model.PackVersion{}
+		if err := rows.Scan(&v.PackID, &v.Version, &v.Changelog, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, rows.Err()
+}
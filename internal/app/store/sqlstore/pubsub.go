@@ -0,0 +1,154 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	pubSubChannel = "campaign_events"
+
+	// Postgres rejects NOTIFY payloads of 8000 bytes or more. Bigger events
+	// are stored in realtime_events and only their id is sent.
+	notifyPayloadLimit = 7900
+	eventRetention     = 5 * time.Minute
+	listenerPing       = 90 * time.Second
+)
+
+// PubSub is a realtime.PubSub backed by Postgres LISTEN/NOTIFY, so several
+// apiserver instances can share events without an extra broker.
+type PubSub struct {
+	db       *sql.DB
+	listener *pq.Listener
+	logger   *logrus.Logger
+	mu       sync.RWMutex
+	handlers []func(*realtime.Event)
+	done     chan struct{}
+}
+
+type notification struct {
+	Ref string `json:"ref"`
+}
+
+func NewPubSub(db *sql.DB, databaseURL string, logger *logrus.Logger) (*PubSub, error) {
+	l := pq.NewListener(databaseURL, 10*time.Millisecond, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.Error(err.Error())
+		}
+
+		if ev == pq.ListenerEventReconnected {
+			logger.Warn("realtime listener reconnected, events sent meanwhile were lost")
+		}
+	})
+	if err := l.Listen(pubSubChannel); err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	p := &PubSub{
+		db:       db,
+		listener: l,
+		logger:   logger,
+		done:     make(chan struct{}),
+	}
+	go p.listen()
+
+	return p, nil
+}
+
+func (p *PubSub) Publish(e *realtime.Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	if len(b) > notifyPayloadLimit {
+		if _, err := p.db.Exec(
+			"DELETE FROM realtime_events WHERE created_at < $1",
+			time.Now().Add(-eventRetention),
+		); err != nil {
+			return err
+		}
+
+		if _, err := p.db.Exec("INSERT INTO realtime_events (id, event) VALUES ($1, $2)", e.ID, b); err != nil {
+			return err
+		}
+
+		b, err = json.Marshal(&notification{Ref: e.ID})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = p.db.Exec("SELECT pg_notify($1, $2)", pubSubChannel, string(b))
+
+	return err
+}
+
+func (p *PubSub) Subscribe(h func(*realtime.Event)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.handlers = append(p.handlers, h)
+}
+
+func (p *PubSub) Close() error {
+	close(p.done)
+
+	return p.listener.Close()
+}
+
+func (p *PubSub) listen() {
+	for {
+		select {
+		case n := <-p.listener.Notify:
+			// A nil notification is sent after the connection was re-established.
+			if n == nil {
+				continue
+			}
+
+			e, err := p.decode(n.Extra)
+			if err != nil {
+				p.logger.Error(err.Error())
+				continue
+			}
+
+			p.mu.RLock()
+			for _, h := range p.handlers {
+				h(e)
+			}
+			p.mu.RUnlock()
+		case <-time.After(listenerPing):
+			go p.listener.Ping()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *PubSub) decode(payload string) (*realtime.Event, error) {
+	n := &notification{}
+	if err := json.Unmarshal([]byte(payload), n); err != nil {
+		return nil, err
+	}
+
+	b := []byte(payload)
+	if n.Ref != "" {
+		if err := p.db.QueryRow("SELECT event FROM realtime_events WHERE id=$1", n.Ref).Scan(&b); err != nil {
+			return nil, err
+		}
+	}
+
+	e := &realtime.Event{}
+	if err := json.Unmarshal(b, e); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
@@ -0,0 +1,52 @@
+package sqlstore_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/realtime"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/sqlstore"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPubSub(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("realtime_events")
+
+	publisher, err := sqlstore.NewPubSub(db, databaseURL, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer publisher.Close()
+
+	subscriber, err := sqlstore.NewPubSub(db, databaseURL, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer subscriber.Close()
+
+	received := make(chan *realtime.Event, 2)
+	subscriber.Subscribe(func(e *realtime.Event) {
+		received <- e
+	})
+
+	small, _ := realtime.NewEvent(realtime.EventRollCreated, uuid.New(), nil, "1d20")
+	small.Audience = &realtime.Audience{GMOnly: true}
+	big, _ := realtime.NewEvent(realtime.EventRollCreated, uuid.New(), nil, strings.Repeat("a", 10000))
+
+	for _, e := range []*realtime.Event{small, big} {
+		assert.NoError(t, publisher.Publish(e))
+
+		select {
+		case got := <-received:
+			assert.Equal(t, e.ID, got.ID)
+			assert.Equal(t, e.Audience, got.Audience)
+			assert.JSONEq(t, string(e.Payload), string(got.Payload))
+		case <-time.After(5 * time.Second):
+			t.Fatal("event not received")
+		}
+	}
+}
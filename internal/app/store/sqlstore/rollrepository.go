@@ -0,0 +1,235 @@
+package sqlstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/dice"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+)
+
+type RollRepository struct {
+	store *Store
+}
+
+func (r *RollRepository) Create(roll *model.Roll) error {
+	if err := roll.Validate(); err != nil {
+		return err
+	}
+
+	tx, err := r.store.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var outcome []byte
+	if roll.Outcome != nil {
+		if outcome, err = json.Marshal(roll.Outcome); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.QueryRow(
+		"INSERT INTO rolls (campaign_id, user_id, character_id, expression, modifier, total, outcome) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at",
+		roll.CampaignID,
+		roll.UserID,
+		roll.CharacterID,
+		roll.Expression,
+		roll.Modifier,
+		roll.Total,
+		outcome,
+	).Scan(&roll.ID, &roll.CreatedAt); err != nil {
+		return err
+	}
+
+	for i, d := range roll.Dice {
+		if _, err := tx.Exec(
+			"INSERT INTO roll_dice (roll_id, position, sides, value, dropped) VALUES ($1, $2, $3, $4, $5)",
+			roll.ID,
+			i,
+			d.Sides,
+			d.Value,
+			d.Dropped,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *RollRepository) FindAll(campaignID uuid.UUID, filter *model.RollFilter) ([]*model.Roll, error) {
+	where, args := rollConditions(campaignID, filter)
+	query := "SELECT r.id, r.campaign_id, r.user_id, r.character_id, r.expression, r.modifier, r.total, r.outcome, r.created_at FROM rolls r WHERE " +
+		where + " ORDER BY r.created_at DESC, r.id"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.store.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rolls := []*model.Roll{}
+	byID := map[uuid.UUID]*model.Roll{}
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		roll := &model.Roll{Dice: []dice.Die{}}
+		characterID := uuid.NullUUID{}
+		outcome := []byte{}
+		if err := rows.Scan(
+			&roll.ID,
+			&roll.CampaignID,
+			&roll.UserID,
+			&characterID,
+			&roll.Expression,
+			&roll.Modifier,
+			&roll.Total,
+			&outcome,
+			&roll.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if characterID.Valid {
+			roll.CharacterID = &characterID.UUID
+		}
+		if len(outcome) > 0 {
+			if err := json.Unmarshal(outcome, &roll.Outcome); err != nil {
+				return nil, err
+			}
+		}
+
+		rolls = append(rolls, roll)
+		byID[roll.ID] = roll
+		ids = append(ids, roll.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return rolls, nil
+	}
+
+	diceRows, err := r.store.db.Query(
+		"SELECT roll_id, sides, value, dropped FROM roll_dice WHERE roll_id = ANY($1::uuid[]) ORDER BY roll_id, position",
+		uuidArray(ids),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer diceRows.Close()
+
+	for diceRows.Next() {
+		var rollID uuid.UUID
+		d := dice.Die{}
+		if err := diceRows.Scan(&rollID, &d.Sides, &d.Value, &d.Dropped); err != nil {
+			return nil, err
+		}
+		byID[rollID].Dice = append(byID[rollID].Dice, d)
+	}
+
+	return rolls, diceRows.Err()
+}
+
+func (r *RollRepository) Stats(campaignID uuid.UUID, filter *model.RollFilter) ([]*model.RollStats, error) {
+	f := *filter
+	f.Sides = 0
+	where, args := rollConditions(campaignID, &f)
+	if filter.Sides > 0 {
+		args = append(args, filter.Sides)
+		where += fmt.Sprintf(" AND d.sides = $%d", len(args))
+	}
+
+	rows, err := r.store.db.Query(
+		"SELECT d.sides, COUNT(*), AVG(d.value)::float8, "+
+			"COUNT(*) FILTER (WHERE d.value = d.sides), COUNT(*) FILTER (WHERE d.value = 1) "+
+			"FROM roll_dice d JOIN rolls r ON r.id = d.roll_id WHERE "+where+
+			" GROUP BY d.sides ORDER BY d.sides",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []*model.RollStats{}
+	bySides := map[int]*model.RollStats{}
+	for rows.Next() {
+		s := &model.RollStats{Distribution: map[int]int{}}
+		if err := rows.Scan(&s.Sides, &s.Count, &s.Average, &s.Crits, &s.Fumbles); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+		bySides[s.Sides] = s
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	distRows, err := r.store.db.Query(
+		"SELECT d.sides, d.value, COUNT(*) FROM roll_dice d JOIN rolls r ON r.id = d.roll_id WHERE "+where+
+			" GROUP BY d.sides, d.value",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer distRows.Close()
+
+	for distRows.Next() {
+		var sides, value, count int
+		if err := distRows.Scan(&sides, &value, &count); err != nil {
+			return nil, err
+		}
+		if s, ok := bySides[sides]; ok {
+			s.Distribution[value] = count
+		}
+	}
+	if err := distRows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, s := range stats {
+		s.ComputeFairness()
+	}
+
+	return stats, nil
+}
+
+func rollConditions(campaignID uuid.UUID, filter *model.RollFilter) (string, []interface{}) {
+	conds := []string{"r.campaign_id = $1"}
+	args := []interface{}{campaignID}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.UserID != nil {
+		add("r.user_id = $%d", *filter.UserID)
+	}
+	if filter.CharacterID != nil {
+		add("r.character_id = $%d", *filter.CharacterID)
+	}
+	if !filter.From.IsZero() {
+		add("r.created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("r.created_at < $%d", filter.To)
+	}
+	if filter.Sides > 0 {
+		add("EXISTS (SELECT 1 FROM roll_dice rd WHERE rd.roll_id = r.id AND rd.sides = $%d)", filter.Sides)
+	}
+
+	return strings.Join(conds, " AND "), args
+}
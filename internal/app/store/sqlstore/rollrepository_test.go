@@ -0,0 +1,106 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/dice"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/ruleset"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/sqlstore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("rolls", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	r := model.TestRoll(t, c, u)
+	assert.NoError(t, s.Roll().Create(r))
+	assert.NotEqual(t, uuid.Nil, r.ID)
+
+	crit := model.TestRoll(t, c, u)
+	crit.Outcome = &ruleset.Outcome{Critical: true}
+	assert.NoError(t, s.Roll().Create(crit))
+
+	rolls, err := s.Roll().FindAll(c.ID, &model.RollFilter{})
+	assert.NoError(t, err)
+	for _, found := range rolls {
+		if found.ID == crit.ID {
+			assert.Equal(t, crit.Outcome, found.Outcome)
+		} else {
+			assert.Nil(t, found.Outcome)
+		}
+	}
+}
+
+func TestRollRepository_FindAll(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("rolls", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	for _, sides := range []int{20, 20, 6} {
+		r := model.TestRoll(t, c, u)
+		r.Dice = []dice.Die{{Sides: sides, Value: 1}}
+		s.Roll().Create(r)
+	}
+
+	rolls, err := s.Roll().FindAll(c.ID, &model.RollFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, rolls, 3)
+	assert.Len(t, rolls[0].Dice, 1)
+
+	rolls, err = s.Roll().FindAll(c.ID, &model.RollFilter{Sides: 20})
+	assert.NoError(t, err)
+	assert.Len(t, rolls, 2)
+
+	rolls, err = s.Roll().FindAll(c.ID, &model.RollFilter{From: time.Now().Add(time.Hour)})
+	assert.NoError(t, err)
+	assert.Empty(t, rolls)
+
+	rolls, err = s.Roll().FindAll(c.ID, &model.RollFilter{UserID: &u.ID, Limit: 1, Offset: 1})
+	assert.NoError(t, err)
+	assert.Len(t, rolls, 1)
+}
+
+func TestRollRepository_Stats(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("rolls", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	r := model.TestRoll(t, c, u)
+	r.Dice = []dice.Die{{Sides: 20, Value: 20}, {Sides: 20, Value: 1}, {Sides: 6, Value: 4}}
+	s.Roll().Create(r)
+
+	stats, err := s.Roll().Stats(c.ID, &model.RollFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, stats, 2)
+	assert.Equal(t, 6, stats[0].Sides)
+	assert.Equal(t, 20, stats[1].Sides)
+	assert.Equal(t, 2, stats[1].Count)
+	assert.Equal(t, 10.5, stats[1].Average)
+	assert.Equal(t, 1, stats[1].Crits)
+	assert.Equal(t, 1, stats[1].Fumbles)
+
+	stats, err = s.Roll().Stats(c.ID, &model.RollFilter{Sides: 6})
+	assert.NoError(t, err)
+	assert.Len(t, stats, 1)
+	assert.Equal(t, map[int]int{4: 1}, stats[0].Distribution)
+}
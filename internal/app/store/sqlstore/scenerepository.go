@@ -0,0 +1,160 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+const sceneColumns = "id, campaign_id, name, background, grid_type, grid_size, grid_offset_x, grid_offset_y, " +
+	"hex_orientation, diagonals, grid_distance, width, height, free_movement, enforce_movement, fog_of_war, dark, " +
+	"created_at, updated_at"
+
+type SceneRepository struct {
+	store *Store
+}
+
+func (r *SceneRepository) Create(s *model.Scene) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO scenes (campaign_id, name, background, grid_type, grid_size, grid_offset_x, grid_offset_y, hex_orientation, "+
+			"diagonals, grid_distance, width, height, free_movement, enforce_movement, fog_of_war, dark) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) RETURNING id, created_at, updated_at",
+		s.CampaignID,
+		s.Name,
+		s.Background,
+		s.GridType,
+		s.GridSize,
+		s.GridOffsetX,
+		s.GridOffsetY,
+		s.HexOrientation,
+		s.Diagonals,
+		s.GridDistance,
+		s.Width,
+		s.Height,
+		s.FreeMovement,
+		s.EnforceMovement,
+		s.FogOfWar,
+		s.Dark,
+	).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+}
+
+func (r *SceneRepository) Find(id uuid.UUID) (*model.Scene, error) {
+	s, err := scanScene(r.store.db.QueryRow("SELECT "+sceneColumns+" FROM scenes WHERE id=$1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (r *SceneRepository) FindAll(campaignID uuid.UUID) ([]*model.Scene, error) {
+	rows, err := r.store.db.Query(
+		"SELECT "+sceneColumns+" FROM scenes WHERE campaign_id=$1 ORDER BY name, id",
+		campaignID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scenes := []*model.Scene{}
+	for rows.Next() {
+		s, err := scanScene(rows)
+		if err != nil {
+			return nil, err
+		}
+		scenes = append(scenes, s)
+	}
+
+	return scenes, rows.Err()
+}
+
+func (r *SceneRepository) Update(s *model.Scene) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.store.db.QueryRow(
+		"UPDATE scenes SET name=$2, background=$3, grid_type=$4, grid_size=$5, grid_offset_x=$6, grid_offset_y=$7, "+
+			"hex_orientation=$8, diagonals=$9, grid_distance=$10, width=$11, height=$12, free_movement=$13, "+
+			"enforce_movement=$14, fog_of_war=$15, dark=$16, updated_at=now() WHERE id=$1 RETURNING updated_at",
+		s.ID,
+		s.Name,
+		s.Background,
+		s.GridType,
+		s.GridSize,
+		s.GridOffsetX,
+		s.GridOffsetY,
+		s.HexOrientation,
+		s.Diagonals,
+		s.GridDistance,
+		s.Width,
+		s.Height,
+		s.FreeMovement,
+		s.EnforceMovement,
+		s.FogOfWar,
+		s.Dark,
+	).Scan(&s.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *SceneRepository) Delete(id uuid.UUID) error {
+	res, err := r.store.db.Exec("DELETE FROM scenes WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func scanScene(row scanner) (*model.Scene, error) {
+	s := &model.Scene{}
+	if err := row.Scan(
+		&s.ID,
+		&s.CampaignID,
+		&s.Name,
+		&s.Background,
+		&s.GridType,
+		&s.GridSize,
+		&s.GridOffsetX,
+		&s.GridOffsetY,
+		&s.HexOrientation,
+		&s.Diagonals,
+		&s.GridDistance,
+		&s.Width,
+		&s.Height,
+		&s.FreeMovement,
+		&s.EnforceMovement,
+		&s.FogOfWar,
+		&s.Dark,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
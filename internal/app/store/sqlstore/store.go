@@ -8,8 +8,32 @@ import (
 )
 
 type Store struct {
-	db	   		   *sql.DB
+	db	   		   querier
 	UserRepository *UserRepository
+	CampaignRepository *CampaignRepository
+	RollRepository *RollRepository
+	ChatMessageRepository *ChatMessageRepository
+	CharacterRepository *CharacterRepository
+	CharacterRevisionRepository *CharacterRevisionRepository
+	SceneRepository *SceneRepository
+	TokenRepository *TokenRepository
+	WallRepository *WallRepository
+	LightRepository *LightRepository
+	ExplorationRepository *ExplorationRepository
+	CombatRepository *CombatRepository
+	CombatantRepository *CombatantRepository
+	AssetRepository *AssetRepository
+	FolderRepository *FolderRepository
+	UploadRepository *UploadRepository
+	UsageRepository *UsageRepository
+	JournalRepository *JournalRepository
+	JournalFolderRepository *JournalFolderRepository
+	JournalOperationRepository *JournalOperationRepository
+	CompendiumRepository *CompendiumRepository
+	PackRepository *PackRepository
+	PackVersionRepository *PackVersionRepository
+	CampaignPackRepository *CampaignPackRepository
+	ActionRepository *ActionRepository
 }
 
 func New(db *sql.DB) *Store {
@@ -18,6 +42,22 @@ func New(db *sql.DB) *Store {
 	}
 }
 
+// Transaction runs fn with a store bound to one transaction, committed if
+// fn succeeds and rolled back otherwise. Nested, it uses a savepoint.
+func (s *Store) Transaction(fn func(store.Store) error) error {
+	tx, err := s.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&Store{db: tx}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func (s *Store) User() store.UserRepository {
 	if s.UserRepository != nil {
 		return s.UserRepository
@@ -28,4 +68,292 @@ func (s *Store) User() store.UserRepository {
 	}
 
 	return s.UserRepository
-}
\ No newline at end of file
+}
+
+func (s *Store) Campaign() store.CampaignRepository {
+	if s.CampaignRepository != nil {
+		return s.CampaignRepository
+	}
+
+	s.CampaignRepository = &CampaignRepository{
+		store: s,
+	}
+
+	return s.CampaignRepository
+}
+
+func (s *Store) Roll() store.RollRepository {
+	if s.RollRepository != nil {
+		return s.RollRepository
+	}
+
+	s.RollRepository = &RollRepository{
+		store: s,
+	}
+
+	return s.RollRepository
+}
+
+func (s *Store) ChatMessage() store.ChatMessageRepository {
+	if s.ChatMessageRepository != nil {
+		return s.ChatMessageRepository
+	}
+
+	s.ChatMessageRepository = &ChatMessageRepository{
+		store: s,
+	}
+
+	return s.ChatMessageRepository
+}
+
+func (s *Store) Character() store.CharacterRepository {
+	if s.CharacterRepository != nil {
+		return s.CharacterRepository
+	}
+
+	s.CharacterRepository = &CharacterRepository{
+		store: s,
+	}
+
+	return s.CharacterRepository
+}
+
+func (s *Store) CharacterRevision() store.CharacterRevisionRepository {
+	if s.CharacterRevisionRepository != nil {
+		return s.CharacterRevisionRepository
+	}
+
+	s.CharacterRevisionRepository = &CharacterRevisionRepository{
+		store: s,
+	}
+
+	return s.CharacterRevisionRepository
+}
+
+func (s *Store) Scene() store.SceneRepository {
+	if s.SceneRepository != nil {
+		return s.SceneRepository
+	}
+
+	s.SceneRepository = &SceneRepository{
+		store: s,
+	}
+
+	return s.SceneRepository
+}
+
+func (s *Store) Token() store.TokenRepository {
+	if s.TokenRepository != nil {
+		return s.TokenRepository
+	}
+
+	s.TokenRepository = &TokenRepository{
+		store: s,
+	}
+
+	return s.TokenRepository
+}
+
+func (s *Store) Wall() store.WallRepository {
+	if s.WallRepository != nil {
+		return s.WallRepository
+	}
+
+	s.WallRepository = &WallRepository{
+		store: s,
+	}
+
+	return s.WallRepository
+}
+
+func (s *Store) Light() store.LightRepository {
+	if s.LightRepository != nil {
+		return s.LightRepository
+	}
+
+	s.LightRepository = &LightRepository{
+		store: s,
+	}
+
+	return s.LightRepository
+}
+
+func (s *Store) Exploration() store.ExplorationRepository {
+	if s.ExplorationRepository != nil {
+		return s.ExplorationRepository
+	}
+
+	s.ExplorationRepository = &ExplorationRepository{
+		store: s,
+	}
+
+	return s.ExplorationRepository
+}
+
+func (s *Store) Combat() store.CombatRepository {
+	if s.CombatRepository != nil {
+		return s.CombatRepository
+	}
+
+	s.CombatRepository = &CombatRepository{
+		store: s,
+	}
+
+	return s.CombatRepository
+}
+
+func (s *Store) Combatant() store.CombatantRepository {
+	if s.CombatantRepository != nil {
+		return s.CombatantRepository
+	}
+
+	s.CombatantRepository = &CombatantRepository{
+		store: s,
+	}
+
+	return s.CombatantRepository
+}
+
+func (s *Store) Asset() store.AssetRepository {
+	if s.AssetRepository != nil {
+		return s.AssetRepository
+	}
+
+	s.AssetRepository = &AssetRepository{
+		store: s,
+	}
+
+	return s.AssetRepository
+}
+
+func (s *Store) Folder() store.FolderRepository {
+	if s.FolderRepository != nil {
+		return s.FolderRepository
+	}
+
+	s.FolderRepository = &FolderRepository{
+		store: s,
+	}
+
+	return s.FolderRepository
+}
+
+func (s *Store) Upload() store.UploadRepository {
+	if s.UploadRepository != nil {
+		return s.UploadRepository
+	}
+
+	s.UploadRepository = &UploadRepository{
+		store: s,
+	}
+
+	return s.UploadRepository
+}
+
+func (s *Store) Usage() store.UsageRepository {
+	if s.UsageRepository != nil {
+		return s.UsageRepository
+	}
+
+	s.UsageRepository = &UsageRepository{
+		store: s,
+	}
+
+	return s.UsageRepository
+}
+
+func (s *Store) Journal() store.JournalRepository {
+	if s.JournalRepository != nil {
+		return s.JournalRepository
+	}
+
+	s.JournalRepository = &JournalRepository{
+		store: s,
+	}
+
+	return s.JournalRepository
+}
+
+func (s *Store) JournalFolder() store.JournalFolderRepository {
+	if s.JournalFolderRepository != nil {
+		return s.JournalFolderRepository
+	}
+
+	s.JournalFolderRepository = &JournalFolderRepository{
+		store: s,
+	}
+
+	return s.JournalFolderRepository
+}
+
+func (s *Store) JournalOperation() store.JournalOperationRepository {
+	if s.JournalOperationRepository != nil {
+		return s.JournalOperationRepository
+	}
+
+	s.JournalOperationRepository = &JournalOperationRepository{
+		store: s,
+	}
+
+	return s.JournalOperationRepository
+}
+
+func (s *Store) Compendium() store.CompendiumRepository {
+	if s.CompendiumRepository != nil {
+		return s.CompendiumRepository
+	}
+
+	s.CompendiumRepository = &CompendiumRepository{
+		store: s,
+	}
+
+	return s.CompendiumRepository
+}
+
+func (s *Store) Pack() store.PackRepository {
+	if s.PackRepository != nil {
+		return s.PackRepository
+	}
+
+	s.PackRepository = &PackRepository{
+		store: s,
+	}
+
+	return s.PackRepository
+}
+
+func (s *Store) PackVersion() store.PackVersionRepository {
+	if s.PackVersionRepository != nil {
+		return s.PackVersionRepository
+	}
+
+	s.PackVersionRepository = &PackVersionRepository{
+		store: s,
+	}
+
+	return s.PackVersionRepository
+}
+
+func (s *Store) CampaignPack() store.CampaignPackRepository {
+	if s.CampaignPackRepository != nil {
+		return s.CampaignPackRepository
+	}
+
+	s.CampaignPackRepository = &CampaignPackRepository{
+		store: s,
+	}
+
+	return s.CampaignPackRepository
+}
+
+func (s *Store) Action() store.ActionRepository {
+	if s.ActionRepository != nil {
+		return s.ActionRepository
+	}
+
+	s.ActionRepository = &ActionRepository{
+		store: s,
+	}
+
+	return s.ActionRepository
+}
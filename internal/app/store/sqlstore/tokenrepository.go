@@ -0,0 +1,194 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+const tokenColumns = "id, scene_id, name, image, x, y, size, rotation, vision, speed, moved, layer, owner_id, character_id, " +
+	"created_at, updated_at"
+
+type TokenRepository struct {
+	store *Store
+}
+
+func (r *TokenRepository) Create(t *model.Token) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO tokens (scene_id, name, image, x, y, size, rotation, vision, speed, moved, layer, owner_id, character_id) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id, created_at, updated_at",
+		t.SceneID,
+		t.Name,
+		t.Image,
+		t.X,
+		t.Y,
+		t.Size,
+		t.Rotation,
+		t.Vision,
+		t.Speed,
+		t.Moved,
+		t.Layer,
+		t.OwnerID,
+		t.CharacterID,
+	).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+}
+
+func (r *TokenRepository) Find(id uuid.UUID) (*model.Token, error) {
+	return r.find("SELECT "+tokenColumns+" FROM tokens WHERE id=$1", id)
+}
+
+func (r *TokenRepository) Lock(id uuid.UUID) (*model.Token, error) {
+	return r.find("SELECT "+tokenColumns+" FROM tokens WHERE id=$1 FOR UPDATE", id)
+}
+
+func (r *TokenRepository) find(query string, id uuid.UUID) (*model.Token, error) {
+	t, err := scanToken(r.store.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (r *TokenRepository) FindAll(sceneID uuid.UUID) ([]*model.Token, error) {
+	rows, err := r.store.db.Query(
+		"SELECT "+tokenColumns+" FROM tokens WHERE scene_id=$1 ORDER BY created_at, id",
+		sceneID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*model.Token{}
+	for rows.Next() {
+		t, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (r *TokenRepository) Update(t *model.Token) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.store.db.QueryRow(
+		"UPDATE tokens SET name=$2, image=$3, x=$4, y=$5, size=$6, rotation=$7, vision=$8, speed=$9, moved=$10, "+
+			"layer=$11, owner_id=$12, character_id=$13, "+
+			"updated_at=now() WHERE id=$1 RETURNING updated_at",
+		t.ID,
+		t.Name,
+		t.Image,
+		t.X,
+		t.Y,
+		t.Size,
+		t.Rotation,
+		t.Vision,
+		t.Speed,
+		t.Moved,
+		t.Layer,
+		t.OwnerID,
+		t.CharacterID,
+	).Scan(&t.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) Restore(t *model.Token) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := r.store.db.Exec(
+		"INSERT INTO tokens ("+tokenColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)",
+		t.ID,
+		t.SceneID,
+		t.Name,
+		t.Image,
+		t.X,
+		t.Y,
+		t.Size,
+		t.Rotation,
+		t.Vision,
+		t.Speed,
+		t.Moved,
+		t.Layer,
+		t.OwnerID,
+		t.CharacterID,
+		t.CreatedAt,
+		t.UpdatedAt,
+	); err != nil {
+		if isUniqueViolation(err) {
+			return store.ErrAlreadyExists
+		}
+		if isForeignKeyViolation(err) {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) Delete(id uuid.UUID) error {
+	res, err := r.store.db.Exec("DELETE FROM tokens WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func scanToken(row scanner) (*model.Token, error) {
+	t := &model.Token{}
+	if err := row.Scan(
+		&t.ID,
+		&t.SceneID,
+		&t.Name,
+		&t.Image,
+		&t.X,
+		&t.Y,
+		&t.Size,
+		&t.Rotation,
+		&t.Vision,
+		&t.Speed,
+		&t.Moved,
+		&t.Layer,
+		&t.OwnerID,
+		&t.CharacterID,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
@@ -0,0 +1,178 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/sqlstore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("tokens", "scenes", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	tok := model.TestToken(t, sc, u)
+	assert.NoError(t, s.Token().Create(tok))
+	assert.NotEqual(t, uuid.Nil, tok.ID)
+
+	tok = model.TestToken(t, sc, u)
+	tok.Layer = "sky"
+	assert.Error(t, s.Token().Create(tok))
+}
+
+func TestTokenRepository_Find(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("tokens", "scenes", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	_, err := s.Token().Find(uuid.New())
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	tok := model.TestToken(t, sc, u)
+	s.Token().Create(tok)
+	found, err := s.Token().Find(tok.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, u.ID, *found.OwnerID)
+
+	tokens, err := s.Token().FindAll(sc.ID)
+	assert.NoError(t, err)
+	assert.Len(t, tokens, 1)
+}
+
+func TestTokenRepository_Lock(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("tokens", "scenes", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+	tok := model.TestToken(t, sc, u)
+	s.Token().Create(tok)
+
+	_, err := s.Token().Lock(uuid.New())
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	locked := make(chan struct{})
+	done := make(chan *model.Token)
+	assert.NoError(t, s.Transaction(func(tx store.Store) error {
+		if _, err := tx.Token().Lock(tok.ID); err != nil {
+			return err
+		}
+
+		go func() {
+			s.Transaction(func(tx store.Store) error {
+				close(locked)
+				tok, err := tx.Token().Lock(tok.ID)
+				done <- tok
+
+				return err
+			})
+		}()
+
+		<-locked
+		select {
+		case <-done:
+			t.Error("token locked twice")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		tok.X = 140
+		return tx.Token().Update(tok)
+	}))
+
+	select {
+	case tok := <-done:
+		assert.Equal(t, 140, tok.X)
+	case <-time.After(time.Second):
+		t.Error("lock not released")
+	}
+}
+
+func TestTokenRepository_Update(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("tokens", "scenes", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	tok := model.TestToken(t, sc, u)
+	s.Token().Create(tok)
+	tok.X, tok.Y = 385, 245
+	tok.Moved = 17.5
+	tok.OwnerID = nil
+	assert.NoError(t, s.Token().Update(tok))
+
+	tok, _ = s.Token().Find(tok.ID)
+	assert.Equal(t, 385, tok.X)
+	assert.Equal(t, 17.5, tok.Moved)
+	assert.Nil(t, tok.OwnerID)
+}
+
+func TestTokenRepository_Delete(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("tokens", "scenes", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	tok := model.TestToken(t, sc, u)
+	s.Token().Create(tok)
+	assert.NoError(t, s.Token().Delete(tok.ID))
+	assert.EqualError(t, s.Token().Delete(tok.ID), store.ErrRecordNotFound.Error())
+}
+
+func TestTokenRepository_Restore(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("tokens", "scenes", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	tok := model.TestToken(t, sc, u)
+	s.Token().Create(tok)
+	s.Token().Delete(tok.ID)
+	assert.NoError(t, s.Token().Restore(tok))
+
+	found, err := s.Token().Find(tok.ID)
+	assert.NoError(t, err)
+	assert.True(t, tok.CreatedAt.Equal(found.CreatedAt))
+	assert.EqualError(t, s.Token().Restore(tok), store.ErrAlreadyExists.Error())
+}
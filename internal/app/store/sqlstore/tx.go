@@ -0,0 +1,67 @@
+package sqlstore
+
+import (
+	"database/sql"
+)
+
+// querier runs statements on the database, or within a transaction.
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+type tx interface {
+	querier
+	Commit() error
+	Rollback() error
+}
+
+// begin starts a transaction, or a savepoint when the store is bound to a
+// transaction already, so repositories needing one can nest in
+// Store.Transaction.
+func (s *Store) begin() (tx, error) {
+	if db, ok := s.db.(*sql.DB); ok {
+		return db.Begin()
+	}
+
+	if _, err := s.db.Exec("SAVEPOINT nested"); err != nil {
+		return nil, err
+	}
+
+	return &savepoint{querier: s.db}, nil
+}
+
+// savepoint is a transaction nested in another. Savepoints of the same name
+// shadow each other, so nesting them is fine as long as they end in order.
+type savepoint struct {
+	querier
+	done bool
+}
+
+func (sp *savepoint) Commit() error {
+	if sp.done {
+		return sql.ErrTxDone
+	}
+	sp.done = true
+
+	_, err := sp.Exec("RELEASE SAVEPOINT nested")
+
+	return err
+}
+
+func (sp *savepoint) Rollback() error {
+	if sp.done {
+		return sql.ErrTxDone
+	}
+	sp.done = true
+
+	// Rolling back keeps the savepoint, which would shadow the ones it's
+	// nested in, so it's released as well.
+	if _, err := sp.Exec("ROLLBACK TO SAVEPOINT nested"); err != nil {
+		return err
+	}
+	_, err := sp.Exec("RELEASE SAVEPOINT nested")
+
+	return err
+}
@@ -0,0 +1,60 @@
+package sqlstore_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/sqlstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore_Transaction(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	kept := model.TestCampaign(t, u)
+	assert.NoError(t, s.Transaction(func(tx store.Store) error {
+		return tx.Campaign().Create(kept)
+	}))
+	_, err := s.Campaign().Find(kept.ID)
+	assert.NoError(t, err)
+
+	failed := errors.New("failed")
+	undone := model.TestCampaign(t, u)
+	assert.Equal(t, failed, s.Transaction(func(tx store.Store) error {
+		if err := tx.Campaign().Create(undone); err != nil {
+			return err
+		}
+
+		return failed
+	}))
+	_, err = s.Campaign().Find(undone.ID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	outer, inner := model.TestCampaign(t, u), model.TestCampaign(t, u)
+	assert.NoError(t, s.Transaction(func(tx store.Store) error {
+		if err := tx.Campaign().Create(outer); err != nil {
+			return err
+		}
+
+		assert.Equal(t, failed, tx.Transaction(func(tx store.Store) error {
+			if err := tx.Campaign().Create(inner); err != nil {
+				return err
+			}
+
+			return failed
+		}))
+
+		return nil
+	}))
+	_, err = s.Campaign().Find(outer.ID)
+	assert.NoError(t, err)
+	_, err = s.Campaign().Find(inner.ID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+}
@@ -0,0 +1,159 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const uploadColumns = "id, user_id, folder_id, campaign_id, name, size, \"offset\", tags, private, created_at, updated_at"
+
+type UploadRepository struct {
+	store *Store
+}
+
+func (r *UploadRepository) Create(u *model.Upload, defaultQuota int64) error {
+	if err := u.Validate(); err != nil {
+		return err
+	}
+
+	if u.Tags == nil {
+		u.Tags = []string{}
+	}
+
+	tx, err := r.store.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Same as for assets, the lock serializes the user's reservations.
+	usage, err := lockUsage(tx, u.UserID)
+	if err != nil {
+		return err
+	}
+
+	if usage.Used+usage.Reserved+u.Size > usage.Limit(defaultQuota) {
+		return store.ErrQuotaExceeded
+	}
+
+	if err := tx.QueryRow(
+		"INSERT INTO uploads (user_id, folder_id, campaign_id, name, size, \"offset\", tags, private) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at, updated_at",
+		u.UserID,
+		u.FolderID,
+		u.CampaignID,
+		u.Name,
+		u.Size,
+		u.Offset,
+		pq.Array(u.Tags),
+		u.Private,
+	).Scan(&u.ID, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *UploadRepository) Find(id uuid.UUID) (*model.Upload, error) {
+	return r.find("SELECT "+uploadColumns+" FROM uploads WHERE id=$1", id)
+}
+
+func (r *UploadRepository) Lock(id uuid.UUID) (*model.Upload, error) {
+	return r.find("SELECT "+uploadColumns+" FROM uploads WHERE id=$1 FOR UPDATE", id)
+}
+
+func (r *UploadRepository) find(query string, id uuid.UUID) (*model.Upload, error) {
+	u := &model.Upload{}
+	folderID := uuid.NullUUID{}
+	campaignID := uuid.NullUUID{}
+	tags := pq.StringArray{}
+	if err := r.store.db.QueryRow(query, id).Scan(
+		&u.ID,
+		&u.UserID,
+		&folderID,
+		&campaignID,
+		&u.Name,
+		&u.Size,
+		&u.Offset,
+		&tags,
+		&u.Private,
+		&u.CreatedAt,
+		&u.UpdatedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	if folderID.Valid {
+		u.FolderID = &folderID.UUID
+	}
+	if campaignID.Valid {
+		u.CampaignID = &campaignID.UUID
+	}
+	u.Tags = []string(tags)
+
+	return u, nil
+}
+
+// Update records the progress of the upload; what it is for can't change.
+func (r *UploadRepository) Update(u *model.Upload) error {
+	if err := u.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.store.db.QueryRow(
+		"UPDATE uploads SET \"offset\"=$2, updated_at=now() WHERE id=$1 RETURNING updated_at",
+		u.ID,
+		u.Offset,
+	).Scan(&u.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *UploadRepository) Delete(id uuid.UUID) error {
+	res, err := r.store.db.Exec("DELETE FROM uploads WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *UploadRepository) DeleteStale(before time.Time) ([]uuid.UUID, error) {
+	rows, err := r.store.db.Query("DELETE FROM uploads WHERE updated_at < $1 RETURNING id", before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
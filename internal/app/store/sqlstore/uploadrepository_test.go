@@ -0,0 +1,143 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/sqlstore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("uploads", "storage_usage", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	up := model.TestUpload(t, u)
+	assert.NoError(t, s.Upload().Create(up, testQuota))
+	assert.NotEqual(t, uuid.Nil, up.ID)
+
+	up = model.TestUpload(t, u)
+	up.Size = 0
+	assert.Error(t, s.Upload().Create(up, testQuota))
+
+	usage, _ := s.Usage().Find(u.ID)
+	assert.Equal(t, int64(4096), usage.Reserved)
+	assert.EqualError(t, s.Upload().Create(model.TestUpload(t, u), 6000), store.ErrQuotaExceeded.Error())
+}
+
+func TestUploadRepository_Update(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("uploads", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	_, err := s.Upload().Find(uuid.New())
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	up := model.TestUpload(t, u)
+	s.Upload().Create(up, testQuota)
+	up.Offset = 1024
+	assert.NoError(t, s.Upload().Update(up))
+
+	up, err = s.Upload().Find(up.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1024), up.Offset)
+	assert.False(t, up.Complete())
+
+	up.Offset = up.Size + 1
+	assert.Error(t, s.Upload().Update(up))
+}
+
+func TestUploadRepository_Lock(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("uploads", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	up := model.TestUpload(t, u)
+	s.Upload().Create(up, testQuota)
+
+	_, err := s.Upload().Lock(uuid.New())
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	locked := make(chan struct{})
+	done := make(chan *model.Upload)
+	assert.NoError(t, s.Transaction(func(tx store.Store) error {
+		if _, err := tx.Upload().Lock(up.ID); err != nil {
+			return err
+		}
+
+		go func() {
+			s.Transaction(func(tx store.Store) error {
+				close(locked)
+				up, err := tx.Upload().Lock(up.ID)
+				done <- up
+
+				return err
+			})
+		}()
+
+		<-locked
+		select {
+		case <-done:
+			t.Error("upload locked twice")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		up.Offset = 1024
+		return tx.Upload().Update(up)
+	}))
+
+	select {
+	case up := <-done:
+		assert.Equal(t, int64(1024), up.Offset)
+	case <-time.After(time.Second):
+		t.Error("lock not released")
+	}
+}
+
+func TestUploadRepository_Delete(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("uploads", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	up := model.TestUpload(t, u)
+	s.Upload().Create(up, testQuota)
+	assert.NoError(t, s.Upload().Delete(up.ID))
+	assert.EqualError(t, s.Upload().Delete(up.ID), store.ErrRecordNotFound.Error())
+}
+
+func TestUploadRepository_DeleteStale(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("uploads", "storage_usage", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	up := model.TestUpload(t, u)
+	s.Upload().Create(up, testQuota)
+
+	ids, err := s.Upload().DeleteStale(up.UpdatedAt)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+
+	ids, err = s.Upload().DeleteStale(time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{up.ID}, ids)
+	_, err = s.Upload().Find(up.ID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+}
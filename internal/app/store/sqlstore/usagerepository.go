@@ -0,0 +1,82 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+)
+
+// usageColumns select a usage along with what the user's uploads reserve.
+const usageColumns = "user_id, used, (SELECT COALESCE(SUM(size), 0) FROM uploads WHERE uploads.user_id=storage_usage.user_id), quota, updated_at"
+
+type UsageRepository struct {
+	store *Store
+}
+
+func (r *UsageRepository) Find(userID uuid.UUID) (*model.Usage, error) {
+	u, err := scanUsage(r.store.db.QueryRow("SELECT "+usageColumns+" FROM storage_usage WHERE user_id=$1", userID))
+	if err == sql.ErrNoRows {
+		return &model.Usage{UserID: userID}, nil
+	}
+
+	return u, err
+}
+
+func (r *UsageRepository) SetQuota(userID uuid.UUID, quota *int64) error {
+	_, err := r.store.db.Exec(
+		"INSERT INTO storage_usage (user_id, quota) VALUES ($1, $2) "+
+			"ON CONFLICT (user_id) DO UPDATE SET quota=excluded.quota, updated_at=now()",
+		userID,
+		quota,
+	)
+
+	return err
+}
+
+func (r *UsageRepository) Campaigns(userID uuid.UUID) ([]*model.CampaignUsage, error) {
+	rows, err := r.store.db.Query(
+		"SELECT campaign_id, SUM(size) FROM (SELECT DISTINCT campaign_id, hash, size FROM assets WHERE user_id=$1 AND campaign_id IS NOT NULL) a "+
+			"GROUP BY campaign_id ORDER BY campaign_id",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usages := []*model.CampaignUsage{}
+	for rows.Next() {
+		u := &model.CampaignUsage{}
+		if err := rows.Scan(&u.CampaignID, &u.Used); err != nil {
+			return nil, err
+		}
+		usages = append(usages, u)
+	}
+
+	return usages, rows.Err()
+}
+
+// lockUsage locks the user's usage row for the rest of tx, creating it if
+// needed.
+func lockUsage(tx querier, userID uuid.UUID) (*model.Usage, error) {
+	if _, err := tx.Exec("INSERT INTO storage_usage (user_id) VALUES ($1) ON CONFLICT (user_id) DO NOTHING", userID); err != nil {
+		return nil, err
+	}
+
+	return scanUsage(tx.QueryRow("SELECT "+usageColumns+" FROM storage_usage WHERE user_id=$1 FOR UPDATE", userID))
+}
+
+func scanUsage(row scanner) (*model.Usage, error) {
+	u := &model.Usage{}
+	quota := sql.NullInt64{}
+	if err := row.Scan(&u.UserID, &u.Used, &u.Reserved, &quota, &u.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	if quota.Valid {
+		u.Quota = &quota.Int64
+	}
+
+	return u, nil
+}
@@ -0,0 +1,97 @@
+package sqlstore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/sqlstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageRepository_Find(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("storage_usage", "assets", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	usage, err := s.Usage().Find(u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), usage.Used)
+	assert.Nil(t, usage.Quota)
+
+	a := model.TestAsset(t, u)
+	s.Asset().Create(a, testQuota)
+	copied := model.TestAsset(t, u)
+	s.Asset().Create(copied, testQuota)
+	other := model.TestAsset(t, u)
+	other.Hash = "60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752"
+	other.Size = 100
+	s.Asset().Create(other, testQuota)
+
+	usage, _ = s.Usage().Find(u.ID)
+	assert.Equal(t, int64(2148), usage.Used)
+
+	s.Asset().Delete(a.ID)
+	usage, _ = s.Usage().Find(u.ID)
+	assert.Equal(t, int64(2148), usage.Used)
+
+	s.Asset().Delete(copied.ID)
+	usage, _ = s.Usage().Find(u.ID)
+	assert.Equal(t, int64(100), usage.Used)
+}
+
+func TestUsageRepository_Quota(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("storage_usage", "assets", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	a := model.TestAsset(t, u)
+	assert.EqualError(t, s.Asset().Create(a, a.Size-1), store.ErrQuotaExceeded.Error())
+	assert.NoError(t, s.Asset().Create(a, a.Size))
+	assert.NoError(t, s.Asset().Create(model.TestAsset(t, u), a.Size), "content the user stores already is free")
+
+	quota := a.Size * 2
+	assert.NoError(t, s.Usage().SetQuota(u.ID, &quota))
+	usage, _ := s.Usage().Find(u.ID)
+	if assert.NotNil(t, usage.Quota) {
+		assert.Equal(t, quota, *usage.Quota)
+	}
+
+	other := model.TestAsset(t, u)
+	other.Hash = "60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752"
+	assert.NoError(t, s.Asset().Create(other, 0))
+
+	assert.NoError(t, s.Usage().SetQuota(u.ID, nil))
+	usage, _ = s.Usage().Find(u.ID)
+	assert.Nil(t, usage.Quota)
+	assert.Equal(t, quota, usage.Used)
+}
+
+func TestUsageRepository_Campaigns(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("storage_usage", "assets", "campaigns", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	a := model.TestAsset(t, u)
+	a.CampaignID = &c.ID
+	s.Asset().Create(a, testQuota)
+	s.Asset().Create(model.TestAsset(t, u), testQuota)
+
+	usages, err := s.Usage().Campaigns(u.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, usages, 1) {
+		assert.Equal(t, c.ID, usages[0].CampaignID)
+		assert.Equal(t, a.Size, usages[0].Used)
+	}
+}
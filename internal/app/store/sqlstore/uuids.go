@@ -0,0 +1,29 @@
+package sqlstore
+
+import (
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// uuidArray converts ids to a value usable as a $n::uuid[] parameter.
+func uuidArray(ids []uuid.UUID) interface{} {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = id.String()
+	}
+
+	return pq.Array(s)
+}
+
+func parseUUIDs(s pq.StringArray) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, len(s))
+	for i, v := range s {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+
+	return ids, nil
+}
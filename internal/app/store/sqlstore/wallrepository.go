@@ -0,0 +1,167 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+const wallColumns = "id, scene_id, x1, y1, x2, y2, kind, open, created_at, updated_at"
+
+type WallRepository struct {
+	store *Store
+}
+
+func (r *WallRepository) Create(w *model.Wall) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO walls (scene_id, x1, y1, x2, y2, kind, open) VALUES ($1, $2, $3, $4, $5, $6, $7) "+
+			"RETURNING id, created_at, updated_at",
+		w.SceneID,
+		w.X1,
+		w.Y1,
+		w.X2,
+		w.Y2,
+		w.Kind,
+		w.Open,
+	).Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt)
+}
+
+func (r *WallRepository) Find(id uuid.UUID) (*model.Wall, error) {
+	return r.find("SELECT "+wallColumns+" FROM walls WHERE id=$1", id)
+}
+
+func (r *WallRepository) Lock(id uuid.UUID) (*model.Wall, error) {
+	return r.find("SELECT "+wallColumns+" FROM walls WHERE id=$1 FOR UPDATE", id)
+}
+
+func (r *WallRepository) find(query string, id uuid.UUID) (*model.Wall, error) {
+	w, err := scanWall(r.store.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (r *WallRepository) FindAll(sceneID uuid.UUID) ([]*model.Wall, error) {
+	rows, err := r.store.db.Query(
+		"SELECT "+wallColumns+" FROM walls WHERE scene_id=$1 ORDER BY created_at, id",
+		sceneID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	walls := []*model.Wall{}
+	for rows.Next() {
+		w, err := scanWall(rows)
+		if err != nil {
+			return nil, err
+		}
+		walls = append(walls, w)
+	}
+
+	return walls, rows.Err()
+}
+
+func (r *WallRepository) Update(w *model.Wall) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.store.db.QueryRow(
+		"UPDATE walls SET x1=$2, y1=$3, x2=$4, y2=$5, kind=$6, open=$7, updated_at=now() WHERE id=$1 RETURNING updated_at",
+		w.ID,
+		w.X1,
+		w.Y1,
+		w.X2,
+		w.Y2,
+		w.Kind,
+		w.Open,
+	).Scan(&w.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *WallRepository) Restore(w *model.Wall) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := r.store.db.Exec(
+		"INSERT INTO walls ("+wallColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		w.ID,
+		w.SceneID,
+		w.X1,
+		w.Y1,
+		w.X2,
+		w.Y2,
+		w.Kind,
+		w.Open,
+		w.CreatedAt,
+		w.UpdatedAt,
+	); err != nil {
+		if isUniqueViolation(err) {
+			return store.ErrAlreadyExists
+		}
+		if isForeignKeyViolation(err) {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *WallRepository) Delete(id uuid.UUID) error {
+	res, err := r.store.db.Exec("DELETE FROM walls WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func scanWall(row scanner) (*model.Wall, error) {
+	w := &model.Wall{}
+	if err := row.Scan(
+		&w.ID,
+		&w.SceneID,
+		&w.X1,
+		&w.Y1,
+		&w.X2,
+		&w.Y2,
+		&w.Kind,
+		&w.Open,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
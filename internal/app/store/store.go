@@ -2,5 +2,32 @@ package store
 
 type Store interface {
 	User() UserRepository
+	Campaign() CampaignRepository
+	Roll() RollRepository
+	ChatMessage() ChatMessageRepository
+	Character() CharacterRepository
+	CharacterRevision() CharacterRevisionRepository
+	Scene() SceneRepository
+	Token() TokenRepository
+	Wall() WallRepository
+	Light() LightRepository
+	Exploration() ExplorationRepository
+	Combat() CombatRepository
+	Combatant() CombatantRepository
+	Asset() AssetRepository
+	Folder() FolderRepository
+	Upload() UploadRepository
+	Usage() UsageRepository
+	Journal() JournalRepository
+	JournalFolder() JournalFolderRepository
+	JournalOperation() JournalOperationRepository
+	Compendium() CompendiumRepository
+	Pack() PackRepository
+	PackVersion() PackVersionRepository
+	CampaignPack() CampaignPackRepository
+	Action() ActionRepository
+	// Transaction runs fn with a store whose changes are all kept if fn
+	// returns nil, and all undone if it returns an error.
+	Transaction(fn func(Store) error) error
 }
 
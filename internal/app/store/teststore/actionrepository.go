@@ -0,0 +1,48 @@
+package teststore
+
+import (
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+)
+
+type ActionRepository struct {
+	store   *Store
+	actions []*model.Action
+}
+
+func (r *ActionRepository) Create(a *model.Action) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+
+	a.ID = uuid.New()
+	a.Seq = int64(len(r.actions) + 1)
+	a.CreatedAt = time.Now()
+	ca := *a
+	r.actions = append(r.actions, &ca)
+
+	return nil
+}
+
+func (r *ActionRepository) FindAll(campaignID uuid.UUID, filter *model.ActionFilter) ([]*model.Action, error) {
+	actions := []*model.Action{}
+	for i := len(r.actions) - 1; i >= 0; i-- {
+		if a := r.actions[i]; a.CampaignID == campaignID && filter.Match(a) {
+			ca := *a
+			actions = append(actions, &ca)
+		}
+	}
+
+	if filter.Offset >= len(actions) {
+		return []*model.Action{}, nil
+	}
+	actions = actions[filter.Offset:]
+
+	if filter.Limit > 0 && filter.Limit < len(actions) {
+		actions = actions[:filter.Limit]
+	}
+
+	return actions, nil
+}
@@ -0,0 +1,153 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type AssetRepository struct {
+	store  *Store
+	assets map[uuid.UUID]*model.Asset
+}
+
+func (r *AssetRepository) Create(a *model.Asset, defaultQuota int64) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+
+	usages := r.store.Usage().(*UsageRepository)
+	usage := usages.usage(a.UserID)
+	if !r.stored(a.UserID, a.Hash) {
+		if usage.Used+usages.reserved(a.UserID)+a.Size > usage.Limit(defaultQuota) {
+			return store.ErrQuotaExceeded
+		}
+
+		usage.Used += a.Size
+		usage.UpdatedAt = time.Now()
+	}
+
+	if a.Tags == nil {
+		a.Tags = []string{}
+	}
+	a.ID = uuid.New()
+	a.CreatedAt = time.Now()
+	a.UpdatedAt = a.CreatedAt
+	r.assets[a.ID] = cloneAsset(a)
+
+	return nil
+}
+
+func (r *AssetRepository) Find(id uuid.UUID) (*model.Asset, error) {
+	a, ok := r.assets[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return cloneAsset(a), nil
+}
+
+func (r *AssetRepository) FindAll(userID uuid.UUID, filter *model.AssetFilter) ([]*model.Asset, error) {
+	assets := []*model.Asset{}
+	for _, a := range r.assets {
+		if a.UserID == userID && filter.Match(a) {
+			assets = append(assets, cloneAsset(a))
+		}
+	}
+
+	sort.Slice(assets, func(i, j int) bool {
+		if !assets[i].CreatedAt.Equal(assets[j].CreatedAt) {
+			return assets[i].CreatedAt.Before(assets[j].CreatedAt)
+		}
+
+		return assets[i].ID.String() < assets[j].ID.String()
+	})
+
+	return assets, nil
+}
+
+func (r *AssetRepository) Update(a *model.Asset) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+
+	stored, ok := r.assets[a.ID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if a.Tags == nil {
+		a.Tags = []string{}
+	}
+	a.UpdatedAt = time.Now()
+	stored.FolderID = a.FolderID
+	stored.CampaignID = a.CampaignID
+	stored.Name = a.Name
+	stored.Tags = append([]string{}, a.Tags...)
+	stored.Private = a.Private
+	stored.UpdatedAt = a.UpdatedAt
+
+	return nil
+}
+
+func (r *AssetRepository) Delete(id uuid.UUID) error {
+	a, ok := r.assets[id]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.assets, id)
+
+	for _, e := range r.store.Journal().(*JournalRepository).entries {
+		if e.ImageID != nil && *e.ImageID == id {
+			e.ImageID = nil
+		}
+	}
+
+	if !r.stored(a.UserID, a.Hash) {
+		usage := r.store.Usage().(*UsageRepository).usage(a.UserID)
+		usage.Used -= a.Size
+		if usage.Used < 0 {
+			usage.Used = 0
+		}
+		usage.UpdatedAt = time.Now()
+	}
+
+	return nil
+}
+
+func (r *AssetRepository) InUse(hash string) (bool, error) {
+	for _, a := range r.assets {
+		if a.Hash == hash || a.Thumbnail == hash {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// LockContent does nothing: the test store is never used concurrently.
+func (r *AssetRepository) LockContent(hashes ...string) error {
+	return nil
+}
+
+// stored reports whether any of the user's assets has the content.
+func (r *AssetRepository) stored(userID uuid.UUID, hash string) bool {
+	for _, a := range r.assets {
+		if a.UserID == userID && a.Hash == hash {
+			return true
+		}
+	}
+
+	return false
+}
+
+func cloneAsset(a *model.Asset) *model.Asset {
+	ca := *a
+	ca.Tags = append([]string{}, a.Tags...)
+
+	return &ca
+}
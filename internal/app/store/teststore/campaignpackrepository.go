@@ -0,0 +1,77 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type CampaignPackRepository struct {
+	store *Store
+	packs map[uuid.UUID]map[uuid.UUID]*model.CampaignPack
+}
+
+func (r *CampaignPackRepository) Save(c *model.CampaignPack) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if _, ok := r.store.PackVersion().(*PackVersionRepository).versions[c.PackID][c.Version]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	c.UpdatedAt = time.Now()
+	if stored, ok := r.packs[c.CampaignID][c.PackID]; ok {
+		c.CreatedAt = stored.CreatedAt
+	} else {
+		c.CreatedAt = c.UpdatedAt
+	}
+
+	if r.packs[c.CampaignID] == nil {
+		r.packs[c.CampaignID] = make(map[uuid.UUID]*model.CampaignPack)
+	}
+	cc := *c
+	r.packs[c.CampaignID][c.PackID] = &cc
+
+	return nil
+}
+
+func (r *CampaignPackRepository) Find(campaignID uuid.UUID, packID uuid.UUID) (*model.CampaignPack, error) {
+	c, ok := r.packs[campaignID][packID]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+	cc := *c
+
+	return &cc, nil
+}
+
+func (r *CampaignPackRepository) FindAll(campaignID uuid.UUID) ([]*model.CampaignPack, error) {
+	packs := []*model.CampaignPack{}
+	for _, c := range r.packs[campaignID] {
+		cc := *c
+		packs = append(packs, &cc)
+	}
+
+	sort.Slice(packs, func(i, j int) bool {
+		if !packs[i].CreatedAt.Equal(packs[j].CreatedAt) {
+			return packs[i].CreatedAt.Before(packs[j].CreatedAt)
+		}
+
+		return packs[i].PackID.String() < packs[j].PackID.String()
+	})
+
+	return packs, nil
+}
+
+func (r *CampaignPackRepository) Delete(campaignID uuid.UUID, packID uuid.UUID) error {
+	if _, ok := r.packs[campaignID][packID]; !ok {
+		return store.ErrRecordNotFound
+	}
+	delete(r.packs[campaignID], packID)
+
+	return nil
+}
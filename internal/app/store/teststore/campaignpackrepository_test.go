@@ -0,0 +1,79 @@
+package teststore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCampaignPackRepository_Save(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	p := model.TestPack(t, u)
+	s.Pack().Create(p)
+	v := model.TestPackVersion(t, p)
+	s.PackVersion().Create(v, v.CompendiumEntries(p))
+
+	cp := &model.CampaignPack{CampaignID: c.ID, PackID: p.ID, Version: 2}
+	assert.EqualError(t, s.CampaignPack().Save(cp), store.ErrRecordNotFound.Error())
+
+	cp.Version = 1
+	assert.NoError(t, s.CampaignPack().Save(cp))
+
+	p.LatestVersion = 1
+	next := model.TestPackVersion(t, p)
+	s.PackVersion().Create(next, next.CompendiumEntries(p))
+	cp.Version = 2
+	assert.NoError(t, s.CampaignPack().Save(cp))
+
+	found, err := s.CampaignPack().Find(c.ID, p.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, found.Version)
+
+	cp.Version = 0
+	assert.Error(t, s.CampaignPack().Save(cp))
+}
+
+func TestCampaignPackRepository_FindAll(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	for _, name := range []string{"Tome of Barovia", "Grimoire of Sorrows"} {
+		p := model.TestPack(t, u)
+		p.Name = name
+		s.Pack().Create(p)
+		v := model.TestPackVersion(t, p)
+		s.PackVersion().Create(v, v.CompendiumEntries(p))
+		s.CampaignPack().Save(&model.CampaignPack{CampaignID: c.ID, PackID: p.ID, Version: 1})
+	}
+
+	packs, err := s.CampaignPack().FindAll(c.ID)
+	assert.NoError(t, err)
+	assert.Len(t, packs, 2)
+}
+
+func TestCampaignPackRepository_Delete(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	p := model.TestPack(t, u)
+	s.Pack().Create(p)
+	v := model.TestPackVersion(t, p)
+	s.PackVersion().Create(v, v.CompendiumEntries(p))
+	s.CampaignPack().Save(&model.CampaignPack{CampaignID: c.ID, PackID: p.ID, Version: 1})
+
+	assert.NoError(t, s.CampaignPack().Delete(c.ID, p.ID))
+	_, err := s.CampaignPack().Find(c.ID, p.ID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+	assert.EqualError(t, s.CampaignPack().Delete(c.ID, p.ID), store.ErrRecordNotFound.Error())
+}
@@ -0,0 +1,80 @@
+package teststore
+
+import (
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type CampaignRepository struct {
+	store     *Store
+	campaigns map[uuid.UUID]*model.Campaign
+	members   map[uuid.UUID]map[uuid.UUID]*model.Member
+}
+
+func (r *CampaignRepository) Create(c *model.Campaign) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	c.ID = uuid.New()
+	c.CreatedAt = time.Now()
+	r.campaigns[c.ID] = c
+	r.members[c.ID] = map[uuid.UUID]*model.Member{
+		c.OwnerID: {
+			CampaignID: c.ID,
+			UserID:     c.OwnerID,
+			Role:       model.RoleGM,
+		},
+	}
+
+	return nil
+}
+
+func (r *CampaignRepository) Find(id uuid.UUID) (*model.Campaign, error) {
+	c, ok := r.campaigns[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return c, nil
+}
+
+func (r *CampaignRepository) AddMember(m *model.Member) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	members, ok := r.members[m.CampaignID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if _, ok := members[m.UserID]; ok {
+		return store.ErrAlreadyExists
+	}
+
+	members[m.UserID] = m
+
+	return nil
+}
+
+func (r *CampaignRepository) FindMember(campaignID uuid.UUID, userID uuid.UUID) (*model.Member, error) {
+	m, ok := r.members[campaignID][userID]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return m, nil
+}
+
+func (r *CampaignRepository) Members(campaignID uuid.UUID) ([]*model.Member, error) {
+	members := []*model.Member{}
+	for _, m := range r.members[campaignID] {
+		members = append(members, m)
+	}
+
+	return members, nil
+}
@@ -0,0 +1,60 @@
+package teststore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCampaignRepository_Create(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	c := model.TestCampaign(t, u)
+	assert.NoError(t, s.Campaign().Create(c))
+	assert.NotEqual(t, uuid.Nil, c.ID)
+
+	m, err := s.Campaign().FindMember(c.ID, u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.RoleGM, m.Role)
+}
+
+func TestCampaignRepository_Find(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	_, err := s.Campaign().Find(uuid.New())
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	tc := model.TestCampaign(t, u)
+	s.Campaign().Create(tc)
+	c, err := s.Campaign().Find(tc.ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+}
+
+func TestCampaignRepository_AddMember(t *testing.T) {
+	s := teststore.New()
+	gm := model.TestUser(t)
+	s.User().Create(gm)
+	player := model.TestUser(t)
+	player.Email, player.Username = "player@test.com", "player"
+	s.User().Create(player)
+
+	c := model.TestCampaign(t, gm)
+	s.Campaign().Create(c)
+
+	m := &model.Member{CampaignID: c.ID, UserID: player.ID, Role: model.RolePlayer}
+	assert.NoError(t, s.Campaign().AddMember(m))
+	assert.EqualError(t, s.Campaign().AddMember(m), store.ErrAlreadyExists.Error())
+
+	members, err := s.Campaign().Members(c.ID)
+	assert.NoError(t, err)
+	assert.Len(t, members, 2)
+}
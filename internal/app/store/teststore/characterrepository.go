@@ -0,0 +1,116 @@
+package teststore
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type CharacterRepository struct {
+	store      *Store
+	characters map[uuid.UUID]*model.Character
+}
+
+func (r *CharacterRepository) Create(c *model.Character) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	c.ID = uuid.New()
+	c.Version = 1
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = c.CreatedAt
+	r.characters[c.ID] = clone(c)
+
+	return nil
+}
+
+func (r *CharacterRepository) Find(id uuid.UUID) (*model.Character, error) {
+	c, ok := r.characters[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return clone(c), nil
+}
+
+func (r *CharacterRepository) FindByName(campaignID uuid.UUID, name string) (*model.Character, error) {
+	var found *model.Character
+	for _, c := range r.characters {
+		if c.CampaignID != campaignID || !strings.EqualFold(c.Name, name) {
+			continue
+		}
+
+		if found == nil || c.CreatedAt.Before(found.CreatedAt) {
+			found = c
+		}
+	}
+
+	if found == nil {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return clone(found), nil
+}
+
+func (r *CharacterRepository) FindAll(campaignID uuid.UUID) ([]*model.Character, error) {
+	characters := []*model.Character{}
+	for _, c := range r.characters {
+		if c.CampaignID == campaignID {
+			characters = append(characters, clone(c))
+		}
+	}
+
+	sort.Slice(characters, func(i, j int) bool {
+		if characters[i].Name != characters[j].Name {
+			return characters[i].Name < characters[j].Name
+		}
+
+		return characters[i].ID.String() < characters[j].ID.String()
+	})
+
+	return characters, nil
+}
+
+func (r *CharacterRepository) Update(c *model.Character) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	stored, ok := r.characters[c.ID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if stored.Version != c.Version {
+		return store.ErrConflict
+	}
+
+	c.Version++
+	c.UpdatedAt = time.Now()
+	r.characters[c.ID] = clone(c)
+
+	return nil
+}
+
+func (r *CharacterRepository) Delete(id uuid.UUID) error {
+	if _, ok := r.characters[id]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.characters, id)
+
+	return nil
+}
+
+// clone keeps callers from changing stored characters without Update, as
+// they can't with the SQL store.
+func clone(c *model.Character) *model.Character {
+	cc := *c
+
+	return &cc
+}
@@ -0,0 +1,56 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type CharacterRevisionRepository struct {
+	store     *Store
+	revisions map[uuid.UUID][]*model.CharacterRevision
+}
+
+func (r *CharacterRevisionRepository) Create(rev *model.CharacterRevision) error {
+	if err := rev.Validate(); err != nil {
+		return err
+	}
+
+	for _, existing := range r.revisions[rev.CharacterID] {
+		if existing.Version == rev.Version {
+			return store.ErrAlreadyExists
+		}
+	}
+
+	rev.ID = uuid.New()
+	rev.CreatedAt = time.Now()
+	r.revisions[rev.CharacterID] = append(r.revisions[rev.CharacterID], rev)
+
+	return nil
+}
+
+func (r *CharacterRevisionRepository) Find(characterID uuid.UUID, version int) (*model.CharacterRevision, error) {
+	for _, rev := range r.revisions[characterID] {
+		if rev.Version == version {
+			return rev, nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+func (r *CharacterRevisionRepository) FindAll(characterID uuid.UUID) ([]*model.CharacterRevision, error) {
+	revisions := []*model.CharacterRevision{}
+	for _, rev := range r.revisions[characterID] {
+		revisions = append(revisions, rev)
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Version < revisions[j].Version
+	})
+
+	return revisions, nil
+}
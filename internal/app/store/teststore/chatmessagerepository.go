@@ -0,0 +1,87 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type ChatMessageRepository struct {
+	store    *Store
+	messages map[uuid.UUID]*model.ChatMessage
+}
+
+func (r *ChatMessageRepository) Create(m *model.ChatMessage) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	m.ID = uuid.New()
+	m.CreatedAt = time.Now()
+	r.messages[m.ID] = m
+
+	return nil
+}
+
+func (r *ChatMessageRepository) Find(id uuid.UUID) (*model.ChatMessage, error) {
+	m, ok := r.messages[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return m, nil
+}
+
+func (r *ChatMessageRepository) Update(m *model.ChatMessage) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	if _, ok := r.messages[m.ID]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	now := time.Now()
+	m.EditedAt = &now
+	r.messages[m.ID] = m
+
+	return nil
+}
+
+func (r *ChatMessageRepository) Delete(id uuid.UUID) error {
+	if _, ok := r.messages[id]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.messages, id)
+
+	return nil
+}
+
+func (r *ChatMessageRepository) FindAll(campaignID uuid.UUID, viewer *model.Member, filter *model.ChatFilter) ([]*model.ChatMessage, error) {
+	messages := []*model.ChatMessage{}
+	for _, m := range r.messages {
+		if m.CampaignID != campaignID || !m.VisibleTo(viewer) {
+			continue
+		}
+
+		if filter.Before != nil && !m.OlderThan(filter.Before) {
+			continue
+		}
+
+		messages = append(messages, m)
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[j].OlderThan(messages[i].Cursor())
+	})
+
+	if filter.Limit > 0 && filter.Limit < len(messages) {
+		messages = messages[:filter.Limit]
+	}
+
+	return messages, nil
+}
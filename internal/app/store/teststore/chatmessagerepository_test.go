@@ -0,0 +1,108 @@
+package teststore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChatMessageRepository_Create(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	m := model.TestChatMessage(t, c, u)
+	assert.NoError(t, s.ChatMessage().Create(m))
+	assert.NotEqual(t, uuid.Nil, m.ID)
+}
+
+func TestChatMessageRepository_Find(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	_, err := s.ChatMessage().Find(uuid.New())
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	tm := model.TestChatMessage(t, c, u)
+	tm.Kind = model.ChatWhisper
+	tm.Recipients = []uuid.UUID{u.ID}
+	s.ChatMessage().Create(tm)
+	m, err := s.ChatMessage().Find(tm.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{u.ID}, m.Recipients)
+}
+
+func TestChatMessageRepository_Update(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	m := model.TestChatMessage(t, c, u)
+	s.ChatMessage().Create(m)
+	m.Body = "back"
+	assert.NoError(t, s.ChatMessage().Update(m))
+	assert.NotNil(t, m.EditedAt)
+
+	m, _ = s.ChatMessage().Find(m.ID)
+	assert.Equal(t, "back", m.Body)
+}
+
+func TestChatMessageRepository_Delete(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	m := model.TestChatMessage(t, c, u)
+	s.ChatMessage().Create(m)
+	assert.NoError(t, s.ChatMessage().Delete(m.ID))
+	assert.EqualError(t, s.ChatMessage().Delete(m.ID), store.ErrRecordNotFound.Error())
+}
+
+func TestChatMessageRepository_FindAll(t *testing.T) {
+	s := teststore.New()
+	gm := model.TestUser(t)
+	s.User().Create(gm)
+	player := model.TestUser(t)
+	player.Email, player.Username = "player@test.com", "player"
+	s.User().Create(player)
+	c := model.TestCampaign(t, gm)
+	s.Campaign().Create(c)
+	s.Campaign().AddMember(&model.Member{CampaignID: c.ID, UserID: player.ID, Role: model.RolePlayer})
+
+	for i := 0; i < 3; i++ {
+		s.ChatMessage().Create(model.TestChatMessage(t, c, player))
+	}
+	whisper := model.TestChatMessage(t, c, gm)
+	whisper.Kind = model.ChatWhisper
+	whisper.Recipients = []uuid.UUID{gm.ID}
+	s.ChatMessage().Create(whisper)
+
+	gmMember, _ := s.Campaign().FindMember(c.ID, gm.ID)
+	playerMember, _ := s.Campaign().FindMember(c.ID, player.ID)
+
+	messages, err := s.ChatMessage().FindAll(c.ID, gmMember, &model.ChatFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, messages, 4)
+
+	messages, err = s.ChatMessage().FindAll(c.ID, playerMember, &model.ChatFilter{Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, messages, 2)
+
+	rest, err := s.ChatMessage().FindAll(c.ID, playerMember, &model.ChatFilter{Before: messages[1].Cursor(), Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, rest, 1)
+	assert.True(t, rest[0].OlderThan(messages[1].Cursor()))
+}
@@ -0,0 +1,100 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type CombatantRepository struct {
+	store      *Store
+	combatants map[uuid.UUID]*model.Combatant
+}
+
+func (r *CombatantRepository) Create(c *model.Combatant) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if c.Conditions == nil {
+		c.Conditions = []model.Condition{}
+	}
+	c.ID = uuid.New()
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = c.CreatedAt
+	r.combatants[c.ID] = cloneCombatant(c)
+
+	return nil
+}
+
+func (r *CombatantRepository) Find(id uuid.UUID) (*model.Combatant, error) {
+	c, ok := r.combatants[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return cloneCombatant(c), nil
+}
+
+func (r *CombatantRepository) FindAll(combatID uuid.UUID) ([]*model.Combatant, error) {
+	combatants := []*model.Combatant{}
+	for _, c := range r.combatants {
+		if c.CombatID == combatID {
+			combatants = append(combatants, cloneCombatant(c))
+		}
+	}
+
+	sort.Slice(combatants, func(i, j int) bool {
+		if !combatants[i].CreatedAt.Equal(combatants[j].CreatedAt) {
+			return combatants[i].CreatedAt.Before(combatants[j].CreatedAt)
+		}
+
+		return combatants[i].ID.String() < combatants[j].ID.String()
+	})
+
+	return combatants, nil
+}
+
+func (r *CombatantRepository) Update(c *model.Combatant) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if _, ok := r.combatants[c.ID]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if c.Conditions == nil {
+		c.Conditions = []model.Condition{}
+	}
+	c.UpdatedAt = time.Now()
+	r.combatants[c.ID] = cloneCombatant(c)
+
+	return nil
+}
+
+func (r *CombatantRepository) Delete(id uuid.UUID) error {
+	if _, ok := r.combatants[id]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.combatants, id)
+
+	return nil
+}
+
+// cloneCombatant copies the conditions too, so callers can't change them
+// without Update.
+func cloneCombatant(c *model.Combatant) *model.Combatant {
+	cc := *c
+	cc.Conditions = append([]model.Condition{}, c.Conditions...)
+	if c.Initiative != nil {
+		n := *c.Initiative
+		cc.Initiative = &n
+	}
+
+	return &cc
+}
@@ -0,0 +1,94 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type CombatRepository struct {
+	store   *Store
+	combats map[uuid.UUID]*model.Combat
+}
+
+func (r *CombatRepository) Create(c *model.Combat) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	c.ID = uuid.New()
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = c.CreatedAt
+	cc := *c
+	r.combats[c.ID] = &cc
+
+	return nil
+}
+
+func (r *CombatRepository) Find(id uuid.UUID) (*model.Combat, error) {
+	c, ok := r.combats[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+	cc := *c
+
+	return &cc, nil
+}
+
+func (r *CombatRepository) FindAll(campaignID uuid.UUID) ([]*model.Combat, error) {
+	combats := []*model.Combat{}
+	for _, c := range r.combats {
+		if c.CampaignID == campaignID {
+			cc := *c
+			combats = append(combats, &cc)
+		}
+	}
+
+	sort.Slice(combats, func(i, j int) bool {
+		if !combats[i].CreatedAt.Equal(combats[j].CreatedAt) {
+			return combats[i].CreatedAt.Before(combats[j].CreatedAt)
+		}
+
+		return combats[i].ID.String() < combats[j].ID.String()
+	})
+
+	return combats, nil
+}
+
+func (r *CombatRepository) Update(c *model.Combat) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if _, ok := r.combats[c.ID]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	c.UpdatedAt = time.Now()
+	cc := *c
+	r.combats[c.ID] = &cc
+
+	return nil
+}
+
+// Delete removes the combat along with its combatants, as the foreign key
+// does in the SQL store.
+func (r *CombatRepository) Delete(id uuid.UUID) error {
+	if _, ok := r.combats[id]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.combats, id)
+
+	combatants := r.store.Combatant().(*CombatantRepository)
+	for cid, c := range combatants.combatants {
+		if c.CombatID == id {
+			delete(combatants.combatants, cid)
+		}
+	}
+
+	return nil
+}
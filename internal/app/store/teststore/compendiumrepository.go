@@ -0,0 +1,97 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type CompendiumRepository struct {
+	store   *Store
+	entries map[uuid.UUID]*model.CompendiumEntry
+}
+
+func (r *CompendiumRepository) Save(e *model.CompendiumEntry) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+
+	e.UpdatedAt = time.Now()
+	for _, stored := range r.entries {
+		if stored.PackID == nil && stored.System == e.System && stored.Kind == e.Kind && stored.Source == e.Source && stored.Name == e.Name {
+			e.ID = stored.ID
+			e.CreatedAt = stored.CreatedAt
+			r.entries[e.ID] = cloneCompendiumEntry(e)
+
+			return nil
+		}
+	}
+
+	e.ID = uuid.New()
+	e.CreatedAt = e.UpdatedAt
+	r.entries[e.ID] = cloneCompendiumEntry(e)
+
+	return nil
+}
+
+func (r *CompendiumRepository) Find(id uuid.UUID) (*model.CompendiumEntry, error) {
+	e, ok := r.entries[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return cloneCompendiumEntry(e), nil
+}
+
+// FindAll searches with model.CompendiumFilter.Match instead of full-text
+// search, so results aren't ranked.
+func (r *CompendiumRepository) FindAll(filter *model.CompendiumFilter) ([]*model.CompendiumEntry, error) {
+	entries := []*model.CompendiumEntry{}
+	for _, e := range r.entries {
+		if filter.Match(e) {
+			entries = append(entries, cloneCompendiumEntry(e))
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Name != entries[j].Name {
+			return entries[i].Name < entries[j].Name
+		}
+
+		return entries[i].ID.String() < entries[j].ID.String()
+	})
+
+	if filter.Offset >= len(entries) {
+		return []*model.CompendiumEntry{}, nil
+	}
+	entries = entries[filter.Offset:]
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+
+	return entries, nil
+}
+
+func (r *CompendiumRepository) DeleteSource(system string, source string) error {
+	for id, e := range r.entries {
+		if e.PackID == nil && e.System == system && e.Source == source {
+			delete(r.entries, id)
+		}
+	}
+
+	return nil
+}
+
+func cloneCompendiumEntry(e *model.CompendiumEntry) *model.CompendiumEntry {
+	ce := *e
+	ce.Data = append([]byte{}, e.Data...)
+	if e.PackID != nil {
+		packID := *e.PackID
+		ce.PackID = &packID
+	}
+
+	return &ce
+}
@@ -0,0 +1,111 @@
+package teststore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompendiumRepository_Save(t *testing.T) {
+	s := teststore.New()
+	e := model.TestCompendiumEntry(t)
+	assert.NoError(t, s.Compendium().Save(e))
+	assert.NotEqual(t, uuid.Nil, e.ID)
+
+	again := model.TestCompendiumEntry(t)
+	again.Data = []byte(`{"hp": 12, "initiative_bonus": 2}`)
+	assert.NoError(t, s.Compendium().Save(again))
+	assert.Equal(t, e.ID, again.ID)
+
+	found, err := s.Compendium().Find(e.ID)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"hp": 12, "initiative_bonus": 2}`, string(found.Data))
+
+	other := model.TestCompendiumEntry(t)
+	other.Source = "homebrew"
+	assert.NoError(t, s.Compendium().Save(other))
+	assert.NotEqual(t, e.ID, other.ID)
+
+	invalid := model.TestCompendiumEntry(t)
+	invalid.Kind = ""
+	assert.Error(t, s.Compendium().Save(invalid))
+}
+
+func TestCompendiumRepository_Find(t *testing.T) {
+	s := teststore.New()
+	_, err := s.Compendium().Find(uuid.New())
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	e := model.TestCompendiumEntry(t)
+	s.Compendium().Save(e)
+	found, err := s.Compendium().Find(e.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, e.Name, found.Name)
+	assert.JSONEq(t, string(e.Data), string(found.Data))
+}
+
+func TestCompendiumRepository_FindAll(t *testing.T) {
+	s := teststore.New()
+	goblin := model.TestCompendiumEntry(t)
+	s.Compendium().Save(goblin)
+	orc := model.TestCompendiumEntry(t)
+	orc.Name = "Orc"
+	orc.Body = "**Aggressive.** As a bonus action, the orc can move up to its speed toward a hostile creature."
+	orc.Data = []byte(`{"size": "Medium", "cr": 0.5, "hp": 15, "initiative_bonus": 1}`)
+	s.Compendium().Save(orc)
+	fireball := model.TestCompendiumEntry(t)
+	fireball.Kind = model.KindSpell
+	fireball.Name = "Fireball"
+	fireball.Body = "A bright streak flashes from your pointing finger."
+	fireball.Data = []byte(`{"level": 3, "school": "evocation"}`)
+	s.Compendium().Save(fireball)
+	pf := model.TestCompendiumEntry(t)
+	pf.System = "pf2e"
+	s.Compendium().Save(pf)
+
+	testCases := []struct {
+		name     string
+		filter   *model.CompendiumFilter
+		expected []string
+	}{
+		{"system", &model.CompendiumFilter{System: "dnd5e"}, []string{"Fireball", "Goblin", "Orc"}},
+		{"kind", &model.CompendiumFilter{System: "dnd5e", Kind: model.KindMonster}, []string{"Goblin", "Orc"}},
+		{"query", &model.CompendiumFilter{System: "dnd5e", Query: "bonus action"}, []string{"Goblin", "Orc"}},
+		{"query by name", &model.CompendiumFilter{Query: "fireball"}, []string{"Fireball"}},
+		{"data", &model.CompendiumFilter{Data: map[string]string{"level": "3", "school": "evocation"}}, []string{"Fireball"}},
+		{"fractional data", &model.CompendiumFilter{Data: map[string]string{"cr": "0.5"}}, []string{"Orc"}},
+		{"limit", &model.CompendiumFilter{System: "dnd5e", Limit: 1, Offset: 1}, []string{"Goblin"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			entries, err := s.Compendium().FindAll(tc.filter)
+			assert.NoError(t, err)
+
+			names := []string{}
+			for _, e := range entries {
+				names = append(names, e.Name)
+			}
+			assert.ElementsMatch(t, tc.expected, names)
+		})
+	}
+}
+
+func TestCompendiumRepository_DeleteSource(t *testing.T) {
+	s := teststore.New()
+	e := model.TestCompendiumEntry(t)
+	s.Compendium().Save(e)
+	other := model.TestCompendiumEntry(t)
+	other.Source = "homebrew"
+	s.Compendium().Save(other)
+
+	assert.NoError(t, s.Compendium().DeleteSource("dnd5e", "srd-5.1"))
+	_, err := s.Compendium().Find(e.ID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+	_, err = s.Compendium().Find(other.ID)
+	assert.NoError(t, err)
+}
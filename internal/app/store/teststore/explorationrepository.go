@@ -0,0 +1,67 @@
+package teststore
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/geometry"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type explorationKey struct {
+	sceneID uuid.UUID
+	userID  uuid.UUID
+}
+
+type ExplorationRepository struct {
+	store        *Store
+	explorations map[explorationKey]*model.Exploration
+}
+
+func (r *ExplorationRepository) Find(sceneID uuid.UUID, userID uuid.UUID) (*model.Exploration, error) {
+	e, ok := r.explorations[explorationKey{sceneID, userID}]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return copyExploration(e)
+}
+
+func (r *ExplorationRepository) Save(e *model.Exploration) error {
+	e.UpdatedAt = time.Now()
+	stored, err := copyExploration(e)
+	if err != nil {
+		return err
+	}
+	r.explorations[explorationKey{e.SceneID, e.UserID}] = stored
+
+	return nil
+}
+
+func (r *ExplorationRepository) DeleteAll(sceneID uuid.UUID) error {
+	for k := range r.explorations {
+		if k.sceneID == sceneID {
+			delete(r.explorations, k)
+		}
+	}
+
+	return nil
+}
+
+// copyExploration copies the mask through JSON, as the SQL store does.
+func copyExploration(e *model.Exploration) (*model.Exploration, error) {
+	b, err := json.Marshal(e.Mask)
+	if err != nil {
+		return nil, err
+	}
+
+	ce := *e
+	ce.Mask = &geometry.Mask{}
+	if err := json.Unmarshal(b, ce.Mask); err != nil {
+		return nil, err
+	}
+
+	return &ce, nil
+}
@@ -0,0 +1,51 @@
+package teststore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplorationRepository_Save(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	_, err := s.Exploration().Find(sc.ID, u.ID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	e := &model.Exploration{SceneID: sc.ID, UserID: u.ID, Mask: sc.NewMask()}
+	e.Mask.Set(1, 1)
+	assert.NoError(t, s.Exploration().Save(e))
+
+	e.Mask.Set(2, 1)
+	assert.NoError(t, s.Exploration().Save(e))
+
+	found, err := s.Exploration().Find(sc.ID, u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, found.Mask.Count())
+	assert.True(t, found.Mask.Has(2, 1))
+}
+
+func TestExplorationRepository_DeleteAll(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	s.Exploration().Save(&model.Exploration{SceneID: sc.ID, UserID: u.ID, Mask: sc.NewMask()})
+	assert.NoError(t, s.Exploration().DeleteAll(sc.ID))
+
+	_, err := s.Exploration().Find(sc.ID, u.ID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+}
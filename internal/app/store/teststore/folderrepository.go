@@ -0,0 +1,105 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type FolderRepository struct {
+	store   *Store
+	folders map[uuid.UUID]*model.Folder
+}
+
+func (r *FolderRepository) Create(f *model.Folder) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+
+	f.ID = uuid.New()
+	f.CreatedAt = time.Now()
+	f.UpdatedAt = f.CreatedAt
+	cf := *f
+	r.folders[f.ID] = &cf
+
+	return nil
+}
+
+func (r *FolderRepository) Find(id uuid.UUID) (*model.Folder, error) {
+	f, ok := r.folders[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+	cf := *f
+
+	return &cf, nil
+}
+
+func (r *FolderRepository) FindAll(userID uuid.UUID) ([]*model.Folder, error) {
+	folders := []*model.Folder{}
+	for _, f := range r.folders {
+		if f.UserID == userID {
+			cf := *f
+			folders = append(folders, &cf)
+		}
+	}
+
+	sort.Slice(folders, func(i, j int) bool {
+		if !folders[i].CreatedAt.Equal(folders[j].CreatedAt) {
+			return folders[i].CreatedAt.Before(folders[j].CreatedAt)
+		}
+
+		return folders[i].ID.String() < folders[j].ID.String()
+	})
+
+	return folders, nil
+}
+
+func (r *FolderRepository) Update(f *model.Folder) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+
+	if _, ok := r.folders[f.ID]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	f.UpdatedAt = time.Now()
+	cf := *f
+	r.folders[f.ID] = &cf
+
+	return nil
+}
+
+// Delete removes the folder and the folders in it, moving their assets to
+// the root of the library, as the foreign keys do in the SQL store.
+func (r *FolderRepository) Delete(id uuid.UUID) error {
+	if _, ok := r.folders[id]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.folders, id)
+
+	for fid, f := range r.folders {
+		if f.ParentID != nil && *f.ParentID == id {
+			r.Delete(fid)
+		}
+	}
+
+	for _, a := range r.store.Asset().(*AssetRepository).assets {
+		if a.FolderID != nil && *a.FolderID == id {
+			a.FolderID = nil
+		}
+	}
+
+	for _, u := range r.store.Upload().(*UploadRepository).uploads {
+		if u.FolderID != nil && *u.FolderID == id {
+			u.FolderID = nil
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type JournalFolderRepository struct {
+	store   *Store
+	folders map[uuid.UUID]*model.JournalFolder
+}
+
+func (r *JournalFolderRepository) Create(f *model.JournalFolder) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+
+	f.ID = uuid.New()
+	f.CreatedAt = time.Now()
+	f.UpdatedAt = f.CreatedAt
+	cf := *f
+	r.folders[f.ID] = &cf
+
+	return nil
+}
+
+func (r *JournalFolderRepository) Find(id uuid.UUID) (*model.JournalFolder, error) {
+	f, ok := r.folders[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+	cf := *f
+
+	return &cf, nil
+}
+
+func (r *JournalFolderRepository) FindAll(campaignID uuid.UUID) ([]*model.JournalFolder, error) {
+	folders := []*model.JournalFolder{}
+	for _, f := range r.folders {
+		if f.CampaignID == campaignID {
+			cf := *f
+			folders = append(folders, &cf)
+		}
+	}
+
+	sort.Slice(folders, func(i, j int) bool {
+		if !folders[i].CreatedAt.Equal(folders[j].CreatedAt) {
+			return folders[i].CreatedAt.Before(folders[j].CreatedAt)
+		}
+
+		return folders[i].ID.String() < folders[j].ID.String()
+	})
+
+	return folders, nil
+}
+
+func (r *JournalFolderRepository) Update(f *model.JournalFolder) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+
+	if _, ok := r.folders[f.ID]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	f.UpdatedAt = time.Now()
+	cf := *f
+	r.folders[f.ID] = &cf
+
+	return nil
+}
+
+// Delete removes the folder and the folders in it, moving their entries to
+// the root of the journal, as the foreign keys do in the SQL store.
+func (r *JournalFolderRepository) Delete(id uuid.UUID) error {
+	if _, ok := r.folders[id]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.folders, id)
+
+	for fid, f := range r.folders {
+		if f.ParentID != nil && *f.ParentID == id {
+			r.Delete(fid)
+		}
+	}
+
+	for _, e := range r.store.Journal().(*JournalRepository).entries {
+		if e.FolderID != nil && *e.FolderID == id {
+			e.FolderID = nil
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package teststore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalFolderRepository_Create(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	f := model.TestJournalFolder(t, c)
+	assert.NoError(t, s.JournalFolder().Create(f))
+	assert.NotEqual(t, uuid.Nil, f.ID)
+
+	f = model.TestJournalFolder(t, c)
+	f.Name = ""
+	assert.Error(t, s.JournalFolder().Create(f))
+}
+
+func TestJournalFolderRepository_Find(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	_, err := s.JournalFolder().Find(uuid.New())
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	f := model.TestJournalFolder(t, c)
+	s.JournalFolder().Create(f)
+	sub := model.TestJournalFolder(t, c)
+	sub.ParentID = &f.ID
+	s.JournalFolder().Create(sub)
+
+	found, err := s.JournalFolder().Find(sub.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, &f.ID, found.ParentID)
+
+	folders, err := s.JournalFolder().FindAll(c.ID)
+	assert.NoError(t, err)
+	assert.Len(t, folders, 2)
+}
+
+func TestJournalFolderRepository_Update(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	f := model.TestJournalFolder(t, c)
+	s.JournalFolder().Create(f)
+	f.Name = "Lore"
+	assert.NoError(t, s.JournalFolder().Update(f))
+
+	f, _ = s.JournalFolder().Find(f.ID)
+	assert.Equal(t, "Lore", f.Name)
+}
+
+func TestJournalFolderRepository_Delete(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	f := model.TestJournalFolder(t, c)
+	s.JournalFolder().Create(f)
+	sub := model.TestJournalFolder(t, c)
+	sub.ParentID = &f.ID
+	s.JournalFolder().Create(sub)
+	e := model.TestJournalEntry(t, c, u)
+	e.FolderID = &sub.ID
+	s.Journal().Create(e)
+
+	assert.NoError(t, s.JournalFolder().Delete(f.ID))
+	assert.EqualError(t, s.JournalFolder().Delete(f.ID), store.ErrRecordNotFound.Error())
+
+	_, err := s.JournalFolder().Find(sub.ID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+	e, _ = s.Journal().Find(e.ID)
+	assert.Nil(t, e.FolderID)
+}
@@ -0,0 +1,66 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type JournalOperationRepository struct {
+	store      *Store
+	operations map[uuid.UUID]map[int]*model.JournalOperation
+}
+
+func (r *JournalOperationRepository) Create(o *model.JournalOperation) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := r.store.Journal().Find(o.EntryID); err != nil {
+		return err
+	}
+
+	entry, ok := r.operations[o.EntryID]
+	if !ok {
+		entry = make(map[int]*model.JournalOperation)
+		r.operations[o.EntryID] = entry
+	}
+	if _, ok := entry[o.Revision]; ok {
+		return store.ErrAlreadyExists
+	}
+
+	o.CreatedAt = time.Now()
+	co := *o
+	entry[o.Revision] = &co
+
+	return nil
+}
+
+func (r *JournalOperationRepository) FindAll(entryID uuid.UUID, after int) ([]*model.JournalOperation, error) {
+	operations := []*model.JournalOperation{}
+	for revision, o := range r.operations[entryID] {
+		if revision > after {
+			co := *o
+			operations = append(operations, &co)
+		}
+	}
+
+	sort.Slice(operations, func(i, j int) bool {
+		return operations[i].Revision < operations[j].Revision
+	})
+
+	return operations, nil
+}
+
+func (r *JournalOperationRepository) Prune(entryID uuid.UUID, through int) error {
+	for revision := range r.operations[entryID] {
+		if revision <= through {
+			delete(r.operations[entryID], revision)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type JournalRepository struct {
+	store   *Store
+	entries map[uuid.UUID]*model.JournalEntry
+}
+
+func (r *JournalRepository) Create(e *model.JournalEntry) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+
+	if e.Members == nil {
+		e.Members = []uuid.UUID{}
+	}
+	e.ID = uuid.New()
+	e.Revision = 0
+	e.CreatedAt = time.Now()
+	e.UpdatedAt = e.CreatedAt
+	r.entries[e.ID] = cloneJournalEntry(e)
+
+	return nil
+}
+
+func (r *JournalRepository) Find(id uuid.UUID) (*model.JournalEntry, error) {
+	e, ok := r.entries[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return cloneJournalEntry(e), nil
+}
+
+// FindAll searches with model.JournalFilter.Match instead of full-text
+// search, so results aren't ranked.
+func (r *JournalRepository) FindAll(campaignID uuid.UUID, viewer *model.Member, filter *model.JournalFilter) ([]*model.JournalEntry, error) {
+	entries := []*model.JournalEntry{}
+	for _, e := range r.entries {
+		if e.CampaignID == campaignID && e.VisibleTo(viewer) && filter.Match(e) {
+			entries = append(entries, cloneJournalEntry(e))
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Title != entries[j].Title {
+			return entries[i].Title < entries[j].Title
+		}
+
+		return entries[i].ID.String() < entries[j].ID.String()
+	})
+
+	return entries, nil
+}
+
+// Update leaves the body alone, it changes through UpdateBody.
+func (r *JournalRepository) Update(e *model.JournalEntry) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+
+	stored, ok := r.entries[e.ID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if e.Members == nil {
+		e.Members = []uuid.UUID{}
+	}
+	e.Body = stored.Body
+	e.BodyHTML = stored.BodyHTML
+	e.Revision = stored.Revision
+	e.CreatedAt = stored.CreatedAt
+	e.UpdatedAt = time.Now()
+	r.entries[e.ID] = cloneJournalEntry(e)
+
+	return nil
+}
+
+func (r *JournalRepository) UpdateBody(e *model.JournalEntry) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+
+	stored, ok := r.entries[e.ID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if stored.Revision > e.Revision {
+		return store.ErrConflict
+	}
+
+	e.UpdatedAt = time.Now()
+	stored.Body = e.Body
+	stored.BodyHTML = e.BodyHTML
+	stored.Revision = e.Revision
+	stored.UpdatedAt = e.UpdatedAt
+
+	return nil
+}
+
+func (r *JournalRepository) Delete(id uuid.UUID) error {
+	if _, ok := r.entries[id]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.entries, id)
+	delete(r.store.JournalOperation().(*JournalOperationRepository).operations, id)
+
+	return nil
+}
+
+func cloneJournalEntry(e *model.JournalEntry) *model.JournalEntry {
+	ce := *e
+	ce.Members = append([]uuid.UUID{}, e.Members...)
+
+	return &ce
+}
@@ -0,0 +1,165 @@
+package teststore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalRepository_Create(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	e := model.TestJournalEntry(t, c, u)
+	assert.NoError(t, s.Journal().Create(e))
+	assert.NotEqual(t, uuid.Nil, e.ID)
+
+	e = model.TestJournalEntry(t, c, u)
+	e.Visibility = "everyone"
+	assert.Error(t, s.Journal().Create(e))
+}
+
+func TestJournalRepository_Find(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	_, err := s.Journal().Find(uuid.New())
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	friend := uuid.New()
+	e := model.TestJournalEntry(t, c, u)
+	e.Visibility = model.VisibilityMembers
+	e.Members = []uuid.UUID{friend}
+	s.Journal().Create(e)
+
+	found, err := s.Journal().Find(e.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{friend}, found.Members)
+	assert.Equal(t, e.Title, found.Title)
+}
+
+func TestJournalRepository_FindAll(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	f := model.TestJournalFolder(t, c)
+	s.JournalFolder().Create(f)
+
+	letter := model.TestJournalEntry(t, c, u)
+	letter.Visibility = model.VisibilityPlayers
+	s.Journal().Create(letter)
+	secret := model.TestJournalEntry(t, c, u)
+	secret.Title = "Strahd's plans"
+	secret.Body = "Strahd wants Ireena for his bride."
+	secret.FolderID = &f.ID
+	s.Journal().Create(secret)
+	shared := model.TestJournalEntry(t, c, u)
+	shared.Title = "Tarokka reading"
+	shared.Body = "The sunsword lies in the castle."
+	shared.Visibility = model.VisibilityMembers
+	player := &model.Member{UserID: uuid.New(), Role: model.RolePlayer}
+	shared.Members = []uuid.UUID{player.UserID}
+	s.Journal().Create(shared)
+
+	gm := &model.Member{UserID: u.ID, Role: model.RoleGM}
+	entries, err := s.Journal().FindAll(c.ID, gm, &model.JournalFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+
+	entries, _ = s.Journal().FindAll(c.ID, player, &model.JournalFilter{})
+	assert.Len(t, entries, 2)
+
+	other := &model.Member{UserID: uuid.New(), Role: model.RolePlayer}
+	entries, _ = s.Journal().FindAll(c.ID, other, &model.JournalFilter{})
+	assert.Len(t, entries, 1)
+
+	entries, _ = s.Journal().FindAll(c.ID, gm, &model.JournalFilter{FolderID: &f.ID})
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, secret.ID, entries[0].ID)
+	}
+
+	entries, _ = s.Journal().FindAll(c.ID, gm, &model.JournalFilter{Query: "strahd"})
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, secret.ID, entries[0].ID)
+	}
+
+	entries, _ = s.Journal().FindAll(c.ID, player, &model.JournalFilter{Query: "strahd"})
+	assert.Len(t, entries, 0)
+}
+
+func TestJournalRepository_Update(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	e := model.TestJournalEntry(t, c, u)
+	s.Journal().Create(e)
+	e.Title = "Invitation"
+	e.Visibility = model.VisibilityPlayers
+	e.Collaborative = true
+	e.Body = "ignored"
+	assert.NoError(t, s.Journal().Update(e))
+	assert.Equal(t, model.TestJournalEntry(t, c, u).Body, e.Body)
+
+	e, _ = s.Journal().Find(e.ID)
+	assert.Equal(t, "Invitation", e.Title)
+	assert.Equal(t, model.VisibilityPlayers, e.Visibility)
+	assert.True(t, e.Collaborative)
+}
+
+func TestJournalRepository_UpdateBody(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	e := model.TestJournalEntry(t, c, u)
+	s.Journal().Create(e)
+	assert.Equal(t, 0, e.Revision)
+
+	e.Body = "Hail"
+	e.BodyHTML = "<p>Hail</p>"
+	e.Revision = 3
+	assert.NoError(t, s.Journal().UpdateBody(e))
+
+	e.Body = "Hail to thee"
+	e.Revision = 2
+	assert.EqualError(t, s.Journal().UpdateBody(e), store.ErrConflict.Error())
+
+	e, _ = s.Journal().Find(e.ID)
+	assert.Equal(t, "Hail", e.Body)
+	assert.Equal(t, "<p>Hail</p>", e.BodyHTML)
+	assert.Equal(t, 3, e.Revision)
+
+	e.ID = uuid.New()
+	assert.EqualError(t, s.Journal().UpdateBody(e), store.ErrRecordNotFound.Error())
+}
+
+func TestJournalRepository_Delete(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	e := model.TestJournalEntry(t, c, u)
+	s.Journal().Create(e)
+
+	assert.NoError(t, s.Journal().Delete(e.ID))
+	assert.EqualError(t, s.Journal().Delete(e.ID), store.ErrRecordNotFound.Error())
+}
@@ -0,0 +1,105 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type LightRepository struct {
+	store  *Store
+	lights map[uuid.UUID]*model.Light
+}
+
+func (r *LightRepository) Create(l *model.Light) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+
+	l.ID = uuid.New()
+	l.CreatedAt = time.Now()
+	l.UpdatedAt = l.CreatedAt
+	cl := *l
+	r.lights[l.ID] = &cl
+
+	return nil
+}
+
+func (r *LightRepository) Find(id uuid.UUID) (*model.Light, error) {
+	l, ok := r.lights[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+	cl := *l
+
+	return &cl, nil
+}
+
+// Lock only finds the light: the test store is never used concurrently.
+func (r *LightRepository) Lock(id uuid.UUID) (*model.Light, error) {
+	return r.Find(id)
+}
+
+func (r *LightRepository) FindAll(sceneID uuid.UUID) ([]*model.Light, error) {
+	lights := []*model.Light{}
+	for _, l := range r.lights {
+		if l.SceneID == sceneID {
+			cl := *l
+			lights = append(lights, &cl)
+		}
+	}
+
+	sort.Slice(lights, func(i, j int) bool {
+		if !lights[i].CreatedAt.Equal(lights[j].CreatedAt) {
+			return lights[i].CreatedAt.Before(lights[j].CreatedAt)
+		}
+
+		return lights[i].ID.String() < lights[j].ID.String()
+	})
+
+	return lights, nil
+}
+
+func (r *LightRepository) Update(l *model.Light) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+
+	if _, ok := r.lights[l.ID]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	l.UpdatedAt = time.Now()
+	cl := *l
+	r.lights[l.ID] = &cl
+
+	return nil
+}
+
+func (r *LightRepository) Restore(l *model.Light) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+
+	if _, ok := r.lights[l.ID]; ok {
+		return store.ErrAlreadyExists
+	}
+
+	cl := *l
+	r.lights[l.ID] = &cl
+
+	return nil
+}
+
+func (r *LightRepository) Delete(id uuid.UUID) error {
+	if _, ok := r.lights[id]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.lights, id)
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type PackRepository struct {
+	store *Store
+	packs map[uuid.UUID]*model.Pack
+}
+
+func (r *PackRepository) Create(p *model.Pack) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	p.ID = uuid.New()
+	p.LatestVersion = 0
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = p.CreatedAt
+	cp := *p
+	r.packs[p.ID] = &cp
+
+	return nil
+}
+
+func (r *PackRepository) Find(id uuid.UUID) (*model.Pack, error) {
+	p, ok := r.packs[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+	cp := *p
+
+	return &cp, nil
+}
+
+func (r *PackRepository) FindAll(filter *model.PackFilter) ([]*model.Pack, error) {
+	packs := []*model.Pack{}
+	for _, p := range r.packs {
+		if filter.OwnerID != nil && p.OwnerID != *filter.OwnerID {
+			continue
+		}
+		if filter.System != "" && p.System != filter.System {
+			continue
+		}
+		cp := *p
+		packs = append(packs, &cp)
+	}
+
+	sort.Slice(packs, func(i, j int) bool {
+		if packs[i].Name != packs[j].Name {
+			return packs[i].Name < packs[j].Name
+		}
+
+		return packs[i].ID.String() < packs[j].ID.String()
+	})
+
+	return packs, nil
+}
+
+func (r *PackRepository) Update(p *model.Pack) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	stored, ok := r.packs[p.ID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	stored.Name = p.Name
+	stored.Description = p.Description
+	stored.DescriptionHTML = p.DescriptionHTML
+	stored.UpdatedAt = time.Now()
+	p.UpdatedAt = stored.UpdatedAt
+
+	return nil
+}
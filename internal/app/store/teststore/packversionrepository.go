@@ -0,0 +1,91 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type PackVersionRepository struct {
+	store    *Store
+	versions map[uuid.UUID]map[int]*model.PackVersion
+}
+
+func (r *PackVersionRepository) Create(v *model.PackVersion, entries []*model.CompendiumEntry) error {
+	for _, e := range entries {
+		if err := e.Validate(); err != nil {
+			return err
+		}
+	}
+
+	p, ok := r.store.Pack().(*PackRepository).packs[v.PackID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+	if _, ok := r.versions[v.PackID][v.Version]; ok {
+		return store.ErrConflict
+	}
+
+	v.CreatedAt = time.Now()
+	if r.versions[v.PackID] == nil {
+		r.versions[v.PackID] = make(map[int]*model.PackVersion)
+	}
+	r.versions[v.PackID][v.Version] = clonePackVersion(v)
+
+	if p.LatestVersion < v.Version {
+		p.LatestVersion = v.Version
+		p.UpdatedAt = v.CreatedAt
+	}
+
+	compendium := r.store.Compendium().(*CompendiumRepository)
+	for _, e := range entries {
+		e.ID = uuid.New()
+		packID := v.PackID
+		e.PackID = &packID
+		e.PackVersion = v.Version
+		e.CreatedAt = v.CreatedAt
+		e.UpdatedAt = v.CreatedAt
+		compendium.entries[e.ID] = cloneCompendiumEntry(e)
+	}
+
+	return nil
+}
+
+func (r *PackVersionRepository) Find(packID uuid.UUID, version int) (*model.PackVersion, error) {
+	v, ok := r.versions[packID][version]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return clonePackVersion(v), nil
+}
+
+func (r *PackVersionRepository) FindAll(packID uuid.UUID) ([]*model.PackVersion, error) {
+	versions := []*model.PackVersion{}
+	for _, v := range r.versions[packID] {
+		cv := *v
+		cv.Entries = nil
+		versions = append(versions, &cv)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version < versions[j].Version
+	})
+
+	return versions, nil
+}
+
+func clonePackVersion(v *model.PackVersion) *model.PackVersion {
+	cv := *v
+	cv.Entries = make([]*model.PackEntry, 0, len(v.Entries))
+	for _, e := range v.Entries {
+		ce := *e
+		ce.Data = append([]byte(nil), e.Data...)
+		cv.Entries = append(cv.Entries, &ce)
+	}
+
+	return &cv
+}
@@ -0,0 +1,90 @@
+package teststore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackVersionRepository_Create(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	p := model.TestPack(t, u)
+	s.Pack().Create(p)
+
+	v := model.TestPackVersion(t, p)
+	entries := v.CompendiumEntries(p)
+	assert.NoError(t, s.PackVersion().Create(v, entries))
+	assert.NotEqual(t, uuid.Nil, entries[0].ID)
+
+	found, _ := s.Pack().Find(p.ID)
+	assert.Equal(t, 1, found.LatestVersion)
+
+	e, err := s.Compendium().Find(entries[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, &p.ID, e.PackID)
+	assert.Equal(t, 1, e.PackVersion)
+
+	again := model.TestPackVersion(t, p)
+	assert.EqualError(t, s.PackVersion().Create(again, again.CompendiumEntries(p)), store.ErrConflict.Error())
+
+	next := model.TestPackVersion(t, found)
+	assert.NoError(t, s.PackVersion().Create(next, next.CompendiumEntries(p)))
+	found, _ = s.Pack().Find(p.ID)
+	assert.Equal(t, 2, found.LatestVersion)
+
+	entries, err = s.Compendium().FindAll(&model.CompendiumFilter{Packs: []model.PackRef{{ID: p.ID, Version: 1}}})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	entries, err = s.Compendium().FindAll(&model.CompendiumFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 0)
+}
+
+func TestPackVersionRepository_Find(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	p := model.TestPack(t, u)
+	s.Pack().Create(p)
+
+	_, err := s.PackVersion().Find(p.ID, 1)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	v := model.TestPackVersion(t, p)
+	s.PackVersion().Create(v, v.CompendiumEntries(p))
+	found, err := s.PackVersion().Find(p.ID, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, v.Changelog, found.Changelog)
+	if assert.Len(t, found.Entries, 2) {
+		assert.Equal(t, v.Entries[0].Name, found.Entries[0].Name)
+		assert.JSONEq(t, string(v.Entries[0].Data), string(found.Entries[0].Data))
+	}
+}
+
+func TestPackVersionRepository_FindAll(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	p := model.TestPack(t, u)
+	s.Pack().Create(p)
+
+	for version := 1; version <= 3; version++ {
+		v := model.TestPackVersion(t, p)
+		v.Version = version
+		s.PackVersion().Create(v, v.CompendiumEntries(p))
+	}
+
+	versions, err := s.PackVersion().FindAll(p.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, versions, 3) {
+		assert.Equal(t, 1, versions[0].Version)
+		assert.Equal(t, 3, versions[2].Version)
+		assert.Nil(t, versions[0].Entries)
+	}
+}
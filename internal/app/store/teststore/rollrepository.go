@@ -0,0 +1,99 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+)
+
+type RollRepository struct {
+	store *Store
+	rolls []*model.Roll
+}
+
+func (r *RollRepository) Create(roll *model.Roll) error {
+	if err := roll.Validate(); err != nil {
+		return err
+	}
+
+	roll.ID = uuid.New()
+	if roll.CreatedAt.IsZero() {
+		roll.CreatedAt = time.Now()
+	}
+	r.rolls = append(r.rolls, roll)
+
+	return nil
+}
+
+func (r *RollRepository) FindAll(campaignID uuid.UUID, filter *model.RollFilter) ([]*model.Roll, error) {
+	rolls := r.find(campaignID, filter)
+	sort.SliceStable(rolls, func(i, j int) bool {
+		return rolls[i].CreatedAt.After(rolls[j].CreatedAt)
+	})
+
+	if filter.Offset >= len(rolls) {
+		return []*model.Roll{}, nil
+	}
+	rolls = rolls[filter.Offset:]
+
+	if filter.Limit > 0 && filter.Limit < len(rolls) {
+		rolls = rolls[:filter.Limit]
+	}
+
+	return rolls, nil
+}
+
+func (r *RollRepository) Stats(campaignID uuid.UUID, filter *model.RollFilter) ([]*model.RollStats, error) {
+	f := *filter
+	f.Sides = 0
+
+	bySides := map[int]*model.RollStats{}
+	for _, roll := range r.find(campaignID, &f) {
+		for _, d := range roll.Dice {
+			if filter.Sides > 0 && d.Sides != filter.Sides {
+				continue
+			}
+
+			s, ok := bySides[d.Sides]
+			if !ok {
+				s = &model.RollStats{Sides: d.Sides, Distribution: map[int]int{}}
+				bySides[d.Sides] = s
+			}
+
+			s.Count++
+			s.Average += float64(d.Value)
+			s.Distribution[d.Value]++
+			if d.Value == d.Sides {
+				s.Crits++
+			}
+			if d.Value == 1 {
+				s.Fumbles++
+			}
+		}
+	}
+
+	stats := []*model.RollStats{}
+	for _, s := range bySides {
+		s.Average /= float64(s.Count)
+		s.ComputeFairness()
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Sides < stats[j].Sides
+	})
+
+	return stats, nil
+}
+
+func (r *RollRepository) find(campaignID uuid.UUID, filter *model.RollFilter) []*model.Roll {
+	rolls := []*model.Roll{}
+	for _, roll := range r.rolls {
+		if roll.CampaignID == campaignID && filter.Match(roll) {
+			rolls = append(rolls, roll)
+		}
+	}
+
+	return rolls
+}
@@ -0,0 +1,108 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type SceneRepository struct {
+	store  *Store
+	scenes map[uuid.UUID]*model.Scene
+}
+
+func (r *SceneRepository) Create(s *model.Scene) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	s.ID = uuid.New()
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = s.CreatedAt
+	cs := *s
+	r.scenes[s.ID] = &cs
+
+	return nil
+}
+
+func (r *SceneRepository) Find(id uuid.UUID) (*model.Scene, error) {
+	s, ok := r.scenes[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+	cs := *s
+
+	return &cs, nil
+}
+
+func (r *SceneRepository) FindAll(campaignID uuid.UUID) ([]*model.Scene, error) {
+	scenes := []*model.Scene{}
+	for _, s := range r.scenes {
+		if s.CampaignID == campaignID {
+			cs := *s
+			scenes = append(scenes, &cs)
+		}
+	}
+
+	sort.Slice(scenes, func(i, j int) bool {
+		if scenes[i].Name != scenes[j].Name {
+			return scenes[i].Name < scenes[j].Name
+		}
+
+		return scenes[i].ID.String() < scenes[j].ID.String()
+	})
+
+	return scenes, nil
+}
+
+func (r *SceneRepository) Update(s *model.Scene) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	if _, ok := r.scenes[s.ID]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	s.UpdatedAt = time.Now()
+	cs := *s
+	r.scenes[s.ID] = &cs
+
+	return nil
+}
+
+// Delete removes the scene along with everything on it, as the foreign
+// keys do in the SQL store.
+func (r *SceneRepository) Delete(id uuid.UUID) error {
+	if _, ok := r.scenes[id]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.scenes, id)
+
+	tokens := r.store.Token().(*TokenRepository)
+	for tid, t := range tokens.tokens {
+		if t.SceneID == id {
+			delete(tokens.tokens, tid)
+		}
+	}
+
+	walls := r.store.Wall().(*WallRepository)
+	for wid, w := range walls.walls {
+		if w.SceneID == id {
+			delete(walls.walls, wid)
+		}
+	}
+
+	lights := r.store.Light().(*LightRepository)
+	for lid, l := range lights.lights {
+		if l.SceneID == id {
+			delete(lights.lights, lid)
+		}
+	}
+
+	return r.store.Exploration().DeleteAll(id)
+}
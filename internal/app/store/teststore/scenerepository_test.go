@@ -0,0 +1,90 @@
+package teststore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSceneRepository_Create(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	sc := model.TestScene(t, c)
+	assert.NoError(t, s.Scene().Create(sc))
+	assert.NotEqual(t, uuid.Nil, sc.ID)
+
+	sc = model.TestScene(t, c)
+	sc.GridType = "triangle"
+	assert.Error(t, s.Scene().Create(sc))
+}
+
+func TestSceneRepository_Find(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	_, err := s.Scene().Find(uuid.New())
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+	found, err := s.Scene().Find(sc.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, sc.GridSize, found.GridSize)
+
+	other := model.TestScene(t, c)
+	other.Name = "Amber Temple"
+	s.Scene().Create(other)
+	scenes, err := s.Scene().FindAll(c.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, scenes, 2) {
+		assert.Equal(t, other.ID, scenes[0].ID)
+	}
+}
+
+func TestSceneRepository_Update(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+	sc.GridType = model.GridHex
+	sc.FreeMovement = true
+	assert.NoError(t, s.Scene().Update(sc))
+
+	sc, _ = s.Scene().Find(sc.ID)
+	assert.Equal(t, model.GridHex, sc.GridType)
+	assert.True(t, sc.FreeMovement)
+}
+
+func TestSceneRepository_Delete(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+	tok := model.TestToken(t, sc, u)
+	s.Token().Create(tok)
+
+	assert.NoError(t, s.Scene().Delete(sc.ID))
+	assert.EqualError(t, s.Scene().Delete(sc.ID), store.ErrRecordNotFound.Error())
+
+	_, err := s.Token().Find(tok.ID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+}
@@ -0,0 +1,96 @@
+package teststore
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// snapshot copies every repository of the store, records included, so that
+// restore can put them back after a failed transaction. Records are copied
+// one level deep: repositories replace or update stored records field by
+// field, never through their nested pointers.
+func (s *Store) snapshot() *Store {
+	v := reflect.ValueOf(s).Elem()
+	snap := &Store{}
+	sv := reflect.ValueOf(snap).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		repo := v.Field(i)
+		if repo.IsNil() {
+			continue
+		}
+
+		cp := reflect.New(repo.Type().Elem())
+		cp.Elem().Set(repo.Elem())
+		for j := 0; j < cp.Elem().NumField(); j++ {
+			f := field(cp.Elem(), j)
+			if f.Kind() == reflect.Map || f.Kind() == reflect.Slice {
+				f.Set(copyValue(f))
+			}
+		}
+		sv.Field(i).Set(cp)
+	}
+
+	return snap
+}
+
+// restore puts back the repositories of a snapshot. Repositories are
+// restored in place, as callers may hold on to them.
+func (s *Store) restore(snap *Store) {
+	v := reflect.ValueOf(s).Elem()
+	sv := reflect.ValueOf(snap).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if sv.Field(i).IsNil() || v.Field(i).IsNil() {
+			v.Field(i).Set(sv.Field(i))
+			continue
+		}
+
+		v.Field(i).Elem().Set(sv.Field(i).Elem())
+	}
+}
+
+// field returns the i-th field of the struct v, settable even if it's
+// unexported, as the repositories' records are.
+func field(v reflect.Value, i int) reflect.Value {
+	f := v.Field(i)
+
+	return reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
+}
+
+func copyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+
+		cp := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			cp.SetMapIndex(iter.Key(), copyValue(iter.Value()))
+		}
+
+		return cp
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			cp.Index(i).Set(copyValue(v.Index(i)))
+		}
+
+		return cp
+	case reflect.Ptr:
+		if v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return v
+		}
+
+		cp := reflect.New(v.Elem().Type())
+		cp.Elem().Set(v.Elem())
+
+		return cp
+	default:
+		return v
+	}
+}
@@ -8,12 +8,48 @@ import (
 
 type Store struct {
 	UserRepository *UserRepository
+	CampaignRepository *CampaignRepository
+	RollRepository *RollRepository
+	ChatMessageRepository *ChatMessageRepository
+	CharacterRepository *CharacterRepository
+	CharacterRevisionRepository *CharacterRevisionRepository
+	SceneRepository *SceneRepository
+	TokenRepository *TokenRepository
+	WallRepository *WallRepository
+	LightRepository *LightRepository
+	ExplorationRepository *ExplorationRepository
+	CombatRepository *CombatRepository
+	CombatantRepository *CombatantRepository
+	AssetRepository *AssetRepository
+	FolderRepository *FolderRepository
+	UploadRepository *UploadRepository
+	UsageRepository *UsageRepository
+	JournalRepository *JournalRepository
+	JournalFolderRepository *JournalFolderRepository
+	JournalOperationRepository *JournalOperationRepository
+	CompendiumRepository *CompendiumRepository
+	PackRepository *PackRepository
+	PackVersionRepository *PackVersionRepository
+	CampaignPackRepository *CampaignPackRepository
+	ActionRepository *ActionRepository
 }
 
 func New() *Store {
 	return &Store{}
 }
 
+// Transaction runs fn against the store itself and, if it fails, puts
+// every repository back as it was.
+func (s *Store) Transaction(fn func(store.Store) error) error {
+	snap := s.snapshot()
+	if err := fn(s); err != nil {
+		s.restore(snap)
+		return err
+	}
+
+	return nil
+}
+
 func (s *Store) User() store.UserRepository {
 	if s.UserRepository != nil {
 		return s.UserRepository
@@ -25,4 +61,315 @@ func (s *Store) User() store.UserRepository {
 	}
 
 	return s.UserRepository
-}
\ No newline at end of file
+}
+
+func (s *Store) Campaign() store.CampaignRepository {
+	if s.CampaignRepository != nil {
+		return s.CampaignRepository
+	}
+
+	s.CampaignRepository = &CampaignRepository{
+		store: s,
+		campaigns: make(map[uuid.UUID]*model.Campaign),
+		members: make(map[uuid.UUID]map[uuid.UUID]*model.Member),
+	}
+
+	return s.CampaignRepository
+}
+
+func (s *Store) Roll() store.RollRepository {
+	if s.RollRepository != nil {
+		return s.RollRepository
+	}
+
+	s.RollRepository = &RollRepository{
+		store: s,
+	}
+
+	return s.RollRepository
+}
+
+func (s *Store) ChatMessage() store.ChatMessageRepository {
+	if s.ChatMessageRepository != nil {
+		return s.ChatMessageRepository
+	}
+
+	s.ChatMessageRepository = &ChatMessageRepository{
+		store: s,
+		messages: make(map[uuid.UUID]*model.ChatMessage),
+	}
+
+	return s.ChatMessageRepository
+}
+
+func (s *Store) Character() store.CharacterRepository {
+	if s.CharacterRepository != nil {
+		return s.CharacterRepository
+	}
+
+	s.CharacterRepository = &CharacterRepository{
+		store: s,
+		characters: make(map[uuid.UUID]*model.Character),
+	}
+
+	return s.CharacterRepository
+}
+
+func (s *Store) CharacterRevision() store.CharacterRevisionRepository {
+	if s.CharacterRevisionRepository != nil {
+		return s.CharacterRevisionRepository
+	}
+
+	s.CharacterRevisionRepository = &CharacterRevisionRepository{
+		store: s,
+		revisions: make(map[uuid.UUID][]*model.CharacterRevision),
+	}
+
+	return s.CharacterRevisionRepository
+}
+
+func (s *Store) Scene() store.SceneRepository {
+	if s.SceneRepository != nil {
+		return s.SceneRepository
+	}
+
+	s.SceneRepository = &SceneRepository{
+		store: s,
+		scenes: make(map[uuid.UUID]*model.Scene),
+	}
+
+	return s.SceneRepository
+}
+
+func (s *Store) Token() store.TokenRepository {
+	if s.TokenRepository != nil {
+		return s.TokenRepository
+	}
+
+	s.TokenRepository = &TokenRepository{
+		store: s,
+		tokens: make(map[uuid.UUID]*model.Token),
+	}
+
+	return s.TokenRepository
+}
+
+func (s *Store) Wall() store.WallRepository {
+	if s.WallRepository != nil {
+		return s.WallRepository
+	}
+
+	s.WallRepository = &WallRepository{
+		store: s,
+		walls: make(map[uuid.UUID]*model.Wall),
+	}
+
+	return s.WallRepository
+}
+
+func (s *Store) Light() store.LightRepository {
+	if s.LightRepository != nil {
+		return s.LightRepository
+	}
+
+	s.LightRepository = &LightRepository{
+		store: s,
+		lights: make(map[uuid.UUID]*model.Light),
+	}
+
+	return s.LightRepository
+}
+
+func (s *Store) Exploration() store.ExplorationRepository {
+	if s.ExplorationRepository != nil {
+		return s.ExplorationRepository
+	}
+
+	s.ExplorationRepository = &ExplorationRepository{
+		store: s,
+		explorations: make(map[explorationKey]*model.Exploration),
+	}
+
+	return s.ExplorationRepository
+}
+
+func (s *Store) Combat() store.CombatRepository {
+	if s.CombatRepository != nil {
+		return s.CombatRepository
+	}
+
+	s.CombatRepository = &CombatRepository{
+		store: s,
+		combats: make(map[uuid.UUID]*model.Combat),
+	}
+
+	return s.CombatRepository
+}
+
+func (s *Store) Combatant() store.CombatantRepository {
+	if s.CombatantRepository != nil {
+		return s.CombatantRepository
+	}
+
+	s.CombatantRepository = &CombatantRepository{
+		store: s,
+		combatants: make(map[uuid.UUID]*model.Combatant),
+	}
+
+	return s.CombatantRepository
+}
+
+func (s *Store) Asset() store.AssetRepository {
+	if s.AssetRepository != nil {
+		return s.AssetRepository
+	}
+
+	s.AssetRepository = &AssetRepository{
+		store: s,
+		assets: make(map[uuid.UUID]*model.Asset),
+	}
+
+	return s.AssetRepository
+}
+
+func (s *Store) Folder() store.FolderRepository {
+	if s.FolderRepository != nil {
+		return s.FolderRepository
+	}
+
+	s.FolderRepository = &FolderRepository{
+		store: s,
+		folders: make(map[uuid.UUID]*model.Folder),
+	}
+
+	return s.FolderRepository
+}
+
+func (s *Store) Upload() store.UploadRepository {
+	if s.UploadRepository != nil {
+		return s.UploadRepository
+	}
+
+	s.UploadRepository = &UploadRepository{
+		store: s,
+		uploads: make(map[uuid.UUID]*model.Upload),
+	}
+
+	return s.UploadRepository
+}
+
+func (s *Store) Usage() store.UsageRepository {
+	if s.UsageRepository != nil {
+		return s.UsageRepository
+	}
+
+	s.UsageRepository = &UsageRepository{
+		store: s,
+		usages: make(map[uuid.UUID]*model.Usage),
+	}
+
+	return s.UsageRepository
+}
+
+func (s *Store) Journal() store.JournalRepository {
+	if s.JournalRepository != nil {
+		return s.JournalRepository
+	}
+
+	s.JournalRepository = &JournalRepository{
+		store: s,
+		entries: make(map[uuid.UUID]*model.JournalEntry),
+	}
+
+	return s.JournalRepository
+}
+
+func (s *Store) JournalFolder() store.JournalFolderRepository {
+	if s.JournalFolderRepository != nil {
+		return s.JournalFolderRepository
+	}
+
+	s.JournalFolderRepository = &JournalFolderRepository{
+		store: s,
+		folders: make(map[uuid.UUID]*model.JournalFolder),
+	}
+
+	return s.JournalFolderRepository
+}
+
+func (s *Store) JournalOperation() store.JournalOperationRepository {
+	if s.JournalOperationRepository != nil {
+		return s.JournalOperationRepository
+	}
+
+	s.JournalOperationRepository = &JournalOperationRepository{
+		store: s,
+		operations: make(map[uuid.UUID]map[int]*model.JournalOperation),
+	}
+
+	return s.JournalOperationRepository
+}
+
+func (s *Store) Compendium() store.CompendiumRepository {
+	if s.CompendiumRepository != nil {
+		return s.CompendiumRepository
+	}
+
+	s.CompendiumRepository = &CompendiumRepository{
+		store: s,
+		entries: make(map[uuid.UUID]*model.CompendiumEntry),
+	}
+
+	return s.CompendiumRepository
+}
+
+func (s *Store) Pack() store.PackRepository {
+	if s.PackRepository != nil {
+		return s.PackRepository
+	}
+
+	s.PackRepository = &PackRepository{
+		store: s,
+		packs: make(map[uuid.UUID]*model.Pack),
+	}
+
+	return s.PackRepository
+}
+
+func (s *Store) PackVersion() store.PackVersionRepository {
+	if s.PackVersionRepository != nil {
+		return s.PackVersionRepository
+	}
+
+	s.PackVersionRepository = &PackVersionRepository{
+		store: s,
+		versions: make(map[uuid.UUID]map[int]*model.PackVersion),
+	}
+
+	return s.PackVersionRepository
+}
+
+func (s *Store) CampaignPack() store.CampaignPackRepository {
+	if s.CampaignPackRepository != nil {
+		return s.CampaignPackRepository
+	}
+
+	s.CampaignPackRepository = &CampaignPackRepository{
+		store: s,
+		packs: make(map[uuid.UUID]map[uuid.UUID]*model.CampaignPack),
+	}
+
+	return s.CampaignPackRepository
+}
+
+func (s *Store) Action() store.ActionRepository {
+	if s.ActionRepository != nil {
+		return s.ActionRepository
+	}
+
+	s.ActionRepository = &ActionRepository{
+		store: s,
+	}
+
+	return s.ActionRepository
+}
@@ -0,0 +1,105 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type TokenRepository struct {
+	store  *Store
+	tokens map[uuid.UUID]*model.Token
+}
+
+func (r *TokenRepository) Create(t *model.Token) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	t.ID = uuid.New()
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = t.CreatedAt
+	ct := *t
+	r.tokens[t.ID] = &ct
+
+	return nil
+}
+
+func (r *TokenRepository) Find(id uuid.UUID) (*model.Token, error) {
+	t, ok := r.tokens[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+	ct := *t
+
+	return &ct, nil
+}
+
+// Lock only finds the token: the test store is never used concurrently.
+func (r *TokenRepository) Lock(id uuid.UUID) (*model.Token, error) {
+	return r.Find(id)
+}
+
+func (r *TokenRepository) FindAll(sceneID uuid.UUID) ([]*model.Token, error) {
+	tokens := []*model.Token{}
+	for _, t := range r.tokens {
+		if t.SceneID == sceneID {
+			ct := *t
+			tokens = append(tokens, &ct)
+		}
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		if !tokens[i].CreatedAt.Equal(tokens[j].CreatedAt) {
+			return tokens[i].CreatedAt.Before(tokens[j].CreatedAt)
+		}
+
+		return tokens[i].ID.String() < tokens[j].ID.String()
+	})
+
+	return tokens, nil
+}
+
+func (r *TokenRepository) Update(t *model.Token) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	if _, ok := r.tokens[t.ID]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	t.UpdatedAt = time.Now()
+	ct := *t
+	r.tokens[t.ID] = &ct
+
+	return nil
+}
+
+func (r *TokenRepository) Restore(t *model.Token) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	if _, ok := r.tokens[t.ID]; ok {
+		return store.ErrAlreadyExists
+	}
+
+	ct := *t
+	r.tokens[t.ID] = &ct
+
+	return nil
+}
+
+func (r *TokenRepository) Delete(id uuid.UUID) error {
+	if _, ok := r.tokens[id]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.tokens, id)
+
+	return nil
+}
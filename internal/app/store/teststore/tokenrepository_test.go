@@ -0,0 +1,109 @@
+package teststore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenRepository_Create(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	tok := model.TestToken(t, sc, u)
+	assert.NoError(t, s.Token().Create(tok))
+	assert.NotEqual(t, uuid.Nil, tok.ID)
+
+	tok = model.TestToken(t, sc, u)
+	tok.Layer = "sky"
+	assert.Error(t, s.Token().Create(tok))
+}
+
+func TestTokenRepository_Find(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	_, err := s.Token().Find(uuid.New())
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	tok := model.TestToken(t, sc, u)
+	s.Token().Create(tok)
+	found, err := s.Token().Find(tok.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, u.ID, *found.OwnerID)
+
+	tokens, err := s.Token().FindAll(sc.ID)
+	assert.NoError(t, err)
+	assert.Len(t, tokens, 1)
+}
+
+func TestTokenRepository_Update(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	tok := model.TestToken(t, sc, u)
+	s.Token().Create(tok)
+	tok.X, tok.Y = 385, 245
+	tok.Moved = 17.5
+	tok.OwnerID = nil
+	assert.NoError(t, s.Token().Update(tok))
+
+	tok, _ = s.Token().Find(tok.ID)
+	assert.Equal(t, 385, tok.X)
+	assert.Equal(t, 17.5, tok.Moved)
+	assert.Nil(t, tok.OwnerID)
+}
+
+func TestTokenRepository_Delete(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	tok := model.TestToken(t, sc, u)
+	s.Token().Create(tok)
+	assert.NoError(t, s.Token().Delete(tok.ID))
+	assert.EqualError(t, s.Token().Delete(tok.ID), store.ErrRecordNotFound.Error())
+}
+
+func TestTokenRepository_Restore(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	tok := model.TestToken(t, sc, u)
+	s.Token().Create(tok)
+	s.Token().Delete(tok.ID)
+	assert.NoError(t, s.Token().Restore(tok))
+
+	found, err := s.Token().Find(tok.ID)
+	assert.NoError(t, err)
+	assert.True(t, tok.CreatedAt.Equal(found.CreatedAt))
+	assert.EqualError(t, s.Token().Restore(tok), store.ErrAlreadyExists.Error())
+}
@@ -0,0 +1,94 @@
+package teststore
+
+import (
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type UploadRepository struct {
+	store   *Store
+	uploads map[uuid.UUID]*model.Upload
+}
+
+func (r *UploadRepository) Create(u *model.Upload, defaultQuota int64) error {
+	if err := u.Validate(); err != nil {
+		return err
+	}
+
+	usages := r.store.Usage().(*UsageRepository)
+	usage := usages.usage(u.UserID)
+	if usage.Used+usages.reserved(u.UserID)+u.Size > usage.Limit(defaultQuota) {
+		return store.ErrQuotaExceeded
+	}
+
+	if u.Tags == nil {
+		u.Tags = []string{}
+	}
+	u.ID = uuid.New()
+	u.CreatedAt = time.Now()
+	u.UpdatedAt = u.CreatedAt
+	cu := *u
+	cu.Tags = append([]string{}, u.Tags...)
+	r.uploads[u.ID] = &cu
+
+	return nil
+}
+
+func (r *UploadRepository) Find(id uuid.UUID) (*model.Upload, error) {
+	u, ok := r.uploads[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+	cu := *u
+	cu.Tags = append([]string{}, u.Tags...)
+
+	return &cu, nil
+}
+
+// Lock only finds the upload: the test store is never used concurrently.
+func (r *UploadRepository) Lock(id uuid.UUID) (*model.Upload, error) {
+	return r.Find(id)
+}
+
+// Update records the progress of the upload; what it is for can't change.
+func (r *UploadRepository) Update(u *model.Upload) error {
+	if err := u.Validate(); err != nil {
+		return err
+	}
+
+	stored, ok := r.uploads[u.ID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	u.UpdatedAt = time.Now()
+	stored.Offset = u.Offset
+	stored.UpdatedAt = u.UpdatedAt
+
+	return nil
+}
+
+func (r *UploadRepository) Delete(id uuid.UUID) error {
+	if _, ok := r.uploads[id]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.uploads, id)
+
+	return nil
+}
+
+func (r *UploadRepository) DeleteStale(before time.Time) ([]uuid.UUID, error) {
+	ids := []uuid.UUID{}
+	for id, u := range r.uploads {
+		if u.UpdatedAt.Before(before) {
+			ids = append(ids, id)
+			delete(r.uploads, id)
+		}
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,101 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/google/uuid"
+)
+
+type UsageRepository struct {
+	store  *Store
+	usages map[uuid.UUID]*model.Usage
+}
+
+func (r *UsageRepository) Find(userID uuid.UUID) (*model.Usage, error) {
+	u, ok := r.usages[userID]
+	if !ok {
+		return &model.Usage{UserID: userID, Reserved: r.reserved(userID)}, nil
+	}
+
+	cu := cloneUsage(u)
+	cu.Reserved = r.reserved(userID)
+
+	return cu, nil
+}
+
+func (r *UsageRepository) SetQuota(userID uuid.UUID, quota *int64) error {
+	u := r.usage(userID)
+	u.Quota = nil
+	if quota != nil {
+		q := *quota
+		u.Quota = &q
+	}
+	u.UpdatedAt = time.Now()
+
+	return nil
+}
+
+func (r *UsageRepository) Campaigns(userID uuid.UUID) ([]*model.CampaignUsage, error) {
+	used := map[uuid.UUID]int64{}
+	seen := map[uuid.UUID]map[string]bool{}
+	for _, a := range r.store.Asset().(*AssetRepository).assets {
+		if a.UserID != userID || a.CampaignID == nil {
+			continue
+		}
+
+		id := *a.CampaignID
+		if seen[id] == nil {
+			seen[id] = map[string]bool{}
+		}
+		if !seen[id][a.Hash] {
+			seen[id][a.Hash] = true
+			used[id] += a.Size
+		}
+	}
+
+	usages := []*model.CampaignUsage{}
+	for id, n := range used {
+		usages = append(usages, &model.CampaignUsage{CampaignID: id, Used: n})
+	}
+
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].CampaignID.String() < usages[j].CampaignID.String()
+	})
+
+	return usages, nil
+}
+
+// reserved sums the sizes of the user's uploads in progress.
+func (r *UsageRepository) reserved(userID uuid.UUID) int64 {
+	n := int64(0)
+	for _, u := range r.store.Upload().(*UploadRepository).uploads {
+		if u.UserID == userID {
+			n += u.Size
+		}
+	}
+
+	return n
+}
+
+// usage returns the user's stored usage, creating it if needed.
+func (r *UsageRepository) usage(userID uuid.UUID) *model.Usage {
+	u, ok := r.usages[userID]
+	if !ok {
+		u = &model.Usage{UserID: userID, UpdatedAt: time.Now()}
+		r.usages[userID] = u
+	}
+
+	return u
+}
+
+func cloneUsage(u *model.Usage) *model.Usage {
+	cu := *u
+	if u.Quota != nil {
+		q := *u.Quota
+		cu.Quota = &q
+	}
+
+	return &cu
+}
@@ -0,0 +1,105 @@
+package teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/google/uuid"
+)
+
+type WallRepository struct {
+	store *Store
+	walls map[uuid.UUID]*model.Wall
+}
+
+func (r *WallRepository) Create(w *model.Wall) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+
+	w.ID = uuid.New()
+	w.CreatedAt = time.Now()
+	w.UpdatedAt = w.CreatedAt
+	cw := *w
+	r.walls[w.ID] = &cw
+
+	return nil
+}
+
+func (r *WallRepository) Find(id uuid.UUID) (*model.Wall, error) {
+	w, ok := r.walls[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+	cw := *w
+
+	return &cw, nil
+}
+
+// Lock only finds the wall: the test store is never used concurrently.
+func (r *WallRepository) Lock(id uuid.UUID) (*model.Wall, error) {
+	return r.Find(id)
+}
+
+func (r *WallRepository) FindAll(sceneID uuid.UUID) ([]*model.Wall, error) {
+	walls := []*model.Wall{}
+	for _, w := range r.walls {
+		if w.SceneID == sceneID {
+			cw := *w
+			walls = append(walls, &cw)
+		}
+	}
+
+	sort.Slice(walls, func(i, j int) bool {
+		if !walls[i].CreatedAt.Equal(walls[j].CreatedAt) {
+			return walls[i].CreatedAt.Before(walls[j].CreatedAt)
+		}
+
+		return walls[i].ID.String() < walls[j].ID.String()
+	})
+
+	return walls, nil
+}
+
+func (r *WallRepository) Update(w *model.Wall) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+
+	if _, ok := r.walls[w.ID]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	w.UpdatedAt = time.Now()
+	cw := *w
+	r.walls[w.ID] = &cw
+
+	return nil
+}
+
+func (r *WallRepository) Restore(w *model.Wall) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+
+	if _, ok := r.walls[w.ID]; ok {
+		return store.ErrAlreadyExists
+	}
+
+	cw := *w
+	r.walls[w.ID] = &cw
+
+	return nil
+}
+
+func (r *WallRepository) Delete(id uuid.UUID) error {
+	if _, ok := r.walls[id]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.walls, id)
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package teststore_test
+
+import (
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/store"
+	"github.com/bruhlord-s/virttable-api/internal/app/store/teststore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWallRepository_Create(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	w := model.TestWall(t, sc)
+	assert.NoError(t, s.Wall().Create(w))
+	assert.NotEqual(t, uuid.Nil, w.ID)
+
+	w = model.TestWall(t, sc)
+	w.Open = true
+	assert.Error(t, s.Wall().Create(w))
+}
+
+func TestWallRepository_Find(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	_, err := s.Wall().Find(uuid.New())
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	w := model.TestWall(t, sc)
+	s.Wall().Create(w)
+	found, err := s.Wall().Find(w.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.WallSolid, found.Kind)
+
+	walls, err := s.Wall().FindAll(sc.ID)
+	assert.NoError(t, err)
+	assert.Len(t, walls, 1)
+}
+
+func TestWallRepository_Update(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	w := model.TestWall(t, sc)
+	w.Kind = model.WallDoor
+	s.Wall().Create(w)
+	w.Open = true
+	assert.NoError(t, s.Wall().Update(w))
+
+	w, _ = s.Wall().Find(w.ID)
+	assert.True(t, w.Open)
+}
+
+func TestWallRepository_Delete(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	w := model.TestWall(t, sc)
+	s.Wall().Create(w)
+	assert.NoError(t, s.Wall().Delete(w.ID))
+	assert.EqualError(t, s.Wall().Delete(w.ID), store.ErrRecordNotFound.Error())
+}
+
+func TestWallRepository_Restore(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestCampaign(t, u)
+	s.Campaign().Create(c)
+	sc := model.TestScene(t, c)
+	s.Scene().Create(sc)
+
+	w := model.TestWall(t, sc)
+	s.Wall().Create(w)
+	s.Wall().Delete(w.ID)
+	assert.NoError(t, s.Wall().Restore(w))
+
+	found, err := s.Wall().Find(w.ID)
+	assert.NoError(t, err)
+	assert.True(t, w.CreatedAt.Equal(found.CreatedAt))
+	assert.EqualError(t, s.Wall().Restore(w), store.ErrAlreadyExists.Error())
+}
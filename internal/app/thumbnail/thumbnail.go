@@ -0,0 +1,109 @@
+// Package thumbnail shrinks uploaded images into small previews.
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif"  // register the GIF decoder
+	_ "image/jpeg" // register the JPEG decoder
+	"image/png"
+	"io"
+)
+
+// MaxPixels caps the size of images decoded, so a small file claiming huge
+// dimensions can't exhaust memory.
+const MaxPixels = 50000000
+
+var (
+	ErrTooLarge = errors.New("image is too large")
+)
+
+// Make decodes a PNG, JPEG or GIF image and returns it scaled down to fit a
+// size by size square as a PNG, along with the image's own dimensions.
+// Images already small enough keep their size.
+func Make(r io.ReadSeeker, size int) ([]byte, image.Config, error) {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, cfg, err
+	}
+
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, cfg, ErrTooLarge
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, cfg, err
+	}
+
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, cfg, err
+	}
+
+	b := &bytes.Buffer{}
+	if err := png.Encode(b, Scale(img, size)); err != nil {
+		return nil, cfg, err
+	}
+
+	return b.Bytes(), cfg, nil
+}
+
+// Scale shrinks the image to fit a size by size square, keeping its aspect
+// ratio, by averaging the pixels each new pixel covers.
+func Scale(img image.Image, size int) *image.NRGBA {
+	bounds := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	if w <= size && h <= size {
+		return src
+	}
+
+	tw, th := size, h*size/w
+	if h > w {
+		tw, th = w*size/h, size
+	}
+	if tw < 1 {
+		tw = 1
+	}
+	if th < 1 {
+		th = 1
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := y*h/th, (y+1)*h/th
+		for x := 0; x < tw; x++ {
+			x0, x1 := x*w/tw, (x+1)*w/tw
+
+			// Colours are weighted by alpha so transparent pixels don't
+			// darken the edges.
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					pa := int(src.Pix[i+3])
+					r += int(src.Pix[i]) * pa
+					g += int(src.Pix[i+1]) * pa
+					b += int(src.Pix[i+2]) * pa
+					a += pa
+					n++
+					i += 4
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			if a > 0 {
+				dst.Pix[i] = uint8(r / a)
+				dst.Pix[i+1] = uint8(g / a)
+				dst.Pix[i+2] = uint8(b / a)
+			}
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
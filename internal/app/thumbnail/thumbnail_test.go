@@ -0,0 +1,50 @@
+package thumbnail_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/thumbnail"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScale(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 400, 100))
+	for x := 0; x < 400; x++ {
+		for y := 0; y < 100; y++ {
+			if x < 200 {
+				img.Set(x, y, color.NRGBA{255, 0, 0, 255})
+			} else {
+				img.Set(x, y, color.NRGBA{0, 0, 255, 255})
+			}
+		}
+	}
+
+	thumb := thumbnail.Scale(img, 100)
+	assert.Equal(t, image.Rect(0, 0, 100, 25), thumb.Rect)
+	assert.Equal(t, color.NRGBA{255, 0, 0, 255}, thumb.NRGBAAt(10, 10))
+	assert.Equal(t, color.NRGBA{0, 0, 255, 255}, thumb.NRGBAAt(90, 10))
+
+	small := thumbnail.Scale(image.NewNRGBA(image.Rect(0, 0, 20, 30)), 100)
+	assert.Equal(t, image.Rect(0, 0, 20, 30), small.Rect)
+}
+
+func TestMake(t *testing.T) {
+	b := &bytes.Buffer{}
+	png.Encode(b, image.NewGray(image.Rect(0, 0, 300, 600)))
+
+	thumb, cfg, err := thumbnail.Make(bytes.NewReader(b.Bytes()), 128)
+	assert.NoError(t, err)
+	assert.Equal(t, 300, cfg.Width)
+	assert.Equal(t, 600, cfg.Height)
+
+	img, err := png.Decode(bytes.NewReader(thumb))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 64, 128), img.Bounds())
+
+	_, _, err = thumbnail.Make(bytes.NewReader([]byte("not an image")), 128)
+	assert.Error(t, err)
+}
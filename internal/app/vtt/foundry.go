@@ -0,0 +1,755 @@
+package vtt
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/grid"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/sheet"
+	"github.com/google/uuid"
+)
+
+var colorRegexp = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Foundry VTT permission levels, of which observers can read a document.
+const foundryObserver = 2
+
+type foundryWorld struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	System      string `json:"system"`
+	Description string `json:"description"`
+}
+
+// foundryDocument has what every document has, whichever version of Foundry
+// VTT wrote it. Version 10 moved the data of actors and items from data to
+// system and permissions from permission to ownership.
+type foundryDocument struct {
+	ID         string            `json:"_id"`
+	Name       string            `json:"name"`
+	Type       string            `json:"type"`
+	Img        string            `json:"img"`
+	Folder     string            `json:"folder"`
+	Parent     string            `json:"parent"`
+	Data       json.RawMessage   `json:"data"`
+	System     json.RawMessage   `json:"system"`
+	Permission map[string]number `json:"permission"`
+	Ownership  map[string]number `json:"ownership"`
+}
+
+func (d *foundryDocument) system() json.RawMessage {
+	if len(d.System) > 0 {
+		return d.System
+	}
+
+	return d.Data
+}
+
+func (d *foundryDocument) observable() bool {
+	perms := d.Ownership
+	if perms == nil {
+		perms = d.Permission
+	}
+
+	return perms["default"] >= foundryObserver
+}
+
+type foundryScene struct {
+	foundryDocument
+	Background struct {
+		Src string `json:"src"`
+	} `json:"background"`
+	Width        number          `json:"width"`
+	Height       number          `json:"height"`
+	Padding      number          `json:"padding"`
+	Grid         json.RawMessage `json:"grid"`
+	GridType     *number         `json:"gridType"`
+	GridDistance number          `json:"gridDistance"`
+	TokenVision  bool            `json:"tokenVision"`
+	GlobalLight  bool            `json:"globalLight"`
+	Tokens       []*foundryToken `json:"tokens"`
+	Walls        []*foundryWall  `json:"walls"`
+	Lights       []*foundryLight `json:"lights"`
+	Drawings     []interface{}   `json:"drawings"`
+	Notes        []interface{}   `json:"notes"`
+	Sounds       []interface{}   `json:"sounds"`
+	Templates    []interface{}   `json:"templates"`
+}
+
+type foundryToken struct {
+	Name     string `json:"name"`
+	X        number `json:"x"`
+	Y        number `json:"y"`
+	Width    number `json:"width"`
+	Height   number `json:"height"`
+	Rotation number `json:"rotation"`
+	Hidden   bool   `json:"hidden"`
+	ActorID  string `json:"actorId"`
+	Img      string `json:"img"`
+	Texture  struct {
+		Src string `json:"src"`
+	} `json:"texture"`
+	DimSight number `json:"dimSight"`
+	Sight    struct {
+		Range number `json:"range"`
+	} `json:"sight"`
+	DimLight number `json:"dimLight"`
+	Light    struct {
+		Dim number `json:"dim"`
+	} `json:"light"`
+}
+
+type foundryWall struct {
+	C     []number `json:"c"`
+	Door  number   `json:"door"`
+	DS    number   `json:"ds"`
+	Sense *number  `json:"sense"`
+	Sight *number  `json:"sight"`
+	Move  *number  `json:"move"`
+}
+
+type foundryLight struct {
+	X         number `json:"x"`
+	Y         number `json:"y"`
+	Dim       number `json:"dim"`
+	Bright    number `json:"bright"`
+	TintColor string `json:"tintColor"`
+	Config    struct {
+		Dim    number `json:"dim"`
+		Bright number `json:"bright"`
+		Color  string `json:"color"`
+	} `json:"config"`
+}
+
+type foundryActor struct {
+	foundryDocument
+	Items []*foundryDocument `json:"items"`
+}
+
+type foundryJournal struct {
+	foundryDocument
+	Content string `json:"content"`
+	Pages   []*struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+		Src  string `json:"src"`
+		Text struct {
+			Content string `json:"content"`
+		} `json:"text"`
+	} `json:"pages"`
+}
+
+// foundrySheet is what both systems keep on actors and items that has a
+// place on a sheet here.
+type foundrySheet struct {
+	Abilities map[string]struct {
+		Value number `json:"value"`
+		Mod   number `json:"mod"`
+	} `json:"abilities"`
+	Attributes struct {
+		HP struct {
+			Value number `json:"value"`
+			Max   number `json:"max"`
+			Temp  number `json:"temp"`
+		} `json:"hp"`
+		AC       number `json:"ac"`
+		Movement struct {
+			Walk number `json:"walk"`
+		} `json:"movement"`
+	} `json:"attributes"`
+	Details struct {
+		Level      number      `json:"level"`
+		Race       interface{} `json:"race"`
+		Background interface{} `json:"background"`
+		Alignment  interface{} `json:"alignment"`
+		Biography  struct {
+			Value     string `json:"value"`
+			Backstory string `json:"backstory"`
+		} `json:"biography"`
+	} `json:"details"`
+	Description struct {
+		Value string `json:"value"`
+	} `json:"description"`
+	Levels      number `json:"levels"`
+	Level       number `json:"level"`
+	Quantity    number `json:"quantity"`
+	Preparation struct {
+		Prepared bool `json:"prepared"`
+	} `json:"preparation"`
+}
+
+func (d *foundryDocument) sheet() *foundrySheet {
+	s := &foundrySheet{}
+	json.Unmarshal(d.system(), s)
+
+	return s
+}
+
+// ReadFoundry converts a zipped Foundry VTT world folder. Worlds of systems
+// other than the ones here become generic campaigns without sheets.
+func ReadFoundry(r io.ReaderAt, size int64, opts *Options) (*Import, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	c := newConverter(zr, opts, "foundry")
+	root, f := c.find("world.json")
+	if f == nil {
+		return nil, ErrNoWorld
+	}
+
+	world := &foundryWorld{}
+	if err := readJSON(f, world); err != nil {
+		return nil, err
+	}
+
+	data := map[string][]json.RawMessage{}
+	for name, f := range c.files {
+		if !strings.HasPrefix(name, root+"data/") {
+			continue
+		}
+
+		rel := strings.TrimPrefix(name, root+"data/")
+		if strings.Contains(rel, "/") {
+			if path.Base(rel) == "CURRENT" {
+				return nil, ErrUnsupportedWorld
+			}
+			continue
+		}
+		if path.Ext(rel) == ".db" {
+			if data[strings.TrimSuffix(rel, ".db")], err = readNeDB(f); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	system := world.System
+	if !isSystem(system) {
+		e := c.im.Report.add("world", world.Title)
+		e.lose("%s isn't a system here, so the campaign is generic and its sheets are dropped", system)
+		system = sheet.SystemGeneric
+	}
+
+	name := world.Title
+	if name == "" {
+		name = "Foundry VTT world"
+	}
+	id := world.ID
+	if id == "" {
+		id = world.Name
+	}
+	fc := &foundryConverter{converter: c, root: root, prefix: "worlds/" + id + "/", system: system}
+
+	ac := c.im.Campaign
+	ac.Campaign = &model.Campaign{Name: name, System: system}
+	if world.Description != "" {
+		ac.Campaign.Description, _ = toMarkdown(world.Description)
+	}
+
+	actors := map[string]uuid.UUID{}
+	for _, raw := range data["actors"] {
+		a := &foundryActor{}
+		if json.Unmarshal(raw, a) != nil {
+			continue
+		}
+		if ch := fc.actor(a); ch != nil {
+			actors[a.ID] = ch.ID
+			ac.Characters = append(ac.Characters, ch)
+		}
+	}
+
+	for _, raw := range data["scenes"] {
+		sc := &foundryScene{}
+		if json.Unmarshal(raw, sc) == nil {
+			fc.scene(sc, actors)
+		}
+	}
+
+	folders := fc.folders(data["folders"])
+	for _, raw := range data["journal"] {
+		j := &foundryJournal{}
+		if json.Unmarshal(raw, j) == nil {
+			fc.journal(j, folders[j.Folder])
+		}
+	}
+
+	var items *uuid.UUID
+	for _, raw := range data["items"] {
+		it := &foundryDocument{}
+		if json.Unmarshal(raw, it) != nil {
+			continue
+		}
+		if items == nil {
+			f := &model.JournalFolder{ID: uuid.New(), Name: "Items"}
+			ac.JournalFolders = append(ac.JournalFolders, f)
+			items = &f.ID
+		}
+		fc.item(it, items)
+	}
+
+	for _, skipped := range []struct {
+		db   string
+		kind string
+	}{
+		{"macros", "macro"},
+		{"tables", "rollable table"},
+		{"playlists", "playlist"},
+		{"cards", "card stack"},
+	} {
+		for _, raw := range data[skipped.db] {
+			doc := &foundryDocument{}
+			json.Unmarshal(raw, doc)
+			c.im.Report.add(skipped.kind, doc.Name).skip("%ss have no counterpart here", skipped.kind)
+		}
+	}
+	if n := len(data["combats"]); n > 0 {
+		c.im.Report.add("combat", "").skip("%d combats in progress aren't imported", n)
+	}
+	if n := len(data["messages"]); n > 0 {
+		c.im.Report.add("chat log", "").skip("%d chat messages aren't imported", n)
+	}
+
+	return c.im, nil
+}
+
+type foundryConverter struct {
+	*converter
+	root string
+	// prefix starts the paths of files in the world folder, as Foundry VTT
+	// keeps them relative to its data folder.
+	prefix string
+	system string
+}
+
+func (c *foundryConverter) image(ref string, e *ReportEntry) string {
+	return c.converter.image(c.root, strings.TrimPrefix(ref, c.prefix), e)
+}
+
+func (c *foundryConverter) scene(src *foundryScene, actors map[string]uuid.UUID) {
+	e := c.im.Report.add("scene", src.Name)
+
+	sc := newScene(src.Name)
+	if sc.Name == "" {
+		sc.Name = "Untitled scene"
+	}
+
+	// Version 10 put the grid settings in an object of their own.
+	gridSize, gridType, distance := number(0), src.GridType, src.GridDistance
+	g := &struct {
+		Size     number  `json:"size"`
+		Type     *number `json:"type"`
+		Distance number  `json:"distance"`
+	}{}
+	if bytes.HasPrefix(bytes.TrimSpace(src.Grid), []byte("{")) {
+		if json.Unmarshal(src.Grid, g) == nil {
+			gridSize, gridType, distance = g.Size, g.Type, g.Distance
+		}
+	} else {
+		json.Unmarshal(src.Grid, &gridSize)
+	}
+	if gridSize > 0 {
+		sc.GridSize = clamp(gridSize.int(), 10, 500)
+	}
+	if distance > 0 {
+		sc.GridDistance = clamp(distance.int(), 1, 1000)
+	}
+
+	if gridType != nil {
+		t := gridType.int()
+		switch t {
+		case 0:
+			e.lose("gridless scenes get a square grid")
+		case 2, 3:
+			sc.GridType, sc.HexOrientation = model.GridHex, grid.Pointy
+		case 4, 5:
+			sc.GridType, sc.HexOrientation = model.GridHex, grid.Flat
+		}
+		if t == 3 || t == 5 {
+			e.lose("hexes are offset from odd rows and columns rather than even ones")
+		}
+	}
+
+	// The canvas has padding around the scene, which positions include.
+	padX := math.Ceil(float64(src.Width*src.Padding)/float64(sc.GridSize)) * float64(sc.GridSize)
+	padY := math.Ceil(float64(src.Height*src.Padding)/float64(sc.GridSize)) * float64(sc.GridSize)
+	point := func(x, y number) (int, int) {
+		return int(math.Round(float64(x) - padX)), int(math.Round(float64(y) - padY))
+	}
+	pixels := func(units number) int {
+		return clamp(int(math.Round(float64(units)/float64(sc.GridDistance)*float64(sc.GridSize))), 0, 20000)
+	}
+
+	sc.Width = clamp(src.Width.int(), 1, 20000)
+	sc.Height = clamp(src.Height.int(), 1, 20000)
+	sc.FogOfWar = src.TokenVision
+	sc.Dark = src.TokenVision && !src.GlobalLight
+
+	bg := src.Background.Src
+	if bg == "" {
+		bg = src.Img
+	}
+	sc.Background = c.image(bg, e)
+	if !valid(sc, e) {
+		return
+	}
+	c.im.Campaign.Scenes = append(c.im.Campaign.Scenes, sc)
+
+	for _, ft := range src.Tokens {
+		w, h := float64(ft.Width), float64(ft.Height)
+		if w <= 0 {
+			w = 1
+		}
+		if h <= 0 {
+			h = w
+		}
+		if w != h {
+			e.lose("token %q became square", ft.Name)
+		}
+
+		x, y := point(ft.X, ft.Y)
+		t := &model.Token{
+			ID:       uuid.New(),
+			SceneID:  sc.ID,
+			Name:     ft.Name,
+			X:        x + int(math.Round(w*float64(sc.GridSize)/2)),
+			Y:        y + int(math.Round(h*float64(sc.GridSize)/2)),
+			Size:     w,
+			Rotation: (ft.Rotation.int()%360 + 360) % 360,
+			Vision:   pixels(ft.DimSight + ft.Sight.Range),
+			Layer:    model.LayerTokens,
+		}
+		if ft.Hidden {
+			t.Layer = model.LayerGM
+		}
+		img := ft.Texture.Src
+		if img == "" {
+			img = ft.Img
+		}
+		t.Image = c.image(img, e)
+		if id, ok := actors[ft.ActorID]; ok {
+			t.CharacterID = &id
+		}
+		if err := t.Validate(); err != nil {
+			e.lose("dropped token %q: %s", ft.Name, err)
+			continue
+		}
+		c.im.Campaign.Tokens = append(c.im.Campaign.Tokens, t)
+
+		if dim := ft.DimLight + ft.Light.Dim; dim > 0 {
+			c.im.Campaign.Lights = append(c.im.Campaign.Lights, &model.Light{ID: uuid.New(), SceneID: sc.ID, X: t.X, Y: t.Y, Radius: clamp(pixels(dim), 1, 20000)})
+			e.lose("the light of token %q stays where the token was", ft.Name)
+		}
+	}
+
+	dropped := map[string]int{}
+	secret, sightOnly := false, false
+	for _, fw := range src.Walls {
+		if len(fw.C) != 4 {
+			continue
+		}
+
+		// Version 10 counts senses in tens, with none still at zero.
+		seeThrough := (fw.Sense != nil && *fw.Sense == 0) || (fw.Sight != nil && *fw.Sight == 0)
+		passable := fw.Move != nil && *fw.Move == 0
+		w := &model.Wall{ID: uuid.New(), SceneID: sc.ID, Kind: model.WallSolid}
+		w.X1, w.Y1 = point(fw.C[0], fw.C[1])
+		w.X2, w.Y2 = point(fw.C[2], fw.C[3])
+
+		switch {
+		case fw.Door > 0:
+			w.Kind, w.Open = model.WallDoor, fw.DS == 1
+			if fw.Door == 2 {
+				secret = true
+			}
+		case passable && seeThrough:
+			dropped["walls that block neither movement nor sight"]++
+			continue
+		case seeThrough:
+			w.Kind = model.WallWindow
+		case passable:
+			sightOnly = true
+		}
+		if w.Validate() != nil {
+			continue
+		}
+		c.im.Campaign.Walls = append(c.im.Campaign.Walls, w)
+	}
+
+	for _, fl := range src.Lights {
+		dim, color := fl.Config.Dim, fl.Config.Color
+		if dim == 0 {
+			dim, color = fl.Dim, fl.TintColor
+		}
+		if dim == 0 {
+			dim = fl.Bright + fl.Config.Bright
+		}
+
+		l := &model.Light{ID: uuid.New(), SceneID: sc.ID, Radius: clamp(pixels(dim), 1, 20000)}
+		l.X, l.Y = point(fl.X, fl.Y)
+		if colorRegexp.MatchString(color) {
+			l.Color = color
+		}
+		c.im.Campaign.Lights = append(c.im.Campaign.Lights, l)
+	}
+
+	dropped["drawings"] += len(src.Drawings)
+	dropped["map notes"] += len(src.Notes)
+	dropped["sounds"] += len(src.Sounds)
+	dropped["measured templates"] += len(src.Templates)
+	e.drop(dropped)
+	if secret {
+		e.lose("secret doors became plain doors")
+	}
+	if sightOnly {
+		e.lose("walls that only block sight block movement too")
+	}
+}
+
+func (c *foundryConverter) actor(src *foundryActor) *model.Character {
+	e := c.im.Report.add("actor", src.Name)
+
+	d := &sheetData{Abilities: map[string]int{}}
+	s := src.sheet()
+	for k, a := range s.Abilities {
+		if c.system == sheet.SystemPF2e {
+			d.Abilities[k] = a.Mod.int()
+		} else {
+			d.Abilities[k] = a.Value.int()
+		}
+	}
+	d.Level = s.Details.Level.int()
+	d.Race, d.Background, d.Alignment = text(s.Details.Race), text(s.Details.Background), text(s.Details.Alignment)
+	if hp := s.Attributes.HP; hp.Max > 0 {
+		d.HP = &sheetHP{Current: hp.Value.int(), Max: hp.Max.int(), Temp: hp.Temp.int()}
+	}
+	d.AC = s.Attributes.AC.int()
+	d.Speed = s.Attributes.Movement.Walk.int()
+
+	levels := 0
+	for _, it := range src.Items {
+		is := it.sheet()
+		switch it.Type {
+		case "class":
+			if d.Class == "" {
+				d.Class = it.Name
+			} else {
+				e.lose("dropped class %s, as characters have one", it.Name)
+			}
+			levels += is.Levels.int()
+		case "subclass":
+			d.Subclass = it.Name
+		case "ancestry", "race":
+			d.Race = it.Name
+		case "heritage":
+			d.Heritage = it.Name
+		case "background":
+			d.Background = it.Name
+		case "feat":
+			d.Feats = append(d.Feats, it.Name)
+		case "spell":
+			d.Spells = append(d.Spells, sheetSpell{Name: it.Name, Level: clamp(is.Level.int(), 0, 9), Prepared: is.Preparation.Prepared})
+		case "weapon", "equipment", "armor", "consumable", "tool", "loot", "treasure", "backpack":
+			q := is.Quantity.int()
+			if q < 0 {
+				q = 0
+			}
+			d.Inventory = append(d.Inventory, sheetItem{Name: it.Name, Quantity: q})
+		default:
+			d.Unknown++
+		}
+	}
+	if d.Level == 0 {
+		d.Level = levels
+	}
+
+	bio := s.Details.Biography.Value
+	if bio == "" {
+		bio = s.Details.Biography.Backstory
+	}
+	if bio != "" {
+		d.Notes = c.markdown(bio, e)
+	}
+
+	ch := &model.Character{
+		ID:       uuid.New(),
+		Name:     src.Name,
+		Portrait: c.image(src.Img, e),
+		System:   c.system,
+		Data:     d.encode(c.system, e),
+	}
+	if !valid(ch, e) {
+		return nil
+	}
+
+	return ch
+}
+
+// folders creates the journal folders and returns their IDs by the
+// Foundry VTT ones. Folders of other documents are left out.
+func (c *foundryConverter) folders(docs []json.RawMessage) map[string]*uuid.UUID {
+	ids := map[string]*uuid.UUID{}
+	folders := []*foundryDocument{}
+	for _, raw := range docs {
+		f := &foundryDocument{}
+		if json.Unmarshal(raw, f) != nil || f.Type != "JournalEntry" {
+			continue
+		}
+		id := uuid.New()
+		ids[f.ID] = &id
+		folders = append(folders, f)
+	}
+
+	for _, f := range folders {
+		parent := f.Folder
+		if parent == "" {
+			parent = f.Parent
+		}
+
+		name := f.Name
+		if name == "" {
+			name = "Untitled folder"
+		}
+		c.im.Campaign.JournalFolders = append(c.im.Campaign.JournalFolders, &model.JournalFolder{ID: *ids[f.ID], ParentID: ids[parent], Name: name})
+	}
+
+	return ids
+}
+
+func (c *foundryConverter) journal(src *foundryJournal, folderID *uuid.UUID) {
+	e := c.im.Report.add("journal entry", src.Name)
+
+	entry := &model.JournalEntry{
+		FolderID:   folderID,
+		Title:      src.Name,
+		Visibility: model.VisibilityGM,
+	}
+	if src.observable() {
+		entry.Visibility = model.VisibilityPlayers
+	}
+
+	img := src.Img
+	body := c.markdown(src.Content, e)
+	// Version 10 split entries into pages.
+	parts := []string{body}
+	for _, p := range src.Pages {
+		switch p.Type {
+		case "text":
+			parts = append(parts, "## "+p.Name+"\n\n"+c.markdown(p.Text.Content, e))
+		case "image":
+			if img == "" {
+				img = p.Src
+				continue
+			}
+			if url := c.image(p.Src, e); url != "" {
+				parts = append(parts, "## "+p.Name+"\n\n!["+p.Name+"]("+url+")")
+			}
+		default:
+			e.lose("dropped %s page %q", p.Type, p.Name)
+		}
+	}
+	entry.Body = strings.TrimSpace(strings.Join(parts, "\n\n"))
+
+	if strings.HasPrefix(img, "http://") || strings.HasPrefix(img, "https://") {
+		entry.Body = strings.TrimSpace("![" + src.Name + "](" + img + ")\n\n" + entry.Body)
+	} else if a := c.file(c.root, strings.TrimPrefix(img, c.prefix), e); a != nil {
+		entry.ImageID = &a.ID
+	}
+
+	if valid(entry, e) {
+		c.im.Campaign.JournalEntries = append(c.im.Campaign.JournalEntries, entry)
+	}
+}
+
+// item keeps the description of an item as a journal entry, as items have
+// no counterpart of their own.
+func (c *foundryConverter) item(src *foundryDocument, folderID *uuid.UUID) {
+	e := c.im.Report.add("item", src.Name)
+	e.lose("only the description is kept, as a journal entry")
+
+	entry := &model.JournalEntry{
+		FolderID:   folderID,
+		Title:      src.Name,
+		Body:       c.markdown(src.sheet().Description.Value, e),
+		Visibility: model.VisibilityGM,
+	}
+	if a := c.file(c.root, strings.TrimPrefix(src.Img, c.prefix), e); a != nil {
+		entry.ImageID = &a.ID
+	}
+
+	if valid(entry, e) {
+		c.im.Campaign.JournalEntries = append(c.im.Campaign.JournalEntries, entry)
+	}
+}
+
+// readNeDB reads the documents of a NeDB file, a log of them a line each
+// in which later lines replace or delete earlier ones by ID.
+func readNeDB(f *zip.File) ([]json.RawMessage, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	docs := map[string]json.RawMessage{}
+	order := []string{}
+	seen := map[string]bool{}
+	sc := bufio.NewScanner(rc)
+	sc.Buffer(nil, 64<<20)
+	for sc.Scan() {
+		line := sc.Bytes()
+		doc := &struct {
+			ID      string `json:"_id"`
+			Deleted bool   `json:"$$deleted"`
+		}{}
+		if json.Unmarshal(line, doc) != nil || doc.ID == "" {
+			continue
+		}
+
+		if doc.Deleted {
+			delete(docs, doc.ID)
+			continue
+		}
+		if !seen[doc.ID] {
+			seen[doc.ID] = true
+			order = append(order, doc.ID)
+		}
+		docs[doc.ID] = append(json.RawMessage{}, line...)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	out := []json.RawMessage{}
+	for _, id := range order {
+		if doc, ok := docs[id]; ok {
+			out = append(out, doc)
+		}
+	}
+
+	return out, nil
+}
+
+func readJSON(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return json.NewDecoder(rc).Decode(v)
+}
+
+func text(v interface{}) string {
+	s, _ := v.(string)
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,206 @@
+package vtt_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/sheet"
+	"github.com/bruhlord-s/virttable-api/internal/app/vtt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadFoundry(t *testing.T) {
+	r := testZip(t, "testdata/foundry", "")
+	im, err := vtt.ReadFoundry(r, r.Size(), &vtt.Options{BaseURL: "https://vt.example.com/", ThumbnailSize: 8})
+	if !assert.NoError(t, err) {
+		return
+	}
+	c := im.Campaign
+	assert.Equal(t, "Lost Mine of Phandelver", c.Campaign.Name)
+	assert.Equal(t, sheet.SystemDnD5e, c.Campaign.System)
+	assert.Equal(t, "The **Phandelver** campaign.", c.Campaign.Description)
+
+	if !assert.Len(t, c.Assets, 2) {
+		return
+	}
+	cave, sildar := c.Assets[0], c.Assets[1]
+	if cave.Name != "cave.png" {
+		cave, sildar = sildar, cave
+	}
+	assert.Equal(t, "image/png", cave.MIME)
+	assert.Equal(t, 100, cave.Width)
+	assert.NotEmpty(t, cave.Thumbnail)
+
+	if assert.Len(t, c.Characters, 2) {
+		ch := c.Characters[0]
+		assert.Equal(t, "Sildar", ch.Name)
+		assert.Equal(t, "https://vt.example.com/assets/"+sildar.ID.String(), ch.Portrait)
+		data := map[string]interface{}{}
+		json.Unmarshal(ch.Data, &data)
+		assert.Equal(t, "Fighter", data["class"])
+		assert.Equal(t, 4.0, data["level"])
+		assert.Equal(t, 13.0, data["abilities"].(map[string]interface{})["str"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"name": "Longsword", "quantity": 1.0}}, data["inventory"])
+		assert.Equal(t, "", c.Characters[1].Portrait)
+	}
+
+	if !assert.Len(t, c.Scenes, 1) {
+		return
+	}
+	sc := c.Scenes[0]
+	assert.Equal(t, "https://vt.example.com/assets/"+cave.ID.String(), sc.Background)
+	assert.Equal(t, 100, sc.GridSize)
+	assert.Equal(t, 1000, sc.Width)
+	assert.True(t, sc.FogOfWar)
+	assert.True(t, sc.Dark)
+
+	if assert.Len(t, c.Tokens, 2) {
+		// Padding of a quarter of the scene puts it 300 by 200 pixels in.
+		assert.Equal(t, []int{50, 50}, []int{c.Tokens[0].X, c.Tokens[0].Y})
+		assert.Equal(t, 1200, c.Tokens[0].Vision)
+		assert.Equal(t, &c.Characters[0].ID, c.Tokens[0].CharacterID)
+		assert.Equal(t, model.LayerGM, c.Tokens[1].Layer)
+		assert.Equal(t, 90, c.Tokens[1].Rotation)
+	}
+	if assert.Len(t, c.Walls, 4) {
+		kinds := []string{}
+		for _, w := range c.Walls {
+			kinds = append(kinds, w.Kind)
+		}
+		assert.Equal(t, []string{model.WallSolid, model.WallDoor, model.WallDoor, model.WallWindow}, kinds)
+		assert.True(t, c.Walls[1].Open)
+		assert.Equal(t, []int{0, 0, 300, 0}, []int{c.Walls[0].X1, c.Walls[0].Y1, c.Walls[0].X2, c.Walls[0].Y2})
+	}
+	if assert.Len(t, c.Lights, 1) {
+		assert.Equal(t, 400, c.Lights[0].Radius)
+		assert.Equal(t, "#ff8800", c.Lights[0].Color)
+	}
+
+	if assert.Len(t, c.JournalFolders, 3) {
+		assert.Equal(t, "Locations", c.JournalFolders[0].Name)
+		assert.Equal(t, &c.JournalFolders[0].ID, c.JournalFolders[1].ParentID)
+		assert.Equal(t, "Items", c.JournalFolders[2].Name)
+	}
+	if assert.Len(t, c.JournalEntries, 3) {
+		e := c.JournalEntries[0]
+		assert.Equal(t, "A frontier town.\n\n- Stonehill Inn\n- Shrine of Luck", e.Body)
+		assert.Equal(t, model.VisibilityPlayers, e.Visibility)
+		assert.Equal(t, &c.JournalFolders[1].ID, e.FolderID)
+		assert.Equal(t, &cave.ID, e.ImageID)
+		assert.Equal(t, model.VisibilityGM, c.JournalEntries[1].Visibility)
+		assert.Equal(t, &c.JournalFolders[2].ID, c.JournalEntries[2].FolderID)
+		assert.Equal(t, "You regain **2d4 + 2** hit points.", c.JournalEntries[2].Body)
+	}
+
+	rep := im.Report
+	assert.Equal(t, "foundry", rep.Source)
+	assert.Equal(t, 2, rep.Count("actor", vtt.StatusLossy))
+	assert.Equal(t, 1, rep.Count("scene", vtt.StatusLossy))
+	assert.Equal(t, 2, rep.Count("journal entry", vtt.StatusConverted))
+	assert.Equal(t, 1, rep.Count("item", vtt.StatusLossy))
+	assert.Equal(t, 1, rep.Count("macro", vtt.StatusSkipped))
+}
+
+func TestReadFoundry_Version10(t *testing.T) {
+	r := testZipFiles(t, map[string]string{
+		"world.json": `{"id": "v10", "title": "Abomination Vaults", "system": "pf2e"}`,
+		"data/scenes.db": `{"_id": "s1", "name": "Gauntlight", "background": {"src": "https://example.com/map.webp"}, "width": 1400, "height": 1000, "padding": 0,` +
+			` "grid": {"size": 140, "type": 4, "distance": 5}, "walls": [{"c": [0, 0, 140, 0], "sight": 0, "move": 20}], "lights": [{"x": 70, "y": 70, "config": {"dim": 10, "color": "#00ff00"}}]}`,
+		"data/actors.db":  `{"_id": "a1", "name": "Ezren", "type": "character", "system": {"abilities": {"int": {"mod": 4}}, "details": {"level": {"value": 1}}}, "items": [{"name": "Wizard", "type": "class", "system": {}}, {"name": "Reach Spell", "type": "feat", "system": {}}]}`,
+		"data/journal.db": `{"_id": "j1", "name": "Otari", "ownership": {"default": 3}, "pages": [{"name": "Overview", "type": "text", "text": {"content": "<p>A fishing town.</p>"}}, {"name": "Theme", "type": "video"}]}`,
+	})
+	im, err := vtt.ReadFoundry(r, r.Size(), &vtt.Options{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	c := im.Campaign
+	assert.Equal(t, sheet.SystemPF2e, c.Campaign.System)
+
+	if assert.Len(t, c.Scenes, 1) {
+		assert.Equal(t, model.GridHex, c.Scenes[0].GridType)
+		assert.Equal(t, "flat", c.Scenes[0].HexOrientation)
+		assert.Equal(t, "https://example.com/map.webp", c.Scenes[0].Background)
+	}
+	if assert.Len(t, c.Walls, 1) {
+		assert.Equal(t, model.WallWindow, c.Walls[0].Kind)
+	}
+	if assert.Len(t, c.Lights, 1) {
+		assert.Equal(t, 280, c.Lights[0].Radius)
+	}
+	if assert.Len(t, c.Characters, 1) {
+		data := map[string]interface{}{}
+		json.Unmarshal(c.Characters[0].Data, &data)
+		assert.Equal(t, map[string]interface{}{"int": 4.0}, data["abilities"])
+		assert.Equal(t, []interface{}{"Reach Spell"}, data["feats"])
+		assert.Equal(t, "Wizard", data["class"])
+	}
+	if assert.Len(t, c.JournalEntries, 1) {
+		assert.Equal(t, "## Overview\n\nA fishing town.", c.JournalEntries[0].Body)
+		assert.Equal(t, model.VisibilityPlayers, c.JournalEntries[0].Visibility)
+		assert.Equal(t, 1, im.Report.Count("journal entry", vtt.StatusLossy))
+	}
+}
+
+func TestReadFoundry_Errors(t *testing.T) {
+	testCases := []struct {
+		name string
+		body *bytes.Reader
+		err  error
+	}{
+		{"no world", testZip(t, "testdata/roll20", ""), vtt.ErrNoWorld},
+		{
+			name: "leveldb",
+			body: testZipFiles(t, map[string]string{
+				"world.json":          `{"id": "v11", "title": "Kingmaker", "system": "pf2e"}`,
+				"data/actors/CURRENT": "MANIFEST-000002",
+			}),
+			err: vtt.ErrUnsupportedWorld,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := vtt.ReadFoundry(tc.body, tc.body.Size(), &vtt.Options{})
+			assert.Equal(t, tc.err, err)
+		})
+	}
+}
+
+func TestReadFoundry_UnknownSystem(t *testing.T) {
+	r := testZipFiles(t, map[string]string{
+		"world.json":     `{"id": "sw", "title": "Edge of the Empire", "system": "swffg"}`,
+		"data/actors.db": `{"_id": "a1", "name": "Kira", "type": "character", "system": {"attributes": {"hp": {"value": 10, "max": 12}}}}`,
+	})
+	im, err := vtt.ReadFoundry(r, r.Size(), &vtt.Options{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, sheet.SystemGeneric, im.Campaign.Campaign.System)
+	assert.Equal(t, 1, im.Report.Count("world", vtt.StatusLossy))
+	if assert.Len(t, im.Campaign.Characters, 1) {
+		assert.JSONEq(t, `{}`, string(im.Campaign.Characters[0].Data))
+		assert.Equal(t, 1, im.Report.Count("actor", vtt.StatusLossy))
+	}
+}
+
+func testZipFiles(t *testing.T, files map[string]string) *bytes.Reader {
+	t.Helper()
+
+	b := &bytes.Buffer{}
+	zw := zip.NewWriter(b)
+	for name, body := range files {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return bytes.NewReader(b.Bytes())
+}
@@ -0,0 +1,162 @@
+package vtt
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	whitespace = regexp.MustCompile(`\s+`)
+	lineSpaces = regexp.MustCompile(` *\n *`)
+	blankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// toMarkdown converts the rich text other tabletops keep as HTML to
+// Markdown, returning the elements it couldn't convert and dropped.
+func toMarkdown(src string) (string, []string) {
+	nodes, err := html.ParseFragment(strings.NewReader(src), &html.Node{Type: html.ElementNode, DataAtom: atom.Body, Data: "body"})
+	if err != nil {
+		return "", []string{"text that isn't HTML"}
+	}
+
+	m := &markdownWriter{dropped: map[string]bool{}}
+	for _, n := range nodes {
+		m.node(n)
+	}
+
+	dropped := make([]string, 0, len(m.dropped))
+	for d := range m.dropped {
+		dropped = append(dropped, d)
+	}
+	sort.Strings(dropped)
+
+	return tidy(m.b.String()), dropped
+}
+
+func tidy(md string) string {
+	return strings.TrimSpace(blankLines.ReplaceAllString(lineSpaces.ReplaceAllString(md, "\n"), "\n\n"))
+}
+
+type markdownWriter struct {
+	b       strings.Builder
+	dropped map[string]bool
+	// list is the marker of the items of the list being written.
+	list string
+}
+
+func (m *markdownWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		m.node(c)
+	}
+}
+
+// wrap writes the children of n between the marks, with the marks kept off
+// the whitespace around them so the Markdown holds.
+func (m *markdownWriter) wrap(n *html.Node, open string, close string) {
+	inner := &markdownWriter{dropped: m.dropped, list: m.list}
+	inner.children(n)
+	text := inner.b.String()
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		m.b.WriteString(text)
+		return
+	}
+
+	lead := text[:strings.Index(text, trimmed)]
+	m.b.WriteString(lead + open + trimmed + close + text[len(lead)+len(trimmed):])
+}
+
+func (m *markdownWriter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		m.b.WriteString(whitespace.ReplaceAllString(n.Data, " "))
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.P, atom.Div, atom.Section, atom.Article:
+		m.b.WriteString("\n\n")
+		m.children(n)
+		m.b.WriteString("\n\n")
+	case atom.Br:
+		m.b.WriteString("\\\n")
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		m.b.WriteString("\n\n" + strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+		m.children(n)
+		m.b.WriteString("\n\n")
+	case atom.Strong, atom.B:
+		m.wrap(n, "**", "**")
+	case atom.Em, atom.I:
+		m.wrap(n, "*", "*")
+	case atom.S, atom.Del, atom.Strike:
+		m.wrap(n, "~~", "~~")
+	case atom.Code:
+		m.wrap(n, "`", "`")
+	case atom.A:
+		href := attr(n, "href")
+		if href == "" || strings.HasPrefix(href, "javascript:") {
+			m.children(n)
+			return
+		}
+		m.wrap(n, "[", "]("+href+")")
+	case atom.Ul, atom.Ol:
+		list := m.list
+		m.list = "- "
+		if n.DataAtom == atom.Ol {
+			m.list = "1. "
+		}
+		m.b.WriteString("\n\n")
+		m.children(n)
+		m.b.WriteString("\n\n")
+		m.list = list
+	case atom.Li:
+		inner := &markdownWriter{dropped: m.dropped, list: m.list}
+		inner.children(n)
+		m.b.WriteString(m.list + strings.ReplaceAll(tidy(inner.b.String()), "\n\n", "\n") + "\n")
+	case atom.Blockquote:
+		inner := &markdownWriter{dropped: m.dropped}
+		inner.children(n)
+		m.b.WriteString("\n\n> " + strings.ReplaceAll(tidy(inner.b.String()), "\n", "\n> ") + "\n\n")
+	case atom.Hr:
+		m.b.WriteString("\n\n---\n\n")
+	case atom.Span, atom.Font, atom.U, atom.Sup, atom.Sub, atom.Small, atom.Big, atom.Label, atom.Center:
+		m.children(n)
+	case atom.Script, atom.Style:
+	case atom.Img:
+		m.dropped["images"] = true
+	case atom.Table:
+		m.dropped["table layout"] = true
+		m.b.WriteString("\n\n")
+		m.children(n)
+		m.b.WriteString("\n\n")
+	case atom.Tr:
+		m.children(n)
+		m.b.WriteString("\\\n")
+	case atom.Td, atom.Th:
+		m.children(n)
+		m.b.WriteString(" ")
+	case atom.Thead, atom.Tbody, atom.Tfoot:
+		m.children(n)
+	default:
+		m.dropped[fmt.Sprintf("%s elements", n.Data)] = true
+		m.children(n)
+	}
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
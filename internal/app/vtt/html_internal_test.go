@@ -0,0 +1,64 @@
+package vtt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToMarkdown(t *testing.T) {
+	testCases := []struct {
+		name    string
+		src     string
+		md      string
+		dropped []string
+	}{
+		{
+			name: "paragraphs",
+			src:  "<p>A  frontier\n town.</p><p>Second</p>",
+			md:   "A frontier town.\n\nSecond",
+		},
+		{
+			name: "emphasis",
+			src:  "<p>A <strong>dying </strong>archmage, <em>cursed</em>.</p>",
+			md:   "A **dying** archmage, *cursed*.",
+		},
+		{
+			name: "headings and links",
+			src:  `<h2>Chult</h2><p>See <a href="https://example.com/chult">the map</a>.</p>`,
+			md:   "## Chult\n\nSee [the map](https://example.com/chult).",
+		},
+		{
+			name: "lists",
+			src:  "<ul><li>Stonehill Inn</li><li><p>Shrine</p></li></ul><ol><li>First</li></ol>",
+			md:   "- Stonehill Inn\n- Shrine\n\n1. First",
+		},
+		{
+			name: "blockquote",
+			src:  "<blockquote><p>Beware.</p><p>The end.</p></blockquote>",
+			md:   "> Beware.\n>\n> The end.",
+		},
+		{
+			name:    "dropped",
+			src:     `<p>Map<img src="map.png"></p><table><tr><td>Port</td><td>North</td></tr></table><marquee>Hi</marquee>`,
+			md:      "Map\n\nPort North \\\n\nHi",
+			dropped: []string{"images", "marquee elements", "table layout"},
+		},
+		{
+			name: "scripts",
+			src:  "<p>Safe</p><script>alert(1)</script>",
+			md:   "Safe",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			md, dropped := toMarkdown(tc.src)
+			assert.Equal(t, tc.md, md)
+			if tc.dropped == nil {
+				tc.dropped = []string{}
+			}
+			assert.Equal(t, tc.dropped, dropped)
+		})
+	}
+}
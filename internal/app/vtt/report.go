@@ -0,0 +1,73 @@
+package vtt
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	StatusConverted = "converted"
+	StatusLossy     = "lossy"
+	StatusSkipped   = "skipped"
+)
+
+// Report lists what was found in an export and what became of it.
+type Report struct {
+	Source  string         `json:"source"`
+	Entries []*ReportEntry `json:"entries"`
+}
+
+// ReportEntry is the fate of one record of the export. Notes explain what
+// was lost of lossy records and why skipped ones were.
+type ReportEntry struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	Status string   `json:"status"`
+	Notes  []string `json:"notes"`
+}
+
+func (r *Report) add(kind string, name string) *ReportEntry {
+	e := &ReportEntry{Kind: kind, Name: name, Status: StatusConverted, Notes: []string{}}
+	r.Entries = append(r.Entries, e)
+
+	return e
+}
+
+// Count returns how many records of the kind ended with the status.
+func (r *Report) Count(kind string, status string) int {
+	n := 0
+	for _, e := range r.Entries {
+		if e.Kind == kind && e.Status == status {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (e *ReportEntry) lose(format string, args ...interface{}) {
+	if e.Status == StatusConverted {
+		e.Status = StatusLossy
+	}
+	e.Notes = append(e.Notes, fmt.Sprintf(format, args...))
+}
+
+// drop notes how many of each kind of thing the record lost.
+func (e *ReportEntry) drop(counts map[string]int) {
+	kinds := make([]string, 0, len(counts))
+	for k, n := range counts {
+		if n > 0 {
+			kinds = append(kinds, k)
+		}
+	}
+	sort.Strings(kinds)
+
+	for _, k := range kinds {
+		e.lose("dropped %s (%d)", k, counts[k])
+	}
+}
+
+func (e *ReportEntry) skip(format string, args ...interface{}) {
+	e.Status = StatusSkipped
+	e.Notes = append(e.Notes, fmt.Sprintf(format, args...))
+}
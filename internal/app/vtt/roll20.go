@@ -0,0 +1,603 @@
+package vtt
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/grid"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/sheet"
+	"github.com/google/uuid"
+)
+
+// roll20Unit is the size of a Roll20 grid unit in pixels, which pages and
+// graphics are measured in.
+const roll20Unit = 70
+
+type roll20Campaign struct {
+	Title         string             `json:"campaign_title"`
+	Pages         []*roll20Page      `json:"pages"`
+	Characters    []*roll20Character `json:"characters"`
+	Handouts      []*roll20Handout   `json:"handouts"`
+	JournalFolder json.RawMessage    `json:"journalfolder"`
+	Decks         []*roll20Named     `json:"decks"`
+	Tables        []*roll20Named     `json:"tables"`
+	Macros        []*roll20Named     `json:"macros"`
+	Jukebox       []*roll20Named     `json:"jukebox"`
+}
+
+type roll20Named struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+}
+
+type roll20Page struct {
+	Name              string            `json:"name"`
+	Width             number            `json:"width"`
+	Height            number            `json:"height"`
+	ScaleNumber       number            `json:"scale_number"`
+	SnappingIncrement number            `json:"snapping_increment"`
+	GridType          string            `json:"grid_type"`
+	DiagonalType      string            `json:"diagonaltype"`
+	DynamicLighting   number            `json:"dynamic_lighting_enabled"`
+	ShowLighting      number            `json:"showlighting"`
+	Graphics          []*roll20Graphic  `json:"graphics"`
+	Paths             []*roll20Path     `json:"paths"`
+	Text              []json.RawMessage `json:"text"`
+}
+
+type roll20Graphic struct {
+	Name        string `json:"name"`
+	ImgSrc      string `json:"imgsrc"`
+	Left        number `json:"left"`
+	Top         number `json:"top"`
+	Width       number `json:"width"`
+	Height      number `json:"height"`
+	Rotation    number `json:"rotation"`
+	Layer       string `json:"layer"`
+	Represents  string `json:"represents"`
+	LightRadius number `json:"light_radius"`
+}
+
+type roll20Path struct {
+	Path     string `json:"path"`
+	Left     number `json:"left"`
+	Top      number `json:"top"`
+	Width    number `json:"width"`
+	Height   number `json:"height"`
+	ScaleX   number `json:"scaleX"`
+	ScaleY   number `json:"scaleY"`
+	Rotation number `json:"rotation"`
+	Layer    string `json:"layer"`
+}
+
+type roll20Character struct {
+	ID         string             `json:"id"`
+	Name       string             `json:"name"`
+	Avatar     string             `json:"avatar"`
+	Bio        string             `json:"bio"`
+	GMNotes    string             `json:"gmnotes"`
+	Attribs    []*roll20Attribute `json:"attribs"`
+	Attributes []*roll20Attribute `json:"attributes"`
+	Abilities  []json.RawMessage  `json:"abilities"`
+}
+
+type roll20Attribute struct {
+	Name    string      `json:"name"`
+	Current interface{} `json:"current"`
+	Max     interface{} `json:"max"`
+}
+
+type roll20Handout struct {
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	Notes            string `json:"notes"`
+	GMNotes          string `json:"gmnotes"`
+	Avatar           string `json:"avatar"`
+	InPlayerJournals string `json:"inplayerjournals"`
+}
+
+// ReadRoll20 converts a zipped Roll20 campaign export. Roll20 doesn't say
+// what system a campaign plays, so it is taken from the options.
+func ReadRoll20(r io.ReaderAt, size int64, opts *Options) (*Import, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	system := opts.System
+	if system == "" {
+		system = sheet.SystemGeneric
+	}
+	if !isSystem(system) {
+		return nil, sheet.ErrUnknownSystem
+	}
+
+	c := newConverter(zr, opts, "roll20")
+	root, f := c.find("campaign.json")
+	if f == nil {
+		return nil, ErrNoCampaign
+	}
+
+	src := &roll20Campaign{}
+	if err := readJSON(f, src); err != nil {
+		return nil, err
+	}
+
+	name := src.Title
+	if name == "" {
+		name = "Roll20 campaign"
+	}
+	ac := c.im.Campaign
+	ac.Campaign = &model.Campaign{Name: name, System: system}
+
+	characters := map[string]uuid.UUID{}
+	for _, rch := range src.Characters {
+		if ch := c.roll20Character(root, rch, system); ch != nil {
+			characters[rch.ID] = ch.ID
+			ac.Characters = append(ac.Characters, ch)
+		}
+	}
+
+	for _, p := range src.Pages {
+		c.roll20Page(root, p, characters)
+	}
+
+	folders := c.roll20Folders(src.JournalFolder)
+	for _, h := range src.Handouts {
+		c.roll20Handout(root, h, folders[h.ID])
+	}
+
+	for _, skipped := range []struct {
+		kind    string
+		records []*roll20Named
+	}{
+		{"deck", src.Decks},
+		{"rollable table", src.Tables},
+		{"macro", src.Macros},
+		{"track", src.Jukebox},
+	} {
+		for _, rec := range skipped.records {
+			name := rec.Name
+			if name == "" {
+				name = rec.Title
+			}
+			c.im.Report.add(skipped.kind, name).skip("%ss have no counterpart here", skipped.kind)
+		}
+	}
+
+	return c.im, nil
+}
+
+func (c *converter) roll20Character(root string, src *roll20Character, system string) *model.Character {
+	e := c.im.Report.add("character", src.Name)
+
+	d := &sheetData{Abilities: map[string]int{}}
+	abilities := map[string]string{
+		"strength":     "str",
+		"dexterity":    "dex",
+		"constitution": "con",
+		"intelligence": "int",
+		"wisdom":       "wis",
+		"charisma":     "cha",
+	}
+	suffix := ""
+	if system == sheet.SystemPF2e {
+		suffix = "_modifier"
+	}
+
+	items := map[string]*sheetItem{}
+	spells := map[string]*sheetSpell{}
+	order := []string{}
+	for _, a := range append(src.Attribs, src.Attributes...) {
+		cur := attrText(a.Current)
+		if strings.HasPrefix(a.Name, "repeating_") {
+			if !roll20Repeating(a.Name, cur, items, spells, &d.Feats, &order) {
+				d.Unknown++
+			}
+			continue
+		}
+
+		switch name := strings.TrimSuffix(a.Name, suffix); {
+		case abilities[name] != "" && a.Name == name+suffix:
+			d.Abilities[abilities[name]] = attrInt(cur)
+		case a.Name == "level" || a.Name == "base_level":
+			d.Level = attrInt(cur)
+		case a.Name == "class":
+			d.Class = cur
+		case a.Name == "subclass":
+			d.Subclass = cur
+		case a.Name == "race" || a.Name == "ancestry":
+			d.Race = cur
+		case a.Name == "heritage":
+			d.Heritage = cur
+		case a.Name == "background":
+			d.Background = cur
+		case a.Name == "alignment":
+			d.Alignment = cur
+		case a.Name == "hp" || a.Name == "hit_points":
+			if d.HP == nil {
+				d.HP = &sheetHP{}
+			}
+			d.HP.Current, d.HP.Max = attrInt(cur), attrInt(attrText(a.Max))
+		case a.Name == "hp_temp" || a.Name == "hit_points_temp":
+			if d.HP == nil {
+				d.HP = &sheetHP{}
+			}
+			d.HP.Temp = attrInt(cur)
+		case a.Name == "ac" || a.Name == "armor_class":
+			d.AC = attrInt(cur)
+		case a.Name == "speed":
+			d.Speed = attrInt(cur)
+		default:
+			d.Unknown++
+		}
+	}
+
+	for _, id := range order {
+		if it, ok := items[id]; ok && it.Name != "" {
+			d.Inventory = append(d.Inventory, *it)
+		}
+		if sp, ok := spells[id]; ok && sp.Name != "" {
+			d.Spells = append(d.Spells, *sp)
+		}
+	}
+
+	if src.Bio != "" {
+		d.Notes = c.markdown(src.Bio, e)
+	}
+	if src.GMNotes != "" {
+		e.lose("dropped the GM notes")
+	}
+	if len(src.Abilities) > 0 {
+		e.drop(map[string]int{"abilities": len(src.Abilities)})
+	}
+
+	ch := &model.Character{
+		ID:       uuid.New(),
+		Name:     src.Name,
+		Portrait: c.image(root, src.Avatar, e),
+		System:   system,
+		Data:     d.encode(system, e),
+	}
+	if !valid(ch, e) {
+		return nil
+	}
+
+	return ch
+}
+
+// roll20Repeating files an attribute of a repeating section of the 5e
+// sheet, repeating_<section>_<row>_<field>, under the row it's of.
+func roll20Repeating(name string, value string, items map[string]*sheetItem, spells map[string]*sheetSpell, feats *[]string, order *[]string) bool {
+	parts := strings.SplitN(strings.TrimPrefix(name, "repeating_"), "_", 3)
+	if len(parts) != 3 {
+		return false
+	}
+	section, row, field := parts[0], parts[1], parts[2]
+
+	switch {
+	case section == "inventory":
+		it, ok := items[row]
+		if !ok {
+			it = &sheetItem{Quantity: 1}
+			items[row] = it
+			*order = append(*order, row)
+		}
+		switch field {
+		case "itemname":
+			it.Name = value
+		case "itemcount":
+			it.Quantity = clamp(attrInt(value), 0, math.MaxInt32)
+		default:
+			return false
+		}
+	case strings.HasPrefix(section, "spell-"):
+		sp, ok := spells[row]
+		if !ok {
+			level, _ := strconv.Atoi(strings.TrimPrefix(section, "spell-"))
+			sp = &sheetSpell{Level: clamp(level, 0, 9)}
+			spells[row] = sp
+			*order = append(*order, row)
+		}
+		switch field {
+		case "spellname":
+			sp.Name = value
+		case "spellprepared":
+			sp.Prepared = value == "1" || value == "on"
+		default:
+			return false
+		}
+	case (section == "traits" || strings.HasPrefix(section, "feat")) && field == "name":
+		*feats = append(*feats, value)
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (c *converter) roll20Page(root string, src *roll20Page, characters map[string]uuid.UUID) {
+	e := c.im.Report.add("page", src.Name)
+
+	sc := newScene(src.Name)
+	if sc.Name == "" {
+		sc.Name = "Untitled page"
+	}
+	snap := float64(src.SnappingIncrement)
+	if snap <= 0 {
+		snap = 1
+	}
+	sc.GridSize = clamp(int(math.Round(roll20Unit*snap)), 10, 500)
+	sc.Width = clamp(int(math.Round(float64(src.Width)*roll20Unit)), 1, 20000)
+	sc.Height = clamp(int(math.Round(float64(src.Height)*roll20Unit)), 1, 20000)
+	if src.ScaleNumber > 0 {
+		sc.GridDistance = clamp(int(math.Round(float64(src.ScaleNumber)*snap)), 1, 1000)
+	}
+	sc.FogOfWar = src.DynamicLighting != 0 || src.ShowLighting != 0
+
+	switch src.GridType {
+	case "hex":
+		sc.GridType, sc.HexOrientation = model.GridHex, grid.Flat
+	case "hexr":
+		sc.GridType, sc.HexOrientation = model.GridHex, grid.Pointy
+	}
+	switch src.DiagonalType {
+	case "", "foure":
+	case "threefive":
+		sc.Diagonals = grid.DiagonalsAlternating
+	case "pythagorean":
+		sc.Diagonals = grid.DiagonalsEuclidean
+	default:
+		e.lose("%s diagonals are measured 5-5-5", src.DiagonalType)
+	}
+
+	// The largest graphic on the map layer is taken for the map itself.
+	var bg *roll20Graphic
+	for _, g := range src.Graphics {
+		if g.Layer == "map" && (bg == nil || g.Width*g.Height > bg.Width*bg.Height) {
+			bg = g
+		}
+	}
+	if bg != nil {
+		sc.Background = c.image(root, bg.ImgSrc, e)
+	}
+	if !valid(sc, e) {
+		return
+	}
+	c.im.Campaign.Scenes = append(c.im.Campaign.Scenes, sc)
+
+	dropped := map[string]int{}
+	curves := false
+	for _, g := range src.Graphics {
+		if g == bg {
+			continue
+		}
+
+		var layer string
+		switch g.Layer {
+		case "objects":
+			layer = model.LayerTokens
+		case "gmlayer":
+			layer = model.LayerGM
+		case "map":
+			dropped["graphics on the map layer besides the map"]++
+			continue
+		default:
+			dropped["graphics on the "+g.Layer+" layer"]++
+			continue
+		}
+
+		t := &model.Token{
+			ID:       uuid.New(),
+			SceneID:  sc.ID,
+			Name:     g.Name,
+			Image:    c.image(root, g.ImgSrc, e),
+			X:        g.Left.int(),
+			Y:        g.Top.int(),
+			Size:     float64(g.Width) / float64(sc.GridSize),
+			Rotation: (g.Rotation.int()%360 + 360) % 360,
+			Layer:    layer,
+		}
+		if id, ok := characters[g.Represents]; ok {
+			t.CharacterID = &id
+		}
+		if err := t.Validate(); err != nil {
+			e.lose("dropped token %q: %s", g.Name, err)
+			continue
+		}
+		c.im.Campaign.Tokens = append(c.im.Campaign.Tokens, t)
+
+		if g.LightRadius > 0 && src.ScaleNumber > 0 {
+			l := &model.Light{
+				ID:      uuid.New(),
+				SceneID: sc.ID,
+				X:       t.X,
+				Y:       t.Y,
+				Radius:  clamp(int(math.Round(float64(g.LightRadius/src.ScaleNumber)*roll20Unit)), 1, 20000),
+			}
+			c.im.Campaign.Lights = append(c.im.Campaign.Lights, l)
+			e.lose("the light of token %q stays where the token was", g.Name)
+		}
+	}
+
+	for _, p := range src.Paths {
+		if p.Layer != "walls" {
+			dropped["drawings"]++
+			continue
+		}
+
+		walls, curved := roll20Walls(sc, p)
+		if curved {
+			curves = true
+		}
+		c.im.Campaign.Walls = append(c.im.Campaign.Walls, walls...)
+	}
+	dropped["text objects"] += len(src.Text)
+
+	e.drop(dropped)
+	if curves {
+		e.lose("curved walls were straightened")
+	}
+}
+
+// roll20Walls returns the walls along a path of the walls layer. Points of
+// a path are relative to the top left of its bounds before it's scaled and
+// rotated about their centre.
+func roll20Walls(sc *model.Scene, p *roll20Path) ([]*model.Wall, bool) {
+	var segments [][]interface{}
+	if err := json.Unmarshal([]byte(p.Path), &segments); err != nil {
+		return nil, false
+	}
+
+	sx, sy := float64(p.ScaleX), float64(p.ScaleY)
+	if sx == 0 {
+		sx = 1
+	}
+	if sy == 0 {
+		sy = 1
+	}
+	sin, cos := math.Sincos(float64(p.Rotation) * math.Pi / 180)
+	point := func(x, y float64) (int, int) {
+		dx, dy := (x-float64(p.Width)/2)*sx, (y-float64(p.Height)/2)*sy
+		return int(math.Round(float64(p.Left) + dx*cos - dy*sin)), int(math.Round(float64(p.Top) + dx*sin + dy*cos))
+	}
+
+	walls := []*model.Wall{}
+	curved := false
+	var last *[2]int
+	for _, seg := range segments {
+		if len(seg) < 3 {
+			continue
+		}
+		op, _ := seg[0].(string)
+		x, _ := seg[len(seg)-2].(float64)
+		y, _ := seg[len(seg)-1].(float64)
+		px, py := point(x, y)
+
+		switch op {
+		case "Q", "C":
+			curved = true
+			fallthrough
+		case "L":
+			if last != nil && (last[0] != px || last[1] != py) {
+				walls = append(walls, &model.Wall{ID: uuid.New(), SceneID: sc.ID, X1: last[0], Y1: last[1], X2: px, Y2: py, Kind: model.WallSolid})
+			}
+		}
+		last = &[2]int{px, py}
+	}
+
+	return walls, curved
+}
+
+// roll20Folders creates the journal folders of the journal tree and
+// returns the folder each handout is in. Roll20 writes the tree as JSON,
+// sometimes inside a string.
+func (c *converter) roll20Folders(raw json.RawMessage) map[string]*uuid.UUID {
+	in := map[string]*uuid.UUID{}
+
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		raw = json.RawMessage(s)
+	}
+	var tree []json.RawMessage
+	if json.Unmarshal(raw, &tree) != nil {
+		return in
+	}
+
+	var walk func(items []json.RawMessage, parent *uuid.UUID)
+	walk = func(items []json.RawMessage, parent *uuid.UUID) {
+		for _, item := range items {
+			var id string
+			if json.Unmarshal(item, &id) == nil {
+				in[id] = parent
+				continue
+			}
+
+			folder := &struct {
+				Name  string            `json:"n"`
+				Items []json.RawMessage `json:"i"`
+			}{}
+			if json.Unmarshal(item, folder) != nil {
+				continue
+			}
+
+			f := &model.JournalFolder{ID: uuid.New(), ParentID: parent, Name: folder.Name}
+			if f.Name == "" {
+				f.Name = "Untitled folder"
+			}
+			c.im.Campaign.JournalFolders = append(c.im.Campaign.JournalFolders, f)
+			walk(folder.Items, &f.ID)
+		}
+	}
+	walk(tree, nil)
+
+	return in
+}
+
+func (c *converter) roll20Handout(root string, src *roll20Handout, folderID *uuid.UUID) {
+	e := c.im.Report.add("handout", src.Name)
+
+	body := c.markdown(src.Notes, e)
+	if src.Avatar != "" {
+		if img := c.image(root, src.Avatar, e); img != "" {
+			body = strings.TrimSpace("![" + src.Name + "](" + img + ")\n\n" + body)
+		}
+	}
+
+	visibility := model.VisibilityGM
+	if src.InPlayerJournals != "" {
+		visibility = model.VisibilityPlayers
+		if src.InPlayerJournals != "all" {
+			e.lose("shared with all players rather than some")
+		}
+	}
+
+	entry := &model.JournalEntry{
+		FolderID:   folderID,
+		Title:      src.Name,
+		Body:       body,
+		Visibility: visibility,
+	}
+	if !valid(entry, e) {
+		return
+	}
+	c.im.Campaign.JournalEntries = append(c.im.Campaign.JournalEntries, entry)
+
+	// Players never see the GM notes, so they get an entry of their own.
+	if src.GMNotes != "" {
+		notes := &model.JournalEntry{
+			FolderID:   folderID,
+			Title:      strings.TrimSpace(src.Name + " (GM notes)"),
+			Body:       c.markdown(src.GMNotes, e),
+			Visibility: model.VisibilityGM,
+		}
+		if err := notes.Validate(); err != nil {
+			e.lose("dropped the GM notes: %s", err)
+			return
+		}
+		c.im.Campaign.JournalEntries = append(c.im.Campaign.JournalEntries, notes)
+	}
+}
+
+func attrText(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	return ""
+}
+
+func attrInt(s string) int {
+	f, _ := strconv.ParseFloat(strings.TrimSpace(leadingNumber.FindString(s)), 64)
+	return int(math.Round(f))
+}
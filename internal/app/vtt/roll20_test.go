@@ -0,0 +1,113 @@
+package vtt_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/sheet"
+	"github.com/bruhlord-s/virttable-api/internal/app/vtt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadRoll20(t *testing.T) {
+	r := testZip(t, "testdata/roll20", "tomb-of-annihilation/")
+	im, err := vtt.ReadRoll20(r, r.Size(), &vtt.Options{System: sheet.SystemDnD5e})
+	if !assert.NoError(t, err) {
+		return
+	}
+	c := im.Campaign
+	assert.Equal(t, "Tomb of Annihilation", c.Campaign.Name)
+	assert.Equal(t, sheet.SystemDnD5e, c.Campaign.System)
+
+	if assert.Len(t, c.Characters, 1) {
+		ch := c.Characters[0]
+		assert.Equal(t, "Syndra Silvane", ch.Name)
+		data := map[string]interface{}{}
+		json.Unmarshal(ch.Data, &data)
+		assert.Equal(t, map[string]interface{}{"str": 10.0, "dex": 12.0, "int": 20.0}, data["abilities"])
+		assert.Equal(t, 13.0, data["level"])
+		assert.Equal(t, "Wizard", data["class"])
+		assert.Equal(t, map[string]interface{}{"current": 48.0, "max": 66.0}, data["hp"])
+		assert.Equal(t, 30.0, data["speed"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"name": "Staff", "quantity": 1.0}}, data["inventory"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"name": "Fireball", "level": 3.0, "prepared": true}}, data["spells"])
+		assert.Equal(t, "A **dying** archmage.", data["notes"])
+	}
+
+	if !assert.Len(t, c.Scenes, 1) {
+		return
+	}
+	sc := c.Scenes[0]
+	assert.Equal(t, "Port Nyanzaru", sc.Name)
+	assert.Equal(t, "https://s3.amazonaws.com/files.d20.io/images/1/port.jpg", sc.Background)
+	assert.Equal(t, 1400, sc.Width)
+	assert.Equal(t, 1050, sc.Height)
+	assert.Equal(t, 70, sc.GridSize)
+	assert.Equal(t, 5, sc.GridDistance)
+	assert.Equal(t, "5-10-5", sc.Diagonals)
+	assert.True(t, sc.FogOfWar)
+
+	if assert.Len(t, c.Tokens, 2) {
+		assert.Equal(t, 315, c.Tokens[0].X)
+		assert.Equal(t, 270, c.Tokens[0].Rotation)
+		assert.Equal(t, model.LayerTokens, c.Tokens[0].Layer)
+		assert.Equal(t, &c.Characters[0].ID, c.Tokens[0].CharacterID)
+		assert.Equal(t, 2.0, c.Tokens[1].Size)
+		assert.Equal(t, model.LayerGM, c.Tokens[1].Layer)
+	}
+	if assert.Len(t, c.Lights, 1) {
+		assert.Equal(t, 280, c.Lights[0].Radius)
+	}
+	if assert.Len(t, c.Walls, 3) {
+		w := c.Walls[0]
+		assert.Equal(t, []int{700, 70, 840, 70}, []int{w.X1, w.Y1, w.X2, w.Y2})
+		w = c.Walls[1]
+		assert.Equal(t, []int{840, 70, 840, 140}, []int{w.X1, w.Y1, w.X2, w.Y2})
+	}
+
+	if assert.Len(t, c.JournalFolders, 2) {
+		assert.Nil(t, c.JournalFolders[0].ParentID)
+		assert.Equal(t, &c.JournalFolders[0].ID, c.JournalFolders[1].ParentID)
+	}
+	if assert.Len(t, c.JournalEntries, 3) {
+		map1 := c.JournalEntries[0]
+		assert.Equal(t, model.VisibilityPlayers, map1.Visibility)
+		assert.Equal(t, &c.JournalFolders[0].ID, map1.FolderID)
+		assert.Contains(t, map1.Body, "![Map of Chult](https://s3.amazonaws.com/files.d20.io/images/5/chult.jpg)")
+		assert.Contains(t, map1.Body, "## Chult")
+		assert.Equal(t, model.VisibilityGM, c.JournalEntries[1].Visibility)
+		assert.Equal(t, "Map of Chult (GM notes)", c.JournalEntries[1].Title)
+		assert.Equal(t, &c.JournalFolders[1].ID, c.JournalEntries[2].FolderID)
+	}
+
+	rep := im.Report
+	assert.Equal(t, "roll20", rep.Source)
+	assert.Equal(t, 1, rep.Count("character", vtt.StatusLossy))
+	assert.Equal(t, 1, rep.Count("page", vtt.StatusLossy))
+	assert.Equal(t, 1, rep.Count("handout", vtt.StatusLossy))
+	assert.Equal(t, 1, rep.Count("handout", vtt.StatusConverted))
+	for _, kind := range []string{"deck", "rollable table", "macro", "track"} {
+		assert.Equal(t, 1, rep.Count(kind, vtt.StatusSkipped), kind)
+	}
+}
+
+func TestReadRoll20_Errors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		body   *bytes.Reader
+		system string
+		err    error
+	}{
+		{"no campaign", testZip(t, "testdata/foundry", ""), sheet.SystemDnD5e, vtt.ErrNoCampaign},
+		{"unknown system", testZip(t, "testdata/roll20", ""), "gurps", sheet.ErrUnknownSystem},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := vtt.ReadRoll20(tc.body, tc.body.Size(), &vtt.Options{System: tc.system})
+			assert.Equal(t, tc.err, err)
+		})
+	}
+}
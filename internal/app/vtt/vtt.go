@@ -0,0 +1,436 @@
+// Package vtt converts exports of other virtual tabletops into campaign
+// archives, reporting what was converted, skipped or lost on the way.
+//
+// Roll20 campaigns are read from the campaign.json of a campaign export,
+// Foundry VTT worlds from their world folder, both uploaded zipped. Images
+// in the upload become assets of the campaign; images elsewhere are linked
+// to where they are if they have a URL, and dropped otherwise.
+package vtt
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"image"
+	"io"
+	"math"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/archive"
+	"github.com/bruhlord-s/virttable-api/internal/app/grid"
+	"github.com/bruhlord-s/virttable-api/internal/app/model"
+	"github.com/bruhlord-s/virttable-api/internal/app/sheet"
+	"github.com/bruhlord-s/virttable-api/internal/app/thumbnail"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoCampaign       = errors.New("export has no campaign.json")
+	ErrNoWorld          = errors.New("export has no world.json")
+	ErrUnsupportedWorld = errors.New("world keeps its data in LevelDB, export it from Foundry VTT 10 or earlier")
+)
+
+var leadingNumber = regexp.MustCompile(`^\s*-?[0-9]*\.?[0-9]+`)
+
+type Options struct {
+	// System is the game system of campaigns whose export doesn't say.
+	System string
+	// BaseURL is the scheme and host the server is reached at, which
+	// asset URLs start with.
+	BaseURL       string
+	MaxAssetSize  int64
+	ThumbnailSize int
+}
+
+// Import is an export converted into an archive. The content of its assets
+// is read with Open, like that of an archive.Reader.
+type Import struct {
+	Campaign *archive.Campaign
+	Report   *Report
+	content  map[string]*zip.File
+	thumbs   map[string][]byte
+}
+
+func (im *Import) Open(hash string) (io.ReadCloser, error) {
+	if b, ok := im.thumbs[hash]; ok {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+
+	f, ok := im.content[hash]
+	if !ok {
+		return nil, archive.ErrMissingContent
+	}
+
+	return f.Open()
+}
+
+// ContentSize is how much the content of the assets takes decompressed,
+// thumbnails included.
+func (im *Import) ContentSize() int64 {
+	var size int64
+	for _, f := range im.content {
+		size += int64(f.UncompressedSize64)
+	}
+	for _, b := range im.thumbs {
+		size += int64(len(b))
+	}
+
+	return size
+}
+
+// converter holds what both importers share: the upload, the import being
+// built and the assets filed so far.
+type converter struct {
+	opts   *Options
+	files  map[string]*zip.File
+	im     *Import
+	assets map[string]*archive.Asset
+}
+
+func newConverter(zr *zip.Reader, opts *Options, source string) *converter {
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		if !f.FileInfo().IsDir() {
+			files[f.Name] = f
+		}
+	}
+
+	return &converter{
+		opts:  opts,
+		files: files,
+		im: &Import{
+			Campaign: &archive.Campaign{},
+			Report:   &Report{Source: source, Entries: []*ReportEntry{}},
+			content:  map[string]*zip.File{},
+			thumbs:   map[string][]byte{},
+		},
+		assets: map[string]*archive.Asset{},
+	}
+}
+
+// find looks a file up by name, at the top of the upload or in the folder
+// closest to it, as uploads are zipped with or without the folder they
+// were in. It returns the folder along with the file.
+func (c *converter) find(name string) (string, *zip.File) {
+	found := ""
+	for p := range c.files {
+		if (p == name || strings.HasSuffix(p, "/"+name)) && (found == "" || len(p) < len(found)) {
+			found = p
+		}
+	}
+	if found == "" {
+		return "", nil
+	}
+
+	return strings.TrimSuffix(found, name), c.files[found]
+}
+
+// image returns the URL of the image at ref: an asset if the upload has the
+// file at the path, relative to root, or ref itself if it's a URL.
+func (c *converter) image(root string, ref string, e *ReportEntry) string {
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") {
+		return ref
+	}
+
+	a := c.file(root, ref, e)
+	if a == nil {
+		return ""
+	}
+
+	return strings.TrimSuffix(c.opts.BaseURL, "/") + "/assets/" + a.ID.String()
+}
+
+// file returns the asset of the file at ref, relative to root, filing it
+// the first time it's referred to.
+func (c *converter) file(root string, ref string, e *ReportEntry) *archive.Asset {
+	if ref == "" {
+		return nil
+	}
+
+	name := path.Clean(root + strings.TrimPrefix(ref, "/"))
+	if a, ok := c.assets[name]; ok {
+		return a
+	}
+
+	f, ok := c.files[name]
+	if !ok {
+		e.lose("image %s is not in the upload", ref)
+		return nil
+	}
+
+	a, err := c.asset(f)
+	if err != nil {
+		e.lose("image %s: %s", ref, err)
+		return nil
+	}
+	c.assets[name] = a
+
+	return a
+}
+
+func (c *converter) asset(f *zip.File) (*archive.Asset, error) {
+	if c.opts.MaxAssetSize > 0 && int64(f.UncompressedSize64) > c.opts.MaxAssetSize {
+		return nil, errors.New("file is too large")
+	}
+
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(b)
+	a := &archive.Asset{Asset: &model.Asset{
+		ID:   uuid.New(),
+		Name: path.Base(f.Name),
+		Hash: hex.EncodeToString(sum[:]),
+		MIME: http.DetectContentType(b),
+		Size: int64(len(b)),
+		Tags: []string{},
+	}}
+	if !model.AllowedMIME(a.MIME) {
+		return nil, errors.New("unsupported file type")
+	}
+
+	if a.IsImage() && c.opts.ThumbnailSize > 0 {
+		thumb, cfg, err := thumbnail.Make(bytes.NewReader(b), c.opts.ThumbnailSize)
+		if err != nil && !errors.Is(err, image.ErrFormat) {
+			return nil, err
+		}
+
+		if err == nil {
+			a.Width, a.Height = cfg.Width, cfg.Height
+			sum := sha256.Sum256(thumb)
+			a.Thumbnail = hex.EncodeToString(sum[:])
+			c.im.thumbs[a.Thumbnail] = thumb
+		}
+	}
+
+	c.im.content[a.Hash] = f
+	c.im.Campaign.Assets = append(c.im.Campaign.Assets, a)
+
+	return a, nil
+}
+
+// newScene returns a scene with the settings exports don't have.
+func newScene(name string) *model.Scene {
+	return &model.Scene{
+		ID:             uuid.New(),
+		Name:           name,
+		GridType:       model.GridSquare,
+		GridSize:       70,
+		HexOrientation: grid.Pointy,
+		Diagonals:      grid.DiagonalsFive,
+		GridDistance:   5,
+	}
+}
+
+// markdown converts rich text, noting what of it was dropped.
+func (c *converter) markdown(src string, e *ReportEntry) string {
+	md, dropped := toMarkdown(src)
+	for _, d := range dropped {
+		e.lose("dropped %s from the text", d)
+	}
+
+	return md
+}
+
+func isSystem(system string) bool {
+	for _, s := range sheet.Systems() {
+		if s == system {
+			return true
+		}
+	}
+
+	return false
+}
+
+// valid reports whether the record converted into a valid one, skipping the
+// entry if it didn't.
+func valid(v interface{ Validate() error }, e *ReportEntry) bool {
+	if err := v.Validate(); err != nil {
+		e.skip("%s", err)
+		return false
+	}
+
+	return true
+}
+
+func clamp(v int, lo int, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+
+	return v
+}
+
+// sheetData is what importers find on a character sheet, written out in
+// the campaign's system as far as it goes.
+type sheetData struct {
+	Class      string
+	Subclass   string
+	Level      int
+	Race       string
+	Heritage   string
+	Background string
+	Alignment  string
+	// Abilities are scores, or modifiers in systems that have no scores.
+	Abilities map[string]int
+	HP        *sheetHP
+	AC        int
+	Speed     int
+	Inventory []sheetItem
+	Spells    []sheetSpell
+	Feats     []string
+	Notes     string
+	// Unknown counts what was on the sheet but has no place on any.
+	Unknown int
+}
+
+type sheetHP struct {
+	Current int `json:"current"`
+	Max     int `json:"max"`
+	Temp    int `json:"temp,omitempty"`
+}
+
+type sheetItem struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+}
+
+type sheetSpell struct {
+	Name     string `json:"name"`
+	Level    int    `json:"level"`
+	Prepared bool   `json:"prepared,omitempty"`
+}
+
+func (d *sheetData) encode(system string, e *ReportEntry) json.RawMessage {
+	out := map[string]interface{}{}
+	set := func(key string, v string) {
+		if v != "" {
+			out[key] = v
+		}
+	}
+	lost := []string{}
+
+	switch system {
+	case sheet.SystemDnD5e, sheet.SystemPF2e:
+		lo, hi := 1, 30
+		if system == sheet.SystemPF2e {
+			lo, hi = -5, 10
+		}
+		abilities := map[string]int{}
+		for k, v := range d.Abilities {
+			if v >= lo && v <= hi {
+				abilities[k] = v
+			}
+		}
+		if len(abilities) > 0 {
+			out["abilities"] = abilities
+		}
+		if d.Level >= 1 && d.Level <= 20 {
+			out["level"] = d.Level
+		}
+		if d.HP != nil && d.HP.Max >= 0 && d.HP.Temp >= 0 {
+			out["hp"] = d.HP
+		}
+		if d.AC > 0 {
+			out["ac"] = d.AC
+		}
+		if len(d.Inventory) > 0 {
+			out["inventory"] = d.Inventory
+		}
+		set("class", d.Class)
+		set("background", d.Background)
+		set("notes", d.Notes)
+
+		if system == sheet.SystemDnD5e {
+			set("subclass", d.Subclass)
+			set("race", d.Race)
+			set("alignment", d.Alignment)
+			if d.Speed > 0 {
+				out["speed"] = d.Speed
+			}
+			if len(d.Spells) > 0 {
+				out["spells"] = d.Spells
+			}
+			if len(d.Feats) > 0 {
+				lost = append(lost, "features")
+			}
+		} else {
+			set("ancestry", d.Race)
+			set("heritage", d.Heritage)
+			if len(d.Feats) > 0 {
+				out["feats"] = d.Feats
+			}
+			if len(d.Spells) > 0 {
+				lost = append(lost, "spells")
+			}
+		}
+	default:
+		if d.Level > 0 || len(d.Abilities) > 0 || d.HP != nil || len(d.Inventory) > 0 || len(d.Spells) > 0 || d.Notes != "" {
+			lost = append(lost, "the sheet, which "+system+" characters don't have")
+		}
+	}
+
+	for _, l := range lost {
+		e.lose("dropped %s", l)
+	}
+	if d.Unknown > 0 {
+		e.drop(map[string]int{"sheet fields with no place on the sheet": d.Unknown})
+	}
+
+	b, _ := json.Marshal(out)
+	if err := sheet.Validate(system, b); err != nil {
+		e.lose("dropped the sheet: %s", err)
+		return json.RawMessage(`{}`)
+	}
+
+	return b
+}
+
+// number is a number exports may write as a string as well, or as an
+// object holding it as its value.
+type number float64
+
+func (n *number) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if m, ok := v.(map[string]interface{}); ok {
+		v = m["value"]
+	}
+
+	switch v := v.(type) {
+	case float64:
+		*n = number(v)
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(leadingNumber.FindString(v)), 64)
+		*n = number(f)
+	case bool:
+		if v {
+			*n = 1
+		}
+	}
+
+	return nil
+}
+
+func (n number) int() int {
+	return int(math.Round(float64(n)))
+}
@@ -0,0 +1,68 @@
+package vtt_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/bruhlord-s/virttable-api/internal/app/archive"
+	"github.com/bruhlord-s/virttable-api/internal/app/vtt"
+	"github.com/stretchr/testify/assert"
+)
+
+// testZip zips the files of the testdata folder under prefix, as uploads
+// come zipped with or without their folder.
+func testZip(t *testing.T, dir string, prefix string) *bytes.Reader {
+	t.Helper()
+
+	b := &bytes.Buffer{}
+	zw := zip.NewWriter(b)
+	if err := fs.WalkDir(os.DirFS(dir), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		content, err := os.ReadFile(dir + "/" + name)
+		if err != nil {
+			return err
+		}
+		f, err := zw.Create(prefix + name)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(content)
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return bytes.NewReader(b.Bytes())
+}
+
+func TestImport_Open(t *testing.T) {
+	r := testZip(t, "testdata/foundry/lost-mine", "")
+	im, err := vtt.ReadFoundry(r, r.Size(), &vtt.Options{BaseURL: "https://vt.example.com", ThumbnailSize: 8})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	hashes := im.Campaign.Hashes()
+	assert.Len(t, hashes, 4)
+	for _, hash := range hashes {
+		f, err := im.Open(hash)
+		if assert.NoError(t, err) {
+			b, _ := io.ReadAll(f)
+			f.Close()
+			assert.NotEmpty(t, b)
+		}
+	}
+
+	_, err = im.Open("missing")
+	assert.Equal(t, archive.ErrMissingContent, err)
+}
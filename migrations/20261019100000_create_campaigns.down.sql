@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS campaign_members;
+DROP TABLE IF EXISTS campaigns;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_pkey;
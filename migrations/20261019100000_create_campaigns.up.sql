@@ -0,0 +1,16 @@
+ALTER TABLE users ADD PRIMARY KEY (id);
+
+CREATE TABLE IF NOT EXISTS campaigns (
+    id uuid primary key default uuid_generate_v4 (),
+    name varchar not null,
+    description text not null default '',
+    owner_id uuid not null references users (id) on delete cascade,
+    created_at timestamptz not null default now()
+);
+
+CREATE TABLE IF NOT EXISTS campaign_members (
+    campaign_id uuid not null references campaigns (id) on delete cascade,
+    user_id uuid not null references users (id) on delete cascade,
+    role varchar not null,
+    primary key (campaign_id, user_id)
+);
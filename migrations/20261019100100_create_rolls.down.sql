@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS roll_dice;
+DROP TABLE IF EXISTS rolls;
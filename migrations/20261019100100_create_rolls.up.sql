@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS rolls (
+    id uuid primary key default uuid_generate_v4 (),
+    campaign_id uuid not null references campaigns (id) on delete cascade,
+    user_id uuid not null references users (id) on delete cascade,
+    character_id uuid,
+    expression varchar not null,
+    modifier integer not null default 0,
+    total integer not null,
+    created_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS rolls_campaign_id_created_at_idx ON rolls (campaign_id, created_at);
+
+CREATE TABLE IF NOT EXISTS roll_dice (
+    roll_id uuid not null references rolls (id) on delete cascade,
+    position integer not null,
+    sides integer not null,
+    value integer not null,
+    dropped boolean not null default false,
+    primary key (roll_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS roll_dice_sides_idx ON roll_dice (sides);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS realtime_events (
+    id varchar primary key,
+    event jsonb not null,
+    created_at timestamptz not null default now()
+);
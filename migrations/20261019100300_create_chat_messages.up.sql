@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS chat_messages (
+    id uuid primary key default uuid_generate_v4 (),
+    campaign_id uuid not null references campaigns (id) on delete cascade,
+    user_id uuid not null references users (id) on delete cascade,
+    kind varchar not null,
+    character_id uuid,
+    speaker varchar not null default '',
+    recipients uuid[] not null default '{}',
+    body text not null,
+    created_at timestamptz not null default now(),
+    edited_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS chat_messages_campaign_id_created_at_idx ON chat_messages (campaign_id, created_at DESC, id DESC);
@@ -0,0 +1,3 @@
+ALTER TABLE chat_messages DROP COLUMN IF EXISTS body_html;
+
+ALTER TABLE campaigns DROP COLUMN IF EXISTS description_html;
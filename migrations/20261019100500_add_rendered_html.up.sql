@@ -0,0 +1,3 @@
+ALTER TABLE campaigns ADD COLUMN IF NOT EXISTS description_html text not null default '';
+
+ALTER TABLE chat_messages ADD COLUMN IF NOT EXISTS body_html text not null default '';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS characters;
+
+ALTER TABLE campaigns DROP COLUMN IF EXISTS system;
@@ -0,0 +1,15 @@
+ALTER TABLE campaigns ADD COLUMN IF NOT EXISTS system varchar not null default 'generic';
+
+CREATE TABLE IF NOT EXISTS characters (
+    id uuid primary key default uuid_generate_v4 (),
+    campaign_id uuid not null references campaigns (id) on delete cascade,
+    owner_id uuid not null references users (id) on delete cascade,
+    name varchar not null,
+    portrait varchar not null default '',
+    system varchar not null,
+    data jsonb not null default '{}',
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS characters_campaign_id_idx ON characters (campaign_id);
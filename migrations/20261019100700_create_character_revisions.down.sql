@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS character_revisions;
+
+ALTER TABLE characters DROP COLUMN IF EXISTS version;
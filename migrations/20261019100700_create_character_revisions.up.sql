@@ -0,0 +1,21 @@
+ALTER TABLE characters ADD COLUMN IF NOT EXISTS version integer not null default 1;
+
+CREATE TABLE IF NOT EXISTS character_revisions (
+    id uuid primary key default uuid_generate_v4 (),
+    character_id uuid not null references characters (id) on delete cascade,
+    version integer not null,
+    author_id uuid references users (id) on delete set null,
+    patch jsonb not null,
+    created_at timestamptz not null default now(),
+    unique (character_id, version)
+);
+
+INSERT INTO character_revisions (character_id, version, author_id, patch, created_at)
+SELECT id, version, owner_id, jsonb_build_array(
+    jsonb_build_object('op', 'add', 'path', '/data', 'value', data),
+    jsonb_build_object('op', 'add', 'path', '/name', 'value', name),
+    jsonb_build_object('op', 'add', 'path', '/owner_id', 'value', owner_id),
+    jsonb_build_object('op', 'add', 'path', '/portrait', 'value', portrait)
+), updated_at
+FROM characters
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS scenes (
+    id uuid primary key default uuid_generate_v4 (),
+    campaign_id uuid not null references campaigns (id) on delete cascade,
+    name varchar not null,
+    background varchar not null default '',
+    grid_type varchar not null default 'square',
+    grid_size integer not null,
+    grid_offset_x integer not null default 0,
+    grid_offset_y integer not null default 0,
+    width integer not null,
+    height integer not null,
+    free_movement boolean not null default false,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS scenes_campaign_id_idx ON scenes (campaign_id);
+
+CREATE TABLE IF NOT EXISTS tokens (
+    id uuid primary key default uuid_generate_v4 (),
+    scene_id uuid not null references scenes (id) on delete cascade,
+    name varchar not null default '',
+    image varchar not null default '',
+    x integer not null,
+    y integer not null,
+    size double precision not null default 1,
+    rotation integer not null default 0,
+    layer varchar not null default 'tokens',
+    owner_id uuid references users (id) on delete set null,
+    character_id uuid references characters (id) on delete set null,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS tokens_scene_id_idx ON tokens (scene_id);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS explorations;
+
+DROP TABLE IF EXISTS lights;
+
+DROP TABLE IF EXISTS walls;
+
+ALTER TABLE tokens DROP COLUMN IF EXISTS vision;
+ALTER TABLE scenes DROP COLUMN IF EXISTS dark;
+ALTER TABLE scenes DROP COLUMN IF EXISTS fog_of_war;
@@ -0,0 +1,39 @@
+ALTER TABLE scenes ADD COLUMN IF NOT EXISTS fog_of_war boolean not null default false;
+ALTER TABLE scenes ADD COLUMN IF NOT EXISTS dark boolean not null default false;
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS vision integer not null default 0;
+
+CREATE TABLE IF NOT EXISTS walls (
+    id uuid primary key default uuid_generate_v4 (),
+    scene_id uuid not null references scenes (id) on delete cascade,
+    x1 integer not null,
+    y1 integer not null,
+    x2 integer not null,
+    y2 integer not null,
+    kind varchar not null default 'wall',
+    open boolean not null default false,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS walls_scene_id_idx ON walls (scene_id);
+
+CREATE TABLE IF NOT EXISTS lights (
+    id uuid primary key default uuid_generate_v4 (),
+    scene_id uuid not null references scenes (id) on delete cascade,
+    x integer not null,
+    y integer not null,
+    radius integer not null,
+    color varchar not null default '',
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS lights_scene_id_idx ON lights (scene_id);
+
+CREATE TABLE IF NOT EXISTS explorations (
+    scene_id uuid not null references scenes (id) on delete cascade,
+    user_id uuid not null references users (id) on delete cascade,
+    mask jsonb not null,
+    updated_at timestamptz not null default now(),
+    primary key (scene_id, user_id)
+);
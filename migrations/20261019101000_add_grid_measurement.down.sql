@@ -0,0 +1,6 @@
+ALTER TABLE tokens DROP COLUMN IF EXISTS moved;
+ALTER TABLE tokens DROP COLUMN IF EXISTS speed;
+ALTER TABLE scenes DROP COLUMN IF EXISTS enforce_movement;
+ALTER TABLE scenes DROP COLUMN IF EXISTS grid_distance;
+ALTER TABLE scenes DROP COLUMN IF EXISTS diagonals;
+ALTER TABLE scenes DROP COLUMN IF EXISTS hex_orientation;
@@ -0,0 +1,6 @@
+ALTER TABLE scenes ADD COLUMN IF NOT EXISTS hex_orientation varchar not null default 'pointy';
+ALTER TABLE scenes ADD COLUMN IF NOT EXISTS diagonals varchar not null default '5-5-5';
+ALTER TABLE scenes ADD COLUMN IF NOT EXISTS grid_distance integer not null default 5;
+ALTER TABLE scenes ADD COLUMN IF NOT EXISTS enforce_movement boolean not null default false;
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS speed integer not null default 0;
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS moved double precision not null default 0;
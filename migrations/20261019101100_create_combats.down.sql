@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS combatants;
+
+DROP TABLE IF EXISTS combats;
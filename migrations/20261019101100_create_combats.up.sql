@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS combats (
+    id uuid primary key default uuid_generate_v4 (),
+    campaign_id uuid not null references campaigns (id) on delete cascade,
+    scene_id uuid references scenes (id) on delete set null,
+    name varchar not null,
+    tie_break varchar not null default 'bonus',
+    round integer not null default 0,
+    current_id uuid,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS combats_campaign_id_idx ON combats (campaign_id);
+
+CREATE TABLE IF NOT EXISTS combatants (
+    id uuid primary key default uuid_generate_v4 (),
+    combat_id uuid not null references combats (id) on delete cascade,
+    name varchar not null,
+    token_id uuid references tokens (id) on delete set null,
+    character_id uuid references characters (id) on delete set null,
+    owner_id uuid references users (id) on delete set null,
+    initiative integer,
+    initiative_bonus integer not null default 0,
+    roll_off integer not null default 0,
+    hp integer not null default 0,
+    max_hp integer not null default 0,
+    temp_hp integer not null default 0,
+    hidden boolean not null default false,
+    defeated boolean not null default false,
+    conditions jsonb not null default '[]',
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS combatants_combat_id_idx ON combatants (combat_id);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS uploads;
+
+DROP TABLE IF EXISTS assets;
+
+DROP TABLE IF EXISTS folders;
@@ -0,0 +1,45 @@
+CREATE TABLE IF NOT EXISTS folders (
+    id uuid primary key default uuid_generate_v4 (),
+    user_id uuid not null references users (id) on delete cascade,
+    parent_id uuid references folders (id) on delete cascade,
+    name varchar not null,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS folders_user_id_idx ON folders (user_id);
+
+CREATE TABLE IF NOT EXISTS assets (
+    id uuid primary key default uuid_generate_v4 (),
+    user_id uuid not null references users (id) on delete cascade,
+    folder_id uuid references folders (id) on delete set null,
+    name varchar not null,
+    hash char(64) not null,
+    mime varchar not null,
+    size bigint not null,
+    width integer not null default 0,
+    height integer not null default 0,
+    thumbnail varchar not null default '',
+    tags text[] not null default '{}',
+    private boolean not null default false,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS assets_user_id_idx ON assets (user_id);
+CREATE INDEX IF NOT EXISTS assets_hash_idx ON assets (hash);
+CREATE INDEX IF NOT EXISTS assets_thumbnail_idx ON assets (thumbnail);
+CREATE INDEX IF NOT EXISTS assets_tags_idx ON assets USING gin (tags);
+
+CREATE TABLE IF NOT EXISTS uploads (
+    id uuid primary key default uuid_generate_v4 (),
+    user_id uuid not null references users (id) on delete cascade,
+    folder_id uuid references folders (id) on delete set null,
+    name varchar not null,
+    size bigint not null,
+    "offset" bigint not null default 0,
+    tags text[] not null default '{}',
+    private boolean not null default false,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
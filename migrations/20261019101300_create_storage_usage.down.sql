@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS storage_usage;
+
+DROP INDEX IF EXISTS assets_campaign_id_idx;
+
+ALTER TABLE uploads DROP COLUMN IF EXISTS campaign_id;
+ALTER TABLE assets DROP COLUMN IF EXISTS campaign_id;
@@ -0,0 +1,15 @@
+ALTER TABLE assets ADD COLUMN IF NOT EXISTS campaign_id uuid references campaigns (id) on delete set null;
+ALTER TABLE uploads ADD COLUMN IF NOT EXISTS campaign_id uuid references campaigns (id) on delete set null;
+
+CREATE INDEX IF NOT EXISTS assets_campaign_id_idx ON assets (campaign_id);
+
+CREATE TABLE IF NOT EXISTS storage_usage (
+    user_id uuid primary key references users (id) on delete cascade,
+    used bigint not null default 0,
+    quota bigint,
+    updated_at timestamptz not null default now()
+);
+
+INSERT INTO storage_usage (user_id, used)
+SELECT user_id, SUM(size) FROM (SELECT DISTINCT user_id, hash, size FROM assets) a GROUP BY user_id
+ON CONFLICT (user_id) DO NOTHING;
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS journal_folders (
+    id uuid primary key default uuid_generate_v4 (),
+    campaign_id uuid not null references campaigns (id) on delete cascade,
+    parent_id uuid references journal_folders (id) on delete cascade,
+    name varchar not null,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS journal_folders_campaign_id_idx ON journal_folders (campaign_id);
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id uuid primary key default uuid_generate_v4 (),
+    campaign_id uuid not null references campaigns (id) on delete cascade,
+    folder_id uuid references journal_folders (id) on delete set null,
+    author_id uuid not null references users (id) on delete cascade,
+    title varchar not null,
+    body text not null default '',
+    body_html text not null default '',
+    image_id uuid references assets (id) on delete set null,
+    visibility varchar not null default 'gm',
+    members uuid[] not null default '{}',
+    search tsvector generated always as (
+        setweight(to_tsvector('english', title), 'A') || setweight(to_tsvector('english', body), 'B')
+    ) stored,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS journal_entries_campaign_id_idx ON journal_entries (campaign_id);
+CREATE INDEX IF NOT EXISTS journal_entries_search_idx ON journal_entries USING gin (search);
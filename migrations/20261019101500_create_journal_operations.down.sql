@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS journal_operations;
+
+ALTER TABLE journal_entries DROP COLUMN IF EXISTS revision;
+ALTER TABLE journal_entries DROP COLUMN IF EXISTS collaborative;
@@ -0,0 +1,11 @@
+ALTER TABLE journal_entries ADD COLUMN IF NOT EXISTS collaborative boolean not null default false;
+ALTER TABLE journal_entries ADD COLUMN IF NOT EXISTS revision integer not null default 0;
+
+CREATE TABLE IF NOT EXISTS journal_operations (
+    entry_id uuid not null references journal_entries (id) on delete cascade,
+    revision integer not null,
+    user_id uuid not null,
+    op jsonb not null,
+    created_at timestamptz not null default now(),
+    primary key (entry_id, revision)
+);
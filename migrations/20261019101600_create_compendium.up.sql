@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS compendium_entries (
+    id uuid primary key default uuid_generate_v4 (),
+    system varchar not null,
+    kind varchar not null,
+    source varchar not null,
+    name varchar not null,
+    body text not null default '',
+    body_html text not null default '',
+    data jsonb not null default '{}',
+    search tsvector generated always as (
+        setweight(to_tsvector('english', name), 'A') || setweight(to_tsvector('english', body), 'B')
+    ) stored,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now(),
+    unique (system, kind, source, name)
+);
+
+CREATE INDEX IF NOT EXISTS compendium_entries_search_idx ON compendium_entries USING gin (search);
@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS campaign_packs;
+
+DELETE FROM compendium_entries WHERE pack_id IS NOT NULL;
+
+DROP INDEX IF EXISTS compendium_entries_pack_key;
+DROP INDEX IF EXISTS compendium_entries_source_key;
+
+ALTER TABLE compendium_entries
+    DROP COLUMN pack_version,
+    DROP COLUMN pack_id,
+    ADD UNIQUE (system, kind, source, name);
+
+DROP TABLE IF EXISTS pack_versions;
+
+DROP TABLE IF EXISTS packs;
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS packs (
+    id uuid primary key default uuid_generate_v4 (),
+    owner_id uuid not null references users (id) on delete cascade,
+    name varchar not null,
+    system varchar not null,
+    description text not null default '',
+    description_html text not null default '',
+    latest_version integer not null default 0,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS packs_owner_id_idx ON packs (owner_id);
+
+CREATE TABLE IF NOT EXISTS pack_versions (
+    pack_id uuid not null references packs (id) on delete cascade,
+    version integer not null,
+    changelog text not null default '',
+    entries jsonb not null,
+    created_at timestamptz not null default now(),
+    primary key (pack_id, version)
+);
+
+ALTER TABLE compendium_entries
+    ADD COLUMN pack_id uuid,
+    ADD COLUMN pack_version integer not null default 0,
+    ADD FOREIGN KEY (pack_id, pack_version) REFERENCES pack_versions (pack_id, version) ON DELETE CASCADE,
+    DROP CONSTRAINT compendium_entries_system_kind_source_name_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS compendium_entries_source_key ON compendium_entries (system, kind, source, name) WHERE pack_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS compendium_entries_pack_key ON compendium_entries (pack_id, pack_version, kind, name) WHERE pack_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS campaign_packs (
+    campaign_id uuid not null references campaigns (id) on delete cascade,
+    pack_id uuid not null,
+    version integer not null,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now(),
+    primary key (campaign_id, pack_id),
+    foreign key (pack_id, version) references pack_versions (pack_id, version) on delete cascade
+);
@@ -0,0 +1 @@
+ALTER TABLE rolls DROP COLUMN IF EXISTS outcome;
@@ -0,0 +1 @@
+ALTER TABLE rolls ADD COLUMN IF NOT EXISTS outcome jsonb;
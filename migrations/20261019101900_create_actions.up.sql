@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS actions (
+    id uuid primary key default uuid_generate_v4 (),
+    seq bigserial not null unique,
+    campaign_id uuid not null references campaigns (id) on delete cascade,
+    scene_id uuid not null references scenes (id) on delete cascade,
+    user_id uuid not null references users (id) on delete cascade,
+    kind varchar not null,
+    target varchar not null,
+    target_id uuid not null,
+    before jsonb,
+    after jsonb,
+    reverts_id uuid references actions (id) on delete cascade,
+    created_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS actions_campaign_id_user_id_seq_idx ON actions (campaign_id, user_id, seq);
+CREATE INDEX IF NOT EXISTS actions_scene_id_seq_idx ON actions (scene_id, seq);